@@ -0,0 +1,38 @@
+package grasp
+
+import (
+	"context"
+	"log/slog"
+)
+
+// WithAuditLog records every Open, Stat, List, Write and Remove call on v to
+// logger, including the calling shell user (taken from the context
+// environment), the operation, the path and whether it succeeded. Pass nil to
+// disable auditing. It returns v for chaining.
+func (v *VirtualOS) WithAuditLog(logger *slog.Logger) *VirtualOS {
+	v.auditLog.Store(logger)
+	return v
+}
+
+// audit logs a single filesystem operation when an audit logger is set.
+func (v *VirtualOS) audit(ctx context.Context, op, path string, err error) {
+	logger := v.auditLog.Load()
+	if logger == nil {
+		return
+	}
+	user := Env(ctx, "USER")
+	if user == "" {
+		user = "unknown"
+	}
+	attrs := []slog.Attr{
+		slog.String("user", user),
+		slog.String("op", op),
+		slog.String("path", path),
+	}
+	if err != nil {
+		attrs = append(attrs, slog.String("status", "error"), slog.String("error", err.Error()))
+	} else {
+		attrs = append(attrs, slog.String("status", "ok"))
+	}
+	logger.LogAttrs(ctx, slog.LevelInfo, "grasp: audit", attrs...)
+}
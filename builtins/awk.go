@@ -0,0 +1,1141 @@
+package builtins
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	grasp "github.com/jackfish212/grasp"
+	"github.com/jackfish212/grasp/mounts"
+)
+
+func builtinAwk(v *grasp.VirtualOS) mounts.ExecFunc {
+	return func(ctx context.Context, args []string, stdin io.Reader) (io.ReadCloser, error) {
+		if hasFlag(args, "-h", "--help") {
+			return io.NopCloser(strings.NewReader(`awk — pattern scanning and text processing
+Usage: awk [-F FS] [-v VAR=VALUE]... 'PROGRAM' [FILE | VAR=VALUE]...
+Options:
+  -F FS          Use FS as the field separator (a character or regex)
+  -v VAR=VALUE   Assign VALUE to VAR before the program starts
+Supported: pattern { action } rules, BEGIN and END, /regex/ patterns,
+$0..$NF, NR, NF, FNR, FS, OFS, ORS, FILENAME, print, printf, if/else,
+while, for, for (k in array), next, exit, delete, associative arrays and
+the functions length, substr, index, split, sub, gsub, match, sprintf,
+tolower, toupper, int, sqrt, exp, log, sin, cos and atan2.
+Output redirection, getline and user-defined functions are not supported.
+With no FILE, or when FILE is -, read standard input.
+`)), nil
+		}
+
+		in := newAwkInterp()
+		var program string
+		haveProgram := false
+		var operands []string
+		for i := 0; i < len(args); i++ {
+			arg := args[i]
+			if haveProgram {
+				operands = append(operands, arg)
+				continue
+			}
+			switch {
+			case arg == "-F" || arg == "-v":
+				if i+1 >= len(args) {
+					return nil, fmt.Errorf("awk: option requires an argument -- '%s'", arg[1:])
+				}
+				i++
+				arg += args[i]
+				fallthrough
+			case strings.HasPrefix(arg, "-F") || strings.HasPrefix(arg, "-v"):
+				if arg[1] == 'F' {
+					fs := awkUnescape(arg[2:])
+					if fs == "t" {
+						fs = "\t"
+					}
+					in.vars["FS"] = awkStr(fs)
+				} else if !in.assign(arg[2:]) {
+					return nil, fmt.Errorf("awk: invalid -v argument %q", arg[2:])
+				}
+			case arg == "--":
+				if i+1 < len(args) {
+					i++
+					program, haveProgram = args[i], true
+				}
+			case strings.HasPrefix(arg, "-") && arg != "-":
+				return nil, fmt.Errorf("awk: invalid option -- '%s'", strings.TrimLeft(arg, "-"))
+			default:
+				program, haveProgram = arg, true
+			}
+		}
+		if !haveProgram {
+			return nil, fmt.Errorf("awk: missing program")
+		}
+		prog, err := parseAwk(program)
+		if err != nil {
+			return nil, err
+		}
+
+		cwd := grasp.Env(ctx, "PWD")
+		if cwd == "" {
+			cwd = "/"
+		}
+		var files []string
+		for _, op := range operands {
+			if !awkAssignRe.MatchString(op) {
+				files = append(files, op)
+			}
+		}
+		// read returns the records of each input in turn; operands of the
+		// form VAR=VALUE are applied when reached.
+		read := func(yield func(name, text string) error) error {
+			if len(files) == 0 {
+				for _, op := range operands {
+					in.assign(op)
+				}
+				var data []byte
+				if stdin != nil {
+					if data, err = io.ReadAll(stdin); err != nil {
+						return fmt.Errorf("awk: %w", err)
+					}
+				}
+				return yield("", string(data))
+			}
+			for _, op := range operands {
+				if awkAssignRe.MatchString(op) {
+					in.assign(op)
+					continue
+				}
+				if op == "-" {
+					var data []byte
+					if stdin != nil {
+						if data, err = io.ReadAll(stdin); err != nil {
+							return fmt.Errorf("awk: %w", err)
+						}
+					}
+					if err := yield("-", string(data)); err != nil {
+						return err
+					}
+					continue
+				}
+				data, err := readFile(ctx, v, resolvePath(cwd, op))
+				if err != nil {
+					return fmt.Errorf("awk: %s: %w", op, err)
+				}
+				if err := yield(op, string(data)); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+
+		code, err := in.run(ctx, prog, read)
+		if err != nil {
+			return nil, err
+		}
+		if code != 0 {
+			return exitWith(in.out.String(), code), nil
+		}
+		return io.NopCloser(strings.NewReader(in.out.String())), nil
+	}
+}
+
+var awkAssignRe = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*=`)
+
+// ─── Values ───
+
+type awkKind uint8
+
+const (
+	awkUninit awkKind = iota
+	awkNum
+	awkStrKind
+	awkStrNum // input that looks numeric: compares as a number
+)
+
+type awkValue struct {
+	kind awkKind
+	s    string
+	n    float64
+}
+
+func awkNumber(n float64) awkValue { return awkValue{kind: awkNum, n: n} }
+func awkStr(s string) awkValue     { return awkValue{kind: awkStrKind, s: s} }
+
+// awkInput wraps text from input (fields, split, -v), which is numeric if
+// it looks like a number.
+func awkInput(s string) awkValue {
+	if awkNumericRe.MatchString(s) {
+		n, _ := strconv.ParseFloat(strings.TrimSpace(s), 64)
+		return awkValue{kind: awkStrNum, s: s, n: n}
+	}
+	return awkStr(s)
+}
+
+var (
+	awkNumericRe = regexp.MustCompile(`^\s*[+-]?(\d+\.?\d*|\.\d+)([eE][+-]?\d+)?\s*$`)
+	awkLeadingRe = regexp.MustCompile(`^\s*[+-]?(\d+\.?\d*|\.\d+)([eE][+-]?\d+)?`)
+)
+
+func (v awkValue) num() float64 {
+	switch v.kind {
+	case awkNum, awkStrNum:
+		return v.n
+	case awkStrKind:
+		n, _ := strconv.ParseFloat(strings.TrimSpace(awkLeadingRe.FindString(v.s)), 64)
+		return n
+	}
+	return 0
+}
+
+func (v awkValue) str() string {
+	if v.kind == awkNum {
+		return awkFormatNum(v.n)
+	}
+	return v.s
+}
+
+func (v awkValue) bool() bool {
+	switch v.kind {
+	case awkNum, awkStrNum:
+		return v.n != 0
+	case awkStrKind:
+		return v.s != ""
+	}
+	return false
+}
+
+func (v awkValue) numeric() bool { return v.kind != awkStrKind }
+
+// awkFormatNum converts a number to a string: integers print exactly,
+// others with six significant digits (CONVFMT and OFMT of "%.6g").
+func awkFormatNum(n float64) string {
+	if n == math.Trunc(n) && math.Abs(n) < 1e16 {
+		return strconv.FormatInt(int64(n), 10)
+	}
+	return strconv.FormatFloat(n, 'g', 6, 64)
+}
+
+// ─── Interpreter ───
+
+type awkCtrl int
+
+const (
+	awkCtrlNone awkCtrl = iota
+	awkCtrlNext
+	awkCtrlExit
+	awkCtrlBreak
+	awkCtrlContinue
+)
+
+type awkInterp struct {
+	ctx      context.Context
+	vars     map[string]awkValue
+	arrays   map[string]map[string]awkValue
+	record   string
+	fields   []string
+	out      strings.Builder
+	exitCode int
+	regexes  map[string]*regexp.Regexp
+}
+
+func newAwkInterp() *awkInterp {
+	return &awkInterp{
+		vars: map[string]awkValue{
+			"FS": awkStr(" "), "OFS": awkStr(" "), "ORS": awkStr("\n"),
+			"RS": awkStr("\n"), "SUBSEP": awkStr("\x1c"),
+			"NR": awkNumber(0), "FNR": awkNumber(0), "FILENAME": awkStr(""),
+			"RSTART": awkNumber(0), "RLENGTH": awkNumber(-1),
+		},
+		arrays:  make(map[string]map[string]awkValue),
+		regexes: make(map[string]*regexp.Regexp),
+	}
+}
+
+// assign applies a VAR=VALUE assignment from the command line.
+func (in *awkInterp) assign(s string) bool {
+	name, value, ok := strings.Cut(s, "=")
+	if !ok || !awkAssignRe.MatchString(s) {
+		return false
+	}
+	in.vars[name] = awkInput(awkUnescape(value))
+	return true
+}
+
+func (in *awkInterp) run(ctx context.Context, prog *awkProgram, read func(func(name, text string) error) error) (int, error) {
+	in.ctx = ctx
+	exited := false
+	for _, b := range prog.begin {
+		ctrl, err := in.exec(b)
+		if err != nil {
+			return 0, err
+		}
+		if ctrl == awkCtrlExit {
+			exited = true
+			break
+		}
+	}
+
+	if !exited && (len(prog.rules) > 0 || len(prog.end) > 0) {
+		errExit := fmt.Errorf("exit")
+		err := read(func(name, text string) error {
+			in.vars["FILENAME"] = awkStr(name)
+			in.vars["FNR"] = awkNumber(0)
+			for _, rec := range in.splitRecords(text) {
+				if err := ctx.Err(); err != nil {
+					return err
+				}
+				in.vars["NR"] = awkNumber(in.vars["NR"].num() + 1)
+				in.vars["FNR"] = awkNumber(in.vars["FNR"].num() + 1)
+				in.setRecord(rec)
+				for _, rule := range prog.rules {
+					if rule.pattern != nil {
+						match, err := in.eval(rule.pattern)
+						if err != nil {
+							return err
+						}
+						if !match.bool() {
+							continue
+						}
+					}
+					if rule.action == nil {
+						in.out.WriteString(in.record)
+						in.out.WriteString(in.vars["ORS"].str())
+						continue
+					}
+					ctrl, err := in.exec(rule.action)
+					if err != nil {
+						return err
+					}
+					if ctrl == awkCtrlNext {
+						break
+					}
+					if ctrl == awkCtrlExit {
+						return errExit
+					}
+				}
+			}
+			return nil
+		})
+		if err != nil && err != errExit {
+			return 0, err
+		}
+	}
+
+	// END rules run after exit too, unless END itself exits.
+	for _, b := range prog.end {
+		ctrl, err := in.exec(b)
+		if err != nil {
+			return 0, err
+		}
+		if ctrl == awkCtrlExit {
+			break
+		}
+	}
+	return in.exitCode, nil
+}
+
+// splitRecords splits input on RS: "\n" by default, any single character,
+// or blank lines when RS is empty.
+func (in *awkInterp) splitRecords(text string) []string {
+	if text == "" {
+		return nil
+	}
+	rs := in.vars["RS"].str()
+	if rs == "" {
+		var recs []string
+		for _, para := range regexp.MustCompile(`\n\n+`).Split(strings.Trim(text, "\n"), -1) {
+			if para != "" {
+				recs = append(recs, para)
+			}
+		}
+		return recs
+	}
+	sep := rs[:1]
+	recs := strings.Split(text, sep)
+	if recs[len(recs)-1] == "" {
+		recs = recs[:len(recs)-1]
+	}
+	return recs
+}
+
+func (in *awkInterp) setRecord(rec string) {
+	in.record = rec
+	in.fields = in.splitFields(rec, in.vars["FS"].str())
+}
+
+func (in *awkInterp) splitFields(s, fs string) []string {
+	switch {
+	case s == "":
+		return nil
+	case fs == " ":
+		return strings.Fields(s)
+	case len([]rune(fs)) == 1 && fs != "\\":
+		return strings.Split(s, fs)
+	}
+	re, err := in.regex(fs)
+	if err != nil {
+		return strings.Split(s, fs)
+	}
+	return re.Split(s, -1)
+}
+
+func (in *awkInterp) rebuildRecord() {
+	in.record = strings.Join(in.fields, in.vars["OFS"].str())
+}
+
+func (in *awkInterp) getField(i int) (awkValue, error) {
+	switch {
+	case i < 0:
+		return awkValue{}, fmt.Errorf("awk: attempt to access field %d", i)
+	case i == 0:
+		return awkInput(in.record), nil
+	case i > len(in.fields):
+		return awkValue{}, nil
+	}
+	return awkInput(in.fields[i-1]), nil
+}
+
+func (in *awkInterp) setField(i int, s string) error {
+	switch {
+	case i < 0:
+		return fmt.Errorf("awk: attempt to access field %d", i)
+	case i == 0:
+		in.setRecord(s)
+		return nil
+	}
+	for len(in.fields) < i {
+		in.fields = append(in.fields, "")
+	}
+	in.fields[i-1] = s
+	in.rebuildRecord()
+	return nil
+}
+
+func (in *awkInterp) regex(src string) (*regexp.Regexp, error) {
+	if re, ok := in.regexes[src]; ok {
+		return re, nil
+	}
+	re, err := regexp.Compile(src)
+	if err != nil {
+		return nil, fmt.Errorf("awk: invalid regex %q: %w", src, err)
+	}
+	in.regexes[src] = re
+	return re, nil
+}
+
+// regexOf returns the regex an operand denotes: a /literal/ or a string.
+func (in *awkInterp) regexOf(e awkExpr) (*regexp.Regexp, error) {
+	if lit, ok := e.(*awkRegexLit); ok {
+		return lit.re, nil
+	}
+	v, err := in.eval(e)
+	if err != nil {
+		return nil, err
+	}
+	return in.regex(v.str())
+}
+
+func (in *awkInterp) getVar(name string) awkValue {
+	if name == "NF" {
+		return awkNumber(float64(len(in.fields)))
+	}
+	return in.vars[name]
+}
+
+func (in *awkInterp) setVar(name string, v awkValue) {
+	if name == "NF" {
+		n := max(int(v.num()), 0)
+		for len(in.fields) < n {
+			in.fields = append(in.fields, "")
+		}
+		in.fields = in.fields[:n]
+		in.rebuildRecord()
+		return
+	}
+	in.vars[name] = v
+}
+
+func (in *awkInterp) array(name string) map[string]awkValue {
+	a, ok := in.arrays[name]
+	if !ok {
+		a = make(map[string]awkValue)
+		in.arrays[name] = a
+	}
+	return a
+}
+
+func (in *awkInterp) subscript(subs []awkExpr) (string, error) {
+	parts := make([]string, len(subs))
+	for i, e := range subs {
+		v, err := in.eval(e)
+		if err != nil {
+			return "", err
+		}
+		parts[i] = v.str()
+	}
+	return strings.Join(parts, in.vars["SUBSEP"].str()), nil
+}
+
+// get reads an lvalue.
+func (in *awkInterp) get(target awkExpr) (awkValue, error) {
+	switch t := target.(type) {
+	case *awkVarRef:
+		return in.getVar(t.name), nil
+	case *awkFieldRef:
+		idx, err := in.eval(t.index)
+		if err != nil {
+			return awkValue{}, err
+		}
+		return in.getField(int(idx.num()))
+	case *awkIndexRef:
+		key, err := in.subscript(t.subs)
+		if err != nil {
+			return awkValue{}, err
+		}
+		// Referencing an element creates it, as in awk.
+		a := in.array(t.name)
+		v, ok := a[key]
+		if !ok {
+			a[key] = v
+		}
+		return v, nil
+	}
+	return awkValue{}, fmt.Errorf("awk: invalid assignment target")
+}
+
+// set writes an lvalue.
+func (in *awkInterp) set(target awkExpr, v awkValue) error {
+	switch t := target.(type) {
+	case *awkVarRef:
+		in.setVar(t.name, v)
+		return nil
+	case *awkFieldRef:
+		idx, err := in.eval(t.index)
+		if err != nil {
+			return err
+		}
+		return in.setField(int(idx.num()), v.str())
+	case *awkIndexRef:
+		key, err := in.subscript(t.subs)
+		if err != nil {
+			return err
+		}
+		in.array(t.name)[key] = v
+		return nil
+	}
+	return fmt.Errorf("awk: invalid assignment target")
+}
+
+func (in *awkInterp) exec(s awkStmt) (awkCtrl, error) {
+	switch s := s.(type) {
+	case *awkBlock:
+		for _, st := range s.stmts {
+			if ctrl, err := in.exec(st); err != nil || ctrl != awkCtrlNone {
+				return ctrl, err
+			}
+		}
+	case *awkExprStmt:
+		_, err := in.eval(s.x)
+		return awkCtrlNone, err
+	case *awkPrint:
+		return awkCtrlNone, in.print(s)
+	case *awkIf:
+		cond, err := in.eval(s.cond)
+		if err != nil {
+			return awkCtrlNone, err
+		}
+		if cond.bool() {
+			return in.exec(s.then)
+		}
+		if s.els != nil {
+			return in.exec(s.els)
+		}
+	case *awkWhile:
+		for {
+			if err := in.ctx.Err(); err != nil {
+				return awkCtrlNone, err
+			}
+			cond, err := in.eval(s.cond)
+			if err != nil || !cond.bool() {
+				return awkCtrlNone, err
+			}
+			if s.body == nil {
+				continue
+			}
+			ctrl, err := in.exec(s.body)
+			if err != nil {
+				return ctrl, err
+			}
+			if ctrl == awkCtrlBreak {
+				break
+			}
+			if ctrl == awkCtrlNext || ctrl == awkCtrlExit {
+				return ctrl, nil
+			}
+		}
+	case *awkFor:
+		if s.init != nil {
+			if _, err := in.exec(s.init); err != nil {
+				return awkCtrlNone, err
+			}
+		}
+		for {
+			if err := in.ctx.Err(); err != nil {
+				return awkCtrlNone, err
+			}
+			if s.cond != nil {
+				cond, err := in.eval(s.cond)
+				if err != nil || !cond.bool() {
+					return awkCtrlNone, err
+				}
+			}
+			if s.body != nil {
+				ctrl, err := in.exec(s.body)
+				if err != nil {
+					return ctrl, err
+				}
+				if ctrl == awkCtrlBreak {
+					break
+				}
+				if ctrl == awkCtrlNext || ctrl == awkCtrlExit {
+					return ctrl, nil
+				}
+			}
+			if s.post != nil {
+				if _, err := in.exec(s.post); err != nil {
+					return awkCtrlNone, err
+				}
+			}
+		}
+	case *awkForIn:
+		for _, key := range awkSortedKeys(in.arrays[s.array]) {
+			if _, ok := in.arrays[s.array][key]; !ok {
+				continue // deleted during the loop
+			}
+			in.setVar(s.name, awkInput(key))
+			ctrl, err := in.exec(s.body)
+			if err != nil {
+				return ctrl, err
+			}
+			if ctrl == awkCtrlBreak {
+				break
+			}
+			if ctrl == awkCtrlNext || ctrl == awkCtrlExit {
+				return ctrl, nil
+			}
+		}
+	case *awkNext:
+		return awkCtrlNext, nil
+	case *awkBreak:
+		return awkCtrlBreak, nil
+	case *awkContinue:
+		return awkCtrlContinue, nil
+	case *awkExit:
+		if s.code != nil {
+			code, err := in.eval(s.code)
+			if err != nil {
+				return awkCtrlNone, err
+			}
+			in.exitCode = int(code.num())
+		}
+		return awkCtrlExit, nil
+	case *awkDelete:
+		if s.subs == nil {
+			delete(in.arrays, s.array)
+			return awkCtrlNone, nil
+		}
+		key, err := in.subscript(s.subs)
+		if err != nil {
+			return awkCtrlNone, err
+		}
+		delete(in.array(s.array), key)
+	}
+	return awkCtrlNone, nil
+}
+
+// awkSortedKeys orders array keys numerically when they all look like
+// numbers, and lexically otherwise, so for-in output is deterministic.
+func awkSortedKeys(a map[string]awkValue) []string {
+	keys := make([]string, 0, len(a))
+	numeric := true
+	for k := range a {
+		keys = append(keys, k)
+		numeric = numeric && awkNumericRe.MatchString(k)
+	}
+	if numeric {
+		sort.Slice(keys, func(i, j int) bool { return awkInput(keys[i]).n < awkInput(keys[j]).n })
+	} else {
+		sort.Strings(keys)
+	}
+	return keys
+}
+
+func (in *awkInterp) print(s *awkPrint) error {
+	vals := make([]awkValue, len(s.args))
+	for i, e := range s.args {
+		v, err := in.eval(e)
+		if err != nil {
+			return err
+		}
+		vals[i] = v
+	}
+	if s.printf {
+		in.out.WriteString(awkSprintf(vals[0].str(), vals[1:]))
+		return nil
+	}
+	if len(vals) == 0 {
+		in.out.WriteString(in.record)
+	}
+	for i, v := range vals {
+		if i > 0 {
+			in.out.WriteString(in.vars["OFS"].str())
+		}
+		in.out.WriteString(v.str())
+	}
+	in.out.WriteString(in.vars["ORS"].str())
+	return nil
+}
+
+func (in *awkInterp) eval(e awkExpr) (awkValue, error) {
+	switch e := e.(type) {
+	case *awkNumLit:
+		return awkNumber(e.v), nil
+	case *awkStrLit:
+		return awkStr(e.v), nil
+	case *awkRegexLit:
+		return awkBool(e.re.MatchString(in.record)), nil
+	case *awkGroup:
+		return in.eval(e.x)
+	case *awkVarRef, *awkFieldRef, *awkIndexRef:
+		return in.get(e)
+	case *awkAssign:
+		v, err := in.eval(e.value)
+		if err != nil {
+			return v, err
+		}
+		if e.op != "=" {
+			cur, err := in.get(e.target)
+			if err != nil {
+				return v, err
+			}
+			if v, err = awkArith(e.op[:1], cur.num(), v.num()); err != nil {
+				return v, err
+			}
+		} else if v.kind == awkUninit {
+			v = awkStr("")
+		}
+		return v, in.set(e.target, v)
+	case *awkIncDec:
+		cur, err := in.get(e.target)
+		if err != nil {
+			return cur, err
+		}
+		old := cur.num()
+		n := old + 1
+		if e.op == "--" {
+			n = old - 1
+		}
+		if err := in.set(e.target, awkNumber(n)); err != nil {
+			return cur, err
+		}
+		if e.prefix {
+			return awkNumber(n), nil
+		}
+		return awkNumber(old), nil
+	case *awkUnary:
+		x, err := in.eval(e.x)
+		if err != nil {
+			return x, err
+		}
+		switch e.op {
+		case "!":
+			return awkBool(!x.bool()), nil
+		case "-":
+			return awkNumber(-x.num()), nil
+		}
+		return awkNumber(x.num()), nil
+	case *awkBinary:
+		return in.evalBinary(e)
+	case *awkMatch:
+		x, err := in.eval(e.x)
+		if err != nil {
+			return x, err
+		}
+		re, err := in.regexOf(e.re)
+		if err != nil {
+			return awkValue{}, err
+		}
+		return awkBool(re.MatchString(x.str()) != e.negate), nil
+	case *awkCond:
+		cond, err := in.eval(e.cond)
+		if err != nil {
+			return cond, err
+		}
+		if cond.bool() {
+			return in.eval(e.yes)
+		}
+		return in.eval(e.no)
+	case *awkIn:
+		key, err := in.subscript(e.subs)
+		if err != nil {
+			return awkValue{}, err
+		}
+		_, ok := in.arrays[e.array][key]
+		return awkBool(ok), nil
+	case *awkCall:
+		return in.call(e)
+	}
+	return awkValue{}, fmt.Errorf("awk: cannot evaluate %T", e)
+}
+
+func awkBool(b bool) awkValue {
+	if b {
+		return awkNumber(1)
+	}
+	return awkNumber(0)
+}
+
+func (in *awkInterp) evalBinary(e *awkBinary) (awkValue, error) {
+	l, err := in.eval(e.l)
+	if err != nil {
+		return l, err
+	}
+	switch e.op {
+	case "&&", "||":
+		if l.bool() == (e.op == "||") {
+			return awkBool(l.bool()), nil
+		}
+		r, err := in.eval(e.r)
+		return awkBool(r.bool()), err
+	}
+	r, err := in.eval(e.r)
+	if err != nil {
+		return r, err
+	}
+	switch e.op {
+	case "":
+		return awkStr(l.str() + r.str()), nil
+	case "<", "<=", ">", ">=", "==", "!=":
+		var c int
+		if l.numeric() && r.numeric() {
+			switch ln, rn := l.num(), r.num(); {
+			case ln < rn:
+				c = -1
+			case ln > rn:
+				c = 1
+			}
+		} else {
+			c = strings.Compare(l.str(), r.str())
+		}
+		switch e.op {
+		case "<":
+			return awkBool(c < 0), nil
+		case "<=":
+			return awkBool(c <= 0), nil
+		case ">":
+			return awkBool(c > 0), nil
+		case ">=":
+			return awkBool(c >= 0), nil
+		case "==":
+			return awkBool(c == 0), nil
+		}
+		return awkBool(c != 0), nil
+	}
+	return awkArith(e.op, l.num(), r.num())
+}
+
+func awkArith(op string, a, b float64) (awkValue, error) {
+	switch op {
+	case "+":
+		return awkNumber(a + b), nil
+	case "-":
+		return awkNumber(a - b), nil
+	case "*":
+		return awkNumber(a * b), nil
+	case "/":
+		if b == 0 {
+			return awkValue{}, fmt.Errorf("awk: division by zero")
+		}
+		return awkNumber(a / b), nil
+	case "%":
+		if b == 0 {
+			return awkValue{}, fmt.Errorf("awk: division by zero in %%")
+		}
+		return awkNumber(math.Mod(a, b)), nil
+	case "^":
+		return awkNumber(math.Pow(a, b)), nil
+	}
+	return awkValue{}, fmt.Errorf("awk: unknown operator %q", op)
+}
+
+func (in *awkInterp) call(e *awkCall) (awkValue, error) {
+	argc := len(e.args)
+	arity := map[string][2]int{
+		"length": {0, 1}, "substr": {2, 3}, "index": {2, 2}, "split": {2, 3},
+		"sub": {2, 3}, "gsub": {2, 3}, "match": {2, 2}, "tolower": {1, 1},
+		"toupper": {1, 1}, "sprintf": {1, -1}, "int": {1, 1}, "sqrt": {1, 1},
+		"exp": {1, 1}, "log": {1, 1}, "sin": {1, 1}, "cos": {1, 1}, "atan2": {2, 2},
+	}[e.name]
+	if argc < arity[0] || arity[1] >= 0 && argc > arity[1] {
+		return awkValue{}, fmt.Errorf("awk: wrong number of arguments to %s", e.name)
+	}
+
+	switch e.name {
+	case "length":
+		if argc == 0 {
+			return awkNumber(float64(len([]rune(in.record)))), nil
+		}
+		if ref, ok := e.args[0].(*awkVarRef); ok {
+			if a, isArray := in.arrays[ref.name]; isArray {
+				return awkNumber(float64(len(a))), nil
+			}
+		}
+	case "split":
+		ref, ok := e.args[1].(*awkVarRef)
+		if !ok {
+			return awkValue{}, fmt.Errorf("awk: split: second argument must be an array")
+		}
+		s, err := in.eval(e.args[0])
+		if err != nil {
+			return s, err
+		}
+		var parts []string
+		switch {
+		case argc == 2:
+			parts = in.splitFields(s.str(), in.vars["FS"].str())
+		default:
+			if lit, isRegex := e.args[2].(*awkRegexLit); isRegex {
+				if s.str() != "" {
+					parts = lit.re.Split(s.str(), -1)
+				}
+			} else {
+				sep, err := in.eval(e.args[2])
+				if err != nil {
+					return sep, err
+				}
+				parts = in.splitFields(s.str(), sep.str())
+			}
+		}
+		a := make(map[string]awkValue, len(parts))
+		for i, p := range parts {
+			a[strconv.Itoa(i+1)] = awkInput(p)
+		}
+		in.arrays[ref.name] = a
+		return awkNumber(float64(len(parts))), nil
+	case "sub", "gsub":
+		re, err := in.regexOf(e.args[0])
+		if err != nil {
+			return awkValue{}, err
+		}
+		repl, err := in.eval(e.args[1])
+		if err != nil {
+			return repl, err
+		}
+		var target awkExpr = &awkFieldRef{&awkNumLit{0}}
+		if argc == 3 {
+			target = e.args[2]
+			if !awkIsLvalue(target) {
+				return awkValue{}, fmt.Errorf("awk: %s: third argument must be a variable", e.name)
+			}
+		}
+		cur, err := in.get(target)
+		if err != nil {
+			return cur, err
+		}
+		out, n := awkSubstitute(re, cur.str(), repl.str(), e.name == "gsub")
+		if n > 0 {
+			if err := in.set(target, awkStr(out)); err != nil {
+				return awkValue{}, err
+			}
+		}
+		return awkNumber(float64(n)), nil
+	case "match":
+		s, err := in.eval(e.args[0])
+		if err != nil {
+			return s, err
+		}
+		re, err := in.regexOf(e.args[1])
+		if err != nil {
+			return awkValue{}, err
+		}
+		start, length := 0, -1
+		if loc := re.FindStringIndex(s.str()); loc != nil {
+			start = len([]rune(s.str()[:loc[0]])) + 1
+			length = len([]rune(s.str()[loc[0]:loc[1]]))
+		}
+		in.vars["RSTART"] = awkNumber(float64(start))
+		in.vars["RLENGTH"] = awkNumber(float64(length))
+		return awkNumber(float64(start)), nil
+	}
+
+	args := make([]awkValue, argc)
+	for i, a := range e.args {
+		v, err := in.eval(a)
+		if err != nil {
+			return v, err
+		}
+		args[i] = v
+	}
+	switch e.name {
+	case "length":
+		return awkNumber(float64(len([]rune(args[0].str())))), nil
+	case "substr":
+		r := []rune(args[0].str())
+		// Positions are 1-based and rounded; the range is clipped to the string.
+		start := math.Round(args[1].num())
+		end := math.Inf(1)
+		if argc == 3 {
+			end = start + math.Round(args[2].num())
+		}
+		start = math.Max(start, 1)
+		end = math.Min(end, float64(len(r)+1))
+		if end <= start {
+			return awkStr(""), nil
+		}
+		return awkStr(string(r[int(start)-1 : int(end)-1])), nil
+	case "index":
+		i := strings.Index(args[0].str(), args[1].str())
+		if i < 0 {
+			return awkNumber(0), nil
+		}
+		return awkNumber(float64(len([]rune(args[0].str()[:i])) + 1)), nil
+	case "tolower":
+		return awkStr(strings.ToLower(args[0].str())), nil
+	case "toupper":
+		return awkStr(strings.ToUpper(args[0].str())), nil
+	case "sprintf":
+		return awkStr(awkSprintf(args[0].str(), args[1:])), nil
+	case "int":
+		return awkNumber(math.Trunc(args[0].num())), nil
+	case "sqrt":
+		return awkNumber(math.Sqrt(args[0].num())), nil
+	case "exp":
+		return awkNumber(math.Exp(args[0].num())), nil
+	case "log":
+		return awkNumber(math.Log(args[0].num())), nil
+	case "sin":
+		return awkNumber(math.Sin(args[0].num())), nil
+	case "cos":
+		return awkNumber(math.Cos(args[0].num())), nil
+	case "atan2":
+		return awkNumber(math.Atan2(args[0].num(), args[1].num())), nil
+	}
+	return awkValue{}, fmt.Errorf("awk: unknown function %s", e.name)
+}
+
+// awkSubstitute replaces the first (or every, with global) match of re in
+// s. In repl, "&" stands for the matched text and "\&" for a literal "&".
+func awkSubstitute(re *regexp.Regexp, s, repl string, global bool) (string, int) {
+	var out strings.Builder
+	n := 0
+	last := 0
+	for _, loc := range re.FindAllStringIndex(s, -1) {
+		if !global && n == 1 {
+			break
+		}
+		out.WriteString(s[last:loc[0]])
+		for i := 0; i < len(repl); i++ {
+			switch {
+			case repl[i] == '\\' && i+1 < len(repl) && (repl[i+1] == '&' || repl[i+1] == '\\'):
+				out.WriteByte(repl[i+1])
+				i++
+			case repl[i] == '&':
+				out.WriteString(s[loc[0]:loc[1]])
+			default:
+				out.WriteByte(repl[i])
+			}
+		}
+		last = loc[1]
+		n++
+	}
+	if n == 0 {
+		return s, 0
+	}
+	out.WriteString(s[last:])
+	return out.String(), n
+}
+
+// awkSprintf formats args per an awk/C printf format string.
+func awkSprintf(format string, args []awkValue) string {
+	var out strings.Builder
+	next := func() awkValue {
+		if len(args) == 0 {
+			return awkValue{}
+		}
+		v := args[0]
+		args = args[1:]
+		return v
+	}
+	for i := 0; i < len(format); i++ {
+		c := format[i]
+		if c != '%' {
+			out.WriteByte(c)
+			continue
+		}
+		j := i + 1
+		for j < len(format) && strings.IndexByte("-+ #0", format[j]) >= 0 {
+			j++
+		}
+		spec := format[i:j]
+		// Width and precision, either digits or "*" taken from the arguments.
+		for _, part := range []string{"width", "precision"} {
+			if part == "precision" {
+				if j >= len(format) || format[j] != '.' {
+					break
+				}
+				spec += "."
+				j++
+			}
+			if j < len(format) && format[j] == '*' {
+				spec += strconv.Itoa(int(next().num()))
+				j++
+				continue
+			}
+			k := j
+			for k < len(format) && format[k] >= '0' && format[k] <= '9' {
+				k++
+			}
+			spec += format[j:k]
+			j = k
+		}
+		if j >= len(format) {
+			out.WriteString(format[i:])
+			break
+		}
+		verb := format[j]
+		switch verb {
+		case '%':
+			out.WriteByte('%')
+		case 'd', 'i':
+			fmt.Fprintf(&out, spec+"d", int64(next().num()))
+		case 'o', 'x', 'X':
+			fmt.Fprintf(&out, spec+string(verb), int64(next().num()))
+		case 'u':
+			fmt.Fprintf(&out, spec+"d", int64(next().num()))
+		case 'e', 'E', 'f', 'F', 'g', 'G':
+			if verb == 'F' {
+				verb = 'f'
+			}
+			fmt.Fprintf(&out, spec+string(verb), next().num())
+		case 'c':
+			v := next()
+			s := v.str()
+			if v.kind == awkNum {
+				s = string(rune(int(v.n)))
+			} else if r := []rune(s); len(r) > 0 {
+				s = string(r[0])
+			}
+			fmt.Fprintf(&out, spec+"s", s)
+		case 's':
+			fmt.Fprintf(&out, spec+"s", next().str())
+		default:
+			out.WriteString(format[i : j+1])
+		}
+		i = j
+	}
+	return out.String()
+}
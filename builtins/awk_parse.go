@@ -0,0 +1,884 @@
+package builtins
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ─── Lexer ───
+
+type awkTokKind int
+
+const (
+	awkEOF awkTokKind = iota
+	awkNewline
+	awkNumTok
+	awkStrTok
+	awkRegex
+	awkName
+	awkFunc    // builtin function name
+	awkKeyword // BEGIN, END, print, if, ...
+	awkPunct   // operators and punctuation
+)
+
+type awkToken struct {
+	kind awkTokKind
+	text string
+	num  float64
+	line int
+}
+
+var awkKeywords = map[string]bool{
+	"BEGIN": true, "END": true, "print": true, "printf": true, "if": true,
+	"else": true, "while": true, "for": true, "in": true, "next": true,
+	"exit": true, "break": true, "continue": true, "delete": true,
+	"function": true, "getline": true, "do": true,
+}
+
+var awkBuiltinFuncs = map[string]bool{
+	"length": true, "substr": true, "index": true, "split": true,
+	"sub": true, "gsub": true, "match": true, "tolower": true,
+	"toupper": true, "sprintf": true, "int": true, "sqrt": true,
+	"exp": true, "log": true, "sin": true, "cos": true, "atan2": true,
+}
+
+// awkPuncts lists operators longest first so the lexer matches greedily.
+var awkPuncts = []string{
+	"+=", "-=", "*=", "/=", "%=", "^=", "==", "<=", ">=", "!=", "++", "--",
+	"&&", "||", "!~", ">>",
+	"{", "}", "(", ")", "[", "]", ";", ",", "+", "-", "*", "/", "%", "^",
+	"!", ">", "<", "|", "?", ":", "~", "$", "=",
+}
+
+func awkLex(src string) ([]awkToken, error) {
+	var toks []awkToken
+	line := 1
+	// regexAllowed reports whether a "/" at this point starts a regex rather
+	// than a division, judging by the previous token.
+	regexAllowed := func() bool {
+		if len(toks) == 0 {
+			return true
+		}
+		t := toks[len(toks)-1]
+		switch t.kind {
+		case awkNumTok, awkStrTok, awkRegex, awkName, awkFunc:
+			return false
+		case awkPunct:
+			return t.text != ")" && t.text != "]" && t.text != "$" && t.text != "++" && t.text != "--"
+		}
+		return true
+	}
+
+	for i := 0; i < len(src); {
+		c := src[i]
+		switch {
+		case c == '\\' && i+1 < len(src) && src[i+1] == '\n':
+			i += 2
+			line++
+		case c == ' ' || c == '\t' || c == '\r':
+			i++
+		case c == '\n':
+			toks = append(toks, awkToken{kind: awkNewline, line: line})
+			line++
+			i++
+		case c == '#':
+			for i < len(src) && src[i] != '\n' {
+				i++
+			}
+		case c == '"':
+			var sb strings.Builder
+			j := i + 1
+			for ; j < len(src) && src[j] != '"'; j++ {
+				if src[j] == '\n' {
+					return nil, fmt.Errorf("awk: line %d: newline in string", line)
+				}
+				if src[j] == '\\' && j+1 < len(src) {
+					j++
+					r, n := awkEscape(src[j:])
+					sb.WriteString(r)
+					j += n - 1
+					continue
+				}
+				sb.WriteByte(src[j])
+			}
+			if j >= len(src) {
+				return nil, fmt.Errorf("awk: line %d: unterminated string", line)
+			}
+			toks = append(toks, awkToken{kind: awkStrTok, text: sb.String(), line: line})
+			i = j + 1
+		case c == '/' && regexAllowed():
+			var sb strings.Builder
+			j := i + 1
+			for ; j < len(src) && src[j] != '/'; j++ {
+				if src[j] == '\n' {
+					return nil, fmt.Errorf("awk: line %d: newline in regex", line)
+				}
+				if src[j] == '\\' && j+1 < len(src) {
+					if src[j+1] == '/' {
+						sb.WriteByte('/')
+					} else {
+						sb.WriteByte('\\')
+						sb.WriteByte(src[j+1])
+					}
+					j++
+					continue
+				}
+				sb.WriteByte(src[j])
+			}
+			if j >= len(src) {
+				return nil, fmt.Errorf("awk: line %d: unterminated regex", line)
+			}
+			toks = append(toks, awkToken{kind: awkRegex, text: sb.String(), line: line})
+			i = j + 1
+		case c >= '0' && c <= '9' || c == '.' && i+1 < len(src) && src[i+1] >= '0' && src[i+1] <= '9':
+			m := awkNumberRe.FindString(src[i:])
+			n, err := strconv.ParseFloat(m, 64)
+			if err != nil {
+				return nil, fmt.Errorf("awk: line %d: invalid number %q", line, m)
+			}
+			toks = append(toks, awkToken{kind: awkNumTok, text: m, num: n, line: line})
+			i += len(m)
+		case c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z':
+			j := i + 1
+			for j < len(src) && (src[j] == '_' || src[j] >= 'a' && src[j] <= 'z' || src[j] >= 'A' && src[j] <= 'Z' || src[j] >= '0' && src[j] <= '9') {
+				j++
+			}
+			word := src[i:j]
+			kind := awkName
+			switch {
+			case awkKeywords[word]:
+				kind = awkKeyword
+			case awkBuiltinFuncs[word]:
+				kind = awkFunc
+			}
+			toks = append(toks, awkToken{kind: kind, text: word, line: line})
+			i = j
+		default:
+			matched := false
+			for _, p := range awkPuncts {
+				if strings.HasPrefix(src[i:], p) {
+					toks = append(toks, awkToken{kind: awkPunct, text: p, line: line})
+					i += len(p)
+					matched = true
+					break
+				}
+			}
+			if !matched {
+				return nil, fmt.Errorf("awk: line %d: unexpected character %q", line, c)
+			}
+		}
+	}
+	return append(toks, awkToken{kind: awkEOF, line: line}), nil
+}
+
+var awkNumberRe = regexp.MustCompile(`^(\d+\.?\d*|\.\d+)([eE][+-]?\d+)?`)
+
+// awkEscape decodes the escape sequence at the start of s (after the
+// backslash) and returns the text and the number of bytes consumed.
+func awkEscape(s string) (string, int) {
+	switch s[0] {
+	case 'n':
+		return "\n", 1
+	case 't':
+		return "\t", 1
+	case 'r':
+		return "\r", 1
+	case 'a':
+		return "\a", 1
+	case 'b':
+		return "\b", 1
+	case 'f':
+		return "\f", 1
+	case 'v':
+		return "\v", 1
+	case '"', '\\', '/':
+		return s[:1], 1
+	}
+	n := 0
+	for n < len(s) && n < 3 && s[n] >= '0' && s[n] <= '7' {
+		n++
+	}
+	if n > 0 {
+		v, _ := strconv.ParseUint(s[:n], 8, 8)
+		return string(rune(v)), n
+	}
+	return "\\" + s[:1], 1
+}
+
+// awkUnescape decodes escapes in command-line values (-v, -F).
+func awkUnescape(s string) string {
+	if !strings.Contains(s, "\\") {
+		return s
+	}
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) {
+			r, n := awkEscape(s[i+1:])
+			sb.WriteString(r)
+			i += n
+			continue
+		}
+		sb.WriteByte(s[i])
+	}
+	return sb.String()
+}
+
+// ─── AST ───
+
+type awkExpr interface{}
+
+type (
+	awkNumLit   struct{ v float64 }
+	awkStrLit   struct{ v string }
+	awkRegexLit struct{ re *regexp.Regexp }
+	awkVarRef   struct{ name string }
+	awkFieldRef struct{ index awkExpr }
+	awkIndexRef struct {
+		name string
+		subs []awkExpr
+	}
+	awkAssign struct {
+		target awkExpr
+		op     string // "=", "+=", ...
+		value  awkExpr
+	}
+	awkIncDec struct {
+		target awkExpr
+		op     string // "++" or "--"
+		prefix bool
+	}
+	awkUnary struct {
+		op string
+		x  awkExpr
+	}
+	awkBinary struct {
+		op   string // arithmetic, comparison, "&&", "||", or "" for concatenation
+		l, r awkExpr
+	}
+	awkMatch struct {
+		x, re  awkExpr
+		negate bool
+	}
+	awkCond struct{ cond, yes, no awkExpr }
+	awkIn   struct {
+		subs  []awkExpr
+		array string
+	}
+	awkCall struct {
+		name string
+		args []awkExpr
+	}
+	awkGroup struct{ x awkExpr } // parenthesised, so "print (a > b)" is a comparison
+)
+
+type awkStmt interface{}
+
+type (
+	awkPrint struct {
+		args   []awkExpr
+		printf bool
+	}
+	awkExprStmt struct{ x awkExpr }
+	awkIf       struct {
+		cond      awkExpr
+		then, els awkStmt
+	}
+	awkWhile struct {
+		cond awkExpr
+		body awkStmt
+	}
+	awkFor struct {
+		init, post awkStmt
+		cond       awkExpr
+		body       awkStmt
+	}
+	awkForIn struct {
+		name, array string
+		body        awkStmt
+	}
+	awkBlock    struct{ stmts []awkStmt }
+	awkNext     struct{}
+	awkExit     struct{ code awkExpr }
+	awkBreak    struct{}
+	awkContinue struct{}
+	awkDelete   struct {
+		array string
+		subs  []awkExpr // nil deletes the whole array
+	}
+)
+
+type awkRule struct {
+	pattern awkExpr   // nil matches every record
+	action  *awkBlock // nil prints the record
+}
+
+type awkProgram struct {
+	begin, end []*awkBlock
+	rules      []awkRule
+}
+
+// ─── Parser ───
+
+type awkParser struct {
+	toks []awkToken
+	pos  int
+}
+
+func parseAwk(src string) (*awkProgram, error) {
+	toks, err := awkLex(src)
+	if err != nil {
+		return nil, err
+	}
+	p := &awkParser{toks: toks}
+	prog := &awkProgram{}
+	err = awkCatch(func() {
+		for {
+			p.skipTerminators()
+			t := p.peek()
+			switch {
+			case t.kind == awkEOF:
+				return
+			case t.kind == awkKeyword && t.text == "BEGIN":
+				p.pos++
+				prog.begin = append(prog.begin, p.block())
+			case t.kind == awkKeyword && t.text == "END":
+				p.pos++
+				prog.end = append(prog.end, p.block())
+			case t.kind == awkKeyword && t.text == "function":
+				p.fail("function definitions are not supported")
+			case p.is("{"):
+				prog.rules = append(prog.rules, awkRule{action: p.block()})
+			default:
+				rule := awkRule{pattern: p.expr(false)}
+				if p.is("{") {
+					rule.action = p.block()
+				}
+				prog.rules = append(prog.rules, rule)
+			}
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return prog, nil
+}
+
+// awkSyntaxError is raised by the parser and recovered by awkCatch.
+type awkSyntaxError struct{ msg string }
+
+func awkCatch(fn func()) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			se, ok := r.(awkSyntaxError)
+			if !ok {
+				panic(r)
+			}
+			err = fmt.Errorf("awk: %s", se.msg)
+		}
+	}()
+	fn()
+	return nil
+}
+
+func (p *awkParser) fail(format string, args ...any) {
+	msg := fmt.Sprintf(format, args...)
+	panic(awkSyntaxError{fmt.Sprintf("line %d: %s", p.peek().line, msg)})
+}
+
+func (p *awkParser) peek() awkToken { return p.toks[p.pos] }
+
+func (p *awkParser) next() awkToken {
+	t := p.toks[p.pos]
+	if t.kind != awkEOF {
+		p.pos++
+	}
+	return t
+}
+
+// is reports whether the next token is the punctuation or keyword s.
+func (p *awkParser) is(s string) bool {
+	t := p.peek()
+	return (t.kind == awkPunct || t.kind == awkKeyword) && t.text == s
+}
+
+func (p *awkParser) accept(s string) bool {
+	if p.is(s) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *awkParser) expect(s string) {
+	if !p.accept(s) {
+		p.fail("syntax error near %s, expected %q", p.describe(), s)
+	}
+}
+
+func (p *awkParser) describe() string {
+	t := p.peek()
+	switch t.kind {
+	case awkEOF:
+		return "end of program"
+	case awkNewline:
+		return "newline"
+	}
+	return strconv.Quote(t.text)
+}
+
+func (p *awkParser) skipNewlines() {
+	for p.peek().kind == awkNewline {
+		p.pos++
+	}
+}
+
+func (p *awkParser) skipTerminators() {
+	for p.peek().kind == awkNewline || p.is(";") {
+		p.pos++
+	}
+}
+
+func (p *awkParser) block() *awkBlock {
+	p.skipNewlines()
+	p.expect("{")
+	b := &awkBlock{}
+	for {
+		p.skipTerminators()
+		if p.accept("}") {
+			return b
+		}
+		if p.peek().kind == awkEOF {
+			p.fail("missing }")
+		}
+		b.stmts = append(b.stmts, p.stmt())
+	}
+}
+
+// endStmt consumes a statement terminator: ";", a newline, or nothing
+// before "}".
+func (p *awkParser) endStmt() {
+	switch {
+	case p.accept(";"), p.peek().kind == awkNewline:
+		p.skipNewlines()
+	case p.is("}"), p.peek().kind == awkEOF:
+	default:
+		p.fail("syntax error near %s", p.describe())
+	}
+}
+
+func (p *awkParser) stmt() awkStmt {
+	t := p.peek()
+	if t.kind == awkPunct && t.text == "{" {
+		return p.block()
+	}
+	if t.kind != awkKeyword {
+		s := p.simpleStmt()
+		p.endStmt()
+		return s
+	}
+	switch t.text {
+	case "if":
+		p.pos++
+		p.expect("(")
+		s := &awkIf{cond: p.expr(false)}
+		p.expect(")")
+		p.skipNewlines()
+		s.then = p.stmt()
+		save := p.pos
+		p.skipTerminators()
+		if p.accept("else") {
+			p.skipNewlines()
+			s.els = p.stmt()
+		} else {
+			p.pos = save
+		}
+		return s
+	case "while":
+		p.pos++
+		p.expect("(")
+		s := &awkWhile{cond: p.expr(false)}
+		p.expect(")")
+		if p.accept(";") {
+			return s
+		}
+		p.skipNewlines()
+		s.body = p.stmt()
+		return s
+	case "for":
+		p.pos++
+		p.expect("(")
+		if p.peek().kind == awkName && p.toks[p.pos+1].text == "in" && p.toks[p.pos+2].kind == awkName && p.toks[p.pos+3].text == ")" {
+			s := &awkForIn{name: p.next().text}
+			p.pos++
+			s.array = p.next().text
+			p.pos++
+			p.skipNewlines()
+			s.body = p.stmt()
+			return s
+		}
+		s := &awkFor{}
+		if !p.is(";") {
+			s.init = p.simpleStmt()
+		}
+		p.expect(";")
+		p.skipNewlines()
+		if !p.is(";") {
+			s.cond = p.expr(false)
+		}
+		p.expect(";")
+		p.skipNewlines()
+		if !p.is(")") {
+			s.post = p.simpleStmt()
+		}
+		p.expect(")")
+		if p.accept(";") {
+			return s
+		}
+		p.skipNewlines()
+		s.body = p.stmt()
+		return s
+	case "next", "break", "continue":
+		p.pos++
+		var s awkStmt = &awkNext{}
+		if t.text == "break" {
+			s = &awkBreak{}
+		} else if t.text == "continue" {
+			s = &awkContinue{}
+		}
+		p.endStmt()
+		return s
+	case "exit":
+		p.pos++
+		s := &awkExit{}
+		if !p.is(";") && !p.is("}") && p.peek().kind != awkNewline && p.peek().kind != awkEOF {
+			s.code = p.expr(false)
+		}
+		p.endStmt()
+		return s
+	case "delete":
+		p.pos++
+		if p.peek().kind != awkName {
+			p.fail("delete requires an array name")
+		}
+		s := &awkDelete{array: p.next().text}
+		if p.accept("[") {
+			s.subs = p.exprList("]")
+		}
+		p.endStmt()
+		return s
+	case "getline", "do":
+		p.fail("%s is not supported", t.text)
+	}
+	s := p.simpleStmt()
+	p.endStmt()
+	return s
+}
+
+// simpleStmt parses a print statement or an expression.
+func (p *awkParser) simpleStmt() awkStmt {
+	if p.is("print") || p.is("printf") {
+		s := &awkPrint{printf: p.next().text == "printf"}
+		if p.is(";") || p.is("}") || p.is(">") || p.is(">>") || p.is("|") || p.peek().kind == awkNewline || p.peek().kind == awkEOF {
+			if s.printf {
+				p.fail("printf: no format")
+			}
+		} else if args, ok := p.tryGroupedList(); ok {
+			s.args = args
+		} else {
+			s.args = append(s.args, p.expr(true))
+			for p.accept(",") {
+				p.skipNewlines()
+				s.args = append(s.args, p.expr(true))
+			}
+		}
+		if p.is(">") || p.is(">>") || p.is("|") {
+			p.fail("output redirection is not supported")
+		}
+		return s
+	}
+	return &awkExprStmt{p.expr(false)}
+}
+
+// tryGroupedList parses "print (a, b)" style argument lists. It backtracks
+// unless the parenthesised list ends the statement.
+func (p *awkParser) tryGroupedList() ([]awkExpr, bool) {
+	if !p.is("(") {
+		return nil, false
+	}
+	save := p.pos
+	ok := awkCatch(func() {
+		p.pos++
+		args := p.exprList(")")
+		if len(args) < 2 {
+			panic(awkSyntaxError{"not a list"})
+		}
+		t := p.peek()
+		if !(t.kind == awkNewline || t.kind == awkEOF || p.is(";") || p.is("}") || p.is(">") || p.is(">>") || p.is("|")) {
+			panic(awkSyntaxError{"not a list"})
+		}
+	}) == nil
+	if !ok {
+		p.pos = save
+		return nil, false
+	}
+	p.pos = save + 1
+	return p.exprList(")"), true
+}
+
+// exprList parses comma-separated expressions up to the closing token.
+func (p *awkParser) exprList(closing string) []awkExpr {
+	var list []awkExpr
+	p.skipNewlines()
+	if p.accept(closing) {
+		return list
+	}
+	for {
+		list = append(list, p.expr(false))
+		p.skipNewlines()
+		if p.accept(closing) {
+			return list
+		}
+		p.expect(",")
+		p.skipNewlines()
+	}
+}
+
+// expr parses an expression. noGT stops at an unparenthesised ">", which
+// in print arguments means output redirection.
+func (p *awkParser) expr(noGT bool) awkExpr {
+	lhs := p.ternary(noGT)
+	t := p.peek()
+	if t.kind == awkPunct {
+		switch t.text {
+		case "=", "+=", "-=", "*=", "/=", "%=", "^=":
+			if !awkIsLvalue(lhs) {
+				p.fail("assignment to non-variable")
+			}
+			p.pos++
+			p.skipNewlines()
+			return &awkAssign{target: lhs, op: t.text, value: p.expr(noGT)}
+		}
+	}
+	return lhs
+}
+
+func awkIsLvalue(e awkExpr) bool {
+	switch e.(type) {
+	case *awkVarRef, *awkFieldRef, *awkIndexRef:
+		return true
+	}
+	return false
+}
+
+func (p *awkParser) ternary(noGT bool) awkExpr {
+	cond := p.or(noGT)
+	if !p.accept("?") {
+		return cond
+	}
+	p.skipNewlines()
+	yes := p.ternary(noGT)
+	p.skipNewlines()
+	p.expect(":")
+	p.skipNewlines()
+	return &awkCond{cond: cond, yes: yes, no: p.ternary(noGT)}
+}
+
+func (p *awkParser) or(noGT bool) awkExpr {
+	x := p.and(noGT)
+	for p.accept("||") {
+		p.skipNewlines()
+		x = &awkBinary{op: "||", l: x, r: p.and(noGT)}
+	}
+	return x
+}
+
+func (p *awkParser) and(noGT bool) awkExpr {
+	x := p.in(noGT)
+	for p.accept("&&") {
+		p.skipNewlines()
+		x = &awkBinary{op: "&&", l: x, r: p.in(noGT)}
+	}
+	return x
+}
+
+func (p *awkParser) in(noGT bool) awkExpr {
+	x := p.match(noGT)
+	for p.is("in") {
+		p.pos++
+		if p.peek().kind != awkName {
+			p.fail("in requires an array name")
+		}
+		x = &awkIn{subs: []awkExpr{x}, array: p.next().text}
+	}
+	return x
+}
+
+func (p *awkParser) match(noGT bool) awkExpr {
+	x := p.relational(noGT)
+	for p.is("~") || p.is("!~") {
+		negate := p.next().text == "!~"
+		x = &awkMatch{x: x, re: p.relational(noGT), negate: negate}
+	}
+	return x
+}
+
+func (p *awkParser) relational(noGT bool) awkExpr {
+	x := p.concat(noGT)
+	t := p.peek()
+	if t.kind == awkPunct {
+		switch t.text {
+		case ">":
+			if noGT {
+				return x
+			}
+			fallthrough
+		case "<", "<=", ">=", "==", "!=":
+			p.pos++
+			return &awkBinary{op: t.text, l: x, r: p.concat(noGT)}
+		}
+	}
+	return x
+}
+
+func (p *awkParser) concat(noGT bool) awkExpr {
+	x := p.additive()
+	for {
+		t := p.peek()
+		// Juxtaposed operands concatenate; "-" and "!" are never taken as
+		// the start of one, so "a -1" stays a subtraction.
+		switch {
+		case t.kind == awkNumTok, t.kind == awkStrTok, t.kind == awkRegex,
+			t.kind == awkName, t.kind == awkFunc,
+			t.kind == awkPunct && (t.text == "$" || t.text == "("):
+			x = &awkBinary{op: "", l: x, r: p.additive()}
+		default:
+			return x
+		}
+	}
+}
+
+func (p *awkParser) additive() awkExpr {
+	x := p.multiplicative()
+	for p.is("+") || p.is("-") {
+		op := p.next().text
+		x = &awkBinary{op: op, l: x, r: p.multiplicative()}
+	}
+	return x
+}
+
+func (p *awkParser) multiplicative() awkExpr {
+	x := p.unary()
+	for p.is("*") || p.is("/") || p.is("%") {
+		op := p.next().text
+		x = &awkBinary{op: op, l: x, r: p.unary()}
+	}
+	return x
+}
+
+func (p *awkParser) unary() awkExpr {
+	if p.is("!") || p.is("-") || p.is("+") {
+		op := p.next().text
+		return &awkUnary{op: op, x: p.unary()}
+	}
+	return p.power()
+}
+
+func (p *awkParser) power() awkExpr {
+	x := p.postfix()
+	if p.accept("^") {
+		// Right associative, and binds tighter than unary minus on the left
+		// but allows it on the right: 2^-1.
+		return &awkBinary{op: "^", l: x, r: p.unaryPower()}
+	}
+	return x
+}
+
+func (p *awkParser) unaryPower() awkExpr {
+	if p.is("-") || p.is("+") || p.is("!") {
+		op := p.next().text
+		return &awkUnary{op: op, x: p.unaryPower()}
+	}
+	return p.power()
+}
+
+func (p *awkParser) postfix() awkExpr {
+	if p.is("++") || p.is("--") {
+		op := p.next().text
+		x := p.postfix()
+		if !awkIsLvalue(x) {
+			p.fail("%s requires a variable", op)
+		}
+		return &awkIncDec{target: x, op: op, prefix: true}
+	}
+	x := p.primary()
+	if awkIsLvalue(x) && (p.is("++") || p.is("--")) {
+		return &awkIncDec{target: x, op: p.next().text}
+	}
+	return x
+}
+
+func (p *awkParser) primary() awkExpr {
+	t := p.next()
+	switch t.kind {
+	case awkNumTok:
+		return &awkNumLit{t.num}
+	case awkStrTok:
+		return &awkStrLit{t.text}
+	case awkRegex:
+		re, err := regexp.Compile(t.text)
+		if err != nil {
+			p.pos--
+			p.fail("invalid regex /%s/: %v", t.text, err)
+		}
+		return &awkRegexLit{re}
+	case awkName:
+		if p.accept("[") {
+			return &awkIndexRef{name: t.text, subs: p.exprList("]")}
+		}
+		return &awkVarRef{t.text}
+	case awkFunc:
+		if !p.accept("(") {
+			if t.text == "length" {
+				return &awkCall{name: "length"}
+			}
+			p.pos--
+			p.fail("%s requires arguments", t.text)
+		}
+		return &awkCall{name: t.text, args: p.exprList(")")}
+	case awkPunct:
+		switch t.text {
+		case "$":
+			if p.is("++") || p.is("--") || p.is("-") {
+				return &awkFieldRef{p.unary()}
+			}
+			return &awkFieldRef{p.primary()}
+		case "(":
+			p.skipNewlines()
+			x := p.expr(false)
+			p.skipNewlines()
+			if p.is(",") {
+				subs := []awkExpr{x}
+				for p.accept(",") {
+					p.skipNewlines()
+					subs = append(subs, p.expr(false))
+				}
+				p.expect(")")
+				if !p.is("in") {
+					p.fail("expected in after (list)")
+				}
+				p.pos++
+				if p.peek().kind != awkName {
+					p.fail("in requires an array name")
+				}
+				return &awkIn{subs: subs, array: p.next().text}
+			}
+			p.expect(")")
+			return &awkGroup{x}
+		}
+	}
+	p.pos--
+	p.fail("syntax error near %s", p.describe())
+	return nil
+}
@@ -1,138 +1,278 @@
 package builtins
 
 import (
+	"sort"
+	"sync"
+
 	grasp "github.com/jackfish212/grasp"
 	"github.com/jackfish212/grasp/mounts"
 )
 
+var (
+	registered   = make(map[*grasp.VirtualOS][]string)
+	registeredMu sync.RWMutex
+)
+
 // RegisterBuiltins mounts standard grasp utilities at the given path (e.g. "/bin").
 func RegisterBuiltins(v *grasp.VirtualOS, mountPath string) error {
 	fs := mounts.NewMemFS(grasp.PermRW)
-	registerAllBuiltins(v, fs, "")
-	return v.Mount(mountPath, fs)
+	names := registerAllBuiltins(v, fs, "")
+	if err := v.Mount(mountPath, fs); err != nil {
+		return err
+	}
+	recordRegistered(v, names)
+	return nil
 }
 
-// RegisterBuiltinsOnFS registers standard grasp utilities on the given MemFS at /usr/bin.
-func RegisterBuiltinsOnFS(v *grasp.VirtualOS, fs *mounts.MemFS) error {
-	registerAllBuiltins(v, fs, "usr/bin/")
-	return nil
+// RegisterBuiltinsOnFS registers standard grasp utilities on the given MemFS
+// at /usr/bin and returns the names of the registered commands.
+func RegisterBuiltinsOnFS(v *grasp.VirtualOS, fs *mounts.MemFS) ([]string, error) {
+	names := registerAllBuiltins(v, fs, "usr/bin/")
+	recordRegistered(v, names)
+	return names, nil
+}
+
+// ListRegistered returns the sorted names of the builtin commands registered
+// on v by RegisterBuiltins or RegisterBuiltinsOnFS.
+func ListRegistered(v *grasp.VirtualOS) []string {
+	registeredMu.RLock()
+	defer registeredMu.RUnlock()
+	return append([]string(nil), registered[v]...)
+}
+
+func recordRegistered(v *grasp.VirtualOS, names []string) {
+	registeredMu.Lock()
+	defer registeredMu.Unlock()
+	seen := make(map[string]bool)
+	var all []string
+	for _, n := range append(registered[v], names...) {
+		if !seen[n] {
+			seen[n] = true
+			all = append(all, n)
+		}
+	}
+	sort.Strings(all)
+	registered[v] = all
 }
 
-func registerAllBuiltins(v *grasp.VirtualOS, fs *mounts.MemFS, prefix string) {
-	fs.AddExecFunc(prefix+"ls", builtinLs(v), mounts.FuncMeta{
+func registerAllBuiltins(v *grasp.VirtualOS, fs *mounts.MemFS, prefix string) []string {
+	var names []string
+	add := func(name string, fn mounts.ExecFunc, meta mounts.FuncMeta) {
+		fs.AddExecFunc(prefix+name, fn, meta)
+		names = append(names, name)
+	}
+
+	add("ls", builtinLs(v), mounts.FuncMeta{
 		Description: "List directory entries",
 		Usage:       "ls [path]",
 	})
-	fs.AddExecFunc(prefix+"read", builtinRead(v), mounts.FuncMeta{
+	add("read", builtinRead(v), mounts.FuncMeta{
 		Description: "Read file content",
 		Usage:       "read <path>",
 	})
-	fs.AddExecFunc(prefix+"cat", builtinRead(v), mounts.FuncMeta{
+	add("cat", builtinRead(v), mounts.FuncMeta{
 		Description: "Read file content",
 		Usage:       "cat <path>",
 	})
-	fs.AddExecFunc(prefix+"write", builtinWrite(v), mounts.FuncMeta{
+	add("write", builtinWrite(v), mounts.FuncMeta{
 		Description: "Write content to file",
 		Usage:       "write <path> [content]",
 	})
-	fs.AddExecFunc(prefix+"stat", builtinStat(v), mounts.FuncMeta{
+	add("stat", builtinStat(v), mounts.FuncMeta{
 		Description: "Show entry metadata",
 		Usage:       "stat <path>",
 	})
-	fs.AddExecFunc(prefix+"search", builtinSearch(v), mounts.FuncMeta{
+	add("search", builtinSearch(v), mounts.FuncMeta{
 		Description: "Cross-mount search",
 		Usage:       "search <query> [--scope <path>] [--max N]",
 	})
-	fs.AddExecFunc(prefix+"grep", builtinGrep(v), mounts.FuncMeta{
+	add("grep", builtinGrep(v), mounts.FuncMeta{
 		Description: "Search for patterns in files",
-		Usage:       "grep [OPTIONS] PATTERN [FILE]...",
+		Usage:       "grep [-i] [-v] [-n] [-c] [-r] [-w] [-e PATTERN] [-A|-B|-C NUM] PATTERN [FILE]...",
 	})
-	fs.AddExecFunc(prefix+"mount", builtinMount(v), mounts.FuncMeta{
+	add("mount", builtinMount(v), mounts.FuncMeta{
 		Description: "List mount points",
 		Usage:       "mount",
 	})
-	fs.AddExecFunc(prefix+"bind", builtinBind(v), mounts.FuncMeta{
+	add("bind", builtinBind(v), mounts.FuncMeta{
 		Description: "Plan 9-style union bind",
 		Usage:       "bind [-b|-a] source_path target_path",
 	})
-	fs.AddExecFunc(prefix+"which", builtinWhich(v), mounts.FuncMeta{
+	add("which", builtinWhich(v), mounts.FuncMeta{
 		Description: "Show full path of command",
 		Usage:       "which <command>...",
 	})
-	fs.AddExecFunc(prefix+"find", builtinFind(v), mounts.FuncMeta{
+	add("find", builtinFind(v), mounts.FuncMeta{
 		Description: "Search for files in a directory hierarchy",
 		Usage:       "find [path] [-name PATTERN] [-type f|d] [-maxdepth N]",
 	})
-	fs.AddExecFunc(prefix+"head", builtinHead(v), mounts.FuncMeta{
+	add("head", builtinHead(v), mounts.FuncMeta{
 		Description: "Output the first part of files",
 		Usage:       "head [-n LINES | -c BYTES] [FILE]...",
 	})
-	fs.AddExecFunc(prefix+"tail", builtinTail(v), mounts.FuncMeta{
+	add("tail", builtinTail(v), mounts.FuncMeta{
 		Description: "Output the last part of files",
 		Usage:       "tail [-n LINES | -c BYTES] [FILE]...",
 	})
-	fs.AddExecFunc(prefix+"mkdir", builtinMkdir(v), mounts.FuncMeta{
+	add("mkdir", builtinMkdir(v), mounts.FuncMeta{
 		Description: "Create directories",
 		Usage:       "mkdir [-p] <path>...",
 	})
-	fs.AddExecFunc(prefix+"rm", builtinRm(v), mounts.FuncMeta{
+	add("rm", builtinRm(v), mounts.FuncMeta{
 		Description: "Remove files or directories",
 		Usage:       "rm [-r|-rf] <path>...",
 	})
-	fs.AddExecFunc(prefix+"rmdir", builtinRmdir(v), mounts.FuncMeta{
+	add("rmdir", builtinRmdir(v), mounts.FuncMeta{
 		Description: "Remove empty directories",
 		Usage:       "rmdir [-p] [--ignore-fail-on-non-empty] [-v] <directory>...",
 	})
-	fs.AddExecFunc(prefix+"mv", builtinMv(v), mounts.FuncMeta{
+	add("mv", builtinMv(v), mounts.FuncMeta{
 		Description: "Move (rename) files",
 		Usage:       "mv <source> <dest>",
 	})
-	fs.AddExecFunc(prefix+"cp", builtinCp(v), mounts.FuncMeta{
+	add("cp", builtinCp(v), mounts.FuncMeta{
 		Description: "Copy files",
 		Usage:       "cp [-r] <source> <dest>",
 	})
-	fs.AddExecFunc(prefix+"uname", builtinUname(), mounts.FuncMeta{
+	add("uname", builtinUname(), mounts.FuncMeta{
 		Description: "Print system information",
 		Usage:       "uname [-a|-s|-n|-r|-v|-m]",
 	})
-	fs.AddExecFunc(prefix+"date", builtinDate(v), mounts.FuncMeta{
+	add("date", builtinDate(v), mounts.FuncMeta{
 		Description: "Display the current date and time",
 		Usage:       "date [+FORMAT]",
 	})
-	fs.AddExecFunc(prefix+"whoami", builtinWhoami(v), mounts.FuncMeta{
+	add("whoami", builtinWhoami(v), mounts.FuncMeta{
 		Description: "Display the current user",
 		Usage:       "whoami",
 	})
-	fs.AddExecFunc(prefix+"sleep", builtinSleep(v), mounts.FuncMeta{
+	add("sleep", builtinSleep(v), mounts.FuncMeta{
 		Description: "Delay for a specified time",
 		Usage:       "sleep NUMBER[SUFFIX]",
 	})
-	fs.AddExecFunc(prefix+"true", builtinTrue(v), mounts.FuncMeta{
+	add("true", builtinTrue(v), mounts.FuncMeta{
 		Description: "Return success exit status",
 		Usage:       "true",
 	})
-	fs.AddExecFunc(prefix+"false", builtinFalse(v), mounts.FuncMeta{
+	add("false", builtinFalse(v), mounts.FuncMeta{
 		Description: "Return failure exit status",
 		Usage:       "false",
 	})
-	fs.AddExecFunc(prefix+"whereis", builtinWhereis(v), mounts.FuncMeta{
+	add("test", builtinTest(v, "test"), mounts.FuncMeta{
+		Description: "Evaluate a conditional expression",
+		Usage:       "test EXPRESSION",
+	})
+	add("[", builtinTest(v, "["), mounts.FuncMeta{
+		Description: "Evaluate a conditional expression",
+		Usage:       "[ EXPRESSION ]",
+	})
+	add("whereis", builtinWhereis(v), mounts.FuncMeta{
 		Description: "Locate command files",
 		Usage:       "whereis COMMAND...",
 	})
-	fs.AddExecFunc(prefix+"sed", builtinSed(v), mounts.FuncMeta{
+	add("sed", builtinSed(v), mounts.FuncMeta{
 		Description: "Stream editor for filtering and transforming text",
 		Usage:       "sed [-n] -e SCRIPT [FILE]...",
 	})
-	fs.AddExecFunc(prefix+"touch", builtinTouch(v), mounts.FuncMeta{
+	add("lsattr", builtinLsattr(v), mounts.FuncMeta{
+		Description: "List file metadata attributes",
+		Usage:       "lsattr [-n KEY] FILE...",
+	})
+	add("setattr", builtinSetattr(v), mounts.FuncMeta{
+		Description: "Set file metadata attributes",
+		Usage:       "setattr FILE KEY=VALUE...",
+	})
+	add("zip", builtinZip(v), mounts.FuncMeta{
+		Description: "Package files into a ZIP archive",
+		Usage:       "zip [-r] ARCHIVE.zip FILE...",
+	})
+	add("unzip", builtinUnzip(v), mounts.FuncMeta{
+		Description: "Extract a ZIP archive",
+		Usage:       "unzip ARCHIVE.zip [-d DEST]",
+	})
+	add("gzip", builtinGzip(v, "gzip"), mounts.FuncMeta{
+		Description: "Compress or decompress files",
+		Usage:       "gzip [-k] [-d] [-f] FILE...",
+	})
+	add("gunzip", builtinGzip(v, "gunzip"), mounts.FuncMeta{
+		Description: "Decompress gzip files",
+		Usage:       "gunzip [-k] [-f] FILE...",
+	})
+	add("ln", builtinLn(v), mounts.FuncMeta{
+		Description: "Make hard or symbolic links",
+		Usage:       "ln [-s] TARGET LINK_NAME",
+	})
+	add("md5sum", builtinChecksum(v, "md5sum"), mounts.FuncMeta{
+		Description: "Compute and check MD5 checksums",
+		Usage:       "md5sum [FILE]... | md5sum --check FILE",
+	})
+	add("sha256sum", builtinChecksum(v, "sha256sum"), mounts.FuncMeta{
+		Description: "Compute and check SHA-256 checksums",
+		Usage:       "sha256sum [FILE]... | sha256sum --check FILE",
+	})
+	add("xxd", builtinXxd(v), mounts.FuncMeta{
+		Description: "Make a hex dump of a file",
+		Usage:       "xxd [-l LENGTH] [-s OFFSET] [-c COLS] [FILE]",
+	})
+	add("install", builtinInstall(v), mounts.FuncMeta{
+		Description: "Copy files or create directories with explicit permissions",
+		Usage:       "install [-d] [-m MODE] [-o OWNER] SOURCE... DEST",
+	})
+	add("mktemp", builtinMktemp(v), mounts.FuncMeta{
+		Description: "Create a uniquely named temporary file or directory",
+		Usage:       "mktemp [-d] [-p DIR] [TEMPLATE]",
+	})
+	add("cut", builtinCut(v), mounts.FuncMeta{
+		Description: "Remove sections from each line of files",
+		Usage:       "cut -f LIST [-d DELIM] [-s] [FILE]... | cut -c LIST [FILE]...",
+	})
+	add("tee", builtinTee(v), mounts.FuncMeta{
+		Description: "Copy standard input to files and standard output",
+		Usage:       "tee [-a] [FILE]...",
+	})
+	add("diff", builtinDiff(v), mounts.FuncMeta{
+		Description: "Compare files or directories line by line",
+		Usage:       "diff [-u | -c] [-q] [-r] FROM TO",
+	})
+	add("awk", builtinAwk(v), mounts.FuncMeta{
+		Description: "Pattern scanning and text processing",
+		Usage:       "awk [-F FS] [-v VAR=VALUE] 'PROGRAM' [FILE]...",
+	})
+	add("tr", builtinTr(v), mounts.FuncMeta{
+		Description: "Translate, squeeze or delete characters",
+		Usage:       "tr [-c] [-d] [-s] SET1 [SET2]",
+	})
+	add("touch", builtinTouch(v), mounts.FuncMeta{
 		Description: "Update file timestamps or create empty files",
 		Usage:       "touch <file>...",
 	})
-	fs.AddExecFunc(prefix+"wc", builtinWc(v), mounts.FuncMeta{
+	add("wc", builtinWc(v), mounts.FuncMeta{
 		Description: "Print newline, word, and byte counts",
 		Usage:       "wc [-l|-w|-m|-c|-L] [FILE]...",
 	})
-	fs.AddExecFunc(prefix+"jsonq", builtinJsonq(v), mounts.FuncMeta{
+	add("nl", builtinNl(v), mounts.FuncMeta{
+		Description: "Number lines of files",
+		Usage:       "nl [-b STYLE] [-h STYLE] [-f STYLE] [-v START] [-i INCREMENT] [FILE]...",
+	})
+	add("fold", builtinFold(v), mounts.FuncMeta{
+		Description: "Wrap long lines to a specified width",
+		Usage:       "fold [-s|--spaces] [-w|--width WIDTH] [FILE]...",
+	})
+	add("paste", builtinPaste(v), mounts.FuncMeta{
+		Description: "Merge lines of files",
+		Usage:       "paste [-s] [-d DELIM] FILE...",
+	})
+	add("shuf", builtinShuf(v), mounts.FuncMeta{
+		Description: "Randomly shuffle input lines",
+		Usage:       "shuf [-n COUNT] [-i LO-HI] [-e ARGS...] [FILE]",
+	})
+	add("comm", builtinComm(v), mounts.FuncMeta{
+		Description: "Compare two sorted files line by line",
+		Usage:       "comm [-1] [-2] [-3] FILE1 FILE2",
+	})
+	add("jsonq", builtinJsonq(v), mounts.FuncMeta{
 		Description: "Query JSON data using gojsonq",
-		Usage:       "jsonq [OPTIONS] [QUERY] [FILE]...",
+		Usage:       "jsonq [-f PATH] [-w COND] [--or-where COND] [--where-in COND] [--where-nil FIELD] [--where-not-nil FIELD] [--sort-by FIELD] [--sort-order asc|desc] [--group-by FIELD] [--distinct FIELD] [-n LIMIT] [--offset N] [--pluck FIELD] [-s FIELDS] [--sum|--avg|--min|--max FIELD] [--count] [-r] [QUERY] [FILE]...",
 	})
+	return names
 }
@@ -2,7 +2,13 @@ package builtins
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"io"
+	"path"
+	"slices"
+	"sort"
+	"strconv"
 	"strings"
 	"testing"
 	"time"
@@ -46,7 +52,7 @@ func setupTestEnv(t *testing.T) (*grasp.VirtualOS, *grasp.Shell) {
   ]
 }`), grasp.PermRW)
 
-	if err := RegisterBuiltinsOnFS(v, root); err != nil {
+	if _, err := RegisterBuiltinsOnFS(v, root); err != nil {
 		t.Fatal(err)
 	}
 
@@ -68,6 +74,15 @@ func runCode(t *testing.T, sh *grasp.Shell, cmd string) (string, int) {
 	return result.Output, result.Code
 }
 
+func readVOSFile(t *testing.T, v *grasp.VirtualOS, p string) string {
+	t.Helper()
+	data, err := readFile(context.Background(), v, p)
+	if err != nil {
+		t.Fatalf("read %s: %v", p, err)
+	}
+	return string(data)
+}
+
 // ─── ls ───
 
 func TestLs(t *testing.T) {
@@ -835,6 +850,9 @@ func TestTrueInCondition(t *testing.T) {
 	if !strings.Contains(out, "success") {
 		t.Errorf("should output 'success': %q", out)
 	}
+	if out, code := runCode(t, sh, "true || echo skipped"); code != 0 || out != "" {
+		t.Errorf("true || echo = %q (code %d), want no output and code 0", out, code)
+	}
 }
 
 func TestFalseInCondition(t *testing.T) {
@@ -846,6 +864,54 @@ func TestFalseInCondition(t *testing.T) {
 	if !strings.Contains(out, "fallback") {
 		t.Errorf("should output 'fallback': %q", out)
 	}
+	if out, code := runCode(t, sh, "false && echo skipped"); code != 1 || out != "" {
+		t.Errorf("false && echo = %q (code %d), want no output and code 1", out, code)
+	}
+}
+
+func TestCommandLists(t *testing.T) {
+	v, sh := setupTestEnv(t)
+
+	tests := []struct {
+		cmd, want string
+		code      int
+	}{
+		{"echo a; echo b", "a\nb\n", 0},
+		{"false; echo after", "after\n", 0},
+		{"echo a; false", "a\n", 1},
+		{"echo a;", "a\n", 0},
+		{"false && echo no || echo yes", "yes\n", 0},
+		{"true || echo no && echo yes", "yes\n", 0},
+		{"false || false || echo third", "third\n", 0},
+		{"false && echo no && echo no; echo next", "next\n", 0},
+		{"true && false || true && echo end", "end\n", 0},
+		{"false || true && false", "", 1},
+		{"cat notes.txt | grep foo && echo found", "foo bar\nfound\n", 0},
+		{"cat notes.txt | grep nomatch || echo none", "none\n", 0},
+		{"echo 'a; b && c' && echo \"d || e\"", "a; b && c\nd || e\n", 0},
+		{"echo $(echo x; echo y) && echo z", "x y\nz\n", 0},
+		{"{ echo g1; echo g2; } && echo after", "g1\ng2\nafter\n", 0},
+	}
+	for _, tt := range tests {
+		out, code := runCode(t, sh, tt.cmd)
+		if out != tt.want || code != tt.code {
+			t.Errorf("%s = %q (code %d), want %q (code %d)", tt.cmd, out, code, tt.want, tt.code)
+		}
+	}
+
+	run(t, sh, "mkdir /tmp/x && echo done > /tmp/x/log; echo more >> /tmp/x/log")
+	if out := run(t, sh, "cat /tmp/x/log"); out != "done\nmore\n" {
+		t.Errorf("/tmp/x/log = %q, want %q", out, "done\nmore\n")
+	}
+	if out, code := runCode(t, sh, "mkdir /tmp/x && echo done"); code == 0 || strings.Contains(out, "done") {
+		t.Errorf("mkdir existing && echo = %q (code %d), want failure without echo", out, code)
+	}
+	if _, err := v.Stat(context.Background(), "/tmp/x/log"); err != nil {
+		t.Errorf("/tmp/x/log: %v", err)
+	}
+	if out, code := runCode(t, sh, "mkdir -p /tmp/x && echo exists"); code != 0 || out != "exists\n" {
+		t.Errorf("mkdir -p existing && echo = %q (code %d)", out, code)
+	}
 }
 
 func TestWhereis(t *testing.T) {
@@ -1142,6 +1208,267 @@ func TestRmdirHelp(t *testing.T) {
 	}
 }
 
+// ─── install ───
+
+func TestInstall(t *testing.T) {
+	v, sh := setupTestEnv(t)
+	ctx := context.Background()
+
+	if out, code := runCode(t, sh, "install -m 755 notes.txt /tmp/notes.sh"); code != 0 {
+		t.Fatalf("install failed: %q", out)
+	}
+	entry, err := v.Stat(ctx, "/tmp/notes.sh")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entry.Perm != grasp.PermRWX {
+		t.Errorf("perm = %s, want rwx", entry.Perm)
+	}
+	if out := run(t, sh, "cat /tmp/notes.sh"); out != "hello world\nfoo bar\nbaz qux\n" {
+		t.Errorf("content = %q", out)
+	}
+
+	if out, code := runCode(t, sh, "install -m ro -o alice notes.txt data.csv /tmp"); code != 0 {
+		t.Fatalf("install into dir failed: %q", out)
+	}
+	for _, p := range []string{"/tmp/notes.txt", "/tmp/data.csv"} {
+		entry, err := v.Stat(ctx, p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if entry.Perm != grasp.PermRO {
+			t.Errorf("%s perm = %s, want r--", p, entry.Perm)
+		}
+		if owner, _ := v.GetMeta(ctx, p, "owner"); owner != "alice" {
+			t.Errorf("%s owner = %q, want alice", p, owner)
+		}
+	}
+}
+
+func TestInstallDir(t *testing.T) {
+	v, sh := setupTestEnv(t)
+	ctx := context.Background()
+
+	if out, code := runCode(t, sh, "install -d -m rx /tmp/a /tmp/b"); code != 0 {
+		t.Fatalf("install -d failed: %q", out)
+	}
+	for _, p := range []string{"/tmp/a", "/tmp/b"} {
+		entry, err := v.Stat(ctx, p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !entry.IsDir || entry.Perm != grasp.PermRX {
+			t.Errorf("%s = dir %v perm %s, want r-x directory", p, entry.IsDir, entry.Perm)
+		}
+	}
+	// Existing directories are accepted and have their mode updated.
+	if out, code := runCode(t, sh, "install -d -m 0700 /tmp/a"); code != 0 {
+		t.Fatalf("install -d on existing dir failed: %q", out)
+	}
+	if entry, _ := v.Stat(ctx, "/tmp/a"); entry.Perm != grasp.PermRWX {
+		t.Errorf("perm = %s, want rwx", entry.Perm)
+	}
+}
+
+func TestInstallErrors(t *testing.T) {
+	_, sh := setupTestEnv(t)
+	for _, cmd := range []string{
+		"install notes.txt",
+		"install -m 9z notes.txt /tmp/x",
+		"install notes.txt data.csv /tmp/missing",
+		"install -d notes.txt",
+		"install docs /tmp/docs",
+	} {
+		if _, code := runCode(t, sh, cmd); code == 0 {
+			t.Errorf("%q should fail", cmd)
+		}
+	}
+}
+
+// ─── mktemp ───
+
+func TestMktemp(t *testing.T) {
+	v, sh := setupTestEnv(t)
+	ctx := context.Background()
+
+	out, code := runCode(t, sh, "mktemp")
+	if code != 0 {
+		t.Fatalf("mktemp failed: %q", out)
+	}
+	file := strings.TrimSpace(out)
+	if !strings.HasPrefix(file, "/tmp/tmp.") || len(file) != len("/tmp/tmp.XXXXXX") {
+		t.Errorf("mktemp = %q, want /tmp/tmp.XXXXXX", file)
+	}
+	if e, err := v.Stat(ctx, file); err != nil || e.IsDir {
+		t.Errorf("Stat(%s) = %v, %v; want a file", file, e, err)
+	}
+
+	out, code = runCode(t, sh, "mktemp -d -p docs build.XXXX")
+	if code != 0 {
+		t.Fatalf("mktemp -d failed: %q", out)
+	}
+	dir := strings.TrimSpace(out)
+	if !strings.HasPrefix(dir, "/home/tester/docs/build.") || len(dir) != len("/home/tester/docs/build.XXXX") {
+		t.Errorf("mktemp -d = %q", dir)
+	}
+	if e, err := v.Stat(ctx, dir); err != nil || !e.IsDir {
+		t.Errorf("Stat(%s) = %v, %v; want a directory", dir, e, err)
+	}
+
+	// Names do not repeat, and the printed path is ready to write to.
+	seen := map[string]bool{}
+	for i := 0; i < 20; i++ {
+		p := strings.TrimSpace(run(t, sh, "mktemp work-XXXXXX"))
+		if seen[p] {
+			t.Fatalf("duplicate name %s", p)
+		}
+		seen[p] = true
+	}
+	scratch := strings.TrimSpace(run(t, sh, "mktemp -p /tmp out.XXX"))
+	run(t, sh, "echo data > "+scratch)
+	if out := run(t, sh, "cat "+scratch); out != "data\n" {
+		t.Errorf("cat %s = %q", scratch, out)
+	}
+}
+
+func TestMktempErrors(t *testing.T) {
+	_, sh := setupTestEnv(t)
+	for _, cmd := range []string{"mktemp fileXX", "mktemp -p", "mktemp a.XXX b.XXX", "mktemp -z"} {
+		if _, code := runCode(t, sh, cmd); code == 0 {
+			t.Errorf("%q should fail", cmd)
+		}
+	}
+}
+
+// ─── cut ───
+
+func TestCutFields(t *testing.T) {
+	v, sh := setupTestEnv(t)
+	ctx := context.Background()
+	_ = v.Write(ctx, "/home/tester/tabs.txt", strings.NewReader("a\tb\tc\nno-tabs\nd\te\tf\n"))
+
+	tests := []struct {
+		cmd, want string
+	}{
+		{"cat data.csv | cut -d, -f2", "b\n2\n5\n"},
+		{"cut -d , -f 1,3 data.csv", "a,c\n1,3\n4,6\n"},
+		{"cut -d, -f2- data.csv", "b,c\n2,3\n5,6\n"},
+		{"cut -d, -f-2 data.csv", "a,b\n1,2\n4,5\n"},
+		{"cut -d, -f3,1 data.csv", "a,c\n1,3\n4,6\n"}, // output keeps input order
+		{"cut -d, -f5 data.csv", "\n\n\n"},
+		{"cut -f2 tabs.txt", "b\nno-tabs\ne\n"}, // tab is the default delimiter
+		{"cut -s -f2 tabs.txt", "b\ne\n"},
+		{"cut -d' ' -f2 notes.txt", "world\nbar\nqux\n"},
+		{"cat notes.txt | cut -s -d' ' -f1 -", "hello\nfoo\nbaz\n"},
+	}
+	for _, tt := range tests {
+		out, code := runCode(t, sh, tt.cmd)
+		if code != 0 || out != tt.want {
+			t.Errorf("%s = %q (code %d), want %q", tt.cmd, out, code, tt.want)
+		}
+	}
+}
+
+func TestCutChars(t *testing.T) {
+	v, sh := setupTestEnv(t)
+	ctx := context.Background()
+	_ = v.Write(ctx, "/home/tester/utf8.txt", strings.NewReader("héllo\n"))
+
+	tests := []struct {
+		cmd, want string
+	}{
+		{"cut -c1-5 notes.txt", "hello\nfoo b\nbaz q\n"},
+		{"cut -c 1,3 notes.txt", "hl\nfo\nbz\n"},
+		{"cut -c5- notes.txt", "o world\nbar\nqux\n"},
+		{"echo abcdef | cut -c-3", "abc\n"},
+		{"cut -c2 utf8.txt", "é\n"}, // characters, not bytes
+	}
+	for _, tt := range tests {
+		out, code := runCode(t, sh, tt.cmd)
+		if code != 0 || out != tt.want {
+			t.Errorf("%s = %q (code %d), want %q", tt.cmd, out, code, tt.want)
+		}
+	}
+}
+
+func TestCutErrors(t *testing.T) {
+	_, sh := setupTestEnv(t)
+	for _, cmd := range []string{
+		"cut data.csv",           // no list
+		"cut -f1 -c1 data.csv",   // both lists
+		"cut -f0 data.csv",       // fields start at 1
+		"cut -f3-1 data.csv",     // decreasing range
+		"cut -f- data.csv",       // no endpoint
+		"cut -f x data.csv",      // not a number
+		"cut -d ab -f1 data.csv", // multi-character delimiter
+		"cut -s -c1 data.csv",    // -s needs -f
+		"cut -f",                 // missing argument
+		"cut -f1 missing.txt",    // missing file
+		"cut -z -f1 data.csv",    // unknown option
+	} {
+		if _, code := runCode(t, sh, cmd); code == 0 {
+			t.Errorf("%q should fail", cmd)
+		}
+	}
+}
+
+// ─── tee ───
+
+func TestTee(t *testing.T) {
+	v, sh := setupTestEnv(t)
+	ctx := context.Background()
+	dir := t.TempDir()
+	if err := v.Mount("/disk", mounts.NewLocalFS(dir, grasp.PermRW)); err != nil {
+		t.Fatal(err)
+	}
+
+	out, code := runCode(t, sh, "cat notes.txt | tee copy.txt /disk/copy.txt")
+	if code != 0 || out != "hello world\nfoo bar\nbaz qux\n" {
+		t.Fatalf("tee stdout = %q (code %d)", out, code)
+	}
+	for _, p := range []string{"/home/tester/copy.txt", "/disk/copy.txt"} {
+		if got := readVOSFile(t, v, p); got != out {
+			t.Errorf("%s = %q, want the same bytes as stdout %q", p, got, out)
+		}
+	}
+
+	// tee passes its input on down the pipeline.
+	if out := run(t, sh, "cat notes.txt | tee mid.txt | grep foo"); out != "foo bar\n" {
+		t.Errorf("tee | grep = %q", out)
+	}
+
+	out, code = runCode(t, sh, "echo more | tee -a copy.txt /disk/copy.txt new.txt")
+	if code != 0 || out != "more\n" {
+		t.Fatalf("tee -a stdout = %q (code %d)", out, code)
+	}
+	for _, p := range []string{"/home/tester/copy.txt", "/disk/copy.txt"} {
+		if got, want := readVOSFile(t, v, p), "hello world\nfoo bar\nbaz qux\nmore\n"; got != want {
+			t.Errorf("%s after -a = %q, want %q", p, got, want)
+		}
+	}
+	if got := readVOSFile(t, v, "/home/tester/new.txt"); got != "more\n" {
+		t.Errorf("tee -a on a new file = %q", got)
+	}
+
+	if _, err := v.Stat(ctx, "/home/tester/mid.txt"); err != nil {
+		t.Errorf("mid.txt not written: %v", err)
+	}
+}
+
+func TestTeeWriteError(t *testing.T) {
+	v, sh := setupTestEnv(t)
+
+	// The unwritable file is reported; the other file and stdout still get
+	// the input.
+	r := sh.Execute(context.Background(), "echo data | tee /etc/profile ok.txt")
+	if r.Code != 1 || r.Stdout != "data\n" || !strings.Contains(r.Stderr, "tee: /etc/profile") {
+		t.Errorf("tee to read-only file = %+v", r)
+	}
+	if got := readVOSFile(t, v, "/home/tester/ok.txt"); got != "data\n" {
+		t.Errorf("ok.txt = %q", got)
+	}
+}
+
 // ─── touch ───
 
 func TestTouchCreateNewFile(t *testing.T) {
@@ -1512,3 +1839,1192 @@ func TestGrepContextCombinedFlags(t *testing.T) {
 		t.Errorf("grep -nB1A1 should work: %q", out)
 	}
 }
+
+// ─── nl ───
+
+func TestNlDefault(t *testing.T) {
+	_, sh := setupTestEnv(t)
+	run(t, sh, `echo -e 'a\n\nb' > /tmp/nl.txt`)
+	out := run(t, sh, "nl /tmp/nl.txt")
+	if out != "     1\ta\n       \n     2\tb\n" {
+		t.Errorf("nl should number non-empty lines: %q", out)
+	}
+}
+
+func TestNlAllLinesStartIncrement(t *testing.T) {
+	_, sh := setupTestEnv(t)
+	out := run(t, sh, "nl -b a -v 5 -i 2 ~/notes.txt")
+	if !strings.Contains(out, "     5\thello world") || !strings.Contains(out, "     9\tbaz qux") {
+		t.Errorf("nl -b a -v 5 -i 2 unexpected output: %q", out)
+	}
+}
+
+func TestNlPattern(t *testing.T) {
+	_, sh := setupTestEnv(t)
+	out := run(t, sh, "cat ~/notes.txt | nl -b pfoo")
+	if !strings.Contains(out, "     1\tfoo bar") {
+		t.Errorf("nl -b pfoo should number matching line: %q", out)
+	}
+	if strings.Contains(out, "1\thello") {
+		t.Errorf("nl -b pfoo should not number non-matching lines: %q", out)
+	}
+}
+
+func TestNlInvalidStyle(t *testing.T) {
+	_, sh := setupTestEnv(t)
+	_, code := runCode(t, sh, "nl -b x ~/notes.txt")
+	if code != 1 {
+		t.Errorf("nl with invalid style should fail, got code %d", code)
+	}
+}
+
+// ─── fold ───
+
+func TestFoldWidth(t *testing.T) {
+	_, sh := setupTestEnv(t)
+	out := run(t, sh, "echo abcdefghij | fold -w 4")
+	if out != "abcd\nefgh\nij\n" {
+		t.Errorf("fold -w 4 unexpected output: %q", out)
+	}
+}
+
+func TestFoldSpaces(t *testing.T) {
+	_, sh := setupTestEnv(t)
+	out := run(t, sh, "echo 'hello world foo' | fold -s -w 8")
+	if out != "hello \nworld \nfoo\n" {
+		t.Errorf("fold -s -w 8 unexpected output: %q", out)
+	}
+}
+
+func TestFoldFile(t *testing.T) {
+	_, sh := setupTestEnv(t)
+	out := run(t, sh, "fold -w 5 ~/notes.txt")
+	if !strings.HasPrefix(out, "hello\n worl\nd\n") {
+		t.Errorf("fold on file unexpected output: %q", out)
+	}
+}
+
+func TestFoldInvalidWidth(t *testing.T) {
+	_, sh := setupTestEnv(t)
+	_, code := runCode(t, sh, "echo abc | fold -w 0")
+	if code != 1 {
+		t.Errorf("fold with invalid width should fail, got code %d", code)
+	}
+}
+
+// ─── paste ───
+
+func TestPasteFiles(t *testing.T) {
+	_, sh := setupTestEnv(t)
+	run(t, sh, `echo -e 'alice\nbob' > /tmp/names.txt`)
+	run(t, sh, `echo -e '90\n85\n70' > /tmp/scores.txt`)
+	out := run(t, sh, "paste /tmp/names.txt /tmp/scores.txt")
+	if out != "alice\t90\nbob\t85\n\t70\n" {
+		t.Errorf("paste unexpected output: %q", out)
+	}
+}
+
+func TestPasteDelimiter(t *testing.T) {
+	_, sh := setupTestEnv(t)
+	run(t, sh, `echo -e 'alice\nbob' > /tmp/names.txt`)
+	out := run(t, sh, "paste -d , /tmp/names.txt /tmp/names.txt")
+	if out != "alice,alice\nbob,bob\n" {
+		t.Errorf("paste -d , unexpected output: %q", out)
+	}
+}
+
+func TestPasteStdinColumns(t *testing.T) {
+	_, sh := setupTestEnv(t)
+	out := run(t, sh, "cat ~/notes.txt | paste - -")
+	if out != "hello world\tfoo bar\nbaz qux\t\n" {
+		t.Errorf("paste - - unexpected output: %q", out)
+	}
+}
+
+func TestPasteSerial(t *testing.T) {
+	_, sh := setupTestEnv(t)
+	out := run(t, sh, "paste -s -d , ~/data.csv")
+	if out != "a,b,c,1,2,3,4,5,6\n" {
+		t.Errorf("paste -s unexpected output: %q", out)
+	}
+}
+
+// ─── shuf ───
+
+func sortedLines(s string) []string {
+	lines := strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+	sort.Strings(lines)
+	return lines
+}
+
+func TestShufFile(t *testing.T) {
+	_, sh := setupTestEnv(t)
+	out := run(t, sh, "shuf ~/notes.txt")
+	got := strings.Join(sortedLines(out), "|")
+	if got != "baz qux|foo bar|hello world" {
+		t.Errorf("shuf should output a permutation of the input lines: %q", out)
+	}
+}
+
+func TestShufRangeCount(t *testing.T) {
+	_, sh := setupTestEnv(t)
+	out := run(t, sh, "shuf -i 1-10 -n 3")
+	lines := sortedLines(out)
+	if len(lines) != 3 {
+		t.Fatalf("shuf -n 3 should output 3 lines: %q", out)
+	}
+	for _, l := range lines {
+		if n, err := strconv.Atoi(l); err != nil || n < 1 || n > 10 {
+			t.Errorf("shuf -i 1-10 produced out-of-range value %q", l)
+		}
+	}
+}
+
+func TestShufLargeRange(t *testing.T) {
+	_, sh := setupTestEnv(t)
+	out := run(t, sh, "shuf -i 1-1000000000 -n 5")
+	lines := sortedLines(out)
+	if len(lines) != 5 {
+		t.Fatalf("shuf -n 5 should output 5 lines: %q", out)
+	}
+	seen := map[string]bool{}
+	for _, l := range lines {
+		if n, err := strconv.Atoi(l); err != nil || n < 1 || n > 1000000000 {
+			t.Errorf("shuf -i 1-1000000000 produced out-of-range value %q", l)
+		}
+		if seen[l] {
+			t.Errorf("shuf -i should not repeat %q", l)
+		}
+		seen[l] = true
+	}
+
+	_, code := runCode(t, sh, "shuf -i 1-1000000000")
+	if code != 1 {
+		t.Errorf("shuf -i over the range limit without -n should fail, got code %d", code)
+	}
+}
+
+func TestShufRangePermutation(t *testing.T) {
+	_, sh := setupTestEnv(t)
+	out := run(t, sh, "shuf -i 3-7")
+	if got := strings.Join(sortedLines(out), ","); got != "3,4,5,6,7" {
+		t.Errorf("shuf -i 3-7 should output each number once: %q", out)
+	}
+}
+
+func TestShufEcho(t *testing.T) {
+	_, sh := setupTestEnv(t)
+	out := run(t, sh, "shuf -e a b c")
+	if got := strings.Join(sortedLines(out), ""); got != "abc" {
+		t.Errorf("shuf -e should shuffle its arguments: %q", out)
+	}
+}
+
+func TestShufInvalidRange(t *testing.T) {
+	_, sh := setupTestEnv(t)
+	_, code := runCode(t, sh, "shuf -i 5")
+	if code != 1 {
+		t.Errorf("shuf with invalid range should fail, got code %d", code)
+	}
+}
+
+// ─── comm ───
+
+func setupCommFiles(t *testing.T, sh *grasp.Shell) {
+	t.Helper()
+	run(t, sh, `echo -e 'apple\nbanana\ncherry' > /tmp/a.txt`)
+	run(t, sh, `echo -e 'banana\ncherry\ndate' > /tmp/b.txt`)
+}
+
+func TestCommColumns(t *testing.T) {
+	_, sh := setupTestEnv(t)
+	setupCommFiles(t, sh)
+	out := run(t, sh, "comm /tmp/a.txt /tmp/b.txt")
+	if out != "apple\n\t\tbanana\n\t\tcherry\n\tdate\n" {
+		t.Errorf("comm unexpected output: %q", out)
+	}
+}
+
+func TestCommSuppress(t *testing.T) {
+	_, sh := setupTestEnv(t)
+	setupCommFiles(t, sh)
+	out := run(t, sh, "comm -12 /tmp/a.txt /tmp/b.txt")
+	if out != "banana\ncherry\n" {
+		t.Errorf("comm -12 should print common lines only: %q", out)
+	}
+	out = run(t, sh, "comm -3 /tmp/a.txt /tmp/b.txt")
+	if out != "apple\n\tdate\n" {
+		t.Errorf("comm -3 unexpected output: %q", out)
+	}
+}
+
+func TestCommStdin(t *testing.T) {
+	_, sh := setupTestEnv(t)
+	setupCommFiles(t, sh)
+	out := run(t, sh, "cat /tmp/a.txt | comm -23 - /tmp/b.txt")
+	if out != "apple\n" {
+		t.Errorf("comm with stdin unexpected output: %q", out)
+	}
+}
+
+func TestCommMissingOperand(t *testing.T) {
+	_, sh := setupTestEnv(t)
+	_, code := runCode(t, sh, "comm /tmp/a.txt")
+	if code != 1 {
+		t.Errorf("comm with one operand should fail, got code %d", code)
+	}
+}
+
+// ─── completion ───
+
+func TestShellComplete(t *testing.T) {
+	_, sh := setupTestEnv(t)
+	ctx := context.Background()
+
+	tests := []struct {
+		name string
+		line string
+		want []string
+		not  []string
+	}{
+		{"command", "gr", []string{"grep"}, []string{"cat"}},
+		{"shell builtin", "hist", []string{"history"}, nil},
+		{"absolute path", "cat /home/tester/no", []string{"/home/tester/notes.txt"}, []string{"/home/tester/data.csv"}},
+		{"absolute dir", "ls /ho", []string{"/home/"}, nil},
+		{"home path", "cat ~/d", []string{"~/data.csv", "~/docs/"}, []string{"~/notes.txt"}},
+		{"hidden only with dot", "cat ~/.h", []string{"~/.hidden"}, nil},
+		{"relative path", "cat no", []string{"notes.txt"}, nil},
+		{"flag", "grep -", []string{"-i", "-n"}, nil},
+		{"long flag", "fold --w", []string{"--width"}, []string{"-s"}},
+		{"read builtin", "rea", []string{"read", "readarray"}, nil},
+		{"test builtin", "te", []string{"test"}, nil},
+		{"bracket builtin", "[", []string{"["}, nil},
+		{"read flags", "read -", []string{"-p", "-r", "-t"}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := sh.Complete(ctx, tt.line)
+			for _, w := range tt.want {
+				if !slices.Contains(got, w) {
+					t.Errorf("Complete(%q) = %v, missing %q", tt.line, got, w)
+				}
+			}
+			for _, n := range tt.not {
+				if slices.Contains(got, n) {
+					t.Errorf("Complete(%q) = %v, should not contain %q", tt.line, got, n)
+				}
+			}
+		})
+	}
+
+	if got := sh.Complete(ctx, "ls ~/"); slices.Contains(got, "~/.hidden") {
+		t.Errorf("hidden files should not be offered without a leading dot: %v", got)
+	}
+	if got := sh.Complete(ctx, "nosuchcmd -"); len(got) != 0 {
+		t.Errorf("unknown command should have no flag candidates: %v", got)
+	}
+}
+
+func TestShellCompleteDoesNotExecute(t *testing.T) {
+	v, sh := setupTestEnv(t)
+	ctx := context.Background()
+
+	// Flags come from the usage metadata; the command itself never runs,
+	// even with --help.
+	tools := mounts.NewMemFS(grasp.PermRW)
+	calls := 0
+	tools.AddExecFunc("deploy", func(context.Context, []string, io.Reader) (io.ReadCloser, error) {
+		calls++
+		return io.NopCloser(strings.NewReader("--from-help\n")), nil
+	}, mounts.FuncMeta{Usage: "deploy [-f|--force] [--dry-run] TARGET"})
+	if err := v.Mount("/opt/tools", tools); err != nil {
+		t.Fatal(err)
+	}
+	sh.Env.Set("PATH", sh.Env.Get("PATH")+":/opt/tools")
+
+	got := sh.Complete(ctx, "deploy -")
+	if want := []string{"--dry-run", "--force", "-f"}; !slices.Equal(got, want) {
+		t.Errorf("Complete(deploy -) = %v, want %v", got, want)
+	}
+	if calls != 0 {
+		t.Errorf("completion ran the command %d times", calls)
+	}
+}
+
+// ─── lsattr / setattr ───
+
+func TestSetattrLsattr(t *testing.T) {
+	_, sh := setupTestEnv(t)
+	run(t, sh, "setattr notes.txt owner=alice tag=draft")
+	out := run(t, sh, "lsattr notes.txt")
+	if out != "owner=alice\ntag=draft\n" {
+		t.Errorf("lsattr unexpected output: %q", out)
+	}
+	out = run(t, sh, "lsattr -n owner notes.txt")
+	if out != "alice\n" {
+		t.Errorf("lsattr -n unexpected output: %q", out)
+	}
+
+	run(t, sh, "setattr notes.txt tag=")
+	out = run(t, sh, "lsattr notes.txt")
+	if out != "owner=alice\n" {
+		t.Errorf("lsattr after removing tag: %q", out)
+	}
+}
+
+func TestSetattrErrors(t *testing.T) {
+	_, sh := setupTestEnv(t)
+	if _, code := runCode(t, sh, "setattr notes.txt novalue"); code != 1 {
+		t.Errorf("setattr without '=' should fail, got code %d", code)
+	}
+	if _, code := runCode(t, sh, "setattr missing.txt k=v"); code != 1 {
+		t.Errorf("setattr on missing file should fail, got code %d", code)
+	}
+	if _, code := runCode(t, sh, "lsattr -n nokey notes.txt"); code != 1 {
+		t.Errorf("lsattr -n on missing key should fail, got code %d", code)
+	}
+}
+
+// ─── zip / unzip ───
+
+func TestZipUnzipRoundTrip(t *testing.T) {
+	_, sh := setupTestEnv(t)
+	out := run(t, sh, "zip -r /tmp/out.zip notes.txt docs")
+	if !strings.Contains(out, "adding: notes.txt") || !strings.Contains(out, "adding: docs/readme.md") {
+		t.Errorf("zip unexpected output: %q", out)
+	}
+
+	run(t, sh, "unzip /tmp/out.zip -d /tmp/extract")
+	if got := run(t, sh, "cat /tmp/extract/notes.txt"); got != "hello world\nfoo bar\nbaz qux\n" {
+		t.Errorf("extracted notes.txt = %q", got)
+	}
+	if got := run(t, sh, "cat /tmp/extract/docs/readme.md"); got != "# README\nProject docs" {
+		t.Errorf("extracted readme.md = %q", got)
+	}
+}
+
+func TestZipDirectoryWithoutRecursive(t *testing.T) {
+	_, sh := setupTestEnv(t)
+	out := run(t, sh, "zip /tmp/out.zip docs notes.txt")
+	if !strings.Contains(out, "docs is a directory") {
+		t.Errorf("zip should skip directories without -r: %q", out)
+	}
+	out = run(t, sh, "unzip /tmp/out.zip -d /tmp/x")
+	if strings.Contains(out, "readme.md") {
+		t.Errorf("directory contents should not be archived: %q", out)
+	}
+}
+
+func TestUnzipInvalidArchive(t *testing.T) {
+	_, sh := setupTestEnv(t)
+	if _, code := runCode(t, sh, "unzip notes.txt"); code != 1 {
+		t.Errorf("unzip on non-archive should fail, got code %d", code)
+	}
+}
+
+// ─── gzip / gunzip ───
+
+func TestGzipRoundTrip(t *testing.T) {
+	_, sh := setupTestEnv(t)
+	run(t, sh, "gzip notes.txt")
+	if _, code := runCode(t, sh, "cat notes.txt"); code == 0 {
+		t.Error("gzip should remove the original file")
+	}
+	if out := run(t, sh, "cat --raw notes.txt.gz"); !strings.HasPrefix(out, "\x1f\x8b") {
+		t.Errorf("notes.txt.gz should be gzip data, got %q", out)
+	}
+
+	run(t, sh, "gunzip notes.txt.gz")
+	if out := run(t, sh, "cat notes.txt"); out != "hello world\nfoo bar\nbaz qux\n" {
+		t.Errorf("gunzip content = %q", out)
+	}
+	if _, code := runCode(t, sh, "cat notes.txt.gz"); code == 0 {
+		t.Error("gunzip should remove the .gz file")
+	}
+}
+
+func TestGzipKeep(t *testing.T) {
+	_, sh := setupTestEnv(t)
+	run(t, sh, "gzip -k data.csv")
+	run(t, sh, "rm data.csv")
+	run(t, sh, "gzip -dk data.csv.gz")
+	if out := run(t, sh, "cat data.csv"); out != "a,b,c\n1,2,3\n4,5,6\n" {
+		t.Errorf("data.csv after -k round trip = %q", out)
+	}
+	if _, code := runCode(t, sh, "cat data.csv.gz"); code != 0 {
+		t.Error("gzip -dk should keep the .gz file")
+	}
+}
+
+func TestGzipExistingOutput(t *testing.T) {
+	_, sh := setupTestEnv(t)
+	run(t, sh, "gzip -k notes.txt")
+	run(t, sh, "echo changed > notes.txt")
+
+	// Neither direction replaces an existing file without -f.
+	if out, code := runCode(t, sh, "gunzip -k notes.txt.gz"); code != 1 || !strings.Contains(out, "already exists") {
+		t.Errorf("gunzip over existing file = %q (code %d)", out, code)
+	}
+	if out := run(t, sh, "cat notes.txt"); out != "changed\n" {
+		t.Errorf("existing file overwritten: %q", out)
+	}
+	if out, code := runCode(t, sh, "gzip notes.txt"); code != 1 || !strings.Contains(out, "already exists") {
+		t.Errorf("gzip over existing .gz = %q (code %d)", out, code)
+	}
+
+	run(t, sh, "gunzip -f notes.txt.gz")
+	if out := run(t, sh, "cat notes.txt"); out != "hello world\nfoo bar\nbaz qux\n" {
+		t.Errorf("gunzip -f content = %q", out)
+	}
+}
+
+// nativeGzipFS records native compression requests.
+type nativeGzipFS struct {
+	*mounts.MemFS
+	calls []string
+}
+
+func (n *nativeGzipFS) Compress(_ context.Context, path string) error {
+	n.calls = append(n.calls, "compress "+path)
+	return nil
+}
+
+func (n *nativeGzipFS) Decompress(_ context.Context, path string) error {
+	n.calls = append(n.calls, "decompress "+path)
+	return nil
+}
+
+func TestGzipNative(t *testing.T) {
+	v, sh := setupTestEnv(t)
+	native := &nativeGzipFS{MemFS: mounts.NewMemFS(grasp.PermRW)}
+	native.AddFile("a.txt", []byte("aaa"), grasp.PermRW)
+	native.AddFile("b.txt.gz", []byte("x"), grasp.PermRW)
+	native.AddFile("c.txt", []byte("c"), grasp.PermRW)
+	native.AddFile("c.txt.gz", []byte("x"), grasp.PermRW)
+	if err := v.Mount("/z", native); err != nil {
+		t.Fatal(err)
+	}
+
+	run(t, sh, "gzip /z/a.txt")
+	run(t, sh, "gunzip /z/b.txt.gz")
+	// The suffix checks run before the provider is asked.
+	if _, code := runCode(t, sh, "gzip /z/b.txt.gz"); code != 1 {
+		t.Errorf("gzip of .gz file: code %d, want 1", code)
+	}
+	if _, code := runCode(t, sh, "gunzip /z/a.txt"); code != 1 {
+		t.Errorf("gunzip without suffix: code %d, want 1", code)
+	}
+	// So does the check for an existing output file.
+	if _, code := runCode(t, sh, "gzip /z/c.txt"); code != 1 {
+		t.Errorf("gzip over existing c.txt.gz: code %d, want 1", code)
+	}
+	want := []string{"compress a.txt", "decompress b.txt.gz"}
+	if !slices.Equal(native.calls, want) {
+		t.Errorf("native calls = %q, want %q", native.calls, want)
+	}
+
+	// -k keeps the input, which in-place native compression cannot.
+	run(t, sh, "gzip -k /z/a.txt")
+	if len(native.calls) != 2 {
+		t.Errorf("gzip -k used native compression: %q", native.calls)
+	}
+	if out := run(t, sh, "cat /z/a.txt"); out != "aaa" {
+		t.Errorf("gzip -k input = %q", out)
+	}
+	if out := run(t, sh, "cat --raw /z/a.txt.gz"); !strings.HasPrefix(out, "\x1f\x8b") {
+		t.Errorf("gzip -k output = %q, want gzip data", out)
+	}
+}
+
+func TestGunzipUnknownSuffix(t *testing.T) {
+	_, sh := setupTestEnv(t)
+	if _, code := runCode(t, sh, "gunzip notes.txt"); code != 1 {
+		t.Errorf("gunzip without .gz suffix should fail, got code %d", code)
+	}
+}
+
+// ─── ln ───
+
+func TestLnSymbolic(t *testing.T) {
+	_, sh := setupTestEnv(t)
+	run(t, sh, "ln -s notes.txt link.txt")
+	if out := run(t, sh, "cat link.txt"); out != "hello world\nfoo bar\nbaz qux\n" {
+		t.Errorf("cat link.txt = %q", out)
+	}
+	run(t, sh, "ln -s /home/tester/docs docs-link")
+	if out := run(t, sh, "cat docs-link/readme.md"); out != "# README\nProject docs" {
+		t.Errorf("cat through dir link = %q", out)
+	}
+}
+
+func TestLnHard(t *testing.T) {
+	_, sh := setupTestEnv(t)
+	run(t, sh, "ln data.csv /tmp")
+	run(t, sh, "rm data.csv")
+	if out := run(t, sh, "cat /tmp/data.csv"); out != "a,b,c\n1,2,3\n4,5,6\n" {
+		t.Errorf("cat /tmp/data.csv = %q", out)
+	}
+	if _, code := runCode(t, sh, "ln missing.txt other.txt"); code != 1 {
+		t.Errorf("ln of missing file should fail, got code %d", code)
+	}
+}
+
+// ─── md5sum / sha256sum ───
+
+func TestChecksumFiles(t *testing.T) {
+	_, sh := setupTestEnv(t)
+	if out := run(t, sh, "md5sum notes.txt"); out != "8d832c4664cc90c05113f74a9a477530  notes.txt\n" {
+		t.Errorf("md5sum = %q", out)
+	}
+	want := "adb17709d060a98cdcd21c9552e1b130af6f2ebcc3c89025192f3d6b206c98c3  notes.txt\n"
+	if out := run(t, sh, "sha256sum notes.txt"); out != want {
+		t.Errorf("sha256sum = %q", out)
+	}
+	if out := run(t, sh, "echo -n abc | sha256sum"); out != "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad  -\n" {
+		t.Errorf("sha256sum stdin = %q", out)
+	}
+	if _, code := runCode(t, sh, "md5sum missing.txt"); code != 1 {
+		t.Errorf("md5sum of missing file should fail, got code %d", code)
+	}
+}
+
+func TestChecksumCheck(t *testing.T) {
+	_, sh := setupTestEnv(t)
+	run(t, sh, "sha256sum notes.txt data.csv > sums")
+	if out := run(t, sh, "sha256sum --check sums"); out != "notes.txt: OK\ndata.csv: OK\n" {
+		t.Errorf("check = %q", out)
+	}
+
+	run(t, sh, "echo changed > data.csv")
+	out, code := runCode(t, sh, "sha256sum -c sums")
+	if code != 1 || !strings.Contains(out, "data.csv: FAILED") || !strings.Contains(out, "1 computed checksum(s) did NOT match") {
+		t.Errorf("check after change = %q (code %d)", out, code)
+	}
+}
+
+// ─── xxd ───
+
+func TestXxd(t *testing.T) {
+	_, sh := setupTestEnv(t)
+	want := "00000000: 6865 6c6c 6f20 776f 726c 640a 666f 6f20  hello world.foo \n" +
+		"00000010: 6261 720a 6261 7a20 7175 780a            bar.baz qux.\n"
+	if out := run(t, sh, "xxd notes.txt"); out != want {
+		t.Errorf("xxd =\n%s\nwant\n%s", out, want)
+	}
+
+	want = "00000004: 6f20 776f 72  o wor\n00000009: 6c64 0a66 6f  ld.fo\n"
+	if out := run(t, sh, "xxd -s 4 -l 10 -c 5 notes.txt"); out != want {
+		t.Errorf("xxd -s -l -c =\n%s\nwant\n%s", out, want)
+	}
+	if out := run(t, sh, "cat notes.txt | xxd -s 0x4 -l 10 -c 5"); out != want {
+		t.Errorf("xxd from stdin =\n%s\nwant\n%s", out, want)
+	}
+
+	if _, code := runCode(t, sh, "xxd -c 0 notes.txt"); code != 1 {
+		t.Errorf("xxd -c 0 should fail, got code %d", code)
+	}
+}
+
+// ─── process substitution ───
+
+func TestProcessSubstitution(t *testing.T) {
+	v, sh := setupTestEnv(t)
+	if out := run(t, sh, "cat <(echo first) <(head -n 1 notes.txt)"); out != "first\nhello world\n" {
+		t.Errorf("cat <(...) <(...) = %q", out)
+	}
+	if out := run(t, sh, "cat <(cat notes.txt | grep baz) | wc -l"); strings.TrimSpace(out) != "1" {
+		t.Errorf("substitution with inner pipe = %q", out)
+	}
+
+	entries, err := v.List(context.Background(), "/tmp", grasp.ListOpts{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range entries {
+		if strings.HasPrefix(e.Name, ".psub-") {
+			t.Errorf("temporary file %s not cleaned up", e.Name)
+		}
+	}
+}
+
+// ─── registration ───
+
+func TestRegisterBuiltinsOnFSNames(t *testing.T) {
+	v := grasp.New()
+	root := mounts.NewMemFS(grasp.PermRW)
+	if err := v.Mount("/", root); err != nil {
+		t.Fatal(err)
+	}
+	if got := ListRegistered(v); len(got) != 0 {
+		t.Errorf("ListRegistered before registration = %v", got)
+	}
+
+	names, err := RegisterBuiltinsOnFS(v, root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	for _, name := range names {
+		if _, err := v.Stat(ctx, "/usr/bin/"+name); err != nil {
+			t.Errorf("returned command %s not registered: %v", name, err)
+		}
+	}
+
+	listed := ListRegistered(v)
+	if !sort.StringsAreSorted(listed) || len(listed) != len(names) {
+		t.Errorf("ListRegistered = %v, want the %d registered names sorted", listed, len(names))
+	}
+	for _, want := range []string{"ls", "cat", "grep", "xxd", "sha256sum"} {
+		if i := sort.SearchStrings(listed, want); i == len(listed) || listed[i] != want {
+			t.Errorf("ListRegistered missing %s", want)
+		}
+	}
+
+	if got := ListRegistered(grasp.New()); len(got) != 0 {
+		t.Errorf("ListRegistered on another VirtualOS = %v", got)
+	}
+}
+
+// ─── pipefail ───
+
+func TestPipefail(t *testing.T) {
+	_, sh := setupTestEnv(t)
+
+	out, code := runCode(t, sh, "cat missing.txt | wc -l")
+	if code != 0 || !strings.Contains(out, "missing.txt") || !strings.HasSuffix(strings.TrimSpace(out), "0") {
+		t.Errorf("without pipefail = %q (code %d), want error text, wc output and code 0", out, code)
+	}
+	if out := run(t, sh, "echo $PIPESTATUS"); out != "1 0\n" {
+		t.Errorf("PIPESTATUS = %q, want \"1 0\"", out)
+	}
+
+	sh.SetPipefail(true)
+	if _, code := runCode(t, sh, "cat missing.txt | wc -l"); code != 1 {
+		t.Errorf("with pipefail code = %d, want 1", code)
+	}
+	if _, code := runCode(t, sh, "cat notes.txt | grep foo | wc -l"); code != 0 {
+		t.Errorf("successful pipeline with pipefail code = %d", code)
+	}
+	if out := run(t, sh, "echo $PIPESTATUS"); out != "0 0 0\n" {
+		t.Errorf("PIPESTATUS = %q, want \"0 0 0\"", out)
+	}
+
+	run(t, sh, "set +o pipefail")
+	if sh.Pipefail() {
+		t.Error("set +o pipefail should disable pipefail")
+	}
+	run(t, sh, "set -o pipefail")
+	if !sh.Pipefail() {
+		t.Error("set -o pipefail should enable pipefail")
+	}
+}
+
+func TestPipefailOptions(t *testing.T) {
+	v, sh := setupTestEnv(t)
+
+	if _, code := runCode(t, sh, "cat missing.txt | wc -l"); code != 0 {
+		t.Errorf("default shell: code = %d, want 0", code)
+	}
+
+	strict := v.Shell("tester", grasp.WithPipeFail(true))
+	if !strict.Pipefail() {
+		t.Fatal("WithPipeFail(true) did not enable pipefail")
+	}
+	if _, code := runCode(t, strict, "cat missing.txt | grep foo"); code != 1 {
+		t.Errorf("WithPipeFail: code = %d, want 1", code)
+	}
+	if _, code := runCode(t, strict, "cat notes.txt | wc -l"); code != 0 {
+		t.Errorf("WithPipeFail, no failure: code = %d, want 0", code)
+	}
+
+	if err := sh.SetOption("pipefail", "1"); err != nil {
+		t.Fatal(err)
+	}
+	if _, code := runCode(t, sh, "cat missing.txt | wc -l"); code != 1 {
+		t.Errorf("SetOption pipefail 1: code = %d, want 1", code)
+	}
+	if err := sh.SetOption("pipefail", "0"); err != nil {
+		t.Fatal(err)
+	}
+	if _, code := runCode(t, sh, "cat missing.txt | wc -l"); code != 0 {
+		t.Errorf("SetOption pipefail 0: code = %d, want 0", code)
+	}
+	if err := sh.SetOption("pipefail", "maybe"); !errors.Is(err, grasp.ErrNotSupported) {
+		t.Errorf("invalid value: err = %v, want ErrNotSupported", err)
+	}
+	if err := sh.SetOption("xtrace", "1"); !errors.Is(err, grasp.ErrNotSupported) {
+		t.Errorf("unknown option: err = %v, want ErrNotSupported", err)
+	}
+}
+
+func TestPipeSemanticsExitCode(t *testing.T) {
+	_, sh := setupTestEnv(t)
+
+	tests := []struct {
+		cmd              string
+		want, wantStrict int // without and with pipefail
+	}{
+		{"false | true", 0, 1},
+		{"true | false", 1, 1},
+		{"true | true | true", 0, 0},
+		{"false | true | true", 0, 1},
+		{"true | false | true", 0, 1},
+		{"true | true | false", 1, 1},
+		{"false | false | true", 0, 1},
+		{"cat missing.txt | cat notes.txt | wc -l", 0, 1},
+		// grep exits 1 when nothing matches; later stages still decide the
+		// status unless pipefail is on.
+		{"cat notes.txt | grep nomatch", 1, 1},
+		{"cat notes.txt | grep nomatch | wc -l", 0, 1},
+		{"cat notes.txt | grep foo | wc -l", 0, 0},
+	}
+	for _, strict := range []bool{false, true} {
+		sh.SetPipefail(strict)
+		for _, tt := range tests {
+			want := tt.want
+			if strict {
+				want = tt.wantStrict
+			}
+			if _, code := runCode(t, sh, tt.cmd); code != want {
+				t.Errorf("pipefail=%v: %s: code = %d, want %d", strict, tt.cmd, code, want)
+			}
+		}
+	}
+
+	sh.SetPipefail(false)
+	out, code := runCode(t, sh, "cat notes.txt | grep nomatch | wc -l")
+	if code != 0 || strings.TrimSpace(out) != "0" {
+		t.Errorf("grep no match | wc -l = %q (code %d), want 0 and code 0", out, code)
+	}
+	if out := run(t, sh, "echo $PIPESTATUS"); out != "0 1 0\n" {
+		t.Errorf("PIPESTATUS = %q, want \"0 1 0\"", out)
+	}
+	if out, code := runCode(t, sh, "false"); code != 1 || out != "" {
+		t.Errorf("false = %q (code %d), want no output and code 1", out, code)
+	}
+}
+
+// ─── tr ───
+
+func TestTr(t *testing.T) {
+	_, sh := setupTestEnv(t)
+
+	tests := []struct {
+		cmd, want string
+	}{
+		{"echo hello | tr '[:lower:]' '[:upper:]'", "HELLO\n"},
+		{"echo HeLLo | tr A-Z a-z", "hello\n"},
+		{"echo hello | tr el ip", "hippo\n"},
+		{"echo abcdef | tr a-f xy", "xyyyyy\n"}, // short SET2 is padded
+		{"echo 'a1b2c3' | tr -d '[:digit:]'", "abc\n"},
+		{"cat notes.txt | tr -d '\\n'", "hello worldfoo barbaz qux"},
+		{"echo 'a    b  c' | tr -s ' '", "a b c\n"},
+		{"echo aabbcc | tr -s a-c x", "x\n"},
+		{"echo 'a1b2c3' | tr -cd '[:digit:]'", "123"},
+		{"echo 'hello, world!' | tr -c '[:alpha:]' _", "hello__world__"},
+		{"echo 'a1  b22' | tr -ds '[:digit:]' ' '", "a b\n"},
+	}
+	for _, tt := range tests {
+		out, code := runCode(t, sh, tt.cmd)
+		if code != 0 || out != tt.want {
+			t.Errorf("%s = %q (code %d), want %q", tt.cmd, out, code, tt.want)
+		}
+	}
+}
+
+func TestTrCarriageReturn(t *testing.T) {
+	v, sh := setupTestEnv(t)
+	ctx := context.Background()
+	_ = v.Write(ctx, "/home/tester/dos.txt", strings.NewReader("a\r\nb\r\n"))
+
+	if out := run(t, sh, `cat dos.txt | tr -d '\r'`); out != "a\nb\n" {
+		t.Errorf("tr -d '\\r' = %q, want %q", out, "a\nb\n")
+	}
+}
+
+func TestTrErrors(t *testing.T) {
+	_, sh := setupTestEnv(t)
+
+	for _, cmd := range []string{
+		"echo a | tr",
+		"echo a | tr abc",
+		"echo a | tr -d a b",
+		"echo a | tr -q a b",
+		"echo a | tr z-a x",
+		"echo a | tr '[:bogus:]' x",
+		"echo a | tr a b c",
+	} {
+		if _, code := runCode(t, sh, cmd); code == 0 {
+			t.Errorf("%s: expected failure", cmd)
+		}
+	}
+}
+
+// ─── diff ───
+
+func TestDiffFiles(t *testing.T) {
+	v, sh := setupTestEnv(t)
+	ctx := context.Background()
+	_ = v.Write(ctx, "/home/tester/a.txt", strings.NewReader("one\ntwo\nthree\nfour\n"))
+	_ = v.Write(ctx, "/home/tester/b.txt", strings.NewReader("one\n2\nthree\nfour\nfive\n"))
+	_ = v.Write(ctx, "/home/tester/c.txt", strings.NewReader("one\ntwo\nthree\nfour\n"))
+
+	if out, code := runCode(t, sh, "diff a.txt c.txt"); code != 0 || out != "" {
+		t.Errorf("diff identical = %q (code %d), want no output and code 0", out, code)
+	}
+
+	want := "--- a.txt\n+++ b.txt\n@@ -1,4 +1,5 @@\n one\n-two\n+2\n three\n four\n+five\n"
+	if out, code := runCode(t, sh, "diff a.txt b.txt"); code != 1 || out != want {
+		t.Errorf("diff a.txt b.txt = %q (code %d), want %q (code 1)", out, code, want)
+	}
+	if out, code := runCode(t, sh, "diff -u a.txt b.txt"); code != 1 || out != want {
+		t.Errorf("diff -u = %q (code %d)", out, code)
+	}
+
+	want = "*** a.txt\n--- b.txt\n***************\n*** 1,4 ****\n  one\n! two\n  three\n  four\n--- 1,5 ----\n  one\n! 2\n  three\n  four\n+ five\n"
+	if out, code := runCode(t, sh, "diff -c a.txt b.txt"); code != 1 || out != want {
+		t.Errorf("diff -c = %q (code %d), want %q", out, code, want)
+	}
+
+	if out, code := runCode(t, sh, "diff -q a.txt b.txt"); code != 1 || out != "Files a.txt and b.txt differ\n" {
+		t.Errorf("diff -q = %q (code %d)", out, code)
+	}
+
+	// The exit status drives && and ||.
+	if out := run(t, sh, "diff -q a.txt c.txt && echo same"); out != "same\n" {
+		t.Errorf("diff && echo = %q", out)
+	}
+	if out := run(t, sh, "diff -q a.txt b.txt > /dev/null || echo changed"); !strings.Contains(out, "changed") {
+		t.Errorf("diff || echo = %q", out)
+	}
+}
+
+func TestDiffHunksAndNewline(t *testing.T) {
+	v, sh := setupTestEnv(t)
+	ctx := context.Background()
+	var a, b strings.Builder
+	for i := 1; i <= 20; i++ {
+		fmt.Fprintf(&a, "%d\n", i)
+		if i == 2 || i == 18 {
+			fmt.Fprintf(&b, "x%d\n", i)
+		} else {
+			fmt.Fprintf(&b, "%d\n", i)
+		}
+	}
+	_ = v.Write(ctx, "/tmp/a", strings.NewReader(a.String()))
+	_ = v.Write(ctx, "/tmp/b", strings.NewReader(b.String()))
+	out, _ := runCode(t, sh, "diff /tmp/a /tmp/b")
+	if !strings.Contains(out, "@@ -1,5 +1,5 @@\n") || !strings.Contains(out, "@@ -15,6 +15,6 @@\n") {
+		t.Errorf("diff should produce two hunks, got:\n%s", out)
+	}
+
+	_ = v.Write(ctx, "/tmp/nl", strings.NewReader("x\n"))
+	_ = v.Write(ctx, "/tmp/nonl", strings.NewReader("x"))
+	want := "--- /tmp/nl\n+++ /tmp/nonl\n@@ -1 +1 @@\n-x\n+x\n\\ No newline at end of file\n"
+	if out, code := runCode(t, sh, "diff /tmp/nl /tmp/nonl"); code != 1 || out != want {
+		t.Errorf("diff newline = %q (code %d), want %q", out, code, want)
+	}
+}
+
+func TestDiffDirs(t *testing.T) {
+	v, sh := setupTestEnv(t)
+	ctx := context.Background()
+	for p, content := range map[string]string{
+		"/tmp/old/same.txt":     "same\n",
+		"/tmp/old/changed.txt":  "before\n",
+		"/tmp/old/gone.txt":     "gone\n",
+		"/tmp/old/sub/deep.txt": "deep\n",
+		"/tmp/new/same.txt":     "same\n",
+		"/tmp/new/changed.txt":  "after\n",
+		"/tmp/new/added.txt":    "added\n",
+		"/tmp/new/sub/deep.txt": "deeper\n",
+	} {
+		_ = mkdirAll(ctx, v, path.Dir(p))
+		_ = v.Write(ctx, p, strings.NewReader(content))
+	}
+
+	out, code := runCode(t, sh, "diff -q /tmp/old /tmp/new")
+	want := "Only in /tmp/new: added.txt\nFiles /tmp/old/changed.txt and /tmp/new/changed.txt differ\n" +
+		"Only in /tmp/old: gone.txt\nCommon subdirectories: /tmp/old/sub and /tmp/new/sub\n"
+	if code != 1 || out != want {
+		t.Errorf("diff -q dirs = %q (code %d), want %q", out, code, want)
+	}
+
+	out, code = runCode(t, sh, "diff -r /tmp/old /tmp/new")
+	if code != 1 ||
+		!strings.Contains(out, "diff -r /tmp/old/changed.txt /tmp/new/changed.txt\n--- /tmp/old/changed.txt\n") ||
+		!strings.Contains(out, "-deep\n+deeper\n") {
+		t.Errorf("diff -r dirs = %q (code %d)", out, code)
+	}
+
+	// A directory operand compares the file of the same name inside it.
+	if out, code := runCode(t, sh, "diff /tmp/old/same.txt /tmp/new"); code != 0 || out != "" {
+		t.Errorf("diff file dir = %q (code %d)", out, code)
+	}
+}
+
+func TestDiffAcrossMounts(t *testing.T) {
+	v, sh := setupTestEnv(t)
+	ctx := context.Background()
+	data := mounts.NewMemFS(grasp.PermRW)
+	data.AddFile("notes.txt", []byte("hello world\nfoo bar\nbaz qux\n"), grasp.PermRO)
+	data.AddFile("report.md", []byte("v2\n"), grasp.PermRO)
+	if err := v.Mount("/data", data); err != nil {
+		t.Fatal(err)
+	}
+	_ = v.Write(ctx, "/home/tester/report.md", strings.NewReader("v1\n"))
+
+	if out, code := runCode(t, sh, "diff notes.txt /data/notes.txt"); code != 0 || out != "" {
+		t.Errorf("diff across mounts (same) = %q (code %d)", out, code)
+	}
+	want := "--- report.md\n+++ /data/report.md\n@@ -1 +1 @@\n-v1\n+v2\n"
+	if out, code := runCode(t, sh, "diff report.md /data/report.md"); code != 1 || out != want {
+		t.Errorf("diff across mounts = %q (code %d), want %q", out, code, want)
+	}
+}
+
+func TestDiffIdenticalMounts(t *testing.T) {
+	v, sh := setupTestEnv(t)
+	ctx := context.Background()
+	data := mounts.NewMemFS(grasp.PermRW)
+	data.AddFile("notes.txt", []byte("hello\n"), grasp.PermRW)
+	data.AddFile("sub/report.md", []byte("v1\n"), grasp.PermRW)
+	mirror := data.Clone()
+	if err := v.Mount("/data", data); err != nil {
+		t.Fatal(err)
+	}
+	if err := v.Mount("/mirror", mirror); err != nil {
+		t.Fatal(err)
+	}
+
+	if out, code := runCode(t, sh, "diff -r /data /mirror"); code != 0 || out != "" {
+		t.Errorf("diff -r of identical mounts = %q (code %d)", out, code)
+	}
+
+	_ = v.Write(ctx, "/mirror/sub/report.md", strings.NewReader("v2\n"))
+	want := "diff -r /data/sub/report.md /mirror/sub/report.md\n--- /data/sub/report.md\n+++ /mirror/sub/report.md\n@@ -1 +1 @@\n-v1\n+v2\n"
+	if out, code := runCode(t, sh, "diff -r /data /mirror"); code != 1 || out != want {
+		t.Errorf("diff -r after write = %q (code %d), want %q", out, code, want)
+	}
+}
+
+func TestDiffErrors(t *testing.T) {
+	_, sh := setupTestEnv(t)
+
+	for _, cmd := range []string{
+		"diff notes.txt",
+		"diff notes.txt missing.txt",
+		"diff -z notes.txt data.csv",
+		"diff a b c",
+	} {
+		if _, code := runCode(t, sh, cmd); code != 2 {
+			t.Errorf("%s: code %d, want 2", cmd, code)
+		}
+	}
+}
+
+// ─── test ───
+
+func TestTestBuiltin(t *testing.T) {
+	_, sh := setupTestEnv(t)
+
+	tests := []struct {
+		cmd  string
+		code int
+	}{
+		{"test -f notes.txt", 0},
+		{"test -f docs", 1},
+		{"test -d docs", 0},
+		{"test -e missing.txt", 1},
+		{"test -r docs/readme.md", 0},
+		{"test -w docs/readme.md", 1},
+		{"test -w /home/tester/notes.txt", 0},
+		{"test -s notes.txt", 0},
+		{"[ -f notes.txt ]", 0},
+		{"[ ! -f notes.txt ]", 1},
+		{"[ abc = abc ]", 0},
+		{"[ abc != abc ]", 1},
+		{"[ -z abc ]", 1},
+		{"[ -n abc ]", 0},
+		{`[ -z "" ]`, 0},
+		{"[ -f ]", 0}, // one argument: a non-empty string
+		{"[ ]", 1},
+		{"[ 10 -gt 9 ]", 0},
+		{"[ 10 -lt 9 ]", 1},
+		{"[ 3 -eq 3 ]", 0},
+		{"[ 3 -ne 3 ]", 1},
+		{"[ 3 -le 3 ]", 0},
+		{"[ -1 -ge 0 ]", 1},
+		{"[ ! 1 -eq 2 ]", 0},
+	}
+	for _, tt := range tests {
+		if out, code := runCode(t, sh, tt.cmd); code != tt.code || out != "" {
+			t.Errorf("%s = %q (code %d), want code %d", tt.cmd, out, code, tt.code)
+		}
+	}
+}
+
+func TestTestBuiltinErrors(t *testing.T) {
+	_, sh := setupTestEnv(t)
+
+	for _, cmd := range []string{
+		"[ -f notes.txt",
+		"[ abc -gt 1 ]",
+		"[ a b c ]",
+		"[ -q x ]",
+		"test a b c d e",
+	} {
+		if _, code := runCode(t, sh, cmd); code != 2 {
+			t.Errorf("%s: code %d, want 2", cmd, code)
+		}
+	}
+	if out := run(t, sh, "[ --help"); !strings.Contains(out, "Usage: test EXPRESSION") {
+		t.Errorf("[ --help = %q", out)
+	}
+}
+
+// ─── awk ───
+
+func TestAwkFields(t *testing.T) {
+	_, sh := setupTestEnv(t)
+
+	tests := []struct {
+		cmd, want string
+	}{
+		{"awk '{print $1}' notes.txt", "hello\nfoo\nbaz\n"},
+		{"awk '{print $NF, NF}' notes.txt", "world 2\nbar 2\nqux 2\n"},
+		{"awk '{print $0}' notes.txt", "hello world\nfoo bar\nbaz qux\n"},
+		{"echo '  a   b  ' | awk '{print $2 \"-\" $1}'", "b-a\n"},
+		{"awk -F, '{print $2}' data.csv", "b\n2\n5\n"},
+		{"awk -F , 'NR > 1 {print $3, $1}' data.csv", "3 1\n6 4\n"},
+		{"awk -F, -v OFS=: '{$2 = \"x\"; print}' data.csv", "a:x:c\n1:x:3\n4:x:6\n"},
+		{"echo a:b::c | awk -F: '{print NF, $4}'", "4 c\n"},
+		{"echo 'a1b22c' | awk -F'[0-9]+' '{print $3}'", "c\n"},
+		{"ls -l docs | awk '{print $1, $NF}'", "-r-- readme.md\n"},
+	}
+	for _, tt := range tests {
+		out, code := runCode(t, sh, tt.cmd)
+		if code != 0 || out != tt.want {
+			t.Errorf("%s = %q (code %d), want %q", tt.cmd, out, code, tt.want)
+		}
+	}
+}
+
+func TestAwkPrograms(t *testing.T) {
+	_, sh := setupTestEnv(t)
+
+	tests := []struct {
+		cmd, want string
+	}{
+		{"awk -F, 'NR > 1 {sum += $1 + $3} END {print sum}' data.csv", "14\n"},
+		{"awk 'BEGIN {print \"start\"} END {print NR, \"lines\"}' notes.txt", "start\n3 lines\n"},
+		{"awk 'BEGIN {print 7 / 2, 2 ^ 10, 7 % 3, -3 * 4}'", "3.5 1024 1 -12\n"},
+		{"awk -F, 'NR > 1 {printf \"%s=%05.1f|%-3d|%x\\n\", $1, $2 * 1.5, $3, $3 * 5}' data.csv", "1=003.0|3  |f\n4=007.5|6  |1e\n"},
+		{"awk '/o/' notes.txt", "hello world\nfoo bar\n"},
+		{"awk '!/o/ {print NR}' notes.txt", "3\n"},
+		{"awk '$2 ~ /^b/ {print $1}' notes.txt", "foo\n"},
+		{"awk 'NR == 2 {next} {print $1}' notes.txt", "hello\nbaz\n"},
+		{"awk -v n=2 'NR == n' notes.txt", "foo bar\n"},
+		{"awk '{n[$1 > \"c\"]++} END {for (k in n) print k, n[k]}' notes.txt", "0 1\n1 2\n"},
+		{"awk 'BEGIN {s = \"a.b.c\"; k = split(s, p, \".\"); print k, p[3], length(s), toupper(substr(s, 3))}'", "3 c 5 B.C\n"},
+		{"awk '{gsub(/o/, \"0\"); print}' notes.txt", "hell0 w0rld\nf00 bar\nbaz qux\n"},
+		{"awk 'BEGIN {x = \"hello\"; sub(/l+/, \"[&]\", x); print x, index(x, \"[\")}'", "he[ll]o 3\n"},
+		{"awk 'BEGIN {if (match(\"foobar\", /ob/)) print RSTART, RLENGTH}'", "3 2\n"},
+		{"awk 'BEGIN {for (i = 1; i <= 3; i++) {if (i == 2) continue; print i}}'", "1\n3\n"},
+		{"awk 'BEGIN {print int(3.9), 1 / 3, \"10\" < \"9\", 10 < 9}'", "3 0.333333 1 0\n"},
+		{"awk '{print FILENAME, FNR}' notes.txt data.csv | tail -n 1", "data.csv 3\n"},
+		{"awk 'NR == 1 {exit} END {print \"end\", NR}' notes.txt", "end 1\n"},
+	}
+	for _, tt := range tests {
+		out, code := runCode(t, sh, tt.cmd)
+		if code != 0 || out != tt.want {
+			t.Errorf("%s = %q (code %d), want %q", tt.cmd, out, code, tt.want)
+		}
+	}
+}
+
+func TestAwkErrors(t *testing.T) {
+	_, sh := setupTestEnv(t)
+
+	if out, code := runCode(t, sh, "awk 'BEGIN {print \"x\"; exit 3}'"); code != 3 || out != "x\n" {
+		t.Errorf("exit 3 = %q (code %d), want %q and code 3", out, code, "x\n")
+	}
+	for _, cmd := range []string{
+		"awk",
+		"awk '{print $1'",
+		"awk '{print $1 > \"out\"}' notes.txt",
+		"awk 'BEGIN {print 1 / 0}'",
+		"awk '{print}' missing.txt",
+		"awk -q '{print}'",
+		"awk 'function f() {}'",
+	} {
+		if _, code := runCode(t, sh, cmd); code == 0 {
+			t.Errorf("%s: expected failure", cmd)
+		}
+	}
+}
+
+// ─── cat (binary) ───
+
+func TestCatBinary(t *testing.T) {
+	v, sh := setupTestEnv(t)
+	ctx := context.Background()
+	data := mounts.NewMemFS(grasp.PermRW)
+	data.AddBinaryFile("logo.png", []byte("\x89PNG\r\n\x1a\n"), "image/png", grasp.PermRO)
+	data.AddBinaryFile("config.json", []byte(`{"a":1}`), "application/json", grasp.PermRO)
+	data.AddBinaryFile("run.sh", []byte("#!/bin/sh\necho hi\n"), "application/x-sh", grasp.PermRO)
+	if err := v.Mount("/data", data); err != nil {
+		t.Fatal(err)
+	}
+	_ = v.Write(ctx, "/tmp/blob", strings.NewReader("ab\x00cd"))
+
+	tests := []struct {
+		cmd, stdout, stderr string
+	}{
+		{"cat /data/logo.png", "\x89PNG\r\n\x1a\n", "cat: /data/logo.png: binary file (image/png, 8 bytes); use xxd to inspect it\n"},
+		{"cat /tmp/blob", "ab\x00cd", "cat: /tmp/blob: binary file (unknown type, 5 bytes); use xxd to inspect it\n"},
+		{"cat --raw /tmp/blob", "ab\x00cd", ""},
+		{"cat /data/config.json", `{"a":1}`, ""},
+		{"cat /data/run.sh", "#!/bin/sh\necho hi\n", ""},
+		{"cat notes.txt", "hello world\nfoo bar\nbaz qux\n", ""},
+	}
+	for _, tt := range tests {
+		r := sh.Execute(ctx, tt.cmd)
+		if r.Code != 0 || r.Stdout != tt.stdout || r.Stderr != tt.stderr {
+			t.Errorf("%s = stdout %q, stderr %q (code %d), want %q, %q", tt.cmd, r.Stdout, r.Stderr, r.Code, tt.stdout, tt.stderr)
+		}
+	}
+
+	// Redirects and pipes get the exact bytes.
+	if r := sh.Execute(ctx, "cat /data/logo.png > /tmp/copy.png"); r.Code != 0 {
+		t.Fatalf("cat > file: %+v", r)
+	}
+	if got := readVOSFile(t, v, "/tmp/copy.png"); got != "\x89PNG\r\n\x1a\n" {
+		t.Errorf("redirected copy = %q", got)
+	}
+	r := sh.Execute(ctx, "cat /tmp/blob | wc -c")
+	if r.Code != 0 || strings.TrimSpace(r.Stdout) != "5" {
+		t.Errorf("cat | wc -c = %+v, want 5 bytes through the pipe", r)
+	}
+	if !strings.Contains(r.Stderr, "binary file") {
+		t.Errorf("cat | wc -c stderr = %q, want the binary note", r.Stderr)
+	}
+
+	run(t, sh, "zip out.zip notes.txt")
+	piped := sh.Execute(ctx, "cat out.zip | md5sum").Stdout
+	direct := run(t, sh, "md5sum out.zip")
+	if sum, _, _ := strings.Cut(direct, " "); sum == "" || !strings.HasPrefix(piped, sum) {
+		t.Errorf("cat out.zip | md5sum = %q, md5sum out.zip = %q", piped, direct)
+	}
+	run(t, sh, "cat out.zip > copy.zip")
+	if out, code := runCode(t, sh, "unzip copy.zip -d /tmp/x"); code != 0 {
+		t.Errorf("unzip of a cat copy = %q (code %d)", out, code)
+	}
+	if got := readVOSFile(t, v, "/tmp/x/notes.txt"); got != "hello world\nfoo bar\nbaz qux\n" {
+		t.Errorf("unzipped notes.txt = %q", got)
+	}
+
+	if e, err := v.Stat(ctx, "/data/logo.png"); err != nil || e.MimeType != "image/png" {
+		t.Errorf("Stat MimeType = %+v, %v", e, err)
+	}
+}
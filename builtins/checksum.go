@@ -0,0 +1,144 @@
+package builtins
+
+import (
+	"bufio"
+	"context"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	grasp "github.com/jackfish212/grasp"
+	"github.com/jackfish212/grasp/mounts"
+)
+
+// builtinChecksum implements md5sum and sha256sum; name selects the hash.
+func builtinChecksum(v *grasp.VirtualOS, name string) mounts.ExecFunc {
+	newHash := sha256.New
+	if name == "md5sum" {
+		newHash = md5.New
+	}
+
+	return func(ctx context.Context, args []string, stdin io.Reader) (io.ReadCloser, error) {
+		if hasFlag(args, "-h", "--help") {
+			return io.NopCloser(strings.NewReader(fmt.Sprintf(`%[1]s — compute and check file checksums
+Usage: %[1]s [FILE]...
+       %[1]s -c|--check CHECKSUM_FILE
+Prints "HASH  FILE" for each FILE. With no FILE, or when FILE is -, read
+standard input. With --check, verify the files listed in CHECKSUM_FILE.
+`, name))), nil
+		}
+
+		cwd := grasp.Env(ctx, "PWD")
+		if cwd == "" {
+			cwd = "/"
+		}
+		sum := func(file string) (string, error) {
+			var r io.Reader
+			if file == "-" {
+				if stdin == nil {
+					return "", fmt.Errorf("no input on standard input")
+				}
+				r = stdin
+			} else {
+				f, err := v.Open(ctx, resolvePath(cwd, file))
+				if err != nil {
+					return "", err
+				}
+				defer func() { _ = f.Close() }()
+				r = f
+			}
+			h := newHash()
+			if _, err := io.Copy(h, r); err != nil {
+				return "", err
+			}
+			return hex.EncodeToString(h.Sum(nil)), nil
+		}
+
+		var files []string
+		check := ""
+		for i := 0; i < len(args); i++ {
+			switch arg := args[i]; {
+			case arg == "-c" || arg == "--check":
+				if i+1 >= len(args) {
+					return nil, fmt.Errorf("%s: option requires an argument -- '%s'", name, strings.TrimLeft(arg, "-"))
+				}
+				i++
+				check = args[i]
+			case strings.HasPrefix(arg, "-") && arg != "-":
+				return nil, fmt.Errorf("%s: invalid option -- '%s'", name, strings.TrimLeft(arg, "-"))
+			default:
+				files = append(files, arg)
+			}
+		}
+
+		if check != "" {
+			return checkSums(ctx, v, name, resolvePath(cwd, check), sum)
+		}
+
+		if len(files) == 0 {
+			files = []string{"-"}
+		}
+		var buf strings.Builder
+		for _, file := range files {
+			digest, err := sum(file)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %s: %w", name, file, err)
+			}
+			fmt.Fprintf(&buf, "%s  %s\n", digest, file)
+		}
+		return io.NopCloser(strings.NewReader(buf.String())), nil
+	}
+}
+
+// checkSums verifies each "HASH  FILE" line of the checksum file at path.
+// Any mismatch or unreadable file makes the command fail, with the per-file
+// report included in the error.
+func checkSums(ctx context.Context, v *grasp.VirtualOS, name, path string, sum func(string) (string, error)) (io.ReadCloser, error) {
+	data, err := readFile(ctx, v, path)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+
+	var buf strings.Builder
+	failed, unreadable := 0, 0
+	sc := bufio.NewScanner(strings.NewReader(string(data)))
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		want, file, ok := strings.Cut(line, " ")
+		if !ok {
+			return nil, fmt.Errorf("%s: %s: improperly formatted checksum line", name, path)
+		}
+		// The second separator character is '*' in binary mode.
+		file = strings.TrimPrefix(strings.TrimLeft(file, " "), "*")
+
+		got, err := sum(file)
+		switch {
+		case err != nil:
+			unreadable++
+			fmt.Fprintf(&buf, "%s: FAILED open or read\n", file)
+		case !strings.EqualFold(got, want):
+			failed++
+			fmt.Fprintf(&buf, "%s: FAILED\n", file)
+		default:
+			fmt.Fprintf(&buf, "%s: OK\n", file)
+		}
+	}
+
+	if failed+unreadable > 0 {
+		if unreadable > 0 {
+			fmt.Fprintf(&buf, "%s: WARNING: %d listed file(s) could not be read\n", name, unreadable)
+		}
+		if failed > 0 {
+			fmt.Fprintf(&buf, "%s: WARNING: %d computed checksum(s) did NOT match\n", name, failed)
+		}
+		return nil, errors.New(strings.TrimSuffix(buf.String(), "\n"))
+	}
+	return io.NopCloser(strings.NewReader(buf.String())), nil
+}
@@ -0,0 +1,107 @@
+package builtins
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	grasp "github.com/jackfish212/grasp"
+	"github.com/jackfish212/grasp/mounts"
+)
+
+func builtinComm(v *grasp.VirtualOS) mounts.ExecFunc {
+	return func(ctx context.Context, args []string, stdin io.Reader) (io.ReadCloser, error) {
+		if hasFlag(args, "-h", "--help") {
+			return io.NopCloser(strings.NewReader(`comm — compare two sorted files line by line
+Usage: comm [OPTION]... FILE1 FILE2
+Options:
+  -1    Suppress column 1 (lines unique to FILE1)
+  -2    Suppress column 2 (lines unique to FILE2)
+  -3    Suppress column 3 (lines that appear in both files)
+When FILE1 or FILE2 is -, read standard input.
+`)), nil
+		}
+
+		var suppress [4]bool
+		var files []string
+		for _, arg := range args {
+			if strings.HasPrefix(arg, "-") && arg != "-" {
+				for _, c := range arg[1:] {
+					switch c {
+					case '1', '2', '3':
+						suppress[c-'0'] = true
+					default:
+						return nil, fmt.Errorf("comm: invalid option -- '%c'", c)
+					}
+				}
+				continue
+			}
+			files = append(files, arg)
+		}
+		if len(files) != 2 {
+			return nil, fmt.Errorf("comm: expected two file operands, got %d", len(files))
+		}
+		if files[0] == "-" && files[1] == "-" {
+			return nil, fmt.Errorf("comm: only one file operand may be standard input")
+		}
+
+		cwd := grasp.Env(ctx, "PWD")
+		if cwd == "" {
+			cwd = "/"
+		}
+
+		var readers [2]io.Reader
+		for i, file := range files {
+			if file == "-" {
+				if stdin == nil {
+					return nil, fmt.Errorf("comm: no input on standard input")
+				}
+				readers[i] = stdin
+				continue
+			}
+			rc, err := v.Open(ctx, resolvePath(cwd, file))
+			if err != nil {
+				return nil, fmt.Errorf("comm: %w", err)
+			}
+			defer func() { _ = rc.Close() }()
+			readers[i] = rc
+		}
+
+		// Columns that are printed shift later columns left by one tab.
+		var prefix [4]string
+		indent := ""
+		for col := 1; col <= 3; col++ {
+			prefix[col] = indent
+			if !suppress[col] {
+				indent += "\t"
+			}
+		}
+
+		var buf strings.Builder
+		emit := func(col int, line string) {
+			if !suppress[col] {
+				buf.WriteString(prefix[col] + line + "\n")
+			}
+		}
+
+		s1 := bufio.NewScanner(readers[0])
+		s2 := bufio.NewScanner(readers[1])
+		ok1, ok2 := s1.Scan(), s2.Scan()
+		for ok1 || ok2 {
+			switch {
+			case !ok2 || (ok1 && s1.Text() < s2.Text()):
+				emit(1, s1.Text())
+				ok1 = s1.Scan()
+			case !ok1 || s2.Text() < s1.Text():
+				emit(2, s2.Text())
+				ok2 = s2.Scan()
+			default:
+				emit(3, s1.Text())
+				ok1, ok2 = s1.Scan(), s2.Scan()
+			}
+		}
+		return io.NopCloser(strings.NewReader(buf.String())), nil
+	}
+}
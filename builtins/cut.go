@@ -0,0 +1,162 @@
+package builtins
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	grasp "github.com/jackfish212/grasp"
+	"github.com/jackfish212/grasp/mounts"
+)
+
+func builtinCut(v *grasp.VirtualOS) mounts.ExecFunc {
+	return func(ctx context.Context, args []string, stdin io.Reader) (io.ReadCloser, error) {
+		if hasFlag(args, "-h", "--help") {
+			return io.NopCloser(strings.NewReader(`cut — remove sections from each line of files
+Usage: cut -f LIST [-d DELIM] [-s] [FILE]...
+       cut -c LIST [FILE]...
+Options:
+  -f LIST    Select only these fields
+  -d DELIM   Use DELIM instead of TAB as the field delimiter
+  -c LIST    Select only these characters
+  -s         Do not print lines that contain no delimiter
+LIST is a comma-separated set of N, N-M, N- and -M ranges, counted from 1.
+With no FILE, or when FILE is -, read standard input.
+`)), nil
+		}
+
+		var (
+			fieldList, charList string
+			delim               = "\t"
+			onlyDelimited       bool
+			files               []string
+		)
+		for i := 0; i < len(args); i++ {
+			arg := args[i]
+			// "-f 2" and "-f2" are equivalent; join the separate form.
+			if (arg == "-f" || arg == "-d" || arg == "-c") && i+1 < len(args) {
+				i++
+				arg += args[i]
+			}
+			switch {
+			case arg == "-s":
+				onlyDelimited = true
+			case arg == "-f" || arg == "-d" || arg == "-c":
+				return nil, fmt.Errorf("cut: option requires an argument -- '%s'", arg[1:])
+			case strings.HasPrefix(arg, "-f"):
+				fieldList = arg[2:]
+			case strings.HasPrefix(arg, "-d"):
+				delim = arg[2:]
+			case strings.HasPrefix(arg, "-c"):
+				charList = arg[2:]
+			case strings.HasPrefix(arg, "-") && arg != "-":
+				return nil, fmt.Errorf("cut: invalid option -- '%s'", strings.TrimLeft(arg, "-"))
+			default:
+				files = append(files, arg)
+			}
+		}
+
+		if (fieldList == "") == (charList == "") {
+			return nil, fmt.Errorf("cut: you must specify a list of fields or characters (exactly one of -f, -c)")
+		}
+		if len([]rune(delim)) != 1 {
+			return nil, fmt.Errorf("cut: the delimiter must be a single character")
+		}
+		if charList != "" && onlyDelimited {
+			return nil, fmt.Errorf("cut: -s is only meaningful when operating on fields")
+		}
+		list := fieldList
+		if list == "" {
+			list = charList
+		}
+		ranges, err := parseCutList(list)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(files) == 1 && files[0] == "-" {
+			files = nil
+		}
+		text, err := readInputs(ctx, v, "cut", files, stdin)
+		if err != nil {
+			return nil, err
+		}
+
+		var out strings.Builder
+		for _, line := range splitLines(text) {
+			if charList != "" {
+				var sel []rune
+				for i, r := range []rune(line) {
+					if ranges.has(i + 1) {
+						sel = append(sel, r)
+					}
+				}
+				out.WriteString(string(sel))
+				out.WriteByte('\n')
+				continue
+			}
+			if !strings.Contains(line, delim) {
+				if !onlyDelimited {
+					out.WriteString(line)
+					out.WriteByte('\n')
+				}
+				continue
+			}
+			var sel []string
+			for i, field := range strings.Split(line, delim) {
+				if ranges.has(i + 1) {
+					sel = append(sel, field)
+				}
+			}
+			out.WriteString(strings.Join(sel, delim))
+			out.WriteByte('\n')
+		}
+		return io.NopCloser(strings.NewReader(out.String())), nil
+	}
+}
+
+// cutRange is an inclusive 1-based range; hi == 0 means open-ended.
+type cutRange struct{ lo, hi int }
+
+type cutRanges []cutRange
+
+func (rs cutRanges) has(n int) bool {
+	for _, r := range rs {
+		if n >= r.lo && (r.hi == 0 || n <= r.hi) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseCutList parses a LIST such as "1,3-5,7-" into ranges.
+func parseCutList(list string) (cutRanges, error) {
+	var rs cutRanges
+	for _, part := range strings.Split(list, ",") {
+		lo, hi, isRange := strings.Cut(part, "-")
+		r := cutRange{lo: 1}
+		var err error
+		if lo != "" {
+			if r.lo, err = strconv.Atoi(lo); err != nil || r.lo < 1 {
+				return nil, fmt.Errorf("cut: invalid field value %q", part)
+			}
+		}
+		switch {
+		case !isRange:
+			r.hi = r.lo
+		case hi != "":
+			if r.hi, err = strconv.Atoi(hi); err != nil || r.hi < 1 {
+				return nil, fmt.Errorf("cut: invalid field value %q", part)
+			}
+			if r.hi < r.lo {
+				return nil, fmt.Errorf("cut: invalid decreasing range %q", part)
+			}
+		case lo == "":
+			return nil, fmt.Errorf("cut: invalid range with no endpoint: -")
+		}
+		rs = append(rs, r)
+	}
+	return rs, nil
+}
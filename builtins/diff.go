@@ -0,0 +1,457 @@
+package builtins
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strings"
+
+	grasp "github.com/jackfish212/grasp"
+	"github.com/jackfish212/grasp/mounts"
+)
+
+// diffContext is the number of unchanged lines shown around each change.
+const diffContext = 3
+
+func builtinDiff(v *grasp.VirtualOS) mounts.ExecFunc {
+	return func(ctx context.Context, args []string, _ io.Reader) (io.ReadCloser, error) {
+		if hasFlag(args, "-h", "--help") {
+			return io.NopCloser(strings.NewReader(`diff — compare files or directories line by line
+Usage: diff [-u | -c] [-q] [-r] FROM TO
+Options:
+  -u    Output a unified diff (default)
+  -c    Output a context diff
+  -q    Report only whether the files differ
+  -r    Recursively compare subdirectories
+FROM and TO may be files or directories on any mount point. If one is a
+directory, the file of the same name inside it is compared with the other.
+Exit status is 0 if the inputs are the same, 1 if they differ, 2 on error.
+`)), nil
+		}
+
+		d := &differ{ctx: ctx, v: v, format: 'u'}
+		var (
+			opts  []string
+			paths []string
+		)
+		for _, arg := range args {
+			if len(arg) < 2 || arg[0] != '-' {
+				paths = append(paths, arg)
+				continue
+			}
+			opts = append(opts, arg)
+			for _, c := range arg[1:] {
+				switch c {
+				case 'u', 'c':
+					d.format = c
+				case 'q':
+					d.quiet = true
+				case 'r':
+					d.recursive = true
+				default:
+					return nil, &grasp.ExitError{Code: 2, Err: fmt.Errorf("diff: invalid option -- '%c'", c)}
+				}
+			}
+		}
+		switch {
+		case len(paths) < 2:
+			return nil, &grasp.ExitError{Code: 2, Err: fmt.Errorf("diff: missing operand")}
+		case len(paths) > 2:
+			return nil, &grasp.ExitError{Code: 2, Err: fmt.Errorf("diff: extra operand %q", paths[2])}
+		}
+		d.cmd = strings.Join(append([]string{"diff"}, opts...), " ")
+
+		cwd := grasp.Env(ctx, "PWD")
+		if cwd == "" {
+			cwd = "/"
+		}
+		differs, err := d.compare(resolvePath(cwd, paths[0]), resolvePath(cwd, paths[1]), paths[0], paths[1])
+		if err != nil {
+			return nil, &grasp.ExitError{Code: 2, Err: err}
+		}
+		if differs {
+			return exitWith(d.out.String(), 1), nil
+		}
+		return io.NopCloser(strings.NewReader(d.out.String())), nil
+	}
+}
+
+// differ compares files and directory trees, accumulating the report.
+type differ struct {
+	ctx       context.Context
+	v         *grasp.VirtualOS
+	format    rune // 'u' (unified) or 'c' (context)
+	quiet     bool
+	recursive bool
+	cmd       string // "diff" plus the options, for per-file headers
+	out       strings.Builder
+}
+
+// compare reports whether the entries at a and b differ. aName and bName
+// are the names shown in the output.
+func (d *differ) compare(a, b, aName, bName string) (bool, error) {
+	aEntry, err := d.v.Stat(d.ctx, a)
+	if err != nil {
+		return false, fmt.Errorf("diff: %s: %w", aName, err)
+	}
+	bEntry, err := d.v.Stat(d.ctx, b)
+	if err != nil {
+		return false, fmt.Errorf("diff: %s: %w", bName, err)
+	}
+	switch {
+	case aEntry.IsDir && bEntry.IsDir:
+		if d.recursive && d.sameTree(a, b) {
+			return false, nil
+		}
+		return d.compareDirs(a, b, aName, bName)
+	case aEntry.IsDir:
+		a, aName = path.Join(a, bEntry.Name), path.Join(aName, bEntry.Name)
+	case bEntry.IsDir:
+		b, bName = path.Join(b, aEntry.Name), path.Join(bName, aEntry.Name)
+	}
+	return d.compareFiles(a, b, aName, bName, false)
+}
+
+// merkleRooter is implemented by providers that can hash their whole tree,
+// such as MemFS.
+type merkleRooter interface {
+	MerkleRoot() [32]byte
+}
+
+// sameTree reports whether a and b are the roots of two mounts whose
+// providers hash to the same Merkle root, in which case a recursive
+// comparison would find nothing. Mounts nested beneath either root are not
+// covered by the hash, so their presence disables the shortcut.
+func (d *differ) sameTree(a, b string) bool {
+	mt := d.v.MountTable()
+	aProv, aInner, errA := mt.Resolve(a)
+	bProv, bInner, errB := mt.Resolve(b)
+	if errA != nil || errB != nil || aInner != "" || bInner != "" {
+		return false
+	}
+	if len(mt.ChildMounts(a)) > 0 || len(mt.ChildMounts(b)) > 0 {
+		return false
+	}
+	aRoot, okA := aProv.(merkleRooter)
+	bRoot, okB := bProv.(merkleRooter)
+	return okA && okB && aRoot.MerkleRoot() == bRoot.MerkleRoot()
+}
+
+func (d *differ) compareDirs(a, b, aName, bName string) (bool, error) {
+	aEntries, err := d.v.List(d.ctx, a, grasp.ListOpts{})
+	if err != nil {
+		return false, fmt.Errorf("diff: %s: %w", aName, err)
+	}
+	bEntries, err := d.v.List(d.ctx, b, grasp.ListOpts{})
+	if err != nil {
+		return false, fmt.Errorf("diff: %s: %w", bName, err)
+	}
+
+	aByName := make(map[string]grasp.Entry, len(aEntries))
+	for _, e := range aEntries {
+		aByName[e.Name] = e
+	}
+	bByName := make(map[string]grasp.Entry, len(bEntries))
+	var names []string
+	for _, e := range bEntries {
+		bByName[e.Name] = e
+		if _, ok := aByName[e.Name]; !ok {
+			names = append(names, e.Name)
+		}
+	}
+	for name := range aByName {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	differs := false
+	for _, name := range names {
+		aEntry, inA := aByName[name]
+		bEntry, inB := bByName[name]
+		aPath, bPath := path.Join(a, name), path.Join(b, name)
+		aSub, bSub := path.Join(aName, name), path.Join(bName, name)
+		switch {
+		case !inB:
+			fmt.Fprintf(&d.out, "Only in %s: %s\n", aName, name)
+			differs = true
+		case !inA:
+			fmt.Fprintf(&d.out, "Only in %s: %s\n", bName, name)
+			differs = true
+		case aEntry.IsDir && bEntry.IsDir:
+			if !d.recursive {
+				fmt.Fprintf(&d.out, "Common subdirectories: %s and %s\n", aSub, bSub)
+				continue
+			}
+			sub, err := d.compareDirs(aPath, bPath, aSub, bSub)
+			if err != nil {
+				return false, err
+			}
+			differs = differs || sub
+		case aEntry.IsDir != bEntry.IsDir:
+			fmt.Fprintf(&d.out, "File %s is a %s while file %s is a %s\n",
+				aSub, entryKind(aEntry), bSub, entryKind(bEntry))
+			differs = true
+		default:
+			sub, err := d.compareFiles(aPath, bPath, aSub, bSub, true)
+			if err != nil {
+				return false, err
+			}
+			differs = differs || sub
+		}
+	}
+	return differs, nil
+}
+
+func entryKind(e grasp.Entry) string {
+	if e.IsDir {
+		return "directory"
+	}
+	return "regular file"
+}
+
+// compareFiles diffs two files. inDir adds the per-file "diff" header used
+// when walking directories.
+func (d *differ) compareFiles(a, b, aName, bName string, inDir bool) (bool, error) {
+	aData, err := readFile(d.ctx, d.v, a)
+	if err != nil {
+		return false, fmt.Errorf("diff: %s: %w", aName, err)
+	}
+	bData, err := readFile(d.ctx, d.v, b)
+	if err != nil {
+		return false, fmt.Errorf("diff: %s: %w", bName, err)
+	}
+	if bytes.Equal(aData, bData) {
+		return false, nil
+	}
+	if d.quiet {
+		fmt.Fprintf(&d.out, "Files %s and %s differ\n", aName, bName)
+		return true, nil
+	}
+	if inDir {
+		fmt.Fprintf(&d.out, "%s %s %s\n", d.cmd, aName, bName)
+	}
+
+	hunks := diffHunks(diffLines(splitLinesKeepEnds(string(aData)), splitLinesKeepEnds(string(bData))), diffContext)
+	if d.format == 'c' {
+		writeContextDiff(&d.out, aName, bName, hunks)
+	} else {
+		writeUnifiedDiff(&d.out, aName, bName, hunks)
+	}
+	return true, nil
+}
+
+// splitLinesKeepEnds splits text into lines, each keeping its newline, so
+// that a missing final newline shows up as a difference.
+func splitLinesKeepEnds(text string) []string {
+	lines := strings.SplitAfter(text, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// diffOp is one line of an edit script: ' ' (kept), '-' (deleted from the
+// first file) or '+' (added from the second).
+type diffOp struct {
+	kind byte
+	text string
+}
+
+// diffLines returns the shortest edit script turning a into b, using
+// Myers' O(ND) algorithm.
+func diffLines(a, b []string) []diffOp {
+	n, m := len(a), len(b)
+	offset := n + m + 1
+	v := make([]int, 2*offset+1)
+	var trace [][]int
+	for depth := 0; depth <= n+m; depth++ {
+		trace = append(trace, append([]int(nil), v...))
+		for k := -depth; k <= depth; k += 2 {
+			var x int
+			if k == -depth || (k != depth && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				return diffBacktrack(trace, a, b, offset)
+			}
+		}
+	}
+	return nil
+}
+
+func diffBacktrack(trace [][]int, a, b []string, offset int) []diffOp {
+	var ops []diffOp
+	x, y := len(a), len(b)
+	for depth := len(trace) - 1; depth >= 0; depth-- {
+		v := trace[depth]
+		k := x - y
+		prevK := k - 1
+		if k == -depth || (k != depth && v[offset+k-1] < v[offset+k+1]) {
+			prevK = k + 1
+		}
+		prevX := v[offset+prevK]
+		prevY := prevX - prevK
+		for x > prevX && y > prevY {
+			ops = append(ops, diffOp{' ', a[x-1]})
+			x--
+			y--
+		}
+		if depth == 0 {
+			break
+		}
+		if x == prevX {
+			ops = append(ops, diffOp{'+', b[y-1]})
+			y--
+		} else {
+			ops = append(ops, diffOp{'-', a[x-1]})
+			x--
+		}
+	}
+	for i, j := 0, len(ops)-1; i < j; i, j = i+1, j-1 {
+		ops[i], ops[j] = ops[j], ops[i]
+	}
+	return ops
+}
+
+// diffHunk is a run of the edit script with its position in both files.
+// Starts are 0-based line indexes.
+type diffHunk struct {
+	ops                        []diffOp
+	aStart, aLen, bStart, bLen int
+}
+
+// diffHunks groups the changes in ops into hunks with context lines of
+// surrounding text. Changes separated by at most 2*context unchanged
+// lines share a hunk.
+func diffHunks(ops []diffOp, context int) []diffHunk {
+	include := make([]bool, len(ops))
+	for i, op := range ops {
+		if op.kind == ' ' {
+			continue
+		}
+		for j := max(0, i-context); j <= min(len(ops)-1, i+context); j++ {
+			include[j] = true
+		}
+	}
+
+	var hunks []diffHunk
+	var cur *diffHunk
+	aPos, bPos := 0, 0
+	for i, op := range ops {
+		if include[i] {
+			if cur == nil {
+				hunks = append(hunks, diffHunk{aStart: aPos, bStart: bPos})
+				cur = &hunks[len(hunks)-1]
+			}
+			cur.ops = append(cur.ops, op)
+			if op.kind != '+' {
+				cur.aLen++
+			}
+			if op.kind != '-' {
+				cur.bLen++
+			}
+		} else {
+			cur = nil
+		}
+		if op.kind != '+' {
+			aPos++
+		}
+		if op.kind != '-' {
+			bPos++
+		}
+	}
+	return hunks
+}
+
+// writeDiffLine writes one output line, flagging a missing final newline
+// the way diff and patch expect.
+func writeDiffLine(w *strings.Builder, prefix, text string) {
+	w.WriteString(prefix)
+	w.WriteString(text)
+	if !strings.HasSuffix(text, "\n") {
+		w.WriteString("\n\\ No newline at end of file\n")
+	}
+}
+
+func writeUnifiedDiff(w *strings.Builder, aName, bName string, hunks []diffHunk) {
+	fmt.Fprintf(w, "--- %s\n+++ %s\n", aName, bName)
+	unifiedRange := func(start, n int) string {
+		switch n {
+		case 0:
+			return fmt.Sprintf("%d,0", start)
+		case 1:
+			return fmt.Sprintf("%d", start+1)
+		}
+		return fmt.Sprintf("%d,%d", start+1, n)
+	}
+	for _, h := range hunks {
+		fmt.Fprintf(w, "@@ -%s +%s @@\n", unifiedRange(h.aStart, h.aLen), unifiedRange(h.bStart, h.bLen))
+		for _, op := range h.ops {
+			writeDiffLine(w, string(op.kind), op.text)
+		}
+	}
+}
+
+func writeContextDiff(w *strings.Builder, aName, bName string, hunks []diffHunk) {
+	fmt.Fprintf(w, "*** %s\n--- %s\n", aName, bName)
+	contextRange := func(start, n int) string {
+		if n <= 1 {
+			return fmt.Sprintf("%d", start+n)
+		}
+		return fmt.Sprintf("%d,%d", start+1, start+n)
+	}
+	for _, h := range hunks {
+		// A block of deletions next to additions is a change, marked "!".
+		marks := make([]byte, len(h.ops))
+		for i := 0; i < len(h.ops); {
+			if h.ops[i].kind == ' ' {
+				marks[i] = ' '
+				i++
+				continue
+			}
+			j, dels, adds := i, false, false
+			for ; j < len(h.ops) && h.ops[j].kind != ' '; j++ {
+				dels = dels || h.ops[j].kind == '-'
+				adds = adds || h.ops[j].kind == '+'
+			}
+			for ; i < j; i++ {
+				marks[i] = h.ops[i].kind
+				if dels && adds {
+					marks[i] = '!'
+				}
+			}
+		}
+
+		w.WriteString("***************\n")
+		side := func(header string, skip byte, hasChange func(byte) bool) {
+			w.WriteString(header)
+			changed := false
+			for _, m := range marks {
+				changed = changed || hasChange(m)
+			}
+			if !changed {
+				return
+			}
+			for i, op := range h.ops {
+				if op.kind != skip {
+					writeDiffLine(w, string(marks[i])+" ", op.text)
+				}
+			}
+		}
+		side(fmt.Sprintf("*** %s ****\n", contextRange(h.aStart, h.aLen)), '+',
+			func(m byte) bool { return m == '-' || m == '!' })
+		side(fmt.Sprintf("--- %s ----\n", contextRange(h.bStart, h.bLen)), '-',
+			func(m byte) bool { return m == '+' || m == '!' })
+	}
+}
@@ -0,0 +1,103 @@
+package builtins
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	grasp "github.com/jackfish212/grasp"
+	"github.com/jackfish212/grasp/mounts"
+)
+
+func builtinFold(v *grasp.VirtualOS) mounts.ExecFunc {
+	return func(ctx context.Context, args []string, stdin io.Reader) (io.ReadCloser, error) {
+		if hasFlag(args, "-h", "--help") {
+			return io.NopCloser(strings.NewReader(`fold — wrap each input line to fit in specified width
+Usage: fold [OPTION]... [FILE]...
+Options:
+  -w, --width=WIDTH    Use WIDTH columns instead of 80
+  -s, --spaces         Break at spaces
+`)), nil
+		}
+
+		width := 80
+		spaces := false
+		var files []string
+
+		for i := 0; i < len(args); i++ {
+			arg := args[i]
+			var widthArg string
+			switch {
+			case arg == "-s" || arg == "--spaces":
+				spaces = true
+				continue
+			case arg == "-w" || arg == "--width":
+				if i+1 >= len(args) {
+					return nil, fmt.Errorf("fold: option requires an argument -- 'w'")
+				}
+				i++
+				widthArg = args[i]
+			case strings.HasPrefix(arg, "--width="):
+				widthArg = strings.TrimPrefix(arg, "--width=")
+			case strings.HasPrefix(arg, "-w"):
+				widthArg = arg[2:]
+			case arg == "-sw":
+				spaces = true
+				if i+1 >= len(args) {
+					return nil, fmt.Errorf("fold: option requires an argument -- 'w'")
+				}
+				i++
+				widthArg = args[i]
+			case strings.HasPrefix(arg, "-") && arg != "-":
+				return nil, fmt.Errorf("fold: invalid option: %s", arg)
+			default:
+				files = append(files, arg)
+				continue
+			}
+			n, err := strconv.Atoi(widthArg)
+			if err != nil || n < 1 {
+				return nil, fmt.Errorf("fold: invalid number of columns: %s", widthArg)
+			}
+			width = n
+		}
+
+		text, err := readInputs(ctx, v, "fold", files, stdin)
+		if err != nil {
+			return nil, err
+		}
+
+		var buf strings.Builder
+		for i, line := range strings.Split(text, "\n") {
+			if i > 0 {
+				buf.WriteByte('\n')
+			}
+			buf.WriteString(foldLine(line, width, spaces))
+		}
+		return io.NopCloser(strings.NewReader(buf.String())), nil
+	}
+}
+
+// foldLine breaks line into chunks of at most width characters. With spaces
+// set, a chunk ends after its last blank when one exists.
+func foldLine(line string, width int, spaces bool) string {
+	runes := []rune(line)
+	var buf strings.Builder
+	for len(runes) > width {
+		cut := width
+		if spaces {
+			for j := width - 1; j >= 0; j-- {
+				if runes[j] == ' ' || runes[j] == '\t' {
+					cut = j + 1
+					break
+				}
+			}
+		}
+		buf.WriteString(string(runes[:cut]))
+		buf.WriteByte('\n')
+		runes = runes[cut:]
+	}
+	buf.WriteString(string(runes))
+	return buf.String()
+}
@@ -104,7 +104,7 @@ func builtinGrep(v *grasp.VirtualOS) func(ctx context.Context, args []string, st
 				result.Reset()
 				result.WriteString(fmt.Sprintf("%d\n", matchCount))
 			}
-			return io.NopCloser(strings.NewReader(result.String())), nil
+			return grepOutput(result.String(), matchCount), nil
 		}
 
 		// Process files
@@ -124,10 +124,19 @@ func builtinGrep(v *grasp.VirtualOS) func(ctx context.Context, args []string, st
 			result.WriteString(fmt.Sprintf("%d\n", totalCount))
 		}
 
-		return io.NopCloser(strings.NewReader(result.String())), nil
+		return grepOutput(result.String(), totalCount), nil
 	}
 }
 
+// grepOutput returns grep's output. As in POSIX grep, the exit status is 1
+// when no lines were selected.
+func grepOutput(out string, selected int) io.ReadCloser {
+	if selected == 0 {
+		return exitWith(out, 1)
+	}
+	return io.NopCloser(strings.NewReader(out))
+}
+
 func parseGrepArgs(args []string, opts *grepOpts) (pattern string, files []string, err error) {
 	i := 0
 	for i < len(args) {
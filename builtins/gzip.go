@@ -0,0 +1,175 @@
+package builtins
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	grasp "github.com/jackfish212/grasp"
+	"github.com/jackfish212/grasp/mounts"
+)
+
+func builtinGzip(v *grasp.VirtualOS, name string) mounts.ExecFunc {
+	return func(ctx context.Context, args []string, _ io.Reader) (io.ReadCloser, error) {
+		if hasFlag(args, "-h", "--help") {
+			return io.NopCloser(strings.NewReader(name + ` — compress or decompress files
+Usage: gzip [-k] [-d] [-f] FILE...
+       gunzip [-k] [-f] FILE...
+Options:
+  -d, --decompress   Decompress FILE.gz to FILE
+  -f, --force        Overwrite an existing output file
+  -k, --keep         Keep the input file
+Mounts with native compression support compress FILE in place, unless -k
+is given.
+`)), nil
+		}
+
+		decompress := name == "gunzip"
+		var opts gzipOpts
+		var files []string
+		for _, arg := range args {
+			switch {
+			case arg == "-d" || arg == "--decompress":
+				decompress = true
+			case arg == "-f" || arg == "--force":
+				opts.force = true
+			case arg == "-k" || arg == "--keep":
+				opts.keep = true
+			case strings.HasPrefix(arg, "-") && !strings.HasPrefix(arg, "--") && len(arg) > 2:
+				for _, c := range arg[1:] {
+					switch c {
+					case 'd':
+						decompress = true
+					case 'f':
+						opts.force = true
+					case 'k':
+						opts.keep = true
+					default:
+						return nil, fmt.Errorf("%s: invalid option -- '%c'", name, c)
+					}
+				}
+			case strings.HasPrefix(arg, "-"):
+				return nil, fmt.Errorf("%s: invalid option: %s", name, arg)
+			default:
+				files = append(files, arg)
+			}
+		}
+		if len(files) == 0 {
+			return nil, fmt.Errorf("%s: missing operand", name)
+		}
+
+		cwd := grasp.Env(ctx, "PWD")
+		if cwd == "" {
+			cwd = "/"
+		}
+
+		for _, file := range files {
+			target := resolvePath(cwd, file)
+			var err error
+			if decompress {
+				err = gunzipFile(ctx, v, target, opts)
+			} else {
+				err = gzipFile(ctx, v, target, opts)
+			}
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", name, err)
+			}
+		}
+		return io.NopCloser(strings.NewReader("")), nil
+	}
+}
+
+type gzipOpts struct {
+	keep  bool // keep the input file
+	force bool // overwrite an existing output file
+}
+
+// checkOutput refuses to replace an existing dst unless forced, as gzip
+// does when it cannot ask.
+func (o gzipOpts) checkOutput(ctx context.Context, v *grasp.VirtualOS, dst string) error {
+	if o.force {
+		return nil
+	}
+	if _, err := v.Stat(ctx, dst); err == nil {
+		return fmt.Errorf("%s already exists; not overwritten", dst)
+	}
+	return nil
+}
+
+// gzipFile compresses src to src.gz. Native compression works in place, so
+// it is only used when the input need not be kept.
+func gzipFile(ctx context.Context, v *grasp.VirtualOS, src string, opts gzipOpts) error {
+	if strings.HasSuffix(src, ".gz") {
+		return fmt.Errorf("%s already has .gz suffix", src)
+	}
+	if err := opts.checkOutput(ctx, v, src+".gz"); err != nil {
+		return err
+	}
+	if !opts.keep {
+		if err := v.Compress(ctx, src); !errors.Is(err, grasp.ErrNotSupported) {
+			return err
+		}
+	}
+
+	data, err := readFile(ctx, v, src)
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(data); err != nil {
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		return err
+	}
+	if err := v.Write(ctx, src+".gz", &buf); err != nil {
+		return err
+	}
+	if opts.keep {
+		return nil
+	}
+	return v.Remove(ctx, src)
+}
+
+// gunzipFile decompresses src, which must end in .gz or .tgz. As in
+// gzipFile, native decompression is only used without -k.
+func gunzipFile(ctx context.Context, v *grasp.VirtualOS, src string, opts gzipOpts) error {
+	dst, ok := grasp.DecompressedName(src)
+	if !ok {
+		return fmt.Errorf("%s: unknown suffix -- ignored", src)
+	}
+	if err := opts.checkOutput(ctx, v, dst); err != nil {
+		return err
+	}
+	if !opts.keep {
+		if err := v.Decompress(ctx, src); !errors.Is(err, grasp.ErrNotSupported) {
+			return err
+		}
+	}
+
+	f, err := v.Open(ctx, src)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = f.Close() }()
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		return fmt.Errorf("%s: %w", src, err)
+	}
+	data, err := io.ReadAll(zr)
+	if err != nil {
+		return fmt.Errorf("%s: %w", src, err)
+	}
+	if err := v.Write(ctx, dst, bytes.NewReader(data)); err != nil {
+		return err
+	}
+	if opts.keep {
+		return nil
+	}
+	return v.Remove(ctx, src)
+}
@@ -1,6 +1,9 @@
 package builtins
 
 import (
+	"context"
+	"fmt"
+	"io"
 	"strings"
 
 	grasp "github.com/jackfish212/grasp"
@@ -52,3 +55,104 @@ func parseLsFlags(args []string) (bool, bool, []string) {
 
 	return showLong, showAll, filtered
 }
+
+// readInputs concatenates the content of files (resolved against the PWD in
+// ctx), or reads stdin when no files are given. name prefixes error messages.
+func readInputs(ctx context.Context, v *grasp.VirtualOS, name string, files []string, stdin io.Reader) (string, error) {
+	if len(files) == 0 {
+		if stdin == nil {
+			return "", fmt.Errorf("%s: missing file operand", name)
+		}
+		data, err := io.ReadAll(stdin)
+		if err != nil {
+			return "", fmt.Errorf("%s: read error: %w", name, err)
+		}
+		return string(data), nil
+	}
+
+	cwd := grasp.Env(ctx, "PWD")
+	if cwd == "" {
+		cwd = "/"
+	}
+
+	var buf strings.Builder
+	for _, file := range files {
+		data, err := readFile(ctx, v, resolvePath(cwd, file))
+		if err != nil {
+			return "", fmt.Errorf("%s: %w", name, err)
+		}
+		buf.Write(data)
+	}
+	return buf.String(), nil
+}
+
+// readFile returns the full content of the file at the absolute path p.
+func readFile(ctx context.Context, v *grasp.VirtualOS, p string) ([]byte, error) {
+	rc, err := v.Open(ctx, p)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rc.Close() }()
+	return io.ReadAll(rc)
+}
+
+// splitLines splits text into lines without their trailing newlines. A final
+// newline does not produce an extra empty line.
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+}
+
+// mkdirAll creates dir and any missing parents.
+func mkdirAll(ctx context.Context, v *grasp.VirtualOS, dir string) error {
+	dir = grasp.CleanPath(dir)
+	if dir == "/" {
+		return nil
+	}
+	if entry, err := v.Stat(ctx, dir); err == nil {
+		if !entry.IsDir {
+			return fmt.Errorf("%w: %s", grasp.ErrNotDir, dir)
+		}
+		return nil
+	}
+	if err := mkdirAll(ctx, v, parentDir(dir)); err != nil {
+		return err
+	}
+	return v.Mkdir(ctx, dir, grasp.PermRWX)
+}
+
+func parentDir(p string) string {
+	idx := strings.LastIndexByte(p, '/')
+	if idx <= 0 {
+		return "/"
+	}
+	return p[:idx]
+}
+
+// exitWith returns a stream that yields out and then ends the command with
+// exit status code, for commands whose output and failure go together.
+func exitWith(out string, code int) io.ReadCloser {
+	return io.NopCloser(&exitReader{r: strings.NewReader(out), code: code})
+}
+
+// exitWithErr is like exitWith, but the command fails with status 1 and
+// err is reported on stderr after out.
+func exitWithErr(out string, err error) io.ReadCloser {
+	return io.NopCloser(&exitReader{r: strings.NewReader(out), code: 1, err: err})
+}
+
+type exitReader struct {
+	r    io.Reader
+	code int
+	err  error
+}
+
+func (e *exitReader) Read(p []byte) (int, error) {
+	n, err := e.r.Read(p)
+	if err == io.EOF {
+		err = &grasp.ExitError{Code: e.code, Err: e.err}
+	}
+	return n, err
+}
@@ -0,0 +1,185 @@
+package builtins
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+
+	grasp "github.com/jackfish212/grasp"
+	"github.com/jackfish212/grasp/mounts"
+)
+
+func builtinInstall(v *grasp.VirtualOS) mounts.ExecFunc {
+	return func(ctx context.Context, args []string, _ io.Reader) (io.ReadCloser, error) {
+		if hasFlag(args, "-h", "--help") {
+			return io.NopCloser(strings.NewReader(`install — copy files and set permissions
+Usage: install [-m MODE] [-o OWNER] SOURCE DEST
+       install [-m MODE] [-o OWNER] SOURCE... DIRECTORY
+       install -d [-m MODE] [-o OWNER] DIRECTORY...
+Options:
+  -d          Create each DIRECTORY instead of copying
+  -m MODE     Set permissions: r, ro, rw, rx, rwx, or octal (e.g. 644, 0755)
+  -o OWNER    Record OWNER in the "owner" metadata attribute
+Octal modes use the owner digit only, since grasp tracks a single r/w/x set.
+`)), nil
+		}
+
+		var (
+			mode, owner string
+			dirs        bool
+			paths       []string
+		)
+		for i := 0; i < len(args); i++ {
+			arg := args[i]
+			var dst *string
+			switch arg {
+			case "-d":
+				dirs = true
+				continue
+			case "-m":
+				dst = &mode
+			case "-o":
+				dst = &owner
+			default:
+				if strings.HasPrefix(arg, "-") && arg != "-" {
+					return nil, fmt.Errorf("install: invalid option -- '%s'", strings.TrimLeft(arg, "-"))
+				}
+				paths = append(paths, arg)
+				continue
+			}
+			if i+1 >= len(args) {
+				return nil, fmt.Errorf("install: option requires an argument -- '%s'", arg[1:])
+			}
+			i++
+			*dst = args[i]
+		}
+
+		perm := grasp.PermRWX
+		if mode != "" {
+			p, err := parseInstallMode(mode)
+			if err != nil {
+				return nil, err
+			}
+			perm = p
+		}
+
+		cwd := grasp.Env(ctx, "PWD")
+		if cwd == "" {
+			cwd = "/"
+		}
+
+		// finish applies the requested mode and owner to an installed entry.
+		finish := func(target string) error {
+			if mode != "" {
+				if err := v.Chmod(ctx, target, perm); err != nil {
+					return fmt.Errorf("install: cannot set mode on %q: %w", target, err)
+				}
+			}
+			if owner != "" {
+				if err := v.SetMeta(ctx, target, "owner", owner); err != nil {
+					return fmt.Errorf("install: cannot set owner on %q: %w", target, err)
+				}
+			}
+			return nil
+		}
+
+		if dirs {
+			if len(paths) == 0 {
+				return nil, fmt.Errorf("install: missing operand")
+			}
+			for _, p := range paths {
+				target := resolvePath(cwd, p)
+				if entry, err := v.Stat(ctx, target); err == nil {
+					if !entry.IsDir {
+						return nil, fmt.Errorf("install: %q exists but is not a directory", target)
+					}
+				} else if err := v.Mkdir(ctx, target, perm); err != nil {
+					return nil, fmt.Errorf("install: cannot create directory %q: %w", target, err)
+				}
+				if err := finish(target); err != nil {
+					return nil, err
+				}
+			}
+			return io.NopCloser(strings.NewReader("")), nil
+		}
+
+		if len(paths) < 2 {
+			return nil, fmt.Errorf("install: missing destination file operand")
+		}
+		dst := resolvePath(cwd, paths[len(paths)-1])
+		srcs := paths[:len(paths)-1]
+		dstEntry, dstErr := v.Stat(ctx, dst)
+		dstIsDir := dstErr == nil && dstEntry.IsDir
+		if len(srcs) > 1 && !dstIsDir {
+			return nil, fmt.Errorf("install: target %q is not a directory", dst)
+		}
+
+		for _, src := range srcs {
+			srcPath := resolvePath(cwd, src)
+			srcEntry, err := v.Stat(ctx, srcPath)
+			if err != nil {
+				return nil, fmt.Errorf("install: cannot stat %q: %w", srcPath, err)
+			}
+			if srcEntry.IsDir {
+				return nil, fmt.Errorf("install: omitting directory %q", srcPath)
+			}
+			target := dst
+			if dstIsDir {
+				target = path.Join(dst, srcEntry.Name)
+			}
+			if err := installFile(ctx, v, srcPath, target); err != nil {
+				return nil, err
+			}
+			if err := finish(target); err != nil {
+				return nil, err
+			}
+		}
+		return io.NopCloser(strings.NewReader("")), nil
+	}
+}
+
+func installFile(ctx context.Context, v *grasp.VirtualOS, src, dst string) error {
+	rc, err := v.Open(ctx, src)
+	if err != nil {
+		return fmt.Errorf("install: cannot open %q: %w", src, err)
+	}
+	defer func() { _ = rc.Close() }()
+	if err := v.Write(ctx, dst, rc); err != nil {
+		return fmt.Errorf("install: cannot write to %q: %w", dst, err)
+	}
+	return nil
+}
+
+// parseInstallMode converts a symbolic (rw, ro, rwx...) or octal mode into a
+// Perm. Octal modes are reduced to their owner digit.
+func parseInstallMode(mode string) (grasp.Perm, error) {
+	switch mode {
+	case "ro", "r":
+		return grasp.PermRO, nil
+	case "rw":
+		return grasp.PermRW, nil
+	case "rx":
+		return grasp.PermRX, nil
+	case "rwx":
+		return grasp.PermRWX, nil
+	}
+	n, err := strconv.ParseUint(mode, 8, 12)
+	if err != nil {
+		return 0, fmt.Errorf("install: invalid mode %q", mode)
+	}
+	owner := n >> 6 & 7
+	var p grasp.Perm
+	if owner&4 != 0 {
+		p |= grasp.PermRead
+	}
+	if owner&2 != 0 {
+		p |= grasp.PermWrite
+	}
+	if owner&1 != 0 {
+		p |= grasp.PermExec
+	}
+	return p, nil
+}
@@ -0,0 +1,64 @@
+package builtins
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+
+	grasp "github.com/jackfish212/grasp"
+	"github.com/jackfish212/grasp/mounts"
+)
+
+func builtinLn(v *grasp.VirtualOS) mounts.ExecFunc {
+	return func(ctx context.Context, args []string, _ io.Reader) (io.ReadCloser, error) {
+		if hasFlag(args, "-h", "--help") {
+			return io.NopCloser(strings.NewReader(`ln — make links between files
+Usage: ln [-s] TARGET LINK_NAME
+Options:
+  -s    Make a symbolic link instead of a hard link
+If LINK_NAME is an existing directory, the link is created inside it.
+`)), nil
+		}
+
+		symbolic := false
+		var operands []string
+		for _, arg := range args {
+			switch {
+			case arg == "-s":
+				symbolic = true
+			case strings.HasPrefix(arg, "-") && arg != "-":
+				return nil, fmt.Errorf("ln: invalid option -- '%s'", strings.TrimLeft(arg, "-"))
+			default:
+				operands = append(operands, arg)
+			}
+		}
+		if len(operands) != 2 {
+			return nil, fmt.Errorf("ln: expected TARGET and LINK_NAME")
+		}
+
+		cwd := grasp.Env(ctx, "PWD")
+		if cwd == "" {
+			cwd = "/"
+		}
+		target := operands[0]
+		link := resolvePath(cwd, operands[1])
+		if e, err := v.Stat(ctx, link); err == nil && e.IsDir {
+			link = path.Join(link, path.Base(target))
+		}
+
+		var err error
+		if symbolic {
+			// The target is stored as typed; relative targets are resolved
+			// against the link's directory when followed.
+			err = v.Symlink(ctx, target, link)
+		} else {
+			err = v.Hardlink(ctx, resolvePath(cwd, target), link)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("ln: %w", err)
+		}
+		return io.NopCloser(strings.NewReader("")), nil
+	}
+}
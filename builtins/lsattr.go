@@ -0,0 +1,81 @@
+package builtins
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	grasp "github.com/jackfish212/grasp"
+	"github.com/jackfish212/grasp/mounts"
+)
+
+func builtinLsattr(v *grasp.VirtualOS) mounts.ExecFunc {
+	return func(ctx context.Context, args []string, _ io.Reader) (io.ReadCloser, error) {
+		if hasFlag(args, "-h", "--help") {
+			return io.NopCloser(strings.NewReader(`lsattr — list file metadata attributes
+Usage: lsattr [-n KEY] FILE...
+Options:
+  -n KEY   Print only the value of KEY
+`)), nil
+		}
+
+		var key string
+		var files []string
+		for i := 0; i < len(args); i++ {
+			switch {
+			case args[i] == "-n":
+				if i+1 >= len(args) {
+					return nil, fmt.Errorf("lsattr: option requires an argument -- 'n'")
+				}
+				i++
+				key = args[i]
+			case strings.HasPrefix(args[i], "-"):
+				return nil, fmt.Errorf("lsattr: invalid option: %s", args[i])
+			default:
+				files = append(files, args[i])
+			}
+		}
+		if len(files) == 0 {
+			return nil, fmt.Errorf("lsattr: missing operand")
+		}
+
+		cwd := grasp.Env(ctx, "PWD")
+		if cwd == "" {
+			cwd = "/"
+		}
+
+		var buf strings.Builder
+		for _, file := range files {
+			target := resolvePath(cwd, file)
+			prefix := ""
+			if len(files) > 1 {
+				prefix = file + ": "
+			}
+
+			if key != "" {
+				value, err := v.GetMeta(ctx, target, key)
+				if err != nil {
+					return nil, fmt.Errorf("lsattr: %w", err)
+				}
+				fmt.Fprintf(&buf, "%s%s\n", prefix, value)
+				continue
+			}
+
+			entry, err := v.Stat(ctx, target)
+			if err != nil {
+				return nil, fmt.Errorf("lsattr: %w", err)
+			}
+			keys := make([]string, 0, len(entry.Meta))
+			for k := range entry.Meta {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			for _, k := range keys {
+				fmt.Fprintf(&buf, "%s%s=%s\n", prefix, k, entry.Meta[k])
+			}
+		}
+		return io.NopCloser(strings.NewReader(buf.String())), nil
+	}
+}
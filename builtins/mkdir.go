@@ -2,6 +2,7 @@ package builtins
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"strings"
@@ -23,17 +24,28 @@ func builtinMkdir(v *grasp.VirtualOS) mounts.ExecFunc {
 			cwd = "/"
 		}
 
+		parents := hasFlag(args, "-p")
 		var out strings.Builder
+		failed := false
 		for _, arg := range args {
 			if arg == "-p" {
 				continue
 			}
 			target := resolvePath(cwd, arg)
 			if err := v.Mkdir(ctx, target, grasp.PermRWX); err != nil {
+				// With -p an existing directory is not an error.
+				if parents && errors.Is(err, grasp.ErrAlreadyMounted) {
+					if e, statErr := v.Stat(ctx, target); statErr == nil && e.IsDir {
+						continue
+					}
+				}
 				fmt.Fprintf(&out, "mkdir: %v\n", err)
-				continue
+				failed = true
 			}
 		}
+		if failed {
+			return exitWith(out.String(), 1), nil
+		}
 		return io.NopCloser(strings.NewReader(out.String())), nil
 	}
 }
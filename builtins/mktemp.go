@@ -0,0 +1,91 @@
+package builtins
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+
+	grasp "github.com/jackfish212/grasp"
+	"github.com/jackfish212/grasp/mounts"
+)
+
+func builtinMktemp(v *grasp.VirtualOS) mounts.ExecFunc {
+	return func(ctx context.Context, args []string, _ io.Reader) (io.ReadCloser, error) {
+		if hasFlag(args, "-h", "--help") {
+			return io.NopCloser(strings.NewReader(`mktemp — create a uniquely named temporary file or directory
+Usage: mktemp [-d] [-p DIR] [TEMPLATE]
+Options:
+  -d       Create a directory instead of a file
+  -p DIR   Create the entry in DIR (default /tmp)
+TEMPLATE must end in at least three X's, which are replaced with random
+characters (default tmp.XXXXXX). A TEMPLATE containing a slash is used
+relative to the working directory unless -p is given. The created path is
+printed.
+`)), nil
+		}
+
+		var (
+			dir, template string
+			makeDir       bool
+		)
+		for i := 0; i < len(args); i++ {
+			switch arg := args[i]; arg {
+			case "-d":
+				makeDir = true
+			case "-p":
+				if i+1 >= len(args) {
+					return nil, fmt.Errorf("mktemp: option requires an argument -- 'p'")
+				}
+				i++
+				dir = args[i]
+			default:
+				if strings.HasPrefix(arg, "-") {
+					return nil, fmt.Errorf("mktemp: invalid option -- '%s'", strings.TrimLeft(arg, "-"))
+				}
+				if template != "" {
+					return nil, fmt.Errorf("mktemp: too many templates")
+				}
+				template = arg
+			}
+		}
+		if template == "" {
+			template = "tmp.XXXXXX"
+		}
+
+		xs := len(template) - len(strings.TrimRight(template, "X"))
+		if xs < 3 {
+			return nil, fmt.Errorf("mktemp: too few X's in template %q", template)
+		}
+
+		cwd := grasp.Env(ctx, "PWD")
+		if cwd == "" {
+			cwd = "/"
+		}
+		var full string
+		switch {
+		case dir != "":
+			full = path.Join(resolvePath(cwd, dir), template)
+		case strings.Contains(template, "/"):
+			full = resolvePath(cwd, template)
+		default:
+			full = path.Join("/tmp", template)
+		}
+		parent, template := path.Dir(full), path.Base(full)
+
+		var (
+			target string
+			err    error
+		)
+		if makeDir {
+			target, err = v.TempDir(ctx, parent, template)
+		} else {
+			target, err = v.TempFile(ctx, parent, template)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("mktemp: %w", err)
+		}
+		return io.NopCloser(strings.NewReader(target + "\n")), nil
+	}
+}
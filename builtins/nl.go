@@ -0,0 +1,186 @@
+package builtins
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	grasp "github.com/jackfish212/grasp"
+	"github.com/jackfish212/grasp/mounts"
+)
+
+// nlStyle decides which lines of a section get a number.
+type nlStyle struct {
+	kind    byte // 'a' all, 't' non-empty, 'n' none, 'p' matching pattern
+	pattern *regexp.Regexp
+}
+
+func (s nlStyle) numbers(line string) bool {
+	switch s.kind {
+	case 'a':
+		return true
+	case 't':
+		return strings.TrimSpace(line) != ""
+	case 'p':
+		return s.pattern.MatchString(line)
+	default:
+		return false
+	}
+}
+
+type nlOpts struct {
+	body, header, footer nlStyle
+	start, incr, width   int
+	sep                  string
+}
+
+func builtinNl(v *grasp.VirtualOS) mounts.ExecFunc {
+	return func(ctx context.Context, args []string, stdin io.Reader) (io.ReadCloser, error) {
+		if hasFlag(args, "--help") {
+			return io.NopCloser(strings.NewReader(`nl — number lines of files
+Usage: nl [OPTION]... [FILE]...
+Options:
+  -b STYLE        Body numbering style (default: t)
+  -h STYLE        Header numbering style (default: n)
+  -f STYLE        Footer numbering style (default: n)
+  -v START        First line number (default: 1)
+  -i INCREMENT    Line number increment (default: 1)
+  -w WIDTH        Line number width (default: 6)
+  -s SEP          Separator after the number (default: TAB)
+Styles:
+  a               Number all lines
+  t               Number non-empty lines only
+  n               Number no lines
+  pREGEX          Number only lines matching REGEX
+Sections are delimited by \:\:\: (header), \:\: (body) and \: (footer) lines.
+`)), nil
+		}
+
+		opts := nlOpts{
+			body:   nlStyle{kind: 't'},
+			header: nlStyle{kind: 'n'},
+			footer: nlStyle{kind: 'n'},
+			start:  1,
+			incr:   1,
+			width:  6,
+			sep:    "\t",
+		}
+		var files []string
+
+		for i := 0; i < len(args); i++ {
+			arg := args[i]
+			switch arg {
+			case "-b", "-h", "-f":
+				if i+1 >= len(args) {
+					return nil, fmt.Errorf("nl: option requires an argument -- '%s'", arg[1:])
+				}
+				i++
+				spec := args[i]
+				if spec == "p" && i+1 < len(args) {
+					i++
+					spec += args[i]
+				}
+				style, err := parseNlStyle(spec)
+				if err != nil {
+					return nil, err
+				}
+				switch arg {
+				case "-b":
+					opts.body = style
+				case "-h":
+					opts.header = style
+				case "-f":
+					opts.footer = style
+				}
+			case "-v", "-i", "-w":
+				if i+1 >= len(args) {
+					return nil, fmt.Errorf("nl: option requires an argument -- '%s'", arg[1:])
+				}
+				i++
+				n, err := strconv.Atoi(args[i])
+				if err != nil {
+					return nil, fmt.Errorf("nl: invalid number: %s", args[i])
+				}
+				switch arg {
+				case "-v":
+					opts.start = n
+				case "-i":
+					opts.incr = n
+				case "-w":
+					if n < 1 {
+						return nil, fmt.Errorf("nl: invalid line number field width: %s", args[i])
+					}
+					opts.width = n
+				}
+			case "-s":
+				if i+1 >= len(args) {
+					return nil, fmt.Errorf("nl: option requires an argument -- 's'")
+				}
+				i++
+				opts.sep = args[i]
+			default:
+				if strings.HasPrefix(arg, "-") && arg != "-" {
+					return nil, fmt.Errorf("nl: invalid option: %s", arg)
+				}
+				files = append(files, arg)
+			}
+		}
+
+		text, err := readInputs(ctx, v, "nl", files, stdin)
+		if err != nil {
+			return nil, err
+		}
+		return io.NopCloser(strings.NewReader(numberLines(text, opts))), nil
+	}
+}
+
+func parseNlStyle(spec string) (nlStyle, error) {
+	switch {
+	case spec == "a" || spec == "t" || spec == "n":
+		return nlStyle{kind: spec[0]}, nil
+	case strings.HasPrefix(spec, "p") && len(spec) > 1:
+		re, err := regexp.Compile(spec[1:])
+		if err != nil {
+			return nlStyle{}, fmt.Errorf("nl: invalid regular expression: %w", err)
+		}
+		return nlStyle{kind: 'p', pattern: re}, nil
+	default:
+		return nlStyle{}, fmt.Errorf("nl: invalid numbering style: '%s'", spec)
+	}
+}
+
+func numberLines(text string, opts nlOpts) string {
+	var buf strings.Builder
+	style := opts.body
+	num := opts.start
+	blank := strings.Repeat(" ", opts.width+len(opts.sep))
+
+	for _, line := range splitLines(text) {
+		switch line {
+		case `\:\:\:`:
+			style = opts.header
+			num = opts.start
+			buf.WriteByte('\n')
+			continue
+		case `\:\:`:
+			style = opts.body
+			buf.WriteByte('\n')
+			continue
+		case `\:`:
+			style = opts.footer
+			buf.WriteByte('\n')
+			continue
+		}
+
+		if style.numbers(line) {
+			fmt.Fprintf(&buf, "%*d%s%s\n", opts.width, num, opts.sep, line)
+			num += opts.incr
+		} else {
+			buf.WriteString(blank + line + "\n")
+		}
+	}
+	return buf.String()
+}
@@ -0,0 +1,165 @@
+package builtins
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	grasp "github.com/jackfish212/grasp"
+	"github.com/jackfish212/grasp/mounts"
+)
+
+func builtinPaste(v *grasp.VirtualOS) mounts.ExecFunc {
+	return func(ctx context.Context, args []string, stdin io.Reader) (io.ReadCloser, error) {
+		if hasFlag(args, "-h", "--help") {
+			return io.NopCloser(strings.NewReader(`paste — merge lines of files
+Usage: paste [OPTION]... [FILE]...
+Options:
+  -d, --delimiters=LIST   Reuse characters from LIST instead of TABs
+  -s, --serial            Paste one file at a time instead of in parallel
+With no FILE, or when FILE is -, read standard input.
+`)), nil
+		}
+
+		delims := []string{"\t"}
+		serial := false
+		var files []string
+
+		for i := 0; i < len(args); i++ {
+			arg := args[i]
+			switch {
+			case arg == "-s" || arg == "--serial":
+				serial = true
+			case arg == "-d" || arg == "--delimiters":
+				if i+1 >= len(args) {
+					return nil, fmt.Errorf("paste: option requires an argument -- 'd'")
+				}
+				i++
+				delims = parsePasteDelims(args[i])
+			case strings.HasPrefix(arg, "--delimiters="):
+				delims = parsePasteDelims(strings.TrimPrefix(arg, "--delimiters="))
+			case strings.HasPrefix(arg, "-d") && len(arg) > 2:
+				delims = parsePasteDelims(arg[2:])
+			case strings.HasPrefix(arg, "-") && arg != "-":
+				return nil, fmt.Errorf("paste: invalid option: %s", arg)
+			default:
+				files = append(files, arg)
+			}
+		}
+		if len(files) == 0 {
+			files = []string{"-"}
+		}
+
+		cwd := grasp.Env(ctx, "PWD")
+		if cwd == "" {
+			cwd = "/"
+		}
+
+		// Every "-" operand draws successive lines from the same stdin.
+		var stdinLines []string
+		stdinPos := 0
+		columns := make([][]string, len(files))
+		isStdin := make([]bool, len(files))
+		for i, file := range files {
+			if file == "-" {
+				if stdin == nil {
+					return nil, fmt.Errorf("paste: no input on standard input")
+				}
+				if stdinLines == nil {
+					data, err := io.ReadAll(stdin)
+					if err != nil {
+						return nil, fmt.Errorf("paste: read error: %w", err)
+					}
+					stdinLines = splitLines(string(data))
+				}
+				isStdin[i] = true
+				continue
+			}
+			data, err := readFile(ctx, v, resolvePath(cwd, file))
+			if err != nil {
+				return nil, fmt.Errorf("paste: %w", err)
+			}
+			columns[i] = splitLines(string(data))
+		}
+
+		var buf strings.Builder
+		if serial {
+			for i := range files {
+				lines := columns[i]
+				if isStdin[i] {
+					lines = stdinLines[stdinPos:]
+					stdinPos = len(stdinLines)
+				}
+				buf.WriteString(joinPaste(lines, delims))
+				buf.WriteByte('\n')
+			}
+			return io.NopCloser(strings.NewReader(buf.String())), nil
+		}
+
+		pos := make([]int, len(files))
+		for {
+			row := make([]string, len(files))
+			more := false
+			for i := range files {
+				if isStdin[i] {
+					if stdinPos < len(stdinLines) {
+						row[i] = stdinLines[stdinPos]
+						stdinPos++
+						more = true
+					}
+					continue
+				}
+				if pos[i] < len(columns[i]) {
+					row[i] = columns[i][pos[i]]
+					pos[i]++
+					more = true
+				}
+			}
+			if !more {
+				break
+			}
+			buf.WriteString(joinPaste(row, delims))
+			buf.WriteByte('\n')
+		}
+		return io.NopCloser(strings.NewReader(buf.String())), nil
+	}
+}
+
+// parsePasteDelims splits a -d LIST into single-character delimiters,
+// honouring the \n, \t, \\ and \0 (empty) escapes.
+func parsePasteDelims(list string) []string {
+	var delims []string
+	for i := 0; i < len(list); i++ {
+		if list[i] == '\\' && i+1 < len(list) {
+			i++
+			switch list[i] {
+			case 'n':
+				delims = append(delims, "\n")
+			case 't':
+				delims = append(delims, "\t")
+			case '0':
+				delims = append(delims, "")
+			default:
+				delims = append(delims, string(list[i]))
+			}
+			continue
+		}
+		delims = append(delims, string(list[i]))
+	}
+	if len(delims) == 0 {
+		delims = []string{""}
+	}
+	return delims
+}
+
+func joinPaste(fields []string, delims []string) string {
+	var buf strings.Builder
+	for i, f := range fields {
+		if i > 0 {
+			buf.WriteString(delims[(i-1)%len(delims)])
+		}
+		buf.WriteString(f)
+	}
+	return buf.String()
+}
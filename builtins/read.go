@@ -1,7 +1,9 @@
 package builtins
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"strings"
@@ -17,11 +19,23 @@ func builtinRead(v *grasp.VirtualOS) mounts.ExecFunc {
 Usage: read <path>
 
 cat — concatenate files and print to stdout
-Usage: cat [FILE]...
+Usage: cat [--raw] [FILE]...
        cat (read from stdin when no file specified)
+Binary files are output unchanged with a note on stderr; --raw omits it.
 `)), nil
 		}
 
+		raw := false
+		var paths []string
+		for _, arg := range args {
+			if arg == "--raw" {
+				raw = true
+				continue
+			}
+			paths = append(paths, arg)
+		}
+		args = paths
+
 		if len(args) == 0 {
 			if stdin == nil {
 				return nil, fmt.Errorf("read: missing path")
@@ -38,7 +52,7 @@ Usage: cat [FILE]...
 			cwd = "/"
 		}
 
-		var results []string
+		var results, binary []string
 		for _, arg := range args {
 			target := resolvePath(cwd, arg)
 			rc, err := v.Open(ctx, target)
@@ -50,8 +64,71 @@ Usage: cat [FILE]...
 			if err != nil {
 				return nil, fmt.Errorf("read: %w", err)
 			}
+			if !raw {
+				var mimeType string
+				if entry, err := v.Stat(ctx, target); err == nil {
+					mimeType = entry.MimeType
+				}
+				if isBinary(mimeType, data) {
+					if mimeType == "" {
+						mimeType = "unknown type"
+					}
+					binary = append(binary, fmt.Sprintf("%s: binary file (%s, %d bytes); use xxd to inspect it", arg, mimeType, len(data)))
+				}
+			}
 			results = append(results, string(data))
 		}
-		return io.NopCloser(strings.NewReader(strings.Join(results, ""))), nil
+		out := strings.Join(results, "")
+		if len(binary) > 0 {
+			// The bytes still go to stdout unchanged, so redirects and
+			// pipes get an exact copy; the note goes to stderr.
+			err := errors.New(strings.Join(binary, "\ncat: "))
+			return io.NopCloser(&exitReader{r: strings.NewReader(out), err: err}), nil
+		}
+		return io.NopCloser(strings.NewReader(out)), nil
 	}
 }
+
+// isBinary reports whether content is not text. A MIME type that is
+// clearly binary (images, audio, video, archives and the like) decides;
+// otherwise, as grep and git do, content with a NUL byte near the start is
+// binary. Unfamiliar types such as application/x-sh are sniffed, since many
+// of them are text.
+func isBinary(mimeType string, data []byte) bool {
+	mediaType, _, _ := strings.Cut(mimeType, ";")
+	mediaType = strings.TrimSpace(strings.ToLower(mediaType))
+	major, _, _ := strings.Cut(mediaType, "/")
+	switch {
+	case major == "image" && mediaType != "image/svg+xml",
+		major == "audio", major == "video", major == "font",
+		binaryMimeTypes[mediaType]:
+		return true
+	}
+	return bytes.IndexByte(data[:min(len(data), 8000)], 0) >= 0
+}
+
+var binaryMimeTypes = map[string]bool{
+	"application/octet-stream":     true,
+	"application/pdf":              true,
+	"application/zip":              true,
+	"application/gzip":             true,
+	"application/x-gzip":           true,
+	"application/x-tar":            true,
+	"application/x-bzip2":          true,
+	"application/x-xz":             true,
+	"application/zstd":             true,
+	"application/x-7z-compressed":  true,
+	"application/x-rar-compressed": true,
+	"application/vnd.rar":          true,
+	"application/wasm":             true,
+	"application/x-executable":     true,
+	"application/x-sharedlib":      true,
+	"application/x-sqlite3":        true,
+	"application/vnd.sqlite3":      true,
+	"application/java-archive":     true,
+	"application/msword":           true,
+	"application/vnd.ms-excel":     true,
+	"application/x-protobuf":       true,
+	"application/vnd.openxmlformats-officedocument.wordprocessingml.document": true,
+	"application/vnd.openxmlformats-officedocument.spreadsheetml.sheet":       true,
+}
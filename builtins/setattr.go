@@ -0,0 +1,42 @@
+package builtins
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	grasp "github.com/jackfish212/grasp"
+	"github.com/jackfish212/grasp/mounts"
+)
+
+func builtinSetattr(v *grasp.VirtualOS) mounts.ExecFunc {
+	return func(ctx context.Context, args []string, _ io.Reader) (io.ReadCloser, error) {
+		if hasFlag(args, "-h", "--help") {
+			return io.NopCloser(strings.NewReader(`setattr — set file metadata attributes
+Usage: setattr FILE KEY=VALUE...
+An empty VALUE (KEY=) removes the attribute.
+`)), nil
+		}
+		if len(args) < 2 {
+			return nil, fmt.Errorf("setattr: missing operand")
+		}
+
+		cwd := grasp.Env(ctx, "PWD")
+		if cwd == "" {
+			cwd = "/"
+		}
+		target := resolvePath(cwd, args[0])
+
+		for _, pair := range args[1:] {
+			key, value, ok := strings.Cut(pair, "=")
+			if !ok || key == "" {
+				return nil, fmt.Errorf("setattr: invalid attribute %q, expected KEY=VALUE", pair)
+			}
+			if err := v.SetMeta(ctx, target, key, value); err != nil {
+				return nil, fmt.Errorf("setattr: %w", err)
+			}
+		}
+		return io.NopCloser(strings.NewReader("")), nil
+	}
+}
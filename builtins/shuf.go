@@ -0,0 +1,164 @@
+package builtins
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"strconv"
+	"strings"
+
+	grasp "github.com/jackfish212/grasp"
+	"github.com/jackfish212/grasp/mounts"
+)
+
+// maxShufRange caps how many numbers -i may produce without -n, since a
+// full permutation has to hold every line in memory.
+const maxShufRange = 1 << 20
+
+func builtinShuf(v *grasp.VirtualOS) mounts.ExecFunc {
+	return func(ctx context.Context, args []string, stdin io.Reader) (io.ReadCloser, error) {
+		if hasFlag(args, "-h", "--help") {
+			return io.NopCloser(strings.NewReader(`shuf — generate random permutations
+Usage: shuf [OPTION]... [FILE]
+       shuf -e [OPTION]... [ARG]...
+       shuf -i LO-HI [OPTION]...
+Options:
+  -e, --echo                Treat each ARG as an input line
+  -i, --input-range=LO-HI   Treat each number LO through HI as an input line
+  -n, --head-count=COUNT    Output at most COUNT lines
+`)), nil
+		}
+
+		echo := false
+		count := -1
+		var rangeSpec string
+		var operands []string
+
+		for i := 0; i < len(args); i++ {
+			arg := args[i]
+			switch {
+			case arg == "-e" || arg == "--echo":
+				echo = true
+			case arg == "-n" || arg == "--head-count":
+				if i+1 >= len(args) {
+					return nil, fmt.Errorf("shuf: option requires an argument -- 'n'")
+				}
+				i++
+				n, err := strconv.Atoi(args[i])
+				if err != nil || n < 0 {
+					return nil, fmt.Errorf("shuf: invalid line count: %s", args[i])
+				}
+				count = n
+			case strings.HasPrefix(arg, "--head-count="):
+				val := strings.TrimPrefix(arg, "--head-count=")
+				n, err := strconv.Atoi(val)
+				if err != nil || n < 0 {
+					return nil, fmt.Errorf("shuf: invalid line count: %s", val)
+				}
+				count = n
+			case arg == "-i" || arg == "--input-range":
+				if i+1 >= len(args) {
+					return nil, fmt.Errorf("shuf: option requires an argument -- 'i'")
+				}
+				i++
+				rangeSpec = args[i]
+			case strings.HasPrefix(arg, "--input-range="):
+				rangeSpec = strings.TrimPrefix(arg, "--input-range=")
+			case strings.HasPrefix(arg, "-") && arg != "-" && !echo:
+				return nil, fmt.Errorf("shuf: invalid option: %s", arg)
+			default:
+				operands = append(operands, arg)
+			}
+		}
+
+		var lines []string
+		switch {
+		case rangeSpec != "":
+			if echo {
+				return nil, fmt.Errorf("shuf: cannot combine -e and -i options")
+			}
+			lo, hi, err := parseShufRange(rangeSpec)
+			if err != nil {
+				return nil, err
+			}
+			if lines, err = sampleShufRange(lo, hi, count); err != nil {
+				return nil, err
+			}
+			return shufOutput(lines), nil
+		case echo:
+			lines = operands
+		default:
+			if len(operands) > 1 {
+				return nil, fmt.Errorf("shuf: extra operand '%s'", operands[1])
+			}
+			if len(operands) == 1 && operands[0] == "-" {
+				operands = nil
+			}
+			text, err := readInputs(ctx, v, "shuf", operands, stdin)
+			if err != nil {
+				return nil, err
+			}
+			lines = splitLines(text)
+		}
+
+		rand.Shuffle(len(lines), func(i, j int) { lines[i], lines[j] = lines[j], lines[i] })
+		if count >= 0 && count < len(lines) {
+			lines = lines[:count]
+		}
+		return shufOutput(lines), nil
+	}
+}
+
+func shufOutput(lines []string) io.ReadCloser {
+	if len(lines) == 0 {
+		return io.NopCloser(strings.NewReader(""))
+	}
+	return io.NopCloser(strings.NewReader(strings.Join(lines, "\n") + "\n"))
+}
+
+// sampleShufRange draws count distinct numbers from lo..hi in random order
+// (all of them when count is negative). It runs a partial Fisher-Yates
+// shuffle over the range without materializing it: swapped records only the
+// positions that no longer hold their own offset, so memory grows with count
+// rather than with the size of the range.
+func sampleShufRange(lo, hi, count int) ([]string, error) {
+	size := hi - lo + 1
+	if count < 0 || count > size {
+		if size > maxShufRange {
+			return nil, fmt.Errorf("shuf: input range too large: %d numbers (limit %d without -n)", size, maxShufRange)
+		}
+		count = size
+	}
+	swapped := make(map[int]int, count)
+	at := func(i int) int {
+		if n, ok := swapped[i]; ok {
+			return n
+		}
+		return i
+	}
+	lines := make([]string, count)
+	for i := range lines {
+		j := i + rand.IntN(size-i)
+		n := at(j)
+		swapped[j] = at(i)
+		lines[i] = strconv.Itoa(lo + n)
+	}
+	return lines, nil
+}
+
+func parseShufRange(spec string) (int, int, error) {
+	loStr, hiStr, ok := strings.Cut(spec, "-")
+	if !ok {
+		return 0, 0, fmt.Errorf("shuf: invalid input range: '%s'", spec)
+	}
+	lo, err := strconv.Atoi(loStr)
+	if err != nil {
+		return 0, 0, fmt.Errorf("shuf: invalid input range: '%s'", spec)
+	}
+	hi, err := strconv.Atoi(hiStr)
+	if err != nil || hi < lo-1 {
+		return 0, 0, fmt.Errorf("shuf: invalid input range: '%s'", spec)
+	}
+	return lo, hi, nil
+}
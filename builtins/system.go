@@ -148,7 +148,7 @@ func builtinTrue(v *grasp.VirtualOS) mounts.ExecFunc {
 // false — return failure exit status
 func builtinFalse(v *grasp.VirtualOS) mounts.ExecFunc {
 	return func(ctx context.Context, args []string, stdin io.Reader) (io.ReadCloser, error) {
-		return nil, fmt.Errorf("false")
+		return nil, &grasp.ExitError{Code: 1}
 	}
 }
 
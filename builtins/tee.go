@@ -0,0 +1,83 @@
+package builtins
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	grasp "github.com/jackfish212/grasp"
+	"github.com/jackfish212/grasp/mounts"
+)
+
+func builtinTee(v *grasp.VirtualOS) mounts.ExecFunc {
+	return func(ctx context.Context, args []string, stdin io.Reader) (io.ReadCloser, error) {
+		if hasFlag(args, "-h", "--help") {
+			return io.NopCloser(strings.NewReader(`tee — copy standard input to files and standard output
+Usage: tee [-a] [FILE]...
+Options:
+  -a, --append    Append to the files instead of overwriting them
+`)), nil
+		}
+
+		appendMode := false
+		var files []string
+		for i, arg := range args {
+			if arg == "--" {
+				files = append(files, args[i+1:]...)
+				break
+			}
+			if arg == "-a" || arg == "--append" {
+				appendMode = true
+				continue
+			}
+			if len(arg) > 1 && arg[0] == '-' {
+				return nil, fmt.Errorf("tee: invalid option: %s", arg)
+			}
+			files = append(files, arg)
+		}
+
+		var data []byte
+		if stdin != nil {
+			var err error
+			if data, err = io.ReadAll(stdin); err != nil {
+				return nil, fmt.Errorf("tee: read error: %w", err)
+			}
+		}
+
+		cwd := grasp.Env(ctx, "PWD")
+		if cwd == "" {
+			cwd = "/"
+		}
+
+		// As in GNU tee, a file that cannot be written does not stop the
+		// others or the copy to stdout; it only makes the exit status 1.
+		var firstErr error
+		for _, f := range files {
+			target := resolvePath(cwd, f)
+			var r io.Reader = bytes.NewReader(data)
+			if appendMode {
+				// Read the old content in full first: Write may truncate
+				// the target before it reads r.
+				old, err := readFile(ctx, v, target)
+				if err != nil && !errors.Is(err, grasp.ErrNotFound) {
+					if firstErr == nil {
+						firstErr = fmt.Errorf("%s: %w", f, err)
+					}
+					continue
+				}
+				r = io.MultiReader(bytes.NewReader(old), r)
+			}
+			if err := v.Write(ctx, target, r); err != nil && firstErr == nil {
+				firstErr = fmt.Errorf("%s: %w", f, err)
+			}
+		}
+
+		if firstErr != nil {
+			return exitWithErr(string(data), firstErr), nil
+		}
+		return io.NopCloser(bytes.NewReader(data)), nil
+	}
+}
@@ -0,0 +1,173 @@
+package builtins
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	grasp "github.com/jackfish212/grasp"
+	"github.com/jackfish212/grasp/mounts"
+)
+
+// builtinTest implements test and its "[" form, which requires a closing
+// "]". As in POSIX, the meaning of the expression depends on how many
+// arguments it has, so a lone "-f" is a non-empty string, not a file test.
+func builtinTest(v *grasp.VirtualOS, name string) mounts.ExecFunc {
+	return func(ctx context.Context, args []string, _ io.Reader) (io.ReadCloser, error) {
+		if name == "[" {
+			if len(args) == 1 && args[0] == "--help" {
+				return io.NopCloser(strings.NewReader(`test — evaluate a conditional expression
+Usage: test EXPRESSION
+       [ EXPRESSION ]
+Expressions:
+  -e FILE, -f FILE, -d FILE   FILE exists; is a regular file; is a directory
+  -r FILE, -w FILE, -x FILE   FILE is readable; writable; executable
+  -s FILE                     FILE exists and is not empty
+  -z STRING, -n STRING        STRING is empty; is not empty
+  STRING                      STRING is not empty
+  S1 = S2, S1 != S2           the strings are equal; differ
+  N1 -eq N2                   integer comparison; also -ne -lt -le -gt -ge
+  ! EXPRESSION                EXPRESSION is false
+Exit status is 0 if EXPRESSION is true, 1 if it is false, 2 on error.
+`)), nil
+			}
+			if len(args) == 0 || args[len(args)-1] != "]" {
+				return nil, &grasp.ExitError{Code: 2, Err: fmt.Errorf("[: missing ']'")}
+			}
+			args = args[:len(args)-1]
+		}
+
+		t := &tester{ctx: ctx, v: v, name: name}
+		ok, err := t.eval(args)
+		if err != nil {
+			return nil, &grasp.ExitError{Code: 2, Err: err}
+		}
+		if !ok {
+			return nil, &grasp.ExitError{Code: 1}
+		}
+		return io.NopCloser(strings.NewReader("")), nil
+	}
+}
+
+// tester evaluates test expressions.
+type tester struct {
+	ctx  context.Context
+	v    *grasp.VirtualOS
+	name string // "test" or "[", for error messages
+}
+
+func (t *tester) eval(args []string) (bool, error) {
+	switch len(args) {
+	case 0:
+		return false, nil
+	case 1:
+		return args[0] != "", nil
+	case 2:
+		if args[0] == "!" {
+			ok, err := t.eval(args[1:])
+			return !ok, err
+		}
+		return t.unary(args[0], args[1])
+	case 3:
+		if isTestBinaryOp(args[1]) {
+			return t.binary(args[0], args[1], args[2])
+		}
+		if args[0] == "!" {
+			ok, err := t.eval(args[1:])
+			return !ok, err
+		}
+		return false, fmt.Errorf("%s: %s: binary operator expected", t.name, args[1])
+	case 4:
+		if args[0] == "!" {
+			ok, err := t.eval(args[1:])
+			return !ok, err
+		}
+	}
+	return false, fmt.Errorf("%s: too many arguments", t.name)
+}
+
+func (t *tester) unary(op, arg string) (bool, error) {
+	switch op {
+	case "-z":
+		return arg == "", nil
+	case "-n":
+		return arg != "", nil
+	case "-e", "-f", "-d", "-r", "-w", "-x", "-s":
+	default:
+		return false, fmt.Errorf("%s: %s: unary operator expected", t.name, op)
+	}
+
+	cwd := grasp.Env(t.ctx, "PWD")
+	if cwd == "" {
+		cwd = "/"
+	}
+	entry, err := t.v.Stat(t.ctx, resolvePath(cwd, arg))
+	if err != nil {
+		return false, nil
+	}
+	switch op {
+	case "-f":
+		return !entry.IsDir, nil
+	case "-d":
+		return entry.IsDir, nil
+	case "-r":
+		return entry.Perm.CanRead(), nil
+	case "-w":
+		return entry.Perm.CanWrite(), nil
+	case "-x":
+		return entry.Perm.CanExec(), nil
+	case "-s":
+		return entry.Size > 0, nil
+	}
+	return true, nil // -e
+}
+
+func isTestBinaryOp(op string) bool {
+	switch op {
+	case "=", "==", "!=", "-eq", "-ne", "-lt", "-le", "-gt", "-ge":
+		return true
+	}
+	return false
+}
+
+func (t *tester) binary(a, op, b string) (bool, error) {
+	switch op {
+	case "=", "==":
+		return a == b, nil
+	case "!=":
+		return a != b, nil
+	}
+
+	x, err := t.integer(a)
+	if err != nil {
+		return false, err
+	}
+	y, err := t.integer(b)
+	if err != nil {
+		return false, err
+	}
+	switch op {
+	case "-eq":
+		return x == y, nil
+	case "-ne":
+		return x != y, nil
+	case "-lt":
+		return x < y, nil
+	case "-le":
+		return x <= y, nil
+	case "-gt":
+		return x > y, nil
+	default: // -ge
+		return x >= y, nil
+	}
+}
+
+func (t *tester) integer(s string) (int64, error) {
+	n, err := strconv.ParseInt(strings.TrimSpace(s), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %s: integer expression expected", t.name, s)
+	}
+	return n, nil
+}
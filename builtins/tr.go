@@ -0,0 +1,231 @@
+package builtins
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode"
+
+	grasp "github.com/jackfish212/grasp"
+	"github.com/jackfish212/grasp/mounts"
+)
+
+func builtinTr(_ *grasp.VirtualOS) mounts.ExecFunc {
+	return func(_ context.Context, args []string, stdin io.Reader) (io.ReadCloser, error) {
+		if hasFlag(args, "-h", "--help") {
+			return io.NopCloser(strings.NewReader(`tr — translate, squeeze or delete characters
+Usage: tr [-c] [-d] [-s] SET1 [SET2]
+Options:
+  -c    Use the complement of SET1
+  -d    Delete characters in SET1
+  -s    Replace each run of a repeated character in the last SET with one
+SETs may contain literal characters, ranges (a-z), escapes (\n, \t, \r,
+\\, \NNN octal) and classes: [:alnum:] [:alpha:] [:blank:] [:cntrl:]
+[:digit:] [:graph:] [:lower:] [:print:] [:punct:] [:space:] [:upper:]
+[:xdigit:]. tr reads standard input only.
+`)), nil
+		}
+
+		var (
+			complement, del, squeeze bool
+			sets                     []string
+		)
+		for _, arg := range args {
+			if len(arg) < 2 || arg[0] != '-' || len(sets) > 0 {
+				sets = append(sets, arg)
+				continue
+			}
+			for _, c := range arg[1:] {
+				switch c {
+				case 'c', 'C':
+					complement = true
+				case 'd':
+					del = true
+				case 's':
+					squeeze = true
+				default:
+					return nil, fmt.Errorf("tr: invalid option -- '%c'", c)
+				}
+			}
+		}
+
+		switch {
+		case len(sets) == 0:
+			return nil, fmt.Errorf("tr: missing operand")
+		case len(sets) > 2:
+			return nil, fmt.Errorf("tr: extra operand %q", sets[2])
+		case del && !squeeze && len(sets) != 1:
+			return nil, fmt.Errorf("tr: extra operand %q; only one set may be given when deleting", sets[1])
+		case del && squeeze && len(sets) != 2:
+			return nil, fmt.Errorf("tr: missing operand after %q; two sets are required when deleting and squeezing", sets[0])
+		case !del && !squeeze && len(sets) != 2:
+			return nil, fmt.Errorf("tr: missing operand after %q", sets[0])
+		}
+
+		set1, err := parseTrSet(sets[0])
+		if err != nil {
+			return nil, err
+		}
+		var set2 []rune
+		if len(sets) == 2 {
+			if set2, err = parseTrSet(sets[1]); err != nil {
+				return nil, err
+			}
+			if len(set2) == 0 && !del {
+				return nil, fmt.Errorf("tr: when translating, SET2 must not be empty")
+			}
+		}
+
+		in1 := make(map[rune]bool, len(set1))
+		for _, r := range set1 {
+			in1[r] = true
+		}
+		inSet1 := func(r rune) bool { return in1[r] != complement }
+
+		// translate maps a rune through SET1 -> SET2. A short SET2 is padded
+		// with its last character, as in GNU tr.
+		mapping := make(map[rune]rune)
+		if !del && len(set2) > 0 && !complement {
+			for i, r := range set1 {
+				mapping[r] = set2[min(i, len(set2)-1)]
+			}
+		}
+		translate := func(r rune) rune {
+			if del || len(set2) == 0 {
+				return r
+			}
+			if complement {
+				if !in1[r] {
+					return set2[len(set2)-1]
+				}
+				return r
+			}
+			if m, ok := mapping[r]; ok {
+				return m
+			}
+			return r
+		}
+
+		// Squeezing applies to the last set given (SET2 when there are two).
+		inSqueeze := inSet1
+		if len(sets) == 2 {
+			in2 := make(map[rune]bool, len(set2))
+			for _, r := range set2 {
+				in2[r] = true
+			}
+			inSqueeze = func(r rune) bool { return in2[r] }
+		}
+
+		var data []byte
+		if stdin != nil {
+			if data, err = io.ReadAll(stdin); err != nil {
+				return nil, fmt.Errorf("tr: %w", err)
+			}
+		}
+
+		var out strings.Builder
+		last, havePrev := rune(0), false
+		for _, r := range string(data) {
+			if del && inSet1(r) {
+				continue
+			}
+			r = translate(r)
+			if squeeze && havePrev && r == last && inSqueeze(r) {
+				continue
+			}
+			out.WriteRune(r)
+			last, havePrev = r, true
+		}
+		return io.NopCloser(strings.NewReader(out.String())), nil
+	}
+}
+
+var trClasses = map[string]func(rune) bool{
+	"alnum":  func(r rune) bool { return unicode.IsLetter(r) || unicode.IsDigit(r) },
+	"alpha":  unicode.IsLetter,
+	"blank":  func(r rune) bool { return r == ' ' || r == '\t' },
+	"cntrl":  unicode.IsControl,
+	"digit":  unicode.IsDigit,
+	"graph":  func(r rune) bool { return unicode.IsGraphic(r) && r != ' ' },
+	"lower":  unicode.IsLower,
+	"print":  unicode.IsPrint,
+	"punct":  unicode.IsPunct,
+	"space":  unicode.IsSpace,
+	"upper":  unicode.IsUpper,
+	"xdigit": func(r rune) bool { return strings.ContainsRune("0123456789abcdefABCDEF", r) },
+}
+
+// parseTrSet expands a tr SET into its characters, in order. Classes cover
+// the ASCII range, so [:lower:] and [:upper:] line up for case mapping.
+func parseTrSet(set string) ([]rune, error) {
+	src := []rune(set)
+	var out []rune
+
+	// next decodes one possibly escaped character at src[i].
+	next := func(i int) (rune, int) {
+		if src[i] != '\\' || i+1 >= len(src) {
+			return src[i], i + 1
+		}
+		i++
+		switch src[i] {
+		case 'n':
+			return '\n', i + 1
+		case 't':
+			return '\t', i + 1
+		case 'r':
+			return '\r', i + 1
+		case 'a':
+			return '\a', i + 1
+		case 'b':
+			return '\b', i + 1
+		case 'f':
+			return '\f', i + 1
+		case 'v':
+			return '\v', i + 1
+		}
+		j := i
+		for j < len(src) && j < i+3 && src[j] >= '0' && src[j] <= '7' {
+			j++
+		}
+		if j > i {
+			n, _ := strconv.ParseUint(string(src[i:j]), 8, 32)
+			return rune(n), j
+		}
+		return src[i], i + 1
+	}
+
+	for i := 0; i < len(src); {
+		if src[i] == '[' && i+1 < len(src) && src[i+1] == ':' {
+			if name, _, ok := strings.Cut(string(src[i+2:]), ":]"); ok {
+				class, ok := trClasses[name]
+				if !ok {
+					return nil, fmt.Errorf("tr: invalid character class %q", name)
+				}
+				for r := rune(0); r < 128; r++ {
+					if class(r) {
+						out = append(out, r)
+					}
+				}
+				i += 2 + len([]rune(name)) + 2
+				continue
+			}
+		}
+		lo, j := next(i)
+		if j+1 < len(src) && src[j] == '-' {
+			hi, k := next(j + 1)
+			if hi < lo {
+				return nil, fmt.Errorf("tr: range-endpoints of '%c-%c' are in reverse collating sequence order", lo, hi)
+			}
+			for r := lo; r <= hi; r++ {
+				out = append(out, r)
+			}
+			i = k
+			continue
+		}
+		out = append(out, lo)
+		i = j
+	}
+	return out, nil
+}
@@ -0,0 +1,98 @@
+package builtins
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	grasp "github.com/jackfish212/grasp"
+	"github.com/jackfish212/grasp/mounts"
+)
+
+func builtinUnzip(v *grasp.VirtualOS) mounts.ExecFunc {
+	return func(ctx context.Context, args []string, _ io.Reader) (io.ReadCloser, error) {
+		if hasFlag(args, "-h", "--help") {
+			return io.NopCloser(strings.NewReader(`unzip — extract a ZIP archive
+Usage: unzip ARCHIVE.zip [-d DEST]
+Options:
+  -d DEST   Extract into DEST instead of the current directory
+`)), nil
+		}
+
+		dest := ""
+		var operands []string
+		for i := 0; i < len(args); i++ {
+			switch {
+			case args[i] == "-d":
+				if i+1 >= len(args) {
+					return nil, fmt.Errorf("unzip: option requires an argument -- 'd'")
+				}
+				i++
+				dest = args[i]
+			case strings.HasPrefix(args[i], "-"):
+				return nil, fmt.Errorf("unzip: invalid option: %s", args[i])
+			default:
+				operands = append(operands, args[i])
+			}
+		}
+		if len(operands) != 1 {
+			return nil, fmt.Errorf("unzip: expected one archive operand")
+		}
+
+		cwd := grasp.Env(ctx, "PWD")
+		if cwd == "" {
+			cwd = "/"
+		}
+		destDir := cwd
+		if dest != "" {
+			destDir = resolvePath(cwd, dest)
+		}
+
+		data, err := readFile(ctx, v, resolvePath(cwd, operands[0]))
+		if err != nil {
+			return nil, fmt.Errorf("unzip: %w", err)
+		}
+		zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			return nil, fmt.Errorf("unzip: %w", err)
+		}
+
+		if err := mkdirAll(ctx, v, destDir); err != nil {
+			return nil, fmt.Errorf("unzip: %w", err)
+		}
+
+		var out strings.Builder
+		for _, zf := range zr.File {
+			target := resolvePath(destDir, zf.Name)
+			if target != destDir && !strings.HasPrefix(target, strings.TrimSuffix(destDir, "/")+"/") {
+				return nil, fmt.Errorf("unzip: %s: path escapes destination", zf.Name)
+			}
+
+			if strings.HasSuffix(zf.Name, "/") {
+				if err := mkdirAll(ctx, v, target); err != nil {
+					return nil, fmt.Errorf("unzip: %w", err)
+				}
+				fmt.Fprintf(&out, "   creating: %s\n", target)
+				continue
+			}
+
+			if err := mkdirAll(ctx, v, parentDir(target)); err != nil {
+				return nil, fmt.Errorf("unzip: %w", err)
+			}
+			rc, err := zf.Open()
+			if err != nil {
+				return nil, fmt.Errorf("unzip: %s: %w", zf.Name, err)
+			}
+			err = v.Write(ctx, target, rc)
+			_ = rc.Close()
+			if err != nil {
+				return nil, fmt.Errorf("unzip: %w", err)
+			}
+			fmt.Fprintf(&out, "  inflating: %s\n", target)
+		}
+		return io.NopCloser(strings.NewReader(out.String())), nil
+	}
+}
@@ -0,0 +1,142 @@
+package builtins
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	grasp "github.com/jackfish212/grasp"
+	"github.com/jackfish212/grasp/mounts"
+)
+
+func builtinXxd(v *grasp.VirtualOS) mounts.ExecFunc {
+	return func(ctx context.Context, args []string, stdin io.Reader) (io.ReadCloser, error) {
+		if hasFlag(args, "-h", "--help") {
+			return io.NopCloser(strings.NewReader(`xxd — make a hex dump
+Usage: xxd [-l LENGTH] [-s OFFSET] [-c COLS] [FILE]
+Options:
+  -l LENGTH   Stop after LENGTH bytes
+  -s OFFSET   Start at byte OFFSET
+  -c COLS     Bytes per line (default 16, max 256)
+Numbers may be given in decimal or with a 0x prefix. With no FILE, or when
+FILE is -, read standard input.
+`)), nil
+		}
+
+		length, offset, cols := int64(-1), int64(0), int64(16)
+		var file string
+		for i := 0; i < len(args); i++ {
+			arg := args[i]
+			var dst *int64
+			switch arg {
+			case "-l":
+				dst = &length
+			case "-s":
+				dst = &offset
+			case "-c":
+				dst = &cols
+			default:
+				if strings.HasPrefix(arg, "-") && arg != "-" {
+					return nil, fmt.Errorf("xxd: invalid option -- '%s'", strings.TrimLeft(arg, "-"))
+				}
+				if file != "" {
+					return nil, fmt.Errorf("xxd: too many arguments")
+				}
+				file = arg
+				continue
+			}
+			if i+1 >= len(args) {
+				return nil, fmt.Errorf("xxd: option requires an argument -- '%s'", arg[1:])
+			}
+			i++
+			n, err := strconv.ParseInt(args[i], 0, 64)
+			if err != nil || n < 0 {
+				return nil, fmt.Errorf("xxd: invalid number %q for %s", args[i], arg)
+			}
+			*dst = n
+		}
+		if cols < 1 || cols > 256 {
+			return nil, fmt.Errorf("xxd: invalid number of columns (max. 256)")
+		}
+
+		var r io.Reader
+		if file == "" || file == "-" {
+			if stdin == nil {
+				return nil, fmt.Errorf("xxd: no input")
+			}
+			r = stdin
+		} else {
+			cwd := grasp.Env(ctx, "PWD")
+			if cwd == "" {
+				cwd = "/"
+			}
+			f, err := v.Open(ctx, resolvePath(cwd, file))
+			if err != nil {
+				return nil, fmt.Errorf("xxd: %w", err)
+			}
+			defer func() { _ = f.Close() }()
+			r = f
+		}
+
+		if offset > 0 {
+			// Seek when the file supports it so large files are not read
+			// from the start.
+			if s, ok := r.(io.Seeker); ok {
+				if _, err := s.Seek(offset, io.SeekStart); err != nil {
+					return nil, fmt.Errorf("xxd: %w", err)
+				}
+			} else if _, err := io.CopyN(io.Discard, r, offset); err != nil && err != io.EOF {
+				return nil, fmt.Errorf("xxd: %w", err)
+			}
+		}
+		if length >= 0 {
+			r = io.LimitReader(r, length)
+		}
+
+		var buf strings.Builder
+		line := make([]byte, cols)
+		pos := offset
+		for {
+			n, err := io.ReadFull(r, line)
+			if n > 0 {
+				writeXxdLine(&buf, pos, line[:n], int(cols))
+				pos += int64(n)
+			}
+			if err == io.EOF || err == io.ErrUnexpectedEOF {
+				break
+			}
+			if err != nil {
+				return nil, fmt.Errorf("xxd: %w", err)
+			}
+		}
+		return io.NopCloser(strings.NewReader(buf.String())), nil
+	}
+}
+
+// writeXxdLine formats one dump line: offset, hex bytes in groups of two,
+// and the printable ASCII rendering. Short lines are padded so the ASCII
+// column stays aligned.
+func writeXxdLine(buf *strings.Builder, pos int64, data []byte, cols int) {
+	fmt.Fprintf(buf, "%08x: ", pos)
+	for i := 0; i < cols; i++ {
+		if i < len(data) {
+			fmt.Fprintf(buf, "%02x", data[i])
+		} else {
+			buf.WriteString("  ")
+		}
+		if i%2 == 1 || i == cols-1 {
+			buf.WriteByte(' ')
+		}
+	}
+	buf.WriteByte(' ')
+	for _, b := range data {
+		if b >= 0x20 && b < 0x7f {
+			buf.WriteByte(b)
+		} else {
+			buf.WriteByte('.')
+		}
+	}
+	buf.WriteByte('\n')
+}
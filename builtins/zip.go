@@ -0,0 +1,108 @@
+package builtins
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+
+	grasp "github.com/jackfish212/grasp"
+	"github.com/jackfish212/grasp/mounts"
+)
+
+func builtinZip(v *grasp.VirtualOS) mounts.ExecFunc {
+	return func(ctx context.Context, args []string, _ io.Reader) (io.ReadCloser, error) {
+		if hasFlag(args, "-h", "--help") {
+			return io.NopCloser(strings.NewReader(`zip — package files into a ZIP archive
+Usage: zip [-r] ARCHIVE.zip FILE...
+Options:
+  -r    Recurse into directories
+`)), nil
+		}
+
+		recursive := false
+		var operands []string
+		for _, arg := range args {
+			switch {
+			case arg == "-r" || arg == "-R":
+				recursive = true
+			case strings.HasPrefix(arg, "-"):
+				return nil, fmt.Errorf("zip: invalid option: %s", arg)
+			default:
+				operands = append(operands, arg)
+			}
+		}
+		if len(operands) < 2 {
+			return nil, fmt.Errorf("zip: missing operand")
+		}
+
+		cwd := grasp.Env(ctx, "PWD")
+		if cwd == "" {
+			cwd = "/"
+		}
+		archive := resolvePath(cwd, operands[0])
+
+		var buf bytes.Buffer
+		var out strings.Builder
+		zw := zip.NewWriter(&buf)
+		for _, file := range operands[1:] {
+			// Member names keep the operand as typed, minus any leading "/" or "./".
+			name := strings.TrimPrefix(path.Clean(strings.TrimPrefix(file, "./")), "/")
+			if err := zipAdd(ctx, v, zw, resolvePath(cwd, file), name, recursive, &out); err != nil {
+				return nil, err
+			}
+		}
+		if err := zw.Close(); err != nil {
+			return nil, fmt.Errorf("zip: %w", err)
+		}
+		if err := v.Write(ctx, archive, &buf); err != nil {
+			return nil, fmt.Errorf("zip: %w", err)
+		}
+		return io.NopCloser(strings.NewReader(out.String())), nil
+	}
+}
+
+func zipAdd(ctx context.Context, v *grasp.VirtualOS, zw *zip.Writer, src, name string, recursive bool, out *strings.Builder) error {
+	entry, err := v.Stat(ctx, src)
+	if err != nil {
+		return fmt.Errorf("zip: %w", err)
+	}
+
+	if entry.IsDir {
+		if !recursive {
+			fmt.Fprintf(out, "zip: %s is a directory (use -r)\n", name)
+			return nil
+		}
+		if _, err := zw.CreateHeader(&zip.FileHeader{Name: name + "/", Modified: entry.Modified}); err != nil {
+			return fmt.Errorf("zip: %w", err)
+		}
+		fmt.Fprintf(out, "  adding: %s/\n", name)
+		children, err := v.List(ctx, src, grasp.ListOpts{})
+		if err != nil {
+			return fmt.Errorf("zip: %w", err)
+		}
+		for _, child := range children {
+			if err := zipAdd(ctx, v, zw, path.Join(src, child.Name), path.Join(name, child.Name), true, out); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	data, err := readFile(ctx, v, src)
+	if err != nil {
+		return fmt.Errorf("zip: %w", err)
+	}
+	w, err := zw.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: entry.Modified})
+	if err != nil {
+		return fmt.Errorf("zip: %w", err)
+	}
+	if _, err := w.Write(data); err != nil {
+		return fmt.Errorf("zip: %w", err)
+	}
+	fmt.Fprintf(out, "  adding: %s\n", name)
+	return nil
+}
@@ -55,7 +55,7 @@ func main() {
 	if err != nil {
 		log.Fatalf("Configure VOS: %v", err)
 	}
-	if err := builtins.RegisterBuiltinsOnFS(v, rootFS); err != nil {
+	if _, err := builtins.RegisterBuiltinsOnFS(v, rootFS); err != nil {
 		log.Fatalf("Register builtins: %v", err)
 	}
 
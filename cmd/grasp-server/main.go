@@ -11,6 +11,8 @@
 //	                        ./dir           LocalFS (host directory)
 //	                        memfs           MemFS (in-memory)
 //	--user  NAME          Shell user name (default: "agent")
+//	--tool-cache DURATION Reuse results of repeated identical tool calls
+//	                      for DURATION (default: off)
 //	--debug               Enable debug logging to stderr
 //	--version             Show version and exit
 //
@@ -48,6 +50,9 @@ func main() {
 	user := flag.String("user", "agent", "Shell user name")
 	showVersion := flag.Bool("version", false, "Show version and exit")
 	debug := flag.Bool("debug", false, "Enable debug logging to stderr")
+	maxConcurrent := flag.Int("max-concurrent", 0, "Maximum tool calls in flight at once (0 = unlimited)")
+	busyTimeout := flag.Duration("busy-timeout", 0, "How long a tool call waits for a free slot before failing (0 = wait)")
+	toolCache := flag.Duration("tool-cache", 0, "Reuse results of repeated identical tool calls for this long (0 = off)")
 	flag.Var(&mntFlags, "mount", "Mount specification PATH:SOURCE (repeatable)")
 	flag.Parse()
 
@@ -69,7 +74,7 @@ func main() {
 		slog.Error("failed to configure VirtualOS", "error", err)
 		os.Exit(1)
 	}
-	if err := builtins.RegisterBuiltinsOnFS(v, rootFS); err != nil {
+	if _, err := builtins.RegisterBuiltinsOnFS(v, rootFS); err != nil {
 		slog.Error("failed to register builtins", "error", err)
 		os.Exit(1)
 	}
@@ -85,7 +90,12 @@ func main() {
 	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
 	defer cancel()
 
-	srv := mcpserver.New(v, *user)
+	srv := mcpserver.New(v, *user,
+		mcpserver.WithMCPServerMaxConcurrent(*maxConcurrent),
+		mcpserver.WithMCPServerBusyTimeout(*busyTimeout),
+		mcpserver.WithMCPServerToolCache(*toolCache),
+	)
+	slog.SetDefault(slog.New(srv.LogHandler(slog.Default().Handler())))
 	if err := srv.Run(ctx, os.Stdin, os.Stdout); err != nil {
 		slog.Error("server error", "error", err)
 		os.Exit(1)
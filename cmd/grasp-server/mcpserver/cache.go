@@ -0,0 +1,126 @@
+package mcpserver
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"maps"
+	"sync"
+	"time"
+
+	grasp "github.com/jackfish212/grasp"
+)
+
+// WithMCPServerToolCache caches tools/call results for ttl, so a client that
+// repeats the same call (say "ls /feeds" on every turn) is answered without
+// running the command again. Calls are identical when they name the same
+// tool with the same arguments from the same working directory. Only
+// successful calls that leave the shell's working directory and environment
+// unchanged and that change nothing in the VFS are cached, and any VFS
+// change event (a write, mkdir, remove or rename, from a tool call or from
+// elsewhere) empties the cache. Changes a provider makes without emitting
+// an event are only seen once the entry expires. ttl <= 0 disables the
+// cache.
+func WithMCPServerToolCache(ttl time.Duration) Option {
+	return func(s *Server) {
+		if s.cache != nil {
+			s.cache.changes.Close()
+		}
+		if ttl > 0 {
+			s.cache = &toolCache{
+				ttl:     ttl,
+				changes: s.vos.Watch("/", grasp.EventAll),
+				entries: make(map[[32]byte]cachedResult),
+			}
+		} else {
+			s.cache = nil
+		}
+	}
+}
+
+// toolCache holds recent tools/call results keyed by toolCacheKey.
+type toolCache struct {
+	ttl     time.Duration
+	changes *grasp.Watcher // every VFS event; any one invalidates the cache
+
+	mu      sync.Mutex
+	entries map[[32]byte]cachedResult
+}
+
+// invalidate drains the events emitted since it last ran and, if any of
+// them is for a path other than ignore, drops every entry. It reports
+// whether the VFS changed. Events are queued as they are emitted, so those
+// of a command that has returned are already waiting. ignore is the shell's
+// history file, which every command rewrites.
+func (c *toolCache) invalidate(ignore string) bool {
+	changed := false
+	for {
+		select {
+		case ev := <-c.changes.Events():
+			if ev.Path != ignore || ev.OldPath != "" {
+				changed = true
+			}
+		default:
+			if changed {
+				c.mu.Lock()
+				clear(c.entries)
+				c.mu.Unlock()
+			}
+			return changed
+		}
+	}
+}
+
+type cachedResult struct {
+	result  toolsCallResult
+	expires time.Time
+}
+
+// toolCacheKey hashes a call's tool name, working directory and JSON
+// encoded arguments. encoding/json sorts map keys, so argument order does
+// not matter.
+func toolCacheKey(tool, cwd string, args map[string]any) ([32]byte, bool) {
+	data, err := json.Marshal(struct {
+		Tool string         `json:"tool"`
+		Cwd  string         `json:"cwd"`
+		Args map[string]any `json:"args"`
+	}{tool, cwd, args})
+	if err != nil {
+		return [32]byte{}, false
+	}
+	return sha256.Sum256(data), true
+}
+
+func (c *toolCache) get(key [32]byte) (toolsCallResult, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[key]
+	if !ok {
+		return toolsCallResult{}, false
+	}
+	if time.Now().After(e.expires) {
+		delete(c.entries, key)
+		return toolsCallResult{}, false
+	}
+	return e.result, true
+}
+
+// put stores a result, first dropping expired entries so the cache holds
+// only calls made within the last ttl.
+func (c *toolCache) put(key [32]byte, result toolsCallResult) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := time.Now()
+	maps.DeleteFunc(c.entries, func(_ [32]byte, e cachedResult) bool {
+		return now.After(e.expires)
+	})
+	c.entries[key] = cachedResult{result: result, expires: now.Add(c.ttl)}
+}
+
+// sameShellState reports whether a command left the shell environment, and
+// with it the working directory in $PWD, as it found it. $PIPESTATUS, which
+// every command rewrites, is ignored. Both maps are modified.
+func sameShellState(before, after map[string]string) bool {
+	delete(before, "PIPESTATUS")
+	delete(after, "PIPESTATUS")
+	return maps.Equal(before, after)
+}
@@ -0,0 +1,144 @@
+package mcpserver
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+)
+
+// mcpLevels maps MCP (RFC 5424) log level names to slog levels.
+var mcpLevels = map[string]slog.Level{
+	"debug":     slog.LevelDebug,
+	"info":      slog.LevelInfo,
+	"notice":    slog.LevelInfo,
+	"warning":   slog.LevelWarn,
+	"error":     slog.LevelError,
+	"critical":  slog.LevelError,
+	"alert":     slog.LevelError,
+	"emergency": slog.LevelError,
+}
+
+func mcpLevelName(l slog.Level) string {
+	switch {
+	case l >= slog.LevelError:
+		return "error"
+	case l >= slog.LevelWarn:
+		return "warning"
+	case l >= slog.LevelInfo:
+		return "info"
+	default:
+		return "debug"
+	}
+}
+
+// LogHandler wraps next so that, once the client has called
+// logging/setLevel, records at or above the requested level are also sent
+// to the client as notifications/message. Install it as the default logger
+// to forward server logs:
+//
+//	slog.SetDefault(slog.New(srv.LogHandler(slog.Default().Handler())))
+func (s *Server) LogHandler(next slog.Handler) slog.Handler {
+	return &clientLogHandler{s: s, next: next}
+}
+
+func (s *Server) handleSetLevel(req *jsonRPCRequest) *jsonRPCResponse {
+	var params setLevelParams
+	if req.Params != nil {
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return &jsonRPCResponse{
+				JSONRPC: "2.0",
+				ID:      req.ID,
+				Error:   &jsonRPCError{Code: errCodeInvalidParams, Message: "Invalid params: " + err.Error()},
+			}
+		}
+	}
+	level, ok := mcpLevels[strings.ToLower(params.Level)]
+	if !ok {
+		return &jsonRPCResponse{
+			JSONRPC: "2.0",
+			ID:      req.ID,
+			Error:   &jsonRPCError{Code: errCodeInvalidParams, Message: "Invalid log level: " + params.Level},
+		}
+	}
+	s.clientLevel.Set(level)
+	s.forwardLogs.Store(true)
+	slog.Debug("client log level set", "level", params.Level)
+	return &jsonRPCResponse{JSONRPC: "2.0", ID: req.ID, Result: map[string]any{}}
+}
+
+// forwards reports whether records at level go to the client.
+func (s *Server) forwards(level slog.Level) bool {
+	return s.forwardLogs.Load() && level >= s.clientLevel.Level()
+}
+
+// clientLogHandler passes records to next and forwards them to the MCP
+// client according to the level it requested.
+type clientLogHandler struct {
+	s      *Server
+	next   slog.Handler
+	attrs  []slog.Attr
+	groups []string
+}
+
+func (h *clientLogHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level) || h.s.forwards(level)
+}
+
+func (h *clientLogHandler) Handle(ctx context.Context, r slog.Record) error {
+	var err error
+	if h.next.Enabled(ctx, r.Level) {
+		err = h.next.Handle(ctx, r)
+	}
+	if !h.s.forwards(r.Level) {
+		return err
+	}
+
+	// Attributes from WithAttrs already carry their group prefix.
+	data := map[string]any{"message": r.Message}
+	for _, a := range h.attrs {
+		data[a.Key] = a.Value.Resolve().Any()
+	}
+	prefix := strings.Join(h.groups, ".")
+	r.Attrs(func(a slog.Attr) bool {
+		key := a.Key
+		if prefix != "" {
+			key = prefix + "." + key
+		}
+		data[key] = a.Value.Resolve().Any()
+		return true
+	})
+	for k, v := range data {
+		if e, ok := v.(error); ok {
+			data[k] = e.Error()
+		}
+	}
+
+	h.s.notify("notifications/message", logMessageParams{
+		Level:  mcpLevelName(r.Level),
+		Logger: "grasp-server",
+		Data:   data,
+	})
+	return err
+}
+
+func (h *clientLogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	cp := *h
+	cp.next = h.next.WithAttrs(attrs)
+	prefix := strings.Join(h.groups, ".")
+	cp.attrs = append([]slog.Attr(nil), h.attrs...)
+	for _, a := range attrs {
+		if prefix != "" {
+			a.Key = prefix + "." + a.Key
+		}
+		cp.attrs = append(cp.attrs, a)
+	}
+	return &cp
+}
+
+func (h *clientLogHandler) WithGroup(name string) slog.Handler {
+	cp := *h
+	cp.next = h.next.WithGroup(name)
+	cp.groups = append(append([]string(nil), h.groups...), name)
+	return &cp
+}
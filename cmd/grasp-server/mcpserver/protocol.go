@@ -20,6 +20,12 @@ type jsonRPCResponse struct {
 	Error   *jsonRPCError   `json:"error,omitempty"`
 }
 
+type jsonRPCNotification struct {
+	JSONRPC string `json:"jsonrpc"`
+	Method  string `json:"method"`
+	Params  any    `json:"params,omitempty"`
+}
+
 type jsonRPCError struct {
 	Code    int    `json:"code"`
 	Message string `json:"message"`
@@ -33,6 +39,7 @@ const (
 	errCodeMethodNotFound = -32601
 	errCodeInvalidParams  = -32602
 	errCodeInternal       = -32603
+	errCodeServerBusy     = -32000 // implementation-defined server error range
 )
 
 // ─── MCP Initialize ───
@@ -58,13 +65,28 @@ type serverInfo struct {
 }
 
 type serverCapabilities struct {
-	Tools *toolsCapability `json:"tools,omitempty"`
+	Tools   *toolsCapability   `json:"tools,omitempty"`
+	Logging *loggingCapability `json:"logging,omitempty"`
 }
 
 type toolsCapability struct {
 	ListChanged bool `json:"listChanged,omitempty"`
 }
 
+type loggingCapability struct{}
+
+// ─── MCP Logging ───
+
+type setLevelParams struct {
+	Level string `json:"level"`
+}
+
+type logMessageParams struct {
+	Level  string         `json:"level"`
+	Logger string         `json:"logger,omitempty"`
+	Data   map[string]any `json:"data"`
+}
+
 // ─── MCP Tools ───
 
 type toolsListResult struct {
@@ -8,6 +8,9 @@ import (
 	"io"
 	"log/slog"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	grasp "github.com/jackfish212/grasp"
 	"github.com/jackfish212/grasp/shell"
@@ -20,24 +23,102 @@ type Server struct {
 	vos   *grasp.VirtualOS
 	shell *shell.Shell
 	info  grasp.VersionInfo
+
+	sem         chan struct{} // nil means unlimited
+	busyTimeout time.Duration
+
+	cache *toolCache // nil when tool results are not cached
+
+	outMu sync.Mutex
+	enc   *json.Encoder // set while Run is active
+
+	clientLevel slog.LevelVar // minimum level forwarded to the client
+	forwardLogs atomic.Bool   // set once the client calls logging/setLevel
+}
+
+// Option configures a Server.
+type Option func(*Server)
+
+// WithMCPServerMaxConcurrent limits the number of tool calls in flight at
+// once to n. Tool calls are handled concurrently, but they share the
+// session's shell, so their commands run one at a time in the order they
+// arrived and the rest queue behind. A call that would exceed n waits for a
+// free slot (see WithMCPServerBusyTimeout). n <= 0 means unlimited.
+func WithMCPServerMaxConcurrent(n int) Option {
+	return func(s *Server) {
+		if n > 0 {
+			s.sem = make(chan struct{}, n)
+		} else {
+			s.sem = nil
+		}
+	}
+}
+
+// WithMCPServerBusyTimeout sets how long a tool call waits for a free slot
+// before failing with a "server busy" error. Zero waits until the request
+// context is cancelled. Only meaningful with WithMCPServerMaxConcurrent.
+func WithMCPServerBusyTimeout(d time.Duration) Option {
+	return func(s *Server) { s.busyTimeout = d }
 }
 
 // New creates an MCP server bound to the given VirtualOS.
 // The user parameter sets the shell's $USER and determines $HOME.
-func New(vos *grasp.VirtualOS, user string) *Server {
-	return &Server{
+func New(vos *grasp.VirtualOS, user string, opts ...Option) *Server {
+	s := &Server{
 		vos:   vos,
 		shell: vos.Shell(user),
 		info:  grasp.GetVersionInfo(),
 	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// acquire reserves an execution slot, waiting up to the busy timeout.
+// The returned release func must be called when the command finishes.
+func (s *Server) acquire(ctx context.Context) (release func(), err error) {
+	if s.sem == nil {
+		return func() {}, nil
+	}
+	if s.busyTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.busyTimeout)
+		defer cancel()
+	}
+	select {
+	case s.sem <- struct{}{}:
+		return func() { <-s.sem }, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
 }
 
 // Run starts the MCP server, reading JSON-RPC messages from in and writing
-// responses to out. It blocks until in is closed or ctx is cancelled.
+// responses to out. It blocks until in is closed or ctx is cancelled and
+// every tool call in progress has been answered. Tool calls are handled in
+// their own goroutines, so a slow command does not hold up pings or other
+// requests, and responses may arrive out of order. The commands themselves
+// still run one after another, in the order the calls arrived, because
+// they share one shell.
 func (s *Server) Run(ctx context.Context, in io.Reader, out io.Writer) error {
 	scanner := bufio.NewScanner(in)
 	scanner.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
-	enc := json.NewEncoder(out)
+	s.outMu.Lock()
+	s.enc = json.NewEncoder(out)
+	s.outMu.Unlock()
+	defer func() {
+		s.outMu.Lock()
+		s.enc = nil
+		s.outMu.Unlock()
+	}()
+
+	var calls sync.WaitGroup
+	defer calls.Wait()
+	// Each tool call waits for the previous one's turn to end before it
+	// touches the shell; turn is closed when the last call so far is done.
+	turn := make(chan struct{})
+	close(turn)
 
 	slog.Info("grasp-server started", "version", s.info.Version)
 
@@ -62,17 +143,34 @@ func (s *Server) Run(ctx context.Context, in io.Reader, out io.Writer) error {
 				ID:      nil,
 				Error:   &jsonRPCError{Code: errCodeParse, Message: "Parse error"},
 			}
-			if err := enc.Encode(resp); err != nil {
+			if err := s.send(resp); err != nil {
 				return fmt.Errorf("write error: %w", err)
 			}
 			continue
 		}
 
-		resp := s.dispatch(ctx, &req)
+		if req.Method == "tools/call" {
+			prev, done := turn, make(chan struct{})
+			turn = done
+			calls.Add(1)
+			go func() {
+				defer calls.Done()
+				defer func() {
+					<-prev
+					close(done)
+				}()
+				if err := s.send(s.handleToolsCall(ctx, &req, prev)); err != nil {
+					slog.Warn("write error", "error", err)
+				}
+			}()
+			continue
+		}
+
+		resp := s.dispatch(&req)
 		if resp == nil {
 			continue
 		}
-		if err := enc.Encode(resp); err != nil {
+		if err := s.send(resp); err != nil {
 			return fmt.Errorf("write error: %w", err)
 		}
 	}
@@ -85,7 +183,9 @@ func (s *Server) Run(ctx context.Context, in io.Reader, out io.Writer) error {
 	return nil
 }
 
-func (s *Server) dispatch(ctx context.Context, req *jsonRPCRequest) *jsonRPCResponse {
+// dispatch handles every request except tools/call, which Run handles
+// concurrently.
+func (s *Server) dispatch(req *jsonRPCRequest) *jsonRPCResponse {
 	switch req.Method {
 	case "initialize":
 		return s.handleInitialize(req)
@@ -93,10 +193,10 @@ func (s *Server) dispatch(ctx context.Context, req *jsonRPCRequest) *jsonRPCResp
 		return nil
 	case "tools/list":
 		return s.handleToolsList(req)
-	case "tools/call":
-		return s.handleToolsCall(ctx, req)
 	case "ping":
 		return &jsonRPCResponse{JSONRPC: "2.0", ID: req.ID, Result: map[string]any{}}
+	case "logging/setLevel":
+		return s.handleSetLevel(req)
 	default:
 		slog.Debug("unknown method", "method", req.Method)
 		if req.ID != nil {
@@ -130,7 +230,7 @@ func (s *Server) handleInitialize(req *jsonRPCRequest) *jsonRPCResponse {
 		ID:      req.ID,
 		Result: initializeResult{
 			ProtocolVersion: protocolVersion,
-			Capabilities:    serverCapabilities{Tools: &toolsCapability{}},
+			Capabilities:    serverCapabilities{Tools: &toolsCapability{}, Logging: &loggingCapability{}},
 			ServerInfo:      serverInfo{Name: "grasp", Version: s.info.Version},
 		},
 	}
@@ -161,7 +261,10 @@ func (s *Server) handleToolsList(req *jsonRPCRequest) *jsonRPCResponse {
 	}
 }
 
-func (s *Server) handleToolsCall(ctx context.Context, req *jsonRPCRequest) *jsonRPCResponse {
+// handleToolsCall runs a tool call once a slot is free and turn is closed,
+// that is, once the calls that arrived before it have finished with the
+// shell.
+func (s *Server) handleToolsCall(ctx context.Context, req *jsonRPCRequest, turn <-chan struct{}) *jsonRPCResponse {
 	var params toolsCallParams
 	if err := json.Unmarshal(req.Params, &params); err != nil {
 		return &jsonRPCResponse{
@@ -188,7 +291,47 @@ func (s *Server) handleToolsCall(ctx context.Context, req *jsonRPCRequest) *json
 		}
 	}
 
+	release, err := s.acquire(ctx)
+	if err != nil {
+		slog.Warn("tool call rejected: server busy", "command", command, "error", err)
+		return &jsonRPCResponse{
+			JSONRPC: "2.0",
+			ID:      req.ID,
+			Error:   &jsonRPCError{Code: errCodeServerBusy, Message: "Server busy: too many concurrent commands"},
+		}
+	}
+	defer release()
+
+	select {
+	case <-turn:
+	case <-ctx.Done():
+		return &jsonRPCResponse{
+			JSONRPC: "2.0",
+			ID:      req.ID,
+			Error:   &jsonRPCError{Code: errCodeInternal, Message: ctx.Err().Error()},
+		}
+	}
+
+	var (
+		cacheKey  [32]byte
+		cacheable bool
+		envBefore map[string]string
+	)
+	if s.cache != nil {
+		cacheKey, cacheable = toolCacheKey(params.Name, s.shell.Cwd(), params.Arguments)
+	}
+	if cacheable {
+		s.cache.invalidate(s.shell.HistoryFile())
+		if cached, ok := s.cache.get(cacheKey); ok {
+			slog.Debug("tool cache hit", "command", command)
+			return &jsonRPCResponse{JSONRPC: "2.0", ID: req.ID, Result: cached}
+		}
+	}
+
 	slog.Debug("executing", "command", command)
+	if cacheable {
+		envBefore = s.shell.Env.All()
+	}
 	result := s.shell.Execute(ctx, command)
 
 	output := result.Output
@@ -199,15 +342,37 @@ func (s *Server) handleToolsCall(ctx context.Context, req *jsonRPCRequest) *json
 		output += fmt.Sprintf("[exit code: %d]", result.Code)
 	}
 
+	callResult := toolsCallResult{Content: []contentBlock{{Type: "text", Text: output}}}
+	// invalidate runs even when the result is not stored, so a command that
+	// changed the VFS also drops the results it made stale.
+	if cacheable && !s.cache.invalidate(s.shell.HistoryFile()) && result.Code == 0 && sameShellState(envBefore, s.shell.Env.All()) {
+		s.cache.put(cacheKey, callResult)
+	}
 	return &jsonRPCResponse{
 		JSONRPC: "2.0",
 		ID:      req.ID,
-		Result:  toolsCallResult{Content: []contentBlock{{Type: "text", Text: output}}},
+		Result:  callResult,
 	}
 }
 
 // ─── Helpers ───
 
+// send writes a message to the client. Responses and notifications may be
+// sent from different goroutines, so writes are serialised.
+func (s *Server) send(msg any) error {
+	s.outMu.Lock()
+	defer s.outMu.Unlock()
+	if s.enc == nil {
+		return nil
+	}
+	return s.enc.Encode(msg)
+}
+
+// notify sends a JSON-RPC notification, dropping it if the client is gone.
+func (s *Server) notify(method string, params any) {
+	_ = s.send(jsonRPCNotification{JSONRPC: "2.0", Method: method, Params: params})
+}
+
 func (s *Server) buildToolDescription() string {
 	var b strings.Builder
 	b.WriteString("Execute a shell command in the grasp virtual filesystem. ")
@@ -4,8 +4,11 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"io"
+	"log/slog"
 	"strings"
 	"testing"
+	"time"
 
 	grasp "github.com/jackfish212/grasp"
 	"github.com/jackfish212/grasp/builtins"
@@ -19,7 +22,7 @@ func setupTestServer(t *testing.T) *Server {
 	if err != nil {
 		t.Fatalf("Configure: %v", err)
 	}
-	if err := builtins.RegisterBuiltinsOnFS(v, rootFS); err != nil {
+	if _, err := builtins.RegisterBuiltinsOnFS(v, rootFS); err != nil {
 		t.Fatalf("RegisterBuiltinsOnFS: %v", err)
 	}
 
@@ -322,3 +325,275 @@ func mustJSON(v any) json.RawMessage {
 	b, _ := json.Marshal(v)
 	return b
 }
+
+func TestToolsCallMaxConcurrentBusy(t *testing.T) {
+	srv := setupTestServer(t)
+	WithMCPServerMaxConcurrent(1)(srv)
+	WithMCPServerBusyTimeout(20 * time.Millisecond)(srv)
+
+	inR, inW := io.Pipe()
+	outR, outW := io.Pipe()
+	done := make(chan error, 1)
+	go func() {
+		done <- srv.Run(context.Background(), inR, outW)
+		_ = outW.Close()
+	}()
+	responses := make(chan jsonRPCResponse)
+	go func() {
+		dec := json.NewDecoder(outR)
+		for {
+			var resp jsonRPCResponse
+			if err := dec.Decode(&resp); err != nil {
+				close(responses)
+				return
+			}
+			if resp.ID != nil {
+				responses <- resp
+			}
+		}
+	}()
+	send := func(id int, method string, params any) {
+		t.Helper()
+		line, _ := json.Marshal(jsonRPCRequest{JSONRPC: "2.0", ID: mustJSON(id), Method: method, Params: mustJSON(params)})
+		if _, err := inW.Write(append(line, '\n')); err != nil {
+			t.Fatal(err)
+		}
+	}
+	shellCall := func(command string) map[string]any {
+		return map[string]any{"name": "shell", "arguments": map[string]any{"command": command}}
+	}
+
+	send(1, "tools/call", shellCall("sleep 0.3"))
+	for deadline := time.Now().Add(time.Second); len(srv.sem) == 0; time.Sleep(time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatal("first call never took the slot")
+		}
+	}
+
+	// The slot is taken: an overlapping call is turned away after the busy
+	// timeout, and other requests are still answered meanwhile.
+	send(2, "tools/call", shellCall("pwd"))
+	send(3, "ping", nil)
+	var order []string
+	for range 3 {
+		resp := <-responses
+		order = append(order, string(resp.ID))
+		switch string(resp.ID) {
+		case "1":
+			if resp.Error != nil {
+				t.Errorf("long call failed: %v", resp.Error.Message)
+			}
+		case "2":
+			if resp.Error == nil || resp.Error.Code != errCodeServerBusy {
+				t.Errorf("overlapping call: error = %+v, want server busy", resp.Error)
+			}
+		}
+	}
+	if order[2] != "1" {
+		t.Errorf("responses arrived in order %v, want the long call last", order)
+	}
+
+	// Once the slot is free, calls go through again.
+	send(4, "tools/call", shellCall("pwd"))
+	if resp := <-responses; resp.Error != nil {
+		t.Errorf("call after release failed: %v", resp.Error.Message)
+	}
+	_ = inW.Close()
+	if err := <-done; err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+}
+
+func TestToolsCallConcurrentSharedShell(t *testing.T) {
+	srv := setupTestServer(t)
+	WithMCPServerMaxConcurrent(4)(srv)
+
+	// Overlapping calls all run, one at a time against the shared shell,
+	// and Run answers every one before it returns.
+	var input bytes.Buffer
+	for i := range 8 {
+		line, _ := json.Marshal(jsonRPCRequest{JSONRPC: "2.0", ID: mustJSON(i), Method: "tools/call",
+			Params: mustJSON(map[string]any{"name": "shell", "arguments": map[string]any{"command": "echo x >> /data/log.txt"}})})
+		input.Write(append(line, '\n'))
+	}
+	var out bytes.Buffer
+	if err := srv.Run(context.Background(), &input, &out); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if n := strings.Count(out.String(), `"id":`); n != 8 {
+		t.Errorf("got %d responses, want 8:\n%s", n, out.String())
+	}
+	if got := callShell(t, srv, 9, "cat /data/log.txt"); got != strings.Repeat("x\n", 8) {
+		t.Errorf("log after concurrent appends = %q", got)
+	}
+}
+
+// callShell runs command through tools/call and returns the text result.
+func callShell(t *testing.T, srv *Server, id int, command string) string {
+	t.Helper()
+	resp := roundTrip(t, srv, "tools/call", id, map[string]any{
+		"name":      "shell",
+		"arguments": map[string]any{"command": command},
+	})
+	if resp.Error != nil {
+		t.Fatalf("%s: unexpected error: %v", command, resp.Error.Message)
+	}
+	b, _ := json.Marshal(resp.Result)
+	var result toolsCallResult
+	if err := json.Unmarshal(b, &result); err != nil {
+		t.Fatalf("unmarshal result: %v", err)
+	}
+	return result.Content[0].Text
+}
+
+// mountQuiet mounts a MemFS at /quiet whose file a.txt can be changed with
+// AddFile, which emits no VFS event, to tell cached results from fresh ones.
+func mountQuiet(t *testing.T, srv *Server) *mounts.MemFS {
+	t.Helper()
+	mem := mounts.NewMemFS(grasp.PermRW)
+	mem.AddFile("a.txt", []byte("one\n"), grasp.PermRW)
+	if err := srv.vos.Mount("/quiet", mem); err != nil {
+		t.Fatalf("Mount: %v", err)
+	}
+	return mem
+}
+
+func TestToolsCallCache(t *testing.T) {
+	srv := setupTestServer(t)
+	WithMCPServerToolCache(time.Minute)(srv)
+	ctx := context.Background()
+	quiet := mountQuiet(t, srv)
+
+	if got := callShell(t, srv, 1, "cat /quiet/a.txt"); got != "one\n" {
+		t.Fatalf("first call = %q", got)
+	}
+	quiet.AddFile("a.txt", []byte("two\n"), grasp.PermRW)
+	if got := callShell(t, srv, 2, "cat /quiet/a.txt"); got != "one\n" {
+		t.Errorf("repeated call = %q, want the cached result", got)
+	}
+	if got := callShell(t, srv, 3, "cat  /quiet/a.txt"); got != "two\n" {
+		t.Errorf("call with different args = %q, want a fresh result", got)
+	}
+
+	// The working directory is part of the key, and cd itself is never
+	// cached because it changes the shell's state.
+	callShell(t, srv, 4, "cd /data")
+	if got := callShell(t, srv, 5, "cat hello.txt"); got != "Hello, grasp!\n" {
+		t.Errorf("cat in /data = %q", got)
+	}
+	callShell(t, srv, 6, "cd /")
+	callShell(t, srv, 7, "cd /data")
+	if got := callShell(t, srv, 8, "pwd"); got != "/data\n" {
+		t.Errorf("pwd after repeated cd = %q, want /data", got)
+	}
+
+	// Failures are not cached.
+	if got := callShell(t, srv, 9, "cat /data/new.txt"); !strings.Contains(got, "[exit code:") {
+		t.Fatalf("cat of missing file = %q", got)
+	}
+	if err := srv.vos.Write(ctx, "/data/new.txt", strings.NewReader("new\n")); err != nil {
+		t.Fatal(err)
+	}
+	if got := callShell(t, srv, 10, "cat /data/new.txt"); got != "new\n" {
+		t.Errorf("call after failure = %q, want a fresh result", got)
+	}
+}
+
+func TestToolsCallCacheInvalidate(t *testing.T) {
+	srv := setupTestServer(t)
+	WithMCPServerToolCache(time.Minute)(srv)
+
+	// A write by another tool call makes the next read fresh.
+	callShell(t, srv, 1, "cat /data/hello.txt")
+	callShell(t, srv, 2, "echo new > /data/hello.txt")
+	if got := callShell(t, srv, 3, "cat /data/hello.txt"); got != "new\n" {
+		t.Errorf("cat after echo > = %q, want the new content", got)
+	}
+
+	// So does a write made directly through the VirtualOS.
+	if err := srv.vos.Write(context.Background(), "/data/hello.txt", strings.NewReader("changed\n")); err != nil {
+		t.Fatal(err)
+	}
+	if got := callShell(t, srv, 4, "cat /data/hello.txt"); got != "changed\n" {
+		t.Errorf("cat after vos.Write = %q, want the new content", got)
+	}
+
+	// A call that changes the VFS is never answered from the cache.
+	callShell(t, srv, 5, "echo x >> /data/log.txt")
+	callShell(t, srv, 6, "echo x >> /data/log.txt")
+	if got := callShell(t, srv, 7, "cat /data/log.txt"); got != "x\nx\n" {
+		t.Errorf("log after two appends = %q, want both lines", got)
+	}
+}
+
+func TestToolsCallCacheExpires(t *testing.T) {
+	srv := setupTestServer(t)
+	WithMCPServerToolCache(20 * time.Millisecond)(srv)
+	quiet := mountQuiet(t, srv)
+
+	callShell(t, srv, 1, "cat /quiet/a.txt")
+	quiet.AddFile("a.txt", []byte("two\n"), grasp.PermRW)
+	time.Sleep(40 * time.Millisecond)
+	if got := callShell(t, srv, 2, "cat /quiet/a.txt"); got != "two\n" {
+		t.Errorf("call after ttl = %q, want a fresh result", got)
+	}
+}
+
+func TestLoggingSetLevel(t *testing.T) {
+	srv := setupTestServer(t)
+	prev := slog.Default()
+	slog.SetDefault(slog.New(srv.LogHandler(slog.NewTextHandler(io.Discard, &slog.HandlerOptions{Level: slog.LevelError}))))
+	defer slog.SetDefault(prev)
+
+	if resp := roundTrip(t, srv, "logging/setLevel", 1, map[string]any{"level": "verbose"}); resp.Error == nil || resp.Error.Code != errCodeInvalidParams {
+		t.Errorf("invalid level: error = %+v, want invalid params", resp.Error)
+	}
+
+	var input bytes.Buffer
+	for _, req := range []jsonRPCRequest{
+		{JSONRPC: "2.0", ID: mustJSON(2), Method: "logging/setLevel", Params: mustJSON(map[string]any{"level": "debug"})},
+		{JSONRPC: "2.0", ID: mustJSON(3), Method: "tools/call", Params: mustJSON(map[string]any{"name": "shell", "arguments": map[string]any{"command": "pwd"}})},
+	} {
+		line, _ := json.Marshal(req)
+		input.Write(line)
+		input.WriteByte('\n')
+	}
+	var out bytes.Buffer
+	if err := srv.Run(context.Background(), &input, &out); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+
+	var sawExecuting bool
+	dec := json.NewDecoder(&out)
+	for {
+		var msg struct {
+			ID     json.RawMessage  `json:"id"`
+			Method string           `json:"method"`
+			Params logMessageParams `json:"params"`
+		}
+		if err := dec.Decode(&msg); err != nil {
+			break
+		}
+		if msg.Method != "notifications/message" {
+			continue
+		}
+		if msg.ID != nil {
+			t.Errorf("notification carries an id: %s", msg.ID)
+		}
+		if msg.Params.Data["message"] == "executing" {
+			sawExecuting = true
+			if msg.Params.Level != "debug" || msg.Params.Data["command"] != "pwd" {
+				t.Errorf("executing notification = %+v", msg.Params)
+			}
+		}
+	}
+	if !sawExecuting {
+		t.Errorf("debug log not forwarded to client:\n%s", out.String())
+	}
+
+	// Raising the level stops debug records from being forwarded.
+	roundTrip(t, srv, "logging/setLevel", 4, map[string]any{"level": "error"})
+	if srv.forwards(slog.LevelDebug) || !srv.forwards(slog.LevelError) {
+		t.Error("forwarding should follow the client's level")
+	}
+}
@@ -0,0 +1,145 @@
+package grasp
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+)
+
+// Copy copies the file at src to dst. The two may be on different mounts:
+// the content is streamed from src's provider with Open and written to
+// dst's with Write, so the destination decides the new file's permissions.
+// If dst is an existing directory the copy is made inside it under src's
+// name, as cp does. Copying a directory fails with ErrIsDir; use CopyDir.
+//
+//	err := v.Copy(ctx, "/feeds/rss/latest.xml", "/db/archive/")
+func (v *VirtualOS) Copy(ctx context.Context, src, dst string) error {
+	src, dst = CleanPath(src), CleanPath(dst)
+	e, err := v.Stat(ctx, src)
+	if err != nil {
+		return err
+	}
+	if e.IsDir {
+		return fmt.Errorf("%w: %s", ErrIsDir, src)
+	}
+	if d, err := v.Stat(ctx, dst); err == nil && d.IsDir {
+		dst = path.Join(dst, path.Base(src))
+	}
+	if dst == src {
+		return fmt.Errorf("%w: copy %s onto itself", ErrNotSupported, src)
+	}
+	return v.copyFile(ctx, src, dst)
+}
+
+// CopyDir copies the directory tree at src to dst, creating dst and any
+// missing parents and keeping the layout below it. Mounts beneath src are
+// copied like any other directory, so one call can gather files served by
+// several providers. Symbolic links are recreated as links rather than
+// followed, which needs a destination provider that implements Linker.
+// Entries already at dst are overwritten; on error, those copied so far
+// are left in place.
+//
+//	err := v.CopyDir(ctx, "/feeds", "/db/feeds")
+func (v *VirtualOS) CopyDir(ctx context.Context, src, dst string) error {
+	src, dst = CleanPath(src), CleanPath(dst)
+	if dst == src || strings.HasPrefix(dst, strings.TrimSuffix(src, "/")+"/") {
+		return fmt.Errorf("%w: copy %s into itself", ErrNotSupported, src)
+	}
+	e, err := v.Stat(ctx, src)
+	if err != nil {
+		return err
+	}
+	if !e.IsDir {
+		return fmt.Errorf("%w: %s", ErrNotDir, src)
+	}
+
+	return v.Walk(ctx, src, func(p string, e Entry, err error) error {
+		if err != nil {
+			return err
+		}
+		target := path.Join(dst, strings.TrimPrefix(p, src))
+		switch {
+		case e.IsSymlink:
+			link, err := v.ReadLink(ctx, p)
+			if err == nil {
+				err = v.Symlink(ctx, link, target)
+			}
+			if err != nil {
+				return fmt.Errorf("copy %s: %w", p, err)
+			}
+		case e.IsDir:
+			if err := v.mkdirAll(ctx, target); err != nil {
+				return fmt.Errorf("copy %s: %w", p, err)
+			}
+		default:
+			if err := v.copyFile(ctx, p, target); err != nil {
+				return fmt.Errorf("copy %s: %w", p, err)
+			}
+		}
+		return nil
+	})
+}
+
+func (v *VirtualOS) copyFile(ctx context.Context, src, dst string) error {
+	f, err := v.Open(ctx, src)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = f.Close() }()
+	return v.Write(ctx, dst, f)
+}
+
+// renameAcross moves oldPath to newPath on a different provider by copying
+// it and then removing the original. The copy is written under a temporary
+// name beside newPath and renamed into place, so newPath never holds a
+// partial copy; if any step of the copy fails the temporary entry is
+// removed and oldPath is left untouched. oldPath is removed only once
+// newPath is complete. pOld must be Mutable.
+func (v *VirtualOS) renameAcross(ctx context.Context, pOld Provider, oldPath, innerOld string, pNew Provider, newPath, innerNew string) error {
+	mNew, ok := pNew.(Mutable)
+	if !ok {
+		return fmt.Errorf("%w: %s (provider is not mutable)", ErrNotSupported, newPath)
+	}
+	if innerOld == "" || innerNew == "" {
+		return fmt.Errorf("%w: cannot move a mount point (%s → %s)", ErrNotSupported, oldPath, newPath)
+	}
+	if len(v.mounts.ChildMounts(oldPath)) > 0 {
+		return fmt.Errorf("%w: %s has mounts beneath it", ErrNotSupported, oldPath)
+	}
+	e, err := pOld.Stat(ctx, innerOld)
+	if err != nil {
+		return err
+	}
+	if !e.Perm.CanWrite() {
+		return fmt.Errorf("%w: %s", ErrNotWritable, oldPath)
+	}
+
+	dir, base := path.Split(newPath)
+	pattern := "." + base + ".XXXXXX"
+	var tmp string
+	if e.IsDir {
+		if tmp, err = v.TempDir(ctx, dir, pattern); err == nil {
+			err = v.CopyDir(ctx, oldPath, tmp)
+		}
+	} else {
+		if tmp, err = v.TempFile(ctx, dir, pattern); err == nil {
+			err = v.copyFile(ctx, oldPath, tmp)
+		}
+	}
+	if err == nil {
+		err = mNew.Rename(ctx, path.Join(path.Dir(innerNew), path.Base(tmp)), innerNew)
+	}
+	if err != nil {
+		if tmp != "" {
+			_ = v.Remove(ctx, tmp)
+		}
+		return fmt.Errorf("move %s to %s: %w", oldPath, newPath, err)
+	}
+
+	if err := pOld.(Mutable).Remove(ctx, innerOld); err != nil {
+		return fmt.Errorf("move %s to %s: copied, but removing the original failed: %w", oldPath, newPath, err)
+	}
+	v.hub.emitRename(EventRename, newPath, oldPath)
+	return nil
+}
@@ -11,8 +11,10 @@ package dbfs
 
 import (
 	"bytes"
+	"compress/gzip"
 	"context"
 	"database/sql"
+	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -32,6 +34,9 @@ var (
 	_ types.Writable          = (*FS)(nil)
 	_ types.Mutable           = (*FS)(nil)
 	_ types.MountInfoProvider = (*FS)(nil)
+	_ types.MetaProvider      = (*FS)(nil)
+	_ types.PagedLister       = (*FS)(nil)
+	_ types.CompressProvider  = (*FS)(nil)
 )
 
 // ErrBadTable indicates an invalid table name was provided.
@@ -161,7 +166,7 @@ func (fs *FS) MountInfo() (string, string) { return "dbfs", fs.dsn }
 
 // ──── types.Provider ────
 
-func (fs *FS) Stat(_ context.Context, path string) (*types.Entry, error) {
+func (fs *FS) Stat(ctx context.Context, path string) (*types.Entry, error) {
 	path = normPath(path)
 
 	var entry types.Entry
@@ -171,7 +176,7 @@ func (fs *FS) Stat(_ context.Context, path string) (*types.Entry, error) {
 	var isDir bool
 	var metaStr sql.NullString
 
-	err := fs.db.QueryRow(
+	err := fs.db.QueryRowContext(ctx,
 		fs.q(`SELECT path, is_dir, perm, modified, version, meta FROM {t} WHERE path = ?`), path,
 	).Scan(&entry.Path, &isDir, &permInt, &modified, &version, &metaStr)
 
@@ -181,7 +186,7 @@ func (fs *FS) Stat(_ context.Context, path string) (*types.Entry, error) {
 			like = "%"
 		}
 		var n int
-		if e := fs.db.QueryRow(fs.q(`SELECT COUNT(*) FROM {t} WHERE path LIKE ?`), like).Scan(&n); e == nil && n > 0 {
+		if e := fs.db.QueryRowContext(ctx, fs.q(`SELECT COUNT(*) FROM {t} WHERE path LIKE ?`), like).Scan(&n); e == nil && n > 0 {
 			return &types.Entry{Name: baseName(path), Path: path, IsDir: true, Perm: types.PermRX}, nil
 		}
 		if path == "" {
@@ -204,22 +209,22 @@ func (fs *FS) Stat(_ context.Context, path string) (*types.Entry, error) {
 	entry.Meta["version"] = strconv.FormatInt(version, 10)
 
 	if !isDir {
-		if err := fs.db.QueryRow(fs.q(`SELECT LENGTH(content) FROM {t} WHERE path = ?`), path).Scan(&entry.Size); err != nil {
+		if err := fs.db.QueryRowContext(ctx, fs.q(`SELECT LENGTH(content) FROM {t} WHERE path = ?`), path).Scan(&entry.Size); err != nil {
 			return nil, fmt.Errorf("dbfs: stat: %w", err)
 		}
 	}
 	return &entry, nil
 }
 
-func (fs *FS) List(_ context.Context, path string, _ types.ListOpts) ([]types.Entry, error) {
+func (fs *FS) List(ctx context.Context, path string, _ types.ListOpts) ([]types.Entry, error) {
 	path = normPath(path)
 
 	var rows *sql.Rows
 	var err error
 	if path == "" {
-		rows, err = fs.db.Query(fs.q(`SELECT path FROM {t} ORDER BY path`))
+		rows, err = fs.db.QueryContext(ctx, fs.q(`SELECT path FROM {t} ORDER BY path`))
 	} else {
-		rows, err = fs.db.Query(fs.q(`SELECT path FROM {t} WHERE path LIKE ? ORDER BY path`), path+"/%")
+		rows, err = fs.db.QueryContext(ctx, fs.q(`SELECT path FROM {t} WHERE path LIKE ? ORDER BY path`), path+"/%")
 	}
 	if err != nil {
 		return nil, fmt.Errorf("dbfs: list: %w", err)
@@ -269,7 +274,7 @@ func (fs *FS) List(_ context.Context, path string, _ types.ListOpts) ([]types.En
 		full := pfx + name
 		if implicit {
 			entries = append(entries, types.Entry{Name: name, Path: full, IsDir: true, Perm: types.PermRX})
-		} else if e, err := fs.Stat(context.Background(), full); err == nil {
+		} else if e, err := fs.Stat(ctx, full); err == nil {
 			entries = append(entries, *e)
 		}
 	}
@@ -280,9 +285,124 @@ func (fs *FS) List(_ context.Context, path string, _ types.ListOpts) ([]types.En
 	return entries, nil
 }
 
+// ──── types.PagedLister ────
+
+// ListPage implements [types.PagedLister] with keyset pagination: each
+// query reads rows after the cursor in path order, so a page costs the
+// same however deep into the directory it starts. Entries come in path
+// order, which differs from name order only when a name is a prefix of a
+// sibling directory ("a-b" sorts before the directory "a"). The cursor is
+// the path of the last row consumed.
+func (fs *FS) ListPage(ctx context.Context, path string, opts types.ListOpts) (*types.ListResult, error) {
+	path = normPath(path)
+	pfx := path + "/"
+	if path == "" {
+		pfx = ""
+	}
+	after := pfx
+	if opts.Cursor != "" {
+		b, err := base64.RawURLEncoding.DecodeString(opts.Cursor)
+		if err != nil || !strings.HasPrefix(string(b), pfx) {
+			return nil, fmt.Errorf("dbfs: invalid list cursor %q", opts.Cursor)
+		}
+		after = string(b)
+	}
+	batch := opts.Limit + 1
+	if opts.Limit <= 0 {
+		batch = 256
+	}
+
+	res := &types.ListResult{}
+	seen := make(map[string]bool)
+	last, hasRows := after, false
+	for {
+		paths, err := fs.pathsAfter(ctx, pfx, last, batch)
+		if err != nil {
+			return nil, fmt.Errorf("dbfs: list: %w", err)
+		}
+		for _, cp := range paths {
+			hasRows = true
+			rest, ok := strings.CutPrefix(cp, pfx)
+			if !ok || rest == "" {
+				last = cp
+				continue
+			}
+			name, implicit := rest, false
+			if i := strings.IndexByte(rest, '/'); i >= 0 {
+				name, implicit = rest[:i], true
+			}
+			if seen[name] {
+				last = cp
+				continue
+			}
+			// A directory whose rows straddle the cursor was returned by an
+			// earlier page.
+			if implicit && opts.Cursor != "" {
+				listed, err := fs.hasRowsUpTo(ctx, pfx+name, after)
+				if err != nil {
+					return nil, fmt.Errorf("dbfs: list: %w", err)
+				}
+				if listed {
+					seen[name] = true
+					last = cp
+					continue
+				}
+			}
+			if opts.Limit > 0 && len(res.Entries) == opts.Limit {
+				res.NextCursor = base64.RawURLEncoding.EncodeToString([]byte(last))
+				return res, nil
+			}
+			seen[name] = true
+			last = cp
+			if implicit {
+				res.Entries = append(res.Entries, types.Entry{Name: name, Path: pfx + name, IsDir: true, Perm: types.PermRX})
+			} else if e, err := fs.Stat(ctx, cp); err == nil {
+				res.Entries = append(res.Entries, *e)
+			}
+		}
+		if len(paths) < batch {
+			break
+		}
+	}
+
+	if path != "" && opts.Cursor == "" && !hasRows {
+		return nil, fmt.Errorf("%w: %s", types.ErrNotFound, path)
+	}
+	return res, nil
+}
+
+// pathsAfter returns up to limit stored paths under pfx that sort after
+// after, in path order.
+func (fs *FS) pathsAfter(ctx context.Context, pfx, after string, limit int) ([]string, error) {
+	rows, err := fs.db.QueryContext(ctx,
+		fs.q(`SELECT path FROM {t} WHERE path LIKE ? AND path > ? ORDER BY path LIMIT ?`), pfx+"%", after, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var paths []string
+	for rows.Next() {
+		var p string
+		if err := rows.Scan(&p); err != nil {
+			return nil, err
+		}
+		paths = append(paths, p)
+	}
+	return paths, rows.Err()
+}
+
+// hasRowsUpTo reports whether p or anything below it is stored at a path
+// that sorts no later than upTo.
+func (fs *FS) hasRowsUpTo(ctx context.Context, p, upTo string) (bool, error) {
+	var n int
+	err := fs.db.QueryRowContext(ctx,
+		fs.q(`SELECT COUNT(*) FROM {t} WHERE (path = ? OR path LIKE ?) AND path <= ?`), p, p+"/%", upTo).Scan(&n)
+	return n > 0, err
+}
+
 // ──── types.Readable ────
 
-func (fs *FS) Open(_ context.Context, path string) (types.File, error) {
+func (fs *FS) Open(ctx context.Context, path string) (types.File, error) {
 	path = normPath(path)
 
 	var content []byte
@@ -291,7 +411,7 @@ func (fs *FS) Open(_ context.Context, path string) (types.File, error) {
 	var modified, version int64
 	var metaStr sql.NullString
 
-	err := fs.db.QueryRow(
+	err := fs.db.QueryRowContext(ctx,
 		fs.q(`SELECT content, is_dir, perm, modified, version, meta FROM {t} WHERE path = ?`), path,
 	).Scan(&content, &isDir, &permInt, &modified, &version, &metaStr)
 	if err == sql.ErrNoRows {
@@ -322,7 +442,7 @@ func (fs *FS) Open(_ context.Context, path string) (types.File, error) {
 
 // ──── types.Writable ────
 
-func (fs *FS) Write(_ context.Context, path string, r io.Reader) error {
+func (fs *FS) Write(ctx context.Context, path string, r io.Reader) error {
 	if !fs.perm.CanWrite() {
 		return fmt.Errorf("%w: %s", types.ErrNotWritable, path)
 	}
@@ -331,7 +451,7 @@ func (fs *FS) Write(_ context.Context, path string, r io.Reader) error {
 		return fmt.Errorf("dbfs: read content: %w", err)
 	}
 	path = normPath(path)
-	_, err = fs.db.Exec(fs.q(`
+	_, err = fs.db.ExecContext(ctx, fs.q(`
 		INSERT INTO {t} (path, content, is_dir, perm, modified, version) VALUES (?, ?, ?, ?, ?, 1)
 		ON CONFLICT(path) DO UPDATE SET content=excluded.content, is_dir=excluded.is_dir,
 			perm=excluded.perm, modified=excluded.modified, version={t}.version+1
@@ -344,12 +464,12 @@ func (fs *FS) Write(_ context.Context, path string, r io.Reader) error {
 
 // ──── types.Mutable ────
 
-func (fs *FS) Mkdir(_ context.Context, path string, perm types.Perm) error {
+func (fs *FS) Mkdir(ctx context.Context, path string, perm types.Perm) error {
 	if !fs.perm.CanWrite() {
 		return fmt.Errorf("%w: %s", types.ErrNotWritable, path)
 	}
 	path = normPath(path)
-	_, err := fs.db.Exec(
+	_, err := fs.db.ExecContext(ctx,
 		fs.q(`INSERT INTO {t} (path, content, is_dir, perm, modified) VALUES (?, NULL, ?, ?, ?) ON CONFLICT(path) DO NOTHING`),
 		path, true, int(perm), time.Now().Unix(),
 	)
@@ -359,19 +479,19 @@ func (fs *FS) Mkdir(_ context.Context, path string, perm types.Perm) error {
 	return nil
 }
 
-func (fs *FS) Remove(_ context.Context, path string) error {
+func (fs *FS) Remove(ctx context.Context, path string) error {
 	if !fs.perm.CanWrite() {
 		return fmt.Errorf("%w: %s", types.ErrNotWritable, path)
 	}
 	path = normPath(path)
 
 	var exists bool
-	if err := fs.db.QueryRow(fs.q(`SELECT EXISTS(SELECT 1 FROM {t} WHERE path = ?)`), path).Scan(&exists); err != nil {
+	if err := fs.db.QueryRowContext(ctx, fs.q(`SELECT EXISTS(SELECT 1 FROM {t} WHERE path = ?)`), path).Scan(&exists); err != nil {
 		return fmt.Errorf("dbfs: remove: %w", err)
 	}
 	if !exists {
 		var n int
-		if err := fs.db.QueryRow(fs.q(`SELECT COUNT(*) FROM {t} WHERE path LIKE ?`), path+"/%").Scan(&n); err != nil {
+		if err := fs.db.QueryRowContext(ctx, fs.q(`SELECT COUNT(*) FROM {t} WHERE path LIKE ?`), path+"/%").Scan(&n); err != nil {
 			return fmt.Errorf("dbfs: remove: %w", err)
 		}
 		if n == 0 {
@@ -379,11 +499,11 @@ func (fs *FS) Remove(_ context.Context, path string) error {
 		}
 	}
 
-	_, err := fs.db.Exec(fs.q(`DELETE FROM {t} WHERE path = ? OR path LIKE ?`), path, path+"/%")
+	_, err := fs.db.ExecContext(ctx, fs.q(`DELETE FROM {t} WHERE path = ? OR path LIKE ?`), path, path+"/%")
 	return err
 }
 
-func (fs *FS) Rename(_ context.Context, oldPath, newPath string) error {
+func (fs *FS) Rename(ctx context.Context, oldPath, newPath string) error {
 	if !fs.perm.CanWrite() {
 		return fmt.Errorf("%w: %s", types.ErrNotWritable, oldPath)
 	}
@@ -391,24 +511,24 @@ func (fs *FS) Rename(_ context.Context, oldPath, newPath string) error {
 	newPath = normPath(newPath)
 
 	var exists bool
-	if err := fs.db.QueryRow(fs.q(`SELECT EXISTS(SELECT 1 FROM {t} WHERE path = ?)`), oldPath).Scan(&exists); err != nil || !exists {
+	if err := fs.db.QueryRowContext(ctx, fs.q(`SELECT EXISTS(SELECT 1 FROM {t} WHERE path = ?)`), oldPath).Scan(&exists); err != nil || !exists {
 		return fmt.Errorf("%w: %s", types.ErrNotFound, oldPath)
 	}
 
-	tx, err := fs.db.Begin()
+	tx, err := fs.db.BeginTx(ctx, nil)
 	if err != nil {
 		return fmt.Errorf("dbfs: rename: %w", err)
 	}
 	defer func() { _ = tx.Rollback() }()
 
 	now := time.Now().Unix()
-	if _, err := tx.Exec(fs.q(`UPDATE {t} SET path = ?, modified = ? WHERE path = ?`), newPath, now, oldPath); err != nil {
+	if _, err := tx.ExecContext(ctx, fs.q(`UPDATE {t} SET path = ?, modified = ? WHERE path = ?`), newPath, now, oldPath); err != nil {
 		return fmt.Errorf("dbfs: rename: %w", err)
 	}
 
 	oldPfx := oldPath + "/"
 	newPfx := newPath + "/"
-	if _, err := tx.Exec(
+	if _, err := tx.ExecContext(ctx,
 		fs.q(`UPDATE {t} SET path = ? || SUBSTR(path, ?), modified = ? WHERE path LIKE ?`),
 		newPfx, len(oldPfx)+1, now, oldPfx+"%",
 	); err != nil {
@@ -418,16 +538,106 @@ func (fs *FS) Rename(_ context.Context, oldPath, newPath string) error {
 	return tx.Commit()
 }
 
+// ──── types.CompressProvider ────
+
+// Compress replaces path with path.gz holding its gzip-compressed content.
+// Neither SQLite nor PostgreSQL can gzip in SQL, so the content is
+// compressed here, but the row is rewritten in place inside one
+// transaction: the file is never missing or duplicated, and it keeps its
+// permissions and metadata.
+func (fs *FS) Compress(ctx context.Context, path string) error {
+	path = normPath(path)
+	return fs.recode(ctx, "compress", path, path+".gz", func(data []byte) ([]byte, error) {
+		var buf bytes.Buffer
+		zw := gzip.NewWriter(&buf)
+		if _, err := zw.Write(data); err != nil {
+			return nil, err
+		}
+		if err := zw.Close(); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	})
+}
+
+// Decompress replaces path, which must end in .gz or .tgz, with its
+// decompressed content under [types.DecompressedName]. See Compress.
+func (fs *FS) Decompress(ctx context.Context, path string) error {
+	path = normPath(path)
+	dst, ok := types.DecompressedName(path)
+	if !ok {
+		return fmt.Errorf("dbfs: decompress: %s: unknown suffix", path)
+	}
+	return fs.recode(ctx, "decompress", path, dst, func(data []byte) ([]byte, error) {
+		zr, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		return io.ReadAll(zr)
+	})
+}
+
+// recode moves the file row at src to dst with its content passed through
+// transform, replacing any file already at dst.
+func (fs *FS) recode(ctx context.Context, op, src, dst string, transform func([]byte) ([]byte, error)) error {
+	if !fs.perm.CanWrite() {
+		return fmt.Errorf("%w: %s", types.ErrNotWritable, src)
+	}
+
+	tx, err := fs.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("dbfs: %s: %w", op, err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var content []byte
+	var isDir bool
+	err = tx.QueryRowContext(ctx, fs.q(`SELECT content, is_dir FROM {t} WHERE path = ?`), src).Scan(&content, &isDir)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("%w: %s", types.ErrNotFound, src)
+	}
+	if err != nil {
+		return fmt.Errorf("dbfs: %s: %w", op, err)
+	}
+	if isDir {
+		return fmt.Errorf("%w: %s", types.ErrIsDir, src)
+	}
+
+	var dstIsDir bool
+	err = tx.QueryRowContext(ctx, fs.q(`SELECT is_dir FROM {t} WHERE path = ?`), dst).Scan(&dstIsDir)
+	if err != nil && err != sql.ErrNoRows {
+		return fmt.Errorf("dbfs: %s: %w", op, err)
+	}
+	if dstIsDir {
+		return fmt.Errorf("%w: %s", types.ErrIsDir, dst)
+	}
+
+	out, err := transform(content)
+	if err != nil {
+		return fmt.Errorf("dbfs: %s: %s: %w", op, src, err)
+	}
+	if _, err := tx.ExecContext(ctx, fs.q(`DELETE FROM {t} WHERE path = ?`), dst); err != nil {
+		return fmt.Errorf("dbfs: %s: %w", op, err)
+	}
+	if _, err := tx.ExecContext(ctx,
+		fs.q(`UPDATE {t} SET path = ?, content = ?, modified = ?, version = version+1 WHERE path = ?`),
+		dst, out, time.Now().Unix(), src,
+	); err != nil {
+		return fmt.Errorf("dbfs: %s: %w", op, err)
+	}
+	return tx.Commit()
+}
+
 // ──── Extended API ────
 
 // WriteFile writes content with metadata in a single operation.
 // The version column is automatically incremented on each write.
-func (fs *FS) WriteFile(_ context.Context, path string, content []byte, meta map[string]string) error {
+func (fs *FS) WriteFile(ctx context.Context, path string, content []byte, meta map[string]string) error {
 	if !fs.perm.CanWrite() {
 		return fmt.Errorf("%w: %s", types.ErrNotWritable, path)
 	}
 	path = normPath(path)
-	_, err := fs.db.Exec(fs.q(`
+	_, err := fs.db.ExecContext(ctx, fs.q(`
 		INSERT INTO {t} (path, content, is_dir, perm, modified, version, meta) VALUES (?, ?, ?, ?, ?, 1, ?)
 		ON CONFLICT(path) DO UPDATE SET content=excluded.content, is_dir=excluded.is_dir,
 			perm=excluded.perm, modified=excluded.modified, version={t}.version+1, meta=excluded.meta
@@ -439,12 +649,12 @@ func (fs *FS) WriteFile(_ context.Context, path string, content []byte, meta map
 }
 
 // WriteMeta updates only the metadata without touching content or version.
-func (fs *FS) WriteMeta(_ context.Context, path string, meta map[string]string) error {
+func (fs *FS) WriteMeta(ctx context.Context, path string, meta map[string]string) error {
 	if !fs.perm.CanWrite() {
 		return fmt.Errorf("%w: %s", types.ErrNotWritable, path)
 	}
 	path = normPath(path)
-	res, err := fs.db.Exec(fs.q(`UPDATE {t} SET meta = ? WHERE path = ?`), encodeMeta(meta), path)
+	res, err := fs.db.ExecContext(ctx, fs.q(`UPDATE {t} SET meta = ? WHERE path = ?`), encodeMeta(meta), path)
 	if err != nil {
 		return fmt.Errorf("dbfs: write meta: %w", err)
 	}
@@ -454,9 +664,65 @@ func (fs *FS) WriteMeta(_ context.Context, path string, meta map[string]string)
 	return nil
 }
 
+// SetMeta sets a single metadata key, leaving other keys untouched.
+// An empty value removes the key.
+func (fs *FS) SetMeta(ctx context.Context, path, key, value string) error {
+	if !fs.perm.CanWrite() {
+		return fmt.Errorf("%w: %s", types.ErrNotWritable, path)
+	}
+	path = normPath(path)
+
+	tx, err := fs.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("dbfs: set meta: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var metaStr sql.NullString
+	err = tx.QueryRowContext(ctx, fs.q(`SELECT meta FROM {t} WHERE path = ?`), path).Scan(&metaStr)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("%w: %s", types.ErrNotFound, path)
+	}
+	if err != nil {
+		return fmt.Errorf("dbfs: set meta: %w", err)
+	}
+
+	meta := decodeMeta(metaStr)
+	if meta == nil {
+		meta = make(map[string]string)
+	}
+	if value == "" {
+		delete(meta, key)
+	} else {
+		meta[key] = value
+	}
+	if _, err := tx.ExecContext(ctx, fs.q(`UPDATE {t} SET meta = ? WHERE path = ?`), encodeMeta(meta), path); err != nil {
+		return fmt.Errorf("dbfs: set meta: %w", err)
+	}
+	return tx.Commit()
+}
+
+// GetMeta returns a single metadata key.
+func (fs *FS) GetMeta(ctx context.Context, path, key string) (string, error) {
+	path = normPath(path)
+	var metaStr sql.NullString
+	err := fs.db.QueryRowContext(ctx, fs.q(`SELECT meta FROM {t} WHERE path = ?`), path).Scan(&metaStr)
+	if err == sql.ErrNoRows {
+		return "", fmt.Errorf("%w: %s", types.ErrNotFound, path)
+	}
+	if err != nil {
+		return "", fmt.Errorf("dbfs: get meta: %w", err)
+	}
+	value, ok := decodeMeta(metaStr)[key]
+	if !ok {
+		return "", fmt.Errorf("%w: %s: meta key %q", types.ErrNotFound, path, key)
+	}
+	return value, nil
+}
+
 // Purge deletes non-directory files older than the given duration.
-func (fs *FS) Purge(_ context.Context, olderThan time.Duration) (int64, error) {
-	res, err := fs.db.Exec(
+func (fs *FS) Purge(ctx context.Context, olderThan time.Duration) (int64, error) {
+	res, err := fs.db.ExecContext(ctx,
 		fs.q(`DELETE FROM {t} WHERE NOT is_dir AND modified < ?`),
 		time.Now().Add(-olderThan).Unix(),
 	)
@@ -467,9 +733,9 @@ func (fs *FS) Purge(_ context.Context, olderThan time.Duration) (int64, error) {
 }
 
 // PurgeByPrefix deletes all entries under a path prefix.
-func (fs *FS) PurgeByPrefix(_ context.Context, prefix string) (int64, error) {
+func (fs *FS) PurgeByPrefix(ctx context.Context, prefix string) (int64, error) {
 	prefix = normPath(prefix)
-	res, err := fs.db.Exec(fs.q(`DELETE FROM {t} WHERE path = ? OR path LIKE ?`), prefix, prefix+"/%")
+	res, err := fs.db.ExecContext(ctx, fs.q(`DELETE FROM {t} WHERE path = ? OR path LIKE ?`), prefix, prefix+"/%")
 	if err != nil {
 		return 0, err
 	}
@@ -477,18 +743,18 @@ func (fs *FS) PurgeByPrefix(_ context.Context, prefix string) (int64, error) {
 }
 
 // TotalSize returns the sum of content sizes for all non-directory files.
-func (fs *FS) TotalSize(_ context.Context) (int64, error) {
+func (fs *FS) TotalSize(ctx context.Context) (int64, error) {
 	var sz sql.NullInt64
-	if err := fs.db.QueryRow(fs.q(`SELECT SUM(LENGTH(content)) FROM {t} WHERE NOT is_dir`)).Scan(&sz); err != nil {
+	if err := fs.db.QueryRowContext(ctx, fs.q(`SELECT SUM(LENGTH(content)) FROM {t} WHERE NOT is_dir`)).Scan(&sz); err != nil {
 		return 0, err
 	}
 	return sz.Int64, nil
 }
 
 // Count returns the number of non-directory files.
-func (fs *FS) Count(_ context.Context) (int64, error) {
+func (fs *FS) Count(ctx context.Context) (int64, error) {
 	var n int64
-	err := fs.db.QueryRow(fs.q(`SELECT COUNT(*) FROM {t} WHERE NOT is_dir`)).Scan(&n)
+	err := fs.db.QueryRowContext(ctx, fs.q(`SELECT COUNT(*) FROM {t} WHERE NOT is_dir`)).Scan(&n)
 	return n, err
 }
 
@@ -1,8 +1,11 @@
 package dbfs
 
 import (
+	"bytes"
+	"compress/gzip"
 	"context"
 	"database/sql"
+	"errors"
 	"io"
 	"os"
 	"path/filepath"
@@ -166,6 +169,66 @@ func TestListNotFound(t *testing.T) {
 	}
 }
 
+func TestListPage(t *testing.T) {
+	fs := setup(t)
+	ctx := context.Background()
+
+	mustWrite(t, fs, ctx, "dir/a.txt", "a")
+	mustWrite(t, fs, ctx, "dir/b.txt", "b")
+	mustWrite(t, fs, ctx, "dir/sub/one.txt", "1")
+	mustWrite(t, fs, ctx, "dir/sub/two.txt", "2")
+	mustWrite(t, fs, ctx, "dir/sub-x.txt", "x")
+	mustWrite(t, fs, ctx, "dir/z.txt", "z")
+	if err := fs.Mkdir(ctx, "dir/empty", types.PermRW); err != nil {
+		t.Fatal(err)
+	}
+
+	var names []string
+	opts := types.ListOpts{Limit: 2}
+	for pages := 0; ; pages++ {
+		if pages > 10 {
+			t.Fatal("pagination does not terminate")
+		}
+		res, err := fs.ListPage(ctx, "dir", opts)
+		if err != nil {
+			t.Fatalf("ListPage: %v", err)
+		}
+		if len(res.Entries) > 2 {
+			t.Fatalf("page has %d entries, want at most 2", len(res.Entries))
+		}
+		for _, e := range res.Entries {
+			names = append(names, e.Name)
+			if e.Name == "sub" && !e.IsDir {
+				t.Errorf("implicit dir listed as a file: %+v", e)
+			}
+		}
+		if res.NextCursor == "" {
+			break
+		}
+		opts.Cursor = res.NextCursor
+	}
+	// "sub" is listed once even though its rows straddle "sub-x.txt".
+	want := "a.txt,b.txt,empty,sub-x.txt,sub,z.txt"
+	if got := strings.Join(names, ","); got != want {
+		t.Errorf("paged names = %s, want %s", got, want)
+	}
+
+	res, err := fs.ListPage(ctx, "dir", types.ListOpts{})
+	if err != nil {
+		t.Fatalf("ListPage without limit: %v", err)
+	}
+	if len(res.Entries) != 6 || res.NextCursor != "" {
+		t.Errorf("ListPage without limit = %d entries, cursor %q", len(res.Entries), res.NextCursor)
+	}
+
+	if _, err := fs.ListPage(ctx, "nonexistent", types.ListOpts{Limit: 2}); !errors.Is(err, types.ErrNotFound) {
+		t.Errorf("ListPage nonexistent: err = %v, want ErrNotFound", err)
+	}
+	if _, err := fs.ListPage(ctx, "dir", types.ListOpts{Cursor: "!!"}); err == nil {
+		t.Error("expected error for malformed cursor")
+	}
+}
+
 func TestOverwrite(t *testing.T) {
 	fs := setup(t)
 	ctx := context.Background()
@@ -416,6 +479,37 @@ func TestWriteMeta(t *testing.T) {
 	}
 }
 
+func TestSetGetMeta(t *testing.T) {
+	fs := setup(t)
+	ctx := context.Background()
+
+	mustWrite(t, fs, ctx, "f.txt", "data")
+
+	if err := fs.SetMeta(ctx, "f.txt", "a", "1"); err != nil {
+		t.Fatalf("SetMeta: %v", err)
+	}
+	if err := fs.SetMeta(ctx, "f.txt", "b", "2"); err != nil {
+		t.Fatalf("SetMeta: %v", err)
+	}
+	if v, err := fs.GetMeta(ctx, "f.txt", "a"); err != nil || v != "1" {
+		t.Errorf("GetMeta(a) = %q, %v; want 1", v, err)
+	}
+
+	if err := fs.SetMeta(ctx, "f.txt", "a", ""); err != nil {
+		t.Fatalf("SetMeta remove: %v", err)
+	}
+	if _, err := fs.GetMeta(ctx, "f.txt", "a"); !errors.Is(err, types.ErrNotFound) {
+		t.Errorf("GetMeta after remove: err = %v, want ErrNotFound", err)
+	}
+	if v, _ := fs.GetMeta(ctx, "f.txt", "b"); v != "2" {
+		t.Errorf("unrelated key lost: b = %q", v)
+	}
+
+	if err := fs.SetMeta(ctx, "ghost.txt", "k", "v"); !errors.Is(err, types.ErrNotFound) {
+		t.Errorf("SetMeta on nonexistent file: err = %v, want ErrNotFound", err)
+	}
+}
+
 func TestMetaInOpen(t *testing.T) {
 	fs := setup(t)
 	ctx := context.Background()
@@ -439,6 +533,81 @@ func TestMetaInOpen(t *testing.T) {
 	}
 }
 
+func mustRead(t *testing.T, fs *FS, ctx context.Context, path string) []byte {
+	t.Helper()
+	f, err := fs.Open(ctx, path)
+	if err != nil {
+		t.Fatalf("Open %s: %v", path, err)
+	}
+	defer f.Close()
+	data, err := io.ReadAll(f)
+	if err != nil {
+		t.Fatalf("read %s: %v", path, err)
+	}
+	return data
+}
+
+func TestCompressDecompress(t *testing.T) {
+	fs := setup(t)
+	ctx := context.Background()
+
+	content := strings.Repeat(`{"id": 1, "title": "item"}`+"\n", 100)
+	mustWrite(t, fs, ctx, "feeds/large.json", content)
+	if err := fs.SetMeta(ctx, "feeds/large.json", "source", "hn"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := fs.Compress(ctx, "feeds/large.json"); err != nil {
+		t.Fatalf("Compress: %v", err)
+	}
+	if _, err := fs.Stat(ctx, "feeds/large.json"); !errors.Is(err, types.ErrNotFound) {
+		t.Errorf("Stat of original after Compress: err = %v, want ErrNotFound", err)
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(mustRead(t, fs, ctx, "feeds/large.json.gz")))
+	if err != nil {
+		t.Fatalf("large.json.gz is not gzip: %v", err)
+	}
+	if data, _ := io.ReadAll(zr); string(data) != content {
+		t.Errorf("gunzipped content = %q, want the original", data)
+	}
+	if v, _ := fs.GetMeta(ctx, "feeds/large.json.gz", "source"); v != "hn" {
+		t.Errorf("meta after Compress = %q, want hn", v)
+	}
+
+	if err := fs.Decompress(ctx, "feeds/large.json.gz"); err != nil {
+		t.Fatalf("Decompress: %v", err)
+	}
+	if got := mustRead(t, fs, ctx, "feeds/large.json"); string(got) != content {
+		t.Errorf("content after Decompress = %q, want the original", got)
+	}
+	if _, err := fs.Stat(ctx, "feeds/large.json.gz"); !errors.Is(err, types.ErrNotFound) {
+		t.Errorf("Stat of .gz after Decompress: err = %v, want ErrNotFound", err)
+	}
+
+	// An existing file at the new name is replaced.
+	mustWrite(t, fs, ctx, "feeds/large.json.gz", "stale")
+	if err := fs.Compress(ctx, "feeds/large.json"); err != nil {
+		t.Fatalf("Compress over existing .gz: %v", err)
+	}
+	if n, _ := fs.Count(ctx); n != 1 {
+		t.Errorf("Count after Compress = %d, want 1", n)
+	}
+
+	if err := fs.Decompress(ctx, "feeds/missing.gz"); !errors.Is(err, types.ErrNotFound) {
+		t.Errorf("Decompress missing: err = %v, want ErrNotFound", err)
+	}
+	mustWrite(t, fs, ctx, "plain.gz", "not gzip")
+	if err := fs.Decompress(ctx, "plain.gz"); err == nil {
+		t.Error("Decompress of non-gzip content should fail")
+	}
+	if got := mustRead(t, fs, ctx, "plain.gz"); string(got) != "not gzip" {
+		t.Errorf("failed Decompress changed the file: %q", got)
+	}
+	if err := fs.Decompress(ctx, "notes.txt"); err == nil {
+		t.Error("Decompress without .gz or .tgz suffix should fail")
+	}
+}
+
 func TestPurge(t *testing.T) {
 	fs := setup(t)
 	ctx := context.Background()
@@ -4,6 +4,8 @@ go 1.24.3
 
 require (
 	github.com/jackfish212/grasp v0.0.0
+	github.com/jackfish212/grasp/builtins v0.0.0
+	github.com/jackfish212/grasp/httpfs v0.0.0
 	modernc.org/sqlite v1.46.1
 )
 
@@ -13,11 +15,18 @@ require (
 	github.com/mattn/go-isatty v0.0.20 // indirect
 	github.com/ncruces/go-strftime v1.0.0 // indirect
 	github.com/remyoudompheng/bigfft v0.0.0-20230129092748-24d4a6f8daec // indirect
+	github.com/rwtodd/Go.Sed v0.0.0-20250326002959-ba712dc84b62 // indirect
+	github.com/thedevsaddam/gojsonq/v2 v2.5.2 // indirect
 	golang.org/x/exp v0.0.0-20251023183803-a4bb9ffd2546 // indirect
 	golang.org/x/sys v0.37.0 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
 	modernc.org/libc v1.67.6 // indirect
 	modernc.org/mathutil v1.7.1 // indirect
 	modernc.org/memory v1.11.0 // indirect
 )
 
-replace github.com/jackfish212/grasp => ../
+replace (
+	github.com/jackfish212/grasp => ../
+	github.com/jackfish212/grasp/builtins => ../builtins
+	github.com/jackfish212/grasp/httpfs => ../httpfs
+)
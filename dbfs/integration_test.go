@@ -0,0 +1,126 @@
+package dbfs
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	grasp "github.com/jackfish212/grasp"
+	"github.com/jackfish212/grasp/builtins"
+	"github.com/jackfish212/grasp/httpfs"
+	"github.com/jackfish212/grasp/types"
+)
+
+// setupVOS mounts a fresh FS at /db in a VirtualOS with the builtin
+// commands registered.
+func setupVOS(t *testing.T) (*FS, *grasp.VirtualOS, *grasp.Shell) {
+	t.Helper()
+	fs := setup(t)
+	v := grasp.New()
+	rootFS, err := grasp.Configure(v)
+	if err != nil {
+		t.Fatalf("Configure: %v", err)
+	}
+	if _, err := builtins.RegisterBuiltinsOnFS(v, rootFS); err != nil {
+		t.Fatalf("RegisterBuiltinsOnFS: %v", err)
+	}
+	if err := v.Mount("/db", fs); err != nil {
+		t.Fatalf("Mount: %v", err)
+	}
+	return fs, v, v.Shell("agent")
+}
+
+func TestGzipCommand(t *testing.T) {
+	fs, _, sh := setupVOS(t)
+	ctx := context.Background()
+
+	content := strings.Repeat(`{"id": 1, "title": "item"}`+"\n", 100)
+	mustWrite(t, fs, ctx, "cache/large.json", content)
+	if err := fs.SetMeta(ctx, "cache/large.json", "source", "hn"); err != nil {
+		t.Fatal(err)
+	}
+
+	if r := sh.Execute(ctx, "gzip /db/cache/large.json"); r.Code != 0 {
+		t.Fatalf("gzip: %+v", r)
+	}
+	if _, err := fs.Stat(ctx, "cache/large.json"); !errors.Is(err, types.ErrNotFound) {
+		t.Errorf("original after gzip: err = %v, want ErrNotFound", err)
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(mustRead(t, fs, ctx, "cache/large.json.gz")))
+	if err != nil {
+		t.Fatalf("large.json.gz is not gzip: %v", err)
+	}
+	if data, _ := io.ReadAll(zr); string(data) != content {
+		t.Errorf("gunzipped content = %q, want the original", data)
+	}
+	// Only the native path keeps the row, and with it the metadata; the
+	// fallback would have written a new file.
+	if v, _ := fs.GetMeta(ctx, "cache/large.json.gz", "source"); v != "hn" {
+		t.Errorf("meta after gzip = %q, want hn (native compression not used)", v)
+	}
+
+	if r := sh.Execute(ctx, "gunzip /db/cache/large.json.gz"); r.Code != 0 {
+		t.Fatalf("gunzip: %+v", r)
+	}
+	if got := mustRead(t, fs, ctx, "cache/large.json"); string(got) != content {
+		t.Errorf("content after gunzip = %q, want the original", got)
+	}
+	if v, _ := fs.GetMeta(ctx, "cache/large.json", "source"); v != "hn" {
+		t.Errorf("meta after gunzip = %q, want hn (native decompression not used)", v)
+	}
+}
+
+func TestCopyFromHTTPFS(t *testing.T) {
+	fs, v, sh := setupVOS(t)
+	ctx := context.Background()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`[{"id":1,"name":"first"},{"id":2,"name":"second"}]`))
+	}))
+	defer srv.Close()
+
+	hfs := httpfs.NewHTTPFS()
+	if err := hfs.Add("api", srv.URL, &httpfs.JSONParser{NameField: "name", IDField: "id"}); err != nil {
+		t.Fatal(err)
+	}
+	hfs.Start(ctx)
+	defer hfs.Stop()
+	if err := v.Mount("/remote", hfs); err != nil {
+		t.Fatal(err)
+	}
+
+	var entries []types.Entry
+	for deadline := time.Now().Add(2 * time.Second); len(entries) < 2; {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for the first fetch; entries = %v", entries)
+		}
+		time.Sleep(10 * time.Millisecond)
+		entries, _ = v.List(ctx, "/remote/api", types.ListOpts{})
+	}
+
+	if r := sh.Execute(ctx, "mkdir /db/feeds && cp /remote/api/* /db/feeds/"); r.Code != 0 {
+		t.Fatalf("cp: %+v", r)
+	}
+	for _, e := range entries {
+		f, err := v.Open(ctx, "/remote/api/"+e.Name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want, _ := io.ReadAll(f)
+		_ = f.Close()
+		if got := mustRead(t, fs, ctx, "feeds/"+e.Name); string(got) != string(want) {
+			t.Errorf("feeds/%s = %q, want %q", e.Name, got, want)
+		}
+	}
+	if got := mustRead(t, fs, ctx, "feeds/"+entries[0].Name); !strings.Contains(string(got), "first") {
+		t.Errorf("copied content = %q, want the first item", got)
+	}
+}
@@ -29,7 +29,7 @@ func main() {
 	root.AddDir("home/user")
 
 	// Register builtins
-	if err := builtins.RegisterBuiltinsOnFS(v, root); err != nil {
+	if _, err := builtins.RegisterBuiltinsOnFS(v, root); err != nil {
 		log.Fatal(err)
 	}
 
@@ -45,7 +45,7 @@ func main() {
 	if err != nil {
 		log.Fatalf("configure: %v", err)
 	}
-	if err := builtins.RegisterBuiltinsOnFS(v, rootFS); err != nil {
+	if _, err := builtins.RegisterBuiltinsOnFS(v, rootFS); err != nil {
 		log.Fatalf("register builtins: %v", err)
 	}
 
@@ -52,36 +52,12 @@ func main() {
 		log.Printf("Warning: .env not loaded: %v", err)
 	}
 
-	v := grasp.New()
-	rootFS, err := grasp.Configure(v)
-	if err != nil {
-		log.Fatalf("Configure: %v", err)
-	}
-	if err := builtins.RegisterBuiltinsOnFS(v, rootFS); err != nil {
-		log.Fatalf("RegisterBuiltinsOnFS: %v", err)
-	}
-
-	if err := v.Mount("/project", mounts.NewMemFS(grasp.PermRW)); err != nil {
-		log.Fatalf("mount /project: %v", err)
-	}
-	if err := v.Mount("/shared", mounts.NewMemFS(grasp.PermRW)); err != nil {
-		log.Fatalf("mount /shared: %v", err)
-	}
-
 	outputDir := filepath.Join(".", "output")
-	if err := os.MkdirAll(outputDir, 0o755); err != nil {
-		log.Fatalf("mkdir output: %v", err)
-	}
-	if err := v.Mount("/output", mounts.NewLocalFS(outputDir, grasp.PermRW)); err != nil {
-		log.Fatalf("mount /output: %v", err)
+	v, err := newVOS(outputDir)
+	if err != nil {
+		log.Fatal(err)
 	}
-
 	ctx := context.Background()
-	setup := v.Shell("setup")
-	setup.Execute(ctx, "mkdir /shared/explorer")
-	setup.Execute(ctx, "mkdir /shared/architect")
-
-	seedProject(v)
 
 	fmt.Println("========================================")
 	fmt.Println("  grasp Multi-Agent Collaboration")
@@ -125,6 +101,41 @@ func main() {
 	fmt.Printf("\nReport saved to: %s\n", filepath.Join(outputDir, "report.md"))
 }
 
+// newVOS builds the shared namespace: /project (seeded with code to review),
+// /shared for inter-agent files, and /output backed by outputDir on disk.
+func newVOS(outputDir string) (*grasp.VirtualOS, error) {
+	v := grasp.New()
+	rootFS, err := grasp.Configure(v)
+	if err != nil {
+		return nil, fmt.Errorf("configure: %w", err)
+	}
+	if _, err := builtins.RegisterBuiltinsOnFS(v, rootFS); err != nil {
+		return nil, fmt.Errorf("register builtins: %w", err)
+	}
+
+	if err := v.Mount("/project", mounts.NewMemFS(grasp.PermRW)); err != nil {
+		return nil, fmt.Errorf("mount /project: %w", err)
+	}
+	if err := v.Mount("/shared", mounts.NewMemFS(grasp.PermRW)); err != nil {
+		return nil, fmt.Errorf("mount /shared: %w", err)
+	}
+
+	if err := os.MkdirAll(outputDir, 0o755); err != nil {
+		return nil, fmt.Errorf("mkdir output: %w", err)
+	}
+	if err := v.Mount("/output", mounts.NewLocalFS(outputDir, grasp.PermRW)); err != nil {
+		return nil, fmt.Errorf("mount /output: %w", err)
+	}
+
+	ctx := context.Background()
+	setup := v.Shell("setup")
+	setup.Execute(ctx, "mkdir /shared/explorer")
+	setup.Execute(ctx, "mkdir /shared/architect")
+
+	seedProject(v)
+	return v, nil
+}
+
 // defineAgents returns the three-stage pipeline.
 // Each agent reads from the previous stage's output directory.
 func defineAgents() []Agent {
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/anthropics/anthropic-sdk-go"
+	"github.com/anthropics/anthropic-sdk-go/option"
+)
+
+// scriptStep returns the next shell command given the output of the
+// previous one, or "" to end the agent's turn.
+type scriptStep func(prev string) string
+
+// scripts drive each agent by its role. Later steps build their content from
+// what earlier steps read, so the final report only contains upstream text
+// if the files really flowed between agents.
+var scripts = map[string][]scriptStep{
+	"explorer": {
+		func(string) string { return "cat /project/README.md" },
+		func(prev string) string {
+			title := strings.TrimPrefix(strings.SplitN(prev, "\n", 2)[0], "# ")
+			return fmt.Sprintf("echo 'Project: %s' > /shared/explorer/summary.md", title)
+		},
+	},
+	"architect": {
+		func(string) string { return "cat /shared/explorer/summary.md" },
+		func(prev string) string {
+			return fmt.Sprintf("echo 'Analysis of %s' > /shared/architect/analysis.md", strings.TrimSpace(prev))
+		},
+	},
+	"reporter": {
+		func(string) string { return "cat /shared/explorer/summary.md /shared/architect/analysis.md" },
+		func(prev string) string {
+			return fmt.Sprintf("echo '# Report: %s' > /output/report.md", strings.Join(strings.Fields(prev), " "))
+		},
+	},
+}
+
+// mockMessages serves the Messages API, answering each agent with the tool
+// calls from its script.
+func mockMessages(t *testing.T) *httptest.Server {
+	t.Helper()
+	var mu sync.Mutex
+	calls := 0
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			System []struct {
+				Text string `json:"text"`
+			} `json:"system"`
+			Messages []struct {
+				Role    string          `json:"role"`
+				Content json.RawMessage `json:"content"`
+			} `json:"messages"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		mu.Lock()
+		calls++
+		id := calls
+		mu.Unlock()
+
+		agent := ""
+		for _, a := range defineAgents() {
+			if len(req.System) > 0 && req.System[0].Text == a.System {
+				agent = a.Name
+			}
+		}
+		script, ok := scripts[agent]
+		if !ok {
+			http.Error(w, "unknown agent", http.StatusBadRequest)
+			return
+		}
+
+		step := len(req.Messages) / 2
+		prev := ""
+		if step > 0 {
+			prev = toolResultText(req.Messages[len(req.Messages)-1].Content)
+		}
+
+		var content []map[string]any
+		stopReason := "end_turn"
+		if step < len(script) {
+			content = []map[string]any{{
+				"type":  "tool_use",
+				"id":    fmt.Sprintf("toolu_%d", id),
+				"name":  "shell",
+				"input": map[string]any{"command": script[step](prev)},
+			}}
+			stopReason = "tool_use"
+		} else {
+			content = []map[string]any{{"type": "text", "text": "done"}}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"id":            fmt.Sprintf("msg_%d", id),
+			"type":          "message",
+			"role":          "assistant",
+			"model":         "mock",
+			"content":       content,
+			"stop_reason":   stopReason,
+			"stop_sequence": nil,
+			"usage":         map[string]any{"input_tokens": 1, "output_tokens": 1},
+		})
+	}))
+}
+
+// toolResultText extracts the text of the tool_result block in a user
+// message, whose content may be a string or a list of text blocks.
+func toolResultText(raw json.RawMessage) string {
+	var blocks []struct {
+		Type    string          `json:"type"`
+		Content json.RawMessage `json:"content"`
+	}
+	if err := json.Unmarshal(raw, &blocks); err != nil {
+		return ""
+	}
+	for _, b := range blocks {
+		if b.Type != "tool_result" {
+			continue
+		}
+		var s string
+		if json.Unmarshal(b.Content, &s) == nil {
+			return s
+		}
+		var parts []struct {
+			Text string `json:"text"`
+		}
+		_ = json.Unmarshal(b.Content, &parts)
+		var out strings.Builder
+		for _, p := range parts {
+			out.WriteString(p.Text)
+		}
+		return out.String()
+	}
+	return ""
+}
+
+func TestMultiAgentPipeline(t *testing.T) {
+	srv := mockMessages(t)
+	defer srv.Close()
+
+	outputDir := t.TempDir()
+	v, err := newVOS(outputDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := anthropic.NewClient(option.WithBaseURL(srv.URL), option.WithAPIKey("test"))
+	tool := shellToolDef()
+	ctx := context.Background()
+
+	for _, agent := range defineAgents() {
+		runAgent(ctx, v, agent, client, tool)
+	}
+
+	sh := v.Shell("checker")
+	if out := sh.Execute(ctx, "cat /shared/explorer/summary.md").Output; !strings.Contains(out, "Project: BookStore API") {
+		t.Errorf("explorer summary = %q", out)
+	}
+	if out := sh.Execute(ctx, "cat /shared/architect/analysis.md").Output; !strings.Contains(out, "Analysis of Project: BookStore API") {
+		t.Errorf("architect analysis = %q, want it built from the explorer summary", out)
+	}
+
+	report, err := os.ReadFile(filepath.Join(outputDir, "report.md"))
+	if err != nil {
+		t.Fatalf("report not written to /output: %v", err)
+	}
+	for _, want := range []string{"Project: BookStore API", "Analysis of Project: BookStore API"} {
+		if !strings.Contains(string(report), want) {
+			t.Errorf("report %q should include %q from upstream agents", report, want)
+		}
+	}
+}
@@ -18,6 +18,7 @@ type (
 	File              = types.File
 	OpenFlag          = types.OpenFlag
 	ListOpts          = types.ListOpts
+	ListResult        = types.ListResult
 	SearchOpts        = types.SearchOpts
 	SearchResult      = types.SearchResult
 	Provider          = types.Provider
@@ -28,9 +29,18 @@ type (
 	MountInfoProvider = types.MountInfoProvider
 	Mutable           = types.Mutable
 	Touchable         = types.Touchable
+	CompressProvider  = types.CompressProvider
+	PagedLister       = types.PagedLister
+	MetaProvider      = types.MetaProvider
+	Chmoder           = types.Chmoder
+	Linker            = types.Linker
+	ExclusiveOpener   = types.ExclusiveOpener
+	LockedFile        = types.LockedFile
 	ExecutableFile    = types.ExecutableFile
 	WatchEvent        = types.WatchEvent
+	WatchOpts         = types.WatchOpts
 	EventType         = types.EventType
+	ExitError         = types.ExitError
 )
 
 const (
@@ -66,6 +76,8 @@ var (
 	NewFile           = types.NewFile
 	NewSeekableFile   = types.NewSeekableFile
 	NewExecutableFile = types.NewExecutableFile
+	ExitCode          = types.ExitCode
+	DecompressedName  = types.DecompressedName
 )
 
 var (
@@ -79,17 +91,41 @@ var (
 	ErrMountUnderMount = types.ErrMountUnderMount
 	ErrNotSupported    = types.ErrNotSupported
 	ErrParentNotExist  = types.ErrParentNotExist
+	ErrFileTooLarge    = types.ErrFileTooLarge
+	ErrTooManyEntries  = types.ErrTooManyEntries
+	ErrNotSymlink      = types.ErrNotSymlink
+	ErrTooManyLinks    = types.ErrTooManyLinks
+	ErrLocked          = types.ErrLocked
 )
 
 // Shell types - re-exported for API compatibility
 type (
-	Shell      = shell.Shell
-	ShellEnv   = shell.ShellEnv
-	ExecResult = shell.ExecResult
-	ExecHook   = shell.ExecHook
+	Shell          = shell.Shell
+	ShellEnv       = shell.ShellEnv
+	ShellOption    = shell.ShellOption
+	ExecResult     = shell.ExecResult
+	ExecHook       = shell.ExecHook
+	BeforeExecHook = shell.BeforeExecHook
+	InputProvider  = shell.InputProvider
+	HistoryEntry   = shell.HistoryEntry
+
+	TruncationStrategy = shell.TruncationStrategy
+)
+
+const (
+	TimeoutExitCode = shell.TimeoutExitCode
+
+	TruncateTail   = shell.TruncateTail
+	TruncateHead   = shell.TruncateHead
+	TruncateMiddle = shell.TruncateMiddle
 )
 
 // Shell constructors and functions
 var (
-	NewShell = shell.NewShell
+	NewShell                = shell.NewShell
+	WithPipeFail            = shell.WithPipeFail
+	WithHistorySize         = shell.WithHistorySize
+	WithOutputLimit         = shell.WithOutputLimit
+	WithOutputLimitStrategy = shell.WithOutputLimitStrategy
+	WithShellTimeout        = shell.WithShellTimeout
 )
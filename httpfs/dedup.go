@@ -0,0 +1,110 @@
+package httpfs
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+
+	"github.com/jackfish212/grasp/types"
+)
+
+// WithHTTPFSDeduplication stores identical file content only once across
+// all sources. Each parsed file is hashed with SHA-256; files with the same
+// hash keep their own names but share one copy of the content. Use
+// References to see which files share content.
+func WithHTTPFSDeduplication(enabled bool) HTTPFSOption {
+	return func(fs *HTTPFS) {
+		if enabled {
+			fs.blobs = make(map[string]*contentBlob)
+		} else {
+			fs.blobs = nil
+		}
+	}
+}
+
+// contentBlob is a unique piece of content and the files referencing it.
+type contentBlob struct {
+	content string
+	refs    map[string]bool // "source/slug"
+}
+
+// storeLocked sets fe's content, sharing it with identical content already
+// stored when deduplication is enabled. Callers must hold fs.mu.
+func (fs *HTTPFS) storeLocked(fe *fileEntry, path, content string) {
+	if fs.blobs == nil {
+		fe.content = content
+		return
+	}
+	sum := sha256.Sum256([]byte(content))
+	hash := hex.EncodeToString(sum[:])
+	if hash == fe.hash {
+		return
+	}
+	fs.releaseLocked(path, fe.hash)
+
+	b, ok := fs.blobs[hash]
+	if !ok {
+		b = &contentBlob{content: content, refs: make(map[string]bool)}
+		fs.blobs[hash] = b
+	}
+	b.refs[path] = true
+	fe.content = b.content
+	fe.hash = hash
+}
+
+// releaseLocked drops path's reference to the content with hash, freeing
+// the content once nothing references it. Callers must hold fs.mu.
+func (fs *HTTPFS) releaseLocked(path, hash string) {
+	if fs.blobs == nil || hash == "" {
+		return
+	}
+	if b, ok := fs.blobs[hash]; ok {
+		delete(b.refs, path)
+		if len(b.refs) == 0 {
+			delete(fs.blobs, hash)
+		}
+	}
+}
+
+// releaseSourceLocked drops the references held by all of src's files.
+func (fs *HTTPFS) releaseSourceLocked(src *httpSource) {
+	for _, fe := range src.files {
+		fs.releaseLocked(src.name+"/"+fe.slug, fe.hash)
+	}
+}
+
+// References returns the paths (relative to the provider root, sorted) of
+// all files whose content is identical to the file at path, including path
+// itself. Without deduplication it returns just path.
+func (fs *HTTPFS) References(path string) ([]string, error) {
+	path = normPath(path)
+
+	fs.mu.RLock()
+	defer fs.mu.RUnlock()
+
+	if fs.groups[path] {
+		return nil, fmt.Errorf("%w: %s", types.ErrIsDir, path)
+	}
+	src, slug, ok := fs.lookupLocked(path)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", types.ErrNotFound, path)
+	}
+	if slug == "" {
+		return nil, fmt.Errorf("%w: %s", types.ErrIsDir, path)
+	}
+	fe, ok := src.fileIdx[slug]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", types.ErrNotFound, path)
+	}
+	b, ok := fs.blobs[fe.hash]
+	if !ok {
+		return []string{path}, nil
+	}
+	refs := make([]string, 0, len(b.refs))
+	for p := range b.refs {
+		refs = append(refs, p)
+	}
+	sort.Strings(refs)
+	return refs, nil
+}
@@ -0,0 +1,131 @@
+package httpfs
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/jackfish212/grasp/types"
+)
+
+// ─── Source Groups ───
+
+// SourceConfig describes one source added through AddGroup.
+type SourceConfig struct {
+	Name   string
+	URL    string
+	Parser ResponseParser // nil selects AutoParser
+	Opts   []SourceOption
+}
+
+// AddGroup registers sources under a common group directory, so that
+// "news/hn" and "github/hn" can coexist. The group appears as a directory at
+// the root and each source as a subdirectory of it. Calling AddGroup again
+// with the same name adds more sources to the existing group.
+//
+// Grouped sources are addressed by "group/source" in RemoveSource, Sources,
+// HealthCheck and Errors. The configs are validated before any source is
+// added.
+func (fs *HTTPFS) AddGroup(name string, sources []SourceConfig) error {
+	if name == "" || strings.Contains(name, "/") {
+		return fmt.Errorf("invalid group name %q", name)
+	}
+
+	fs.mu.Lock()
+	if _, ok := fs.sources[name]; ok {
+		fs.mu.Unlock()
+		return fmt.Errorf("group %q: a source with that name already exists", name)
+	}
+	seen := make(map[string]bool, len(sources))
+	for _, sc := range sources {
+		if sc.Name == "" || strings.Contains(sc.Name, "/") {
+			fs.mu.Unlock()
+			return fmt.Errorf("group %q: invalid source name %q", name, sc.Name)
+		}
+		if sc.URL == "" {
+			fs.mu.Unlock()
+			return fmt.Errorf("group %q: source %q: missing url", name, sc.Name)
+		}
+		if _, ok := fs.sources[name+"/"+sc.Name]; ok || seen[sc.Name] {
+			fs.mu.Unlock()
+			return fmt.Errorf("group %q: source %q already exists", name, sc.Name)
+		}
+		seen[sc.Name] = true
+	}
+	fs.groups[name] = true
+	fs.mu.Unlock()
+
+	for _, sc := range sources {
+		parser := sc.Parser
+		if parser == nil {
+			parser = &AutoParser{}
+		}
+		if err := fs.Add(name+"/"+sc.Name, sc.URL, parser, sc.Opts...); err != nil {
+			return fmt.Errorf("group %q: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// Groups returns the sorted names of all source groups.
+func (fs *HTTPFS) Groups() []string {
+	fs.mu.RLock()
+	defer fs.mu.RUnlock()
+	names := make([]string, 0, len(fs.groups))
+	for name := range fs.groups {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// lookupLocked resolves path to a source and, when path points inside it,
+// the file slug. Group directories are not sources and are not matched.
+func (fs *HTTPFS) lookupLocked(path string) (src *httpSource, slug string, ok bool) {
+	if src, ok := fs.sources[path]; ok {
+		return src, "", true
+	}
+	i := strings.LastIndex(path, "/")
+	if i <= 0 {
+		return nil, "", false
+	}
+	src, ok = fs.sources[path[:i]]
+	return src, path[i+1:], ok
+}
+
+// groupMembersLocked returns the sources in group, sorted by name.
+func (fs *HTTPFS) groupMembersLocked(group string) []*httpSource {
+	prefix := group + "/"
+	var members []*httpSource
+	for key, src := range fs.sources {
+		if strings.HasPrefix(key, prefix) {
+			members = append(members, src)
+		}
+	}
+	sort.Slice(members, func(i, j int) bool { return members[i].name < members[j].name })
+	return members
+}
+
+func (fs *HTTPFS) groupEntryLocked(group string) *types.Entry {
+	return &types.Entry{
+		Name:  group,
+		IsDir: true,
+		Perm:  types.PermRO,
+		Meta: map[string]string{
+			"sources": fmt.Sprintf("%d", len(fs.groupMembersLocked(group))),
+		},
+	}
+}
+
+// validSourcePath reports whether path names a top-level source or a source
+// directly inside an existing group.
+func (fs *HTTPFS) validSourcePath(path string) bool {
+	if path == "" || fs.groups[path] {
+		return false
+	}
+	group, name, nested := strings.Cut(path, "/")
+	if !nested {
+		return true
+	}
+	return fs.groups[group] && name != "" && !strings.Contains(name, "/")
+}
@@ -0,0 +1,74 @@
+package httpfs
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// SourceHealth is the result of probing a source URL.
+type SourceHealth struct {
+	Reachable  bool          // an HTTP response was received
+	Latency    time.Duration // time until the response headers arrived
+	StatusCode int           // zero when unreachable
+	Error      error         // transport or lookup error, if any
+}
+
+// HealthCheck sends a HEAD request to the named source's URL, using the
+// source's configured headers, and reports whether it responded and how fast.
+// Any HTTP response counts as reachable; inspect StatusCode for its outcome.
+func (fs *HTTPFS) HealthCheck(ctx context.Context, name string) SourceHealth {
+	fs.mu.RLock()
+	src, ok := fs.sources[name]
+	if !ok {
+		fs.mu.RUnlock()
+		return SourceHealth{Error: fmt.Errorf("source %q not found", name)}
+	}
+	srcURL := src.url
+	headers := make(map[string]string, len(src.headers))
+	for k, v := range src.headers {
+		headers[k] = v
+	}
+	fs.mu.RUnlock()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, srcURL, nil)
+	if err != nil {
+		return SourceHealth{Error: err}
+	}
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	start := time.Now()
+	resp, err := fs.client.Do(req)
+	latency := time.Since(start)
+	if err != nil {
+		return SourceHealth{Latency: latency, Error: err}
+	}
+	_ = resp.Body.Close()
+	return SourceHealth{Reachable: true, Latency: latency, StatusCode: resp.StatusCode}
+}
+
+// HealthCheckAll checks every source concurrently and returns the results
+// keyed by source name.
+func (fs *HTTPFS) HealthCheckAll(ctx context.Context) map[string]SourceHealth {
+	names := fs.Sources()
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	results := make(map[string]SourceHealth, len(names))
+	for name := range names {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			h := fs.HealthCheck(ctx, name)
+			mu.Lock()
+			results[name] = h
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+	return results
+}
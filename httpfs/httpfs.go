@@ -6,8 +6,10 @@ import (
 	"context"
 	"encoding/json"
 	"encoding/xml"
+	"errors"
 	"fmt"
 	"io"
+	"mime"
 	"net/http"
 	"regexp"
 	"sort"
@@ -27,6 +29,13 @@ var (
 	_ types.MountInfoProvider = (*HTTPFS)(nil)
 )
 
+// ErrResponseTooLarge is returned when a source's response body exceeds the
+// limit set with WithHTTPFSMaxResponseSize.
+var ErrResponseTooLarge = errors.New("httpfs: response too large")
+
+// defaultMaxResponseSize caps response bodies unless overridden.
+const defaultMaxResponseSize = 10 << 20
+
 // ─── ResponseParser interface ───
 
 // ResponseParser transforms an HTTP response body into virtual files.
@@ -35,6 +44,13 @@ type ResponseParser interface {
 	Parse(body []byte) ([]ParsedFile, error)
 }
 
+// MetaParser is optionally implemented by a ResponseParser that also wants
+// the HTTP response headers, e.g. to pick a format from Content-Type.
+// When implemented, HTTPFS calls ParseWithMeta instead of Parse.
+type MetaParser interface {
+	ParseWithMeta(body []byte, headers http.Header) ([]ParsedFile, error)
+}
+
 // ParsedFile represents a single file produced by parsing an HTTP response.
 type ParsedFile struct {
 	Name    string    // display name (will be slugified for the filename)
@@ -51,9 +67,10 @@ type ParsedFile struct {
 //
 // Filesystem layout:
 //
-//	(root)               — directory listing all sources
+//	(root)               — directory listing all sources and groups
 //	<name>/              — source directory containing parsed files
 //	<name>/<slug>.txt    — a single parsed file (read-only)
+//	<group>/<name>/      — a source added with AddGroup
 //
 // Adding sources:
 //
@@ -66,13 +83,18 @@ type ParsedFile struct {
 //	Shell:   rm /mount/name
 type HTTPFS struct {
 	mu       sync.RWMutex
-	sources  map[string]*httpSource
+	sources  map[string]*httpSource // keyed by name, or group/name
+	groups   map[string]bool
 	client   *http.Client
 	interval time.Duration
 	onEvent  func(types.EventType, string)
+	onError  func(string, error)
+	mws      []func(http.RoundTripper) http.RoundTripper
 	cancel   context.CancelFunc
 	runCtx   context.Context
 	wg       sync.WaitGroup
+	blobs    map[string]*contentBlob // sha256 → content; nil unless deduplicating
+	maxBody  int64                   // response size limit; <= 0 means none
 }
 
 type httpSource struct {
@@ -86,11 +108,13 @@ type httpSource struct {
 	etag     string
 	lastMod  string
 	updated  time.Time
+	lastErr  error // outcome of the most recent fetch; nil on success
 }
 
 type fileEntry struct {
 	slug    string
 	content string
+	hash    string // content sha256, set only when deduplicating
 	modTime time.Time
 }
 
@@ -114,6 +138,29 @@ func WithHTTPFSOnEvent(fn func(types.EventType, string)) HTTPFSOption {
 	return func(fs *HTTPFS) { fs.onEvent = fn }
 }
 
+// WithHTTPFSOnError sets a callback invoked with the source name whenever a
+// fetch fails: transport errors, non-2xx/304 responses and parse errors.
+// Fetches cut short by Stop are not reported.
+func WithHTTPFSOnError(fn func(sourceName string, err error)) HTTPFSOption {
+	return func(fs *HTTPFS) { fs.onError = fn }
+}
+
+// WithHTTPFSMiddleware wraps the HTTP transport used for all outbound
+// requests, e.g. to log traffic, inject auth tokens or sign requests.
+// Multiple middlewares chain in order: the first one registered sees each
+// request first and each response last.
+func WithHTTPFSMiddleware(fn func(http.RoundTripper) http.RoundTripper) HTTPFSOption {
+	return func(fs *HTTPFS) { fs.mws = append(fs.mws, fn) }
+}
+
+// WithHTTPFSMaxResponseSize limits how many bytes of a response body are
+// read (default 10 MB). A larger response fails the fetch with
+// ErrResponseTooLarge, reported through WithHTTPFSOnError, and the source
+// keeps its previous content. A limit <= 0 disables the check.
+func WithHTTPFSMaxResponseSize(bytes int64) HTTPFSOption {
+	return func(fs *HTTPFS) { fs.maxBody = bytes }
+}
+
 // SourceOption configures an individual source.
 type SourceOption func(*httpSource)
 
@@ -131,12 +178,27 @@ func WithSourceHeader(key, value string) SourceOption {
 func NewHTTPFS(opts ...HTTPFSOption) *HTTPFS {
 	fs := &HTTPFS{
 		sources:  make(map[string]*httpSource),
+		groups:   make(map[string]bool),
 		client:   &http.Client{Timeout: 30 * time.Second},
 		interval: 5 * time.Minute,
+		maxBody:  defaultMaxResponseSize,
 	}
 	for _, opt := range opts {
 		opt(fs)
 	}
+	if len(fs.mws) > 0 {
+		transport := fs.client.Transport
+		if transport == nil {
+			transport = http.DefaultTransport
+		}
+		for i := len(fs.mws) - 1; i >= 0; i-- {
+			transport = fs.mws[i](transport)
+		}
+		// Copy the client so a caller-supplied one is left untouched.
+		client := *fs.client
+		client.Transport = transport
+		fs.client = &client
+	}
 	return fs
 }
 
@@ -148,6 +210,10 @@ func (fs *HTTPFS) Add(name, url string, parser ResponseParser, opts ...SourceOpt
 		fs.mu.Unlock()
 		return fmt.Errorf("source %q already exists", name)
 	}
+	if fs.groups[name] {
+		fs.mu.Unlock()
+		return fmt.Errorf("source %q: a group with that name already exists", name)
+	}
 	src := newHTTPSource(name, url, parser)
 	for _, opt := range opts {
 		opt(src)
@@ -171,9 +237,11 @@ func (fs *HTTPFS) RemoveSource(name string) error {
 }
 
 func (fs *HTTPFS) removeLocked(name string) error {
-	if _, ok := fs.sources[name]; !ok {
+	src, ok := fs.sources[name]
+	if !ok {
 		return fmt.Errorf("source %q not found", name)
 	}
+	fs.releaseSourceLocked(src)
 	delete(fs.sources, name)
 	return nil
 }
@@ -257,15 +325,17 @@ func (fs *HTTPFS) Stat(_ context.Context, path string) (*types.Entry, error) {
 	fs.mu.RLock()
 	defer fs.mu.RUnlock()
 
-	parts := strings.SplitN(path, "/", 2)
-	src, ok := fs.sources[parts[0]]
+	if fs.groups[path] {
+		return fs.groupEntryLocked(path), nil
+	}
+	src, slug, ok := fs.lookupLocked(path)
 	if !ok {
 		return nil, fmt.Errorf("%w: %s", types.ErrNotFound, path)
 	}
-	if len(parts) == 1 {
+	if slug == "" {
 		return src.toEntry(), nil
 	}
-	fe, ok := src.fileIdx[parts[1]]
+	fe, ok := src.fileIdx[slug]
 	if !ok {
 		return nil, fmt.Errorf("%w: %s", types.ErrNotFound, path)
 	}
@@ -279,20 +349,33 @@ func (fs *HTTPFS) List(_ context.Context, path string, _ types.ListOpts) ([]type
 	defer fs.mu.RUnlock()
 
 	if path == "" {
-		entries := make([]types.Entry, 0, len(fs.sources))
-		for _, src := range fs.sources {
-			entries = append(entries, *src.toEntry())
+		entries := make([]types.Entry, 0, len(fs.sources)+len(fs.groups))
+		for key, src := range fs.sources {
+			if !strings.Contains(key, "/") {
+				entries = append(entries, *src.toEntry())
+			}
+		}
+		for group := range fs.groups {
+			entries = append(entries, *fs.groupEntryLocked(group))
 		}
 		sort.Slice(entries, func(i, j int) bool { return entries[i].Name < entries[j].Name })
 		return entries, nil
 	}
 
-	parts := strings.SplitN(path, "/", 2)
-	src, ok := fs.sources[parts[0]]
+	if fs.groups[path] {
+		members := fs.groupMembersLocked(path)
+		entries := make([]types.Entry, len(members))
+		for i, src := range members {
+			entries[i] = *src.toEntry()
+		}
+		return entries, nil
+	}
+
+	src, slug, ok := fs.lookupLocked(path)
 	if !ok {
 		return nil, fmt.Errorf("%w: %s", types.ErrNotFound, path)
 	}
-	if len(parts) > 1 {
+	if slug != "" {
 		return nil, fmt.Errorf("%w: %s", types.ErrNotDir, path)
 	}
 	entries := make([]types.Entry, len(src.files))
@@ -310,15 +393,17 @@ func (fs *HTTPFS) Open(_ context.Context, path string) (types.File, error) {
 	fs.mu.RLock()
 	defer fs.mu.RUnlock()
 
-	parts := strings.SplitN(path, "/", 2)
-	if len(parts) != 2 {
+	if fs.groups[path] {
 		return nil, fmt.Errorf("%w: %s", types.ErrIsDir, path)
 	}
-	src, ok := fs.sources[parts[0]]
+	src, slug, ok := fs.lookupLocked(path)
 	if !ok {
 		return nil, fmt.Errorf("%w: %s", types.ErrNotFound, path)
 	}
-	fe, ok := src.fileIdx[parts[1]]
+	if slug == "" {
+		return nil, fmt.Errorf("%w: %s", types.ErrIsDir, path)
+	}
+	fe, ok := src.fileIdx[slug]
 	if !ok {
 		return nil, fmt.Errorf("%w: %s", types.ErrNotFound, path)
 	}
@@ -330,7 +415,10 @@ func (fs *HTTPFS) Open(_ context.Context, path string) (types.File, error) {
 
 func (fs *HTTPFS) Write(_ context.Context, path string, r io.Reader) error {
 	path = normPath(path)
-	if strings.Contains(path, "/") || path == "" {
+	fs.mu.RLock()
+	valid := fs.validSourcePath(path)
+	fs.mu.RUnlock()
+	if !valid {
 		return fmt.Errorf("%w: %s (write a URL to a source name to subscribe)", types.ErrNotWritable, path)
 	}
 	data, err := io.ReadAll(r)
@@ -345,6 +433,7 @@ func (fs *HTTPFS) Write(_ context.Context, path string, r io.Reader) error {
 	isNew := false
 	fs.mu.Lock()
 	if src, ok := fs.sources[path]; ok {
+		fs.releaseSourceLocked(src)
 		src.url = url
 		src.files = nil
 		src.fileIdx = make(map[string]*fileEntry)
@@ -373,11 +462,21 @@ func (fs *HTTPFS) Mkdir(_ context.Context, _ string, _ types.Perm) error {
 
 func (fs *HTTPFS) Remove(_ context.Context, path string) error {
 	path = normPath(path)
-	if strings.Contains(path, "/") || path == "" {
-		return fmt.Errorf("%w: can only remove sources, not individual files", types.ErrNotSupported)
-	}
 	fs.mu.Lock()
 	defer fs.mu.Unlock()
+	if fs.groups[path] {
+		// Removing a group unsubscribes from every source in it.
+		for _, src := range fs.groupMembersLocked(path) {
+			if err := fs.removeLocked(src.name); err != nil {
+				return err
+			}
+		}
+		delete(fs.groups, path)
+		return nil
+	}
+	if _, ok := fs.sources[path]; !ok && (strings.Contains(path, "/") || path == "") {
+		return fmt.Errorf("%w: can only remove sources, not individual files", types.ErrNotSupported)
+	}
 	return fs.removeLocked(path)
 }
 
@@ -413,12 +512,45 @@ func (fs *HTTPFS) fetchAll(ctx context.Context) {
 	wg.Wait()
 }
 
+// Errors returns the error from the most recent fetch of each source whose
+// last fetch failed, keyed by source name.
+func (fs *HTTPFS) Errors() map[string]error {
+	fs.mu.RLock()
+	defer fs.mu.RUnlock()
+	out := make(map[string]error)
+	for name, src := range fs.sources {
+		if src.lastErr != nil {
+			out[name] = src.lastErr
+		}
+	}
+	return out
+}
+
+// fetchSource fetches a source and records the outcome.
 func (fs *HTTPFS) fetchSource(ctx context.Context, name string) {
+	err := fs.fetch(ctx, name)
+	if err != nil && ctx.Err() != nil {
+		return
+	}
+
+	fs.mu.Lock()
+	src, ok := fs.sources[name]
+	if ok {
+		src.lastErr = err
+	}
+	fs.mu.Unlock()
+
+	if ok && err != nil && fs.onError != nil {
+		fs.onError(name, err)
+	}
+}
+
+func (fs *HTTPFS) fetch(ctx context.Context, name string) error {
 	fs.mu.RLock()
 	src, ok := fs.sources[name]
 	if !ok {
 		fs.mu.RUnlock()
-		return
+		return nil
 	}
 	srcURL := src.url
 	etag := src.etag
@@ -435,7 +567,7 @@ func (fs *HTTPFS) fetchSource(ctx context.Context, name string) {
 
 	req, err := http.NewRequestWithContext(ctx, "GET", srcURL, nil)
 	if err != nil {
-		return
+		return err
 	}
 	if etag != "" {
 		req.Header.Set("If-None-Match", etag)
@@ -449,32 +581,47 @@ func (fs *HTTPFS) fetchSource(ctx context.Context, name string) {
 
 	resp, err := fs.client.Do(req)
 	if err != nil {
-		return
+		return err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode == http.StatusNotModified {
-		return
+		return nil
 	}
 	if resp.StatusCode != http.StatusOK {
-		return
+		return fmt.Errorf("GET %s: unexpected status %s", srcURL, resp.Status)
 	}
 
-	body, err := io.ReadAll(resp.Body)
+	var r io.Reader = resp.Body
+	if fs.maxBody > 0 {
+		r = io.LimitReader(resp.Body, fs.maxBody+1)
+	}
+	body, err := io.ReadAll(r)
 	if err != nil {
-		return
+		return err
+	}
+	if fs.maxBody > 0 && int64(len(body)) > fs.maxBody {
+		return fmt.Errorf("%w: GET %s: body exceeds %d bytes", ErrResponseTooLarge, srcURL, fs.maxBody)
 	}
 
-	parsed, err := parser.Parse(body)
-	if err != nil || len(parsed) == 0 {
-		return
+	var parsed []ParsedFile
+	if mp, ok := parser.(MetaParser); ok {
+		parsed, err = mp.ParseWithMeta(body, resp.Header)
+	} else {
+		parsed, err = parser.Parse(body)
+	}
+	if err != nil {
+		return fmt.Errorf("parse %s: %w", srcURL, err)
+	}
+	if len(parsed) == 0 {
+		return nil
 	}
 
 	fs.mu.Lock()
 	src, ok = fs.sources[name]
 	if !ok {
 		fs.mu.Unlock()
-		return
+		return nil
 	}
 	src.etag = resp.Header.Get("ETag")
 	src.lastMod = resp.Header.Get("Last-Modified")
@@ -493,7 +640,7 @@ func (fs *HTTPFS) fetchSource(ctx context.Context, name string) {
 
 		if existingSlug, known := src.idToSlug[id]; known {
 			if fe := src.fileIdx[existingSlug]; fe != nil && fe.content != pf.Content {
-				fe.content = pf.Content
+				fs.storeLocked(fe, name+"/"+existingSlug, pf.Content)
 				fe.modTime = modTime
 				updatedPaths = append(updatedPaths, name+"/"+existingSlug)
 			}
@@ -506,7 +653,8 @@ func (fs *HTTPFS) fetchSource(ctx context.Context, name string) {
 			slug = fmt.Sprintf("%s-%d.txt", base, i)
 		}
 
-		fe := &fileEntry{slug: slug, content: pf.Content, modTime: modTime}
+		fe := &fileEntry{slug: slug, modTime: modTime}
+		fs.storeLocked(fe, name+"/"+slug, pf.Content)
 		src.fileIdx[slug] = fe
 		src.idToSlug[id] = slug
 		src.files = append(src.files, fe)
@@ -522,6 +670,7 @@ func (fs *HTTPFS) fetchSource(ctx context.Context, name string) {
 			fs.onEvent(types.EventWrite, p)
 		}
 	}
+	return nil
 }
 
 // ─── Built-in Parsers ───
@@ -654,6 +803,7 @@ func (p *RawParser) Parse(body []byte) ([]ParsedFile, error) {
 
 // AutoParser tries RSS/Atom first, then falls back to raw content.
 // Used by default when sources are added via shell (echo URL > /mount/name).
+// When response headers are available, Content-Type selects the format.
 type AutoParser struct{}
 
 func (AutoParser) Parse(body []byte) ([]ParsedFile, error) {
@@ -663,6 +813,33 @@ func (AutoParser) Parse(body []byte) ([]ParsedFile, error) {
 	return (&RawParser{}).Parse(body)
 }
 
+// ParseWithMeta uses the Content-Type header as the primary signal:
+// RSS/Atom/XML types go to RSSParser, JSON types to JSONParser, and HTML or
+// other text is kept raw. A missing or unrecognised type falls back to Parse.
+func (p AutoParser) ParseWithMeta(body []byte, headers http.Header) ([]ParsedFile, error) {
+	mediaType, _, err := mime.ParseMediaType(headers.Get("Content-Type"))
+	if err != nil {
+		return p.Parse(body)
+	}
+	switch {
+	case mediaType == "application/rss+xml", mediaType == "application/atom+xml",
+		mediaType == "application/xml", mediaType == "text/xml":
+		if files, err := (RSSParser{}).Parse(body); err == nil && len(files) > 0 {
+			return files, nil
+		}
+		return (&RawParser{}).Parse(body)
+	case mediaType == "application/json", strings.HasSuffix(mediaType, "+json"):
+		if files, err := (&JSONParser{}).Parse(body); err == nil && len(files) > 0 {
+			return files, nil
+		}
+		return (&RawParser{}).Parse(body)
+	case mediaType == "text/html", mediaType == "text/plain":
+		return (&RawParser{}).Parse(body)
+	default:
+		return p.Parse(body)
+	}
+}
+
 // ─── RSS/Atom XML internals ───
 
 var (
@@ -844,7 +1021,7 @@ func newHTTPSource(name, url string, parser ResponseParser) *httpSource {
 
 func (src *httpSource) toEntry() *types.Entry {
 	return &types.Entry{
-		Name:     src.name,
+		Name:     src.name[strings.LastIndex(src.name, "/")+1:],
 		IsDir:    true,
 		Perm:     types.PermRO,
 		Modified: src.updated,
@@ -856,12 +1033,16 @@ func (src *httpSource) toEntry() *types.Entry {
 }
 
 func (f *fileEntry) toEntry() *types.Entry {
-	return &types.Entry{
+	e := &types.Entry{
 		Name:     f.slug,
 		Perm:     types.PermRO,
 		Size:     int64(len(f.content)),
 		Modified: f.modTime,
 	}
+	if f.hash != "" {
+		e.Meta = map[string]string{"sha256": f.hash}
+	}
+	return e
 }
 
 func makeSlug(title string) string {
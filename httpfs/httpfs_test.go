@@ -2,12 +2,18 @@ package httpfs
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
+	grasp "github.com/jackfish212/grasp"
+	"github.com/jackfish212/grasp/mounts"
 	"github.com/jackfish212/grasp/types"
 )
 
@@ -298,6 +304,52 @@ func TestAutoParser(t *testing.T) {
 	}
 }
 
+func TestAutoParserParseWithMeta(t *testing.T) {
+	parser := &AutoParser{}
+	header := func(ct string) http.Header {
+		h := http.Header{}
+		h.Set("Content-Type", ct)
+		return h
+	}
+
+	// JSON content type splits an array into one file per item
+	files, err := parser.ParseWithMeta([]byte(`[{"a":1},{"a":2}]`), header("application/json; charset=utf-8"))
+	if err != nil {
+		t.Fatalf("ParseWithMeta JSON failed: %v", err)
+	}
+	if len(files) != 2 {
+		t.Errorf("JSON len(files) = %d, want 2", len(files))
+	}
+
+	// RSS content type parses feed items
+	rss := `<rss><channel><item><title>One</title></item><item><title>Two</title></item></channel></rss>`
+	files, err = parser.ParseWithMeta([]byte(rss), header("application/rss+xml"))
+	if err != nil {
+		t.Fatalf("ParseWithMeta RSS failed: %v", err)
+	}
+	if len(files) != 2 {
+		t.Errorf("RSS len(files) = %d, want 2", len(files))
+	}
+
+	// HTML is kept raw even if it looks like a feed
+	files, err = parser.ParseWithMeta([]byte(rss), header("text/html"))
+	if err != nil {
+		t.Fatalf("ParseWithMeta HTML failed: %v", err)
+	}
+	if len(files) != 1 || files[0].Content != rss {
+		t.Errorf("HTML should be a single raw file, got %d files", len(files))
+	}
+
+	// No content type falls back to body sniffing
+	files, err = parser.ParseWithMeta([]byte(rss), http.Header{})
+	if err != nil {
+		t.Fatalf("ParseWithMeta without header failed: %v", err)
+	}
+	if len(files) != 2 {
+		t.Errorf("sniffed len(files) = %d, want 2", len(files))
+	}
+}
+
 func TestMakeSlug(t *testing.T) {
 	tests := []struct {
 		input    string
@@ -397,6 +449,185 @@ func TestLoadSchema(t *testing.T) {
 	}
 }
 
+func TestLoadSchemaYAML(t *testing.T) {
+	schema := `
+baseURL: https://api.example.com
+defaults:
+  headers:
+    Authorization: Bearer token
+sources:
+  users:
+    path: /users
+    parser: {type: json, nameField: name}
+  feed:
+    url: https://blog.example.com/rss
+    parser:
+      type: rss
+`
+	fs := NewHTTPFS()
+	if err := fs.LoadSchemaYAML([]byte(schema)); err != nil {
+		t.Fatalf("LoadSchemaYAML failed: %v", err)
+	}
+	sources := fs.Sources()
+	if sources["users"] != "https://api.example.com/users" {
+		t.Errorf("sources[users] = %s", sources["users"])
+	}
+	if sources["feed"] != "https://blog.example.com/rss" {
+		t.Errorf("sources[feed] = %s", sources["feed"])
+	}
+
+	if err := NewHTTPFS().LoadSchemaYAML([]byte("sources: [")); err == nil {
+		t.Error("LoadSchemaYAML should reject malformed YAML")
+	}
+}
+
+func TestLoadSchemaFromFile(t *testing.T) {
+	v := grasp.New()
+	root := mounts.NewMemFS(grasp.PermRW)
+	if err := v.Mount("/", root); err != nil {
+		t.Fatal(err)
+	}
+	root.AddFile("etc/sources.yml", []byte("sources:\n  a:\n    url: https://a.example.com\n"), grasp.PermRO)
+	root.AddFile("etc/sources.json", []byte(`{"sources": {"b": {"url": "https://b.example.com"}}}`), grasp.PermRO)
+	root.AddFile("etc/sources.toml", []byte(""), grasp.PermRO)
+	ctx := context.Background()
+
+	fs := NewHTTPFS()
+	if err := fs.LoadSchemaFromFile(ctx, v, "/etc/sources.yml"); err != nil {
+		t.Fatalf("YAML: %v", err)
+	}
+	if err := fs.LoadSchemaFromFile(ctx, v, "/etc/sources.json"); err != nil {
+		t.Fatalf("JSON: %v", err)
+	}
+	if len(fs.Sources()) != 2 {
+		t.Errorf("sources = %v, want a and b", fs.Sources())
+	}
+	if err := fs.LoadSchemaFromFile(ctx, v, "/etc/sources.toml"); err == nil {
+		t.Error("unknown extension should fail")
+	}
+	if err := fs.LoadSchemaFromFile(ctx, v, "/etc/missing.yaml"); err == nil {
+		t.Error("missing file should fail")
+	}
+}
+
+func TestSchemaValidate(t *testing.T) {
+	source := func(url string) map[string]SchemaSource {
+		return map[string]SchemaSource{"a": {URL: url}}
+	}
+	tests := []struct {
+		name   string
+		schema Schema
+		want   string // substring of the error; "" means valid
+	}{
+		{"valid url", Schema{Sources: source("https://a.example.com/feed")}, ""},
+		{"valid path", Schema{BaseURL: "http://api.local", Sources: map[string]SchemaSource{
+			"users": {Path: "/users", Parser: SchemaParser{Type: "json"}, Headers: map[string]string{"X-Api-Key": "k"}},
+		}}, ""},
+		{"no sources", Schema{BaseURL: "https://api.example.com"}, "no sources"},
+		{"missing url", Schema{Sources: map[string]SchemaSource{"a": {}}}, "missing url or path"},
+		{"path without baseURL", Schema{Sources: map[string]SchemaSource{"a": {Path: "/a"}}}, "requires baseURL"},
+		{"relative url", Schema{Sources: source("/feed")}, "not an absolute"},
+		{"ftp url", Schema{Sources: source("ftp://a.example.com")}, "not an absolute"},
+		{"bad baseURL", Schema{BaseURL: "api.example.com", Sources: source("https://a.example.com")}, "baseURL"},
+		{"bad name", Schema{Sources: map[string]SchemaSource{"a/b": {URL: "https://a.example.com"}}}, "invalid source name"},
+		{"bad parser", Schema{Sources: map[string]SchemaSource{
+			"a": {URL: "https://a.example.com", Parser: SchemaParser{Type: "xml"}},
+		}}, `unknown parser type "xml"`},
+		{"bad header", Schema{Sources: map[string]SchemaSource{
+			"a": {URL: "https://a.example.com", Headers: map[string]string{"Bad Header": "x"}},
+		}}, "invalid header name"},
+		{"bad default header", Schema{
+			Defaults: SchemaDefaults{Headers: map[string]string{"X:Y": "x"}},
+			Sources:  source("https://a.example.com"),
+		}, "defaults"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.schema.Validate()
+			if tt.want == "" {
+				if err != nil {
+					t.Fatalf("Validate() = %v, want nil", err)
+				}
+				return
+			}
+			if !errors.Is(err, ErrInvalidSchema) || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Validate() = %v, want ErrInvalidSchema mentioning %q", err, tt.want)
+			}
+		})
+	}
+
+	// LoadSchema validates before adding anything.
+	fs := NewHTTPFS()
+	err := fs.LoadSchema([]byte(`{"sources": {"a": {"url": "https://a.example.com"}, "b": {"url": "https://b.example.com", "parser": {"type": "csv"}}}}`))
+	if !errors.Is(err, ErrInvalidSchema) {
+		t.Errorf("LoadSchema = %v, want ErrInvalidSchema", err)
+	}
+	if len(fs.Sources()) != 0 {
+		t.Errorf("invalid schema added sources: %v", fs.Sources())
+	}
+}
+
+func TestSchemaToHTTPFSOpts(t *testing.T) {
+	schema := Schema{
+		BaseURL:  "https://api.example.com/",
+		Defaults: SchemaDefaults{Headers: map[string]string{"Authorization": "Bearer t"}},
+		Sources: map[string]SchemaSource{
+			"users": {Path: "users", Parser: SchemaParser{Type: "json", NameField: "login"}},
+			"feed":  {URL: "https://blog.example.com/rss", Parser: SchemaParser{Type: "rss"}},
+		},
+	}
+	if err := schema.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	fs := NewHTTPFS(append(schema.ToHTTPFSOpts(), WithHTTPFSInterval(time.Minute))...)
+
+	want := map[string]string{
+		"users": "https://api.example.com/users",
+		"feed":  "https://blog.example.com/rss",
+	}
+	got := fs.Sources()
+	if len(got) != len(want) {
+		t.Fatalf("Sources() = %v, want %v", got, want)
+	}
+	for name, url := range want {
+		if got[name] != url {
+			t.Errorf("source %s url = %q, want %q", name, got[name], url)
+		}
+	}
+	users := fs.sources["users"]
+	if p, ok := users.parser.(*JSONParser); !ok || p.NameField != "login" {
+		t.Errorf("users parser = %#v", users.parser)
+	}
+	if users.headers["Authorization"] != "Bearer t" {
+		t.Errorf("users headers = %v", users.headers)
+	}
+	if fs.interval != time.Minute {
+		t.Errorf("interval = %v; other options should still apply", fs.interval)
+	}
+
+	var failed []string
+	onError := WithHTTPFSOnError(func(name string, _ error) { failed = append(failed, name) })
+	NewHTTPFS(append([]HTTPFSOption{onError}, append(schema.ToHTTPFSOpts(), schema.ToHTTPFSOpts()...)...)...)
+	if len(failed) != 2 {
+		t.Errorf("duplicate sources reported %v, want both", failed)
+	}
+}
+
+func TestJSONSchema(t *testing.T) {
+	var doc map[string]any
+	if err := json.Unmarshal(JSONSchema(), &doc); err != nil {
+		t.Fatalf("JSONSchema is not valid JSON: %v", err)
+	}
+	if req, _ := doc["required"].([]any); len(req) != 1 || req[0] != "sources" {
+		t.Errorf("required = %v, want [sources]", doc["required"])
+	}
+	for _, typ := range schemaParserTypes {
+		if !strings.Contains(string(JSONSchema()), `"`+typ+`"`) {
+			t.Errorf("JSONSchema does not list parser type %q", typ)
+		}
+	}
+}
+
 func TestLoadOpenAPI(t *testing.T) {
 	spec := `{
 		"openapi": "3.0.0",
@@ -447,6 +678,95 @@ func TestLoadOpenAPI(t *testing.T) {
 	}
 }
 
+func TestOpenAPIFilterOperations(t *testing.T) {
+	spec := `{
+		"openapi": "3.0.0",
+		"servers": [{"url": "https://api.example.com/"}],
+		"paths": {
+			"/posts": {
+				"get": {"tags": ["posts"], "responses": {"200": {"description": "ok"}}},
+				"post": {"tags": ["posts"], "responses": {"201": {"description": "created"}}}
+			},
+			"/legacy": {
+				"get": {"deprecated": true, "responses": {"200": {"description": "ok"}}}
+			},
+			"/users/{id}": {
+				"get": {"responses": {"200": {"description": "ok"}}}
+			}
+		}
+	}`
+
+	api, err := ParseOpenAPI([]byte(spec))
+	if err != nil {
+		t.Fatalf("ParseOpenAPI failed: %v", err)
+	}
+
+	var seen []string
+	api.FilterOperations(func(path, method string, op Operation) bool {
+		seen = append(seen, method+" "+path)
+		return false
+	})
+	want := []string{"GET /legacy", "GET /posts", "POST /posts", "GET /users/{id}"}
+	if strings.Join(seen, ",") != strings.Join(want, ",") {
+		t.Errorf("operations = %v, want %v", seen, want)
+	}
+
+	// Unfiltered, the parameterized path cannot be loaded.
+	if err := api.LoadInto(NewHTTPFS()); err == nil {
+		t.Error("LoadInto with path parameters should fail")
+	}
+
+	fs := NewHTTPFS()
+	err = api.FilterOperations(func(path, method string, op Operation) bool {
+		return method == "GET" && !strings.Contains(path, "{") && !op.Deprecated
+	}).LoadInto(fs)
+	if err != nil {
+		t.Fatalf("LoadInto failed: %v", err)
+	}
+	sources := fs.Sources()
+	if len(sources) != 1 {
+		t.Errorf("len(sources) = %d, want 1", len(sources))
+	}
+	if src, ok := sources["posts"]; !ok {
+		t.Error("missing 'posts' source")
+	} else if src != "https://api.example.com/posts" {
+		t.Errorf("URL = %q, want https://api.example.com/posts", src)
+	}
+}
+
+// FuzzHTTPFSParse feeds arbitrary documents to the OpenAPI and schema
+// loaders. Malformed input must be rejected with an error, never a panic.
+//
+//	go test ./httpfs -run '^$' -fuzz FuzzHTTPFSParse -fuzztime 1m
+func FuzzHTTPFSParse(f *testing.F) {
+	for _, seed := range []string{
+		`{"openapi":"3.0.0","servers":[{"url":"https://api.example.com/"}],"paths":{"/users":{"get":{"responses":{"200":{"content":{"application/json":{"schema":{"type":"array","items":{"$ref":"#/components/schemas/User"}}}}}}}}},"components":{"schemas":{"User":{"properties":{"id":{"type":"integer"},"name":{"type":"string"}}}}}}`,
+		`{"paths":{"/a":{"get":{"responses":{"200":{"content":{"text/plain":{"schema":{"$ref":"#/paths"}}}}}}},"/b/{id}":{"post":{}}}}`,
+		`{"paths":{"/x":{"get":{"responses":{"200":{"content":{"application/json":{"schema":{"$ref":"#/components/schemas/Missing/deeper"}}}}}}}}}`,
+		`{"paths":{"/x":{"get":null,"put":{"tags":[1]}}},"servers":[]}`,
+		`{"baseURL":"https://api.example.com","defaults":{"headers":{"Accept":"application/json"}},"sources":{"posts":{"path":"/posts","parser":{"type":"json","nameField":"title"}}}}`,
+		`{"sources":{"feed":{"url":"https://example.com/rss","parser":{"type":"rss"}},"bad":{"url":"::"}}}`,
+		"baseURL: https://api.example.com\nsources:\n  users:\n    path: /users\n    parser: {type: json}\n",
+		"sources: [1, 2\n",
+		`{"paths":`,
+		"\x00\xff{}",
+		"",
+	} {
+		f.Add([]byte(seed))
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		if spec, err := ParseOpenAPI(data); err == nil {
+			_ = spec.FilterOperations(func(path, method string, op Operation) bool {
+				return true
+			}).LoadInto(NewHTTPFS())
+		}
+		_ = NewHTTPFS().LoadOpenAPI(data)
+		_ = NewHTTPFS().LoadSchema(data)
+		_ = NewHTTPFS().LoadSchemaYAML(data)
+	})
+}
+
 func TestOpenAndRead(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
@@ -1092,6 +1412,50 @@ func TestLoadOpenAPIFromURLErrors(t *testing.T) {
 	}
 }
 
+func TestLoadOpenAPIFromURLWithAuth(t *testing.T) {
+	spec := `{
+		"openapi": "3.0.0",
+		"servers": [{"url": "https://api.example.com"}],
+		"paths": {"/status": {"get": {}}}
+	}`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth := r.Header.Get("Authorization")
+		if auth != "Bearer tok" && (auth != "Bearer secret" || r.Header.Get("X-Tenant") != "acme") {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write([]byte(spec))
+	}))
+	defer server.Close()
+	ctx := context.Background()
+
+	if err := NewHTTPFS().LoadOpenAPIFromURL(ctx, server.URL); err == nil || !strings.Contains(err.Error(), "401") {
+		t.Errorf("unauthenticated load err = %v, want HTTP 401", err)
+	}
+
+	fs := NewHTTPFS()
+	headers := map[string]string{"Authorization": "Bearer secret", "X-Tenant": "acme"}
+	if err := fs.LoadOpenAPIFromURLWithAuth(ctx, server.URL, headers, WithSourceHeader("X-Tenant", "other")); err != nil {
+		t.Fatalf("LoadOpenAPIFromURLWithAuth: %v", err)
+	}
+	src := fs.sources["status"]
+	if src == nil {
+		t.Fatalf("sources = %v, want status", fs.Sources())
+	}
+	// Auth headers carry over to the sources; explicit options win.
+	if src.headers["Authorization"] != "Bearer secret" || src.headers["X-Tenant"] != "other" {
+		t.Errorf("source headers = %v", src.headers)
+	}
+
+	fs = NewHTTPFS()
+	if err := fs.LoadOpenAPIFromURLWithToken(ctx, server.URL, "tok"); err != nil {
+		t.Fatalf("LoadOpenAPIFromURLWithToken: %v", err)
+	}
+	if got := fs.sources["status"].headers["Authorization"]; got != "Bearer tok" {
+		t.Errorf("Authorization = %q, want Bearer tok", got)
+	}
+}
+
 func TestLoadOpenAPIWithRef(t *testing.T) {
 	spec := `{
 		"openapi": "3.0.0",
@@ -1542,3 +1906,395 @@ func TestInferParserFromOpenAPIWithUsername(t *testing.T) {
 		t.Errorf("len(sources) = %d, want 1", len(sources))
 	}
 }
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(r *http.Request) (*http.Response, error) { return f(r) }
+
+func TestWithHTTPFSMiddleware(t *testing.T) {
+	var gotAuth string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotAuth = r.Header.Get("Authorization")
+		w.Write([]byte("body"))
+	}))
+	defer server.Close()
+
+	var order []string
+	mw := func(name string) func(http.RoundTripper) http.RoundTripper {
+		return func(next http.RoundTripper) http.RoundTripper {
+			return roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+				order = append(order, name)
+				if name == "auth" {
+					r = r.Clone(r.Context())
+					r.Header.Set("Authorization", "Bearer injected")
+				}
+				return next.RoundTrip(r)
+			})
+		}
+	}
+
+	client := &http.Client{}
+	fs := NewHTTPFS(
+		WithHTTPFSClient(client),
+		WithHTTPFSMiddleware(mw("log")),
+		WithHTTPFSMiddleware(mw("auth")),
+	)
+	if client.Transport != nil {
+		t.Error("caller-supplied client should not be modified")
+	}
+
+	if err := fs.Add("raw", server.URL, &RawParser{}); err != nil {
+		t.Fatal(err)
+	}
+	fs.fetchSource(context.Background(), "raw")
+
+	if strings.Join(order, ",") != "log,auth" {
+		t.Errorf("middleware order = %v, want [log auth]", order)
+	}
+	if gotAuth != "Bearer injected" {
+		t.Errorf("Authorization = %q, want injected token", gotAuth)
+	}
+	if _, err := fs.Stat(context.Background(), "raw/content.txt"); err != nil {
+		t.Errorf("expected fetched file: %v", err)
+	}
+}
+
+func TestHealthCheck(t *testing.T) {
+	var gotMethod string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotMethod = r.Method
+		if r.URL.Path == "/down" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	fs := NewHTTPFS()
+	if err := fs.Add("up", server.URL+"/up", &RawParser{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := fs.Add("down", server.URL+"/down", &RawParser{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := fs.Add("dead", "http://127.0.0.1:1/", &RawParser{}); err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	h := fs.HealthCheck(ctx, "up")
+	if !h.Reachable || h.StatusCode != http.StatusOK || h.Error != nil {
+		t.Errorf("up: %+v", h)
+	}
+	if gotMethod != http.MethodHead {
+		t.Errorf("method = %s, want HEAD", gotMethod)
+	}
+	if h.Latency <= 0 {
+		t.Errorf("latency should be measured, got %v", h.Latency)
+	}
+
+	if h := fs.HealthCheck(ctx, "missing"); h.Error == nil {
+		t.Error("unknown source should report an error")
+	}
+
+	all := fs.HealthCheckAll(ctx)
+	if len(all) != 3 {
+		t.Fatalf("HealthCheckAll returned %d results, want 3", len(all))
+	}
+	if all["down"].StatusCode != http.StatusServiceUnavailable || !all["down"].Reachable {
+		t.Errorf("down: %+v", all["down"])
+	}
+	if all["dead"].Reachable || all["dead"].Error == nil {
+		t.Errorf("dead: %+v", all["dead"])
+	}
+}
+
+func TestHTTPFSDeduplication(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("same article"))
+	}))
+	defer server.Close()
+
+	fs := NewHTTPFS(WithHTTPFSDeduplication(true))
+	ctx := context.Background()
+	for _, name := range []string{"a", "b"} {
+		if err := fs.Add(name, server.URL+"/"+name, &RawParser{Filename: "post"}); err != nil {
+			t.Fatal(err)
+		}
+		fs.fetchSource(ctx, name)
+	}
+
+	refs, err := fs.References("a/post.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(refs, ",") != "a/post.txt,b/post.txt" {
+		t.Errorf("References = %v, want both sources", refs)
+	}
+	if len(fs.blobs) != 1 {
+		t.Errorf("stored %d blobs, want 1", len(fs.blobs))
+	}
+
+	f, err := fs.Open(ctx, "b/post.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := io.ReadAll(f)
+	if string(data) != "same article" {
+		t.Errorf("Open = %q", data)
+	}
+	if e, _ := fs.Stat(ctx, "b/post.txt"); e == nil || e.Meta["sha256"] == "" {
+		t.Errorf("Stat should report the content hash: %+v", e)
+	}
+
+	// Grouped sources are addressed as group/source/file.
+	if err := fs.AddGroup("news", []SourceConfig{
+		{Name: "hn", URL: server.URL + "/hn", Parser: &RawParser{Filename: "item"}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	fs.fetchSource(ctx, "news/hn")
+	refs, err = fs.References("/news/hn/item.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(refs, ",") != "a/post.txt,b/post.txt,news/hn/item.txt" {
+		t.Errorf("References(news/hn/item.txt) = %v", refs)
+	}
+	for _, dir := range []string{"news", "news/hn", "a"} {
+		if _, err := fs.References(dir); !errors.Is(err, types.ErrIsDir) {
+			t.Errorf("References(%s): err = %v, want ErrIsDir", dir, err)
+		}
+	}
+	if err := fs.RemoveSource("news/hn"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := fs.RemoveSource("a"); err != nil {
+		t.Fatal(err)
+	}
+	if refs, _ := fs.References("b/post.txt"); len(refs) != 1 {
+		t.Errorf("References after RemoveSource = %v", refs)
+	}
+	if err := fs.RemoveSource("b"); err != nil {
+		t.Fatal(err)
+	}
+	if len(fs.blobs) != 0 {
+		t.Errorf("blobs not released: %d left", len(fs.blobs))
+	}
+}
+
+func TestHTTPFSOnError(t *testing.T) {
+	failing := true
+	var mu sync.Mutex
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch {
+		case r.URL.Path == "/flaky" && failing:
+			w.WriteHeader(http.StatusInternalServerError)
+		case r.URL.Path == "/json":
+			_, _ = w.Write([]byte("not json"))
+		default:
+			_, _ = w.Write([]byte("ok"))
+		}
+	}))
+	defer server.Close()
+
+	reported := make(map[string]error)
+	fs := NewHTTPFS(WithHTTPFSOnError(func(name string, err error) {
+		mu.Lock()
+		reported[name] = err
+		mu.Unlock()
+	}))
+	if err := fs.Add("good", server.URL+"/good", &RawParser{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := fs.Add("flaky", server.URL+"/flaky", &RawParser{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := fs.Add("json", server.URL+"/json", &JSONParser{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := fs.Add("dead", "http://127.0.0.1:1/", &RawParser{}); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	fs.fetchAll(ctx)
+
+	errs := fs.Errors()
+	if len(errs) != 3 || errs["good"] != nil {
+		t.Fatalf("Errors() = %v, want flaky, json and dead", errs)
+	}
+	if !strings.Contains(errs["flaky"].Error(), "500") {
+		t.Errorf("flaky error = %v, want status", errs["flaky"])
+	}
+	if !strings.Contains(errs["json"].Error(), "invalid JSON") {
+		t.Errorf("json error = %v, want parse error", errs["json"])
+	}
+	mu.Lock()
+	if len(reported) != 3 || reported["dead"] == nil {
+		t.Errorf("OnError reported %v", reported)
+	}
+	failing = false
+	mu.Unlock()
+
+	// A successful fetch clears the recorded error.
+	fs.fetchSource(ctx, "flaky")
+	if err, ok := fs.Errors()["flaky"]; ok {
+		t.Errorf("flaky still failing after recovery: %v", err)
+	}
+}
+
+func TestHTTPFSMaxResponseSize(t *testing.T) {
+	var mu sync.Mutex
+	body := "small"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		_, _ = w.Write([]byte(body))
+	}))
+	defer server.Close()
+
+	var reported error
+	fs := NewHTTPFS(
+		WithHTTPFSMaxResponseSize(16),
+		WithHTTPFSOnError(func(name string, err error) { reported = err }),
+	)
+	if err := fs.Add("src", server.URL, &RawParser{}); err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	fs.fetchSource(ctx, "src")
+	entries, err := fs.List(ctx, "src", types.ListOpts{})
+	if err != nil || len(entries) != 1 {
+		t.Fatalf("List = %v, %v", entries, err)
+	}
+	filePath := "src/" + entries[0].Name
+
+	mu.Lock()
+	body = strings.Repeat("x", 17)
+	mu.Unlock()
+	fs.fetchSource(ctx, "src")
+	if !errors.Is(reported, ErrResponseTooLarge) {
+		t.Errorf("OnError got %v, want ErrResponseTooLarge", reported)
+	}
+	if err := fs.Errors()["src"]; !errors.Is(err, ErrResponseTooLarge) {
+		t.Errorf("Errors()[src] = %v", err)
+	}
+	rc, err := fs.Open(ctx, filePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := io.ReadAll(rc)
+	rc.Close()
+	if string(data) != "small" {
+		t.Errorf("content after oversized fetch = %q, want previous content", data)
+	}
+
+	// A body exactly at the limit is accepted.
+	mu.Lock()
+	body = strings.Repeat("y", 16)
+	mu.Unlock()
+	fs.fetchSource(ctx, "src")
+	if err := fs.Errors()["src"]; err != nil {
+		t.Errorf("fetch at limit failed: %v", err)
+	}
+}
+
+func TestHTTPFSAddGroup(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("body of " + r.URL.Path))
+	}))
+	defer server.Close()
+
+	fs := NewHTTPFS()
+	if err := fs.Add("status", server.URL+"/status", &RawParser{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := fs.AddGroup("news", []SourceConfig{
+		{Name: "hn", URL: server.URL + "/hn", Parser: &RawParser{}},
+		{Name: "lobsters", URL: server.URL + "/lobsters", Parser: &RawParser{}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	// The same source name may be reused in a different group.
+	if err := fs.AddGroup("github", []SourceConfig{{Name: "hn", URL: server.URL + "/gh"}}); err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	fs.fetchAll(ctx)
+
+	root, err := fs.List(ctx, "", types.ListOpts{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, e := range root {
+		names = append(names, e.Name)
+	}
+	if got := strings.Join(names, ","); got != "github,news,status" {
+		t.Errorf("root = %s, want github,news,status", got)
+	}
+
+	group, err := fs.List(ctx, "news", types.ListOpts{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(group) != 2 || group[0].Name != "hn" || group[1].Name != "lobsters" {
+		t.Errorf("news = %v, want hn and lobsters", group)
+	}
+	if e, err := fs.Stat(ctx, "news"); err != nil || !e.IsDir || e.Meta["sources"] != "2" {
+		t.Errorf("Stat(news) = %v, %v", e, err)
+	}
+
+	f, err := fs.Open(ctx, "/news/hn/content.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := io.ReadAll(f)
+	_ = f.Close()
+	if string(data) != "body of /hn" {
+		t.Errorf("news/hn content = %q", data)
+	}
+	if _, err := fs.Open(ctx, "news"); !errors.Is(err, types.ErrIsDir) {
+		t.Errorf("Open(news) err = %v, want ErrIsDir", err)
+	}
+	if got := fs.Sources()["github/hn"]; got != server.URL+"/gh" {
+		t.Errorf("Sources()[github/hn] = %q", got)
+	}
+
+	// Collisions between groups and sources are rejected.
+	if err := fs.AddGroup("status", nil); err == nil {
+		t.Error("AddGroup over an existing source should fail")
+	}
+	if err := fs.Add("news", server.URL, &RawParser{}); err == nil {
+		t.Error("Add over an existing group should fail")
+	}
+	if err := fs.AddGroup("news", []SourceConfig{{Name: "hn", URL: server.URL}}); err == nil {
+		t.Error("duplicate source in group should fail")
+	}
+
+	// Subscribing through the shell works inside an existing group only.
+	if err := fs.Write(ctx, "news/reddit", strings.NewReader(server.URL+"/reddit")); err != nil {
+		t.Errorf("Write into group: %v", err)
+	}
+	if err := fs.Write(ctx, "nogroup/x", strings.NewReader(server.URL)); err == nil {
+		t.Error("Write into unknown group should fail")
+	}
+
+	if err := fs.Remove(ctx, "news/hn"); err != nil {
+		t.Fatal(err)
+	}
+	if err := fs.Remove(ctx, "github"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := fs.Stat(ctx, "github/hn"); !errors.Is(err, types.ErrNotFound) {
+		t.Errorf("github/hn after group removal: %v", err)
+	}
+	if got := fs.Groups(); len(got) != 1 || got[0] != "news" {
+		t.Errorf("Groups() = %v, want [news]", got)
+	}
+}
@@ -0,0 +1,121 @@
+package httpfs
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// OpenAPISpec is a parsed OpenAPI 3.x specification: its operations,
+// narrowed with FilterOperations and turned into sources with LoadInto.
+//
+//	spec, err := httpfs.ParseOpenAPI(data)
+//	if err != nil { ... }
+//	err = spec.FilterOperations(func(path, method string, op httpfs.Operation) bool {
+//		return method == "GET" && !strings.Contains(path, "{")
+//	}).LoadInto(fs)
+type OpenAPISpec struct {
+	raw     map[string]any // the whole document, for resolving $ref
+	baseURL string
+	ops     []specOperation // sorted by path, then method
+}
+
+// Operation describes one operation of an OpenAPI spec.
+type Operation struct {
+	OperationID string
+	Summary     string
+	Description string
+	Tags        []string
+	Deprecated  bool
+
+	op *openAPIOperation
+}
+
+type specOperation struct {
+	path   string
+	method string
+	op     Operation
+}
+
+// ParseOpenAPI parses an OpenAPI 3.x specification (JSON). The first
+// server URL, if any, is the base URL of the sources LoadInto creates.
+func ParseOpenAPI(spec []byte) (*OpenAPISpec, error) {
+	var raw map[string]any
+	if err := json.Unmarshal(spec, &raw); err != nil {
+		return nil, fmt.Errorf("invalid OpenAPI spec: %w", err)
+	}
+	var api openAPISpec
+	if err := json.Unmarshal(spec, &api); err != nil {
+		return nil, fmt.Errorf("invalid OpenAPI spec: %w", err)
+	}
+
+	s := &OpenAPISpec{raw: raw}
+	if len(api.Servers) > 0 {
+		s.baseURL = strings.TrimRight(api.Servers[0].URL, "/")
+	}
+	for path, item := range api.Paths {
+		for _, m := range item.operations() {
+			if m.op == nil {
+				continue
+			}
+			s.ops = append(s.ops, specOperation{path: path, method: m.method, op: Operation{
+				OperationID: m.op.OperationID,
+				Summary:     m.op.Summary,
+				Description: m.op.Description,
+				Tags:        m.op.Tags,
+				Deprecated:  m.op.Deprecated,
+				op:          m.op,
+			}})
+		}
+	}
+	sort.Slice(s.ops, func(i, j int) bool {
+		if s.ops[i].path != s.ops[j].path {
+			return s.ops[i].path < s.ops[j].path
+		}
+		return s.ops[i].method < s.ops[j].method
+	})
+	return s, nil
+}
+
+// FilterOperations returns a spec holding only the operations for which
+// keep returns true. method is upper case, as in "GET". s is unchanged, so
+// one parsed spec can be filtered several ways.
+func (s *OpenAPISpec) FilterOperations(keep func(path, method string, op Operation) bool) *OpenAPISpec {
+	out := &OpenAPISpec{raw: s.raw, baseURL: s.baseURL}
+	for _, o := range s.ops {
+		if keep(o.path, o.method, o.op) {
+			out.ops = append(out.ops, o)
+		}
+	}
+	return out
+}
+
+// LoadInto adds a source to fs for each GET operation in s, in path order.
+// Sources are named after the path (/api/v1/posts becomes api-v1-posts) and
+// get a parser chosen from the 200 response schema. Other methods are
+// skipped, since sources are polled with GET. A path with parameters, such
+// as /users/{id}, cannot be polled without values for them and is an
+// error; filter such operations out first.
+func (s *OpenAPISpec) LoadInto(fs *HTTPFS, opts ...SourceOption) error {
+	for _, o := range s.ops {
+		if o.method != http.MethodGet {
+			continue
+		}
+		if strings.Contains(o.path, "{") {
+			return fmt.Errorf("endpoint %s: path parameters are not supported", o.path)
+		}
+		parser := inferParserFromOpenAPI(s.raw, o.op.op)
+		if err := fs.Add(openAPIPathToName(o.path), s.baseURL+o.path, parser, opts...); err != nil {
+			return fmt.Errorf("endpoint %s: %w", o.path, err)
+		}
+	}
+	return nil
+}
+
+// pollableOperation keeps the operations LoadOpenAPI loads: GET endpoints
+// without path parameters.
+func pollableOperation(path, method string, _ Operation) bool {
+	return method == http.MethodGet && !strings.Contains(path, "{")
+}
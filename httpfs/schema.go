@@ -6,13 +6,18 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"path"
 	"strings"
+
+	grasp "github.com/jackfish212/grasp"
+	"gopkg.in/yaml.v3"
 )
 
 // ─── Declarative Schema ───
 
-// HTTPFSSchema defines a declarative JSON configuration for bulk-adding
-// HTTPFS sources. Useful for loading source definitions from config files.
+// Schema is the declarative JSON configuration read by LoadSchema for
+// bulk-adding HTTPFS sources. Useful for loading source definitions from
+// config files; JSONSchema describes the format for editors.
 //
 // Example:
 //
@@ -25,12 +30,19 @@ import (
 //	    "status": { "path": "/health", "parser": { "type": "raw" } }
 //	  }
 //	}
-type HTTPFSSchema struct {
-	BaseURL  string                 `json:"baseURL,omitempty"`
-	Defaults SchemaDefaults         `json:"defaults,omitempty"`
+type Schema struct {
+	// BaseURL is the absolute URL that source paths are resolved against.
+	// It is required when any source uses Path.
+	BaseURL  string                  `json:"baseURL,omitempty"`
+	Defaults SchemaDefaults          `json:"defaults,omitempty"`
 	Sources  map[string]SchemaSource `json:"sources"`
 }
 
+// HTTPFSSchema is the former name of Schema.
+//
+// Deprecated: use Schema.
+type HTTPFSSchema = Schema
+
 // SchemaDefaults are applied to all sources unless overridden.
 type SchemaDefaults struct {
 	Headers map[string]string `json:"headers,omitempty"`
@@ -59,39 +71,69 @@ type SchemaParser struct {
 }
 
 // LoadSchema loads sources from a declarative JSON configuration.
-// All configured sources are added to the HTTPFS; call Start to begin polling.
+// The schema is validated before any source is added; call Start to begin
+// polling.
 func (fs *HTTPFS) LoadSchema(data []byte) error {
-	var schema HTTPFSSchema
+	var schema Schema
 	if err := json.Unmarshal(data, &schema); err != nil {
 		return fmt.Errorf("invalid schema: %w", err)
 	}
-
-	baseURL := strings.TrimRight(schema.BaseURL, "/")
-
-	for name, src := range schema.Sources {
-		url := src.URL
-		if url == "" && src.Path != "" {
-			url = baseURL + "/" + strings.TrimLeft(src.Path, "/")
-		}
-		if url == "" {
-			return fmt.Errorf("source %q: missing url or path", name)
+	if err := schema.Validate(); err != nil {
+		return err
+	}
+	for _, sc := range schema.sourceConfigs() {
+		if err := fs.Add(sc.Name, sc.URL, sc.Parser, sc.Opts...); err != nil {
+			return fmt.Errorf("source %q: %w", sc.Name, err)
 		}
+	}
+	return nil
+}
 
-		parser := buildParserFromSchema(src.Parser)
+// LoadSchemaYAML loads sources from a YAML configuration with the same
+// structure as the JSON schema accepted by LoadSchema.
+//
+// Example:
+//
+//	baseURL: https://api.example.com
+//	sources:
+//	  users:
+//	    path: /users
+//	    parser: {type: json, nameField: name}
+func (fs *HTTPFS) LoadSchemaYAML(data []byte) error {
+	var doc map[string]any
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return fmt.Errorf("invalid schema: %w", err)
+	}
+	// Round-trip through JSON so both formats share one decoder and its
+	// field names.
+	jsonData, err := json.Marshal(doc)
+	if err != nil {
+		return fmt.Errorf("invalid schema: %w", err)
+	}
+	return fs.LoadSchema(jsonData)
+}
 
-		var opts []SourceOption
-		for k, v := range schema.Defaults.Headers {
-			opts = append(opts, WithSourceHeader(k, v))
-		}
-		for k, v := range src.Headers {
-			opts = append(opts, WithSourceHeader(k, v))
-		}
+// LoadSchemaFromFile reads a schema from the VFS and loads it, choosing the
+// format by extension: .yaml and .yml are YAML, .json is JSON.
+func (fs *HTTPFS) LoadSchemaFromFile(ctx context.Context, vos *grasp.VirtualOS, file string) error {
+	f, err := vos.Open(ctx, file)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = f.Close() }()
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return fmt.Errorf("read schema %s: %w", file, err)
+	}
 
-		if err := fs.Add(name, url, parser, opts...); err != nil {
-			return fmt.Errorf("source %q: %w", name, err)
-		}
+	switch ext := strings.ToLower(path.Ext(file)); ext {
+	case ".yaml", ".yml":
+		return fs.LoadSchemaYAML(data)
+	case ".json":
+		return fs.LoadSchema(data)
+	default:
+		return fmt.Errorf("schema %s: unsupported extension %q (want .json, .yaml or .yml)", file, ext)
 	}
-	return nil
 }
 
 func buildParserFromSchema(sp SchemaParser) ResponseParser {
@@ -121,57 +163,63 @@ func buildParserFromSchema(sp SchemaParser) ResponseParser {
 // they require runtime values that cannot be polled generically.
 //
 // Schema $ref references are resolved within the spec's components/schemas.
+// Use ParseOpenAPI and OpenAPISpec.FilterOperations to choose the endpoints.
 func (fs *HTTPFS) LoadOpenAPI(spec []byte, opts ...SourceOption) error {
-	var raw map[string]any
-	if err := json.Unmarshal(spec, &raw); err != nil {
-		return fmt.Errorf("invalid OpenAPI spec: %w", err)
+	api, err := ParseOpenAPI(spec)
+	if err != nil {
+		return err
 	}
+	return api.FilterOperations(pollableOperation).LoadInto(fs, opts...)
+}
 
-	var api openAPISpec
-	if err := json.Unmarshal(spec, &api); err != nil {
-		return fmt.Errorf("invalid OpenAPI spec: %w", err)
+// LoadOpenAPIFromURL fetches an OpenAPI spec from a URL and loads it.
+func (fs *HTTPFS) LoadOpenAPIFromURL(ctx context.Context, specURL string, opts ...SourceOption) error {
+	data, err := fs.fetchSpec(ctx, specURL, nil)
+	if err != nil {
+		return err
 	}
+	return fs.LoadOpenAPI(data, opts...)
+}
 
-	baseURL := ""
-	if len(api.Servers) > 0 {
-		baseURL = strings.TrimRight(api.Servers[0].URL, "/")
+// LoadOpenAPIFromURLWithAuth fetches an OpenAPI spec that sits behind
+// authentication, sending headers with the spec request. Since a spec usually
+// shares the auth of the API it describes, the same headers are also set on
+// every source it creates; opts are applied afterwards and may override them.
+func (fs *HTTPFS) LoadOpenAPIFromURLWithAuth(ctx context.Context, specURL string, headers map[string]string, opts ...SourceOption) error {
+	data, err := fs.fetchSpec(ctx, specURL, headers)
+	if err != nil {
+		return err
 	}
-
-	for path, item := range api.Paths {
-		if item.Get == nil || strings.Contains(path, "{") {
-			continue
-		}
-
-		name := openAPIPathToName(path)
-		url := baseURL + path
-		parser := inferParserFromOpenAPI(raw, item.Get)
-
-		if err := fs.Add(name, url, parser, opts...); err != nil {
-			return fmt.Errorf("endpoint %s: %w", path, err)
-		}
+	all := make([]SourceOption, 0, len(headers)+len(opts))
+	for k, v := range headers {
+		all = append(all, WithSourceHeader(k, v))
 	}
-	return nil
+	return fs.LoadOpenAPI(data, append(all, opts...)...)
 }
 
-// LoadOpenAPIFromURL fetches an OpenAPI spec from a URL and loads it.
-func (fs *HTTPFS) LoadOpenAPIFromURL(ctx context.Context, specURL string, opts ...SourceOption) error {
+// LoadOpenAPIFromURLWithToken is LoadOpenAPIFromURLWithAuth with an
+// "Authorization: Bearer <token>" header.
+func (fs *HTTPFS) LoadOpenAPIFromURLWithToken(ctx context.Context, specURL, token string, opts ...SourceOption) error {
+	return fs.LoadOpenAPIFromURLWithAuth(ctx, specURL, map[string]string{"Authorization": "Bearer " + token}, opts...)
+}
+
+func (fs *HTTPFS) fetchSpec(ctx context.Context, specURL string, headers map[string]string) ([]byte, error) {
 	req, err := http.NewRequestWithContext(ctx, "GET", specURL, nil)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	for k, v := range headers {
+		req.Header.Set(k, v)
 	}
 	resp, err := fs.client.Do(req)
 	if err != nil {
-		return fmt.Errorf("fetch spec: %w", err)
+		return nil, fmt.Errorf("fetch spec: %w", err)
 	}
 	defer resp.Body.Close()
 	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("fetch spec: HTTP %d", resp.StatusCode)
+		return nil, fmt.Errorf("fetch spec: HTTP %d", resp.StatusCode)
 	}
-	data, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return err
-	}
-	return fs.LoadOpenAPI(data, opts...)
+	return io.ReadAll(resp.Body)
 }
 
 // ─── OpenAPI types (minimal subset) ───
@@ -184,12 +232,43 @@ type openAPISpec struct {
 }
 
 type openAPIPathItem struct {
-	Get *openAPIOperation `json:"get"`
+	Get     *openAPIOperation `json:"get"`
+	Put     *openAPIOperation `json:"put"`
+	Post    *openAPIOperation `json:"post"`
+	Delete  *openAPIOperation `json:"delete"`
+	Options *openAPIOperation `json:"options"`
+	Head    *openAPIOperation `json:"head"`
+	Patch   *openAPIOperation `json:"patch"`
+	Trace   *openAPIOperation `json:"trace"`
+}
+
+// operations lists the item's operations by HTTP method; missing ones are
+// nil.
+func (item openAPIPathItem) operations() []struct {
+	method string
+	op     *openAPIOperation
+} {
+	return []struct {
+		method string
+		op     *openAPIOperation
+	}{
+		{http.MethodGet, item.Get},
+		{http.MethodPut, item.Put},
+		{http.MethodPost, item.Post},
+		{http.MethodDelete, item.Delete},
+		{http.MethodOptions, item.Options},
+		{http.MethodHead, item.Head},
+		{http.MethodPatch, item.Patch},
+		{http.MethodTrace, item.Trace},
+	}
 }
 
 type openAPIOperation struct {
 	OperationID string                     `json:"operationId"`
 	Summary     string                     `json:"summary"`
+	Description string                     `json:"description"`
+	Tags        []string                   `json:"tags"`
+	Deprecated  bool                       `json:"deprecated"`
 	Responses   map[string]openAPIResponse `json:"responses"`
 }
 
@@ -0,0 +1,230 @@
+package httpfs
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// ErrInvalidSchema is returned by Schema.Validate, and so by LoadSchema,
+// when a configuration is incomplete or malformed.
+var ErrInvalidSchema = errors.New("httpfs: invalid schema")
+
+// schemaParserTypes are the values accepted for SchemaParser.Type.
+var schemaParserTypes = []string{"auto", "rss", "json", "raw"}
+
+// Validate checks that the schema can be loaded: it has at least one
+// source, every source has a URL or a Path, BaseURL is set when a Path
+// needs it, URLs are absolute http or https URLs, parser types are known
+// and header names are valid HTTP tokens. Sources are checked in name
+// order and the first problem is reported.
+func (s *Schema) Validate() error {
+	if len(s.Sources) == 0 {
+		return fmt.Errorf("%w: no sources", ErrInvalidSchema)
+	}
+	if s.BaseURL != "" {
+		if err := checkSchemaURL(s.BaseURL); err != nil {
+			return fmt.Errorf("%w: baseURL: %v", ErrInvalidSchema, err)
+		}
+	}
+	if err := checkHeaderNames(s.Defaults.Headers); err != nil {
+		return fmt.Errorf("%w: defaults: %v", ErrInvalidSchema, err)
+	}
+
+	for _, name := range sortedSourceNames(s.Sources) {
+		src := s.Sources[name]
+		if err := s.checkSource(name, src); err != nil {
+			return fmt.Errorf("%w: source %q: %v", ErrInvalidSchema, name, err)
+		}
+	}
+	return nil
+}
+
+func (s *Schema) checkSource(name string, src SchemaSource) error {
+	if name == "" || strings.Contains(name, "/") {
+		return errors.New("invalid source name")
+	}
+	switch {
+	case src.URL != "":
+		if err := checkSchemaURL(src.URL); err != nil {
+			return fmt.Errorf("url: %v", err)
+		}
+	case src.Path != "":
+		if s.BaseURL == "" {
+			return errors.New("path requires baseURL")
+		}
+	default:
+		return errors.New("missing url or path")
+	}
+	if t := src.Parser.Type; t != "" && !slices.Contains(schemaParserTypes, t) {
+		return fmt.Errorf("unknown parser type %q (want one of %s)", t, strings.Join(schemaParserTypes, ", "))
+	}
+	return checkHeaderNames(src.Headers)
+}
+
+func checkSchemaURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return err
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("%q is not an absolute http or https URL", raw)
+	}
+	return nil
+}
+
+func checkHeaderNames(headers map[string]string) error {
+	for name := range headers {
+		if !isHeaderToken(name) {
+			return fmt.Errorf("invalid header name %q", name)
+		}
+	}
+	return nil
+}
+
+// isHeaderToken reports whether name is an RFC 9110 token, the syntax of
+// a header field name.
+func isHeaderToken(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, r := range name {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		case strings.ContainsRune("!#$%&'*+-.^_`|~", r):
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// ToHTTPFSOpts converts the schema into options for NewHTTPFS, one per
+// source, so that a configuration file can be combined with other options:
+//
+//	fs := httpfs.NewHTTPFS(append(schema.ToHTTPFSOpts(), httpfs.WithHTTPFSInterval(time.Minute))...)
+//
+// Options cannot fail, so call Validate first. A source that cannot be
+// added is reported through WithHTTPFSOnError, if that option comes
+// earlier in the list.
+func (s *Schema) ToHTTPFSOpts() []HTTPFSOption {
+	configs := s.sourceConfigs()
+	opts := make([]HTTPFSOption, 0, len(configs))
+	for _, sc := range configs {
+		opts = append(opts, func(fs *HTTPFS) {
+			if err := fs.Add(sc.Name, sc.URL, sc.Parser, sc.Opts...); err != nil && fs.onError != nil {
+				fs.onError(sc.Name, err)
+			}
+		})
+	}
+	return opts
+}
+
+// sourceConfigs resolves each source's URL, parser and headers, in name
+// order. Sources without a URL are skipped; Validate reports them.
+func (s *Schema) sourceConfigs() []SourceConfig {
+	baseURL := strings.TrimRight(s.BaseURL, "/")
+	var configs []SourceConfig
+	for _, name := range sortedSourceNames(s.Sources) {
+		src := s.Sources[name]
+		u := src.URL
+		if u == "" && src.Path != "" {
+			u = baseURL + "/" + strings.TrimLeft(src.Path, "/")
+		}
+		if u == "" {
+			continue
+		}
+		var opts []SourceOption
+		for k, v := range s.Defaults.Headers {
+			opts = append(opts, WithSourceHeader(k, v))
+		}
+		for k, v := range src.Headers {
+			opts = append(opts, WithSourceHeader(k, v))
+		}
+		configs = append(configs, SourceConfig{
+			Name:   name,
+			URL:    u,
+			Parser: buildParserFromSchema(src.Parser),
+			Opts:   opts,
+		})
+	}
+	return configs
+}
+
+func sortedSourceNames(sources map[string]SchemaSource) []string {
+	names := make([]string, 0, len(sources))
+	for name := range sources {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// JSONSchema returns a JSON Schema (draft 2020-12) describing the format
+// read by LoadSchema. Editors use it for completion and checking when a
+// configuration file points at it with "$schema".
+func JSONSchema() []byte {
+	return []byte(schemaJSONSchema)
+}
+
+const schemaJSONSchema = `{
+  "$schema": "https://json-schema.org/draft/2020-12/schema",
+  "title": "HTTPFS sources",
+  "type": "object",
+  "required": ["sources"],
+  "properties": {
+    "$schema": {"type": "string"},
+    "baseURL": {
+      "type": "string",
+      "format": "uri",
+      "pattern": "^https?://",
+      "description": "Absolute URL that source paths are resolved against."
+    },
+    "defaults": {
+      "type": "object",
+      "properties": {
+        "headers": {"$ref": "#/$defs/headers"}
+      },
+      "additionalProperties": false
+    },
+    "sources": {
+      "type": "object",
+      "minProperties": 1,
+      "propertyNames": {"pattern": "^[^/]+$"},
+      "additionalProperties": {"$ref": "#/$defs/source"}
+    }
+  },
+  "additionalProperties": false,
+  "$defs": {
+    "headers": {
+      "type": "object",
+      "propertyNames": {"pattern": "^[!#$%&'*+.^_` + "`" + `|~0-9A-Za-z-]+$"},
+      "additionalProperties": {"type": "string"}
+    },
+    "source": {
+      "type": "object",
+      "properties": {
+        "url": {"type": "string", "format": "uri", "pattern": "^https?://", "description": "Absolute URL of the source."},
+        "path": {"type": "string", "description": "Path resolved against baseURL."},
+        "headers": {"$ref": "#/$defs/headers"},
+        "parser": {
+          "type": "object",
+          "properties": {
+            "type": {"enum": ["auto", "rss", "json", "raw"], "default": "auto"},
+            "arrayField": {"type": "string", "description": "json: dot path to the array of items."},
+            "nameField": {"type": "string", "description": "json: field used for file names."},
+            "idField": {"type": "string", "description": "json: field used to recognise an item across fetches."},
+            "filename": {"type": "string", "description": "raw: name of the file holding the body."}
+          },
+          "additionalProperties": false
+        }
+      },
+      "anyOf": [{"required": ["url"]}, {"required": ["path"]}],
+      "additionalProperties": false
+    }
+  }
+}
+`
@@ -18,7 +18,7 @@ func setupIntegration(t *testing.T) (*grasp.VirtualOS, *grasp.Shell) {
 	if err != nil {
 		t.Fatalf("Configure: %v", err)
 	}
-	if err := builtins.RegisterBuiltinsOnFS(v, rootFS); err != nil {
+	if _, err := builtins.RegisterBuiltinsOnFS(v, rootFS); err != nil {
 		t.Fatalf("RegisterBuiltinsOnFS: %v", err)
 	}
 
@@ -0,0 +1,383 @@
+package xz
+
+// LZMA decoding, as used inside LZMA2 chunks. The layout of the
+// probability model follows the LZMA specification; see xz-embedded for a
+// reference implementation of the same scheme.
+
+const (
+	states        = 12
+	maxPosBits    = 4
+	distStates    = 4
+	distSlots     = 64
+	distModelEnd  = 14
+	fullDistances = 128
+	alignBits     = 4
+	literalCoders = 0x300
+)
+
+// prob is an adaptive bit probability, scaled to 11 bits.
+type prob uint16
+
+const probInit = prob(1 << 10)
+
+func resetProbs(p []prob) {
+	for i := range p {
+		p[i] = probInit
+	}
+}
+
+// rangeDecoder decodes bits from one LZMA2 chunk held in memory.
+type rangeDecoder struct {
+	in   []byte
+	pos  int
+	rng  uint32
+	code uint32
+	eof  bool // read past the end of in
+}
+
+func (rc *rangeDecoder) init(in []byte) error {
+	if len(in) < 5 || in[0] != 0 {
+		return errFormat
+	}
+	*rc = rangeDecoder{
+		in:   in,
+		pos:  5,
+		rng:  0xFFFFFFFF,
+		code: uint32(in[1])<<24 | uint32(in[2])<<16 | uint32(in[3])<<8 | uint32(in[4]),
+	}
+	return nil
+}
+
+// finished reports whether the chunk was consumed exactly, as an encoder
+// flush leaves it. Bits normalize before decoding, so the last input byte
+// is only read here.
+func (rc *rangeDecoder) finished() bool {
+	rc.normalize()
+	return !rc.eof && rc.pos == len(rc.in) && rc.code == 0
+}
+
+func (rc *rangeDecoder) normalize() {
+	if rc.rng >= 1<<24 {
+		return
+	}
+	rc.rng <<= 8
+	rc.code <<= 8
+	if rc.pos < len(rc.in) {
+		rc.code |= uint32(rc.in[rc.pos])
+		rc.pos++
+	} else {
+		rc.eof = true
+	}
+}
+
+func (rc *rangeDecoder) bit(p *prob) uint32 {
+	rc.normalize()
+	bound := (rc.rng >> 11) * uint32(*p)
+	if rc.code < bound {
+		rc.rng = bound
+		*p += (1<<11 - *p) >> 5
+		return 0
+	}
+	rc.rng -= bound
+	rc.code -= bound
+	*p -= *p >> 5
+	return 1
+}
+
+// tree decodes a symbol of log2(len(p)) bits, most significant bit first.
+func (rc *rangeDecoder) tree(p []prob) uint32 {
+	sym := uint32(1)
+	for sym < uint32(len(p)) {
+		sym = sym<<1 | rc.bit(&p[sym])
+	}
+	return sym - uint32(len(p))
+}
+
+// reverse decodes a symbol of n bits, least significant bit first, using
+// the probabilities at p[off+1:].
+func (rc *rangeDecoder) reverse(p []prob, off int, n uint32) uint32 {
+	sym, v := uint32(1), uint32(0)
+	for i := uint32(0); i < n; i++ {
+		b := rc.bit(&p[off+int(sym)])
+		sym = sym<<1 | b
+		v |= b << i
+	}
+	return v
+}
+
+// direct decodes n bits with fixed probability one half.
+func (rc *rangeDecoder) direct(n uint32) uint32 {
+	var v uint32
+	for ; n > 0; n-- {
+		rc.normalize()
+		rc.rng >>= 1
+		rc.code -= rc.rng
+		mask := 0 - (rc.code >> 31)
+		rc.code += rc.rng & mask
+		v = v<<1 + mask + 1
+	}
+	return v
+}
+
+// window is the LZMA dictionary: the most recent size bytes of output. It
+// grows as data is decoded, so a large declared size costs nothing until
+// that much has been written.
+type window struct {
+	buf   []byte
+	size  int
+	pos   int    // next write position in buf
+	full  int    // bytes of history available, at most size
+	total uint64 // bytes written since the last reset
+	out   []byte // bytes written during the current chunk
+}
+
+func (w *window) reset() {
+	w.buf = w.buf[:0]
+	w.pos, w.full, w.total = 0, 0, 0
+}
+
+func (w *window) put(b byte) {
+	if w.pos == w.size {
+		w.pos = 0
+	}
+	if w.pos < len(w.buf) {
+		w.buf[w.pos] = b
+	} else {
+		w.buf = append(w.buf, b)
+	}
+	w.pos++
+	if w.full < w.size {
+		w.full++
+	}
+	w.total++
+	w.out = append(w.out, b)
+}
+
+// get returns the byte dist+1 positions back.
+func (w *window) get(dist uint32) byte {
+	i := w.pos - int(dist) - 1
+	if i < 0 {
+		i += len(w.buf)
+	}
+	return w.buf[i]
+}
+
+type lenDecoder struct {
+	choice  prob
+	choice2 prob
+	low     [1 << maxPosBits][8]prob
+	mid     [1 << maxPosBits][8]prob
+	high    [256]prob
+}
+
+func (l *lenDecoder) reset() {
+	l.choice, l.choice2 = probInit, probInit
+	for i := range l.low {
+		resetProbs(l.low[i][:])
+		resetProbs(l.mid[i][:])
+	}
+	resetProbs(l.high[:])
+}
+
+func (l *lenDecoder) decode(rc *rangeDecoder, posState uint32) int {
+	if rc.bit(&l.choice) == 0 {
+		return 2 + int(rc.tree(l.low[posState][:]))
+	}
+	if rc.bit(&l.choice2) == 0 {
+		return 10 + int(rc.tree(l.mid[posState][:]))
+	}
+	return 18 + int(rc.tree(l.high[:]))
+}
+
+// lzmaDecoder holds the LZMA state carried between LZMA2 chunks.
+type lzmaDecoder struct {
+	lc, lp, pb uint32
+	state      uint32
+	rep        [4]uint32
+
+	literal     []prob
+	isMatch     [states << maxPosBits]prob
+	isRep       [states]prob
+	isRep0      [states]prob
+	isRep1      [states]prob
+	isRep2      [states]prob
+	isRep0Long  [states << maxPosBits]prob
+	distSlot    [distStates][distSlots]prob
+	distSpecial [fullDistances - distModelEnd]prob
+	distAlign   [1 << alignBits]prob
+	matchLen    lenDecoder
+	repLen      lenDecoder
+}
+
+// setProps applies an LZMA2 properties byte encoding lc, lp and pb.
+func (d *lzmaDecoder) setProps(b byte) error {
+	if b >= 9*5*5 {
+		return errFormat
+	}
+	d.lc = uint32(b % 9)
+	b /= 9
+	d.lp = uint32(b % 5)
+	d.pb = uint32(b / 5)
+	if d.lc+d.lp > 4 {
+		return errFormat
+	}
+	d.literal = make([]prob, literalCoders<<(d.lc+d.lp))
+	return nil
+}
+
+func (d *lzmaDecoder) reset() {
+	d.state = 0
+	d.rep = [4]uint32{}
+	resetProbs(d.literal)
+	resetProbs(d.isMatch[:])
+	resetProbs(d.isRep[:])
+	resetProbs(d.isRep0[:])
+	resetProbs(d.isRep1[:])
+	resetProbs(d.isRep2[:])
+	resetProbs(d.isRep0Long[:])
+	for i := range d.distSlot {
+		resetProbs(d.distSlot[i][:])
+	}
+	resetProbs(d.distSpecial[:])
+	resetProbs(d.distAlign[:])
+	d.matchLen.reset()
+	d.repLen.reset()
+}
+
+// decode writes n bytes decoded from rc into w.
+func (d *lzmaDecoder) decode(rc *rangeDecoder, w *window, n int) error {
+	pbMask := uint64(1)<<d.pb - 1
+	for len(w.out) < n {
+		posState := uint32(w.total & pbMask)
+		if rc.bit(&d.isMatch[d.state<<maxPosBits|posState]) == 0 {
+			d.decodeLiteral(rc, w)
+		} else {
+			var length int
+			if rc.bit(&d.isRep[d.state]) == 0 {
+				length = d.decodeMatch(rc, posState)
+			} else {
+				length = d.decodeRep(rc, posState)
+			}
+			if int(d.rep[0]) >= w.full || length > n-len(w.out) {
+				return errFormat
+			}
+			for ; length > 0; length-- {
+				w.put(w.get(d.rep[0]))
+			}
+		}
+		if rc.eof {
+			return errFormat
+		}
+	}
+	return nil
+}
+
+func (d *lzmaDecoder) decodeLiteral(rc *rangeDecoder, w *window) {
+	var prev uint32
+	if w.full > 0 {
+		prev = uint32(w.get(0))
+	}
+	lpMask := uint32(1)<<d.lp - 1
+	ctx := (uint32(w.total)&lpMask)<<d.lc + prev>>(8-d.lc)
+	probs := d.literal[literalCoders*ctx : literalCoders*(ctx+1)]
+
+	sym := uint32(1)
+	if d.state < 7 {
+		for sym < 0x100 {
+			sym = sym<<1 | rc.bit(&probs[sym])
+		}
+	} else {
+		// After a match, the byte at rep0 predicts the literal until the
+		// first bit that differs from it.
+		match := uint32(w.get(d.rep[0])) << 1
+		offset := uint32(0x100)
+		for sym < 0x100 {
+			matchBit := match & offset
+			match <<= 1
+			b := rc.bit(&probs[offset+matchBit+sym])
+			sym = sym<<1 | b
+			if b == 1 {
+				offset = matchBit
+			} else {
+				offset &^= matchBit
+			}
+		}
+	}
+	w.put(byte(sym))
+
+	switch {
+	case d.state < 4:
+		d.state = 0
+	case d.state < 10:
+		d.state -= 3
+	default:
+		d.state -= 6
+	}
+}
+
+// decodeMatch decodes a match with a new distance into rep[0] and returns
+// its length.
+func (d *lzmaDecoder) decodeMatch(rc *rangeDecoder, posState uint32) int {
+	if d.state < 7 {
+		d.state = 7
+	} else {
+		d.state = 10
+	}
+	d.rep[3], d.rep[2], d.rep[1] = d.rep[2], d.rep[1], d.rep[0]
+	length := d.matchLen.decode(rc, posState)
+
+	distState := min(length-2, distStates-1)
+	slot := rc.tree(d.distSlot[distState][:])
+	if slot < 4 {
+		d.rep[0] = slot
+		return length
+	}
+	limit := slot>>1 - 1
+	dist := 2 + slot&1
+	if slot < distModelEnd {
+		dist <<= limit
+		dist += rc.reverse(d.distSpecial[:], int(dist)-int(slot)-1, limit)
+	} else {
+		dist = dist<<(limit-alignBits) | rc.direct(limit-alignBits)
+		dist = dist<<alignBits | rc.reverse(d.distAlign[:], 0, alignBits)
+	}
+	d.rep[0] = dist
+	return length
+}
+
+// decodeRep decodes a match reusing one of the last four distances, moving
+// it to rep[0], and returns its length.
+func (d *lzmaDecoder) decodeRep(rc *rangeDecoder, posState uint32) int {
+	if rc.bit(&d.isRep0[d.state]) == 0 {
+		if rc.bit(&d.isRep0Long[d.state<<maxPosBits|posState]) == 0 {
+			// Short rep: a single byte from rep0.
+			if d.state < 7 {
+				d.state = 9
+			} else {
+				d.state = 11
+			}
+			return 1
+		}
+	} else {
+		var dist uint32
+		if rc.bit(&d.isRep1[d.state]) == 0 {
+			dist = d.rep[1]
+		} else {
+			if rc.bit(&d.isRep2[d.state]) == 0 {
+				dist = d.rep[2]
+			} else {
+				dist = d.rep[3]
+				d.rep[3] = d.rep[2]
+			}
+			d.rep[2] = d.rep[1]
+		}
+		d.rep[1] = d.rep[0]
+		d.rep[0] = dist
+	}
+	if d.state < 7 {
+		d.state = 8
+	} else {
+		d.state = 11
+	}
+	return d.repLen.decode(rc, posState)
+}
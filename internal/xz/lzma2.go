@@ -0,0 +1,107 @@
+package xz
+
+import "io"
+
+// lzma2Decoder decodes the LZMA2 data of one block, a chunk at a time.
+type lzma2Decoder struct {
+	in        *input
+	dict      window
+	lz        lzmaDecoder
+	needReset bool // the first chunk must reset the dictionary
+	needProps bool // an LZMA chunk must set properties before use
+	buf       []byte
+}
+
+func newLZMA2Decoder(in *input, dictSize int) *lzma2Decoder {
+	return &lzma2Decoder{in: in, dict: window{size: dictSize}, needReset: true, needProps: true}
+}
+
+// next decodes the next chunk and returns its data, valid until the
+// following call. It returns io.EOF at the end of the block's data.
+func (d *lzma2Decoder) next() ([]byte, error) {
+	c, err := d.in.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+	if c == 0x00 {
+		return nil, io.EOF
+	}
+	if c >= 0xE0 || c == 0x01 {
+		d.needProps = true
+		d.needReset = false
+		d.dict.reset()
+	} else if d.needReset {
+		return nil, errFormat
+	}
+	d.dict.out = d.dict.out[:0]
+
+	if c < 0x80 {
+		// Uncompressed chunk.
+		if c > 0x02 {
+			return nil, errFormat
+		}
+		var h [2]byte
+		if err := d.in.full(h[:]); err != nil {
+			return nil, err
+		}
+		data, err := d.read(int(h[0])<<8 | int(h[1]) + 1)
+		if err != nil {
+			return nil, err
+		}
+		for _, b := range data {
+			d.dict.put(b)
+		}
+		return d.dict.out, nil
+	}
+
+	var h [4]byte
+	if err := d.in.full(h[:]); err != nil {
+		return nil, err
+	}
+	unpacked := int(c&0x1F)<<16 | int(h[0])<<8 | int(h[1]) + 1
+	packed := int(h[2])<<8 | int(h[3]) + 1
+	switch {
+	case c >= 0xC0:
+		props, err := d.in.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+		if err := d.lz.setProps(props); err != nil {
+			return nil, err
+		}
+		d.needProps = false
+		d.lz.reset()
+	case d.needProps:
+		return nil, errFormat
+	case c >= 0xA0:
+		d.lz.reset()
+	}
+
+	data, err := d.read(packed)
+	if err != nil {
+		return nil, err
+	}
+	var rc rangeDecoder
+	if err := rc.init(data); err != nil {
+		return nil, err
+	}
+	if err := d.lz.decode(&rc, &d.dict, unpacked); err != nil {
+		return nil, err
+	}
+	if !rc.finished() {
+		return nil, errFormat
+	}
+	return d.dict.out, nil
+}
+
+// read reads n bytes of chunk data into d.buf.
+func (d *lzma2Decoder) read(n int) ([]byte, error) {
+	if cap(d.buf) < n {
+		d.buf = make([]byte, n)
+	}
+	d.buf = d.buf[:n]
+	if err := d.in.full(d.buf); err != nil {
+		return nil, err
+	}
+	return d.buf, nil
+}
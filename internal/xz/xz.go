@@ -0,0 +1,437 @@
+// Package xz decompresses the .xz format written by xz(1) and liblzma.
+//
+// Blocks must use the LZMA2 filter alone, which is what xz produces unless
+// given explicit filter options; BCJ and delta filters are not supported.
+// Block checks (CRC32, CRC64, SHA-256), the index, and concatenated streams
+// are all verified.
+package xz
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"hash"
+	"hash/crc32"
+	"hash/crc64"
+	"io"
+)
+
+var (
+	errFormat      = errors.New("xz: invalid or corrupt data")
+	errUnsupported = errors.New("xz: unsupported filter or option")
+)
+
+var (
+	headerMagic = []byte{0xFD, '7', 'z', 'X', 'Z', 0x00}
+	footerMagic = []byte{'Y', 'Z'}
+	crc64Table  = crc64.MakeTable(crc64.ECMA)
+)
+
+const lzma2FilterID = 0x21
+
+// Reader decompresses an xz stream.
+type Reader struct {
+	in    input
+	flags [2]byte // stream flags; flags[1] is the check type
+
+	block      *lzma2Decoder // nil between blocks
+	check      hash.Hash     // nil when the check type is not verified
+	blockStart int64         // input offset of the block header
+	dataStart  int64         // input offset of the compressed data
+	packed     int64         // compressed size from the block header, or -1
+	unpacked   int64         // uncompressed size from the block header, or -1
+	size       int64         // bytes decoded in the current block
+	records    []record      // blocks of the current stream, for the index
+
+	out []byte
+	err error
+}
+
+// record is an index entry describing one block.
+type record struct {
+	unpadded     uint64
+	uncompressed uint64
+}
+
+// NewReader returns a Reader decompressing r, after checking that it starts
+// with an xz stream header.
+func NewReader(r io.Reader) (*Reader, error) {
+	z := &Reader{in: input{r: bufio.NewReader(r)}}
+	if err := z.streamHeader(); err != nil {
+		return nil, err
+	}
+	return z, nil
+}
+
+func (z *Reader) Read(p []byte) (int, error) {
+	for len(z.out) == 0 {
+		if z.err != nil {
+			return 0, z.err
+		}
+		z.out, z.err = z.next()
+	}
+	n := copy(p, z.out)
+	z.out = z.out[n:]
+	return n, nil
+}
+
+// next returns the next decoded data, which may be empty when a block
+// boundary was crossed.
+func (z *Reader) next() ([]byte, error) {
+	if z.block == nil {
+		if err := z.startBlock(); err != nil {
+			return nil, err
+		}
+	}
+	data, err := z.block.next()
+	if err == io.EOF {
+		return nil, z.endBlock()
+	}
+	if err != nil {
+		return nil, err
+	}
+	if z.check != nil {
+		z.check.Write(data)
+	}
+	z.size += int64(len(data))
+	return data, nil
+}
+
+func (z *Reader) streamHeader() error {
+	var h [12]byte
+	if err := z.in.full(h[:]); err != nil {
+		return err
+	}
+	if !bytes.Equal(h[:6], headerMagic) {
+		return errFormat
+	}
+	if crc32.ChecksumIEEE(h[6:8]) != binary.LittleEndian.Uint32(h[8:]) {
+		return errFormat
+	}
+	if h[6] != 0 || h[7] > 0x0F {
+		return errUnsupported
+	}
+	z.flags = [2]byte{h[6], h[7]}
+	return nil
+}
+
+// startBlock reads the next block header. At the index it instead checks
+// the end of the stream and moves on to the first block of the next one,
+// returning io.EOF after the last stream.
+func (z *Reader) startBlock() error {
+	z.blockStart = z.in.n
+	b, err := z.in.ReadByte()
+	if err != nil {
+		return err
+	}
+	if b == 0 {
+		if err := z.streamEnd(); err != nil {
+			return err
+		}
+		if err := z.nextStream(); err != nil {
+			return err
+		}
+		return z.startBlock()
+	}
+
+	hdr := make([]byte, (int(b)+1)*4)
+	hdr[0] = b
+	if err := z.in.full(hdr[1:]); err != nil {
+		return err
+	}
+	body, sum := hdr[:len(hdr)-4], hdr[len(hdr)-4:]
+	if crc32.ChecksumIEEE(body) != binary.LittleEndian.Uint32(sum) {
+		return errFormat
+	}
+	flags := body[1]
+	if flags&0x3C != 0 {
+		return errFormat
+	}
+	if flags&0x03 != 0 {
+		return errUnsupported // more than one filter
+	}
+
+	r := bytes.NewReader(body[2:])
+	z.packed, z.unpacked = -1, -1
+	if flags&0x40 != 0 {
+		v, err := readVLI(r)
+		if err != nil || v == 0 {
+			return errFormat
+		}
+		z.packed = int64(v)
+	}
+	if flags&0x80 != 0 {
+		v, err := readVLI(r)
+		if err != nil {
+			return errFormat
+		}
+		z.unpacked = int64(v)
+	}
+	id, err := readVLI(r)
+	if err != nil {
+		return errFormat
+	}
+	if id != lzma2FilterID {
+		return errUnsupported
+	}
+	if n, err := readVLI(r); err != nil || n != 1 {
+		return errFormat
+	}
+	props, err := r.ReadByte()
+	if err != nil || props > 40 {
+		return errFormat
+	}
+	for r.Len() > 0 {
+		if b, _ := r.ReadByte(); b != 0 {
+			return errFormat
+		}
+	}
+
+	z.block = newLZMA2Decoder(&z.in, dictSize(props))
+	z.check = newCheck(z.flags[1])
+	z.dataStart = z.in.n
+	z.size = 0
+	return nil
+}
+
+// endBlock verifies the sizes, padding and check of the finished block.
+func (z *Reader) endBlock() error {
+	packed := z.in.n - z.dataStart
+	if z.packed >= 0 && packed != z.packed || z.unpacked >= 0 && z.size != z.unpacked {
+		return errFormat
+	}
+	if err := z.skipPadding(z.blockStart); err != nil {
+		return err
+	}
+	sum := make([]byte, checkSize(z.flags[1]))
+	if err := z.in.full(sum); err != nil {
+		return err
+	}
+	if !z.verify(sum) {
+		return errFormat
+	}
+	z.records = append(z.records, record{
+		unpadded:     uint64(z.dataStart-z.blockStart+packed) + uint64(len(sum)),
+		uncompressed: uint64(z.size),
+	})
+	z.block = nil
+	return nil
+}
+
+func (z *Reader) verify(sum []byte) bool {
+	switch h := z.check.(type) {
+	case nil:
+		return true
+	case hash.Hash32:
+		return h.Sum32() == binary.LittleEndian.Uint32(sum)
+	case hash.Hash64:
+		return h.Sum64() == binary.LittleEndian.Uint64(sum)
+	default:
+		return bytes.Equal(h.Sum(nil), sum)
+	}
+}
+
+// streamEnd checks the index, whose indicator byte has been read, against
+// the blocks decoded, then the stream footer.
+func (z *Reader) streamEnd() error {
+	start := z.in.n - 1
+	crc := crc32.NewIEEE()
+	crc.Write([]byte{0})
+	r := hashReader{&z.in, crc}
+
+	count, err := readVLI(r)
+	if err != nil {
+		return err
+	}
+	if count != uint64(len(z.records)) {
+		return errFormat
+	}
+	for _, rec := range z.records {
+		unpadded, err := readVLI(r)
+		if err != nil {
+			return err
+		}
+		uncompressed, err := readVLI(r)
+		if err != nil {
+			return err
+		}
+		if unpadded != rec.unpadded || uncompressed != rec.uncompressed {
+			return errFormat
+		}
+	}
+	for (z.in.n-start)%4 != 0 {
+		if b, err := r.ReadByte(); err != nil || b != 0 {
+			return errFormatOr(err)
+		}
+	}
+	var sum [4]byte
+	if err := z.in.full(sum[:]); err != nil {
+		return err
+	}
+	if crc.Sum32() != binary.LittleEndian.Uint32(sum[:]) {
+		return errFormat
+	}
+	indexSize := z.in.n - start
+
+	var f [12]byte
+	if err := z.in.full(f[:]); err != nil {
+		return err
+	}
+	if crc32.ChecksumIEEE(f[4:10]) != binary.LittleEndian.Uint32(f[:4]) ||
+		int64(binary.LittleEndian.Uint32(f[4:8])+1)*4 != indexSize ||
+		f[8] != z.flags[0] || f[9] != z.flags[1] ||
+		!bytes.Equal(f[10:], footerMagic) {
+		return errFormat
+	}
+	z.records = nil
+	return nil
+}
+
+// nextStream skips stream padding and reads the header of the stream that
+// follows, returning io.EOF if there is none.
+func (z *Reader) nextStream() error {
+	for {
+		b, err := z.in.r.Peek(1)
+		if len(b) == 0 {
+			if err == io.EOF {
+				return io.EOF
+			}
+			return err
+		}
+		if b[0] != 0 {
+			return z.streamHeader()
+		}
+		var pad [4]byte
+		if err := z.in.full(pad[:]); err != nil {
+			return err
+		}
+		if pad != [4]byte{} {
+			return errFormat
+		}
+	}
+}
+
+// skipPadding consumes the zero bytes aligning the input to a multiple of
+// four bytes from start.
+func (z *Reader) skipPadding(start int64) error {
+	for (z.in.n-start)%4 != 0 {
+		b, err := z.in.ReadByte()
+		if err != nil {
+			return err
+		}
+		if b != 0 {
+			return errFormat
+		}
+	}
+	return nil
+}
+
+// dictSize decodes the LZMA2 dictionary size property.
+func dictSize(props byte) int {
+	if props == 40 {
+		return 0xFFFFFFFF
+	}
+	return (2 | int(props&1)) << (props/2 + 11)
+}
+
+func checkSize(check byte) int {
+	switch {
+	case check == 0:
+		return 0
+	case check <= 3:
+		return 4
+	case check <= 6:
+		return 8
+	case check <= 9:
+		return 16
+	case check <= 12:
+		return 32
+	default:
+		return 64
+	}
+}
+
+// newCheck returns the hash for check, or nil when none is defined; the
+// check bytes of undefined types are skipped unverified.
+func newCheck(check byte) hash.Hash {
+	switch check {
+	case 0x01:
+		return crc32.NewIEEE()
+	case 0x04:
+		return crc64.New(crc64Table)
+	case 0x0A:
+		return sha256.New()
+	}
+	return nil
+}
+
+// readVLI reads a variable-length integer of up to nine bytes.
+func readVLI(r io.ByteReader) (uint64, error) {
+	var v uint64
+	for i := 0; i < 9; i++ {
+		b, err := r.ReadByte()
+		if err != nil {
+			return 0, errFormatOr(err)
+		}
+		if i > 0 && b == 0 {
+			return 0, errFormat // not the shortest encoding
+		}
+		v |= uint64(b&0x7F) << (7 * i)
+		if b&0x80 == 0 {
+			return v, nil
+		}
+	}
+	return 0, errFormat
+}
+
+// errFormatOr returns err, or errFormat when the data merely ran out.
+func errFormatOr(err error) error {
+	if err == nil || err == io.EOF {
+		return errFormat
+	}
+	return err
+}
+
+// input counts the bytes read, to locate padding and check sizes.
+type input struct {
+	r *bufio.Reader
+	n int64
+}
+
+func (in *input) ReadByte() (byte, error) {
+	b, err := in.r.ReadByte()
+	if err != nil {
+		return 0, unexpectedEOF(err)
+	}
+	in.n++
+	return b, nil
+}
+
+func (in *input) full(p []byte) error {
+	n, err := io.ReadFull(in.r, p)
+	in.n += int64(n)
+	return unexpectedEOF(err)
+}
+
+func unexpectedEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+// hashReader feeds every byte read into h.
+type hashReader struct {
+	r io.ByteReader
+	h hash.Hash
+}
+
+func (r hashReader) ReadByte() (byte, error) {
+	b, err := r.r.ReadByte()
+	if err == nil {
+		r.h.Write([]byte{b})
+	}
+	return b, err
+}
@@ -0,0 +1,120 @@
+package xz
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// The files in testdata were written by xz(1) from sample and random.
+
+func sample() []byte {
+	var b bytes.Buffer
+	for i := 0; i < 2000; i++ {
+		fmt.Fprintf(&b, "line %d: the quick brown fox %d jumps over %d lazy dogs\n", i, i*i%977, i%13)
+	}
+	b.Write(b.Bytes()[:8000])
+	return b.Bytes()
+}
+
+func random(n int) []byte {
+	b := make([]byte, n)
+	x := uint32(1)
+	for i := range b {
+		x = x*1664525 + 1013904223
+		b[i] = byte(x >> 24)
+	}
+	return b
+}
+
+func readTestdata(t *testing.T, name string) []byte {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func decompress(data []byte) ([]byte, error) {
+	r, err := NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	return io.ReadAll(r)
+}
+
+func TestReader(t *testing.T) {
+	want := sample()
+	tests := []struct {
+		file string
+		want []byte
+	}{
+		{"sample.xz", want},         // preset 6, CRC64
+		{"sample-crc32.xz", want},   // preset 0, CRC32
+		{"sample-sha256.xz", want},  // SHA-256 check
+		{"sample-none.xz", want},    // no check
+		{"sample-blocks.xz", want},  // several blocks
+		{"sample-lp2.xz", want},     // lc=0 lp=2 pb=0
+		{"random.xz", random(4096)}, // stored as uncompressed chunks
+	}
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			got, err := decompress(readTestdata(t, tt.file))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, tt.want) {
+				t.Errorf("got %d bytes, want %d bytes of the original", len(got), len(tt.want))
+			}
+		})
+	}
+}
+
+func TestReaderConcatenated(t *testing.T) {
+	var data []byte
+	data = append(data, readTestdata(t, "sample.xz")...)
+	data = append(data, 0, 0, 0, 0) // stream padding
+	data = append(data, readTestdata(t, "random.xz")...)
+
+	got, err := decompress(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := append(sample(), random(4096)...); !bytes.Equal(got, want) {
+		t.Errorf("got %d bytes, want %d", len(got), len(want))
+	}
+}
+
+func TestReaderInvalid(t *testing.T) {
+	valid := readTestdata(t, "sample.xz")
+	corrupt := func(i int) []byte {
+		data := bytes.Clone(valid)
+		data[i] ^= 0x55
+		return data
+	}
+
+	tests := []struct {
+		name string
+		data []byte
+		want error
+	}{
+		{"not xz", []byte("plain text, not compressed"), errFormat},
+		{"compressed data", corrupt(len(valid) / 2), errFormat},
+		{"block check", corrupt(len(valid) - 40), errFormat},
+		{"footer", corrupt(len(valid) - 3), errFormat},
+		{"truncated", valid[:len(valid)/2], io.ErrUnexpectedEOF},
+		{"odd padding", append(bytes.Clone(valid), 0, 0), io.ErrUnexpectedEOF},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := decompress(tt.data); !errors.Is(err, tt.want) {
+				t.Errorf("err = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,38 @@
+package grasp
+
+import (
+	"context"
+	"fmt"
+)
+
+// OpenExclusive opens the file at path for reading and writing under an
+// exclusive lock, creating it if it does not exist. It returns ErrLocked
+// while another caller holds the file; closing the returned file releases
+// the lock. Plugins see it as an open for writing. The provider must
+// implement ExclusiveOpener.
+func (v *VirtualOS) OpenExclusive(ctx context.Context, path string) (_ LockedFile, err error) {
+	path = CleanPath(path)
+	defer func() { v.audit(ctx, "lock", path, err) }()
+
+	resolved, err := v.resolveSymlinks(ctx, path, maxSymlinkDepth)
+	if err != nil {
+		return nil, err
+	}
+	path = resolved
+
+	p, inner, err := v.mounts.Resolve(path)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, path)
+	}
+	if err := v.pluginsOpen(ctx, path, true); err != nil {
+		return nil, err
+	}
+	if inner == "" {
+		return nil, fmt.Errorf("%w: %s", ErrIsDir, path)
+	}
+	o, ok := p.(ExclusiveOpener)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s (provider does not support locking)", ErrNotSupported, path)
+	}
+	return o.OpenExclusive(ctx, inner)
+}
@@ -0,0 +1,123 @@
+package mounts
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jackfish212/grasp/types"
+)
+
+// archiveFS is the read-only in-memory tree shared by archive providers
+// such as TarFS and ZipFS.
+type archiveFS struct {
+	files map[string]*archiveEntry
+}
+
+type archiveEntry struct {
+	data     []byte
+	isDir    bool
+	modified time.Time
+}
+
+func newArchiveFS() archiveFS {
+	return archiveFS{files: make(map[string]*archiveEntry)}
+}
+
+// add records an archive member, skipping the archive root.
+func (fs *archiveFS) add(name string, e *archiveEntry) {
+	p := normPath(strings.TrimPrefix(name, "./"))
+	if p == "" || p == "." {
+		return
+	}
+	fs.files[p] = e
+}
+
+func (fs *archiveFS) Stat(_ context.Context, path string) (*types.Entry, error) {
+	path = normPath(path)
+	if f, ok := fs.files[path]; ok {
+		return f.toEntry(path), nil
+	}
+	prefix := path + "/"
+	if path == "" {
+		return &types.Entry{Name: "/", Path: "", IsDir: true, Perm: types.PermRX}, nil
+	}
+	for k := range fs.files {
+		if strings.HasPrefix(k, prefix) {
+			return &types.Entry{Name: baseName(path), Path: path, IsDir: true, Perm: types.PermRX}, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s", types.ErrNotFound, path)
+}
+
+func (fs *archiveFS) List(_ context.Context, path string, _ types.ListOpts) ([]types.Entry, error) {
+	path = normPath(path)
+	prefix := path + "/"
+	if path == "" {
+		prefix = ""
+	}
+
+	seen := make(map[string]bool)
+	var entries []types.Entry
+	for k, f := range fs.files {
+		if !strings.HasPrefix(k, prefix) || len(k) == len(prefix) {
+			continue
+		}
+		rest := k[len(prefix):]
+		name := rest
+		implicit := false
+		if idx := strings.IndexByte(rest, '/'); idx >= 0 {
+			name = rest[:idx]
+			implicit = true
+		}
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		if implicit {
+			if explicit, ok := fs.files[prefix+name]; ok {
+				entries = append(entries, *explicit.toEntry(prefix + name))
+			} else {
+				entries = append(entries, types.Entry{Name: name, Path: prefix + name, IsDir: true, Perm: types.PermRX})
+			}
+			continue
+		}
+		entries = append(entries, *f.toEntry(k))
+	}
+
+	if path != "" && len(entries) == 0 {
+		if f, ok := fs.files[path]; !ok || !f.isDir {
+			return nil, fmt.Errorf("%w: %s", types.ErrNotFound, path)
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name < entries[j].Name })
+	return entries, nil
+}
+
+func (fs *archiveFS) Open(_ context.Context, path string) (types.File, error) {
+	p := normPath(path)
+	f, ok := fs.files[p]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", types.ErrNotFound, path)
+	}
+	if f.isDir {
+		return nil, fmt.Errorf("%w: %s", types.ErrIsDir, path)
+	}
+	br := bytes.NewReader(f.data)
+	return types.NewSeekableFile(p, f.toEntry(p), io.NopCloser(br), br), nil
+}
+
+func (f *archiveEntry) toEntry(path string) *types.Entry {
+	perm := types.PermRO
+	if f.isDir {
+		perm = types.PermRX
+	}
+	return &types.Entry{
+		Name: baseName(path), Path: path, IsDir: f.isDir, Perm: perm,
+		Size: int64(len(f.data)), Modified: f.modified,
+	}
+}
@@ -32,12 +32,16 @@ var (
 //	/repos/{owner}/{repo}/contents/... - repository files (read-only)
 //	/repos/{owner}/{repo}/issues     - list issues
 //	/repos/{owner}/{repo}/issues/{N} - read issue N
+//	/repos/{owner}/{repo}/commits    - list branches
+//	/repos/{owner}/{repo}/commits/{branch}       - list commit SHAs on branch
+//	/repos/{owner}/{repo}/commits/{branch}/{sha} - read commit details
 //
 // Example:
 //
 //	ls /repos                           -> list repositories
 //	cat /repos/golang/go/README.md      -> read file from go repo
 //	cat /repos/golang/go/issues/123     -> read issue #123
+//	ls /repos/golang/go/commits/master  -> list recent commits
 //	search "bug" --scope /repos/owner/repo/issues
 type GitHubFS struct {
 	client   *http.Client
@@ -137,7 +141,8 @@ func (fs *GitHubFS) statRepos(ctx context.Context, parts []string) (*types.Entry
 		}, nil
 
 	case 4:
-		// /repos/{owner}/{repo}/contents, /repos/{owner}/{repo}/issues
+		// /repos/{owner}/{repo}/contents, /repos/{owner}/{repo}/issues,
+		// /repos/{owner}/{repo}/commits
 		return &types.Entry{Name: parts[3], Path: strings.Join(parts, "/"), IsDir: true, Perm: types.PermRX}, nil
 
 	case 5:
@@ -155,6 +160,10 @@ func (fs *GitHubFS) statRepos(ctx context.Context, parts []string) (*types.Entry
 				Meta:  map[string]string{"title": issue.Title, "state": issue.State},
 			}, nil
 		}
+		if parts[3] == "commits" {
+			// /repos/{owner}/{repo}/commits/{branch}
+			return &types.Entry{Name: parts[4], Path: strings.Join(parts, "/"), IsDir: true, Perm: types.PermRX}, nil
+		}
 		if parts[3] == "contents" {
 			content, err := fs.getContentInfo(ctx, parts[1], parts[2], parts[4])
 			if err != nil {
@@ -169,6 +178,17 @@ func (fs *GitHubFS) statRepos(ctx context.Context, parts []string) (*types.Entry
 		}
 
 	default:
+		// /repos/{owner}/{repo}/commits/{branch}/{sha}
+		if parts[3] == "commits" && len(parts) == 6 {
+			commit, err := fs.getCommit(ctx, parts[1], parts[2], parts[5])
+			if err != nil {
+				return nil, err
+			}
+			e := commitEntry(strings.Join(parts[:5], "/"), commit)
+			e.Name = parts[5]
+			e.Path = strings.Join(parts, "/")
+			return &e, nil
+		}
 		// /repos/{owner}/{repo}/contents/{path...}
 		if parts[3] == "contents" {
 			contentPath := strings.Join(parts[4:], "/")
@@ -221,18 +241,26 @@ func (fs *GitHubFS) listRepos(ctx context.Context, parts []string) ([]types.Entr
 		return []types.Entry{
 			{Name: "contents", Path: "repos/" + parts[1] + "/" + parts[2] + "/contents", IsDir: true, Perm: types.PermRX},
 			{Name: "issues", Path: "repos/" + parts[1] + "/" + parts[2] + "/issues", IsDir: true, Perm: types.PermRX},
+			{Name: "commits", Path: "repos/" + parts[1] + "/" + parts[2] + "/commits", IsDir: true, Perm: types.PermRX},
 		}, nil
 
 	case 4:
-		// /repos/{owner}/{repo}/contents or /repos/{owner}/{repo}/issues
+		// /repos/{owner}/{repo}/contents, /repos/{owner}/{repo}/issues or
+		// /repos/{owner}/{repo}/commits
 		switch parts[3] {
 		case "contents":
 			return fs.listContents(ctx, parts[1], parts[2], "")
 		case "issues":
 			return fs.listIssues(ctx, parts[1], parts[2])
+		case "commits":
+			return fs.listBranches(ctx, parts[1], parts[2])
 		}
 
 	default:
+		// /repos/{owner}/{repo}/commits/{branch}
+		if parts[3] == "commits" && len(parts) == 5 {
+			return fs.listCommits(ctx, parts[1], parts[2], parts[4])
+		}
 		// /repos/{owner}/{repo}/contents/{path...}
 		if parts[3] == "contents" {
 			contentPath := strings.Join(parts[4:], "/")
@@ -277,6 +305,23 @@ func (fs *GitHubFS) Open(ctx context.Context, path string) (types.File, error) {
 			Meta:  map[string]string{"title": issue.Title},
 		}
 
+	case "commits":
+		if len(parts) < 6 {
+			return nil, fmt.Errorf("%w: %s is a directory", types.ErrIsDir, path)
+		}
+		if len(parts) > 6 {
+			return nil, fmt.Errorf("%w: %s", types.ErrNotFound, path)
+		}
+		commit, err := fs.getCommit(ctx, parts[1], parts[2], parts[5])
+		if err != nil {
+			return nil, err
+		}
+		content = []byte(fs.formatCommit(commit))
+		e := commitEntry(strings.Join(parts[:5], "/"), commit)
+		e.Name = parts[5]
+		e.Path = path
+		entry = &e
+
 	case "contents":
 		if len(parts) < 5 {
 			return nil, fmt.Errorf("%w: %s is a directory", types.ErrIsDir, path)
@@ -348,6 +393,34 @@ type githubIssue struct {
 	Labels    []struct{ Name string } `json:"labels"`
 }
 
+type githubBranch struct {
+	Name string `json:"name"`
+}
+
+type githubCommit struct {
+	SHA    string `json:"sha"`
+	Commit struct {
+		Message string `json:"message"`
+		Author  struct {
+			Name  string    `json:"name"`
+			Email string    `json:"email"`
+			Date  time.Time `json:"date"`
+		} `json:"author"`
+	} `json:"commit"`
+	Parents []struct {
+		SHA string `json:"sha"`
+	} `json:"parents"`
+	Stats struct {
+		Additions int `json:"additions"`
+		Deletions int `json:"deletions"`
+	} `json:"stats"`
+	Files []struct {
+		Filename  string `json:"filename"`
+		Additions int    `json:"additions"`
+		Deletions int    `json:"deletions"`
+	} `json:"files"`
+}
+
 type githubSearchResult struct {
 	TotalCount int           `json:"total_count"`
 	Items      []githubIssue `json:"items"`
@@ -484,6 +557,46 @@ func (fs *GitHubFS) getIssue(ctx context.Context, owner, repo, number string) (*
 	return &issue, nil
 }
 
+func (fs *GitHubFS) listBranches(ctx context.Context, owner, repo string) ([]types.Entry, error) {
+	var branches []githubBranch
+	if err := fs.apiGet(ctx, "/repos/"+owner+"/"+repo+"/branches?per_page=100", &branches); err != nil {
+		return nil, err
+	}
+
+	var entries []types.Entry
+	for _, b := range branches {
+		entries = append(entries, types.Entry{
+			Name:  b.Name,
+			Path:  "repos/" + owner + "/" + repo + "/commits/" + b.Name,
+			IsDir: true,
+			Perm:  types.PermRX,
+		})
+	}
+	return entries, nil
+}
+
+func (fs *GitHubFS) listCommits(ctx context.Context, owner, repo, branch string) ([]types.Entry, error) {
+	var commits []githubCommit
+	if err := fs.apiGet(ctx, "/repos/"+owner+"/"+repo+"/commits?sha="+url.QueryEscape(branch)+"&per_page=100", &commits); err != nil {
+		return nil, err
+	}
+
+	dir := "repos/" + owner + "/" + repo + "/commits/" + branch
+	var entries []types.Entry
+	for i := range commits {
+		entries = append(entries, commitEntry(dir, &commits[i]))
+	}
+	return entries, nil
+}
+
+func (fs *GitHubFS) getCommit(ctx context.Context, owner, repo, sha string) (*githubCommit, error) {
+	var commit githubCommit
+	if err := fs.apiGet(ctx, "/repos/"+owner+"/"+repo+"/commits/"+sha, &commit); err != nil {
+		return nil, err
+	}
+	return &commit, nil
+}
+
 func (fs *GitHubFS) searchIssues(ctx context.Context, owner, repo, query string, maxResults int) ([]types.SearchResult, error) {
 	q := fmt.Sprintf("%s repo:%s/%s is:issue", url.QueryEscape(query), owner, repo)
 	var result githubSearchResult
@@ -594,6 +707,46 @@ func (fs *GitHubFS) formatIssue(issue *githubIssue) string {
 	return buf.String()
 }
 
+// commitEntry describes a commit as a file named by its SHA under dir.
+func commitEntry(dir string, c *githubCommit) types.Entry {
+	subject, _, _ := strings.Cut(c.Commit.Message, "\n")
+	return types.Entry{
+		Name:     c.SHA,
+		Path:     dir + "/" + c.SHA,
+		IsDir:    false,
+		Perm:     types.PermRO,
+		Modified: c.Commit.Author.Date,
+		Meta:     map[string]string{"message": subject, "author": c.Commit.Author.Name},
+	}
+}
+
+func (fs *GitHubFS) formatCommit(c *githubCommit) string {
+	var buf strings.Builder
+	fmt.Fprintf(&buf, "Commit: %s\n", c.SHA)
+	fmt.Fprintf(&buf, "Author: %s <%s>\n", c.Commit.Author.Name, c.Commit.Author.Email)
+	fmt.Fprintf(&buf, "Date: %s\n", c.Commit.Author.Date.Format("2006-01-02 15:04"))
+
+	if len(c.Parents) > 0 {
+		parents := make([]string, len(c.Parents))
+		for i, p := range c.Parents {
+			parents[i] = p.SHA
+		}
+		fmt.Fprintf(&buf, "Parents: %s\n", strings.Join(parents, ", "))
+	}
+	fmt.Fprintf(&buf, "Stats: %d files changed, %d insertions(+), %d deletions(-)\n",
+		len(c.Files), c.Stats.Additions, c.Stats.Deletions)
+
+	fmt.Fprintf(&buf, "\n---\n\n%s\n", c.Commit.Message)
+
+	if len(c.Files) > 0 {
+		buf.WriteString("\n")
+		for _, f := range c.Files {
+			fmt.Fprintf(&buf, " %s | +%d -%d\n", f.Filename, f.Additions, f.Deletions)
+		}
+	}
+	return buf.String()
+}
+
 func (fs *GitHubFS) MountInfo() (string, string) {
 	return "githubfs", "github-api"
 }
@@ -2,8 +2,11 @@ package mounts
 
 import (
 	"context"
+	"errors"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
@@ -218,3 +221,78 @@ func TestGitHubFS_Search(t *testing.T) {
 		t.Error("Search with non-issues scope should fail")
 	}
 }
+
+func TestGitHubFS_Commits(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/repos/owner/repo/branches":
+			_, _ = w.Write([]byte(`[{"name":"main"},{"name":"dev"}]`))
+		case "/repos/owner/repo/commits":
+			if r.URL.Query().Get("sha") != "main" {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			_, _ = w.Write([]byte(`[
+				{"sha":"abc123","commit":{"message":"Fix bug\n\nDetails","author":{"name":"Ann","date":"2024-01-02T03:04:05Z"}}},
+				{"sha":"def456","commit":{"message":"Initial commit","author":{"name":"Bob","date":"2024-01-01T00:00:00Z"}}}
+			]`))
+		case "/repos/owner/repo/commits/abc123":
+			_, _ = w.Write([]byte(`{"sha":"abc123",
+				"commit":{"message":"Fix bug\n\nDetails","author":{"name":"Ann","email":"ann@example.com","date":"2024-01-02T03:04:05Z"}},
+				"parents":[{"sha":"def456"}],
+				"stats":{"additions":3,"deletions":1},
+				"files":[{"filename":"main.go","additions":3,"deletions":1}]}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	fs := NewGitHubFS(WithGitHubBaseURL(server.URL))
+	ctx := context.Background()
+
+	entries, err := fs.List(ctx, "/repos/owner/repo/commits", types.ListOpts{})
+	if err != nil {
+		t.Fatalf("List(commits) error = %v", err)
+	}
+	if len(entries) != 2 || entries[0].Name != "main" || !entries[0].IsDir {
+		t.Errorf("List(commits) = %v, want branches [main dev]", entries)
+	}
+
+	entries, err = fs.List(ctx, "/repos/owner/repo/commits/main", types.ListOpts{})
+	if err != nil {
+		t.Fatalf("List(commits/main) error = %v", err)
+	}
+	if len(entries) != 2 || entries[0].Name != "abc123" || entries[0].Meta["message"] != "Fix bug" {
+		t.Errorf("List(commits/main) = %v", entries)
+	}
+
+	entry, err := fs.Stat(ctx, "/repos/owner/repo/commits/main/abc123")
+	if err != nil || entry.IsDir || entry.Meta["author"] != "Ann" {
+		t.Fatalf("Stat(commit) = %v, %v", entry, err)
+	}
+
+	f, err := fs.Open(ctx, "/repos/owner/repo/commits/main/abc123")
+	if err != nil {
+		t.Fatalf("Open(commit) error = %v", err)
+	}
+	data, _ := io.ReadAll(f)
+	_ = f.Close()
+	for _, want := range []string{
+		"Commit: abc123",
+		"Author: Ann <ann@example.com>",
+		"Parents: def456",
+		"1 files changed, 3 insertions(+), 1 deletions(-)",
+		"Fix bug\n\nDetails",
+		" main.go | +3 -1",
+	} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("commit content missing %q:\n%s", want, data)
+		}
+	}
+
+	if _, err := fs.Open(ctx, "/repos/owner/repo/commits/main"); !errors.Is(err, types.ErrIsDir) {
+		t.Errorf("Open(branch) error = %v, want ErrIsDir", err)
+	}
+}
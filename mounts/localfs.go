@@ -7,6 +7,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/jackfish212/grasp/types"
@@ -23,19 +24,69 @@ var (
 
 // LocalFS mounts a host directory into grasp.
 type LocalFS struct {
+	mu   sync.RWMutex // guards root
 	root string
 	perm types.Perm
+
+	onEvent        func(types.EventType, string)
+	coalesceWindow time.Duration
+	coalescer      *eventCoalescer
+	pollInterval   time.Duration
+	gitIgnore      bool
+}
+
+func NewLocalFS(root string, perm types.Perm, opts ...LocalFSOption) *LocalFS {
+	fs := &LocalFS{root: filepath.Clean(root), perm: perm}
+	for _, opt := range opts {
+		opt(fs)
+	}
+	if fs.onEvent != nil && fs.coalesceWindow > 0 {
+		fs.coalescer = newEventCoalescer(fs.coalesceWindow, fs.onEvent)
+	}
+	return fs
 }
 
-func NewLocalFS(root string, perm types.Perm) *LocalFS {
-	return &LocalFS{root: filepath.Clean(root), perm: perm}
+// Root returns the host directory currently mounted.
+func (fs *LocalFS) Root() string {
+	fs.mu.RLock()
+	defer fs.mu.RUnlock()
+	return fs.root
+}
+
+// SetRoot switches the mount to another host directory, for example when a
+// deployment moves its data directory, without remounting. newRoot must be
+// an existing directory. Each operation resolves its paths once, when it
+// starts, so calls in progress, and files already open, finish against the
+// old root while later calls use the new one.
+func (fs *LocalFS) SetRoot(newRoot string) error {
+	newRoot = filepath.Clean(newRoot)
+	info, err := os.Stat(newRoot)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("%w: %s", types.ErrNotFound, newRoot)
+		}
+		return err
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("%w: %s", types.ErrNotDir, newRoot)
+	}
+
+	fs.mu.Lock()
+	fs.root = newRoot
+	fs.mu.Unlock()
+	return nil
 }
 
 func (fs *LocalFS) hostPath(vosPath string) string {
+	return joinHostPath(fs.Root(), vosPath)
+}
+
+// joinHostPath returns the host path of vosPath under root.
+func joinHostPath(root, vosPath string) string {
 	if vosPath == "" {
-		return fs.root
+		return root
 	}
-	return filepath.Join(fs.root, filepath.FromSlash(vosPath))
+	return filepath.Join(root, filepath.FromSlash(vosPath))
 }
 
 func (fs *LocalFS) Stat(_ context.Context, path string) (*types.Entry, error) {
@@ -60,6 +111,11 @@ func (fs *LocalFS) List(_ context.Context, path string, _ types.ListOpts) ([]typ
 		return nil, err
 	}
 
+	var rules []ignoreRule
+	if fs.gitIgnore {
+		rules = fs.ignoreRules(path)
+	}
+
 	entries := make([]types.Entry, 0, len(dirEntries))
 	for _, de := range dirEntries {
 		info, infoErr := de.Info()
@@ -70,6 +126,9 @@ func (fs *LocalFS) List(_ context.Context, path string, _ types.ListOpts) ([]typ
 		if path != "" {
 			childPath = path + "/" + de.Name()
 		}
+		if fs.gitIgnore && ignored(rules, childPath, de.IsDir()) {
+			continue
+		}
 		entries = append(entries, *fs.infoToEntry(childPath, info))
 	}
 	return entries, nil
@@ -104,13 +163,20 @@ func (fs *LocalFS) Write(_ context.Context, path string, r io.Reader) error {
 	if err := os.MkdirAll(filepath.Dir(hp), 0o755); err != nil {
 		return err
 	}
+	_, statErr := os.Stat(hp)
 	f, err := os.Create(hp)
 	if err != nil {
 		return err
 	}
 	defer func() { _ = f.Close() }()
-	_, err = io.Copy(f, r)
-	return err
+	if _, err = io.Copy(f, r); err != nil {
+		return err
+	}
+	if os.IsNotExist(statErr) {
+		fs.notify(types.EventCreate, path)
+	}
+	fs.notify(types.EventWrite, path)
+	return nil
 }
 
 func (fs *LocalFS) Mkdir(_ context.Context, path string, _ types.Perm) error {
@@ -118,7 +184,11 @@ func (fs *LocalFS) Mkdir(_ context.Context, path string, _ types.Perm) error {
 		return fmt.Errorf("%w: %s", types.ErrNotWritable, path)
 	}
 	hp := fs.hostPath(path)
-	return os.MkdirAll(hp, 0o755)
+	if err := os.MkdirAll(hp, 0o755); err != nil {
+		return err
+	}
+	fs.notify(types.EventMkdir, path)
+	return nil
 }
 
 func (fs *LocalFS) Remove(_ context.Context, path string) error {
@@ -129,22 +199,31 @@ func (fs *LocalFS) Remove(_ context.Context, path string) error {
 	if _, err := os.Stat(hp); os.IsNotExist(err) {
 		return fmt.Errorf("%w: %s", types.ErrNotFound, path)
 	}
-	return os.RemoveAll(hp)
+	if err := os.RemoveAll(hp); err != nil {
+		return err
+	}
+	fs.notify(types.EventRemove, path)
+	return nil
 }
 
 func (fs *LocalFS) Rename(_ context.Context, oldPath, newPath string) error {
 	if !fs.perm.CanWrite() {
 		return fmt.Errorf("%w: %s", types.ErrNotWritable, oldPath)
 	}
-	hpOld := fs.hostPath(oldPath)
-	hpNew := fs.hostPath(newPath)
+	root := fs.Root()
+	hpOld := joinHostPath(root, oldPath)
+	hpNew := joinHostPath(root, newPath)
 	if _, err := os.Stat(hpOld); os.IsNotExist(err) {
 		return fmt.Errorf("%w: %s", types.ErrNotFound, oldPath)
 	}
 	if err := os.MkdirAll(filepath.Dir(hpNew), 0o755); err != nil {
 		return err
 	}
-	return os.Rename(hpOld, hpNew)
+	if err := os.Rename(hpOld, hpNew); err != nil {
+		return err
+	}
+	fs.notify(types.EventRename, newPath)
+	return nil
 }
 
 func (fs *LocalFS) Touch(_ context.Context, path string) error {
@@ -154,7 +233,11 @@ func (fs *LocalFS) Touch(_ context.Context, path string) error {
 	hp := fs.hostPath(path)
 	// If file exists, update modification time
 	if _, err := os.Stat(hp); err == nil {
-		return os.Chtimes(hp, time.Now(), time.Now())
+		if err := os.Chtimes(hp, time.Now(), time.Now()); err != nil {
+			return err
+		}
+		fs.notify(types.EventWrite, path)
+		return nil
 	}
 	// Create parent directories if needed
 	if err := os.MkdirAll(filepath.Dir(hp), 0o755); err != nil {
@@ -165,7 +248,11 @@ func (fs *LocalFS) Touch(_ context.Context, path string) error {
 	if err != nil {
 		return err
 	}
-	return f.Close()
+	if err := f.Close(); err != nil {
+		return err
+	}
+	fs.notify(types.EventCreate, path)
+	return nil
 }
 
 func (fs *LocalFS) Search(_ context.Context, query string, opts types.SearchOpts) ([]types.SearchResult, error) {
@@ -206,4 +293,4 @@ func (fs *LocalFS) infoToEntry(vosPath string, info os.FileInfo) *types.Entry {
 	}
 }
 
-func (fs *LocalFS) MountInfo() (string, string) { return "localfs", fs.root }
+func (fs *LocalFS) MountInfo() (string, string) { return "localfs", fs.Root() }
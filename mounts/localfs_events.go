@@ -0,0 +1,102 @@
+package mounts
+
+import (
+	"sync"
+	"time"
+
+	"github.com/jackfish212/grasp/types"
+)
+
+// LocalFSOption configures a LocalFS.
+type LocalFSOption func(*LocalFS)
+
+// WithLocalFSOnEvent sets a callback invoked when the LocalFS changes a file.
+// Path is relative to the provider root (e.g., "dir/file.txt").
+// Wire this to VirtualOS.Notify to propagate events through the watch system.
+func WithLocalFSOnEvent(fn func(types.EventType, string)) LocalFSOption {
+	return func(fs *LocalFS) { fs.onEvent = fn }
+}
+
+// WithLocalFSEventCoalescing batches events that occur within window.
+// Events for the same path are merged into a single event whose type is the
+// union of the batched types, delivered once the window elapses. It applies
+// both to the WithLocalFSOnEvent callback and to the channels returned by
+// WatchRecursive, which carry the changes made outside grasp, such as a
+// build writing the same files over and over.
+func WithLocalFSEventCoalescing(window time.Duration) LocalFSOption {
+	return func(fs *LocalFS) { fs.coalesceWindow = window }
+}
+
+// notify reports a change to the configured event callback, going through
+// the coalescer when one is enabled.
+func (fs *LocalFS) notify(evType types.EventType, path string) {
+	if fs.onEvent == nil {
+		return
+	}
+	if fs.coalescer != nil {
+		fs.coalescer.add(evType, path)
+		return
+	}
+	fs.onEvent(evType, path)
+}
+
+// pendingEvents accumulates events, merging those for the same path.
+type pendingEvents struct {
+	types map[string]types.EventType
+	order []string // paths in first-seen order
+}
+
+// add records an event and reports whether it is the first one pending.
+func (p *pendingEvents) add(evType types.EventType, path string) bool {
+	first := len(p.order) == 0
+	if p.types == nil {
+		p.types = make(map[string]types.EventType)
+	}
+	if _, ok := p.types[path]; !ok {
+		p.order = append(p.order, path)
+	}
+	p.types[path] |= evType
+	return first
+}
+
+// take returns the merged events in first-seen order and empties p.
+func (p *pendingEvents) take() []types.WatchEvent {
+	now := time.Now()
+	events := make([]types.WatchEvent, len(p.order))
+	for i, path := range p.order {
+		events[i] = types.WatchEvent{Type: p.types[path], Path: path, Time: now}
+	}
+	*p = pendingEvents{}
+	return events
+}
+
+// eventCoalescer merges per-path events arriving within a time window.
+type eventCoalescer struct {
+	mu      sync.Mutex
+	window  time.Duration
+	emit    func(types.EventType, string)
+	pending pendingEvents
+}
+
+func newEventCoalescer(window time.Duration, emit func(types.EventType, string)) *eventCoalescer {
+	return &eventCoalescer{window: window, emit: emit}
+}
+
+func (c *eventCoalescer) add(evType types.EventType, path string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.pending.add(evType, path) {
+		time.AfterFunc(c.window, c.flush)
+	}
+}
+
+// flush delivers all pending events in first-seen order.
+func (c *eventCoalescer) flush() {
+	c.mu.Lock()
+	events := c.pending.take()
+	c.mu.Unlock()
+
+	for _, ev := range events {
+		c.emit(ev.Type, ev.Path)
+	}
+}
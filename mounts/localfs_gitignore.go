@@ -0,0 +1,145 @@
+package mounts
+
+import (
+	"bufio"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// WithLocalFSGitIgnore hides entries matched by the mounted directory's
+// ignore rules from List and WatchRecursive: the root .gitignore,
+// .git/info/exclude, and .gitignore files in subdirectories, with git's
+// precedence (deeper files and later lines win; "!" re-includes). The .git
+// directory itself is always hidden when enabled.
+func WithLocalFSGitIgnore(enabled bool) LocalFSOption {
+	return func(fs *LocalFS) { fs.gitIgnore = enabled }
+}
+
+// ignoreRule is one pattern line from an ignore file. Matching covers the
+// gitignore syntax that github.com/monochromegane/go-gitignore handles
+// (globs, "**", trailing "/", leading "/" and "!") but is done here, with
+// path.Match, so the core module keeps no external dependencies.
+type ignoreRule struct {
+	base     string   // directory of the ignore file, relative to the root
+	segs     []string // pattern split on "/"
+	negate   bool
+	dirOnly  bool
+	anchored bool // pattern contains a slash: match from base, not any depth
+}
+
+// ignoreRules loads the rules that apply to the children of dir, lowest
+// precedence first. Missing files are skipped.
+func (fs *LocalFS) ignoreRules(dir string) []ignoreRule {
+	root := fs.Root()
+	rules := readIgnoreFile(joinHostPath(root, ".git/info/exclude"), "")
+	rules = append(rules, readIgnoreFile(joinHostPath(root, ".gitignore"), "")...)
+	if dir == "" {
+		return rules
+	}
+	prefix := ""
+	for _, seg := range strings.Split(dir, "/") {
+		prefix = path.Join(prefix, seg)
+		rules = append(rules, readIgnoreFile(joinHostPath(root, prefix+"/.gitignore"), prefix)...)
+	}
+	return rules
+}
+
+func readIgnoreFile(hostPath, base string) []ignoreRule {
+	f, err := os.Open(filepath.Clean(hostPath))
+	if err != nil {
+		return nil
+	}
+	defer func() { _ = f.Close() }()
+
+	var rules []ignoreRule
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		line := strings.TrimRight(sc.Text(), " \t\r")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		r := ignoreRule{base: base}
+		if strings.HasPrefix(line, "!") {
+			r.negate = true
+			line = line[1:]
+		} else if strings.HasPrefix(line, `\`) {
+			line = line[1:] // escaped leading "#" or "!"
+		}
+		if strings.HasSuffix(line, "/") {
+			r.dirOnly = true
+			line = strings.TrimRight(line, "/")
+		}
+		if strings.Contains(line, "/") {
+			r.anchored = true
+			line = strings.TrimPrefix(line, "/")
+		}
+		if line == "" {
+			continue
+		}
+		r.segs = strings.Split(line, "/")
+		rules = append(rules, r)
+	}
+	return rules
+}
+
+// ignored reports whether the entry at rel (relative to the root) is hidden
+// by rules.
+func ignored(rules []ignoreRule, rel string, isDir bool) bool {
+	if rel == ".git" {
+		return true
+	}
+	result := false
+	for _, r := range rules {
+		if r.matches(rel, isDir) {
+			result = !r.negate
+		}
+	}
+	return result
+}
+
+func (r ignoreRule) matches(rel string, isDir bool) bool {
+	if r.dirOnly && !isDir {
+		return false
+	}
+	sub := rel
+	if r.base != "" {
+		if !strings.HasPrefix(rel, r.base+"/") {
+			return false
+		}
+		sub = rel[len(r.base)+1:]
+	}
+	if !r.anchored {
+		ok, _ := path.Match(r.segs[0], path.Base(sub))
+		return ok
+	}
+	return matchSegments(r.segs, strings.Split(sub, "/"))
+}
+
+// matchSegments matches path segments against pattern segments, where a
+// "**" segment matches zero or more path segments.
+func matchSegments(pat, segs []string) bool {
+	for len(pat) > 0 {
+		if pat[0] == "**" {
+			rest := pat[1:]
+			if len(rest) == 0 {
+				return len(segs) > 0
+			}
+			for i := 0; i <= len(segs); i++ {
+				if matchSegments(rest, segs[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(segs) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pat[0], segs[0]); !ok {
+			return false
+		}
+		pat, segs = pat[1:], segs[1:]
+	}
+	return len(segs) == 0
+}
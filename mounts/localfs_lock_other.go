@@ -0,0 +1,15 @@
+//go:build !unix
+
+package mounts
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackfish212/grasp/types"
+)
+
+// OpenExclusive is not supported on this platform.
+func (fs *LocalFS) OpenExclusive(_ context.Context, path string) (types.LockedFile, error) {
+	return nil, fmt.Errorf("%w: file locking: %s", types.ErrNotSupported, path)
+}
@@ -0,0 +1,41 @@
+//go:build unix
+
+package mounts
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"syscall"
+
+	"github.com/jackfish212/grasp/types"
+)
+
+var _ types.ExclusiveOpener = (*LocalFS)(nil)
+
+// OpenExclusive opens the host file for reading and writing, creating it if
+// needed, and takes a non-blocking flock on it. Closing the file releases
+// the lock. The lock is visible to other processes using flock.
+func (fs *LocalFS) OpenExclusive(_ context.Context, path string) (types.LockedFile, error) {
+	if !fs.perm.CanWrite() {
+		return nil, fmt.Errorf("%w: %s", types.ErrNotWritable, path)
+	}
+	hp := fs.hostPath(path)
+	if err := os.MkdirAll(filepath.Dir(hp), 0o755); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(hp, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB); err != nil {
+		_ = f.Close()
+		if errors.Is(err, syscall.EWOULDBLOCK) {
+			return nil, fmt.Errorf("%w: %s", types.ErrLocked, path)
+		}
+		return nil, err
+	}
+	return f, nil
+}
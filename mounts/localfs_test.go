@@ -2,11 +2,13 @@ package mounts
 
 import (
 	"context"
+	"errors"
 	"io"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/jackfish212/grasp/types"
 )
@@ -298,3 +300,394 @@ func TestLocalFSMountInfo(t *testing.T) {
 		t.Error("MountInfo extra should not be empty")
 	}
 }
+
+func TestLocalFSSetRoot(t *testing.T) {
+	fs, oldDir := setupLocalFS(t)
+	ctx := context.Background()
+	newDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(newDir, "hello.txt"), []byte("hello v2"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := fs.Open(ctx, "hello.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	if err := fs.SetRoot(newDir); err != nil {
+		t.Fatalf("SetRoot: %v", err)
+	}
+	if fs.Root() != newDir {
+		t.Errorf("Root() = %q, want %q", fs.Root(), newDir)
+	}
+	if _, extra := fs.MountInfo(); extra != newDir {
+		t.Errorf("MountInfo extra = %q, want %q", extra, newDir)
+	}
+
+	// A file opened before the switch still reads from the old root.
+	data, _ := io.ReadAll(f)
+	if string(data) != "hello world" {
+		t.Errorf("open file content = %q", data)
+	}
+	g, err := fs.Open(ctx, "hello.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ = io.ReadAll(g)
+	g.Close()
+	if string(data) != "hello v2" {
+		t.Errorf("content after SetRoot = %q", data)
+	}
+	if _, err := fs.Stat(ctx, "sub/nested.txt"); !errors.Is(err, types.ErrNotFound) {
+		t.Errorf("old root still visible: %v", err)
+	}
+
+	if err := fs.SetRoot(filepath.Join(newDir, "missing")); !errors.Is(err, types.ErrNotFound) {
+		t.Errorf("SetRoot to missing dir: %v", err)
+	}
+	if err := fs.SetRoot(filepath.Join(oldDir, "hello.txt")); !errors.Is(err, types.ErrNotDir) {
+		t.Errorf("SetRoot to file: %v", err)
+	}
+	if fs.Root() != newDir {
+		t.Errorf("failed SetRoot changed the root to %q", fs.Root())
+	}
+}
+
+func TestLocalFSSetRootConcurrent(t *testing.T) {
+	fs, oldDir := setupLocalFS(t)
+	ctx := context.Background()
+	newDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(newDir, "hello.txt"), []byte("hello v2"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			if _, err := fs.Stat(ctx, "hello.txt"); err != nil {
+				t.Error(err)
+				return
+			}
+		}
+	}()
+	for i := 0; i < 100; i++ {
+		root := oldDir
+		if i%2 == 0 {
+			root = newDir
+		}
+		if err := fs.SetRoot(root); err != nil {
+			t.Fatal(err)
+		}
+	}
+	<-done
+}
+
+func TestLocalFSOnEvent(t *testing.T) {
+	dir := t.TempDir()
+	var got []string
+	fs := NewLocalFS(dir, types.PermRW, WithLocalFSOnEvent(func(et types.EventType, path string) {
+		got = append(got, et.String()+" "+path)
+	}))
+	ctx := context.Background()
+
+	if err := fs.Write(ctx, "a.txt", strings.NewReader("x")); err != nil {
+		t.Fatal(err)
+	}
+	if err := fs.Remove(ctx, "a.txt"); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"CREATE a.txt", "WRITE a.txt", "REMOVE a.txt"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("events = %v, want %v", got, want)
+	}
+}
+
+func TestLocalFSEventCoalescing(t *testing.T) {
+	dir := t.TempDir()
+	type event struct {
+		typ  types.EventType
+		path string
+	}
+	events := make(chan event, 16)
+	fs := NewLocalFS(dir, types.PermRW,
+		WithLocalFSOnEvent(func(et types.EventType, path string) { events <- event{et, path} }),
+		WithLocalFSEventCoalescing(50*time.Millisecond),
+	)
+	ctx := context.Background()
+
+	for i := 0; i < 5; i++ {
+		if err := fs.Write(ctx, "build.log", strings.NewReader("line")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := fs.Mkdir(ctx, "out", types.PermRW); err != nil {
+		t.Fatal(err)
+	}
+
+	var got []event
+	timeout := time.After(time.Second)
+	for len(got) < 2 {
+		select {
+		case ev := <-events:
+			got = append(got, ev)
+		case <-timeout:
+			t.Fatalf("timeout, got %v", got)
+		}
+	}
+	if got[0].path != "build.log" || got[0].typ != types.EventCreate|types.EventWrite {
+		t.Errorf("first event = %v %s, want CREATE|WRITE build.log", got[0].typ, got[0].path)
+	}
+	if got[1].path != "out" || got[1].typ != types.EventMkdir {
+		t.Errorf("second event = %v %s, want MKDIR out", got[1].typ, got[1].path)
+	}
+
+	select {
+	case ev := <-events:
+		t.Errorf("unexpected extra event %v %s", ev.typ, ev.path)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestLocalFSWatchRecursive(t *testing.T) {
+	dir := t.TempDir()
+	fs := NewLocalFS(dir, types.PermRW, WithLocalFSPollInterval(10*time.Millisecond))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	events := fs.WatchRecursiveWithFilter(ctx, "", []string{"*.go", "pkg"})
+	time.Sleep(30 * time.Millisecond) // let the initial snapshot settle
+
+	// Changes made outside grasp, including in a new subdirectory.
+	if err := os.MkdirAll(filepath.Join(dir, "pkg"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "pkg", "main.go"), []byte("package main"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "pkg", "notes.txt"), []byte("skip"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]types.EventType{"pkg": types.EventMkdir, "pkg/main.go": types.EventCreate}
+	got := make(map[string]types.EventType)
+	timeout := time.After(2 * time.Second)
+	for len(got) < len(want) {
+		select {
+		case ev := <-events:
+			got[ev.Path] |= ev.Type
+		case <-timeout:
+			t.Fatalf("timed out; got %v, want %v", got, want)
+		}
+	}
+	for p, typ := range want {
+		if !got[p].Matches(typ) {
+			t.Errorf("%s: got %v, want %v", p, got[p], typ)
+		}
+	}
+	if _, ok := got["pkg/notes.txt"]; ok {
+		t.Error("filtered file should not be reported")
+	}
+
+	if err := os.Remove(filepath.Join(dir, "pkg", "main.go")); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case ev := <-events:
+		if ev.Type != types.EventRemove || ev.Path != "pkg/main.go" {
+			t.Errorf("got %v %s, want REMOVE pkg/main.go", ev.Type, ev.Path)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for remove event")
+	}
+
+	cancel()
+	for range events {
+	}
+}
+
+func TestLocalFSWatchRecursiveCoalescing(t *testing.T) {
+	dir := t.TempDir()
+	fs := NewLocalFS(dir, types.PermRW,
+		WithLocalFSPollInterval(10*time.Millisecond),
+		WithLocalFSEventCoalescing(300*time.Millisecond),
+	)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	events := fs.WatchRecursive(ctx, "")
+	time.Sleep(30 * time.Millisecond) // let the initial snapshot settle
+
+	// A build outside grasp rewriting the same file across many polls.
+	out := filepath.Join(dir, "build.log")
+	for i := 1; i <= 10; i++ {
+		if err := os.WriteFile(out, []byte(strings.Repeat("x", i)), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		time.Sleep(15 * time.Millisecond)
+	}
+
+	select {
+	case ev := <-events:
+		if ev.Path != "build.log" || ev.Type != types.EventCreate|types.EventWrite {
+			t.Errorf("got %v %s, want CREATE|WRITE build.log", ev.Type, ev.Path)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for coalesced event")
+	}
+	select {
+	case ev := <-events:
+		t.Errorf("unexpected extra event %v %s", ev.Type, ev.Path)
+	case <-time.After(400 * time.Millisecond):
+	}
+
+	cancel()
+	for range events {
+	}
+}
+
+func TestLocalFSWatchRecursiveSkipsIgnored(t *testing.T) {
+	dir := t.TempDir()
+	for _, d := range []string{".git", "node_modules/lib", "build", "src"} {
+		if err := os.MkdirAll(filepath.Join(dir, d), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(dir, ".gitignore"), []byte("build/\n*.log\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	fs := NewLocalFS(dir, types.PermRW, WithLocalFSPollInterval(10*time.Millisecond), WithLocalFSGitIgnore(true))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	events := fs.WatchRecursive(ctx, "")
+	time.Sleep(30 * time.Millisecond) // let the initial snapshot settle
+
+	for _, p := range []string{".git/index", "node_modules/lib/x.js", "build/out.bin", "src/debug.log", "src/main.go"} {
+		if err := os.WriteFile(filepath.Join(dir, p), []byte("x"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	select {
+	case ev := <-events:
+		if ev.Path != "src/main.go" {
+			t.Errorf("got event for %s, want only src/main.go", ev.Path)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for src/main.go")
+	}
+	select {
+	case ev := <-events:
+		t.Errorf("unexpected event %v %s", ev.Type, ev.Path)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	// Without gitignore support only .git and node_modules are skipped.
+	plain := NewLocalFS(dir, types.PermRW)
+	snap := plain.snapshot("")
+	for _, p := range []string{".git", ".git/index", "node_modules", "node_modules/lib/x.js"} {
+		if _, ok := snap[p]; ok {
+			t.Errorf("snapshot includes %s", p)
+		}
+	}
+	for _, p := range []string{"build/out.bin", "src/debug.log"} {
+		if _, ok := snap[p]; !ok {
+			t.Errorf("snapshot without gitignore is missing %s", p)
+		}
+	}
+}
+
+func TestLocalFSOpenExclusive(t *testing.T) {
+	fs, dir := setupLocalFS(t)
+	ctx := context.Background()
+
+	f, err := fs.OpenExclusive(ctx, "hello.txt")
+	if errors.Is(err, types.ErrNotSupported) {
+		t.Skip("file locking not supported on this platform")
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := fs.OpenExclusive(ctx, "hello.txt"); !errors.Is(err, types.ErrLocked) {
+		t.Errorf("second OpenExclusive: err = %v, want ErrLocked", err)
+	}
+	if _, err := io.WriteString(f, "HELLO"); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	data, _ := os.ReadFile(filepath.Join(dir, "hello.txt"))
+	if string(data) != "HELLO world" {
+		t.Errorf("content = %q, want %q", data, "HELLO world")
+	}
+	f, err = fs.OpenExclusive(ctx, "hello.txt")
+	if err != nil {
+		t.Fatalf("OpenExclusive after Close: %v", err)
+	}
+	_ = f.Close()
+}
+
+func TestLocalFSGitIgnore(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		".gitignore":              "node_modules/\n*.log\n/build\n!keep.log\ndocs/**/draft.md\n",
+		".git/info/exclude":       "secret.txt\n",
+		".git/HEAD":               "ref: refs/heads/main\n",
+		"main.go":                 "package main\n",
+		"debug.log":               "x",
+		"keep.log":                "x",
+		"secret.txt":              "x",
+		"build/out":               "x",
+		"node_modules/pkg/a.js":   "x",
+		"src/build/gen.go":        "x",
+		"src/.gitignore":          "*.tmp\n",
+		"src/cache.tmp":           "x",
+		"src/lib.go":              "x",
+		"docs/guide/draft.md":     "x",
+		"docs/guide/final.md":     "x",
+		"other/cache.tmp":         "x",
+		"other/node_modules/b.js": "x",
+	}
+	for name, content := range files {
+		hp := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(hp), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(hp, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ctx := context.Background()
+	names := func(fs *LocalFS, p string) string {
+		t.Helper()
+		entries, err := fs.List(ctx, p, types.ListOpts{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var out []string
+		for _, e := range entries {
+			out = append(out, e.Name)
+		}
+		return strings.Join(out, ",")
+	}
+
+	fs := NewLocalFS(dir, types.PermRO, WithLocalFSGitIgnore(true))
+	tests := map[string]string{
+		"":           ".gitignore,docs,keep.log,main.go,other,src",
+		"src":        ".gitignore,build,lib.go",
+		"docs/guide": "final.md",
+		"other":      "cache.tmp",
+	}
+	for p, want := range tests {
+		if got := names(fs, p); got != want {
+			t.Errorf("List(%q) = %s, want %s", p, got, want)
+		}
+	}
+
+	if got := names(NewLocalFS(dir, types.PermRO), ""); !strings.Contains(got, "node_modules") {
+		t.Errorf("without WithLocalFSGitIgnore everything is listed, got %s", got)
+	}
+}
@@ -0,0 +1,200 @@
+package mounts
+
+import (
+	"context"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/jackfish212/grasp/types"
+)
+
+const defaultLocalFSPollInterval = 500 * time.Millisecond
+
+// WithLocalFSPollInterval sets how often WatchRecursive rescans the host
+// directory tree. The default is 500ms.
+func WithLocalFSPollInterval(d time.Duration) LocalFSOption {
+	return func(fs *LocalFS) { fs.pollInterval = d }
+}
+
+// WatchRecursive reports changes anywhere under root (relative to the LocalFS
+// root; "" for the whole tree), including changes made outside grasp.
+// Subdirectories created later are picked up automatically. Event paths are
+// relative to the LocalFS root. The channel is closed when ctx is done.
+//
+// Changes are detected by periodically rescanning the tree, so a rename shows
+// up as a remove followed by a create, and changes that are undone within one
+// poll interval are not reported. Polling is used instead of inotify-style
+// notification (fsnotify) so that the core module keeps no external
+// dependencies, and because it also sees changes on network and bind-mounted
+// filesystems that do not deliver notifications, without one OS watch per
+// directory. The cost is a tree walk per interval, so .git and node_modules
+// directories are never scanned, and neither is anything hidden by
+// WithLocalFSGitIgnore.
+func (fs *LocalFS) WatchRecursive(ctx context.Context, root string) <-chan types.WatchEvent {
+	return fs.WatchRecursiveWithFilter(ctx, root, nil)
+}
+
+// WatchRecursiveWithFilter is like WatchRecursive but only reports entries
+// whose base name matches at least one of the filepath.Match patterns
+// (e.g. "*.go"). No patterns means no filtering.
+//
+// With WithLocalFSEventCoalescing, the events of the polls made within the
+// window after a change are merged per path, as for WithLocalFSOnEvent.
+func (fs *LocalFS) WatchRecursiveWithFilter(ctx context.Context, root string, patterns []string) <-chan types.WatchEvent {
+	ch := make(chan types.WatchEvent, 64)
+	interval := fs.pollInterval
+	if interval <= 0 {
+		interval = defaultLocalFSPollInterval
+	}
+	root = normPath(root)
+
+	go func() {
+		defer close(ch)
+		send := func(events []types.WatchEvent) bool {
+			for _, ev := range events {
+				select {
+				case ch <- ev:
+				case <-ctx.Done():
+					return false
+				}
+			}
+			return true
+		}
+
+		var pending pendingEvents
+		var flush <-chan time.Time // set while coalesced events are pending
+		prev := fs.snapshot(root)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-flush:
+				flush = nil
+				if !send(pending.take()) {
+					return
+				}
+				continue
+			case <-ticker.C:
+			}
+			cur := fs.snapshot(root)
+			var events []types.WatchEvent
+			for _, ev := range diffSnapshots(prev, cur) {
+				if matchesAny(patterns, ev.Path) {
+					events = append(events, ev)
+				}
+			}
+			prev = cur
+			if fs.coalesceWindow <= 0 {
+				if !send(events) {
+					return
+				}
+				continue
+			}
+			for _, ev := range events {
+				if pending.add(ev.Type, ev.Path) {
+					flush = time.After(fs.coalesceWindow)
+				}
+			}
+		}
+	}()
+	return ch
+}
+
+type snapEntry struct {
+	isDir   bool
+	size    int64
+	modTime time.Time
+}
+
+// unwatchedDirs are never scanned by WatchRecursive: they are large, churn
+// constantly, and are not of interest to agents.
+var unwatchedDirs = map[string]bool{".git": true, "node_modules": true}
+
+// snapshot records every entry under root, keyed by path relative to the
+// LocalFS root. Directories in unwatchedDirs and, with WithLocalFSGitIgnore,
+// ignored entries are left out.
+func (fs *LocalFS) snapshot(root string) map[string]snapEntry {
+	snap := make(map[string]snapEntry)
+	top := fs.Root()
+	base := joinHostPath(top, root)
+	rules := make(map[string][]ignoreRule) // by parent directory
+	_ = filepath.WalkDir(base, func(hp string, d os.DirEntry, err error) error {
+		if err != nil || hp == base {
+			return nil
+		}
+		rel, relErr := filepath.Rel(top, hp)
+		if relErr != nil {
+			return nil
+		}
+		rel = filepath.ToSlash(rel)
+		if d.IsDir() && unwatchedDirs[d.Name()] {
+			return filepath.SkipDir
+		}
+		if fs.gitIgnore {
+			dir := path.Dir(rel)
+			if dir == "." {
+				dir = ""
+			}
+			r, ok := rules[dir]
+			if !ok {
+				r = fs.ignoreRules(dir)
+				rules[dir] = r
+			}
+			if ignored(r, rel, d.IsDir()) {
+				if d.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+		}
+		info, infoErr := d.Info()
+		if infoErr != nil {
+			return nil
+		}
+		snap[rel] = snapEntry{isDir: d.IsDir(), size: info.Size(), modTime: info.ModTime()}
+		return nil
+	})
+	return snap
+}
+
+// diffSnapshots returns the events that turn prev into cur, ordered by path.
+func diffSnapshots(prev, cur map[string]snapEntry) []types.WatchEvent {
+	now := time.Now()
+	var events []types.WatchEvent
+	for p, c := range cur {
+		old, existed := prev[p]
+		switch {
+		case !existed && c.isDir:
+			events = append(events, types.WatchEvent{Type: types.EventMkdir, Path: p, Time: now})
+		case !existed:
+			events = append(events, types.WatchEvent{Type: types.EventCreate, Path: p, Time: now})
+		case !c.isDir && (c.size != old.size || !c.modTime.Equal(old.modTime)):
+			events = append(events, types.WatchEvent{Type: types.EventWrite, Path: p, Time: now})
+		}
+	}
+	for p := range prev {
+		if _, ok := cur[p]; !ok {
+			events = append(events, types.WatchEvent{Type: types.EventRemove, Path: p, Time: now})
+		}
+	}
+	sort.Slice(events, func(i, j int) bool { return events[i].Path < events[j].Path })
+	return events
+}
+
+func matchesAny(patterns []string, p string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+	name := baseName(p)
+	for _, pat := range patterns {
+		if ok, _ := filepath.Match(pat, name); ok {
+			return true
+		}
+	}
+	return false
+}
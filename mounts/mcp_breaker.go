@@ -0,0 +1,94 @@
+package mounts
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned by HttpMCPClient.CallTool when a tool's circuit
+// breaker is open and the call was rejected without contacting the server.
+var ErrCircuitOpen = errors.New("mcp: circuit open")
+
+// WithCircuitBreaker stops calling a tool after maxFailures consecutive
+// failures. A failure is a transport or JSON-RPC error or a result with
+// IsError set. While the circuit is open, CallTool returns ErrCircuitOpen
+// immediately; after openTimeout one trial call is let through, and its
+// outcome closes or reopens the circuit. Each tool has its own circuit.
+func WithCircuitBreaker(maxFailures int, openTimeout time.Duration) HttpMCPOption {
+	return func(c *HttpMCPClient) {
+		if maxFailures > 0 {
+			c.breaker = &circuitBreaker{
+				maxFailures: maxFailures,
+				openTimeout: openTimeout,
+				tools:       make(map[string]*circuitState),
+			}
+		}
+	}
+}
+
+// circuitBreaker follows the consecutive-failures policy of
+// github.com/sony/gobreaker (closed, open, then half-open with a single
+// trial call) with one circuit per tool. It is written here rather than
+// imported so the core module keeps no external dependencies.
+type circuitBreaker struct {
+	maxFailures int
+	openTimeout time.Duration
+
+	mu    sync.Mutex
+	tools map[string]*circuitState
+}
+
+type circuitState struct {
+	failures int
+	openedAt time.Time // zero while closed
+	probing  bool      // a half-open trial call is in flight
+}
+
+// allow reports whether a call to tool may proceed.
+func (b *circuitBreaker) allow(tool string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	st := b.tools[tool]
+	if st == nil || st.openedAt.IsZero() {
+		return nil
+	}
+	if st.probing || time.Since(st.openedAt) < b.openTimeout {
+		return fmt.Errorf("%w: %s", ErrCircuitOpen, tool)
+	}
+	st.probing = true
+	return nil
+}
+
+// record updates tool's circuit with the outcome of an allowed call.
+func (b *circuitBreaker) record(tool string, ok bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if ok {
+		delete(b.tools, tool)
+		return
+	}
+	st := b.tools[tool]
+	if st == nil {
+		st = &circuitState{}
+		b.tools[tool] = st
+	}
+	st.failures++
+	if st.probing || st.failures >= b.maxFailures {
+		st.openedAt = time.Now()
+		st.probing = false
+	}
+}
+
+// abandon releases a half-open trial whose outcome is unknown, letting the
+// next call probe again.
+func (b *circuitBreaker) abandon(tool string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if st := b.tools[tool]; st != nil {
+		st.probing = false
+	}
+}
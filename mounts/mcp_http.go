@@ -24,9 +24,12 @@ type HttpMCPClient struct {
 	url        string
 	httpClient *http.Client
 	headers    map[string]string
+	headerFn   func() map[string]string
+	hmu        sync.RWMutex // guards headers and headerFn
 	sessionID  string
 	reqID      atomic.Int64
 	mu         sync.Mutex
+	breaker    *circuitBreaker // nil unless WithCircuitBreaker is set
 }
 
 // HttpMCPOption configures an HttpMCPClient.
@@ -61,6 +64,45 @@ func NewHttpMCPClient(url string, opts ...HttpMCPOption) *HttpMCPClient {
 	return c
 }
 
+// SetHeaders updates the headers sent with every subsequent request. Each
+// entry overrides any existing value for that header; an empty value removes
+// the header. Use it to rotate tokens or attach trace IDs at runtime.
+func (c *HttpMCPClient) SetHeaders(headers map[string]string) {
+	c.hmu.Lock()
+	defer c.hmu.Unlock()
+	for k, v := range headers {
+		if v == "" {
+			delete(c.headers, k)
+			continue
+		}
+		c.headers[k] = v
+	}
+}
+
+// SetHeaderFunc registers fn to compute headers for each request, e.g. to
+// read a freshly refreshed OAuth token. Computed headers take precedence over
+// static ones. Pass nil to remove it.
+func (c *HttpMCPClient) SetHeaderFunc(fn func() map[string]string) {
+	c.hmu.Lock()
+	defer c.hmu.Unlock()
+	c.headerFn = fn
+}
+
+func (c *HttpMCPClient) applyHeaders(req *http.Request) {
+	c.hmu.RLock()
+	for k, v := range c.headers {
+		req.Header.Set(k, v)
+	}
+	fn := c.headerFn
+	c.hmu.RUnlock()
+
+	if fn != nil {
+		for k, v := range fn() {
+			req.Header.Set(k, v)
+		}
+	}
+}
+
 func (c *HttpMCPClient) call(ctx context.Context, method string, params any) (*jsonRPCResponse, error) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
@@ -90,9 +132,7 @@ func (c *HttpMCPClient) call(ctx context.Context, method string, params any) (*j
 
 	httpReq.Header.Set("Content-Type", "application/json")
 	httpReq.Header.Set("Accept", "application/json, text/event-stream")
-	for k, v := range c.headers {
-		httpReq.Header.Set(k, v)
-	}
+	c.applyHeaders(httpReq)
 	if c.sessionID != "" {
 		httpReq.Header.Set("Mcp-Session-Id", c.sessionID)
 	}
@@ -162,9 +202,7 @@ func (c *HttpMCPClient) notify(ctx context.Context, method string) {
 		return
 	}
 	httpReq.Header.Set("Content-Type", "application/json")
-	for k, v := range c.headers {
-		httpReq.Header.Set(k, v)
-	}
+	c.applyHeaders(httpReq)
 	if c.sessionID != "" {
 		httpReq.Header.Set("Mcp-Session-Id", c.sessionID)
 	}
@@ -209,8 +247,26 @@ func (c *HttpMCPClient) ListTools(ctx context.Context) ([]MCPTool, error) {
 	return parseToolsList(resultBytes)
 }
 
-// CallTool invokes a tool on the MCP server.
+// CallTool invokes a tool on the MCP server. With WithCircuitBreaker, calls
+// to a tool whose circuit is open fail fast with ErrCircuitOpen.
 func (c *HttpMCPClient) CallTool(ctx context.Context, name string, args map[string]any) (*MCPToolResult, error) {
+	if c.breaker == nil {
+		return c.callTool(ctx, name, args)
+	}
+	if err := c.breaker.allow(name); err != nil {
+		return nil, err
+	}
+	result, err := c.callTool(ctx, name, args)
+	if err != nil && ctx.Err() != nil {
+		// The caller gave up; that says nothing about the tool's health.
+		c.breaker.abandon(name)
+		return nil, err
+	}
+	c.breaker.record(name, err == nil && !result.IsError)
+	return result, err
+}
+
+func (c *HttpMCPClient) callTool(ctx context.Context, name string, args map[string]any) (*MCPToolResult, error) {
 	params := map[string]any{
 		"name":      name,
 		"arguments": args,
@@ -0,0 +1,142 @@
+package mounts
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newHeaderRecorder starts an MCP-like server that answers every JSON-RPC
+// call with an empty tools list and records the headers of each request.
+func newHeaderRecorder(t *testing.T) (*httptest.Server, func() []http.Header) {
+	t.Helper()
+	var mu sync.Mutex
+	var seen []http.Header
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		seen = append(seen, r.Header.Clone())
+		mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"jsonrpc": "2.0",
+			"id":      1,
+			"result":  map[string]any{"tools": []any{}},
+		})
+	}))
+	t.Cleanup(srv.Close)
+	return srv, func() []http.Header {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]http.Header(nil), seen...)
+	}
+}
+
+func TestHttpMCPClientSetHeaders(t *testing.T) {
+	srv, seen := newHeaderRecorder(t)
+	c := NewHttpMCPClient(srv.URL, WithBearerToken("old"), WithHeader("X-Static", "1"))
+	ctx := context.Background()
+
+	if _, err := c.ListTools(ctx); err != nil {
+		t.Fatalf("ListTools: %v", err)
+	}
+	c.SetHeaders(map[string]string{"Authorization": "Bearer new", "X-Trace-Id": "abc", "X-Static": ""})
+	if _, err := c.ListTools(ctx); err != nil {
+		t.Fatalf("ListTools: %v", err)
+	}
+
+	reqs := seen()
+	if got := reqs[0].Get("Authorization"); got != "Bearer old" {
+		t.Errorf("first Authorization = %q", got)
+	}
+	if got := reqs[1].Get("Authorization"); got != "Bearer new" {
+		t.Errorf("second Authorization = %q, want rotated token", got)
+	}
+	if got := reqs[1].Get("X-Trace-Id"); got != "abc" {
+		t.Errorf("X-Trace-Id = %q", got)
+	}
+	if got := reqs[1].Get("X-Static"); got != "" {
+		t.Errorf("X-Static should be removed, got %q", got)
+	}
+}
+
+func TestHttpMCPClientSetHeaderFunc(t *testing.T) {
+	srv, seen := newHeaderRecorder(t)
+	c := NewHttpMCPClient(srv.URL, WithBearerToken("static"))
+	token := "t1"
+	c.SetHeaderFunc(func() map[string]string {
+		return map[string]string{"Authorization": "Bearer " + token}
+	})
+	ctx := context.Background()
+
+	if _, err := c.ListTools(ctx); err != nil {
+		t.Fatalf("ListTools: %v", err)
+	}
+	token = "t2"
+	if _, err := c.ListTools(ctx); err != nil {
+		t.Fatalf("ListTools: %v", err)
+	}
+
+	reqs := seen()
+	if got := reqs[0].Get("Authorization"); got != "Bearer t1" {
+		t.Errorf("first Authorization = %q, want computed header", got)
+	}
+	if got := reqs[1].Get("Authorization"); got != "Bearer t2" {
+		t.Errorf("second Authorization = %q, want refreshed header", got)
+	}
+}
+
+func TestHttpMCPClientCircuitBreaker(t *testing.T) {
+	var calls atomic.Int32
+	var failing atomic.Bool
+	failing.Store(true)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		if failing.Load() {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"jsonrpc": "2.0",
+			"id":      1,
+			"result":  map[string]any{"content": []any{map[string]any{"type": "text", "text": "ok"}}},
+		})
+	}))
+	defer srv.Close()
+
+	c := NewHttpMCPClient(srv.URL, WithCircuitBreaker(2, 50*time.Millisecond))
+	ctx := context.Background()
+	for i := 0; i < 2; i++ {
+		if _, err := c.CallTool(ctx, "flaky", nil); err == nil || errors.Is(err, ErrCircuitOpen) {
+			t.Fatalf("call %d: err = %v, want server error", i, err)
+		}
+	}
+	if _, err := c.CallTool(ctx, "flaky", nil); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("err = %v, want ErrCircuitOpen", err)
+	}
+	if n := calls.Load(); n != 2 {
+		t.Errorf("server saw %d calls, want 2 (open circuit must not send)", n)
+	}
+
+	// Other tools have their own circuit.
+	if _, err := c.CallTool(ctx, "other", nil); errors.Is(err, ErrCircuitOpen) {
+		t.Error("unrelated tool should not be blocked")
+	}
+
+	// After the timeout a trial call goes through and, on success, closes
+	// the circuit.
+	time.Sleep(60 * time.Millisecond)
+	failing.Store(false)
+	if _, err := c.CallTool(ctx, "flaky", nil); err != nil {
+		t.Fatalf("half-open trial: %v", err)
+	}
+	if _, err := c.CallTool(ctx, "flaky", nil); err != nil {
+		t.Errorf("closed circuit: %v", err)
+	}
+}
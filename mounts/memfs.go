@@ -3,24 +3,29 @@ package mounts
 import (
 	"bytes"
 	"context"
+	"encoding/base64"
 	"fmt"
 	"io"
 	"log/slog"
+	"runtime/debug"
 	"sort"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/jackfish212/grasp/types"
 )
 
 var (
-	_ types.Provider   = (*MemFS)(nil)
-	_ types.Readable   = (*MemFS)(nil)
-	_ types.Writable   = (*MemFS)(nil)
-	_ types.Executable = (*MemFS)(nil)
-	_ types.Mutable    = (*MemFS)(nil)
-	_ types.Touchable  = (*MemFS)(nil)
+	_ types.Provider     = (*MemFS)(nil)
+	_ types.Readable     = (*MemFS)(nil)
+	_ types.Writable     = (*MemFS)(nil)
+	_ types.Executable   = (*MemFS)(nil)
+	_ types.Mutable      = (*MemFS)(nil)
+	_ types.Touchable    = (*MemFS)(nil)
+	_ types.MetaProvider = (*MemFS)(nil)
+	_ types.PagedLister  = (*MemFS)(nil)
 )
 
 // Func is the signature for functions registered as binaries.
@@ -36,59 +41,405 @@ type FuncMeta struct {
 }
 
 // MemFS is an in-memory filesystem.
+//
+// Entries are stored per directory and locked hierarchically. Operations on
+// a single entry hold the tree lock shared, then lock only the directory
+// they look up or add to and, for content and metadata, the file itself, so
+// reads and writes of different files proceed in parallel and a write
+// blocks only readers of the same file. Operations that remove entries or
+// span directories (Remove, Rename, Link, Clone, Compact, MerkleRoot and
+// the Add methods) hold the tree lock exclusively.
 type MemFS struct {
+	// mu is the tree lock. Shared holders may look up entries and add them
+	// to existing directories, locking the memDir and memFile they touch.
+	// Creating or dropping a directory shard, and removing or replacing
+	// entries, require holding it exclusively, which also makes the
+	// directory and file locks unnecessary.
 	mu    sync.RWMutex
-	files map[string]*memFile
+	dirs  map[string]*memDir // keyed by directory; "" is the root
+	count atomic.Int64       // number of entries, for maxEntries
 	perm  types.Perm
+
+	maxFileSize int64 // 0 means unlimited
+	maxEntries  int   // 0 means unlimited
+
+	compactInterval time.Duration
+	stopCompact     chan struct{}
+	closeOnce       sync.Once
+
+	locked map[string]bool // paths held by OpenExclusive
+
+	mmapThreshold int64 // 0 disables memory-mapped content
+
+	caseInsensitive bool // paths are stored and looked up in lower case
+
+	logMu   sync.Mutex     // guards changes and the journal
+	changes []changeRecord // modification log backing ListChanged
+
+	journalSubs   []*journalSub // Journal subscribers
+	journalClosed bool
 }
 
+// MemFSOption configures a MemFS.
+type MemFSOption func(*MemFS)
+
+// WithMemFSMaxFileSize rejects writes that would make a single file larger
+// than bytes with types.ErrFileTooLarge.
+func WithMemFSMaxFileSize(bytes int64) MemFSOption {
+	return func(fs *MemFS) { fs.maxFileSize = bytes }
+}
+
+// WithMemFSMaxEntries limits the total number of entries (files, directories
+// and functions) the filesystem may hold. Creating an entry beyond the limit
+// fails with types.ErrTooManyEntries.
+func WithMemFSMaxEntries(n int) MemFSOption {
+	return func(fs *MemFS) { fs.maxEntries = n }
+}
+
+// WithMemFSCaseInsensitive makes path lookups ignore case, as on Windows and
+// macOS filesystems: "README.md" and "readme.md" name the same file. Paths are
+// stored in lower case, so listings show lower-case names.
+func WithMemFSCaseInsensitive(enabled bool) MemFSOption {
+	return func(fs *MemFS) { fs.caseInsensitive = enabled }
+}
+
+// WithMemFSCompactInterval runs Compact every d in a background goroutine.
+// Call Close to stop it when the filesystem is no longer used.
+func WithMemFSCompactInterval(d time.Duration) MemFSOption {
+	return func(fs *MemFS) { fs.compactInterval = d }
+}
+
+// memDir holds the entries stored directly in one directory, keyed by base
+// name. A directory has a memDir only while it holds entries.
+type memDir struct {
+	mu      sync.RWMutex // guards entries
+	entries map[string]*memFile
+}
+
+// memFile is one entry. Hard links share a memFile.
 type memFile struct {
-	content  []byte
+	// mu guards content, perm, modified and meta. The other fields do not
+	// change once the entry is stored.
+	mu       sync.RWMutex
+	content  *contentRef
 	isDir    bool
 	perm     types.Perm
 	modified time.Time
 	meta     map[string]string
+	link     string // symlink target; empty for regular entries
+	mimeType string // declared content type; empty when unknown
 	fn       Func
 	execFn   ExecFunc
 }
 
+// contentRef is an immutable block of file content. Writes never mutate a
+// contentRef in place; they swap in a new one. This lets clones share content
+// with the original until either side modifies a file (copy-on-write).
+type contentRef struct {
+	data []byte
+
+	// Mapped content lives outside the Go heap and is unmapped when refs
+	// drops to zero.
+	mapped bool
+	refs   atomic.Int32
+
+	sum atomic.Pointer[[32]byte] // SHA-256 of data, computed on demand
+}
+
+func newContentRef(data []byte) *contentRef {
+	return &contentRef{data: data}
+}
+
+func (c *contentRef) bytes() []byte {
+	if c == nil {
+		return nil
+	}
+	return c.data
+}
+
+func (c *contentRef) size() int64 {
+	if c == nil {
+		return 0
+	}
+	return int64(len(c.data))
+}
+
 // NewMemFS creates a new in-memory filesystem.
-func NewMemFS(perm types.Perm) *MemFS {
-	return &MemFS{files: make(map[string]*memFile), perm: perm}
+func NewMemFS(perm types.Perm, opts ...MemFSOption) *MemFS {
+	fs := &MemFS{dirs: make(map[string]*memDir), perm: perm}
+	for _, opt := range opts {
+		opt(fs)
+	}
+	if fs.compactInterval > 0 {
+		fs.stopCompact = make(chan struct{})
+		go fs.compactLoop()
+	}
+	return fs
+}
+
+func (fs *MemFS) compactLoop() {
+	ticker := time.NewTicker(fs.compactInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if freed, _ := fs.Compact(); freed > 0 {
+				slog.Debug("memfs: compacted", "freed", freed)
+			}
+		case <-fs.stopCompact:
+			return
+		}
+	}
+}
+
+// Close stops background compaction started by WithMemFSCompactInterval,
+// ends Journal subscriptions, and moves memory-mapped content from
+// WithMemFSMmap back to the heap. The filesystem remains usable, with all
+// its files. Close is safe to call more than once.
+func (fs *MemFS) Close() error {
+	fs.closeOnce.Do(func() {
+		if fs.stopCompact != nil {
+			close(fs.stopCompact)
+		}
+		fs.unmapAll()
+		fs.closeJournal()
+	})
+	return nil
+}
+
+// key returns the map key for path.
+func (fs *MemFS) key(path string) string {
+	if fs.caseInsensitive {
+		return strings.ToLower(normPath(path))
+	}
+	return normPath(path)
+}
+
+// splitKey splits a key into its directory and base name.
+func splitKey(p string) (dir, name string) {
+	if i := strings.LastIndexByte(p, '/'); i >= 0 {
+		return p[:i], p[i+1:]
+	}
+	return "", p
+}
+
+// joinKey is the inverse of splitKey.
+func joinKey(dir, name string) string {
+	if dir == "" {
+		return name
+	}
+	return dir + "/" + name
+}
+
+// checkNewEntry reserves room for one more entry, failing once the limit
+// set by WithMemFSMaxEntries is reached. The caller must then store the
+// entry without counting it again.
+func (fs *MemFS) checkNewEntry(path string) error {
+	for {
+		n := fs.count.Load()
+		if fs.maxEntries > 0 && n >= int64(fs.maxEntries) {
+			return fmt.Errorf("%w: %s (limit %d)", types.ErrTooManyEntries, path, fs.maxEntries)
+		}
+		if fs.count.CompareAndSwap(n, n+1) {
+			return nil
+		}
+	}
+}
+
+// lookup returns the entry stored at key p. Callers must hold fs.mu, in
+// either mode, and must not hold the lock of p's directory.
+func (fs *MemFS) lookup(p string) (*memFile, bool) {
+	dir, name := splitKey(p)
+	d := fs.dirs[dir]
+	if d == nil {
+		return nil, false
+	}
+	d.mu.RLock()
+	f, ok := d.entries[name]
+	d.mu.RUnlock()
+	return f, ok
+}
+
+// hasDescendants reports whether anything is stored below p, which makes p
+// a directory even without an entry of its own. Callers must hold fs.mu.
+func (fs *MemFS) hasDescendants(p string) bool {
+	if p == "" {
+		return len(fs.dirs) > 0
+	}
+	for k := range fs.dirs {
+		if k == p || strings.HasPrefix(k, p+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// lockDir locks directory dir for adding entries and returns it with the
+// function that unlocks it. An existing directory is locked on its own
+// under the shared tree lock; creating one takes the tree lock exclusively,
+// and the directory is dropped again on unlock if nothing was added.
+// Callers must not hold fs.mu.
+func (fs *MemFS) lockDir(dir string) (*memDir, func()) {
+	fs.mu.RLock()
+	if d := fs.dirs[dir]; d != nil {
+		d.mu.Lock()
+		return d, func() {
+			d.mu.Unlock()
+			fs.mu.RUnlock()
+		}
+	}
+	fs.mu.RUnlock()
+
+	fs.mu.Lock()
+	d := fs.dirLocked(dir)
+	return d, func() {
+		if len(d.entries) == 0 {
+			delete(fs.dirs, dir)
+		}
+		fs.mu.Unlock()
+	}
+}
+
+// dirLocked returns directory dir, creating it if needed. Callers must hold
+// fs.mu for writing.
+func (fs *MemFS) dirLocked(dir string) *memDir {
+	d := fs.dirs[dir]
+	if d == nil {
+		d = &memDir{entries: make(map[string]*memFile)}
+		fs.dirs[dir] = d
+	}
+	return d
+}
+
+// storeLocked stores f at key p without counting it; see checkNewEntry.
+// Callers must hold fs.mu for writing.
+func (fs *MemFS) storeLocked(p string, f *memFile) {
+	dir, name := splitKey(p)
+	fs.dirLocked(dir).entries[name] = f
+}
+
+// putLocked stores f at key p, counting it if p was free, and returns the
+// entry it replaced. Callers must hold fs.mu for writing.
+func (fs *MemFS) putLocked(p string, f *memFile) (*memFile, bool) {
+	dir, name := splitKey(p)
+	d := fs.dirLocked(dir)
+	old, existed := d.entries[name]
+	if !existed {
+		fs.count.Add(1)
+	}
+	d.entries[name] = f
+	return old, existed
+}
+
+// deleteLocked removes the entry at key p, dropping its directory once
+// empty. Callers must hold fs.mu for writing.
+func (fs *MemFS) deleteLocked(p string) (*memFile, bool) {
+	dir, name := splitKey(p)
+	d := fs.dirs[dir]
+	if d == nil {
+		return nil, false
+	}
+	f, ok := d.entries[name]
+	if !ok {
+		return nil, false
+	}
+	delete(d.entries, name)
+	fs.count.Add(-1)
+	if len(d.entries) == 0 {
+		delete(fs.dirs, dir)
+	}
+	return f, true
+}
+
+// walkLocked calls fn for every entry. Callers must hold fs.mu for writing.
+func (fs *MemFS) walkLocked(fn func(p string, f *memFile)) {
+	for dir, d := range fs.dirs {
+		for name, f := range d.entries {
+			fn(joinKey(dir, name), f)
+		}
+	}
+}
+
+// subtreeLocked returns the keys of all entries below p. Callers must hold
+// fs.mu for writing.
+func (fs *MemFS) subtreeLocked(p string) []string {
+	var keys []string
+	for dir, d := range fs.dirs {
+		if dir != p && !strings.HasPrefix(dir, p+"/") {
+			continue
+		}
+		for name := range d.entries {
+			keys = append(keys, joinKey(dir, name))
+		}
+	}
+	return keys
 }
 
 func (fs *MemFS) AddFile(path string, content []byte, perm types.Perm) {
 	fs.mu.Lock()
 	defer fs.mu.Unlock()
-	fs.files[normPath(path)] = &memFile{content: content, perm: perm, modified: time.Now()}
+	p := fs.key(path)
+	f := &memFile{content: fs.newContent(content), perm: perm, modified: time.Now()}
+	old, existed := fs.putLocked(p, f)
+	fs.noteChange(p, f.modified)
+	fs.journalReplace(p, existed)
+	if existed {
+		fs.releaseUnreferenced([]*memFile{old})
+	}
 	slog.Debug("memfs: added file", "path", path, "size", len(content), "perm", perm)
 }
 
+// AddBinaryFile adds a file whose content type is known, such as an image
+// or archive. The MIME type is reported in Entry.MimeType and survives later
+// writes to the file, so tools can tell binary content from text without
+// sniffing it.
+func (fs *MemFS) AddBinaryFile(path string, content []byte, mimeType string, perm types.Perm) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	p := fs.key(path)
+	f := &memFile{content: fs.newContent(content), perm: perm, modified: time.Now(), mimeType: mimeType}
+	old, existed := fs.putLocked(p, f)
+	fs.noteChange(p, f.modified)
+	fs.journalReplace(p, existed)
+	if existed {
+		fs.releaseUnreferenced([]*memFile{old})
+	}
+	slog.Debug("memfs: added binary file", "path", path, "size", len(content), "mime", mimeType, "perm", perm)
+}
+
 func (fs *MemFS) AddDir(path string) {
 	fs.mu.Lock()
 	defer fs.mu.Unlock()
-	fs.files[normPath(path)] = &memFile{isDir: true, perm: types.PermRX, modified: time.Now()}
+	p := fs.key(path)
+	if _, existed := fs.putLocked(p, &memFile{isDir: true, perm: types.PermRX, modified: time.Now()}); !existed {
+		fs.journal(types.EventMkdir, p, "")
+	}
 	slog.Debug("memfs: added directory", "path", path)
 }
 
 func (fs *MemFS) AddFunc(path string, fn Func, meta FuncMeta) {
 	fs.mu.Lock()
 	defer fs.mu.Unlock()
-	fs.files[normPath(path)] = &memFile{
+	p := fs.key(path)
+	f := &memFile{
 		perm:     types.PermRX,
 		modified: time.Now(),
 		meta:     map[string]string{"kind": "func", "description": meta.Description},
 		fn:       fn,
 	}
 	if meta.Usage != "" {
-		fs.files[normPath(path)].meta["usage"] = meta.Usage
+		f.meta["usage"] = meta.Usage
 	}
+	_, existed := fs.putLocked(p, f)
+	fs.noteChange(p, f.modified)
+	fs.journalReplace(p, existed)
 }
 
 func (fs *MemFS) AddExecFunc(path string, fn ExecFunc, meta FuncMeta) {
 	fs.mu.Lock()
 	defer fs.mu.Unlock()
-	fs.files[normPath(path)] = &memFile{
+	p := fs.key(path)
+	f := &memFile{
 		perm:     types.PermRX,
 		modified: time.Now(),
 		meta:     map[string]string{"kind": "func", "description": meta.Description},
@@ -96,15 +447,19 @@ func (fs *MemFS) AddExecFunc(path string, fn ExecFunc, meta FuncMeta) {
 	}
 	slog.Debug("memfs: added exec function", "path", path, "description", meta.Description, "usage", meta.Usage)
 	if meta.Usage != "" {
-		fs.files[normPath(path)].meta["usage"] = meta.Usage
+		f.meta["usage"] = meta.Usage
 	}
+	_, existed := fs.putLocked(p, f)
+	fs.noteChange(p, f.modified)
+	fs.journalReplace(p, existed)
 }
 
 func (fs *MemFS) RemoveFunc(path string) bool {
 	fs.mu.Lock()
 	defer fs.mu.Unlock()
-	if _, ok := fs.files[normPath(path)]; ok {
-		delete(fs.files, normPath(path))
+	if _, ok := fs.deleteLocked(fs.key(path)); ok {
+		fs.journal(types.EventRemove, fs.key(path), "")
+		fs.pruneChangesLocked()
 		return true
 	}
 	return false
@@ -114,84 +469,266 @@ func (fs *MemFS) Stat(_ context.Context, path string) (*types.Entry, error) {
 	fs.mu.RLock()
 	defer fs.mu.RUnlock()
 
-	path = normPath(path)
+	path = fs.key(path)
 
-	if f, ok := fs.files[path]; ok {
-		return f.toEntry(path), nil
+	if f, ok := fs.lookup(path); ok {
+		e := f.snapshot(path)
+		return &e, nil
 	}
+	if fs.hasDescendants(path) {
+		return &types.Entry{Name: baseName(path), Path: path, IsDir: true, Perm: types.PermRX}, nil
+	}
+
+	return nil, fmt.Errorf("%w: %s", types.ErrNotFound, path)
+}
+
+func (fs *MemFS) List(_ context.Context, path string, _ types.ListOpts) ([]types.Entry, error) {
+	fs.mu.RLock()
+	defer fs.mu.RUnlock()
 
+	path = fs.key(path)
 	prefix := path + "/"
 	if path == "" {
 		prefix = ""
 	}
-	for k := range fs.files {
-		if strings.HasPrefix(k, prefix) {
-			return &types.Entry{Name: baseName(path), Path: path, IsDir: true, Perm: types.PermRX}, nil
+
+	seen := make(map[string]bool)
+	var entries []types.Entry
+
+	if d := fs.dirs[path]; d != nil {
+		d.mu.RLock()
+		for name, f := range d.entries {
+			if name == "" {
+				continue
+			}
+			seen[name] = true
+			entries = append(entries, f.snapshot(prefix+name))
 		}
+		d.mu.RUnlock()
+	}
+	// Directories below path that hold entries but have none of their own.
+	for dir := range fs.dirs {
+		if dir == path || !strings.HasPrefix(dir, prefix) {
+			continue
+		}
+		name := dir[len(prefix):]
+		if idx := strings.IndexByte(name, '/'); idx >= 0 {
+			name = name[:idx]
+		}
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		entries = append(entries, types.Entry{Name: name, Path: prefix + name, IsDir: true, Perm: types.PermRX})
 	}
 
-	return nil, fmt.Errorf("%w: %s", types.ErrNotFound, path)
+	if path != "" && len(entries) == 0 {
+		return nil, fmt.Errorf("%w: %s", types.ErrNotFound, path)
+	}
+
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name < entries[j].Name })
+	return entries, nil
 }
 
-func (fs *MemFS) List(_ context.Context, path string, _ types.ListOpts) ([]types.Entry, error) {
+// ForEach calls fn for each direct child of path without building a slice,
+// stopping early when fn returns false. Entries are visited in unspecified
+// order. fn runs with the filesystem read-locked and must not modify fs.
+func (fs *MemFS) ForEach(ctx context.Context, path string, fn func(types.Entry) bool) error {
 	fs.mu.RLock()
 	defer fs.mu.RUnlock()
 
-	path = normPath(path)
+	path = fs.key(path)
 	prefix := path + "/"
 	if path == "" {
 		prefix = ""
 	}
 
-	seen := make(map[string]bool)
-	var entries []types.Entry
+	d := fs.dirs[path]
+	if d != nil {
+		d.mu.RLock()
+		defer d.mu.RUnlock()
+	}
+
+	found := false
+	if d != nil {
+		for name, f := range d.entries {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			if name == "" {
+				continue
+			}
+			found = true
+			if !fn(f.snapshot(prefix + name)) {
+				return nil
+			}
+		}
+	}
 
-	for k, f := range fs.files {
-		if !strings.HasPrefix(k, prefix) {
+	// Implicit directories may appear once per descendant directory; the
+	// set is only allocated when there are any.
+	var implicit map[string]bool
+	for dir := range fs.dirs {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if dir == path || !strings.HasPrefix(dir, prefix) {
 			continue
 		}
-		rest := k[len(prefix):]
-		if rest == "" {
+		name := dir[len(prefix):]
+		if idx := strings.IndexByte(name, '/'); idx >= 0 {
+			name = name[:idx]
+		}
+		if d != nil && d.entries[name] != nil || implicit[name] {
 			continue
 		}
+		if implicit == nil {
+			implicit = make(map[string]bool)
+		}
+		implicit[name] = true
 
-		name := rest
-		isImplicitDir := false
-		if idx := strings.IndexByte(rest, '/'); idx >= 0 {
-			name = rest[:idx]
-			isImplicitDir = true
+		found = true
+		if !fn(types.Entry{Name: name, Path: prefix + name, IsDir: true, Perm: types.PermRX}) {
+			return nil
 		}
+	}
 
-		if seen[name] {
+	if path != "" && !found {
+		return fmt.Errorf("%w: %s", types.ErrNotFound, path)
+	}
+	return nil
+}
+
+// ListPage implements types.PagedLister. Entries come sorted by name and
+// the cursor is the last name returned, so only the page itself is copied
+// out of the filesystem.
+func (fs *MemFS) ListPage(_ context.Context, path string, opts types.ListOpts) (*types.ListResult, error) {
+	after := ""
+	if opts.Cursor != "" {
+		b, err := base64.RawURLEncoding.DecodeString(opts.Cursor)
+		if err != nil {
+			return nil, fmt.Errorf("invalid list cursor %q: %w", opts.Cursor, err)
+		}
+		after = string(b)
+	}
+
+	fs.mu.RLock()
+	defer fs.mu.RUnlock()
+
+	path = fs.key(path)
+	prefix := path + "/"
+	if path == "" {
+		prefix = ""
+	}
+
+	// children maps the names after the cursor to their file, or to nil for
+	// implicit directories.
+	children := make(map[string]*memFile)
+	found := false
+	if d := fs.dirs[path]; d != nil {
+		d.mu.RLock()
+		defer d.mu.RUnlock()
+		for name, f := range d.entries {
+			if name == "" {
+				continue
+			}
+			found = true
+			if name > after {
+				children[name] = f
+			}
+		}
+	}
+	for dir := range fs.dirs {
+		if dir == path || !strings.HasPrefix(dir, prefix) {
 			continue
 		}
-		seen[name] = true
+		name := dir[len(prefix):]
+		if idx := strings.IndexByte(name, '/'); idx >= 0 {
+			name = name[:idx]
+		}
+		found = true
+		if _, ok := children[name]; !ok && name > after {
+			children[name] = nil
+		}
+	}
+	if path != "" && !found {
+		return nil, fmt.Errorf("%w: %s", types.ErrNotFound, path)
+	}
+
+	names := make([]string, 0, len(children))
+	for name := range children {
+		names = append(names, name)
+	}
+	sort.Strings(names)
 
-		if isImplicitDir {
-			entries = append(entries, types.Entry{Name: name, Path: prefix + name, IsDir: true, Perm: types.PermRX})
+	res := &types.ListResult{}
+	if opts.Limit > 0 && len(names) > opts.Limit {
+		names = names[:opts.Limit]
+		res.NextCursor = base64.RawURLEncoding.EncodeToString([]byte(names[len(names)-1]))
+	}
+	res.Entries = make([]types.Entry, len(names))
+	for i, name := range names {
+		if f := children[name]; f != nil {
+			res.Entries[i] = f.snapshot(prefix + name)
 		} else {
-			entries = append(entries, *f.toEntry(prefix + name))
+			res.Entries[i] = types.Entry{Name: name, Path: prefix + name, IsDir: true, Perm: types.PermRX}
 		}
 	}
+	return res, nil
+}
 
-	if path != "" && len(entries) == 0 {
-		return nil, fmt.Errorf("%w: %s", types.ErrNotFound, path)
+// ForRange calls fn for every stored entry whose path begins with prefix, at
+// any depth, in path order, stopping early when fn returns false. The prefix
+// is matched as a string, so "docs/a" matches both "docs/a.txt" and
+// "docs/archive/x". Implicit parent directories are not visited. fn runs
+// with the filesystem read-locked and must not modify fs.
+func (fs *MemFS) ForRange(ctx context.Context, prefix string, fn func(types.Entry) bool) error {
+	fs.mu.RLock()
+	defer fs.mu.RUnlock()
+
+	prefix = strings.TrimPrefix(prefix, "/")
+	if fs.caseInsensitive {
+		prefix = strings.ToLower(prefix)
 	}
+	type keyed struct {
+		key string
+		f   *memFile
+	}
+	var matches []keyed
+	for dir, d := range fs.dirs {
+		d.mu.RLock()
+		for name, f := range d.entries {
+			if k := joinKey(dir, name); strings.HasPrefix(k, prefix) {
+				matches = append(matches, keyed{k, f})
+			}
+		}
+		d.mu.RUnlock()
+	}
+	sort.Slice(matches, func(i, j int) bool { return matches[i].key < matches[j].key })
 
-	sort.Slice(entries, func(i, j int) bool { return entries[i].Name < entries[j].Name })
-	return entries, nil
+	for _, m := range matches {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if !fn(m.f.snapshot(m.key)) {
+			return nil
+		}
+	}
+	return nil
 }
 
 func (fs *MemFS) Open(_ context.Context, path string) (types.File, error) {
 	fs.mu.RLock()
 	defer fs.mu.RUnlock()
 
-	p := normPath(path)
-	f, ok := fs.files[p]
+	p := fs.key(path)
+	f, ok := fs.lookup(p)
 	if !ok {
 		return nil, fmt.Errorf("%w: %s", types.ErrNotFound, path)
 	}
 
+	f.mu.RLock()
+	defer f.mu.RUnlock()
 	entry := f.toEntry(p)
 
 	if f.fn != nil || f.execFn != nil {
@@ -222,41 +759,79 @@ func (fs *MemFS) Open(_ context.Context, path string) (types.File, error) {
 		return nil, fmt.Errorf("%w: %s", types.ErrNotReadable, path)
 	}
 
-	br := bytes.NewReader(f.content)
+	if f.content != nil && f.content.mapped {
+		mr := newMappedReader(f.content)
+		return types.NewSeekableFile(p, entry, mr, mr.Reader), nil
+	}
+	br := bytes.NewReader(f.content.bytes())
 	rc := io.NopCloser(br)
 	return types.NewSeekableFile(p, entry, rc, br), nil
 }
 
+// Write replaces the content of the file at path, creating it if needed.
+// Overwriting locks only the file; creating it locks only its directory.
 func (fs *MemFS) Write(_ context.Context, path string, r io.Reader) error {
 	if !fs.perm.CanWrite() {
 		return fmt.Errorf("%w: %s", types.ErrNotWritable, path)
 	}
 
+	if fs.maxFileSize > 0 {
+		// Read one byte past the limit so oversized input is detected without
+		// buffering all of it.
+		r = io.LimitReader(r, fs.maxFileSize+1)
+	}
 	data, err := io.ReadAll(r)
 	if err != nil {
 		return err
 	}
+	if fs.maxFileSize > 0 && int64(len(data)) > fs.maxFileSize {
+		return fmt.Errorf("%w: %s (limit %d bytes)", types.ErrFileTooLarge, path, fs.maxFileSize)
+	}
 
-	fs.mu.Lock()
-	defer fs.mu.Unlock()
+	p := fs.key(path)
+	fs.mu.RLock()
+	if existing, ok := fs.lookup(p); ok {
+		err := fs.overwrite(path, p, existing, data)
+		fs.mu.RUnlock()
+		return err
+	}
+	fs.mu.RUnlock()
 
-	if existing, ok := fs.files[normPath(path)]; ok && (existing.fn != nil || existing.execFn != nil) {
-		return fmt.Errorf("%w: %s (use RemoveFunc first)", types.ErrNotWritable, path)
+	dir, name := splitKey(p)
+	d, unlock := fs.lockDir(dir)
+	defer unlock()
+	if existing, ok := d.entries[name]; ok {
+		// Created by another writer since the lookup above.
+		return fs.overwrite(path, p, existing, data)
 	}
+	if err := fs.checkNewEntry(p); err != nil {
+		return err
+	}
+	f := &memFile{content: fs.newContent(data), perm: fs.perm, modified: time.Now()}
+	d.entries[name] = f
+	fs.noteChange(p, f.modified)
+	fs.journal(types.EventCreate, p, "")
+	return nil
+}
 
-	p := normPath(path)
-	if existing, ok := fs.files[p]; ok {
-		existing.content = data
-		existing.modified = time.Now()
-	} else {
-		fs.files[p] = &memFile{content: data, perm: fs.perm, modified: time.Now()}
+// overwrite replaces the content of f, the existing entry at key p.
+// Callers must hold fs.mu.
+func (fs *MemFS) overwrite(path, p string, f *memFile, data []byte) error {
+	if f.fn != nil || f.execFn != nil {
+		return fmt.Errorf("%w: %s (use RemoveFunc first)", types.ErrNotWritable, path)
 	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.setContent(fs.newContent(data))
+	f.modified = time.Now()
+	fs.noteChange(p, f.modified)
+	fs.journal(types.EventWrite, p, "")
 	return nil
 }
 
 func (fs *MemFS) Exec(ctx context.Context, path string, args []string, stdin io.Reader) (io.ReadCloser, error) {
 	fs.mu.RLock()
-	f, ok := fs.files[normPath(path)]
+	f, ok := fs.lookup(fs.key(path))
 	fs.mu.RUnlock()
 
 	if !ok {
@@ -287,17 +862,22 @@ func (fs *MemFS) Exec(ctx context.Context, path string, args []string, stdin io.
 }
 
 func (fs *MemFS) Mkdir(_ context.Context, path string, perm types.Perm) error {
-	fs.mu.Lock()
-	defer fs.mu.Unlock()
-
-	p := normPath(path)
+	p := fs.key(path)
 	if p == "" {
 		return fmt.Errorf("%w: cannot mkdir root", types.ErrNotSupported)
 	}
-	if _, ok := fs.files[p]; ok {
+
+	dir, name := splitKey(p)
+	d, unlock := fs.lockDir(dir)
+	defer unlock()
+	if _, ok := d.entries[name]; ok {
 		return fmt.Errorf("%w: %s", types.ErrAlreadyMounted, p)
 	}
-	fs.files[p] = &memFile{isDir: true, perm: perm, modified: time.Now()}
+	if err := fs.checkNewEntry(p); err != nil {
+		return err
+	}
+	d.entries[name] = &memFile{isDir: true, perm: perm, modified: time.Now()}
+	fs.journal(types.EventMkdir, p, "")
 	return nil
 }
 
@@ -305,62 +885,103 @@ func (fs *MemFS) Remove(_ context.Context, path string) error {
 	fs.mu.Lock()
 	defer fs.mu.Unlock()
 
-	p := normPath(path)
+	p := fs.key(path)
 	if p == "" {
 		return fmt.Errorf("%w: cannot remove root", types.ErrNotSupported)
 	}
 
-	_, exists := fs.files[p]
-	prefix := p + "/"
-	hasChildren := false
-	for k := range fs.files {
-		if strings.HasPrefix(k, prefix) {
-			hasChildren = true
-			break
-		}
-	}
-
-	if !exists && !hasChildren {
+	f, exists := fs.lookup(p)
+	children := fs.subtreeLocked(p)
+	if !exists && len(children) == 0 {
 		return fmt.Errorf("%w: %s", types.ErrNotFound, path)
 	}
 
-	delete(fs.files, p)
-	for k := range fs.files {
-		if strings.HasPrefix(k, prefix) {
-			delete(fs.files, k)
-		}
+	var removed []*memFile
+	if exists {
+		fs.deleteLocked(p)
+		removed = append(removed, f)
+	}
+	for _, k := range children {
+		child, _ := fs.deleteLocked(k)
+		removed = append(removed, child)
 	}
+	fs.journal(types.EventRemove, p, "")
+	fs.releaseUnreferenced(removed)
+	fs.pruneChangesLocked()
 	return nil
 }
 
+// Rename moves an entry, and for directories every entry beneath it, to
+// newPath. The move happens under the exclusive tree lock, so readers see
+// either the old layout or the new one, never a mix. Moving across
+// directories is allowed; moving a directory into its own subtree or onto a
+// non-empty directory is not.
 func (fs *MemFS) Rename(_ context.Context, oldPath, newPath string) error {
+	if !fs.perm.CanWrite() {
+		return fmt.Errorf("%w: %s", types.ErrNotWritable, oldPath)
+	}
+
 	fs.mu.Lock()
 	defer fs.mu.Unlock()
 
-	old := normPath(oldPath)
-	nw := normPath(newPath)
+	old := fs.key(oldPath)
+	nw := fs.key(newPath)
 	if old == "" || nw == "" {
 		return fmt.Errorf("%w: cannot rename root", types.ErrNotSupported)
 	}
+	if old == nw {
+		return nil
+	}
+	if strings.HasPrefix(nw, old+"/") {
+		return fmt.Errorf("%w: cannot move %s into itself", types.ErrNotSupported, oldPath)
+	}
 
-	f, exists := fs.files[old]
-	if !exists {
+	f, exists := fs.lookup(old)
+	children := fs.subtreeLocked(old)
+	if !exists && len(children) == 0 {
 		return fmt.Errorf("%w: %s", types.ErrNotFound, oldPath)
 	}
+	srcIsDir := !exists || f.isDir
 
-	delete(fs.files, old)
-	fs.files[nw] = f
-	f.modified = time.Now()
-
-	oldPrefix := old + "/"
-	newPrefix := nw + "/"
-	for k, v := range fs.files {
-		if strings.HasPrefix(k, oldPrefix) {
-			rest := k[len(oldPrefix):]
-			delete(fs.files, k)
-			fs.files[newPrefix+rest] = v
+	var removed []*memFile
+	prev, prevExists := fs.lookup(nw)
+	if fs.hasDescendants(nw) {
+		if !srcIsDir {
+			return fmt.Errorf("%w: %s", types.ErrIsDir, newPath)
 		}
+		return fmt.Errorf("%w: %s (directory not empty)", types.ErrNotSupported, newPath)
+	}
+	if prevExists {
+		switch {
+		case prev.isDir && !srcIsDir:
+			return fmt.Errorf("%w: %s", types.ErrIsDir, newPath)
+		case !prev.isDir && srcIsDir:
+			return fmt.Errorf("%w: %s", types.ErrNotDir, newPath)
+		}
+		if prev != f {
+			removed = append(removed, prev)
+		}
+		fs.deleteLocked(nw)
+	}
+
+	if exists {
+		fs.deleteLocked(old)
+		fs.putLocked(nw, f)
+		f.modified = time.Now()
+		fs.noteChange(nw, f.modified)
+	}
+	// Detach every child before re-inserting any, so a moved key can never
+	// be mistaken for one still waiting to move.
+	moved := make([]*memFile, len(children))
+	for i, k := range children {
+		moved[i], _ = fs.deleteLocked(k)
+	}
+	for i, k := range children {
+		fs.putLocked(nw+k[len(old):], moved[i])
 	}
+	fs.journal(types.EventRename, nw, old)
+	fs.releaseUnreferenced(removed)
+	fs.pruneChangesLocked()
 	return nil
 }
 
@@ -369,27 +990,265 @@ func (fs *MemFS) Touch(_ context.Context, path string) error {
 		return fmt.Errorf("%w: %s", types.ErrNotWritable, path)
 	}
 
-	fs.mu.Lock()
-	defer fs.mu.Unlock()
-
-	p := normPath(path)
+	p := fs.key(path)
 	if p == "" {
 		return fmt.Errorf("%w: cannot touch root", types.ErrNotSupported)
 	}
 
-	if f, ok := fs.files[p]; ok {
-		f.modified = time.Now()
+	fs.mu.RLock()
+	if f, ok := fs.lookup(p); ok {
+		fs.touch(p, f)
+		fs.mu.RUnlock()
+		return nil
+	}
+	fs.mu.RUnlock()
+
+	dir, name := splitKey(p)
+	d, unlock := fs.lockDir(dir)
+	defer unlock()
+	if f, ok := d.entries[name]; ok {
+		fs.touch(p, f)
+		return nil
+	}
+	if err := fs.checkNewEntry(p); err != nil {
+		return err
+	}
+	f := &memFile{content: newContentRef([]byte{}), perm: fs.perm, modified: time.Now()}
+	d.entries[name] = f
+	fs.journal(types.EventCreate, p, "")
+	fs.noteChange(p, f.modified)
+	return nil
+}
+
+// touch updates the modification time of f, the existing entry at key p.
+func (fs *MemFS) touch(p string, f *memFile) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.modified = time.Now()
+	fs.noteChange(p, f.modified)
+}
+
+// Chmod replaces the permission bits of an existing entry. Directories that
+// exist only implicitly (as a prefix of other files) are materialised first.
+func (fs *MemFS) Chmod(_ context.Context, path string, perm types.Perm) error {
+	if !fs.perm.CanWrite() {
+		return fmt.Errorf("%w: %s", types.ErrNotWritable, path)
+	}
+
+	p := fs.key(path)
+	if p == "" {
+		return fmt.Errorf("%w: cannot chmod root", types.ErrNotSupported)
+	}
+
+	fs.mu.RLock()
+	if f, ok := fs.lookup(p); ok {
+		f.setPerm(perm)
+		fs.mu.RUnlock()
+		return nil
+	}
+	fs.mu.RUnlock()
+
+	dir, name := splitKey(p)
+	d, unlock := fs.lockDir(dir)
+	defer unlock()
+	if f, ok := d.entries[name]; ok {
+		f.setPerm(perm)
+		return nil
+	}
+	if fs.hasDescendants(p) {
+		// The implicit directory becomes a stored entry.
+		if err := fs.checkNewEntry(p); err != nil {
+			return err
+		}
+		d.entries[name] = &memFile{isDir: true, perm: perm, modified: time.Now()}
+		return nil
+	}
+	return fmt.Errorf("%w: %s", types.ErrNotFound, path)
+}
+
+func (f *memFile) setPerm(perm types.Perm) {
+	f.mu.Lock()
+	f.perm = perm
+	f.mu.Unlock()
+}
+
+// SetMeta sets a metadata key on an existing entry. An empty value removes
+// the key.
+func (fs *MemFS) SetMeta(_ context.Context, path, key, value string) error {
+	if !fs.perm.CanWrite() {
+		return fmt.Errorf("%w: %s", types.ErrNotWritable, path)
+	}
+
+	fs.mu.RLock()
+	defer fs.mu.RUnlock()
+
+	f, ok := fs.lookup(fs.key(path))
+	if !ok {
+		return fmt.Errorf("%w: %s", types.ErrNotFound, path)
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	// Replace rather than mutate the map: entries returned by Stat share it.
+	meta := make(map[string]string, len(f.meta)+1)
+	for k, v := range f.meta {
+		meta[k] = v
+	}
+	if value == "" {
+		delete(meta, key)
 	} else {
-		fs.files[p] = &memFile{content: []byte{}, perm: fs.perm, modified: time.Now()}
+		meta[key] = value
 	}
+	f.meta = meta
 	return nil
 }
 
+// GetMeta returns the value of a metadata key on an entry.
+func (fs *MemFS) GetMeta(_ context.Context, path, key string) (string, error) {
+	fs.mu.RLock()
+	defer fs.mu.RUnlock()
+
+	f, ok := fs.lookup(fs.key(path))
+	if !ok {
+		return "", fmt.Errorf("%w: %s", types.ErrNotFound, path)
+	}
+	f.mu.RLock()
+	value, ok := f.meta[key]
+	f.mu.RUnlock()
+	if !ok {
+		return "", fmt.Errorf("%w: %s: meta key %q", types.ErrNotFound, path, key)
+	}
+	return value, nil
+}
+
+// Clone returns an independent copy of the filesystem. File content is shared
+// copy-on-write with the original, so cloning costs O(number of files) rather
+// than O(total content size); only files modified afterwards allocate.
+func (fs *MemFS) Clone() *MemFS {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	clone := &MemFS{
+		dirs:        make(map[string]*memDir, len(fs.dirs)),
+		perm:        fs.perm,
+		maxFileSize: fs.maxFileSize,
+		maxEntries:  fs.maxEntries,
+
+		mmapThreshold:   fs.mmapThreshold,
+		caseInsensitive: fs.caseInsensitive,
+	}
+	fs.logMu.Lock()
+	clone.changes = append([]changeRecord(nil), fs.changes...)
+	fs.logMu.Unlock()
+	// Hard links share one memFile; keep them shared in the clone.
+	copied := make(map[*memFile]*memFile)
+	fs.walkLocked(func(k string, f *memFile) {
+		cp, ok := copied[f]
+		if !ok {
+			cp = f.clone()
+			copied[f] = cp
+		}
+		clone.putLocked(k, cp)
+	})
+	return clone
+}
+
+// clone copies f, sharing its content copy-on-write.
+func (f *memFile) clone() *memFile {
+	cp := &memFile{
+		content:  f.content,
+		isDir:    f.isDir,
+		perm:     f.perm,
+		modified: f.modified,
+		link:     f.link,
+		mimeType: f.mimeType,
+		fn:       f.fn,
+		execFn:   f.execFn,
+	}
+	cp.content.retain()
+	if f.meta != nil {
+		cp.meta = make(map[string]string, len(f.meta))
+		for mk, mv := range f.meta {
+			cp.meta[mk] = mv
+		}
+	}
+	return cp
+}
+
+// Compact releases unused capacity in file content slices (io.ReadAll and
+// appends over-allocate) by copying each into an exactly sized slice. It
+// returns the number of bytes of capacity released. Content shared with
+// clones stays shared.
+func (fs *MemFS) Compact() (int64, error) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	return fs.compactLocked(), nil
+}
+
+// compactLocked trims content capacity. Callers must hold fs.mu for writing.
+func (fs *MemFS) compactLocked() int64 {
+	var freed int64
+	compacted := make(map[*contentRef]*contentRef)
+	fs.walkLocked(func(_ string, f *memFile) {
+		if f.content == nil || cap(f.content.data) == len(f.content.data) {
+			return
+		}
+		ref, ok := compacted[f.content]
+		if !ok {
+			data := make([]byte, len(f.content.data))
+			copy(data, f.content.data)
+			ref = newContentRef(data)
+			compacted[f.content] = ref
+			freed += int64(cap(f.content.data) - len(data))
+		}
+		f.content = ref
+	})
+	return freed
+}
+
+// Defrag reclaims memory left behind by write/delete cycles. It compacts
+// file content like Compact, rebuilds the entry maps (Go maps never shrink
+// after deletes), and then asks the runtime to return freed memory to the
+// OS. Other operations block while the entries are rebuilt.
+func (fs *MemFS) Defrag() error {
+	fs.mu.Lock()
+	fs.compactLocked()
+	dirs := make(map[string]*memDir, len(fs.dirs))
+	for dir, d := range fs.dirs {
+		entries := make(map[string]*memFile, len(d.entries))
+		for name, f := range d.entries {
+			entries[name] = f
+		}
+		dirs[dir] = &memDir{entries: entries}
+	}
+	fs.dirs = dirs
+	fs.mu.Unlock()
+
+	debug.FreeOSMemory()
+	return nil
+}
+
+// snapshot returns f's entry for path, read under f's lock.
+func (f *memFile) snapshot(path string) types.Entry {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return f.entry(path)
+}
+
 func (f *memFile) toEntry(path string) *types.Entry {
-	return &types.Entry{
+	e := f.entry(path)
+	return &e
+}
+
+func (f *memFile) entry(path string) types.Entry {
+	e := types.Entry{
 		Name: baseName(path), Path: path, IsDir: f.isDir, Perm: f.perm,
-		Size: int64(len(f.content)), Modified: f.modified, Meta: f.meta,
+		Size: f.content.size(), MimeType: f.mimeType, Modified: f.modified, Meta: f.meta,
+	}
+	if f.link != "" {
+		e.IsSymlink = true
+		e.Size = int64(len(f.link))
 	}
+	return e
 }
 
 func (fs *MemFS) formatHelp(name string, f *memFile) string {
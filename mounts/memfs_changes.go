@@ -0,0 +1,121 @@
+package mounts
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/jackfish212/grasp/types"
+)
+
+// changeRecord notes that the entry at path was modified at the given time.
+// MemFS appends one per modification, so the log is ordered by time.
+type changeRecord struct {
+	path string
+	at   time.Time
+}
+
+// noteChange records a modification, at the given time, of the
+// non-directory entry at path. Writers in different directories run
+// concurrently, so records are inserted in time order rather than appended.
+// Superseded records are dropped once they outnumber live entries, keeping
+// the log proportional to the filesystem size.
+func (fs *MemFS) noteChange(path string, at time.Time) {
+	fs.logMu.Lock()
+	defer fs.logMu.Unlock()
+
+	i := len(fs.changes)
+	for i > 0 && fs.changes[i-1].at.After(at) {
+		i--
+	}
+	fs.changes = slices.Insert(fs.changes, i, changeRecord{path: path, at: at})
+	if len(fs.changes) > fs.changesLimit() {
+		fs.dedupeChangesLocked()
+	}
+}
+
+// changesLimit is the log length beyond which superseded records are
+// dropped.
+func (fs *MemFS) changesLimit() int {
+	return 2*int(fs.count.Load()) + 64
+}
+
+// dedupeChangesLocked keeps only the newest record for each path. The
+// caller must hold fs.logMu.
+func (fs *MemFS) dedupeChangesLocked() {
+	seen := make(map[string]bool, len(fs.changes))
+	changes := fs.changes
+	n := len(changes)
+	for i := len(changes) - 1; i >= 0; i-- {
+		if seen[changes[i].path] {
+			continue
+		}
+		seen[changes[i].path] = true
+		n--
+		changes[n] = changes[i]
+	}
+	fs.changes = append(changes[:0], changes[n:]...)
+}
+
+// pruneChangesLocked rebuilds the change log once removals have left it
+// longer than the limit. The caller must hold fs.mu for writing.
+func (fs *MemFS) pruneChangesLocked() {
+	fs.logMu.Lock()
+	defer fs.logMu.Unlock()
+	if len(fs.changes) > fs.changesLimit() {
+		fs.rebuildChangesLocked()
+	}
+}
+
+// rebuildChangesLocked replaces the change log with one record per live
+// non-directory entry, sorted by modification time. The caller must hold
+// fs.mu for writing and fs.logMu.
+func (fs *MemFS) rebuildChangesLocked() {
+	changes := fs.changes[:0]
+	fs.walkLocked(func(k string, f *memFile) {
+		if !f.isDir {
+			changes = append(changes, changeRecord{path: k, at: f.modified})
+		}
+	})
+	sort.Slice(changes, func(i, j int) bool { return changes[i].at.Before(changes[j].at) })
+	fs.changes = changes
+}
+
+// ListChanged returns the files (not directories) modified after since,
+// oldest first. Only the part of the change log newer than since is
+// examined, so frequent incremental syncs cost O(changes) rather than
+// O(files). Removed files are not reported; callers that must notice
+// deletions should diff a full listing.
+func (fs *MemFS) ListChanged(ctx context.Context, since time.Time) ([]types.Entry, error) {
+	fs.mu.RLock()
+	defer fs.mu.RUnlock()
+
+	fs.logMu.Lock()
+	i := sort.Search(len(fs.changes), func(i int) bool { return fs.changes[i].at.After(since) })
+	changes := slices.Clone(fs.changes[i:])
+	fs.logMu.Unlock()
+
+	seen := make(map[string]bool)
+	var entries []types.Entry
+	for _, c := range changes {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if seen[c.path] {
+			continue
+		}
+		seen[c.path] = true
+		// The record may be stale: the file could since have been removed,
+		// renamed or replaced by a directory.
+		f, ok := fs.lookup(c.path)
+		if !ok || f.isDir {
+			continue
+		}
+		if e := f.snapshot(c.path); e.Modified.After(since) {
+			entries = append(entries, e)
+		}
+	}
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].Modified.Before(entries[j].Modified) })
+	return entries, nil
+}
@@ -0,0 +1,132 @@
+package mounts
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/jackfish212/grasp/types"
+)
+
+// JournalEntry records one change to a MemFS. Op is EventCreate,
+// EventMkdir, EventWrite, EventRemove or EventRename; PreviousPath is set
+// only for renames. Removing or renaming a directory is a single entry that
+// covers everything beneath it.
+type JournalEntry struct {
+	Timestamp    time.Time
+	Op           types.EventType
+	Path         string
+	PreviousPath string
+}
+
+// journalSub feeds one Journal channel. Entries are queued without bound
+// so that filesystem operations never wait on a slow reader.
+type journalSub struct {
+	queue  []JournalEntry // guarded by MemFS.logMu
+	signal chan struct{}  // wakes the pump when queue grows
+	done   chan struct{}  // closed by MemFS.Close
+	out    chan JournalEntry
+}
+
+// Journal returns a channel that receives every change made to the
+// filesystem from this call onwards: creates, writes, removals and renames,
+// in the order they were applied. Each call returns an independent channel.
+// Entries are never dropped; they queue in memory until read, so readers
+// should drain the channel promptly. The channel is closed, and its queue
+// released, when ctx is cancelled or the filesystem is closed.
+func (fs *MemFS) Journal(ctx context.Context) <-chan JournalEntry {
+	fs.logMu.Lock()
+	defer fs.logMu.Unlock()
+
+	sub := &journalSub{
+		signal: make(chan struct{}, 1),
+		done:   make(chan struct{}),
+		out:    make(chan JournalEntry),
+	}
+	if fs.journalClosed {
+		close(sub.out)
+		return sub.out
+	}
+	fs.journalSubs = append(fs.journalSubs, sub)
+	go fs.pumpJournal(ctx, sub)
+	return sub.out
+}
+
+// pumpJournal moves queued entries to the subscriber's channel until the
+// subscription ends.
+func (fs *MemFS) pumpJournal(ctx context.Context, sub *journalSub) {
+	defer close(sub.out)
+	for {
+		select {
+		case <-sub.signal:
+		case <-sub.done:
+			return
+		case <-ctx.Done():
+			fs.unsubscribeJournal(sub)
+			return
+		}
+		fs.logMu.Lock()
+		batch := sub.queue
+		sub.queue = nil
+		fs.logMu.Unlock()
+		for _, e := range batch {
+			select {
+			case sub.out <- e:
+			case <-sub.done:
+				return
+			case <-ctx.Done():
+				fs.unsubscribeJournal(sub)
+				return
+			}
+		}
+	}
+}
+
+// unsubscribeJournal stops queueing entries for sub and drops its backlog.
+func (fs *MemFS) unsubscribeJournal(sub *journalSub) {
+	fs.logMu.Lock()
+	defer fs.logMu.Unlock()
+	fs.journalSubs = slices.DeleteFunc(fs.journalSubs, func(s *journalSub) bool { return s == sub })
+	sub.queue = nil
+}
+
+// journal appends a change to every subscriber's queue. The caller must
+// hold the lock guarding the change, which keeps entries for any one path
+// in the order its changes applied.
+func (fs *MemFS) journal(op types.EventType, path, previous string) {
+	fs.logMu.Lock()
+	defer fs.logMu.Unlock()
+	if len(fs.journalSubs) == 0 {
+		return
+	}
+	e := JournalEntry{Timestamp: time.Now(), Op: op, Path: path, PreviousPath: previous}
+	for _, sub := range fs.journalSubs {
+		sub.queue = append(sub.queue, e)
+		select {
+		case sub.signal <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// journalReplace records that path was written, or created if it did not
+// exist before. The caller must hold the lock guarding the change.
+func (fs *MemFS) journalReplace(path string, existed bool) {
+	if existed {
+		fs.journal(types.EventWrite, path, "")
+	} else {
+		fs.journal(types.EventCreate, path, "")
+	}
+}
+
+// closeJournal ends every Journal subscription. Entries not yet read are
+// discarded.
+func (fs *MemFS) closeJournal() {
+	fs.logMu.Lock()
+	defer fs.logMu.Unlock()
+	for _, sub := range fs.journalSubs {
+		close(sub.done)
+	}
+	fs.journalSubs = nil
+	fs.journalClosed = true
+}
@@ -0,0 +1,91 @@
+package mounts
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackfish212/grasp/types"
+)
+
+var _ types.Linker = (*MemFS)(nil)
+
+// Symlink creates a symbolic link at path pointing to target. The target is
+// stored verbatim and is not required to exist; VirtualOS resolves it on
+// access.
+func (fs *MemFS) Symlink(_ context.Context, target, path string) error {
+	if !fs.perm.CanWrite() {
+		return fmt.Errorf("%w: %s", types.ErrNotWritable, path)
+	}
+	if target == "" {
+		return fmt.Errorf("%w: empty symlink target", types.ErrNotSupported)
+	}
+
+	p := fs.key(path)
+	if p == "" {
+		return fmt.Errorf("%w: cannot replace root", types.ErrNotSupported)
+	}
+
+	dir, name := splitKey(p)
+	d, unlock := fs.lockDir(dir)
+	defer unlock()
+	if _, ok := d.entries[name]; ok {
+		return fmt.Errorf("%w: %s", types.ErrAlreadyMounted, p)
+	}
+	if err := fs.checkNewEntry(p); err != nil {
+		return err
+	}
+	f := &memFile{link: target, perm: types.PermRW, modified: time.Now()}
+	d.entries[name] = f
+	fs.noteChange(p, f.modified)
+	fs.journal(types.EventCreate, p, "")
+	return nil
+}
+
+// Readlink returns the target of the symbolic link at path.
+func (fs *MemFS) Readlink(_ context.Context, path string) (string, error) {
+	fs.mu.RLock()
+	defer fs.mu.RUnlock()
+
+	f, ok := fs.lookup(fs.key(path))
+	if !ok {
+		return "", fmt.Errorf("%w: %s", types.ErrNotFound, path)
+	}
+	if f.link == "" {
+		return "", fmt.Errorf("%w: %s", types.ErrNotSymlink, path)
+	}
+	return f.link, nil
+}
+
+// Link creates a hard link: newPath becomes another name for the file at
+// oldPath, so writes through either name are visible through both.
+// Directories cannot be hard linked.
+func (fs *MemFS) Link(_ context.Context, oldPath, newPath string) error {
+	if !fs.perm.CanWrite() {
+		return fmt.Errorf("%w: %s", types.ErrNotWritable, newPath)
+	}
+
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	f, ok := fs.lookup(fs.key(oldPath))
+	if !ok {
+		return fmt.Errorf("%w: %s", types.ErrNotFound, oldPath)
+	}
+	if f.isDir {
+		return fmt.Errorf("%w: %s", types.ErrIsDir, oldPath)
+	}
+	p := fs.key(newPath)
+	if p == "" {
+		return fmt.Errorf("%w: cannot replace root", types.ErrNotSupported)
+	}
+	if _, ok := fs.lookup(p); ok {
+		return fmt.Errorf("%w: %s", types.ErrAlreadyMounted, p)
+	}
+	if err := fs.checkNewEntry(p); err != nil {
+		return err
+	}
+	fs.storeLocked(p, f)
+	fs.journal(types.EventCreate, p, "")
+	return nil
+}
@@ -0,0 +1,110 @@
+package mounts
+
+import (
+	"crypto/sha256"
+	"sort"
+	"strings"
+)
+
+// Node kinds mixed into each Merkle hash so that, say, an empty file and an
+// empty directory never hash alike.
+const (
+	merkleDir  = 'd'
+	merkleFile = 'f'
+	merkleLink = 'l'
+	merkleFunc = 'x'
+)
+
+// merkleNode is one path component in the tree built by MerkleRoot.
+type merkleNode struct {
+	children map[string]*merkleNode
+	leaf     *[32]byte // set for files, symlinks and functions
+}
+
+// MerkleRoot returns a hash of every path in the filesystem and the content
+// stored there. Two filesystems with the same tree of files, directories and
+// symlink targets have the same root, whatever order they were built in, so
+// comparing roots tells whether a clone has diverged without reading either
+// tree. Permissions, timestamps and metadata are not covered, and functions
+// contribute only their path.
+//
+// Each directory hashes its children's names and hashes, so the cost is
+// linear in the number of entries. Content hashes are cached on the shared
+// immutable content blocks, which makes repeated calls, and calls on clones,
+// cheap for files that have not changed.
+func (fs *MemFS) MerkleRoot() [32]byte {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	root := &merkleNode{}
+	fs.walkLocked(func(k string, f *memFile) {
+		n := root
+		if k != "" {
+			for _, name := range strings.Split(k, "/") {
+				child, ok := n.children[name]
+				if !ok {
+					if n.children == nil {
+						n.children = make(map[string]*merkleNode)
+					}
+					child = &merkleNode{}
+					n.children[name] = child
+				}
+				n = child
+			}
+		}
+		if !f.isDir {
+			sum := f.merkleLeaf()
+			n.leaf = &sum
+		}
+	})
+	return root.hash()
+}
+
+// merkleLeaf hashes a non-directory entry.
+func (f *memFile) merkleLeaf() [32]byte {
+	switch {
+	case f.link != "":
+		return sha256.Sum256(append([]byte{merkleLink}, f.link...))
+	case f.fn != nil || f.execFn != nil:
+		return sha256.Sum256([]byte{merkleFunc})
+	}
+	sum := f.content.sha256()
+	return sha256.Sum256(append([]byte{merkleFile}, sum[:]...))
+}
+
+func (n *merkleNode) hash() [32]byte {
+	if n.leaf != nil {
+		return *n.leaf
+	}
+	names := make([]string, 0, len(n.children))
+	for name := range n.children {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	h := sha256.New()
+	h.Write([]byte{merkleDir})
+	for _, name := range names {
+		sum := n.children[name].hash()
+		h.Write([]byte(name))
+		h.Write([]byte{0})
+		h.Write(sum[:])
+	}
+	var out [32]byte
+	h.Sum(out[:0])
+	return out
+}
+
+// sha256 returns the hash of the block's data, computing it at most once
+// per block in the common case.
+func (c *contentRef) sha256() [32]byte {
+	if c == nil {
+		return sha256.Sum256(nil)
+	}
+	if sum := c.sum.Load(); sum != nil {
+		return *sum
+	}
+	sum := sha256.Sum256(c.data)
+	c.sum.Store(&sum)
+	return sum
+}
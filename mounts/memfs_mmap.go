@@ -0,0 +1,122 @@
+package mounts
+
+import (
+	"bytes"
+	"log/slog"
+)
+
+// WithMemFSMmap stores the content of files larger than threshold bytes in
+// anonymous memory mappings outside the Go heap, so large files do not add
+// to garbage collection work. Mapped memory is released when a file is
+// overwritten or removed. Close copies mapped content back to the heap and
+// stops mapping new content. On platforms without mmap, content stays on
+// the heap.
+func WithMemFSMmap(threshold int64) MemFSOption {
+	return func(fs *MemFS) { fs.mmapThreshold = threshold }
+}
+
+// newContent wraps data in a contentRef, moving it into a memory mapping
+// when it exceeds the mmap threshold.
+func (fs *MemFS) newContent(data []byte) *contentRef {
+	if fs.mmapThreshold <= 0 || int64(len(data)) <= fs.mmapThreshold {
+		return newContentRef(data)
+	}
+	mem, err := mmapAlloc(len(data))
+	if err != nil {
+		slog.Debug("memfs: mmap failed, using heap", "size", len(data), "error", err)
+		return newContentRef(data)
+	}
+	copy(mem, data)
+	ref := &contentRef{data: mem, mapped: true}
+	ref.refs.Store(1)
+	return ref
+}
+
+// setContent replaces f's content, releasing the previous block.
+func (f *memFile) setContent(ref *contentRef) {
+	old := f.content
+	f.content = ref
+	old.release()
+}
+
+// retain adds a reference to mapped content. It is a no-op for heap content,
+// which the garbage collector tracks.
+func (c *contentRef) retain() {
+	if c != nil && c.mapped {
+		c.refs.Add(1)
+	}
+}
+
+// release drops a reference to mapped content and unmaps it once the last
+// reference is gone.
+func (c *contentRef) release() {
+	if c == nil || !c.mapped || c.refs.Add(-1) != 0 {
+		return
+	}
+	if err := mmapFree(c.data); err != nil {
+		slog.Debug("memfs: munmap failed", "error", err)
+	}
+	c.data = nil
+}
+
+// releaseUnreferenced releases the content of removed entries that are no
+// longer reachable under another name (hard links share a memFile). Callers
+// must hold fs.mu for writing.
+func (fs *MemFS) releaseUnreferenced(removed []*memFile) {
+	var mapped []*memFile
+	for _, f := range removed {
+		if f.content != nil && f.content.mapped {
+			mapped = append(mapped, f)
+		}
+	}
+	if len(mapped) == 0 {
+		return
+	}
+	live := make(map[*memFile]bool, fs.count.Load())
+	fs.walkLocked(func(_ string, f *memFile) { live[f] = true })
+	for _, f := range mapped {
+		if !live[f] {
+			f.content.release()
+			f.content = nil
+			live[f] = true // hard links may appear more than once in removed
+		}
+	}
+}
+
+// unmapAll moves all mapped content back to the heap and disables mapping
+// for later writes, so the files stay readable after Close. Readers opened
+// before keep their mapping until they are closed.
+func (fs *MemFS) unmapAll() {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	fs.mmapThreshold = 0
+	fs.walkLocked(func(_ string, f *memFile) {
+		// Hard links visit f more than once; only the first sees it mapped.
+		if f.content != nil && f.content.mapped {
+			f.setContent(newContentRef(bytes.Clone(f.content.data)))
+		}
+	})
+}
+
+// mappedReader reads mapped content and holds a reference to it until
+// closed, so the mapping outlives removal of the file while still open.
+type mappedReader struct {
+	*bytes.Reader
+	ref    *contentRef
+	closed bool
+}
+
+func newMappedReader(ref *contentRef) *mappedReader {
+	ref.retain()
+	return &mappedReader{Reader: bytes.NewReader(ref.data), ref: ref}
+}
+
+func (r *mappedReader) Close() error {
+	if r.closed {
+		return nil
+	}
+	r.closed = true
+	r.Reader.Reset(nil)
+	r.ref.release()
+	return nil
+}
@@ -0,0 +1,16 @@
+//go:build !unix
+
+package mounts
+
+import (
+	"fmt"
+
+	"github.com/jackfish212/grasp/types"
+)
+
+// mmapAlloc is not supported on this platform; callers fall back to the heap.
+func mmapAlloc(int) ([]byte, error) {
+	return nil, fmt.Errorf("%w: mmap", types.ErrNotSupported)
+}
+
+func mmapFree([]byte) error { return nil }
@@ -0,0 +1,14 @@
+//go:build unix
+
+package mounts
+
+import "syscall"
+
+// mmapAlloc returns n bytes of anonymous memory outside the Go heap.
+func mmapAlloc(n int) ([]byte, error) {
+	return syscall.Mmap(-1, 0, n, syscall.PROT_READ|syscall.PROT_WRITE, syscall.MAP_ANON|syscall.MAP_PRIVATE)
+}
+
+func mmapFree(b []byte) error {
+	return syscall.Munmap(b)
+}
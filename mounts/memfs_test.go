@@ -2,9 +2,15 @@ package mounts
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"io"
+	"runtime"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/jackfish212/grasp/types"
 )
@@ -341,6 +347,124 @@ func TestMemFSRenameWithChildren(t *testing.T) {
 	}
 }
 
+func TestMemFSRenameAcrossDirs(t *testing.T) {
+	fs := NewMemFS(types.PermRW)
+	ctx := context.Background()
+
+	fs.AddFile("data/temp.txt", []byte("t"), types.PermRW)
+	fs.AddFile("data/in/a.txt", []byte("a"), types.PermRW)
+	fs.AddFile("data/in/sub/b.txt", []byte("b"), types.PermRW)
+	fs.AddDir("data/processed")
+
+	if err := fs.Rename(ctx, "/data/temp.txt", "/data/processed/temp.txt"); err != nil {
+		t.Fatalf("Rename file: %v", err)
+	}
+	if _, err := fs.Stat(ctx, "data/temp.txt"); !errors.Is(err, types.ErrNotFound) {
+		t.Errorf("old path still present: %v", err)
+	}
+
+	// "data/in" exists only implicitly; its whole subtree moves.
+	if err := fs.Rename(ctx, "data/in", "data/processed/in"); err != nil {
+		t.Fatalf("Rename implicit dir: %v", err)
+	}
+	for _, p := range []string{"data/processed/temp.txt", "data/processed/in/a.txt", "data/processed/in/sub/b.txt"} {
+		if _, err := fs.Stat(ctx, p); err != nil {
+			t.Errorf("Stat(%s): %v", p, err)
+		}
+	}
+	if _, err := fs.Stat(ctx, "data/in"); !errors.Is(err, types.ErrNotFound) {
+		t.Errorf("data/in still present: %v", err)
+	}
+
+	for _, tc := range []struct {
+		old, new string
+		want     error
+	}{
+		{"data/processed", "data/processed/in/x", types.ErrNotSupported}, // into itself
+		{"data/processed/temp.txt", "data/processed/in", types.ErrIsDir},
+		{"data/processed/in", "data/processed/temp.txt", types.ErrNotDir},
+		{"data/missing", "data/x", types.ErrNotFound},
+	} {
+		if err := fs.Rename(ctx, tc.old, tc.new); !errors.Is(err, tc.want) {
+			t.Errorf("Rename(%s, %s) = %v, want %v", tc.old, tc.new, err, tc.want)
+		}
+	}
+}
+
+func TestMemFSRenameConcurrentRead(t *testing.T) {
+	fs := NewMemFS(types.PermRW)
+	ctx := context.Background()
+
+	fs.AddFile("data/temp.txt", []byte("payload"), types.PermRW)
+	for i := 0; i < 5; i++ {
+		fs.AddFile(fmt.Sprintf("data/batch/%d.txt", i), []byte("payload"), types.PermRW)
+	}
+	fs.AddDir("data/processed")
+	const total = 6
+
+	var wg sync.WaitGroup
+	stop := make(chan struct{})
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		defer close(stop)
+		pairs := [][2]string{
+			{"data/temp.txt", "data/processed/temp.txt"},
+			{"data/batch", "data/processed/batch"},
+		}
+		for i := 0; i < 200; i++ {
+			for _, p := range pairs {
+				from, to := p[0], p[1]
+				if i%2 == 1 {
+					from, to = to, from
+				}
+				if err := fs.Rename(ctx, from, to); err != nil {
+					t.Errorf("Rename(%s, %s): %v", from, to, err)
+					return
+				}
+			}
+		}
+	}()
+
+	for r := 0; r < 4; r++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				// Every snapshot sees each file exactly once.
+				n := 0
+				_ = fs.ForRange(ctx, "data/", func(e types.Entry) bool {
+					if !e.IsDir {
+						n++
+					}
+					return true
+				})
+				if n != total {
+					t.Errorf("snapshot has %d files, want %d", n, total)
+					return
+				}
+				for _, p := range []string{"data/temp.txt", "data/processed/temp.txt"} {
+					f, err := fs.Open(ctx, p)
+					if err != nil {
+						continue
+					}
+					data, _ := io.ReadAll(f)
+					_ = f.Close()
+					if string(data) != "payload" {
+						t.Errorf("Open(%s) = %q", p, data)
+					}
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
 func TestMemFSStatImplicitDir(t *testing.T) {
 	fs := NewMemFS(types.PermRW)
 	fs.AddFile("docs/readme.md", []byte("hi"), types.PermRO)
@@ -402,3 +526,896 @@ func TestMemFSError(t *testing.T) {
 		t.Errorf("Error() = %q, want %q", err.Error(), "test error")
 	}
 }
+
+// memFileAt returns the entry stored under key k.
+func memFileAt(t *testing.T, fs *MemFS, k string) *memFile {
+	t.Helper()
+	f, ok := fs.lookup(k)
+	if !ok {
+		t.Fatalf("no entry at %q", k)
+	}
+	return f
+}
+
+func TestMemFSCloneCopyOnWrite(t *testing.T) {
+	fs := NewMemFS(types.PermRW)
+	fs.AddFile("a.txt", []byte("original"), types.PermRW)
+	fs.AddFile("b.txt", []byte("shared"), types.PermRW)
+
+	clone := fs.Clone()
+	if memFileAt(t, clone, "b.txt").content != memFileAt(t, fs, "b.txt").content {
+		t.Error("unmodified file content should be shared with the clone")
+	}
+
+	ctx := context.Background()
+	if err := clone.Write(ctx, "a.txt", strings.NewReader("changed")); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if err := fs.Write(ctx, "new.txt", strings.NewReader("only in original")); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+
+	read := func(m *MemFS, path string) string {
+		f, err := m.Open(ctx, path)
+		if err != nil {
+			t.Fatalf("Open %s: %v", path, err)
+		}
+		defer func() { _ = f.Close() }()
+		data, _ := io.ReadAll(f)
+		return string(data)
+	}
+	if got := read(fs, "a.txt"); got != "original" {
+		t.Errorf("original a.txt = %q, want %q", got, "original")
+	}
+	if got := read(clone, "a.txt"); got != "changed" {
+		t.Errorf("clone a.txt = %q, want %q", got, "changed")
+	}
+	if _, err := clone.Stat(ctx, "new.txt"); err == nil {
+		t.Error("file written to original should not appear in clone")
+	}
+}
+
+func TestMemFSMaxFileSize(t *testing.T) {
+	fs := NewMemFS(types.PermRW, WithMemFSMaxFileSize(4))
+	ctx := context.Background()
+
+	if err := fs.Write(ctx, "ok.txt", strings.NewReader("abcd")); err != nil {
+		t.Fatalf("Write at limit: %v", err)
+	}
+	err := fs.Write(ctx, "big.txt", strings.NewReader("abcde"))
+	if !errors.Is(err, types.ErrFileTooLarge) {
+		t.Fatalf("Write over limit: err = %v, want ErrFileTooLarge", err)
+	}
+	if _, err := fs.Stat(ctx, "big.txt"); err == nil {
+		t.Error("oversized file should not be created")
+	}
+	if err := fs.Write(ctx, "ok.txt", strings.NewReader("too long")); !errors.Is(err, types.ErrFileTooLarge) {
+		t.Errorf("overwrite over limit: err = %v, want ErrFileTooLarge", err)
+	}
+}
+
+func TestMemFSMaxEntries(t *testing.T) {
+	fs := NewMemFS(types.PermRW, WithMemFSMaxEntries(3))
+	ctx := context.Background()
+
+	if err := fs.Write(ctx, "a.txt", strings.NewReader("a")); err != nil {
+		t.Fatal(err)
+	}
+	if err := fs.Touch(ctx, "b.txt"); err != nil {
+		t.Fatal(err)
+	}
+	if err := fs.Write(ctx, "sub/s.txt", strings.NewReader("s")); err != nil {
+		t.Fatal(err)
+	}
+	// Chmod on the implicit directory "sub" would store a new entry.
+	if err := fs.Chmod(ctx, "sub", types.PermRO); !errors.Is(err, types.ErrTooManyEntries) {
+		t.Errorf("Chmod of implicit dir past limit: err = %v, want ErrTooManyEntries", err)
+	}
+	if n := fs.count.Load(); n != 3 {
+		t.Errorf("entry count = %d, want 3", n)
+	}
+	if err := fs.Write(ctx, "c.txt", strings.NewReader("c")); !errors.Is(err, types.ErrTooManyEntries) {
+		t.Errorf("Write past limit: err = %v, want ErrTooManyEntries", err)
+	}
+	if err := fs.Mkdir(ctx, "dir", types.PermRW); !errors.Is(err, types.ErrTooManyEntries) {
+		t.Errorf("Mkdir past limit: err = %v, want ErrTooManyEntries", err)
+	}
+	// Overwriting an existing file does not create a new entry.
+	if err := fs.Write(ctx, "a.txt", strings.NewReader("again")); err != nil {
+		t.Errorf("overwrite at limit: %v", err)
+	}
+}
+
+func TestMemFSForEach(t *testing.T) {
+	fs := NewMemFS(types.PermRW)
+	fs.AddFile("a.txt", []byte("a"), types.PermRW)
+	fs.AddDir("dir")
+	fs.AddFile("dir/b.txt", []byte("b"), types.PermRW)
+	fs.AddFile("implicit/deep/c.txt", []byte("c"), types.PermRW)
+	fs.AddFile("implicit/d.txt", []byte("d"), types.PermRW)
+	ctx := context.Background()
+
+	got := make(map[string]bool)
+	err := fs.ForEach(ctx, "", func(e types.Entry) bool {
+		if got[e.Name] {
+			t.Errorf("entry %q visited twice", e.Name)
+		}
+		got[e.Name] = e.IsDir
+		return true
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]bool{"a.txt": false, "dir": true, "implicit": true}
+	if len(got) != len(want) {
+		t.Fatalf("ForEach visited %v, want %v", got, want)
+	}
+	for name, isDir := range want {
+		if d, ok := got[name]; !ok || d != isDir {
+			t.Errorf("entry %q: visited=%v isDir=%v, want isDir=%v", name, ok, d, isDir)
+		}
+	}
+
+	n := 0
+	if err := fs.ForEach(ctx, "", func(types.Entry) bool { n++; return false }); err != nil {
+		t.Fatal(err)
+	}
+	if n != 1 {
+		t.Errorf("ForEach should stop after fn returns false, visited %d", n)
+	}
+
+	if err := fs.ForEach(ctx, "missing", func(types.Entry) bool { return true }); !errors.Is(err, types.ErrNotFound) {
+		t.Errorf("ForEach on missing path: err = %v, want ErrNotFound", err)
+	}
+}
+
+func TestMemFSListPage(t *testing.T) {
+	fs := NewMemFS(types.PermRW)
+	fs.AddFile("a.txt", []byte("a"), types.PermRW)
+	fs.AddDir("dir")
+	fs.AddFile("dir/b.txt", []byte("b"), types.PermRW)
+	fs.AddFile("implicit/deep/c.txt", []byte("c"), types.PermRW)
+	fs.AddFile("implicit-x.txt", []byte("x"), types.PermRW)
+	fs.AddFile("z.txt", []byte("z"), types.PermRW)
+	ctx := context.Background()
+
+	var pages [][]string
+	opts := types.ListOpts{Limit: 2}
+	for {
+		res, err := fs.ListPage(ctx, "", opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var names []string
+		for _, e := range res.Entries {
+			names = append(names, e.Name)
+			if e.Name == "implicit" && !e.IsDir {
+				t.Errorf("implicit directory listed as a file: %+v", e)
+			}
+		}
+		pages = append(pages, names)
+		if res.NextCursor == "" {
+			break
+		}
+		opts.Cursor = res.NextCursor
+	}
+	want := "[[a.txt dir] [implicit implicit-x.txt] [z.txt]]"
+	if got := fmt.Sprint(pages); got != want {
+		t.Errorf("pages = %s, want %s", got, want)
+	}
+
+	res, err := fs.ListPage(ctx, "/dir", types.ListOpts{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Entries) != 1 || res.Entries[0].Path != "dir/b.txt" || res.NextCursor != "" {
+		t.Errorf("ListPage(dir) = %+v", res)
+	}
+	if _, err := fs.ListPage(ctx, "missing", types.ListOpts{}); !errors.Is(err, types.ErrNotFound) {
+		t.Errorf("ListPage on missing path: err = %v, want ErrNotFound", err)
+	}
+	if _, err := fs.ListPage(ctx, "", types.ListOpts{Cursor: "!!"}); err == nil {
+		t.Error("expected error for malformed cursor")
+	}
+}
+
+func TestMemFSForRange(t *testing.T) {
+	fs := NewMemFS(types.PermRW)
+	fs.AddFile("src/a/one.txt", []byte("1"), types.PermRW)
+	fs.AddFile("src/a/two.txt", []byte("2"), types.PermRW)
+	fs.AddFile("src/ab.txt", []byte("3"), types.PermRW)
+	fs.AddFile("src/b/three.txt", []byte("4"), types.PermRW)
+	fs.AddFile("other.txt", []byte("5"), types.PermRW)
+	ctx := context.Background()
+
+	var got []string
+	err := fs.ForRange(ctx, "/src/a", func(e types.Entry) bool {
+		got = append(got, e.Path)
+		return true
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"src/a/one.txt", "src/a/two.txt", "src/ab.txt"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("ForRange visited %v, want %v", got, want)
+	}
+
+	got = nil
+	_ = fs.ForRange(ctx, "src/", func(e types.Entry) bool {
+		got = append(got, e.Path)
+		return len(got) < 2
+	})
+	if len(got) != 2 {
+		t.Errorf("ForRange should stop after fn returns false, visited %v", got)
+	}
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	if err := fs.ForRange(cancelled, "", func(types.Entry) bool { return true }); !errors.Is(err, context.Canceled) {
+		t.Errorf("ForRange with cancelled ctx: err = %v, want context.Canceled", err)
+	}
+}
+
+func TestMemFSDefrag(t *testing.T) {
+	fs := NewMemFS(types.PermRW)
+	ctx := context.Background()
+	for i := 0; i < 10; i++ {
+		if err := fs.Write(ctx, fmt.Sprintf("f%d.txt", i), strings.NewReader(strings.Repeat("x", 100))); err != nil {
+			t.Fatal(err)
+		}
+	}
+	clone := fs.Clone()
+
+	if err := fs.Defrag(); err != nil {
+		t.Fatalf("Defrag: %v", err)
+	}
+	fs.walkLocked(func(k string, f *memFile) {
+		if data := f.content.bytes(); cap(data) != len(data) {
+			t.Errorf("%s: cap %d != len %d after Defrag", k, cap(data), len(data))
+		}
+	})
+	assertContent(t, fs, "f3.txt", strings.Repeat("x", 100))
+	assertContent(t, clone, "f3.txt", strings.Repeat("x", 100))
+}
+
+func TestMemFSCompact(t *testing.T) {
+	fs := NewMemFS(types.PermRW)
+	fs.AddFile("a.txt", make([]byte, 10, 100), types.PermRW)
+	fs.AddFile("b.txt", make([]byte, 5, 5), types.PermRW)
+	if err := fs.Link(context.Background(), "a.txt", "c.txt"); err != nil {
+		t.Fatal(err)
+	}
+
+	freed, err := fs.Compact()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if freed != 90 {
+		t.Errorf("Compact freed %d bytes, want 90", freed)
+	}
+	if freed, _ := fs.Compact(); freed != 0 {
+		t.Errorf("second Compact freed %d bytes, want 0", freed)
+	}
+	assertContent(t, fs, "c.txt", string(make([]byte, 10)))
+}
+
+func TestMemFSCompactInterval(t *testing.T) {
+	fs := NewMemFS(types.PermRW, WithMemFSCompactInterval(10*time.Millisecond))
+	defer fs.Close()
+	fs.AddFile("a.txt", make([]byte, 10, 100), types.PermRW)
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		fs.mu.RLock()
+		c := cap(memFileAt(t, fs, "a.txt").content.data)
+		fs.mu.RUnlock()
+		if c == 10 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("background compaction did not run, cap = %d", c)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	if err := fs.Close(); err != nil {
+		t.Fatal(err)
+	}
+	_ = fs.Close()
+}
+
+func assertContent(t *testing.T, fs *MemFS, path, want string) {
+	t.Helper()
+	f, err := fs.Open(context.Background(), path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	got, _ := io.ReadAll(f)
+	if string(got) != want {
+		t.Errorf("%s content = %q, want %q", path, got, want)
+	}
+}
+
+// BenchmarkMemFSDefrag churns a MemFS with files whose content slices carry
+// spare capacity (as buffers handed to AddFile often do) plus deletes, then
+// reports the live heap before and after Defrag.
+func BenchmarkMemFSDefrag(b *testing.B) {
+	ctx := context.Background()
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		fs := NewMemFS(types.PermRW)
+		for j := 0; j < 5000; j++ {
+			name := fmt.Sprintf("f%d", j)
+			buf := make([]byte, 1024, 8192)
+			fs.AddFile(name, buf, types.PermRW)
+			if j%2 == 0 {
+				_ = fs.Remove(ctx, name)
+			}
+		}
+		before := liveHeap()
+		b.StartTimer()
+
+		_ = fs.Defrag()
+
+		b.StopTimer()
+		after := liveHeap()
+		b.ReportMetric(float64(before)/1024, "KiB-before")
+		b.ReportMetric(float64(after)/1024, "KiB-after")
+		runtime.KeepAlive(fs)
+		b.StartTimer()
+	}
+}
+
+func liveHeap() uint64 {
+	runtime.GC()
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+	return m.HeapAlloc
+}
+
+func TestMemFSMmap(t *testing.T) {
+	fs := NewMemFS(types.PermRW, WithMemFSMmap(16))
+	ctx := context.Background()
+	big := strings.Repeat("x", 64)
+
+	if err := fs.Write(ctx, "small.txt", strings.NewReader("tiny")); err != nil {
+		t.Fatal(err)
+	}
+	if err := fs.Write(ctx, "big.txt", strings.NewReader(big)); err != nil {
+		t.Fatal(err)
+	}
+	if memFileAt(t, fs, "small.txt").content.mapped {
+		t.Error("file under the threshold should stay on the heap")
+	}
+	ref := memFileAt(t, fs, "big.txt").content
+	if !ref.mapped {
+		t.Skip("mmap not supported on this platform")
+	}
+
+	// An open reader keeps the mapping alive past Remove.
+	f, err := fs.Open(ctx, "big.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := fs.Remove(ctx, "big.txt"); err != nil {
+		t.Fatal(err)
+	}
+	data, err := io.ReadAll(f)
+	if err != nil || string(data) != big {
+		t.Fatalf("read after remove = %q, %v", data, err)
+	}
+	_ = f.Close()
+	if ref.data != nil {
+		t.Error("mapping not released after last reader closed")
+	}
+
+	// Clones share mapped content; closing the original keeps the clone's copy.
+	if err := fs.Write(ctx, "big.txt", strings.NewReader(big)); err != nil {
+		t.Fatal(err)
+	}
+	if err := fs.Link(ctx, "big.txt", "hard.txt"); err != nil {
+		t.Fatal(err)
+	}
+	clone := fs.Clone()
+	open, err := fs.Open(ctx, "big.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = fs.Close()
+
+	// Close moves the content to the heap; files and open readers survive.
+	data, err = io.ReadAll(open)
+	_ = open.Close()
+	if err != nil || string(data) != big {
+		t.Errorf("reader opened before Close = %q, %v", data, err)
+	}
+	for _, p := range []string{"big.txt", "hard.txt"} {
+		if memFileAt(t, fs, p).content.mapped {
+			t.Errorf("%s still mapped after Close", p)
+		}
+		assertContent(t, fs, p, big)
+	}
+	if err := fs.Write(ctx, "later.txt", strings.NewReader(big)); err != nil {
+		t.Fatal(err)
+	}
+	if memFileAt(t, fs, "later.txt").content.mapped {
+		t.Error("content written after Close should stay on the heap")
+	}
+
+	cf, err := clone.Open(ctx, "big.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ = io.ReadAll(cf)
+	_ = cf.Close()
+	if string(data) != big {
+		t.Errorf("clone content after original Close = %q", data)
+	}
+	_ = clone.Close()
+}
+
+func TestMemFSCaseInsensitive(t *testing.T) {
+	fs := NewMemFS(types.PermRW, WithMemFSCaseInsensitive(true))
+	ctx := context.Background()
+
+	if err := fs.Mkdir(ctx, "Docs", types.PermRW); err != nil {
+		t.Fatal(err)
+	}
+	if err := fs.Write(ctx, "Docs/README.md", strings.NewReader("hello")); err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range []string{"Docs/README.md", "docs/readme.md", "DOCS/ReadMe.MD"} {
+		f, err := fs.Open(ctx, p)
+		if err != nil {
+			t.Fatalf("Open(%s): %v", p, err)
+		}
+		data, _ := io.ReadAll(f)
+		_ = f.Close()
+		if string(data) != "hello" {
+			t.Errorf("Open(%s) = %q", p, data)
+		}
+	}
+
+	// Writing under another casing replaces the same file.
+	if err := fs.Write(ctx, "docs/Readme.md", strings.NewReader("bye")); err != nil {
+		t.Fatal(err)
+	}
+	entries, err := fs.List(ctx, "DOCS", types.ListOpts{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Name != "readme.md" || entries[0].Size != 3 {
+		t.Errorf("List = %v, want a single readme.md", entries)
+	}
+
+	if err := fs.Remove(ctx, "DOCS/README.MD"); err != nil {
+		t.Errorf("Remove with other casing: %v", err)
+	}
+
+	// The default stays case-sensitive.
+	cs := NewMemFS(types.PermRW)
+	cs.AddFile("README.md", []byte("x"), types.PermRW)
+	if _, err := cs.Stat(ctx, "readme.md"); !errors.Is(err, types.ErrNotFound) {
+		t.Errorf("case-sensitive Stat(readme.md) err = %v", err)
+	}
+}
+
+func TestMemFSChmod(t *testing.T) {
+	fs := NewMemFS(types.PermRW)
+	ctx := context.Background()
+	fs.AddFile("bin/run.sh", []byte("echo"), types.PermRW)
+
+	if err := fs.Chmod(ctx, "bin/run.sh", types.PermRX); err != nil {
+		t.Fatal(err)
+	}
+	if e, _ := fs.Stat(ctx, "bin/run.sh"); e.Perm != types.PermRX {
+		t.Errorf("file perm = %s, want r-x", e.Perm)
+	}
+	// Implicit directories become explicit so the mode sticks.
+	if err := fs.Chmod(ctx, "bin", types.PermRO); err != nil {
+		t.Fatal(err)
+	}
+	if e, _ := fs.Stat(ctx, "bin"); !e.IsDir || e.Perm != types.PermRO {
+		t.Errorf("dir = %+v, want r-- directory", e)
+	}
+	if err := fs.Chmod(ctx, "missing", types.PermRW); !errors.Is(err, types.ErrNotFound) {
+		t.Errorf("Chmod(missing) err = %v, want ErrNotFound", err)
+	}
+}
+
+func TestMemFSAddBinaryFile(t *testing.T) {
+	fs := NewMemFS(types.PermRW)
+	ctx := context.Background()
+	png := []byte("\x89PNG\r\n\x1a\n\x00\x00")
+	fs.AddBinaryFile("img/logo.png", png, "image/png", types.PermRO)
+	fs.AddFile("notes.txt", []byte("text"), types.PermRW)
+
+	e, err := fs.Stat(ctx, "img/logo.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e.MimeType != "image/png" || e.Size != int64(len(png)) {
+		t.Errorf("entry = %+v, want image/png of %d bytes", e, len(png))
+	}
+	if entries, _ := fs.List(ctx, "img", types.ListOpts{}); len(entries) != 1 || entries[0].MimeType != "image/png" {
+		t.Errorf("List entries = %+v", entries)
+	}
+	if e, _ := fs.Stat(ctx, "notes.txt"); e.MimeType != "" {
+		t.Errorf("AddFile MimeType = %q, want empty", e.MimeType)
+	}
+
+	// The type is kept across writes and renames.
+	fs.AddBinaryFile("a.bin", []byte{0, 1}, "application/octet-stream", types.PermRW)
+	if err := fs.Write(ctx, "a.bin", strings.NewReader("\x02\x03")); err != nil {
+		t.Fatal(err)
+	}
+	if err := fs.Rename(ctx, "a.bin", "b.bin"); err != nil {
+		t.Fatal(err)
+	}
+	if e, _ := fs.Stat(ctx, "b.bin"); e.MimeType != "application/octet-stream" {
+		t.Errorf("MimeType after write and rename = %q", e.MimeType)
+	}
+}
+
+func TestMemFSListChanged(t *testing.T) {
+	fs := NewMemFS(types.PermRW)
+	ctx := context.Background()
+
+	fs.AddFile("old.txt", []byte("old"), types.PermRW)
+	fs.AddFile("gone.txt", []byte("x"), types.PermRW)
+	since := time.Now()
+
+	if err := fs.Write(ctx, "data/new.txt", strings.NewReader("new")); err != nil {
+		t.Fatal(err)
+	}
+	if err := fs.Mkdir(ctx, "dir", types.PermRWX); err != nil {
+		t.Fatal(err)
+	}
+	if err := fs.Touch(ctx, "gone.txt"); err != nil {
+		t.Fatal(err)
+	}
+	if err := fs.Remove(ctx, "gone.txt"); err != nil {
+		t.Fatal(err)
+	}
+	if err := fs.Write(ctx, "data/new.txt", strings.NewReader("newer")); err != nil {
+		t.Fatal(err)
+	}
+	if err := fs.Rename(ctx, "data/new.txt", "data/final.txt"); err != nil {
+		t.Fatal(err)
+	}
+	fs.AddFile("later.txt", []byte("l"), types.PermRW)
+
+	entries, err := fs.ListChanged(ctx, since)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var paths []string
+	for _, e := range entries {
+		paths = append(paths, e.Path)
+	}
+	// Directories, removed files and unchanged files are left out; each
+	// file appears once, oldest first.
+	if got := strings.Join(paths, ","); got != "data/final.txt,later.txt" {
+		t.Errorf("ListChanged = %s, want data/final.txt,later.txt", got)
+	}
+
+	if entries, _ := fs.ListChanged(ctx, time.Now()); len(entries) != 0 {
+		t.Errorf("ListChanged(now) = %v, want none", entries)
+	}
+
+	// Repeated writes to one file do not grow the log without bound.
+	for i := 0; i < 1000; i++ {
+		_ = fs.Write(ctx, "hot.txt", strings.NewReader(fmt.Sprint(i)))
+	}
+	fs.mu.RLock()
+	n := len(fs.changes)
+	fs.mu.RUnlock()
+	if n > 2*6+64+1 {
+		t.Errorf("change log has %d records for 6 entries", n)
+	}
+	if entries, _ := fs.ListChanged(ctx, since); len(entries) != 3 {
+		t.Errorf("ListChanged after compaction = %d entries, want 3", len(entries))
+	}
+}
+
+func TestMemFSJournal(t *testing.T) {
+	fs := NewMemFS(types.PermRW)
+	ctx := context.Background()
+
+	fs.AddFile("before.txt", []byte("x"), types.PermRW)
+	journal := fs.Journal(ctx)
+
+	if err := fs.Write(ctx, "a.txt", strings.NewReader("1")); err != nil {
+		t.Fatal(err)
+	}
+	if err := fs.Write(ctx, "a.txt", strings.NewReader("2")); err != nil {
+		t.Fatal(err)
+	}
+	if err := fs.Mkdir(ctx, "dir", types.PermRWX); err != nil {
+		t.Fatal(err)
+	}
+	if err := fs.Rename(ctx, "a.txt", "dir/b.txt"); err != nil {
+		t.Fatal(err)
+	}
+	if err := fs.Chmod(ctx, "dir/b.txt", types.PermRO); err != nil {
+		t.Fatal(err)
+	}
+	if err := fs.Remove(ctx, "dir"); err != nil {
+		t.Fatal(err)
+	}
+	fs.AddFile("before.txt", []byte("y"), types.PermRW)
+
+	want := []JournalEntry{
+		{Op: types.EventCreate, Path: "a.txt"},
+		{Op: types.EventWrite, Path: "a.txt"},
+		{Op: types.EventMkdir, Path: "dir"},
+		{Op: types.EventRename, Path: "dir/b.txt", PreviousPath: "a.txt"},
+		{Op: types.EventRemove, Path: "dir"},
+		{Op: types.EventWrite, Path: "before.txt"},
+	}
+	var last time.Time
+	for i, w := range want {
+		select {
+		case e := <-journal:
+			if e.Op != w.Op || e.Path != w.Path || e.PreviousPath != w.PreviousPath {
+				t.Errorf("entry %d = %v %s (from %q), want %v %s (from %q)", i, e.Op, e.Path, e.PreviousPath, w.Op, w.Path, w.PreviousPath)
+			}
+			if e.Timestamp.Before(last) {
+				t.Errorf("entry %d timestamp %v is before %v", i, e.Timestamp, last)
+			}
+			last = e.Timestamp
+		case <-time.After(time.Second):
+			t.Fatalf("timed out waiting for entry %d", i)
+		}
+	}
+
+	// A second subscriber only sees changes made after it subscribed, and
+	// Close ends every subscription.
+	late := fs.Journal(ctx)
+	fs.AddFile("after.txt", nil, types.PermRW)
+	if e := <-late; e.Op != types.EventCreate || e.Path != "after.txt" {
+		t.Errorf("late subscriber got %v %s, want CREATE after.txt", e.Op, e.Path)
+	}
+	if e := <-journal; e.Path != "after.txt" {
+		t.Errorf("first subscriber got %s, want after.txt", e.Path)
+	}
+	fs.Close()
+	for _, ch := range []<-chan JournalEntry{journal, late, fs.Journal(ctx)} {
+		select {
+		case e, ok := <-ch:
+			if ok {
+				t.Errorf("unexpected entry after Close: %v %s", e.Op, e.Path)
+			}
+		case <-time.After(time.Second):
+			t.Error("journal not closed by Close")
+		}
+	}
+}
+
+func TestMemFSJournalCancel(t *testing.T) {
+	fs := NewMemFS(types.PermRW)
+	defer fs.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	journal := fs.Journal(ctx)
+	kept := fs.Journal(context.Background())
+	fs.AddFile("a.txt", nil, types.PermRW)
+	cancel()
+
+	// The channel closes once the cancellation is seen; an entry queued
+	// before that may still arrive.
+	deadline := time.After(time.Second)
+	for closed := false; !closed; {
+		select {
+		case _, ok := <-journal:
+			closed = !ok
+		case <-deadline:
+			t.Fatal("journal not closed after cancel")
+		}
+	}
+
+	fs.logMu.Lock()
+	subs := len(fs.journalSubs)
+	fs.logMu.Unlock()
+	if subs != 1 {
+		t.Errorf("%d journal subscribers after cancel, want 1", subs)
+	}
+	fs.AddFile("b.txt", nil, types.PermRW)
+	for _, want := range []string{"a.txt", "b.txt"} {
+		select {
+		case e := <-kept:
+			if e.Path != want {
+				t.Errorf("remaining subscriber got %s, want %s", e.Path, want)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("remaining subscriber timed out waiting for %s", want)
+		}
+	}
+}
+
+func TestMemFSMerkleRoot(t *testing.T) {
+	ctx := context.Background()
+	empty := NewMemFS(types.PermRW).MerkleRoot()
+
+	a := NewMemFS(types.PermRW)
+	a.AddFile("docs/readme.md", []byte("hello"), types.PermRW)
+	a.AddFile("data.csv", []byte("1,2,3"), types.PermRW)
+	a.AddDir("empty")
+
+	b := NewMemFS(types.PermRW)
+	b.AddDir("empty")
+	b.AddFile("data.csv", []byte("1,2,3"), types.PermRO)
+	b.AddFile("docs/readme.md", []byte("hello"), types.PermRO)
+
+	root := a.MerkleRoot()
+	if root == empty {
+		t.Fatal("non-empty filesystem hashed like an empty one")
+	}
+	if b.MerkleRoot() != root {
+		t.Error("same tree built in a different order should have the same root")
+	}
+	if a.MerkleRoot() != root {
+		t.Error("MerkleRoot is not stable across calls")
+	}
+
+	clone := a.Clone()
+	if clone.MerkleRoot() != root {
+		t.Error("clone should have the same root")
+	}
+	if err := clone.Write(ctx, "docs/readme.md", strings.NewReader("changed")); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if clone.MerkleRoot() == root {
+		t.Error("root unchanged after write")
+	}
+	if a.MerkleRoot() != root {
+		t.Error("writing to the clone changed the original's root")
+	}
+
+	moved := a.Clone()
+	if err := moved.Rename(ctx, "data.csv", "docs/data.csv"); err != nil {
+		t.Fatalf("Rename: %v", err)
+	}
+	if moved.MerkleRoot() == root {
+		t.Error("root unchanged after moving a file")
+	}
+
+	dir := NewMemFS(types.PermRW)
+	dir.AddDir("x")
+	file := NewMemFS(types.PermRW)
+	file.AddFile("x", nil, types.PermRW)
+	if dir.MerkleRoot() == file.MerkleRoot() {
+		t.Error("empty directory and empty file should hash differently")
+	}
+}
+
+// benchMemFS returns a MemFS holding files spread over dirs directories,
+// and their paths.
+func TestMemFSConcurrentDirectories(t *testing.T) {
+	fs := NewMemFS(types.PermRW)
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			dir := fmt.Sprintf("d%d", g%4)
+			for i := 0; i < 50; i++ {
+				p := fmt.Sprintf("%s/f%d-%d.txt", dir, g, i)
+				if err := fs.Write(ctx, p, strings.NewReader(p)); err != nil {
+					t.Error(err)
+					return
+				}
+				if _, err := fs.Stat(ctx, p); err != nil {
+					t.Error(err)
+				}
+				if _, err := fs.List(ctx, dir, types.ListOpts{}); err != nil {
+					t.Error(err)
+				}
+				if i%10 == 0 {
+					if err := fs.Rename(ctx, p, p+".old"); err != nil {
+						t.Error(err)
+					}
+					if err := fs.Remove(ctx, p+".old"); err != nil {
+						t.Error(err)
+					}
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+
+	for d := 0; d < 4; d++ {
+		entries, err := fs.List(ctx, fmt.Sprintf("d%d", d), types.ListOpts{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(entries) != 90 {
+			t.Errorf("d%d: %d entries, want 90", d, len(entries))
+		}
+	}
+	if n := fs.count.Load(); n != 360 {
+		t.Errorf("count = %d, want 360", n)
+	}
+}
+
+func benchMemFS(dirs, files int) (*MemFS, []string) {
+	fs := NewMemFS(types.PermRW)
+	paths := make([]string, 0, dirs*files)
+	for d := 0; d < dirs; d++ {
+		for f := 0; f < files; f++ {
+			p := fmt.Sprintf("dir%d/file%d.txt", d, f)
+			fs.AddFile(p, []byte(strings.Repeat("x", 512)), types.PermRW)
+			paths = append(paths, p)
+		}
+	}
+	return fs, paths
+}
+
+// BenchmarkMemFSParallelRead opens and reads files from many goroutines.
+func BenchmarkMemFSParallelRead(b *testing.B) {
+	fs, paths := benchMemFS(16, 64)
+	ctx := context.Background()
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			f, err := fs.Open(ctx, paths[i%len(paths)])
+			if err != nil {
+				b.Error(err)
+				return
+			}
+			_, _ = io.Copy(io.Discard, f)
+			_ = f.Close()
+			i += 7
+		}
+	})
+}
+
+// BenchmarkMemFSParallelReadWrite mixes reads with writes to other files:
+// one operation in eight overwrites a file in the goroutine's own directory.
+func BenchmarkMemFSParallelReadWrite(b *testing.B) {
+	fs, paths := benchMemFS(16, 64)
+	ctx := context.Background()
+	var next atomic.Int32
+	b.RunParallel(func(pb *testing.PB) {
+		dir := int(next.Add(1)) % 16
+		i := 0
+		for pb.Next() {
+			if i%8 == 0 {
+				p := fmt.Sprintf("dir%d/file%d.txt", dir, i%64)
+				if err := fs.Write(ctx, p, strings.NewReader("updated")); err != nil {
+					b.Error(err)
+					return
+				}
+			} else {
+				f, err := fs.Open(ctx, paths[i%len(paths)])
+				if err != nil {
+					b.Error(err)
+					return
+				}
+				_, _ = io.Copy(io.Discard, f)
+				_ = f.Close()
+			}
+			i++
+		}
+	})
+}
+
+// BenchmarkMemFSParallelList lists directories from many goroutines.
+func BenchmarkMemFSParallelList(b *testing.B) {
+	fs, _ := benchMemFS(16, 64)
+	ctx := context.Background()
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			if _, err := fs.List(ctx, fmt.Sprintf("dir%d", i%16), types.ListOpts{}); err != nil {
+				b.Error(err)
+				return
+			}
+			i++
+		}
+	})
+}
@@ -0,0 +1,54 @@
+package mounts
+
+import (
+	"archive/tar"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/jackfish212/grasp/types"
+)
+
+var (
+	_ types.Provider          = (*TarFS)(nil)
+	_ types.Readable          = (*TarFS)(nil)
+	_ types.MountInfoProvider = (*TarFS)(nil)
+)
+
+// TarFS is a read-only provider exposing the contents of a tar archive as a
+// directory tree. The archive is read fully into memory when created.
+type TarFS struct {
+	archiveFS
+}
+
+// NewTarFS reads an uncompressed tar stream into a TarFS. Callers wrap r with
+// a decompressor for compressed archives.
+func NewTarFS(r io.Reader) (*TarFS, error) {
+	fs := &TarFS{archiveFS: newArchiveFS()}
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("tarfs: %w", err)
+		}
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			fs.add(hdr.Name, &archiveEntry{isDir: true, modified: hdr.ModTime})
+		case tar.TypeReg:
+			data, err := io.ReadAll(tr)
+			if err != nil {
+				return nil, fmt.Errorf("tarfs: %s: %w", hdr.Name, err)
+			}
+			fs.add(hdr.Name, &archiveEntry{data: data, modified: hdr.ModTime})
+		}
+		// Links, devices and other special entries are skipped.
+	}
+	return fs, nil
+}
+
+func (fs *TarFS) MountInfo() (string, string) {
+	return "tarfs", fmt.Sprintf("%d entries", len(fs.files))
+}
@@ -262,6 +262,62 @@ func (u *UnionProvider) backfill(ctx context.Context, path string, data []byte)
 	}
 }
 
+// changeLister is implemented by layers that can report recently modified
+// files without a full listing, such as MemFS.
+type changeLister interface {
+	ListChanged(ctx context.Context, since time.Time) ([]types.Entry, error)
+}
+
+// InvalidateChanged removes cached copies of files that changed in an origin
+// layer after since, so the next Open reads through to the new content.
+// Origin layers that cannot report changes are skipped. It returns the number
+// of cache entries removed.
+func (u *UnionProvider) InvalidateChanged(ctx context.Context, since time.Time) (int, error) {
+	u.mu.RLock()
+	layers := make([]Layer, len(u.layers))
+	copy(layers, u.layers)
+	u.mu.RUnlock()
+
+	var changed []string
+	for _, layer := range layers {
+		if layer.Cache {
+			continue
+		}
+		cl, ok := layer.Provider.(changeLister)
+		if !ok {
+			continue
+		}
+		entries, err := cl.ListChanged(ctx, since)
+		if err != nil {
+			return 0, err
+		}
+		for _, e := range entries {
+			changed = append(changed, normPath(e.Path))
+		}
+	}
+
+	removed := 0
+	for _, layer := range layers {
+		if !layer.Cache {
+			continue
+		}
+		m, ok := layer.Provider.(types.Mutable)
+		if !ok {
+			continue
+		}
+		for _, path := range changed {
+			if _, err := layer.Provider.Stat(ctx, path); err != nil {
+				continue
+			}
+			if err := m.Remove(ctx, path); err != nil {
+				return removed, fmt.Errorf("invalidate %s: %w", path, err)
+			}
+			removed++
+		}
+	}
+	return removed, nil
+}
+
 // Write writes to the first writable layer.
 func (u *UnionProvider) Write(ctx context.Context, path string, r io.Reader) error {
 	path = normPath(path)
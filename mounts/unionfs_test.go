@@ -296,3 +296,46 @@ func TestUnionWriteNoWritable(t *testing.T) {
 		t.Errorf("Write = %v, want ErrNotWritable", err)
 	}
 }
+
+func TestCachedUnionInvalidateChanged(t *testing.T) {
+	ctx := context.Background()
+	cache := NewMemFS(types.PermRW)
+	origin := NewMemFS(types.PermRW)
+	origin.AddFile("a.txt", []byte("a1"), types.PermRW)
+	origin.AddFile("b.txt", []byte("b1"), types.PermRW)
+
+	u := NewCachedUnion(cache, origin, 0)
+	for _, p := range []string{"a.txt", "b.txt"} {
+		f, err := u.Open(ctx, p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_ = f.Close()
+	}
+
+	since := time.Now()
+	if err := origin.Write(ctx, "a.txt", strings.NewReader("a2")); err != nil {
+		t.Fatal(err)
+	}
+
+	n, err := u.InvalidateChanged(ctx, since)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 1 {
+		t.Errorf("InvalidateChanged removed %d entries, want 1", n)
+	}
+	if _, err := cache.Stat(ctx, "b.txt"); err != nil {
+		t.Errorf("unchanged b.txt evicted: %v", err)
+	}
+
+	f, err := u.Open(ctx, "a.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := io.ReadAll(f)
+	_ = f.Close()
+	if string(data) != "a2" {
+		t.Errorf("a.txt after invalidation = %q, want a2", data)
+	}
+}
@@ -0,0 +1,54 @@
+package mounts
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/jackfish212/grasp/types"
+)
+
+var (
+	_ types.Provider          = (*ZipFS)(nil)
+	_ types.Readable          = (*ZipFS)(nil)
+	_ types.MountInfoProvider = (*ZipFS)(nil)
+)
+
+// ZipFS is a read-only provider exposing the contents of a ZIP archive as a
+// directory tree. All members are decompressed into memory when created.
+type ZipFS struct {
+	archiveFS
+}
+
+// NewZipFS reads a ZIP archive held in data into a ZipFS.
+func NewZipFS(data []byte) (*ZipFS, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("zipfs: %w", err)
+	}
+
+	fs := &ZipFS{archiveFS: newArchiveFS()}
+	for _, zf := range zr.File {
+		if zf.FileInfo().IsDir() || strings.HasSuffix(zf.Name, "/") {
+			fs.add(zf.Name, &archiveEntry{isDir: true, modified: zf.Modified})
+			continue
+		}
+		rc, err := zf.Open()
+		if err != nil {
+			return nil, fmt.Errorf("zipfs: %s: %w", zf.Name, err)
+		}
+		content, err := io.ReadAll(rc)
+		_ = rc.Close()
+		if err != nil {
+			return nil, fmt.Errorf("zipfs: %s: %w", zf.Name, err)
+		}
+		fs.add(zf.Name, &archiveEntry{data: content, modified: zf.Modified})
+	}
+	return fs, nil
+}
+
+func (fs *ZipFS) MountInfo() (string, string) {
+	return "zipfs", fmt.Sprintf("%d entries", len(fs.files))
+}
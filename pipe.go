@@ -0,0 +1,99 @@
+package grasp
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"regexp"
+)
+
+// Transform wraps a reader to produce a transformed stream. Transforms are
+// chained by VirtualOS.Pipe, each consuming the previous one's output.
+type Transform func(r io.Reader) io.Reader
+
+// Pipe streams the file at src through the given transforms, in order, and
+// writes the result to dst. With no transforms it copies src to dst.
+func (v *VirtualOS) Pipe(ctx context.Context, src, dst string, transforms ...func(r io.Reader) io.Reader) error {
+	f, err := v.Open(ctx, src)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = f.Close() }()
+
+	var r io.Reader = f
+	for _, t := range transforms {
+		r = t(r)
+		// Closing each stage on return unblocks transform goroutines if the
+		// write stops consuming early.
+		if c, ok := r.(io.Closer); ok {
+			defer func() { _ = c.Close() }()
+		}
+	}
+	return v.Write(ctx, dst, r)
+}
+
+// pipeTransform runs fn in a goroutine that writes to an io.Pipe and returns
+// the read side. Errors returned by fn surface on the reader.
+func pipeTransform(fn func(w io.Writer) error) io.Reader {
+	pr, pw := io.Pipe()
+	go func() {
+		pw.CloseWithError(fn(pw))
+	}()
+	return pr
+}
+
+// GzipTransform returns a Transform that gzip-compresses its input.
+func GzipTransform() Transform {
+	return func(r io.Reader) io.Reader {
+		return pipeTransform(func(w io.Writer) error {
+			zw := gzip.NewWriter(w)
+			if _, err := io.Copy(zw, r); err != nil {
+				_ = zw.Close()
+				return err
+			}
+			return zw.Close()
+		})
+	}
+}
+
+// Base64EncodeTransform returns a Transform that base64-encodes its input
+// using standard encoding.
+func Base64EncodeTransform() Transform {
+	return func(r io.Reader) io.Reader {
+		return pipeTransform(func(w io.Writer) error {
+			enc := base64.NewEncoder(base64.StdEncoding, w)
+			if _, err := io.Copy(enc, r); err != nil {
+				_ = enc.Close()
+				return err
+			}
+			return enc.Close()
+		})
+	}
+}
+
+// LineFilterTransform returns a Transform that keeps only lines matching the
+// regular expression pattern. An invalid pattern surfaces as a read error.
+func LineFilterTransform(pattern string) Transform {
+	re, reErr := regexp.Compile(pattern)
+	return func(r io.Reader) io.Reader {
+		return pipeTransform(func(w io.Writer) error {
+			if reErr != nil {
+				return fmt.Errorf("line filter: %w", reErr)
+			}
+			sc := bufio.NewScanner(r)
+			for sc.Scan() {
+				line := sc.Bytes()
+				if !re.Match(line) {
+					continue
+				}
+				if _, err := w.Write(append(line, '\n')); err != nil {
+					return err
+				}
+			}
+			return sc.Err()
+		})
+	}
+}
@@ -0,0 +1,85 @@
+package grasp
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/base64"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/jackfish212/grasp/mounts"
+)
+
+func readAllVOS(t *testing.T, v *VirtualOS, path string) string {
+	t.Helper()
+	f, err := v.Open(context.Background(), path)
+	if err != nil {
+		t.Fatalf("Open %s: %v", path, err)
+	}
+	defer func() { _ = f.Close() }()
+	data, err := io.ReadAll(f)
+	if err != nil {
+		t.Fatalf("ReadAll %s: %v", path, err)
+	}
+	return string(data)
+}
+
+func TestVOSPipeCopy(t *testing.T) {
+	v := setupVOS(t)
+	ctx := context.Background()
+	if err := v.Pipe(ctx, "/home/agent/notes.txt", "/home/agent/copy.txt"); err != nil {
+		t.Fatalf("Pipe: %v", err)
+	}
+	if got := readAllVOS(t, v, "/home/agent/copy.txt"); got != "my notes" {
+		t.Errorf("copy = %q, want %q", got, "my notes")
+	}
+}
+
+func TestVOSPipeTransforms(t *testing.T) {
+	v := New()
+	root := mounts.NewMemFS(PermRW)
+	if err := v.Mount("/", root); err != nil {
+		t.Fatal(err)
+	}
+	root.AddFile("log.txt", []byte("INFO start\nERROR disk\nINFO done\nERROR net\n"), PermRW)
+	ctx := context.Background()
+
+	if err := v.Pipe(ctx, "/log.txt", "/errors.b64", LineFilterTransform("^ERROR"), Base64EncodeTransform()); err != nil {
+		t.Fatalf("Pipe: %v", err)
+	}
+	decoded, err := base64.StdEncoding.DecodeString(readAllVOS(t, v, "/errors.b64"))
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if string(decoded) != "ERROR disk\nERROR net\n" {
+		t.Errorf("filtered = %q", decoded)
+	}
+
+	if err := v.Pipe(ctx, "/log.txt", "/log.txt.gz", GzipTransform()); err != nil {
+		t.Fatalf("Pipe gzip: %v", err)
+	}
+	zr, err := gzip.NewReader(strings.NewReader(readAllVOS(t, v, "/log.txt.gz")))
+	if err != nil {
+		t.Fatalf("gzip.NewReader: %v", err)
+	}
+	plain, _ := io.ReadAll(zr)
+	if !strings.HasPrefix(string(plain), "INFO start") {
+		t.Errorf("gunzipped = %q", plain)
+	}
+}
+
+func TestVOSPipeInvalidFilter(t *testing.T) {
+	v := setupVOS(t)
+	err := v.Pipe(context.Background(), "/home/agent/notes.txt", "/home/agent/out.txt", LineFilterTransform("("))
+	if err == nil {
+		t.Error("expected error for invalid line filter pattern")
+	}
+}
+
+func TestVOSPipeSourceNotFound(t *testing.T) {
+	v := setupVOS(t)
+	if err := v.Pipe(context.Background(), "/missing", "/home/agent/out.txt"); err == nil {
+		t.Error("expected error for missing source")
+	}
+}
@@ -0,0 +1,87 @@
+package grasp
+
+import (
+	"context"
+	"fmt"
+)
+
+// Plugin extends VirtualOS with hooks into its lifecycle, for concerns
+// such as auditing, access policy or replication that apply across
+// providers. OnOpen and OnWrite run before the operation, with the path
+// after symlinks are resolved; an error from either rejects it. Plugins
+// that need the content written can follow up through Watch, which reports
+// each completed write.
+type Plugin interface {
+	// Name identifies the plugin; it must be unique within a VirtualOS.
+	Name() string
+	// OnMount is called after a provider is mounted at path.
+	OnMount(path string, provider Provider)
+	// OnOpen is called before a file is opened, for reading or writing.
+	OnOpen(ctx context.Context, path string) error
+	// OnWrite is called before content is written, by Write or by opening
+	// a file for writing.
+	OnWrite(ctx context.Context, path string) error
+}
+
+// RegisterPlugin adds p to v. Plugins run in registration order. On
+// registration p receives OnMount for every existing mount, so it sees the
+// same mounts whenever it is added. Names must be unique.
+func (v *VirtualOS) RegisterPlugin(p Plugin) error {
+	v.pluginMu.Lock()
+	defer v.pluginMu.Unlock()
+
+	var plugins []Plugin
+	if cur := v.plugins.Load(); cur != nil {
+		plugins = *cur
+	}
+	for _, existing := range plugins {
+		if existing.Name() == p.Name() {
+			return fmt.Errorf("%w: plugin %q already registered", ErrNotSupported, p.Name())
+		}
+	}
+	for _, info := range v.mounts.AllInfo() {
+		p.OnMount(info.Path, info.Provider)
+	}
+	// Copy on write, so running operations keep the list they loaded.
+	next := append(plugins[:len(plugins):len(plugins)], p)
+	v.plugins.Store(&next)
+	return nil
+}
+
+func (v *VirtualOS) loadPlugins() []Plugin {
+	if cur := v.plugins.Load(); cur != nil {
+		return *cur
+	}
+	return nil
+}
+
+// pluginsMount tells every plugin about a new mount.
+func (v *VirtualOS) pluginsMount(path string, provider Provider) {
+	for _, p := range v.loadPlugins() {
+		p.OnMount(path, provider)
+	}
+}
+
+// pluginsOpen asks every plugin whether path may be opened, and written if
+// write is set.
+func (v *VirtualOS) pluginsOpen(ctx context.Context, path string, write bool) error {
+	for _, p := range v.loadPlugins() {
+		if err := p.OnOpen(ctx, path); err != nil {
+			return fmt.Errorf("plugin %s: %w", p.Name(), err)
+		}
+	}
+	if write {
+		return v.pluginsWrite(ctx, path)
+	}
+	return nil
+}
+
+// pluginsWrite asks every plugin whether path may be written.
+func (v *VirtualOS) pluginsWrite(ctx context.Context, path string) error {
+	for _, p := range v.loadPlugins() {
+		if err := p.OnWrite(ctx, path); err != nil {
+			return fmt.Errorf("plugin %s: %w", p.Name(), err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,165 @@
+package grasp
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/jackfish212/grasp/mounts"
+)
+
+// recordingPlugin logs every hook call and rejects paths under deny.
+type recordingPlugin struct {
+	name  string
+	deny  string
+	calls []string
+}
+
+var errDenied = errors.New("denied by policy")
+
+func (p *recordingPlugin) Name() string { return p.name }
+
+func (p *recordingPlugin) OnMount(path string, _ Provider) {
+	p.calls = append(p.calls, "mount "+path)
+}
+
+func (p *recordingPlugin) OnOpen(_ context.Context, path string) error {
+	p.calls = append(p.calls, "open "+path)
+	return p.check(path)
+}
+
+func (p *recordingPlugin) OnWrite(_ context.Context, path string) error {
+	p.calls = append(p.calls, "write "+path)
+	return p.check(path)
+}
+
+func (p *recordingPlugin) check(path string) error {
+	if p.deny != "" && strings.HasPrefix(path, p.deny) {
+		return errDenied
+	}
+	return nil
+}
+
+func TestRegisterPlugin(t *testing.T) {
+	v := setupVOS(t)
+	ctx := context.Background()
+
+	audit := &recordingPlugin{name: "audit"}
+	if err := v.RegisterPlugin(audit); err != nil {
+		t.Fatal(err)
+	}
+	if err := v.RegisterPlugin(&recordingPlugin{name: "audit"}); !errors.Is(err, ErrNotSupported) {
+		t.Errorf("duplicate name: %v", err)
+	}
+	if err := v.Mount("/data", mounts.NewMemFS(PermRW)); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := v.Write(ctx, "/data/a.txt", strings.NewReader("a")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := v.Open(ctx, "/data/a.txt"); err != nil {
+		t.Fatal(err)
+	}
+	f, err := v.OpenFile(ctx, "/data/b.txt", O_WRONLY|O_CREATE)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = f.Close()
+
+	want := []string{
+		"mount /", // existing mounts are replayed on registration
+		"mount /data",
+		"write /data/a.txt",
+		"open /data/a.txt",
+		"open /data/b.txt",
+		"write /data/b.txt",
+	}
+	if strings.Join(audit.calls, "\n") != strings.Join(want, "\n") {
+		t.Errorf("calls = %q, want %q", audit.calls, want)
+	}
+}
+
+func TestPluginRejects(t *testing.T) {
+	v := setupVOS(t)
+	ctx := context.Background()
+
+	first := &recordingPlugin{name: "first"}
+	policy := &recordingPlugin{name: "policy", deny: "/home/agent/secret"}
+	if err := v.RegisterPlugin(first); err != nil {
+		t.Fatal(err)
+	}
+	if err := v.RegisterPlugin(policy); err != nil {
+		t.Fatal(err)
+	}
+
+	err := v.Write(ctx, "/home/agent/secret.txt", strings.NewReader("x"))
+	if !errors.Is(err, errDenied) || !strings.Contains(err.Error(), "policy") {
+		t.Fatalf("Write = %v, want rejection by policy", err)
+	}
+	if _, err := v.Stat(ctx, "/home/agent/secret.txt"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("rejected write created the file: %v", err)
+	}
+	if _, err := v.OpenFile(ctx, "/home/agent/secret.txt", O_WRONLY|O_CREATE); !errors.Is(err, errDenied) {
+		t.Errorf("OpenFile for writing = %v", err)
+	}
+
+	// The check applies to the target of a symlink.
+	if err := v.Write(ctx, "/home/agent/secret-notes.txt", strings.NewReader("hidden")); err == nil {
+		t.Fatal("write should have been rejected")
+	}
+	if err := v.Symlink(ctx, "/home/agent/notes.txt", "/home/agent/link"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := v.Open(ctx, "/home/agent/link"); err != nil {
+		t.Errorf("Open through symlink to allowed file: %v", err)
+	}
+	if got := first.calls[len(first.calls)-1]; got != "open /home/agent/notes.txt" {
+		t.Errorf("plugin saw %q, want the resolved path", got)
+	}
+	if _, err := v.Open(ctx, "/home/agent/notes.txt"); err != nil {
+		t.Errorf("allowed Open: %v", err)
+	}
+}
+
+func TestPluginRejectsLockAndCompress(t *testing.T) {
+	v := setupVOS(t)
+	ctx := context.Background()
+
+	cfs := &compressingFS{MemFS: mounts.NewMemFS(PermRW)}
+	if err := v.Mount("/z", cfs); err != nil {
+		t.Fatal(err)
+	}
+	if err := v.RegisterPlugin(&recordingPlugin{name: "policy", deny: "/home/agent/secret"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := v.RegisterPlugin(&recordingPlugin{name: "archive", deny: "/z/keep"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := v.OpenExclusive(ctx, "/home/agent/secret.lock"); !errors.Is(err, errDenied) {
+		t.Errorf("OpenExclusive = %v, want rejection", err)
+	}
+	if _, err := v.Stat(ctx, "/home/agent/secret.lock"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("rejected OpenExclusive created the file: %v", err)
+	}
+	f, err := v.OpenExclusive(ctx, "/home/agent/notes.txt")
+	if err != nil {
+		t.Fatalf("allowed OpenExclusive: %v", err)
+	}
+	_ = f.Close()
+
+	if err := v.Compress(ctx, "/z/keep.txt"); !errors.Is(err, errDenied) {
+		t.Errorf("Compress = %v, want rejection", err)
+	}
+	if err := v.Decompress(ctx, "/z/keep.txt.gz"); !errors.Is(err, errDenied) {
+		t.Errorf("Decompress = %v, want rejection", err)
+	}
+	if len(cfs.compressed) != 0 {
+		t.Errorf("provider Compress ran despite rejection: %v", cfs.compressed)
+	}
+	if err := v.Compress(ctx, "/z/a.txt"); err != nil {
+		t.Errorf("allowed Compress: %v", err)
+	}
+}
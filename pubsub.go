@@ -0,0 +1,110 @@
+package grasp
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrEmptyTopic is returned by Subscribe and Publish when no topic is given.
+var ErrEmptyTopic = errors.New("grasp: empty topic")
+
+// Event is a message published on a named topic via VirtualOS.Publish.
+type Event struct {
+	Topic   string
+	Payload string
+	Time    time.Time
+}
+
+// topicHub is an in-process publish/subscribe hub keyed by topic name.
+type topicHub struct {
+	mu   sync.RWMutex
+	subs map[string][]*subscription
+}
+
+type subscription struct {
+	ch     chan Event
+	closed chan struct{}
+	once   sync.Once
+}
+
+func newTopicHub() *topicHub {
+	return &topicHub{subs: make(map[string][]*subscription)}
+}
+
+func (h *topicHub) subscribe(ctx context.Context, topic string) *subscription {
+	s := &subscription{
+		ch:     make(chan Event, 64),
+		closed: make(chan struct{}),
+	}
+	h.mu.Lock()
+	h.subs[topic] = append(h.subs[topic], s)
+	h.mu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+		h.unsubscribe(topic, s)
+	}()
+	return s
+}
+
+// unsubscribe removes s from topic and closes its channel. Holding the write
+// lock guarantees no publisher is sending on the channel while it closes.
+func (h *topicHub) unsubscribe(topic string, s *subscription) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	subs := h.subs[topic]
+	for i, x := range subs {
+		if x == s {
+			h.subs[topic] = append(subs[:i], subs[i+1:]...)
+			break
+		}
+	}
+	if len(h.subs[topic]) == 0 {
+		delete(h.subs, topic)
+	}
+	s.once.Do(func() {
+		close(s.closed)
+		close(s.ch)
+	})
+}
+
+// publish fans ev out to every subscriber of its topic (non-blocking).
+func (h *topicHub) publish(ev Event) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	for _, s := range h.subs[ev.Topic] {
+		select {
+		case s.ch <- ev:
+		case <-s.closed:
+		default:
+			// channel full, drop event (back-pressure)
+		}
+	}
+}
+
+// Subscribe returns a channel that receives every Event published on topic.
+// The subscription ends and the channel is closed when ctx is cancelled.
+func (v *VirtualOS) Subscribe(ctx context.Context, topic string) (<-chan Event, error) {
+	if topic == "" {
+		return nil, ErrEmptyTopic
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return v.topics.subscribe(ctx, topic).ch, nil
+}
+
+// Publish sends payload to all current subscribers of topic. Delivery is
+// non-blocking: subscribers whose buffers are full miss the event.
+func (v *VirtualOS) Publish(ctx context.Context, topic, payload string) error {
+	if topic == "" {
+		return ErrEmptyTopic
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	v.topics.publish(Event{Topic: topic, Payload: payload, Time: time.Now()})
+	return nil
+}
@@ -0,0 +1,198 @@
+package shell
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/jackfish212/grasp/types"
+)
+
+// aliasNameSpecial are the characters an alias name may not contain.
+const aliasNameSpecial = " \t\n/$`=\\'\"|&;()<>{}"
+
+// Alias defines name as an alias for expansion, replacing any previous
+// definition, as "alias name='expansion'" does. Aliases belong to this
+// shell only. Names may not contain blanks, quotes, "/", "$", "=" or shell
+// operators.
+func (s *Shell) Alias(name, expansion string) error {
+	if name == "" || strings.ContainsAny(name, aliasNameSpecial) {
+		return fmt.Errorf("%w: invalid alias name %q", types.ErrNotSupported, name)
+	}
+	if s.aliases == nil {
+		s.aliases = make(map[string]string)
+	}
+	s.aliases[name] = expansion
+	return nil
+}
+
+// expandAliases replaces the first word of each simple command in line
+// with its alias expansion. Words that are quoted or escaped are not
+// expanded. The expansion is itself scanned for aliases, except that, as in
+// bash, an alias is not expanded again within its own expansion, so
+// recursive definitions terminate. An expansion that ends in a blank lets
+// the word after it be expanded too. Here-document bodies, after the first
+// line, are left alone.
+func (s *Shell) expandAliases(line string) string {
+	if len(s.aliases) == 0 {
+		return line
+	}
+	out, _ := s.expandAliasesIn(line, map[string]bool{})
+	return out
+}
+
+// expandAliasesIn expands line, skipping the aliases in active, and
+// reports whether the word following it is in command position.
+func (s *Shell) expandAliasesIn(line string, active map[string]bool) (string, bool) {
+	var out strings.Builder
+	cmdPos := true
+	for i := 0; i < len(line); {
+		ch := line[i]
+		switch {
+		case ch == '\n':
+			out.WriteString(line[i:])
+			return out.String(), false
+		case ch == ' ' || ch == '\t':
+			out.WriteByte(ch)
+			i++
+			continue
+		case ch == '&' && i > 0 && (line[i-1] == '>' || line[i-1] == '<'):
+			out.WriteByte(ch) // a redirection such as 2>&1
+			i++
+			continue
+		case strings.IndexByte(";&|({", ch) >= 0:
+			out.WriteByte(ch)
+			i++
+			cmdPos = true
+			continue
+		case strings.IndexByte(")}<>", ch) >= 0:
+			out.WriteByte(ch)
+			i++
+			cmdPos = false
+			continue
+		}
+
+		end := wordEnd(line, i)
+		word := line[i:end]
+		i = end
+		expansion, isAlias := s.aliases[word]
+		switch {
+		case !cmdPos:
+			out.WriteString(word)
+		case keywordAt(word, 0) == word:
+			out.WriteString(word)
+			cmdPos = word != "fi"
+		case isAssignmentWord(word):
+			out.WriteString(word)
+		case !isAlias || active[word]:
+			out.WriteString(word)
+			cmdPos = false
+		default:
+			active[word] = true
+			expansion, cmdPos = s.expandAliasesIn(expansion, active)
+			delete(active, word)
+			out.WriteString(expansion)
+			if strings.HasSuffix(expansion, " ") || strings.HasSuffix(expansion, "\t") {
+				cmdPos = true
+			}
+		}
+	}
+	return out.String(), cmdPos
+}
+
+// wordEnd returns the index just past the word starting at line[i]: the
+// first unquoted blank or operator character. A ${...} expansion is part
+// of the word.
+func wordEnd(line string, i int) int {
+	inSingle, inDouble := false, false
+	for ; i < len(line); i++ {
+		ch := line[i]
+		switch {
+		case ch == '\\' && !inSingle && i+1 < len(line):
+			i++
+		case ch == '$' && !inSingle && i+1 < len(line) && line[i+1] == '{':
+			if end := strings.IndexByte(line[i:], '}'); end >= 0 {
+				i += end
+			}
+		case ch == '\'' && !inDouble:
+			inSingle = !inSingle
+		case ch == '"' && !inSingle:
+			inDouble = !inDouble
+		case inSingle || inDouble:
+		case strings.IndexByte(" \t\n;&|(){}<>", ch) >= 0:
+			return i
+		}
+	}
+	return i
+}
+
+// isAssignmentWord reports whether word is a NAME=value assignment, after
+// which the next word is still in command position.
+func isAssignmentWord(word string) bool {
+	name, _, ok := strings.Cut(word, "=")
+	return ok && isName(name)
+}
+
+// cmdAlias lists, shows or defines aliases.
+func (s *Shell) cmdAlias(args []string) *ExecResult {
+	if len(args) > 0 && args[0] == "-p" {
+		args = args[1:]
+	}
+	if len(args) == 0 {
+		names := make([]string, 0, len(s.aliases))
+		for name := range s.aliases {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		var buf strings.Builder
+		for _, name := range names {
+			buf.WriteString(formatAlias(name, s.aliases[name]))
+		}
+		return stdoutResult(buf.String())
+	}
+
+	res := &ExecResult{}
+	for _, arg := range args {
+		name, expansion, define := strings.Cut(arg, "=")
+		if !define {
+			if expansion, ok := s.aliases[name]; ok {
+				res.appendOutput(stdoutResult(formatAlias(name, expansion)))
+			} else {
+				res.appendOutput(stderrResult("alias: "+name+": not found\n", 1))
+				res.Code = 1
+			}
+			continue
+		}
+		if err := s.Alias(name, expansion); err != nil {
+			res.appendOutput(stderrResult("alias: `"+name+"': invalid alias name\n", 1))
+			res.Code = 1
+		}
+	}
+	return res
+}
+
+// cmdUnalias removes aliases; -a removes them all.
+func (s *Shell) cmdUnalias(args []string) *ExecResult {
+	if len(args) == 0 {
+		return stderrResult("unalias: usage: unalias [-a] name [name ...]\n", 2)
+	}
+	if args[0] == "-a" {
+		s.aliases = nil
+		return &ExecResult{}
+	}
+	res := &ExecResult{}
+	for _, name := range args {
+		if _, ok := s.aliases[name]; !ok {
+			res.appendOutput(stderrResult("unalias: "+name+": not found\n", 1))
+			res.Code = 1
+			continue
+		}
+		delete(s.aliases, name)
+	}
+	return res
+}
+
+// formatAlias prints a definition so that it can be read back in.
+func formatAlias(name, expansion string) string {
+	return "alias " + name + "='" + strings.ReplaceAll(expansion, "'", `'\''`) + "'\n"
+}
@@ -0,0 +1,41 @@
+package shell
+
+import "testing"
+
+func TestExpandAliases(t *testing.T) {
+	s := &Shell{aliases: map[string]string{
+		"ll":   "ls -l",
+		"sudo": "sudo ",
+		"l":    "ll",
+		"loop": "loop -x",
+		"a":    "b",
+		"b":    "a",
+	}}
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"first word", "ll /tmp", "ls -l /tmp"},
+		{"argument untouched", "echo ll", "echo ll"},
+		{"after operators", "ll; ll && ll || ll | ll", "ls -l; ls -l && ls -l || ls -l | ls -l"},
+		{"quoted word", "'ll' x", "'ll' x"},
+		{"after assignment", "X=1 ll", "X=1 ls -l"},
+		{"chained", "l", "ls -l"},
+		{"self reference", "loop", "loop -x"},
+		{"mutual recursion", "a", "a"},
+		{"trailing blank", "sudo ll", "sudo  ls -l"},
+		{"if compound", "if ll; then ll; fi", "if ls -l; then ls -l; fi"},
+		{"redirection", "ll 2>&1 ll", "ls -l 2>&1 ll"},
+		{"brace expansion", "echo ${ll}", "echo ${ll}"},
+		{"command substitution", "echo $(ll)", "echo $(ls -l)"},
+		{"heredoc body", "cat <<EOF\nll\nEOF", "cat <<EOF\nll\nEOF"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := s.expandAliases(tt.input); got != tt.want {
+				t.Errorf("expandAliases(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
@@ -4,28 +4,42 @@ import (
 	"context"
 	"fmt"
 	"sort"
+	"strconv"
 	"strings"
 )
 
 func (s *Shell) cmdCd(args []string) *ExecResult {
 	var target string
-	if len(args) == 0 {
+	printDir := false
+	switch {
+	case len(args) == 0:
 		target = s.Env.Get("HOME")
 		if target == "" {
 			target = "/"
 		}
-	} else {
+	case args[0] == "-":
+		target = s.Env.Get("OLDPWD")
+		if target == "" {
+			return stderrResult("cd: OLDPWD not set\n", 1)
+		}
+		printDir = true
+	default:
 		target = s.absPath(args[0])
 	}
 	ctx := context.Background()
 	entry, err := s.vos.Stat(ctx, target)
 	if err != nil {
-		return &ExecResult{Output: fmt.Sprintf("cd: %s: No such file or directory\n", target), Code: 1}
+		return stderrResult(fmt.Sprintf("cd: %s: No such file or directory\n", target), 1)
 	}
 	if !entry.IsDir {
-		return &ExecResult{Output: fmt.Sprintf("cd: %s: Not a directory\n", target), Code: 1}
+		return stderrResult(fmt.Sprintf("cd: %s: Not a directory\n", target), 1)
 	}
+	s.Env.Set("OLDPWD", s.Env.Get("PWD"))
 	s.Env.Set("PWD", target)
+	if printDir {
+		// Like bash, "cd -" reports where it went.
+		return stdoutResult(target + "\n")
+	}
 	return &ExecResult{}
 }
 
@@ -93,7 +107,7 @@ func (s *Shell) cmdEcho(args []string) *ExecResult {
 		output += "\n"
 	}
 
-	return &ExecResult{Output: output}
+	return stdoutResult(output)
 }
 
 // processEchoEscapes handles escape sequences like \n, \t, \\, etc.
@@ -207,44 +221,122 @@ func (s *Shell) cmdEnv() *ExecResult {
 		buf.WriteString(all[k])
 		buf.WriteByte('\n')
 	}
-	return &ExecResult{Output: buf.String()}
+	return stdoutResult(buf.String())
 }
 
-func (s *Shell) cmdHistory(args []string) *ExecResult {
+// cmdExport sets and exports variables given as NAME=value, or exports
+// existing ones given as NAME. With no names, or -p, it lists the exported
+// variables.
+func (s *Shell) cmdExport(args []string) *ExecResult {
+	if len(args) > 0 && args[0] == "-p" {
+		args = args[1:]
+	}
 	if len(args) == 0 {
+		exported := s.Env.Exported()
+		keys := make([]string, 0, len(exported))
+		for k := range exported {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		var buf strings.Builder
+		for _, k := range keys {
+			fmt.Fprintf(&buf, "export %s=\"%s\"\n", k, exported[k])
+		}
+		return stdoutResult(buf.String())
+	}
+
+	res := &ExecResult{}
+	for _, arg := range args {
+		name, value, hasValue := strings.Cut(arg, "=")
+		if !isName(name) {
+			res.appendOutput(stderrResult("export: `"+arg+"': not a valid identifier\n", 1))
+			res.Code = 1
+			continue
+		}
+		if hasValue {
+			s.Env.Set(name, value)
+		}
+		s.Env.Export(name)
+	}
+	return res
+}
+
+// cmdUnset removes variables from the environment.
+func (s *Shell) cmdUnset(args []string) *ExecResult {
+	if len(args) > 0 && args[0] == "-v" {
+		args = args[1:]
+	}
+	res := &ExecResult{}
+	for _, name := range args {
+		if !isName(name) {
+			res.appendOutput(stderrResult("unset: `"+name+"': not a valid identifier\n", 1))
+			res.Code = 1
+			continue
+		}
+		s.Env.Unset(name)
+	}
+	return res
+}
+
+// isName reports whether name is a valid variable name.
+func isName(name string) bool {
+	if name == "" || name[0] >= '0' && name[0] <= '9' {
+		return false
+	}
+	for i := 0; i < len(name); i++ {
+		if !isAlnumOrUnderscore(name[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// cmdHistory lists the history with each command's number, as bash does,
+// or edits it.
+func (s *Shell) cmdHistory(args []string) *ExecResult {
+	if len(args) == 0 || len(args) == 1 && args[0] != "" && args[0][0] != '-' {
+		entries := s.history.entries()
+		if len(args) == 1 {
+			n, err := strconv.Atoi(args[0])
+			if err != nil || n < 0 {
+				return stderrResult("history: "+args[0]+": numeric argument required\n", 1)
+			}
+			if n < len(entries) {
+				entries = entries[len(entries)-n:]
+			}
+		}
+		num := s.history.total - len(entries) + 1
 		var buf strings.Builder
-		for i, entry := range s.history {
-			cmd := ExtractCommand(entry)
-			fmt.Fprintf(&buf, "%d %s\n", i+1, cmd)
+		for i, e := range entries {
+			fmt.Fprintf(&buf, "%5d  %s\n", num+i, e.Command)
 		}
-		return &ExecResult{Output: buf.String()}
+		return stdoutResult(buf.String())
 	}
 
 	switch args[0] {
 	case "-c":
-		s.history = nil
+		s.history.clear()
 		return &ExecResult{}
 	case "-d":
 		if len(args) < 2 {
-			return &ExecResult{Output: "history: -d requires an offset argument\n", Code: 1}
+			return stderrResult("history: -d requires an offset argument\n", 1)
 		}
 		var offset int
 		if _, err := fmt.Sscanf(args[1], "%d", &offset); err != nil {
-			return &ExecResult{Output: "history: invalid offset\n", Code: 1}
+			return stderrResult("history: invalid offset\n", 1)
 		}
-		idx := offset - 1
-		if idx < 0 || idx >= len(s.history) {
-			return &ExecResult{Output: "history: offset out of range\n", Code: 1}
+		if !s.history.remove(offset) {
+			return stderrResult("history: offset out of range\n", 1)
 		}
-		s.history = append(s.history[:idx], s.history[idx+1:]...)
 		return &ExecResult{}
 	case "-a":
+		s.saveHistory()
 		return &ExecResult{}
 	case "-n":
-		s.history = nil
+		s.history.clear()
 		s.loadHistory()
 		return &ExecResult{}
 	default:
-		return &ExecResult{Output: "history: unknown option: " + args[0] + "\n", Code: 1}
+		return stderrResult("history: unknown option: "+args[0]+"\n", 1)
 	}
 }
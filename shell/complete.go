@@ -0,0 +1,157 @@
+package shell
+
+import (
+	"context"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/jackfish212/grasp/types"
+)
+
+// shellBuiltins are the commands handled by the shell itself, plus test
+// and [, which are builtins in sh and offered as such.
+var shellBuiltins = []string{"[", "alias", "cd", "echo", "env", "export", "history", "mapfile", "pwd", "read", "readarray", "set", "source", "test", "unalias", "unset", "xargs"}
+
+// flagPattern matches short and long options in command usage text.
+var flagPattern = regexp.MustCompile(`(?:^|[\s,\[|])(--?[A-Za-z0-9][A-Za-z0-9-]*)`)
+
+// Complete returns completion candidates for the last word of line.
+// Each candidate is a full replacement for that word: command names in
+// command position, option flags after a known command, and paths otherwise.
+// Directory candidates end in "/". Candidates are sorted.
+func (s *Shell) Complete(ctx context.Context, line string) []string {
+	words := strings.Fields(line)
+	word := ""
+	if len(words) > 0 && !strings.HasSuffix(line, " ") {
+		word = words[len(words)-1]
+		words = words[:len(words)-1]
+	}
+
+	ctx = WithEnv(ctx, s.execEnv())
+	switch {
+	case len(words) == 0 && !strings.Contains(word, "/"):
+		return s.completeCommand(ctx, word)
+	case len(words) > 0 && strings.HasPrefix(word, "-"):
+		return s.completeFlag(ctx, words[0], word)
+	default:
+		return s.completePath(ctx, word)
+	}
+}
+
+func (s *Shell) completeCommand(ctx context.Context, prefix string) []string {
+	seen := make(map[string]bool)
+	for _, name := range shellBuiltins {
+		if strings.HasPrefix(name, prefix) {
+			seen[name] = true
+		}
+	}
+	for _, dir := range strings.Split(s.Env.Get("PATH"), ":") {
+		if dir == "" {
+			continue
+		}
+		entries, err := s.vos.List(ctx, dir, types.ListOpts{})
+		if err != nil {
+			continue
+		}
+		for _, e := range entries {
+			if !e.IsDir && e.Perm.CanExec() && strings.HasPrefix(e.Name, prefix) {
+				seen[e.Name] = true
+			}
+		}
+	}
+	return sortedKeys(seen)
+}
+
+// completeFlag collects the options cmd advertises. For commands on PATH
+// they come from the "usage" metadata the provider reports in Stat (see
+// mounts.FuncMeta); nothing is executed, since a command may not treat
+// --help as harmless.
+func (s *Shell) completeFlag(ctx context.Context, cmd, prefix string) []string {
+	var help string
+	switch cmd {
+	case "alias":
+		help = "-p"
+	case "echo":
+		help = "-n -e -E"
+	case "history":
+		help = "-c -d"
+	case "mapfile", "readarray":
+		help = "-d -n -O -s -t"
+	case "read":
+		help = "-p -r -t"
+	case "export":
+		help = "-p"
+	case "set":
+		help = "-o +o"
+	case "unalias":
+		help = "-a"
+	case "unset":
+		help = "-v"
+	case "xargs":
+		help = "-0 -d -I -n"
+	default:
+		p, err := s.resolveCommand(ctx, cmd)
+		if err != nil {
+			return nil
+		}
+		entry, err := s.vos.Stat(ctx, p)
+		if err != nil {
+			return nil
+		}
+		help = entry.Meta["usage"]
+	}
+
+	seen := make(map[string]bool)
+	for _, m := range flagPattern.FindAllStringSubmatch(help, -1) {
+		if strings.HasPrefix(m[1], prefix) {
+			seen[m[1]] = true
+		}
+	}
+	return sortedKeys(seen)
+}
+
+func (s *Shell) completePath(ctx context.Context, word string) []string {
+	// Keep the directory part exactly as typed so candidates replace word.
+	typedDir, base := "", word
+	if i := strings.LastIndex(word, "/"); i >= 0 {
+		typedDir, base = word[:i+1], word[i+1:]
+	}
+
+	dir := s.Cwd()
+	if typedDir != "" {
+		dir = s.absPath(s.expandTilde(typedDir))
+	} else if word == "~" {
+		return []string{"~/"}
+	}
+
+	entries, err := s.vos.List(ctx, dir, types.ListOpts{})
+	if err != nil {
+		return nil
+	}
+	var out []string
+	for _, e := range entries {
+		if !strings.HasPrefix(e.Name, base) {
+			continue
+		}
+		if strings.HasPrefix(e.Name, ".") && !strings.HasPrefix(base, ".") {
+			continue
+		}
+		candidate := typedDir + e.Name
+		if e.IsDir {
+			candidate += "/"
+		}
+		out = append(out, candidate)
+	}
+	sort.Strings(out)
+	return out
+}
+
+func sortedKeys(m map[string]bool) []string {
+	out := make([]string, 0, len(m))
+	for k := range m {
+		out = append(out, k)
+	}
+	sort.Strings(out)
+	return out
+}
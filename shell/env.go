@@ -2,12 +2,19 @@ package shell
 
 import (
 	"context"
+	"fmt"
+	"sort"
+	"strconv"
 	"strings"
+	"unicode/utf8"
 )
 
-// ShellEnv provides environment variables for Shell.
+// ShellEnv provides environment variables for Shell. Variables marked with
+// Export are also passed to the commands the shell runs.
 type ShellEnv struct {
-	data map[string]string
+	data     map[string]string
+	exported map[string]bool
+	arrays   map[string]map[int]string // indexed arrays; may be sparse
 }
 
 // NewShellEnv creates a new ShellEnv with default PATH, PWD, USER, and HOME.
@@ -20,8 +27,92 @@ func NewShellEnv() *ShellEnv {
 	}}
 }
 
-func (e *ShellEnv) Get(key string) string    { return e.data[key] }
-func (e *ShellEnv) Set(key, value string)    { e.data[key] = value }
+func (e *ShellEnv) Get(key string) string { return e.data[key] }
+
+// Set sets key to value. If key is an array, value replaces its element 0,
+// as assigning to an array name does in bash.
+func (e *ShellEnv) Set(key, value string) {
+	e.data[key] = value
+	if a, ok := e.arrays[key]; ok {
+		a[0] = value
+	}
+}
+
+// Unset removes key, whether a plain variable or an array, and its export
+// mark.
+func (e *ShellEnv) Unset(key string) {
+	delete(e.data, key)
+	delete(e.exported, key)
+	delete(e.arrays, key)
+}
+
+// SetArray makes key an array holding values, indexed from 0. The plain
+// value of an array, $key, is its element 0.
+func (e *ShellEnv) SetArray(key string, values []string) {
+	a := make(map[int]string, len(values))
+	for i, v := range values {
+		a[i] = v
+	}
+	e.setArray(key, a)
+}
+
+// setArray replaces key with the array a, keeping key's plain value in
+// step with element 0.
+func (e *ShellEnv) setArray(key string, a map[int]string) {
+	if e.arrays == nil {
+		e.arrays = make(map[string]map[int]string)
+	}
+	e.arrays[key] = a
+	if v, ok := a[0]; ok {
+		e.data[key] = v
+	} else {
+		delete(e.data, key)
+	}
+}
+
+// Array returns the elements of the array key in index order, or nil if
+// key is not an array. A plain variable is not an array.
+func (e *ShellEnv) Array(key string) []string {
+	a, ok := e.arrays[key]
+	if !ok {
+		return nil
+	}
+	values := make([]string, 0, len(a))
+	for _, i := range arrayIndices(a) {
+		values = append(values, a[i])
+	}
+	return values
+}
+
+// arrayIndices returns the indices set in a, in ascending order.
+func arrayIndices(a map[int]string) []int {
+	indices := make([]int, 0, len(a))
+	for i := range a {
+		indices = append(indices, i)
+	}
+	sort.Ints(indices)
+	return indices
+}
+
+// Export marks key to be passed to commands. The mark outlives changes to
+// the value but not Unset.
+func (e *ShellEnv) Export(key string) {
+	if e.exported == nil {
+		e.exported = make(map[string]bool)
+	}
+	e.exported[key] = true
+}
+
+// Exported returns a copy of the exported variables that are set.
+func (e *ShellEnv) Exported() map[string]string {
+	cp := make(map[string]string, len(e.exported))
+	for k := range e.exported {
+		if v, ok := e.data[k]; ok {
+			cp[k] = v
+		}
+	}
+	return cp
+}
 
 // All returns a copy of all environment variables.
 func (e *ShellEnv) All() map[string]string {
@@ -32,22 +123,63 @@ func (e *ShellEnv) All() map[string]string {
 	return cp
 }
 
-func (s *Shell) expandEnvVars(cmdLine string) string {
+// expandCommandVars expands variables in a command line, leaving text in
+// single quotes untouched as POSIX shells do. A single quote inside double
+// quotes is literal.
+func (s *Shell) expandCommandVars(cmdLine string) (string, error) {
+	if !strings.Contains(cmdLine, "'") {
+		return s.expandEnvVars(cmdLine)
+	}
+	var result strings.Builder
+	inDouble := false
+	start := 0
+	for i := 0; i < len(cmdLine); i++ {
+		switch ch := cmdLine[i]; {
+		case ch == '"':
+			inDouble = !inDouble
+		case ch == '\'' && !inDouble:
+			end := strings.IndexByte(cmdLine[i+1:], '\'')
+			if end < 0 {
+				continue
+			}
+			expanded, err := s.expandEnvVars(cmdLine[start:i])
+			if err != nil {
+				return "", err
+			}
+			result.WriteString(expanded)
+			result.WriteString(cmdLine[i : i+end+2])
+			i += end + 1
+			start = i + 1
+		}
+	}
+	expanded, err := s.expandEnvVars(cmdLine[start:])
+	if err != nil {
+		return "", err
+	}
+	result.WriteString(expanded)
+	return result.String(), nil
+}
+
+// expandEnvVars expands $NAME and ${NAME} references, including the
+// ${NAME:-word}, ${NAME:=word}, ${NAME:?word} and ${NAME:+word} forms (and
+// their variants without the colon, which only test whether NAME is set).
+// It fails when a ${NAME:?word} check fails or a ${...} is malformed.
+func (s *Shell) expandEnvVars(cmdLine string) (string, error) {
 	var result strings.Builder
 	for i := 0; i < len(cmdLine); i++ {
 		if cmdLine[i] == '$' && i+1 < len(cmdLine) {
-			if i+1 < len(cmdLine) && cmdLine[i+1] == '{' {
-				end := strings.Index(cmdLine[i+2:], "}")
+			if cmdLine[i+1] == '{' {
+				end := matchingBrace(cmdLine, i+1)
 				if end == -1 {
 					result.WriteByte(cmdLine[i])
-					i++
 					continue
 				}
-				varName := cmdLine[i+2 : i+2+end]
-				if val := s.Env.Get(varName); val != "" {
-					result.WriteString(val)
+				val, err := s.expandParam(cmdLine[i+2 : end])
+				if err != nil {
+					return "", err
 				}
-				i += 2 + end
+				result.WriteString(val)
+				i = end
 				continue
 			}
 			start := i + 1
@@ -56,19 +188,187 @@ func (s *Shell) expandEnvVars(cmdLine string) string {
 			}
 			if start == i+1 {
 				result.WriteByte(cmdLine[i])
-				i++
 				continue
 			}
 			varName := cmdLine[i+1 : start]
-			if val := s.Env.Get(varName); val != "" {
-				result.WriteString(val)
+			val, set := s.Env.data[varName]
+			if !set && s.nounset {
+				return "", fmt.Errorf("%s: unbound variable", varName)
 			}
+			result.WriteString(val)
 			i = start - 1
 			continue
 		}
 		result.WriteByte(cmdLine[i])
 	}
-	return result.String()
+	return result.String(), nil
+}
+
+// matchingBrace returns the index of the "}" closing the "{" at open,
+// allowing nested ${...}, or -1 if there is none.
+func matchingBrace(s string, open int) int {
+	depth := 0
+	for i := open; i < len(s); i++ {
+		switch s[i] {
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// expandParam expands the inside of a ${...} reference. Besides the
+// operators above it handles the array forms ${NAME[N]}, ${NAME[@]} and
+// ${NAME[*]} (all elements, separated by spaces) and the lengths ${#NAME}
+// and ${#NAME[@]}, the number of elements.
+func (s *Shell) expandParam(expr string) (string, error) {
+	if length, ok := strings.CutPrefix(expr, "#"); ok && length != "" {
+		return s.expandLength(length)
+	}
+	n := 0
+	for n < len(expr) && isAlnumOrUnderscore(expr[n]) {
+		n++
+	}
+	name, op := expr[:n], expr[n:]
+	if name == "" {
+		return "", fmt.Errorf("${%s}: bad substitution", expr)
+	}
+
+	val, set := s.Env.data[name]
+	subscript, all := strings.HasPrefix(op, "["), false
+	if subscript {
+		end := strings.IndexByte(op, ']')
+		if end < 0 {
+			return "", fmt.Errorf("${%s}: bad substitution", expr)
+		}
+		sub := op[1:end]
+		all = sub == "@" || sub == "*"
+		var err error
+		if val, set, err = s.arrayElement(name, sub); err != nil {
+			return "", err
+		}
+		op = op[end+1:]
+	}
+	if op == "" {
+		// As in bash, ${A[@]} of an empty array is not an error.
+		if !set && !all && s.nounset {
+			return "", fmt.Errorf("%s: unbound variable", name)
+		}
+		return val, nil
+	}
+
+	colon := strings.HasPrefix(op, ":")
+	if colon {
+		op = op[1:]
+		// With a colon, an empty value counts as unset.
+		set = set && val != ""
+	}
+	if op == "" {
+		return "", fmt.Errorf("${%s}: bad substitution", expr)
+	}
+	switch op[0] {
+	case '-', '=', '?', '+':
+	default:
+		return "", fmt.Errorf("${%s}: bad substitution", expr)
+	}
+	word, err := s.expandEnvVars(op[1:])
+	if err != nil {
+		return "", err
+	}
+
+	switch op[0] {
+	case '-':
+		if !set {
+			return word, nil
+		}
+	case '=':
+		if !set {
+			if subscript {
+				return "", fmt.Errorf("${%s}: cannot assign in this way", expr)
+			}
+			s.Env.Set(name, word)
+			return word, nil
+		}
+	case '?':
+		if !set {
+			if word == "" {
+				word = "parameter null or not set"
+			}
+			return "", fmt.Errorf("%s: %s", name, word)
+		}
+	case '+':
+		if set {
+			return word, nil
+		}
+		return "", nil
+	}
+	return val, nil
+}
+
+// arrayElement looks up NAME[sub]: element sub, or all elements joined by
+// spaces for "@" and "*". A plain variable acts as an array of one element.
+// The subscript may refer to variables, as in ${A[$i]}.
+func (s *Shell) arrayElement(name, sub string) (val string, set bool, err error) {
+	if sub == "@" || sub == "*" {
+		if a, ok := s.Env.arrays[name]; ok {
+			return strings.Join(s.Env.Array(name), " "), len(a) > 0, nil
+		}
+		val, set = s.Env.data[name]
+		return val, set, nil
+	}
+	sub, err = s.expandEnvVars(sub)
+	if err != nil {
+		return "", false, err
+	}
+	i, err := strconv.Atoi(strings.TrimSpace(sub))
+	if err != nil {
+		return "", false, fmt.Errorf("%s[%s]: bad array subscript", name, sub)
+	}
+	if a, ok := s.Env.arrays[name]; ok {
+		if i < 0 && len(a) > 0 {
+			indices := arrayIndices(a)
+			i += indices[len(indices)-1] + 1
+		}
+		val, set = a[i]
+		return val, set, nil
+	}
+	if i != 0 && i != -1 {
+		return "", false, nil
+	}
+	val, set = s.Env.data[name]
+	return val, set, nil
+}
+
+// expandLength expands ${#NAME}, the length of NAME's value, and
+// ${#NAME[@]}, the number of elements in the array NAME.
+func (s *Shell) expandLength(expr string) (string, error) {
+	name, sub, isElem := strings.Cut(expr, "[")
+	if !isName(name) || isElem && !strings.HasSuffix(sub, "]") {
+		return "", fmt.Errorf("${#%s}: bad substitution", expr)
+	}
+	sub = strings.TrimSuffix(sub, "]")
+	if isElem && (sub == "@" || sub == "*") {
+		if a, ok := s.Env.arrays[name]; ok {
+			return strconv.Itoa(len(a)), nil
+		}
+		if _, ok := s.Env.data[name]; ok {
+			return "1", nil
+		}
+		return "0", nil
+	}
+	val := s.Env.Get(name)
+	if isElem {
+		var err error
+		if val, _, err = s.arrayElement(name, sub); err != nil {
+			return "", err
+		}
+	}
+	return strconv.Itoa(utf8.RuneCountInString(val)), nil
 }
 
 func isAlnumOrUnderscore(ch byte) bool {
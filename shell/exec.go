@@ -3,6 +3,7 @@ package shell
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"log/slog"
@@ -11,29 +12,53 @@ import (
 	"github.com/jackfish212/grasp/types"
 )
 
+// execEnv returns the environment passed to commands: the exported
+// variables plus PWD, PATH, USER and HOME.
 func (s *Shell) execEnv() map[string]string {
-	return map[string]string{
-		"PWD":  s.Env.Get("PWD"),
-		"PATH": s.Env.Get("PATH"),
-		"USER": s.Env.Get("USER"),
-		"HOME": s.Env.Get("HOME"),
+	env := s.Env.Exported()
+	for _, k := range []string{"PWD", "PATH", "USER", "HOME"} {
+		env[k] = s.Env.Get(k)
 	}
+	return env
 }
 
 func (s *Shell) executeSingleStream(ctx context.Context, cmdLine string, stdin io.Reader) (io.ReadCloser, *ExecResult) {
+	if assigns, rest := splitAssignments(cmdLine); len(assigns) > 0 {
+		var rc io.ReadCloser
+		result := s.withAssignments(ctx, assigns, rest, func(line string) *ExecResult {
+			var res *ExecResult
+			rc, res = s.executeSingleStream(ctx, line, stdin)
+			return res
+		})
+		return rc, result
+	}
+
 	// Expand command substitutions first (`cmd` or $(cmd))
 	cmdLine = s.expandCommandSubstitution(ctx, cmdLine)
-	cmdLine = s.expandEnvVars(cmdLine)
+	cmdLine, err := s.expandCommandVars(cmdLine)
+	if err != nil {
+		return nil, stderrResult(err.Error()+"\n", 1)
+	}
 
 	args, quoted := tokenizeWithQuoteInfo(cmdLine)
 	for i := range args {
 		args[i] = s.expandTilde(args[i])
 	}
+	args, quoted, in, errResult := s.inputRedirect(ctx, args, quoted)
+	if errResult != nil {
+		return nil, errResult
+	}
+	if in != nil {
+		stdin = in
+	}
 	if len(args) == 0 {
 		return nil, &ExecResult{}
 	}
 	cmd := args[0]
-	cmdArgs := s.expandGlobs(ctx, args[1:], quoted[1:])
+	cmdArgs, err := s.expandGlobs(ctx, args[1:], quoted[1:])
+	if err != nil {
+		return nil, stderrResult(err.Error()+"\n", 1)
+	}
 
 	switch cmd {
 	case "cd":
@@ -47,25 +72,44 @@ func (s *Shell) executeSingleStream(ctx context.Context, cmdLine string, stdin i
 	case "env":
 		result := s.cmdEnv()
 		return io.NopCloser(strings.NewReader(result.Output)), nil
+	case "alias", "export", "mapfile", "readarray", "source", ".", "unalias", "unset":
+		result := s.runArgs(ctx, cmd, cmdArgs, stdin)
+		if result.Code != 0 {
+			return nil, result
+		}
+		return io.NopCloser(strings.NewReader(result.Output)), nil
 	case "history":
 		result := s.cmdHistory(cmdArgs)
 		return io.NopCloser(strings.NewReader(result.Output)), nil
+	case "read":
+		if result, ok := s.runRead(ctx, cmdArgs, stdin); ok {
+			if result.Code != 0 {
+				return nil, result
+			}
+			return io.NopCloser(strings.NewReader(result.Output)), nil
+		}
+	case "set":
+		result := s.cmdSet(cmdArgs)
+		return io.NopCloser(strings.NewReader(result.Output)), nil
+	case "xargs":
+		result := s.cmdXargs(ctx, cmdArgs, stdin)
+		return io.NopCloser(strings.NewReader(result.Output)), nil
 	}
 
 	path, err := s.resolveCommand(ctx, cmd)
 	if err != nil {
-		return nil, &ExecResult{Output: err.Error() + "\n", Code: 1}
+		return nil, stderrResult(err.Error()+"\n", 1)
 	}
 
 	if entry, statErr := s.vos.Stat(ctx, path); statErr == nil && entry.IsDir {
 		lsPath, lsErr := s.resolveCommand(ctx, "ls")
 		if lsErr != nil {
-			return nil, &ExecResult{Output: lsErr.Error() + "\n", Code: 1}
+			return nil, stderrResult(lsErr.Error()+"\n", 1)
 		}
 		ctx = WithEnv(ctx, s.execEnv())
 		rc, execErr := s.vos.Exec(ctx, lsPath, []string{path}, nil)
 		if execErr != nil {
-			return nil, &ExecResult{Output: fmt.Sprintf("ls: %v\n", execErr), Code: 1}
+			return nil, stderrResult(fmt.Sprintf("ls: %v\n", execErr), 1)
 		}
 		return rc, nil
 	}
@@ -73,95 +117,278 @@ func (s *Shell) executeSingleStream(ctx context.Context, cmdLine string, stdin i
 	ctx = WithEnv(ctx, s.execEnv())
 	rc, execErr := s.vos.Exec(ctx, path, cmdArgs, stdin)
 	if execErr != nil {
-		return nil, &ExecResult{Output: fmt.Sprintf("%s: %v\n", cmd, execErr), Code: 1}
+		return nil, execErrResult(cmd, execErr)
 	}
 	return rc, nil
 }
 
 func (s *Shell) executeSingle(ctx context.Context, cmdLine string, stdin io.Reader, redir *redirection) *ExecResult {
 	slog.Debug("executeSingle called", "cmdLine", cmdLine, "hasRedir", redir != nil)
+	if assigns, rest := splitAssignments(cmdLine); len(assigns) > 0 {
+		return s.withAssignments(ctx, assigns, rest, func(line string) *ExecResult {
+			return s.executeSingle(ctx, line, stdin, redir)
+		})
+	}
+
 	// Expand command substitutions first (`cmd` or $(cmd))
 	cmdLine = s.expandCommandSubstitution(ctx, cmdLine)
-	cmdLine = s.expandEnvVars(cmdLine)
+	cmdLine, err := s.expandCommandVars(cmdLine)
+	if err != nil {
+		return stderrResult(err.Error()+"\n", 1)
+	}
 
 	args, quoted := tokenizeWithQuoteInfo(cmdLine)
 	for i := range args {
 		args[i] = s.expandTilde(args[i])
 	}
+	args, quoted, in, errResult := s.inputRedirect(ctx, args, quoted)
+	if errResult != nil {
+		return errResult
+	}
+	if in != nil {
+		stdin = in
+	}
 	if len(args) == 0 {
 		return &ExecResult{}
 	}
 	cmd := args[0]
 	cmdArgs, cmdQuoted := filterRedirectionArgsWithQuotes(args[1:], quoted[1:])
-	cmdArgs = s.expandGlobs(ctx, cmdArgs, cmdQuoted)
+	cmdArgs, err = s.expandGlobs(ctx, cmdArgs, cmdQuoted)
+	if err != nil {
+		return stderrResult(err.Error()+"\n", 1)
+	}
 
 	switch cmd {
 	case "cd":
 		return s.cmdCd(cmdArgs)
 	case "pwd":
-		return &ExecResult{Output: s.Env.Get("PWD") + "\n"}
-	case "echo":
-		result := s.cmdEcho(cmdArgs)
-		if redir != nil {
-			return s.writeOutput(ctx, redir, result.Output)
+		return stdoutResult(s.Env.Get("PWD") + "\n")
+	case "env":
+		return s.cmdEnv()
+	case "export":
+		return s.cmdExport(cmdArgs)
+	case "history":
+		return s.cmdHistory(cmdArgs)
+	case "set":
+		return s.cmdSet(cmdArgs)
+	case "unset":
+		return s.cmdUnset(cmdArgs)
+	case "alias":
+		return s.cmdAlias(cmdArgs)
+	case "unalias":
+		return s.cmdUnalias(cmdArgs)
+	case "source", ".":
+		return s.cmdSource(ctx, cmd, cmdArgs)
+	}
+
+	if redir != nil {
+		// Output written to a file is kept whole.
+		return s.writeOutput(ctx, redir, s.runArgs(context.WithValue(ctx, unlimitedKey{}, true), cmd, cmdArgs, stdin))
+	}
+	return s.runArgs(ctx, cmd, cmdArgs, stdin)
+}
+
+// withAssignments applies NAME=value words. On their own they set shell
+// variables; before a command they apply only while run executes it. Each
+// value is expanded in turn, so later values can refer to earlier names,
+// and is not split into words.
+func (s *Shell) withAssignments(ctx context.Context, assigns []assignment, rest string, run func(string) *ExecResult) *ExecResult {
+	type saved struct {
+		value string
+		set   bool
+	}
+	prev := make(map[string]saved, len(assigns))
+	for _, a := range assigns {
+		value, err := s.expandCommandVars(s.expandCommandSubstitution(ctx, a.value))
+		if err != nil {
+			return stderrResult(err.Error()+"\n", 1)
+		}
+		words, _ := tokenizeWithQuoteInfo(value)
+		value = s.expandTilde(strings.Join(words, " "))
+		if _, ok := prev[a.name]; !ok {
+			old, set := s.Env.data[a.name]
+			prev[a.name] = saved{old, set}
+		}
+		s.Env.Set(a.name, value)
+	}
+	if rest == "" {
+		return &ExecResult{}
+	}
+
+	// Assignments before a command are in that command's environment.
+	wasExported := make(map[string]bool, len(prev))
+	for name := range prev {
+		wasExported[name] = s.Env.exported[name]
+		s.Env.Export(name)
+	}
+	defer func() {
+		for name, p := range prev {
+			if p.set {
+				s.Env.Set(name, p.value)
+			} else {
+				s.Env.Unset(name)
+			}
+			if !wasExported[name] {
+				delete(s.Env.exported, name)
+			}
 		}
-		return result
+	}()
+	return run(rest)
+}
+
+// runArgs runs an already expanded command: a shell builtin or a command
+// found on PATH.
+func (s *Shell) runArgs(ctx context.Context, cmd string, cmdArgs []string, stdin io.Reader) *ExecResult {
+	switch cmd {
+	case "cd":
+		return s.cmdCd(cmdArgs)
+	case "pwd":
+		return stdoutResult(s.Env.Get("PWD") + "\n")
+	case "echo":
+		return s.cmdEcho(cmdArgs)
 	case "env":
 		return s.cmdEnv()
+	case "export":
+		return s.cmdExport(cmdArgs)
 	case "history":
 		return s.cmdHistory(cmdArgs)
+	case "set":
+		return s.cmdSet(cmdArgs)
+	case "unset":
+		return s.cmdUnset(cmdArgs)
+	case "alias":
+		return s.cmdAlias(cmdArgs)
+	case "unalias":
+		return s.cmdUnalias(cmdArgs)
+	case "source", ".":
+		return s.cmdSource(ctx, cmd, cmdArgs)
+	case "xargs":
+		return s.cmdXargs(ctx, cmdArgs, stdin)
+	case "mapfile", "readarray":
+		return s.cmdMapfile(cmd, cmdArgs, stdin)
+	case "read":
+		if result, ok := s.runRead(ctx, cmdArgs, stdin); ok {
+			return result
+		}
 	}
+	return s.runCommand(ctx, cmd, cmdArgs, stdin)
+}
 
+// runCommand resolves cmd on PATH and runs it to completion. A directory
+// given as a command is listed with ls.
+func (s *Shell) runCommand(ctx context.Context, cmd string, cmdArgs []string, stdin io.Reader) *ExecResult {
 	path, err := s.resolveCommand(ctx, cmd)
 	if err != nil {
-		errMsg := err.Error() + "\n"
-		if redir != nil {
-			return s.writeOutput(ctx, redir, errMsg)
-		}
-		return &ExecResult{Output: errMsg, Code: 1}
+		return stderrResult(err.Error()+"\n", 1)
 	}
 
 	if entry, statErr := s.vos.Stat(ctx, path); statErr == nil && entry.IsDir {
 		lsPath, lsErr := s.resolveCommand(ctx, "ls")
 		if lsErr != nil {
-			return &ExecResult{Output: lsErr.Error() + "\n", Code: 1}
+			return stderrResult(lsErr.Error()+"\n", 1)
 		}
 		ctx = WithEnv(ctx, s.execEnv())
 		rc, execErr := s.vos.Exec(ctx, lsPath, []string{path}, nil)
 		if execErr != nil {
-			return &ExecResult{Output: fmt.Sprintf("ls: %v\n", execErr), Code: 1}
+			return stderrResult(fmt.Sprintf("ls: %v\n", execErr), 1)
 		}
 		defer func() { _ = rc.Close() }()
 		var buf bytes.Buffer
 		_, _ = io.Copy(&buf, rc)
-		output := buf.String()
-		if redir != nil {
-			return s.writeOutput(ctx, redir, output)
-		}
-		return &ExecResult{Output: output}
+		return stdoutResult(buf.String())
 	}
 
 	ctx = WithEnv(ctx, s.execEnv())
 	rc, execErr := s.vos.Exec(ctx, path, cmdArgs, stdin)
 	if execErr != nil {
-		errMsg := fmt.Sprintf("%s: %v\n", cmd, execErr)
-		if redir != nil {
-			return s.writeOutput(ctx, redir, errMsg)
-		}
-		return &ExecResult{Output: errMsg, Code: 1}
+		return execErrResult(cmd, execErr)
 	}
 	defer func() { _ = rc.Close() }()
-	var buf bytes.Buffer
-	_, _ = io.Copy(&buf, rc)
-	output := buf.String()
-	if redir != nil {
-		return s.writeOutput(ctx, redir, output)
+	buf := newLimitBuffer(s.captureLimit(ctx), s.truncation)
+	_, copyErr := io.Copy(buf, rc)
+	result := stdoutResult(buf.String())
+	result.Truncated = buf.truncated()
+	if copyErr != nil {
+		result.appendOutput(execErrResult(cmd, copyErr))
+		result.Code = types.ExitCode(copyErr)
+	}
+	return result
+}
+
+// execErrResult reports a command failure. An ExitError without a message
+// only sets the exit status.
+func execErrResult(cmd string, err error) *ExecResult {
+	var exitErr *types.ExitError
+	if errors.As(err, &exitErr) && exitErr.Err == nil {
+		return &ExecResult{Code: exitErr.Code}
+	}
+	return stderrResult(fmt.Sprintf("%s: %v\n", cmd, err), types.ExitCode(err))
+}
+
+// inputRedirect removes "< FILE" (or "<FILE") from args and returns the
+// file's content as standard input, in place of any pipe or here-document.
+// If several are given the last wins. A quoted "<" is an ordinary argument.
+func (s *Shell) inputRedirect(ctx context.Context, args []string, quoted []bool) ([]string, []bool, io.Reader, *ExecResult) {
+	var (
+		keptArgs   []string
+		keptQuoted []bool
+		source     string
+	)
+	for i := 0; i < len(args); i++ {
+		if quoted[i] || !strings.HasPrefix(args[i], "<") {
+			keptArgs = append(keptArgs, args[i])
+			keptQuoted = append(keptQuoted, quoted[i])
+			continue
+		}
+		source = args[i][1:]
+		if source == "" {
+			if i+1 >= len(args) {
+				return nil, nil, nil, stderrResult("syntax error near unexpected token `newline'\n", 2)
+			}
+			i++
+			source = args[i]
+		}
+	}
+	if source == "" {
+		return args, quoted, nil, nil
+	}
+
+	target := s.absPath(source)
+	f, err := s.vos.Open(ctx, target)
+	if err != nil {
+		return nil, nil, nil, stderrResult(fmt.Sprintf("%s: %v\n", target, err), 1)
 	}
-	return &ExecResult{Output: output}
+	defer func() { _ = f.Close() }()
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return nil, nil, nil, stderrResult(fmt.Sprintf("%s: %v\n", target, err), 1)
+	}
+	return keptArgs, keptQuoted, bytes.NewReader(data), nil
 }
 
-func (s *Shell) writeOutput(ctx context.Context, redir *redirection, output string) *ExecResult {
-	targetPath := s.absPath(s.expandTilde(s.expandEnvVars(redir.path)))
+// writeOutput sends the streams of result selected by redir to the target
+// file: stdout for ">", stderr for "2>", and both for "&>" or "> f 2>&1".
+// The streams that are not redirected stay in the returned result, along
+// with the exit code.
+func (s *Shell) writeOutput(ctx context.Context, redir *redirection, result *ExecResult) *ExecResult {
+	target, err := s.expandCommandVars(redir.path)
+	if err != nil {
+		return stderrResult(err.Error()+"\n", 1)
+	}
+	targetPath := s.absPath(s.expandTilde(target))
+
+	var output string
+	rest := &ExecResult{Code: result.Code}
+	switch {
+	case redir.isCombined || redir.stderrToStdout:
+		output = result.Output
+	case redir.isStderr:
+		output = result.Stderr
+		rest.Output, rest.Stdout = result.Stdout, result.Stdout
+	default:
+		output = result.Stdout
+		rest.Output, rest.Stderr = result.Stderr, result.Stderr
+	}
 	slog.Debug("writeOutput", "path", targetPath, "output", output)
 
 	flag := types.O_WRONLY | types.O_CREATE
@@ -172,62 +399,159 @@ func (s *Shell) writeOutput(ctx context.Context, redir *redirection, output stri
 	}
 	f, err := s.vos.OpenFile(ctx, targetPath, flag)
 	if err != nil {
-		return &ExecResult{Output: fmt.Sprintf("%s: %v\n", targetPath, err), Code: 1}
+		return stderrResult(fmt.Sprintf("%s: %v\n", targetPath, err), 1)
 	}
 	w, ok := f.(io.Writer)
 	if !ok {
 		_ = f.Close()
-		return &ExecResult{Output: fmt.Sprintf("%s: file not writable\n", targetPath), Code: 1}
+		return stderrResult(fmt.Sprintf("%s: file not writable\n", targetPath), 1)
 	}
 	_, _ = fmt.Fprint(w, output)
 	if err := f.Close(); err != nil {
-		return &ExecResult{Output: fmt.Sprintf("%s: %v\n", targetPath, err), Code: 1}
+		return stderrResult(fmt.Sprintf("%s: %v\n", targetPath, err), 1)
 	}
-	return &ExecResult{}
+	return rest
 }
 
+// executeLogicalOps runs a command list. As in sh, "&&" and "||" bind
+// left to right with equal precedence: a command after "&&" runs only if
+// the last command run succeeded, one after "||" only if it failed, and one
+// after ";" always. Skipped commands leave the status unchanged, so the
+// result's code is that of the last command actually run.
 func (s *Shell) executeLogicalOps(ctx context.Context, segments []logicalSegment) *ExecResult {
-	var output strings.Builder
-	var lastCode int
+	res := &ExecResult{}
 
+	prev := opSeq
 	for _, seg := range segments {
-		seg.cmd = strings.TrimSpace(seg.cmd)
-		if seg.cmd == "" {
+		cmd := strings.TrimSpace(seg.cmd)
+		run := cmd != "" &&
+			(prev == opSeq || prev == opAnd && res.Code == 0 || prev == opOr && res.Code != 0)
+		if seg.op != opNone {
+			prev = seg.op
+		}
+		if !run {
 			continue
 		}
-
-		redir, cmdPart := parseRedirection(seg.cmd)
-		cmdPart = strings.TrimSpace(cmdPart)
-		if redir != nil {
-			cmdPart, redir.stderrToStdout = parseStderrToStdout(cmdPart)
+		result := s.execute(ctx, cmd)
+		res.appendOutput(result)
+		res.Code = result.Code
+		if ctx.Err() != nil {
+			break
 		}
+	}
 
-		result := s.executeSingle(ctx, cmdPart, nil, redir)
-		output.WriteString(result.Output)
-		lastCode = result.Code
+	return res
+}
 
-		switch seg.op {
-		case opAnd:
-			if result.Code != 0 {
-				return &ExecResult{Output: output.String(), Code: result.Code}
+// ifClause is one "if" or "elif" test and the list run when it succeeds.
+type ifClause struct {
+	cond, body []logicalSegment
+}
+
+// executeIf runs an if compound written on one line:
+//
+//	if LIST; then LIST; [elif LIST; then LIST;]... [else LIST;] fi
+//
+// The exit status is that of the list run last, or 0 if no test succeeded
+// and there is no else branch.
+func (s *Shell) executeIf(ctx context.Context, cmdLine string) *ExecResult {
+	const (
+		inCond = iota
+		inBody
+		inElse
+		done
+	)
+	var (
+		clauses  = []ifClause{{}}
+		elseBody []logicalSegment
+		state    = inCond
+	)
+	syntaxErr := func(token string) *ExecResult {
+		return stderrResult(fmt.Sprintf("syntax error near unexpected token `%s'\n", token), 2)
+	}
+
+	for _, seg := range splitLogicalOps(cmdLine[len("if"):]) {
+		cmd := strings.TrimSpace(seg.cmd)
+		if state == done {
+			return syntaxErr(cmd)
+		}
+		last := &clauses[len(clauses)-1]
+		kw := keywordAt(cmd, 0)
+		switch kw {
+		case "then":
+			if state != inCond || len(last.cond) == 0 {
+				return syntaxErr(kw)
+			}
+			state = inBody
+		case "elif", "else":
+			if state != inBody || len(last.body) == 0 {
+				return syntaxErr(kw)
+			}
+			if kw == "elif" {
+				clauses = append(clauses, ifClause{})
+				last = &clauses[len(clauses)-1]
+				state = inCond
+			} else {
+				state = inElse
+			}
+		case "fi":
+			if state == inCond || state == inBody && len(last.body) == 0 || state == inElse && len(elseBody) == 0 {
+				return syntaxErr(kw)
 			}
-		case opOr:
-			if result.Code == 0 {
-				return &ExecResult{Output: output.String(), Code: 0}
+			if rest := strings.TrimSpace(cmd[len(kw):]); rest != "" {
+				return syntaxErr(rest)
 			}
-		case opNone:
-			return &ExecResult{Output: output.String(), Code: result.Code}
+			state = done
+			continue
+		}
+		if kw != "" && kw != "if" {
+			cmd = strings.TrimSpace(cmd[len(kw):])
 		}
+		if cmd == "" {
+			continue
+		}
+		seg.cmd = cmd
+		switch state {
+		case inCond:
+			last.cond = append(last.cond, seg)
+		case inBody:
+			last.body = append(last.body, seg)
+		case inElse:
+			elseBody = append(elseBody, seg)
+		}
+	}
+	if state != done {
+		return stderrResult("syntax error: expected `fi'\n", 2)
 	}
 
-	return &ExecResult{Output: output.String(), Code: lastCode}
+	res := &ExecResult{}
+	for _, c := range clauses {
+		cond := s.executeLogicalOps(ctx, c.cond)
+		res.appendOutput(cond)
+		if ctx.Err() != nil {
+			res.Code = cond.Code
+			return res
+		}
+		if cond.Code == 0 {
+			body := s.executeLogicalOps(ctx, c.body)
+			res.appendOutput(body)
+			res.Code = body.Code
+			return res
+		}
+	}
+	if elseBody != nil {
+		body := s.executeLogicalOps(ctx, elseBody)
+		res.appendOutput(body)
+		res.Code = body.Code
+	}
+	return res
 }
 
 func (s *Shell) executeCommandGroup(ctx context.Context, cmdLine string) *ExecResult {
 	start := strings.Index(cmdLine, "{")
 	end := strings.LastIndex(cmdLine, "}")
 	if start == -1 || end == -1 || end <= start {
-		return &ExecResult{Output: "invalid command group\n", Code: 1}
+		return stderrResult("invalid command group\n", 1)
 	}
 
 	inner := cmdLine[start+1 : end]
@@ -235,22 +559,19 @@ func (s *Shell) executeCommandGroup(ctx context.Context, cmdLine string) *ExecRe
 
 	commands := splitBySemicolon(inner)
 
-	var output strings.Builder
-	var lastCode int
-
+	res := &ExecResult{}
 	for _, cmd := range commands {
 		cmd = strings.TrimSpace(cmd)
 		if cmd == "" {
 			continue
 		}
 		result := s.Execute(ctx, cmd)
-		output.WriteString(result.Output)
-		lastCode = result.Code
+		res.appendOutput(result)
+		res.Code = result.Code
 	}
 
 	if redir != nil && redir.path != "" {
-		return s.writeOutput(ctx, redir, output.String())
+		return s.writeOutput(ctx, redir, res)
 	}
-
-	return &ExecResult{Output: output.String(), Code: lastCode}
+	return res
 }
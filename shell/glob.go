@@ -2,7 +2,9 @@ package shell
 
 import (
 	"context"
+	"fmt"
 	"path"
+	"slices"
 	"sort"
 	"strings"
 
@@ -14,8 +16,9 @@ func hasGlobChars(s string) bool {
 }
 
 // expandGlobs expands wildcard patterns in command arguments.
-// Quoted arguments are never expanded.
-func (s *Shell) expandGlobs(ctx context.Context, args []string, quoted []bool) []string {
+// Quoted arguments are never expanded. A pattern that matches nothing is
+// kept as written, dropped under nullglob, or, under failglob, an error.
+func (s *Shell) expandGlobs(ctx context.Context, args []string, quoted []bool) ([]string, error) {
 	var result []string
 	for i, arg := range args {
 		if i < len(quoted) && quoted[i] {
@@ -27,9 +30,18 @@ func (s *Shell) expandGlobs(ctx context.Context, args []string, quoted []bool) [
 			continue
 		}
 		matches := s.globMatch(ctx, arg)
+		if len(matches) == 0 {
+			switch {
+			case s.failglob:
+				return nil, fmt.Errorf("no matches found: %s", arg)
+			case s.nullglob:
+				continue
+			}
+			matches = []string{arg}
+		}
 		result = append(result, matches...)
 	}
-	return result
+	return result, nil
 }
 
 func (s *Shell) globMatch(ctx context.Context, pattern string) []string {
@@ -53,9 +65,8 @@ func (s *Shell) globMatch(ctx context.Context, pattern string) []string {
 
 	parts := strings.Split(strings.TrimPrefix(absPattern, "/"), "/")
 	matches := s.globRecurse(ctx, "/", parts)
-
 	if len(matches) == 0 {
-		return []string{pattern}
+		return nil
 	}
 
 	if !isAbsolute {
@@ -72,7 +83,7 @@ func (s *Shell) globMatch(ctx context.Context, pattern string) []string {
 	}
 
 	sort.Strings(matches)
-	return matches
+	return slices.Compact(matches)
 }
 
 func (s *Shell) globRecurse(ctx context.Context, base string, parts []string) []string {
@@ -83,6 +94,10 @@ func (s *Shell) globRecurse(ctx context.Context, base string, parts []string) []
 	part := parts[0]
 	rest := parts[1:]
 
+	if part == "**" {
+		return s.globStar(ctx, base, rest)
+	}
+
 	if !hasGlobChars(part) {
 		var next string
 		if base == "/" {
@@ -126,3 +141,29 @@ func (s *Shell) globRecurse(ctx context.Context, base string, parts []string) []
 
 	return results
 }
+
+// globStar matches a "**" component, which stands for zero or more
+// directories, followed by the components in rest. A trailing "**" matches
+// everything below base. Symlinked directories are not descended into, so
+// a link cycle cannot recurse forever.
+func (s *Shell) globStar(ctx context.Context, base string, rest []string) []string {
+	var results []string
+	if len(rest) > 0 {
+		results = s.globRecurse(ctx, base, rest)
+	}
+
+	entries, err := s.vos.List(ctx, base, types.ListOpts{})
+	if err != nil {
+		return results
+	}
+	for _, entry := range entries {
+		next := path.Join(base, entry.Name)
+		if len(rest) == 0 {
+			results = append(results, next)
+		}
+		if entry.IsDir && !entry.IsSymlink {
+			results = append(results, s.globStar(ctx, next, rest)...)
+		}
+	}
+	return results
+}
@@ -7,9 +7,116 @@ import (
 	"time"
 )
 
-const MaxHistorySize = 1000
+// DefaultHistorySize is the number of commands a shell remembers unless
+// WithHistorySize says otherwise.
+const DefaultHistorySize = 100
 
-func (s *Shell) getHistoryFilePath() string {
+// HistoryEntry is one command in a shell's history. ExitCode is 0 for
+// commands loaded from ~/.bash_history, which does not record it.
+type HistoryEntry struct {
+	Command  string    `json:"command"`
+	ExitCode int       `json:"exitCode"`
+	Time     time.Time `json:"time"`
+}
+
+// WithHistorySize sets how many commands the shell remembers. Once full,
+// each new command drops the oldest. n <= 0 means DefaultHistorySize.
+func WithHistorySize(n int) ShellOption {
+	return func(s *Shell) { s.history.resize(n) }
+}
+
+// historyRing holds the most recent commands in a fixed-size ring. Entries
+// are numbered from 1 in the order they were added, and keep their number
+// as older entries are dropped, as in bash.
+type historyRing struct {
+	buf   []HistoryEntry
+	start int // index in buf of the oldest entry
+	n     int // number of entries held
+	total int // entries ever added; the newest is number total
+}
+
+func (r *historyRing) size() int {
+	if len(r.buf) == 0 {
+		return DefaultHistorySize
+	}
+	return len(r.buf)
+}
+
+// resize changes the capacity, keeping the newest entries.
+func (r *historyRing) resize(n int) {
+	if n <= 0 {
+		n = DefaultHistorySize
+	}
+	entries := r.entries()
+	if len(entries) > n {
+		entries = entries[len(entries)-n:]
+	}
+	total := r.total
+	*r = historyRing{buf: make([]HistoryEntry, n)}
+	for _, e := range entries {
+		r.push(e)
+	}
+	r.total = total
+}
+
+// push adds e as the newest entry, dropping the oldest if the ring is full.
+func (r *historyRing) push(e HistoryEntry) {
+	if len(r.buf) == 0 {
+		r.buf = make([]HistoryEntry, DefaultHistorySize)
+	}
+	if r.n < len(r.buf) {
+		r.buf[(r.start+r.n)%len(r.buf)] = e
+		r.n++
+	} else {
+		r.buf[r.start] = e
+		r.start = (r.start + 1) % len(r.buf)
+	}
+	r.total++
+}
+
+// first returns the number of the oldest entry held.
+func (r *historyRing) first() int { return r.total - r.n + 1 }
+
+// get returns the entry numbered num, or nil if it is no longer held.
+func (r *historyRing) get(num int) *HistoryEntry {
+	i := num - r.first()
+	if i < 0 || i >= r.n {
+		return nil
+	}
+	return &r.buf[(r.start+i)%len(r.buf)]
+}
+
+// remove deletes the entry numbered num. Later entries are renumbered.
+func (r *historyRing) remove(num int) bool {
+	i := num - r.first()
+	if i < 0 || i >= r.n {
+		return false
+	}
+	for ; i < r.n-1; i++ {
+		r.buf[(r.start+i)%len(r.buf)] = r.buf[(r.start+i+1)%len(r.buf)]
+	}
+	r.n--
+	r.total--
+	return true
+}
+
+// clear drops every entry. Numbering starts again from 1.
+func (r *historyRing) clear() {
+	r.start, r.n, r.total = 0, 0, 0
+}
+
+// entries returns the entries held, oldest first.
+func (r *historyRing) entries() []HistoryEntry {
+	out := make([]HistoryEntry, r.n)
+	for i := range out {
+		out[i] = r.buf[(r.start+i)%len(r.buf)]
+	}
+	return out
+}
+
+// HistoryFile returns the path of the file the shell saves its history to,
+// ~/.bash_history. The shell writes it after every command.
+func (s *Shell) HistoryFile() string {
 	home := s.Env.Get("HOME")
 	if home == "" {
 		home = "/"
@@ -17,9 +124,11 @@ func (s *Shell) getHistoryFilePath() string {
 	return home + "/.bash_history"
 }
 
+// loadHistory reads the newest commands from ~/.bash_history, as many as
+// the history holds.
 func (s *Shell) loadHistory() {
 	ctx := context.Background()
-	histFile := s.getHistoryFilePath()
+	histFile := s.HistoryFile()
 
 	rc, err := s.vos.Open(ctx, histFile)
 	if err != nil {
@@ -32,29 +141,42 @@ func (s *Shell) loadHistory() {
 		return
 	}
 
-	lines := strings.Split(string(data), "\n")
-	start := 0
-	if len(lines) > MaxHistorySize {
-		start = len(lines) - MaxHistorySize
+	var lines []string
+	for _, line := range strings.Split(string(data), "\n") {
+		if line = strings.TrimRight(line, "\r"); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	if size := s.history.size(); len(lines) > size {
+		lines = lines[len(lines)-size:]
 	}
-	for i := start; i < len(lines); i++ {
-		line := strings.TrimRight(lines[i], "\r")
-		if line != "" {
-			s.history = append(s.history, line)
+	for _, line := range lines {
+		e := HistoryEntry{Command: ExtractCommand(line)}
+		if idx := strings.Index(line, " ## "); idx != -1 {
+			e.Time, _ = time.Parse(time.RFC3339, line[idx+len(" ## "):])
 		}
+		s.history.push(e)
 	}
-	s.savedOffset = len(s.history)
+	s.unsaved = 0
 }
 
+// saveHistory appends the commands added since the last save to
+// ~/.bash_history, one "command ## timestamp" line each.
 func (s *Shell) saveHistory() {
-	if len(s.history) <= s.savedOffset {
+	if s.unsaved == 0 {
 		return
 	}
-	ctx := context.Background()
-	histFile := s.getHistoryFilePath()
-	newCommands := s.history[s.savedOffset:]
-	content := strings.Join(newCommands, "\n") + "\n"
+	entries := s.history.entries()
+	if s.unsaved < len(entries) {
+		entries = entries[len(entries)-s.unsaved:]
+	}
+	var content strings.Builder
+	for _, e := range entries {
+		content.WriteString(e.Command + " ## " + e.Time.Format(time.RFC3339) + "\n")
+	}
 
+	ctx := context.Background()
+	histFile := s.HistoryFile()
 	existing := ""
 	rc, err := s.vos.Open(ctx, histFile)
 	if err == nil {
@@ -63,10 +185,10 @@ func (s *Shell) saveHistory() {
 		existing = string(data)
 	}
 
-	if err := s.vos.Write(ctx, histFile, strings.NewReader(existing+content)); err != nil {
+	if err := s.vos.Write(ctx, histFile, strings.NewReader(existing+content.String())); err != nil {
 		return
 	}
-	s.savedOffset = len(s.history)
+	s.unsaved = 0
 }
 
 // ExtractCommand extracts the command part from a history entry.
@@ -77,31 +199,34 @@ func ExtractCommand(entry string) string {
 	return entry
 }
 
-func (s *Shell) addToHistory(cmd string) {
+// addToHistory records cmd and returns its history number, or 0 if it was
+// not recorded. A command that repeats the previous one is not added again;
+// its number is returned so that the exit code can be updated.
+func (s *Shell) addToHistory(cmd string) int {
 	if strings.TrimSpace(cmd) == "" {
-		return
+		return 0
 	}
-	if len(s.history) > 0 {
-		lastCmd := ExtractCommand(s.history[len(s.history)-1])
-		if lastCmd == cmd {
-			return
-		}
+	if last := s.history.get(s.history.total); last != nil && last.Command == cmd {
+		return s.history.total
 	}
-	timestamp := time.Now().Format(time.RFC3339)
-	entry := cmd + " ## " + timestamp
-	s.history = append(s.history, entry)
+	s.history.push(HistoryEntry{Command: cmd, Time: time.Now()})
+	s.unsaved++
 	s.saveHistory()
+	return s.history.total
 }
 
-// History returns a copy of the command history.
-func (s *Shell) History() []string {
-	cp := make([]string, len(s.history))
-	copy(cp, s.history)
-	return cp
+// setHistoryExitCode records the exit code of the command numbered num.
+func (s *Shell) setHistoryExitCode(num, code int) {
+	if e := s.history.get(num); e != nil {
+		e.ExitCode = code
+	}
 }
 
+// History returns a copy of the command history, oldest first.
+func (s *Shell) History() []HistoryEntry { return s.history.entries() }
+
 // ClearHistory clears the command history.
-func (s *Shell) ClearHistory() { s.history = nil }
+func (s *Shell) ClearHistory() { s.history.clear() }
 
 // HistorySize returns the number of commands in history.
-func (s *Shell) HistorySize() int { return len(s.history) }
+func (s *Shell) HistorySize() int { return s.history.n }
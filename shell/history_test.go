@@ -1,6 +1,7 @@
 package shell
 
 import (
+	"strings"
 	"testing"
 )
 
@@ -46,3 +47,46 @@ func TestExtractCommand(t *testing.T) {
 		})
 	}
 }
+
+func TestHistoryRing(t *testing.T) {
+	var r historyRing
+	r.resize(3)
+	for _, cmd := range []string{"a", "b", "c", "d"} {
+		r.push(HistoryEntry{Command: cmd})
+	}
+	commands := func() string {
+		var out []string
+		for _, e := range r.entries() {
+			out = append(out, e.Command)
+		}
+		return strings.Join(out, " ")
+	}
+
+	if got := commands(); got != "b c d" {
+		t.Fatalf("entries after wrap = %q, want %q", got, "b c d")
+	}
+	if r.first() != 2 || r.get(1) != nil || r.get(4).Command != "d" {
+		t.Errorf("numbering after wrap: first=%d", r.first())
+	}
+
+	if !r.remove(3) || commands() != "b d" || r.get(3).Command != "d" {
+		t.Errorf("after remove(3): %q", commands())
+	}
+	if r.remove(9) {
+		t.Error("remove of an unknown number succeeded")
+	}
+
+	r.resize(1)
+	if got := commands(); got != "d" || r.get(3) == nil {
+		t.Errorf("after resize(1): %q", got)
+	}
+	r.push(HistoryEntry{Command: "e"})
+	if got := commands(); got != "e" || r.get(4).Command != "e" {
+		t.Errorf("after push into resized ring: %q", got)
+	}
+
+	r.clear()
+	if len(r.entries()) != 0 || r.total != 0 {
+		t.Errorf("clear left %d entries", len(r.entries()))
+	}
+}
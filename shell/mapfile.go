@@ -0,0 +1,132 @@
+package shell
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// mapfileOpts holds the parsed options of the mapfile builtin.
+type mapfileOpts struct {
+	delim  byte
+	count  int // lines to store; 0 is all
+	origin int
+	append bool // -O given: keep the existing elements
+	skip   int
+	trim   bool
+	name   string
+}
+
+const mapfileUsage = "usage: mapfile [-d delim] [-n count] [-O origin] [-s count] [-t] [array]"
+
+// parseMapfileArgs parses "mapfile [-d DELIM] [-n COUNT] [-O ORIGIN]
+// [-s SKIP] [-t] [ARRAY]". The array defaults to MAPFILE.
+func parseMapfileArgs(args []string) (mapfileOpts, error) {
+	opts := mapfileOpts{delim: '\n', name: "MAPFILE"}
+	i := 0
+	for ; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			i++
+			break
+		}
+		if len(arg) < 2 || arg[0] != '-' {
+			break
+		}
+		if arg == "-t" {
+			opts.trim = true
+			continue
+		}
+		if arg != "-d" && arg != "-n" && arg != "-O" && arg != "-s" {
+			return opts, fmt.Errorf("%s: invalid option", arg)
+		}
+		if i+1 >= len(args) {
+			return opts, fmt.Errorf("%s: option requires an argument", arg)
+		}
+		i++
+		if arg == "-d" {
+			// An empty delimiter means NUL, as in bash.
+			opts.delim = 0
+			if args[i] != "" {
+				opts.delim = args[i][0]
+			}
+			continue
+		}
+		n, err := strconv.Atoi(args[i])
+		if err != nil || n < 0 {
+			return opts, fmt.Errorf("%s: invalid count", args[i])
+		}
+		switch arg {
+		case "-n":
+			opts.count = n
+		case "-O":
+			opts.origin, opts.append = n, true
+		case "-s":
+			opts.skip = n
+		}
+	}
+	switch rest := args[i:]; len(rest) {
+	case 0:
+	case 1:
+		if !isName(rest[0]) {
+			return opts, fmt.Errorf("`%s': not a valid identifier", rest[0])
+		}
+		opts.name = rest[0]
+	default:
+		return opts, fmt.Errorf("too many arguments")
+	}
+	return opts, nil
+}
+
+// cmdMapfile implements mapfile and its synonym readarray: it reads lines
+// from stdin into an indexed array, one line per element, starting at
+// index 0 or the -O origin. Without -O the array is cleared first. Lines
+// keep their delimiter unless -t is given. With no stdin the input is
+// empty.
+func (s *Shell) cmdMapfile(cmd string, args []string, stdin io.Reader) *ExecResult {
+	opts, err := parseMapfileArgs(args)
+	if err != nil {
+		return stderrResult(cmd+": "+err.Error()+"\n"+cmd+": "+mapfileUsage+"\n", 2)
+	}
+
+	var data []byte
+	if stdin != nil {
+		if data, err = io.ReadAll(stdin); err != nil {
+			return stderrResult(cmd+": "+err.Error()+"\n", 1)
+		}
+	}
+
+	a := make(map[int]string)
+	if existing, ok := s.Env.arrays[opts.name]; ok && opts.append {
+		for i, v := range existing {
+			a[i] = v
+		}
+	} else if v, ok := s.Env.data[opts.name]; ok && opts.append {
+		a[0] = v
+	}
+
+	idx, stored := opts.origin, 0
+	for line := 0; len(data) > 0; line++ {
+		n := bytes.IndexByte(data, opts.delim) + 1
+		if n == 0 {
+			n = len(data)
+		}
+		elem := data[:n]
+		data = data[n:]
+		if line < opts.skip {
+			continue
+		}
+		if opts.count > 0 && stored == opts.count {
+			break
+		}
+		if opts.trim && elem[len(elem)-1] == opts.delim {
+			elem = elem[:len(elem)-1]
+		}
+		a[idx] = string(elem)
+		idx++
+		stored++
+	}
+	s.Env.setArray(opts.name, a)
+	return &ExecResult{}
+}
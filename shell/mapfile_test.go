@@ -0,0 +1,112 @@
+package shell
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/jackfish212/grasp/types"
+)
+
+func TestMapfile(t *testing.T) {
+	sh, v := setupTestShell(t)
+	ctx := context.Background()
+	v.files["/tmp/lines.txt"] = &mockFile{content: []byte("one\ntwo\nthree\nfour\n"), perm: types.PermRW}
+
+	tests := []struct {
+		cmd  string
+		want []string
+	}{
+		{"cat /tmp/lines.txt | mapfile -t A", []string{"one", "two", "three", "four"}},
+		{"cat /tmp/lines.txt | mapfile A", []string{"one\n", "two\n", "three\n", "four\n"}},
+		{"cat /tmp/lines.txt | readarray -t -s 1 -n 2 A", []string{"two", "three"}},
+		{"cat /tmp/lines.txt | mapfile -t -O 2 -n 1 A", []string{"two", "three", "one"}},
+		{"cat /tmp/lines.txt | mapfile -t -d e A", []string{"on", "\ntwo\nthr", "", "\nfour\n"}},
+		// Input redirection from a file.
+		{"mapfile -t A < /tmp/lines.txt", []string{"one", "two", "three", "four"}},
+		{"readarray -t -n 2 A </tmp/lines.txt", []string{"one", "two"}},
+		{"cd /tmp && mapfile -t -s 3 A < lines.txt", []string{"four"}},
+	}
+	for _, tt := range tests {
+		if result := sh.Execute(ctx, tt.cmd); result.Code != 0 {
+			t.Fatalf("%s: code %d: %s", tt.cmd, result.Code, result.Output)
+		}
+		if got := sh.Env.Array("A"); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: A = %q, want %q", tt.cmd, got, tt.want)
+		}
+	}
+
+	sh.Execute(ctx, "cat /tmp/lines.txt | mapfile")
+	if got := sh.Env.Array("MAPFILE"); len(got) != 4 {
+		t.Errorf("MAPFILE = %q, want 4 lines", got)
+	}
+
+	if result := sh.Execute(ctx, "mapfile A < /tmp/missing.txt"); result.Code != 1 || !strings.Contains(result.Output, "/tmp/missing.txt") {
+		t.Errorf("mapfile from missing file = %q (code %d)", result.Output, result.Code)
+	}
+	if result := sh.Execute(ctx, "mapfile A <"); result.Code != 2 {
+		t.Errorf("mapfile A < without a file: code %d, want 2", result.Code)
+	}
+	if result := sh.Execute(ctx, "mapfile -x A"); result.Code != 2 || !strings.Contains(result.Output, "usage") {
+		t.Errorf("mapfile -x = %q (code %d), want usage error", result.Output, result.Code)
+	}
+	if result := sh.Execute(ctx, "mapfile 1A"); result.Code != 2 {
+		t.Errorf("mapfile 1A: code %d, want 2", result.Code)
+	}
+}
+
+func TestArrayExpansion(t *testing.T) {
+	sh, _ := setupTestShell(t)
+	ctx := context.Background()
+	sh.Env.SetArray("A", []string{"alpha", "beta", "gamma"})
+	sh.Env.Set("I", "1")
+
+	tests := []struct {
+		cmd, want string
+	}{
+		{"echo ${A[@]}", "alpha beta gamma"},
+		{"echo ${A[*]}", "alpha beta gamma"},
+		{"echo ${A[2]}", "gamma"},
+		{"echo ${A[$I]}", "beta"},
+		{"echo ${A[-1]}", "gamma"},
+		{"echo $A", "alpha"},
+		{"echo ${#A[@]}", "3"},
+		{"echo ${#A}", "5"},
+		{"echo ${A[7]:-none}", "none"},
+		{"echo ${#I[@]} ${#NOPE[@]}", "1 0"},
+	}
+	for _, tt := range tests {
+		result := sh.Execute(ctx, tt.cmd)
+		if got := strings.TrimSpace(result.Output); got != tt.want {
+			t.Errorf("%s = %q, want %q", tt.cmd, got, tt.want)
+		}
+	}
+
+	if result := sh.Execute(ctx, "echo ${A[x]}"); result.Code == 0 {
+		t.Errorf("bad subscript should fail, got %q", result.Output)
+	}
+
+	sh.Execute(ctx, "A=first")
+	if got := sh.Env.Array("A"); !reflect.DeepEqual(got, []string{"first", "beta", "gamma"}) {
+		t.Errorf("after A=first, A = %q", got)
+	}
+
+	data, err := json.Marshal(sh)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var restored Shell
+	if err := json.Unmarshal(data, &restored); err != nil {
+		t.Fatal(err)
+	}
+	if got := restored.Env.Array("A"); !reflect.DeepEqual(got, []string{"first", "beta", "gamma"}) {
+		t.Errorf("restored A = %q", got)
+	}
+
+	sh.Execute(ctx, "unset A")
+	if sh.Env.Array("A") != nil {
+		t.Error("unset should remove the array")
+	}
+}
@@ -0,0 +1,135 @@
+package shell
+
+import "context"
+
+// TruncationStrategy selects which part of a command's output is kept when
+// it exceeds the limit set by WithOutputLimit.
+type TruncationStrategy int
+
+const (
+	// TruncateTail keeps the start of the output and drops the rest.
+	TruncateTail TruncationStrategy = iota
+	// TruncateHead keeps the end of the output and drops the start, which
+	// suits logs and other output whose latest lines matter most.
+	TruncateHead
+	// TruncateMiddle keeps the start and the end, half the limit each, and
+	// drops what lies between.
+	TruncateMiddle
+)
+
+// WithOutputLimit caps the output a command returns at maxBytes, so that a
+// command such as "cat" on a large file cannot exhaust the caller's memory.
+// Output from commands is capped as it is read, and Output, Stdout and
+// Stderr of the final result are each held to the limit. A result that was
+// cut short has Truncated set. Output redirected to a file is not limited.
+// maxBytes <= 0 means no limit, the default.
+func WithOutputLimit(maxBytes int) ShellOption {
+	return func(s *Shell) { s.outputLimit = maxBytes }
+}
+
+// WithOutputLimitStrategy sets which part of oversized output is kept; the
+// default is TruncateTail. See WithOutputLimit.
+func WithOutputLimitStrategy(strategy TruncationStrategy) ShellOption {
+	return func(s *Shell) { s.truncation = strategy }
+}
+
+// unlimitedKey marks a context whose command output is redirected, and so
+// must be captured in full.
+type unlimitedKey struct{}
+
+// captureLimit returns the number of bytes of command output to capture
+// for ctx, or 0 for all of it.
+func (s *Shell) captureLimit(ctx context.Context) int {
+	if ctx.Value(unlimitedKey{}) != nil {
+		return 0
+	}
+	return s.outputLimit
+}
+
+// limitOutput holds each stream of res to the output limit.
+func (s *Shell) limitOutput(res *ExecResult) {
+	if s.outputLimit <= 0 {
+		return
+	}
+	for _, out := range []*string{&res.Output, &res.Stdout, &res.Stderr} {
+		if len(*out) <= s.outputLimit {
+			continue
+		}
+		b := newLimitBuffer(s.outputLimit, s.truncation)
+		_, _ = b.Write([]byte(*out))
+		*out = b.String()
+		res.Truncated = true
+	}
+}
+
+// limitBuffer is an io.Writer that keeps at most max bytes of what is
+// written to it, chosen by strategy, in memory bounded by about twice max.
+// A max of 0 or less keeps everything.
+type limitBuffer struct {
+	max      int
+	strategy TruncationStrategy
+	head     []byte // kept from the start
+	tail     []byte // kept from the end; only its last tailMax bytes count
+	total    int
+}
+
+func newLimitBuffer(max int, strategy TruncationStrategy) *limitBuffer {
+	return &limitBuffer{max: max, strategy: strategy}
+}
+
+// split returns how many bytes to keep from the start and from the end.
+func (b *limitBuffer) split() (headMax, tailMax int) {
+	switch {
+	case b.max <= 0:
+		return -1, 0
+	case b.strategy == TruncateHead:
+		return 0, b.max
+	case b.strategy == TruncateMiddle:
+		return b.max - b.max/2, b.max / 2
+	default:
+		return b.max, 0
+	}
+}
+
+func (b *limitBuffer) Write(p []byte) (int, error) {
+	n := len(p)
+	b.total += n
+	headMax, tailMax := b.split()
+	if headMax < 0 {
+		b.head = append(b.head, p...)
+		return n, nil
+	}
+	if room := headMax - len(b.head); room > 0 {
+		if room > len(p) {
+			room = len(p)
+		}
+		b.head = append(b.head, p[:room]...)
+		p = p[room:]
+	}
+	if tailMax == 0 || len(p) == 0 {
+		return n, nil
+	}
+	if len(p) > tailMax {
+		p = p[len(p)-tailMax:]
+	}
+	b.tail = append(b.tail, p...)
+	if len(b.tail) > 2*tailMax {
+		b.tail = b.tail[:copy(b.tail, b.tail[len(b.tail)-tailMax:])]
+	}
+	return n, nil
+}
+
+// truncated reports whether more was written than was kept.
+func (b *limitBuffer) truncated() bool {
+	return b.max > 0 && b.total > b.max
+}
+
+// String returns the bytes kept: the start, then the end.
+func (b *limitBuffer) String() string {
+	_, tailMax := b.split()
+	tail := b.tail
+	if len(tail) > tailMax {
+		tail = tail[len(tail)-tailMax:]
+	}
+	return string(b.head) + string(tail)
+}
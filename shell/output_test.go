@@ -0,0 +1,39 @@
+package shell
+
+import "testing"
+
+func TestLimitBuffer(t *testing.T) {
+	tests := []struct {
+		name      string
+		max       int
+		strategy  TruncationStrategy
+		writes    []string
+		want      string
+		truncated bool
+	}{
+		{"under limit", 10, TruncateTail, []string{"abc", "def"}, "abcdef", false},
+		{"no limit", 0, TruncateHead, []string{"abc", "def"}, "abcdef", false},
+		{"tail", 4, TruncateTail, []string{"abc", "defgh"}, "abcd", true},
+		{"head", 4, TruncateHead, []string{"abc", "defgh"}, "efgh", true},
+		{"head many writes", 3, TruncateHead, []string{"a", "b", "c", "d", "e", "f", "g", "h"}, "fgh", true},
+		{"middle", 5, TruncateMiddle, []string{"0123456789"}, "01289", true},
+		{"middle many writes", 4, TruncateMiddle, []string{"01", "23", "45", "67", "89"}, "0189", true},
+		{"middle under limit", 10, TruncateMiddle, []string{"01234", "567"}, "01234567", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newLimitBuffer(tt.max, tt.strategy)
+			for _, w := range tt.writes {
+				if n, err := b.Write([]byte(w)); n != len(w) || err != nil {
+					t.Fatalf("Write(%q) = %d, %v", w, n, err)
+				}
+			}
+			if got := b.String(); got != tt.want {
+				t.Errorf("String() = %q, want %q", got, tt.want)
+			}
+			if b.truncated() != tt.truncated {
+				t.Errorf("truncated() = %v, want %v", b.truncated(), tt.truncated)
+			}
+		})
+	}
+}
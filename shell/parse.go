@@ -36,51 +36,86 @@ const (
 	opNone logicalOp = iota
 	opAnd
 	opOr
+	opSeq // ";"
 )
 
+// logicalSegment is one command of a list, with the operator that follows it.
 type logicalSegment struct {
 	cmd string
 	op  logicalOp
 }
 
+// splitLogicalOps splits a command list on "&&", "||" and ";". Operators
+// inside quotes, after a backslash (as in find's "\;"), inside $(...),
+// {...} or if ... fi, or in a here-document body (after the first line) are
+// left alone.
 func splitLogicalOps(s string) []logicalSegment {
 	var segments []logicalSegment
 	var current strings.Builder
 	inSingle := false
 	inDouble := false
+	depth := 0
+	cmdPos := true // at the start of a command, where keywords are recognised
 
 	for i := 0; i < len(s); i++ {
 		ch := s[i]
+		quoted := inSingle || inDouble
+		if !quoted && cmdPos {
+			if kw := keywordAt(s, i); kw != "" {
+				switch {
+				case kw == "if":
+					depth++
+				case kw == "fi" && depth > 0:
+					depth--
+				}
+				current.WriteString(kw)
+				i += len(kw) - 1
+				cmdPos = kw != "fi"
+				continue
+			}
+		}
+		if !quoted && ch != ' ' && ch != '\t' {
+			cmdPos = strings.IndexByte(";&|({", ch) >= 0
+		}
 		switch {
+		case ch == '\\' && !inSingle && i+1 < len(s):
+			current.WriteByte(ch)
+			i++
+			current.WriteByte(s[i])
+			continue
 		case ch == '\'' && !inDouble:
 			inSingle = !inSingle
-			current.WriteByte(ch)
 		case ch == '"' && !inSingle:
 			inDouble = !inDouble
-			current.WriteByte(ch)
-		case ch == '&' && !inSingle && !inDouble:
-			if i+1 < len(s) && s[i+1] == '&' {
-				if current.Len() > 0 {
-					segments = append(segments, logicalSegment{cmd: current.String(), op: opAnd})
-					current.Reset()
-				}
+		case quoted:
+		case ch == '\n':
+			current.WriteString(s[i:])
+			i = len(s)
+			continue
+		case ch == '(' || ch == '{':
+			depth++
+		case (ch == ')' || ch == '}') && depth > 0:
+			depth--
+		case depth > 0:
+		case ch == '&' && i+1 < len(s) && s[i+1] == '&',
+			ch == '|' && i+1 < len(s) && s[i+1] == '|',
+			ch == ';':
+			op := opSeq
+			switch ch {
+			case '&':
+				op = opAnd
 				i++
-				continue
-			}
-			current.WriteByte(ch)
-		case ch == '|' && !inSingle && !inDouble:
-			if i+1 < len(s) && s[i+1] == '|' {
-				if current.Len() > 0 {
-					segments = append(segments, logicalSegment{cmd: current.String(), op: opOr})
-					current.Reset()
-				}
+			case '|':
+				op = opOr
 				i++
-				continue
 			}
-			current.WriteByte(ch)
-		default:
-			current.WriteByte(ch)
+			if current.Len() > 0 {
+				segments = append(segments, logicalSegment{cmd: current.String(), op: op})
+				current.Reset()
+			}
+			continue
 		}
+		current.WriteByte(ch)
 	}
 	if current.Len() > 0 {
 		segments = append(segments, logicalSegment{cmd: current.String(), op: opNone})
@@ -92,6 +127,23 @@ func splitLogicalOps(s string) []logicalSegment {
 	return segments
 }
 
+// shellKeywords are the reserved words of if compounds.
+var shellKeywords = []string{"if", "then", "elif", "else", "fi"}
+
+// keywordAt returns the reserved word starting at s[i], or "" if the word
+// there is not one.
+func keywordAt(s string, i int) string {
+	for _, kw := range shellKeywords {
+		if !strings.HasPrefix(s[i:], kw) {
+			continue
+		}
+		if end := i + len(kw); end == len(s) || strings.IndexByte(" \t\n;&|)}", s[end]) >= 0 {
+			return kw
+		}
+	}
+	return ""
+}
+
 func splitBySemicolon(s string) []string {
 	var commands []string
 	var current strings.Builder
@@ -122,6 +174,56 @@ func splitBySemicolon(s string) []string {
 	return commands
 }
 
+// assignment is a NAME=value word at the start of a command, with value
+// still unexpanded.
+type assignment struct {
+	name, value string
+}
+
+// splitAssignments separates leading NAME=value words from the rest of a
+// command line. Values may be quoted and are returned as written.
+func splitAssignments(cmdLine string) ([]assignment, string) {
+	var assigns []assignment
+	i := 0
+	for {
+		for i < len(cmdLine) && (cmdLine[i] == ' ' || cmdLine[i] == '\t') {
+			i++
+		}
+		n := i
+		for n < len(cmdLine) && isAlnumOrUnderscore(cmdLine[n]) {
+			n++
+		}
+		if n == i || n >= len(cmdLine) || cmdLine[n] != '=' || cmdLine[i] >= '0' && cmdLine[i] <= '9' {
+			break
+		}
+		end := n + 1
+		inSingle, inDouble := false, false
+		depth := 0 // nesting of $(...) and ${...}
+	word:
+		for ; end < len(cmdLine); end++ {
+			switch ch := cmdLine[end]; {
+			case ch == '\\' && !inSingle && end+1 < len(cmdLine):
+				end++
+			case ch == '\'' && !inDouble:
+				inSingle = !inSingle
+			case ch == '"' && !inSingle:
+				inDouble = !inDouble
+			case inSingle:
+			case ch == '$' && end+1 < len(cmdLine) && (cmdLine[end+1] == '(' || cmdLine[end+1] == '{'):
+				depth++
+				end++
+			case (ch == ')' || ch == '}') && depth > 0:
+				depth--
+			case (ch == ' ' || ch == '\t') && !inDouble && depth == 0:
+				break word
+			}
+		}
+		assigns = append(assigns, assignment{name: cmdLine[i:n], value: cmdLine[n+1 : end]})
+		i = end
+	}
+	return assigns, strings.TrimSpace(cmdLine[i:])
+}
+
 type redirection struct {
 	path           string
 	append         bool
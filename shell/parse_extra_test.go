@@ -102,6 +102,47 @@ func TestSplitLogicalOps(t *testing.T) {
 				{cmd: " echo test", op: opNone},
 			},
 		},
+		{
+			name:  "semicolons",
+			input: "echo a; echo b && echo c;",
+			expected: []logicalSegment{
+				{cmd: "echo a", op: opSeq},
+				{cmd: " echo b ", op: opAnd},
+				{cmd: " echo c", op: opSeq},
+			},
+		},
+		{
+			name:  "nested and escaped operators",
+			input: "echo $(a; b) { c && d; } \\; && e",
+			expected: []logicalSegment{
+				{cmd: "echo $(a; b) { c && d; } \\; ", op: opAnd},
+				{cmd: " e", op: opNone},
+			},
+		},
+		{
+			name:  "here-document body",
+			input: "cat <<EOF && echo ok\na; b && c\nEOF",
+			expected: []logicalSegment{
+				{cmd: "cat <<EOF ", op: opAnd},
+				{cmd: " echo ok\na; b && c\nEOF", op: opNone},
+			},
+		},
+		{
+			name:  "if compound",
+			input: "if [ -f a ]; then if b; then c; fi; else d || e; fi && echo ok",
+			expected: []logicalSegment{
+				{cmd: "if [ -f a ]; then if b; then c; fi; else d || e; fi ", op: opAnd},
+				{cmd: " echo ok", op: opNone},
+			},
+		},
+		{
+			name:  "keywords as arguments",
+			input: "echo if; echo fi",
+			expected: []logicalSegment{
+				{cmd: "echo if", op: opSeq},
+				{cmd: " echo fi", op: opNone},
+			},
+		},
 		{
 			name:     "empty string",
 			input:    "",
@@ -463,3 +504,55 @@ func TestParseStderrToStdout(t *testing.T) {
 		})
 	}
 }
+
+func TestFindProcessSubst(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"diff <(ls /data) <(ls /backup)", "<(ls /data)"},
+		{"cat <(echo $(pwd))", "<(echo $(pwd))"},
+		{"echo '<(ls)' <(ls)", "<(ls)"},
+		{"cat < (ls)", ""},
+		{"echo a<(ls)", ""},
+		{"cat <(ls", ""},
+	}
+	for _, tt := range tests {
+		start, end, ok := findProcessSubst(tt.input)
+		got := ""
+		if ok {
+			got = tt.input[start:end]
+		}
+		if got != tt.want {
+			t.Errorf("findProcessSubst(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestSplitAssignments(t *testing.T) {
+	tests := []struct {
+		input   string
+		assigns []assignment
+		rest    string
+	}{
+		{"echo a=b", nil, "echo a=b"},
+		{"A=1", []assignment{{"A", "1"}}, ""},
+		{"A=1 B='x y' cmd arg", []assignment{{"A", "1"}, {"B", "'x y'"}}, "cmd arg"},
+		{`MSG="a b" N=$(echo c d) E=`, []assignment{{"MSG", `"a b"`}, {"N", "$(echo c d)"}, {"E", ""}}, ""},
+		{"X=${Y:-a b}", []assignment{{"X", "${Y:-a b}"}}, ""},
+		{"1A=x", nil, "1A=x"},
+		{"=x", nil, "=x"},
+	}
+	for _, tt := range tests {
+		assigns, rest := splitAssignments(tt.input)
+		if len(assigns) != len(tt.assigns) || rest != tt.rest {
+			t.Errorf("splitAssignments(%q) = %v, %q; want %v, %q", tt.input, assigns, rest, tt.assigns, tt.rest)
+			continue
+		}
+		for i := range assigns {
+			if assigns[i] != tt.assigns[i] {
+				t.Errorf("splitAssignments(%q)[%d] = %v, want %v", tt.input, i, assigns[i], tt.assigns[i])
+			}
+		}
+	}
+}
@@ -1,7 +1,10 @@
 package shell
 
 import (
+	"context"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestParseRedirection(t *testing.T) {
@@ -137,3 +140,66 @@ func TestParseRedirectionTrailingSpaceInFilename(t *testing.T) {
 		t.Errorf("cmdPart = %q, want %q", cmdPart, "cat << 'EOF'")
 	}
 }
+
+// FuzzShellParse feeds arbitrary command lines through the parser and then
+// through Execute against the mock VirtualOS. The parser must not panic or
+// hang on any input, however malformed.
+//
+//	go test ./shell -run '^$' -fuzz FuzzShellParse -fuzztime 1m
+func FuzzShellParse(f *testing.F) {
+	for _, seed := range []string{
+		"echo hello",
+		"cat /tmp/a.txt | grep foo | head -n 1 | wc -l",
+		"echo $(echo $(echo $(pwd)))",
+		"echo `echo inner` $(echo `pwd`)",
+		`echo "a 'b' c" 'd "e" f' "unterminated`,
+		"echo 'unterminated",
+		"echo \\ \\\\\\\\\\ \\$HOME \\'",
+		"mkdir /tmp/w && cd /tmp/w || echo failed; pwd",
+		"{ echo a; echo b; } | cat > /tmp/out.txt 2>&1",
+		"cat << EOF | cat\nline1\nline2\nEOF",
+		"cat <<'EOF' > /tmp/x\n$HOME\nEOF",
+		"A=1 B=${A:-2} echo ${B:?unset} ${C:=3} ${D:+x} ${#A} ${E[@]} ${E[-1]}",
+		"echo ${unclosed ${A:-${B:-${C}}}",
+		"if [ -f /tmp/a.txt ]; then echo yes; elif [ -d /tmp ]; then echo dir; else echo no; fi",
+		"if true; then",
+		"echo *.txt **/*.go /tmp/[a-z]?.txt [",
+		"diff <(cat /tmp/a.txt) <(echo b)",
+		"echo a > > b >> c 2> d &> e 2>&1 <",
+		"echo \x00 \x00\x00 | cat",
+		"echo " + strings.Repeat("x", 10000),
+		strings.Repeat("echo a | ", 100) + "cat",
+		strings.Repeat("$(", 50) + "echo deep" + strings.Repeat(")", 50),
+		";;; && || | |& ;",
+		"alias ll='ls -l'; ll; unalias ll",
+		"cat /tmp/a.txt | mapfile -t -d '' -n 2 A; echo ${A[@]}",
+		"read -r -p 'name: ' -t 0.01 NAME",
+	} {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, cmdLine string) {
+		for _, stmt := range scriptStatements(cmdLine) {
+			for _, seg := range splitLogicalOps(stmt) {
+				for _, part := range splitBySemicolon(seg.cmd) {
+					for _, stage := range splitPipe(part) {
+						_, rest := parseRedirection(stage)
+						splitAssignments(rest)
+						parseStderrToStdout(rest)
+						parseHereDoc(rest)
+						args, quoted := tokenizeWithQuoteInfo(rest)
+						if len(args) != len(quoted) {
+							t.Fatalf("tokenizeWithQuoteInfo(%q): %d args, %d quote flags", rest, len(args), len(quoted))
+						}
+						filterRedirectionArgsWithQuotes(args, quoted)
+					}
+				}
+			}
+		}
+
+		sh, v := setupTestShell(t)
+		v.files["/tmp/a.txt"] = &mockFile{content: []byte("foo\nbar\n")}
+		sh.SetTimeout(5 * time.Second)
+		sh.Execute(context.Background(), cmdLine)
+	})
+}
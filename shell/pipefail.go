@@ -0,0 +1,161 @@
+package shell
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/jackfish212/grasp/types"
+)
+
+// WithPipeFail starts the shell with pipefail mode enabled or disabled.
+// See SetPipefail.
+func WithPipeFail(enabled bool) ShellOption {
+	return func(s *Shell) { s.pipefail = enabled }
+}
+
+// SetPipefail enables or disables pipefail mode, equivalent to
+// "set -o pipefail". When enabled, a pipeline exits with the code of its
+// rightmost failing command instead of the code of its last command.
+func (s *Shell) SetPipefail(enabled bool) { s.pipefail = enabled }
+
+// Pipefail reports whether pipefail mode is enabled.
+func (s *Shell) Pipefail() bool { return s.pipefail }
+
+// shellOptions lists the options SetOption and "set -o" accept.
+var shellOptions = []string{"errexit", "failglob", "nounset", "nullglob", "pipefail"}
+
+// shortOptions maps the single-letter flags of set to option names.
+var shortOptions = map[rune]string{'e': "errexit", 'u': "nounset"}
+
+// option returns the flag behind the named shell option, or nil if there
+// is no such option.
+func (s *Shell) option(name string) *bool {
+	switch name {
+	case "errexit":
+		return &s.errexit
+	case "nounset":
+		return &s.nounset
+	case "failglob":
+		return &s.failglob
+	case "nullglob":
+		return &s.nullglob
+	case "pipefail":
+		return &s.pipefail
+	}
+	return nil
+}
+
+// SetOption sets a named shell option, as "set -o NAME" does from a
+// script. The options are "pipefail" (see SetPipefail), "errexit" (a script
+// stops at the first failing statement), "nounset" (expanding an unset
+// variable is an error), "nullglob" (a glob that matches nothing expands to
+// no words) and "failglob" (a glob that matches nothing fails the command);
+// value is "1", "on" or "true" to enable one and "0", "off" or "false" to
+// disable it.
+func (s *Shell) SetOption(name, value string) error {
+	opt := s.option(name)
+	if opt == nil {
+		return fmt.Errorf("%w: shell option %q", types.ErrNotSupported, name)
+	}
+	switch strings.ToLower(value) {
+	case "1", "on", "true":
+		*opt = true
+	case "0", "off", "false":
+		*opt = false
+	default:
+		return fmt.Errorf("%w: invalid value %q for shell option %s", types.ErrNotSupported, value, name)
+	}
+	return nil
+}
+
+// cmdSet implements the subset of set used to toggle shell options:
+// "-o NAME" and "+o NAME", the flags -e (errexit) and -u (nounset), and
+// combinations of them such as "-eu" or "-euo pipefail". A "+" turns
+// options off. With no arguments, or just -o, it lists the options.
+func (s *Shell) cmdSet(args []string) *ExecResult {
+	if len(args) == 0 || (len(args) == 1 && args[0] == "-o") {
+		var b strings.Builder
+		for _, name := range shellOptions {
+			state := "off"
+			if *s.option(name) {
+				state = "on"
+			}
+			b.WriteString(name + "\t" + state + "\n")
+		}
+		return stdoutResult(b.String())
+	}
+	const usage = "set: usage: set [-eu] [-o option] [+eu] [+o option]\n"
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if len(arg) < 2 || arg[0] != '-' && arg[0] != '+' {
+			return stderrResult(usage, 2)
+		}
+		for _, c := range arg[1:] {
+			name, ok := shortOptions[c]
+			if c == 'o' {
+				if i+1 >= len(args) {
+					return stderrResult(usage, 2)
+				}
+				i++
+				name, ok = args[i], true
+			}
+			if !ok {
+				return stderrResult(fmt.Sprintf("set: %c%c: invalid option\n%s", arg[0], c, usage), 2)
+			}
+			opt := s.option(name)
+			if opt == nil {
+				return stderrResult("set: "+name+": invalid option name\n", 2)
+			}
+			*opt = arg[0] == '-'
+		}
+	}
+	return &ExecResult{}
+}
+
+// setPipeStatus records the exit codes of the last pipeline in $PIPESTATUS
+// and returns the pipeline's exit code.
+func (s *Shell) setPipeStatus(codes []int) int {
+	strs := make([]string, len(codes))
+	for i, c := range codes {
+		strs[i] = strconv.Itoa(c)
+	}
+	s.Env.Set("PIPESTATUS", strings.Join(strs, " "))
+
+	last := codes[len(codes)-1]
+	if !s.pipefail {
+		return last
+	}
+	for i := len(codes) - 1; i >= 0; i-- {
+		if codes[i] != 0 {
+			return codes[i]
+		}
+	}
+	return 0
+}
+
+// stageReader remembers whether a pipeline stage's output stream failed,
+// which is how streaming commands report errors after they have started.
+// A stream ending in an ExitError has delivered all its output, so the
+// next stage sees io.EOF, as it would reading a pipe; only the status and
+// message are kept for the pipeline's result.
+type stageReader struct {
+	io.ReadCloser
+	err error
+}
+
+func (r *stageReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	if err != nil && !errors.Is(err, io.EOF) {
+		if r.err == nil {
+			r.err = err
+		}
+		var exitErr *types.ExitError
+		if errors.As(err, &exitErr) {
+			err = io.EOF
+		}
+	}
+	return n, err
+}
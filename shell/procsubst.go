@@ -0,0 +1,89 @@
+package shell
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync/atomic"
+)
+
+// procSubstDir is where process substitution output is staged.
+const procSubstDir = "/tmp"
+
+var procSubstSeq atomic.Int64
+
+// remover is implemented by VirtualOS instances that can delete files.
+type remover interface {
+	Remove(ctx context.Context, path string) error
+}
+
+// findProcessSubst returns the bounds of the first <(...) in cmdLine that is
+// outside quotes and starts a word. end is the index just past the closing
+// paren. ok is false when there is none.
+func findProcessSubst(cmdLine string) (start, end int, ok bool) {
+	inSingle, inDouble := false, false
+	for i := 0; i < len(cmdLine); i++ {
+		ch := cmdLine[i]
+		switch {
+		case ch == '\'' && !inDouble:
+			inSingle = !inSingle
+			continue
+		case ch == '"' && !inSingle:
+			inDouble = !inDouble
+			continue
+		}
+		if inSingle || inDouble || ch != '<' || i+1 >= len(cmdLine) || cmdLine[i+1] != '(' {
+			continue
+		}
+		if i > 0 && cmdLine[i-1] != ' ' && cmdLine[i-1] != '\t' {
+			continue
+		}
+		depth := 1
+		for j := i + 2; j < len(cmdLine); j++ {
+			switch cmdLine[j] {
+			case '(':
+				depth++
+			case ')':
+				depth--
+			}
+			if depth == 0 {
+				return i, j + 1, true
+			}
+		}
+		return 0, 0, false
+	}
+	return 0, 0, false
+}
+
+// expandProcessSubstitution replaces each <(CMD) in cmdLine with the path of
+// a temporary file holding CMD's output. The returned cleanup removes those
+// files and must be called once the outer command has completed.
+func (s *Shell) expandProcessSubstitution(ctx context.Context, cmdLine string) (string, func(), error) {
+	var temps []string
+	cleanup := func() {
+		rm, ok := s.vos.(remover)
+		if !ok {
+			return
+		}
+		for _, p := range temps {
+			_ = rm.Remove(ctx, p)
+		}
+	}
+
+	for {
+		start, end, ok := findProcessSubst(cmdLine)
+		if !ok {
+			return cmdLine, cleanup, nil
+		}
+		inner := cmdLine[start+2 : end-1]
+		result := s.execute(ctx, strings.TrimSpace(inner))
+
+		p := fmt.Sprintf("%s/.psub-%d", procSubstDir, procSubstSeq.Add(1))
+		if err := s.vos.Write(ctx, p, strings.NewReader(result.Output)); err != nil {
+			cleanup()
+			return "", nil, fmt.Errorf("process substitution: %w", err)
+		}
+		temps = append(temps, p)
+		cmdLine = cmdLine[:start] + p + cmdLine[end:]
+	}
+}
@@ -54,6 +54,9 @@ func (s *Shell) loadProfileFile(ctx context.Context, path string) {
 		if varName != "" {
 			slog.Debug("shell: loaded profile variable", "varName", varName, "varValue", varValue)
 			s.Env.Set(varName, varValue)
+			if strings.HasPrefix(line, "export ") {
+				s.Env.Export(varName)
+			}
 		}
 	}
 }
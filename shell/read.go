@@ -0,0 +1,261 @@
+package shell
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// InputProvider supplies interactive input to the read builtin when it is
+// not given stdin through a pipe or here-document. prompt is the text given
+// with read -p, or empty. It returns one line without its newline, or
+// io.EOF when there is no more input.
+type InputProvider func(ctx context.Context, prompt string) (string, error)
+
+// SetInputProvider sets where read takes input from when it has no stdin,
+// such as an agent answering prompts or a test feeding fixed answers. A nil
+// provider makes such reads fail.
+func (s *Shell) SetInputProvider(p InputProvider) {
+	s.input = p
+}
+
+// readTimeoutCode is the exit status of read -t when no input arrives in
+// time, as in bash (128 + SIGALRM).
+const readTimeoutCode = 142
+
+// readOpts holds the parsed options of the read builtin.
+type readOpts struct {
+	prompt  string
+	timeout time.Duration
+	raw     bool
+	names   []string
+}
+
+// parseReadArgs parses "read [-r] [-p PROMPT] [-t TIMEOUT] [NAME...]". ok
+// is false when the arguments are not for the read builtin at all: the
+// file reader on PATH shares the name, so read is only taken as the builtin
+// when every operand is a variable name, or when there are no operands but
+// builtin options are given.
+func parseReadArgs(args []string) (opts readOpts, ok bool, err error) {
+	flagged := false
+	i := 0
+	for ; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			i++
+			break
+		}
+		if arg != "-r" && arg != "-p" && arg != "-t" {
+			break
+		}
+		flagged = true
+		if arg == "-r" {
+			opts.raw = true
+			continue
+		}
+		if i+1 >= len(args) {
+			return opts, true, fmt.Errorf("%s: option requires an argument", arg)
+		}
+		i++
+		if arg == "-p" {
+			opts.prompt = args[i]
+			continue
+		}
+		if opts.timeout, err = parseReadTimeout(args[i]); err != nil {
+			return opts, true, err
+		}
+	}
+	opts.names = args[i:]
+	for _, name := range opts.names {
+		if !isName(name) {
+			return opts, false, nil
+		}
+	}
+	return opts, flagged || len(opts.names) > 0, nil
+}
+
+// parseReadTimeout accepts seconds, as in "-t 5" or "-t 0.5", or a Go
+// duration such as "500ms".
+func parseReadTimeout(s string) (time.Duration, error) {
+	if secs, err := strconv.ParseFloat(s, 64); err == nil && secs >= 0 {
+		return time.Duration(secs * float64(time.Second)), nil
+	}
+	if d, err := time.ParseDuration(s); err == nil && d >= 0 {
+		return d, nil
+	}
+	return 0, fmt.Errorf("%s: invalid timeout specification", s)
+}
+
+// runRead runs the read builtin, reporting false when args are meant for
+// the read command on PATH instead.
+func (s *Shell) runRead(ctx context.Context, args []string, stdin io.Reader) (*ExecResult, bool) {
+	opts, ok, err := parseReadArgs(args)
+	if !ok {
+		return nil, false
+	}
+	if err != nil {
+		return stderrResult("read: "+err.Error()+"\nread: usage: read [-r] [-p prompt] [-t timeout] [name ...]\n", 2), true
+	}
+	return s.cmdRead(ctx, opts, stdin), true
+}
+
+// cmdRead reads one line from stdin, or from the input provider when there
+// is no stdin, and assigns it to shell variables. The line is split into
+// words on blanks; each name takes one word and the last takes the rest of
+// the line. With no names the whole line goes to REPLY. Unless -r is given,
+// a backslash quotes the next character and a trailing backslash continues
+// the line.
+func (s *Shell) cmdRead(ctx context.Context, opts readOpts, stdin io.Reader) *ExecResult {
+	res := &ExecResult{}
+	if opts.prompt != "" {
+		res.appendOutput(stderrResult(opts.prompt, 0))
+	}
+
+	if opts.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.timeout)
+		defer cancel()
+	}
+
+	line, err := s.readInput(ctx, opts, stdin)
+	if errors.Is(err, context.DeadlineExceeded) {
+		res.appendOutput(stderrResult("read: timed out waiting for input\n", 0))
+		res.Code = readTimeoutCode
+		return res
+	}
+	if err != nil && !errors.Is(err, io.EOF) {
+		res.appendOutput(stderrResult("read: "+err.Error()+"\n", 0))
+		res.Code = 1
+		return res
+	}
+
+	names := opts.names
+	if len(names) == 0 {
+		names = []string{"REPLY"}
+	}
+	fields := splitReadFields(line, len(names), opts.raw)
+	for i, name := range names {
+		value := ""
+		if i < len(fields) {
+			value = fields[i]
+		}
+		s.Env.Set(name, value)
+	}
+	if err != nil {
+		res.Code = 1 // end of input, as in bash
+	}
+	return res
+}
+
+// readInput returns the next logical line, joining continuation lines
+// unless opts.raw is set. The read happens in its own goroutine so that a
+// timeout or cancellation returns promptly; a stdin reader that never
+// returns leaves that goroutine blocked until it does.
+func (s *Shell) readInput(ctx context.Context, opts readOpts, stdin io.Reader) (string, error) {
+	readLine := func() (string, error) {
+		if stdin != nil {
+			return readStdinLine(stdin)
+		}
+		if s.input == nil {
+			return "", errors.New("no input available")
+		}
+		return s.input(ctx, opts.prompt)
+	}
+
+	type lineResult struct {
+		line string
+		err  error
+	}
+	done := make(chan lineResult, 1)
+	go func() {
+		var buf strings.Builder
+		for {
+			line, err := readLine()
+			if !opts.raw && err == nil && trailingBackslashes(line)%2 == 1 {
+				buf.WriteString(line[:len(line)-1])
+				continue
+			}
+			buf.WriteString(line)
+			done <- lineResult{buf.String(), err}
+			return
+		}
+	}()
+
+	select {
+	case r := <-done:
+		return r.line, r.err
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
+
+// readStdinLine reads up to and including the next newline, one byte at a
+// time so that input after the line stays unread. It returns io.EOF,
+// together with any partial line, when the input ends before a newline.
+func readStdinLine(r io.Reader) (string, error) {
+	var line []byte
+	b := make([]byte, 1)
+	for {
+		n, err := r.Read(b)
+		if n > 0 {
+			if b[0] == '\n' {
+				return string(line), nil
+			}
+			line = append(line, b[0])
+		}
+		if err != nil {
+			return string(line), err
+		}
+	}
+}
+
+func trailingBackslashes(s string) int {
+	n := 0
+	for n < len(s) && s[len(s)-1-n] == '\\' {
+		n++
+	}
+	return n
+}
+
+// splitReadFields splits line into at most n words separated by blanks.
+// The last word is the rest of the line with surrounding blanks removed.
+// Unless raw, a backslash quotes the next character, so an escaped blank
+// does not separate words, and the backslash itself is dropped.
+func splitReadFields(line string, n int, raw bool) []string {
+	var fields []string
+	var word strings.Builder
+	pending := "" // blanks seen inside the last word, kept if more follows
+	inWord := false
+	for i := 0; i < len(line); i++ {
+		ch := line[i]
+		if ch == ' ' || ch == '\t' {
+			if !inWord {
+				continue
+			}
+			if len(fields) < n-1 {
+				fields = append(fields, word.String())
+				word.Reset()
+				inWord = false
+				continue
+			}
+			pending += string(ch)
+			continue
+		}
+		if ch == '\\' && !raw && i+1 < len(line) {
+			i++
+			ch = line[i]
+		}
+		word.WriteString(pending)
+		pending = ""
+		word.WriteByte(ch)
+		inWord = true
+	}
+	if inWord {
+		fields = append(fields, word.String())
+	}
+	return fields
+}
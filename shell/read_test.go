@@ -0,0 +1,59 @@
+package shell
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitReadFields(t *testing.T) {
+	tests := []struct {
+		name string
+		line string
+		n    int
+		raw  bool
+		want []string
+	}{
+		{"single name keeps line", "  hello  big world  ", 1, false, []string{"hello  big world"}},
+		{"one word each", "a b c", 3, false, []string{"a", "b", "c"}},
+		{"last takes rest", "a b c d", 2, false, []string{"a", "b c d"}},
+		{"fewer words", "a", 3, false, []string{"a"}},
+		{"tabs", "a\tb", 2, false, []string{"a", "b"}},
+		{"escaped blank", `a\ b c`, 2, false, []string{"a b", "c"}},
+		{"escaped backslash", `a\\b`, 1, false, []string{`a\b`}},
+		{"raw", `a\ b c`, 2, true, []string{`a\`, "b c"}},
+		{"empty", "", 1, false, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := splitReadFields(tt.line, tt.n, tt.raw); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("splitReadFields(%q, %d, %v) = %q, want %q", tt.line, tt.n, tt.raw, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseReadArgs(t *testing.T) {
+	tests := []struct {
+		args    []string
+		builtin bool
+		wantErr bool
+	}{
+		{[]string{"NAME"}, true, false},
+		{[]string{"A", "B"}, true, false},
+		{[]string{"-p", "Name: ", "NAME"}, true, false},
+		{[]string{"-r"}, true, false},
+		{[]string{"-t", "0.5", "X"}, true, false},
+		{[]string{"-t", "soon", "X"}, true, true},
+		{[]string{"-p"}, true, true},
+		{nil, false, false},
+		{[]string{"notes.txt"}, false, false},
+		{[]string{"/etc/profile"}, false, false},
+		{[]string{"--raw", "a.txt"}, false, false},
+	}
+	for _, tt := range tests {
+		_, ok, err := parseReadArgs(tt.args)
+		if ok != tt.builtin || (err != nil) != tt.wantErr {
+			t.Errorf("parseReadArgs(%q) = %v, %v; want builtin %v, error %v", tt.args, ok, err, tt.builtin, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,201 @@
+package shell
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ExecuteFile runs the file at path as a script, as ExecuteScript does. $0
+// is set to the script path while it runs.
+//
+// The result holds the combined output and the exit code of the last command
+// run (the failing one when stopped by set -e).
+func (s *Shell) ExecuteFile(ctx context.Context, path string) *ExecResult {
+	abs := s.absPath(path)
+	rc, err := s.vos.Open(ctx, abs)
+	if err != nil {
+		return stderrResult(fmt.Sprintf("%s: %v\n", path, err), 1)
+	}
+	data, err := io.ReadAll(rc)
+	_ = rc.Close()
+	if err != nil {
+		return stderrResult(fmt.Sprintf("%s: %v\n", path, err), 1)
+	}
+
+	prev, hadPrev := s.Env.All()["0"]
+	s.Env.Set("0", abs)
+	defer func() {
+		if hadPrev {
+			s.Env.Set("0", prev)
+		} else {
+			s.Env.Unset("0")
+		}
+	}()
+
+	res := &ExecResult{}
+	s.runScript(ctx, string(data), func(result *ExecResult) {
+		res.appendOutput(result)
+		res.Code = result.Code
+	})
+	return res
+}
+
+// ExecuteScript runs a multi-line script in this shell, one statement at a
+// time, so that cd, variable assignments and aliases carry over from one
+// statement to the next. Each line is a statement; a line ending in "\"
+// continues on the next, a here-document runs to its delimiter line, and an
+// if compound runs to its closing fi. Blank lines and lines starting with
+// "#" are skipped. "set -e" (or any set that enables errexit, such as
+// "set -euo pipefail") makes the script stop at the first failing
+// statement and "set +e" turns that off again. The option belongs to the
+// shell, so it stays in effect after the script, as in a sourced file.
+//
+// The result holds one ExecResult per statement run, in order; when set -e
+// stops the script, the failing statement is the last.
+func (s *Shell) ExecuteScript(ctx context.Context, script string) []ExecResult {
+	var results []ExecResult
+	s.runScript(ctx, script, func(result *ExecResult) {
+		results = append(results, *result)
+	})
+	return results
+}
+
+// runScript executes each statement of script, passing every result to
+// report.
+func (s *Shell) runScript(ctx context.Context, script string, report func(*ExecResult)) {
+	for _, stmt := range scriptStatements(script) {
+		result := s.Execute(ctx, stmt)
+		report(result)
+		if s.errexit && result.Code != 0 || ctx.Err() != nil {
+			return
+		}
+	}
+}
+
+// scriptStatements splits a script into statements: one per line, with a
+// line that ends in a backslash joined to the next. Blank lines and
+// comments are dropped. A here-document's lines stay with the command
+// that reads them, up to the delimiter line, as Execute expects; and the
+// lines of an if compound, up to the fi that closes it, are joined with
+// ";" into one statement, since Execute runs an if written on one line.
+func scriptStatements(script string) []string {
+	var (
+		stmts   []string
+		stmt    strings.Builder
+		cont    strings.Builder // a line being continued with backslashes
+		heredoc string          // delimiter of the here-document being read
+		ifDepth int             // if compounds opened and not yet closed
+	)
+	emit := func() {
+		if s := strings.TrimSpace(stmt.String()); s != "" {
+			stmts = append(stmts, s)
+		}
+		stmt.Reset()
+	}
+	for _, line := range strings.Split(script, "\n") {
+		line = strings.TrimRight(line, "\r")
+		if heredoc != "" {
+			stmt.WriteString("\n" + line)
+			if strings.TrimSpace(line) == heredoc {
+				heredoc = ""
+				if ifDepth == 0 {
+					emit()
+				}
+			}
+			continue
+		}
+		if strings.HasSuffix(line, "\\") {
+			cont.WriteString(line[:len(line)-1])
+			continue
+		}
+		cont.WriteString(line)
+		line = strings.TrimSpace(cont.String())
+		cont.Reset()
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if prev := strings.TrimSpace(stmt.String()); prev != "" {
+			if strings.HasSuffix(prev, ";") || strings.HasSuffix(prev, "&&") || strings.HasSuffix(prev, "||") || strings.HasSuffix(prev, "|") {
+				stmt.WriteString(" ")
+			} else {
+				stmt.WriteString("; ")
+			}
+		}
+		stmt.WriteString(line)
+		ifDepth = max(ifDepth+ifDepthChange(line), 0)
+		if hd, _, _ := parseHereDoc(line); hd != nil {
+			heredoc = hd.delimiter
+			continue
+		}
+		if ifDepth == 0 {
+			emit()
+		}
+	}
+	if s := strings.TrimSpace(cont.String()); s != "" && !strings.HasPrefix(s, "#") {
+		stmt.WriteString(s)
+	}
+	emit()
+	return stmts
+}
+
+// ifDepthChange returns the number of if compounds line opens less the
+// number it closes, counting keywords in command position only.
+func ifDepthChange(line string) int {
+	n := 0
+	inSingle, inDouble, cmdPos := false, false, true
+	for i := 0; i < len(line); i++ {
+		ch := line[i]
+		quoted := inSingle || inDouble
+		if !quoted && cmdPos {
+			if kw := keywordAt(line, i); kw != "" {
+				switch kw {
+				case "if":
+					n++
+				case "fi":
+					n--
+				}
+				i += len(kw) - 1
+				cmdPos = kw != "fi"
+				continue
+			}
+		}
+		switch {
+		case ch == '\\' && !inSingle:
+			i++
+			continue
+		case ch == '\'' && !inDouble:
+			inSingle = !inSingle
+		case ch == '"' && !inSingle:
+			inDouble = !inDouble
+		case ch == '#' && !quoted && (i == 0 || line[i-1] == ' ' || line[i-1] == '\t'):
+			return n
+		}
+		if !quoted && ch != ' ' && ch != '\t' {
+			cmdPos = strings.IndexByte(";&|({", ch) >= 0
+		}
+	}
+	return n
+}
+
+// maxSourceDepth bounds nested source commands, so that a script which
+// sources itself fails instead of recursing forever.
+const maxSourceDepth = 64
+
+// cmdSource runs a script in the current shell with ExecuteFile, so the
+// variables, aliases and working directory it sets outlast it. name is
+// "source" or ".". Arguments after the file name are ignored; the shell
+// has no positional parameters.
+func (s *Shell) cmdSource(ctx context.Context, name string, args []string) *ExecResult {
+	if len(args) == 0 {
+		return stderrResult(name+": filename argument required\n", 2)
+	}
+	if s.sourcing >= maxSourceDepth {
+		return stderrResult(fmt.Sprintf("%s: %s: maximum nesting depth exceeded\n", name, args[0]), 1)
+	}
+	s.sourcing++
+	defer func() { s.sourcing-- }()
+	return s.ExecuteFile(ctx, args[0])
+}
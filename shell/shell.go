@@ -2,10 +2,14 @@ package shell
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"path"
 	"strings"
+	"time"
+
+	"github.com/jackfish212/grasp/types"
 )
 
 type hereDocInfo struct {
@@ -18,17 +22,37 @@ type hereDocInfo struct {
 // cmdLine is the raw command string; result is the execution outcome.
 type ExecHook func(cmdLine string, result *ExecResult)
 
+// BeforeExecHook is called before every top-level command execution, with
+// the raw command string. Returning an error rejects the command.
+type BeforeExecHook func(ctx context.Context, cmdLine string) error
+
 // Shell provides a command-line interface to grasp operations.
 type Shell struct {
-	vos         VirtualOS
-	Env         *ShellEnv
-	history     []string
-	savedOffset int
-	execHooks   []ExecHook
+	vos       VirtualOS
+	Env       *ShellEnv
+	history   historyRing
+	unsaved   int // newest history entries not yet in ~/.bash_history
+	execHooks []ExecHook
+	preHooks  []BeforeExecHook
+	pipefail  bool
+	errexit   bool // scripts stop at the first failing statement
+	nounset   bool // expanding an unset variable is an error
+	nullglob  bool // unmatched globs expand to nothing
+	failglob  bool // unmatched globs fail the command
+	aliases   map[string]string
+	sourcing  int // depth of nested source commands
+	input     InputProvider
+
+	outputLimit int // bytes of output kept per command; 0 is unlimited
+	truncation  TruncationStrategy
+	timeout     time.Duration // per command; 0 is none
 }
 
+// ShellOption configures a Shell.
+type ShellOption func(*Shell)
+
 // NewShell creates a Shell bound to a VirtualOS instance.
-func NewShell(v VirtualOS, user string) *Shell {
+func NewShell(v VirtualOS, user string, opts ...ShellOption) *Shell {
 	env := NewShellEnv()
 	env.Set("USER", user)
 	if user == "root" {
@@ -39,7 +63,10 @@ func NewShell(v VirtualOS, user string) *Shell {
 	env.Set("PWD", env.Get("HOME"))
 	home := env.Get("HOME")
 	env.Set("PATH", env.Get("PATH")+":"+home+"/.bin")
-	sh := &Shell{vos: v, Env: env, history: []string{}}
+	sh := &Shell{vos: v, Env: env}
+	for _, opt := range opts {
+		opt(sh)
+	}
 	sh.loadProfileEnv()
 	sh.loadHistory()
 	return sh
@@ -51,6 +78,15 @@ func (s *Shell) OnExec(hook ExecHook) {
 	s.execHooks = append(s.execHooks, hook)
 }
 
+// OnBeforeExec registers a hook that is called before every top-level
+// Execute call, for example to enforce a command allowlist or to log intent.
+// Hooks run in registration order and all must pass: the first error stops
+// the command from running, and it fails with exit code 1 and the error
+// message as its output. Hooks registered with OnExec still see the result.
+func (s *Shell) OnBeforeExec(hook BeforeExecHook) {
+	s.preHooks = append(s.preHooks, hook)
+}
+
 // Cwd returns the current working directory.
 func (s *Shell) Cwd() string {
 	return s.Env.Get("PWD")
@@ -86,10 +122,32 @@ func (s *Shell) resolveCommand(ctx context.Context, cmd string) (string, error)
 	return "", fmt.Errorf("command not found: %s", cmd)
 }
 
-// ExecResult holds the output of a shell command.
+// ExecResult holds the output of a shell command. Stdout and Stderr hold the
+// two streams separately; Output holds both, in the order they were produced.
+// Truncated is set when output was cut to the limit set by WithOutputLimit.
 type ExecResult struct {
-	Output string
-	Code   int
+	Output    string
+	Stdout    string
+	Stderr    string
+	Code      int
+	Truncated bool
+}
+
+func stdoutResult(out string) *ExecResult {
+	return &ExecResult{Output: out, Stdout: out}
+}
+
+func stderrResult(msg string, code int) *ExecResult {
+	return &ExecResult{Output: msg, Stderr: msg, Code: code}
+}
+
+// appendOutput adds r's output streams after those already in res. The exit
+// code is left alone.
+func (res *ExecResult) appendOutput(r *ExecResult) {
+	res.Output += r.Output
+	res.Stdout += r.Stdout
+	res.Stderr += r.Stderr
+	res.Truncated = res.Truncated || r.Truncated
 }
 
 func parseHereDoc(cmdLine string) (*hereDocInfo, string, string) {
@@ -191,23 +249,52 @@ func (s *Shell) Execute(ctx context.Context, cmdLine string) *ExecResult {
 	}
 
 	raw := cmdLine
-	result := s.execute(ctx, cmdLine)
+	// Carry the shell identity so filesystem calls made directly by the
+	// shell (redirections, cd, globbing) are attributed to its user.
+	ctx = WithEnv(ctx, s.execEnv())
+	histNum := s.addToHistory(cmdLine)
+
+	result := s.checkBeforeExec(ctx, raw)
+	if result == nil {
+		result = s.withTimeout(ctx, func(ctx context.Context) *ExecResult {
+			expanded, cleanup, err := s.expandProcessSubstitution(ctx, s.expandAliases(cmdLine))
+			if err != nil {
+				return stderrResult(err.Error()+"\n", 1)
+			}
+			defer cleanup()
+			return s.execute(ctx, expanded)
+		})
+	}
+	s.limitOutput(result)
+	s.setHistoryExitCode(histNum, result.Code)
 	for _, hook := range s.execHooks {
 		hook(raw, result)
 	}
 	return result
 }
 
+// checkBeforeExec runs the OnBeforeExec hooks, returning the failed result
+// of a rejected command or nil if all hooks passed.
+func (s *Shell) checkBeforeExec(ctx context.Context, cmdLine string) *ExecResult {
+	for _, hook := range s.preHooks {
+		if err := hook(ctx, cmdLine); err != nil {
+			return stderrResult(err.Error()+"\n", 1)
+		}
+	}
+	return nil
+}
+
 func (s *Shell) execute(ctx context.Context, cmdLine string) *ExecResult {
-	s.addToHistory(cmdLine)
+	if segs := splitLogicalOps(cmdLine); len(segs) > 1 || len(segs) == 1 && segs[0].op != opNone {
+		return s.executeLogicalOps(ctx, segs)
+	}
 
-	if strings.HasPrefix(cmdLine, "{") && strings.Contains(cmdLine, "}") {
-		return s.executeCommandGroup(ctx, cmdLine)
+	if trimmed := strings.TrimSpace(cmdLine); keywordAt(trimmed, 0) == "if" {
+		return s.executeIf(ctx, trimmed)
 	}
 
-	logicalSegs := splitLogicalOps(cmdLine)
-	if len(logicalSegs) > 1 {
-		return s.executeLogicalOps(ctx, logicalSegs)
+	if strings.HasPrefix(cmdLine, "{") && strings.Contains(cmdLine, "}") {
+		return s.executeCommandGroup(ctx, cmdLine)
 	}
 
 	var hereDoc *hereDocInfo
@@ -218,10 +305,14 @@ func (s *Shell) execute(ctx context.Context, cmdLine string) *ExecResult {
 	if hereDoc != nil {
 		content, _, err := extractHereDocContent(originalCmdLine, hereDoc.delimiter)
 		if err != "" {
-			return &ExecResult{Output: err + "\n", Code: 1}
+			return stderrResult(err+"\n", 1)
 		}
 		if !hereDoc.quoted {
-			content = s.expandEnvVars(content)
+			expanded, err := s.expandEnvVars(content)
+			if err != nil {
+				return stderrResult(err.Error()+"\n", 1)
+			}
+			content = expanded
 		}
 		hereDoc.content = content
 		if content != "" && !strings.HasSuffix(content, "\n") {
@@ -243,7 +334,9 @@ func (s *Shell) execute(ctx context.Context, cmdLine string) *ExecResult {
 			cmdPart, redir.stderrToStdout = parseStderrToStdout(cmdPart)
 		}
 		stdin := hereDocStdin
-		return s.executeSingle(ctx, cmdPart, stdin, redir)
+		result := s.executeSingle(ctx, cmdPart, stdin, redir)
+		s.setPipeStatus([]int{result.Code})
+		return result
 	}
 
 	var pipeInput io.Reader = hereDocStdin
@@ -254,6 +347,11 @@ func (s *Shell) execute(ctx context.Context, cmdLine string) *ExecResult {
 		}
 	}()
 
+	// Like stderr in bash, error output from earlier stages is shown ahead
+	// of the pipeline's output while the pipeline carries on.
+	var errOut strings.Builder
+	codes := make([]int, len(pipeSegs))
+	stages := make([]*stageReader, len(pipeSegs))
 	for i, seg := range pipeSegs {
 		seg = strings.TrimSpace(seg)
 		if seg == "" {
@@ -268,15 +366,33 @@ func (s *Shell) execute(ctx context.Context, cmdLine string) *ExecResult {
 
 		isLast := i == len(pipeSegs)-1
 		if isLast {
-			return s.executeSingle(ctx, cmdPart, pipeInput, redir)
+			result := s.executeSingle(ctx, cmdPart, pipeInput, redir)
+			codes[i] = result.Code
+			for j, st := range stages {
+				if st != nil && st.err != nil && codes[j] == 0 {
+					codes[j] = types.ExitCode(st.err)
+					var exitErr *types.ExitError
+					if !errors.As(st.err, &exitErr) || exitErr.Err != nil {
+						errOut.WriteString(st.err.Error() + "\n")
+					}
+				}
+			}
+			result.Output = errOut.String() + result.Output
+			result.Stderr = errOut.String() + result.Stderr
+			result.Code = s.setPipeStatus(codes)
+			return result
 		}
 
 		rc, errResult := s.executeSingleStream(ctx, cmdPart, pipeInput)
 		if errResult != nil {
-			return errResult
+			codes[i] = errResult.Code
+			errOut.WriteString(errResult.Output)
+			pipeInput = strings.NewReader("")
+			continue
 		}
 		closers = append(closers, rc)
-		pipeInput = rc
+		stages[i] = &stageReader{ReadCloser: rc}
+		pipeInput = stages[i]
 	}
 
 	return &ExecResult{}
@@ -457,6 +457,149 @@ func TestShellIntegrationGlobStar(t *testing.T) {
 	}
 }
 
+func TestShellIntegrationGlobMultiLevel(t *testing.T) {
+	sh, v := setupTestShell(t)
+	ctx := context.Background()
+
+	v.files["/home/tester/src/a/x.go"] = &mockFile{perm: types.PermRW}
+	v.files["/home/tester/src/b/y.go"] = &mockFile{perm: types.PermRW}
+	v.files["/home/tester/src/b/z.md"] = &mockFile{perm: types.PermRW}
+	v.dirs["/home/tester/src"] = true
+	v.dirs["/home/tester/src/a"] = true
+	v.dirs["/home/tester/src/b"] = true
+
+	result := sh.Execute(ctx, "echo src/*/*.go")
+	if got := strings.TrimSpace(result.Output); got != "src/a/x.go src/b/y.go" {
+		t.Errorf("src/*/*.go = %q, want %q", got, "src/a/x.go src/b/y.go")
+	}
+}
+
+func TestShellIntegrationGlobRecursive(t *testing.T) {
+	sh, v := setupTestShell(t)
+	ctx := context.Background()
+
+	v.files["/home/tester/top.log"] = &mockFile{perm: types.PermRW}
+	v.files["/home/tester/docs/a.txt"] = &mockFile{perm: types.PermRW}
+	v.files["/home/tester/docs/deep/b.txt"] = &mockFile{perm: types.PermRW}
+	v.files["/home/tester/docs/deep/c.log"] = &mockFile{perm: types.PermRW}
+	v.dirs["/home/tester/docs"] = true
+	v.dirs["/home/tester/docs/deep"] = true
+
+	tests := []struct {
+		cmd, want string
+	}{
+		{"echo **/*.log", "docs/deep/c.log top.log"},
+		{"echo docs/**/*.txt", "docs/a.txt docs/deep/b.txt"},
+		{"echo /home/tester/**/b.txt", "/home/tester/docs/deep/b.txt"},
+		{"echo docs/**", "docs/a.txt docs/deep docs/deep/b.txt docs/deep/c.log"},
+	}
+	for _, tt := range tests {
+		result := sh.Execute(ctx, tt.cmd)
+		if got := strings.TrimSpace(result.Output); got != tt.want {
+			t.Errorf("%s = %q, want %q", tt.cmd, got, tt.want)
+		}
+	}
+}
+
+func TestShellIntegrationGlobNoMatch(t *testing.T) {
+	sh, _ := setupTestShell(t)
+	ctx := context.Background()
+
+	result := sh.Execute(ctx, "echo *.zzz end")
+	if got := strings.TrimSpace(result.Output); got != "*.zzz end" {
+		t.Errorf("unmatched glob = %q, want it kept as written", got)
+	}
+
+	if err := sh.SetOption("nullglob", "1"); err != nil {
+		t.Fatal(err)
+	}
+	result = sh.Execute(ctx, "echo *.zzz end")
+	if got := strings.TrimSpace(result.Output); got != "end" {
+		t.Errorf("unmatched glob with nullglob = %q, want %q", got, "end")
+	}
+
+	sh.Execute(ctx, "set -o failglob")
+	result = sh.Execute(ctx, "echo *.zzz end")
+	if result.Code != 1 || !strings.Contains(result.Output, "no matches found: *.zzz") {
+		t.Errorf("unmatched glob with failglob = %q (code %d), want error", result.Output, result.Code)
+	}
+
+	if err := sh.SetOption("globstar", "1"); err == nil {
+		t.Error("SetOption(globstar) should fail")
+	}
+}
+
+func TestShellIntegrationSetFlags(t *testing.T) {
+	sh, _ := setupTestShell(t)
+	ctx := context.Background()
+
+	if r := sh.Execute(ctx, "set -euo pipefail"); r.Code != 0 {
+		t.Fatalf("set -euo pipefail = %q (code %d)", r.Output, r.Code)
+	}
+	if !sh.errexit || !sh.nounset || !sh.pipefail {
+		t.Errorf("after set -euo pipefail: errexit %v, nounset %v, pipefail %v", sh.errexit, sh.nounset, sh.pipefail)
+	}
+	if got := sh.Execute(ctx, "set -o").Output; !strings.Contains(got, "errexit\ton\n") || !strings.Contains(got, "nounset\ton\n") {
+		t.Errorf("set -o listing = %q", got)
+	}
+
+	sh.Execute(ctx, "set +eu")
+	if sh.errexit || sh.nounset || !sh.pipefail {
+		t.Errorf("after set +eu: errexit %v, nounset %v, pipefail %v", sh.errexit, sh.nounset, sh.pipefail)
+	}
+	sh.Execute(ctx, "set -o errexit")
+	sh.Execute(ctx, "set +o pipefail")
+	if !sh.errexit || sh.pipefail {
+		t.Errorf("after -o errexit +o pipefail: errexit %v, pipefail %v", sh.errexit, sh.pipefail)
+	}
+
+	for _, cmd := range []string{"set -x", "set -eo", "set -o nosuch", "set foo"} {
+		if r := sh.Execute(ctx, cmd); r.Code != 2 {
+			t.Errorf("%s: code %d, want 2 (output %q)", cmd, r.Code, r.Output)
+		}
+	}
+}
+
+func TestShellIntegrationScriptErrexit(t *testing.T) {
+	sh, _ := setupTestShell(t)
+	ctx := context.Background()
+
+	results := sh.ExecuteScript(ctx, "set -euo pipefail\necho one\nnonexistent_command\necho two")
+	if len(results) != 3 || results[2].Code == 0 {
+		t.Fatalf("set -euo pipefail script should stop at the failure: %+v", results)
+	}
+
+	results = sh.ExecuteScript(ctx, "set +e\nnonexistent_command\necho two")
+	if len(results) != 3 || results[2].Output != "two\n" {
+		t.Errorf("set +e should let the script continue: %+v", results)
+	}
+}
+
+func TestShellIntegrationNounset(t *testing.T) {
+	sh, _ := setupTestShell(t)
+	ctx := context.Background()
+
+	sh.Execute(ctx, "set -u")
+	sh.Execute(ctx, "EMPTY=")
+	tests := []struct {
+		cmd, want string
+		code      int
+	}{
+		{"echo $MISSING", "MISSING: unbound variable", 1},
+		{"echo ${MISSING}", "MISSING: unbound variable", 1},
+		{"echo ${MISSING:-default}", "default", 0},
+		{"echo [$EMPTY]", "[]", 0},
+		{"echo '$MISSING'", "$MISSING", 0},
+		{"echo [${NOARRAY[@]}]", "[]", 0},
+	}
+	for _, tt := range tests {
+		r := sh.Execute(ctx, tt.cmd)
+		if r.Code != tt.code || !strings.Contains(r.Output, tt.want) {
+			t.Errorf("%s = %q (code %d), want %q (code %d)", tt.cmd, r.Output, r.Code, tt.want, tt.code)
+		}
+	}
+}
+
 func TestShellIntegrationWithEnv(t *testing.T) {
 	ctx := context.Background()
 	env := map[string]string{"TEST": "value"}
@@ -0,0 +1,90 @@
+package shell
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// shellState is the serialized form of a Shell.
+type shellState struct {
+	Cwd      string                    `json:"cwd"`
+	Env      map[string]string         `json:"env"`
+	Exported []string                  `json:"exported,omitempty"`
+	History  []HistoryEntry            `json:"history,omitempty"`
+	Pipefail bool                      `json:"pipefail,omitempty"`
+	Errexit  bool                      `json:"errexit,omitempty"`
+	Nounset  bool                      `json:"nounset,omitempty"`
+	Nullglob bool                      `json:"nullglob,omitempty"`
+	Failglob bool                      `json:"failglob,omitempty"`
+	Aliases  map[string]string         `json:"aliases,omitempty"`
+	Arrays   map[string]map[int]string `json:"arrays,omitempty"`
+}
+
+// MarshalJSON serializes the shell's session state: working directory,
+// environment and which variables are exported, history, options and
+// aliases. Exec hooks and the VirtualOS binding are not included.
+func (s *Shell) MarshalJSON() ([]byte, error) {
+	exported := make([]string, 0, len(s.Env.exported))
+	for k := range s.Env.exported {
+		exported = append(exported, k)
+	}
+	sort.Strings(exported)
+	return json.Marshal(shellState{
+		Cwd:      s.Cwd(),
+		Env:      s.Env.All(),
+		Exported: exported,
+		History:  s.History(),
+		Pipefail: s.pipefail,
+		Errexit:  s.errexit,
+		Nounset:  s.nounset,
+		Nullglob: s.nullglob,
+		Failglob: s.failglob,
+		Aliases:  s.aliases,
+		Arrays:   s.Env.arrays,
+	})
+}
+
+// UnmarshalJSON restores state written by MarshalJSON, replacing the
+// shell's environment, history, options and aliases. A Shell decoded from
+// scratch has no VirtualOS; call AttachTo before running commands in it.
+// The restored history counts as already saved to ~/.bash_history.
+func (s *Shell) UnmarshalJSON(data []byte) error {
+	var st shellState
+	if err := json.Unmarshal(data, &st); err != nil {
+		return fmt.Errorf("shell: decode state: %w", err)
+	}
+	env := &ShellEnv{data: make(map[string]string, len(st.Env)+1)}
+	for k, v := range st.Env {
+		env.data[k] = v
+	}
+	if st.Cwd != "" {
+		env.data["PWD"] = st.Cwd
+	}
+	for _, k := range st.Exported {
+		env.Export(k)
+	}
+	for k, a := range st.Arrays {
+		env.setArray(k, a)
+	}
+	s.Env = env
+	s.history.clear()
+	for _, e := range st.History {
+		s.history.push(e)
+	}
+	s.unsaved = 0
+	s.pipefail = st.Pipefail
+	s.errexit = st.Errexit
+	s.nounset = st.Nounset
+	s.nullglob = st.Nullglob
+	s.failglob = st.Failglob
+	s.aliases = st.Aliases
+	return nil
+}
+
+// AttachTo binds the shell to v, typically after restoring it with
+// UnmarshalJSON. The shell's state is left as it is; the profile and
+// history file are not reloaded.
+func (s *Shell) AttachTo(v VirtualOS) {
+	s.vos = v
+}
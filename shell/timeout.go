@@ -0,0 +1,49 @@
+package shell
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// TimeoutExitCode is the exit status of a command stopped by the shell
+// timeout, as with GNU timeout.
+const TimeoutExitCode = 124
+
+// WithShellTimeout starts the shell with a timeout on each command. See
+// SetTimeout.
+func WithShellTimeout(d time.Duration) ShellOption {
+	return func(s *Shell) { s.timeout = d }
+}
+
+// SetTimeout limits how long each Execute call may run. The context passed
+// to commands carries the deadline; when it passes, the command is
+// cancelled and its result has exit code TimeoutExitCode and a message
+// saying so. Commands must honour context cancellation to be stopped. A
+// duration of zero or less disables the timeout.
+func (s *Shell) SetTimeout(d time.Duration) { s.timeout = d }
+
+// Timeout returns the per-command timeout, or zero if there is none.
+func (s *Shell) Timeout() time.Duration { return s.timeout }
+
+// timeoutKey marks a context whose deadline was set by a shell timeout, so
+// that commands run by that command (source, scripts) share it.
+type timeoutKey struct{}
+
+// withTimeout runs run with the shell timeout applied to ctx and reports a
+// timed-out command as such.
+func (s *Shell) withTimeout(ctx context.Context, run func(context.Context) *ExecResult) *ExecResult {
+	if s.timeout <= 0 || ctx.Value(timeoutKey{}) != nil {
+		return run(ctx)
+	}
+	tctx, cancel := context.WithTimeout(context.WithValue(ctx, timeoutKey{}, true), s.timeout)
+	defer cancel()
+
+	result := run(tctx)
+	if ctx.Err() == nil && errors.Is(tctx.Err(), context.DeadlineExceeded) {
+		result.appendOutput(stderrResult(fmt.Sprintf("command timed out after %v\n", s.timeout), 0))
+		result.Code = TimeoutExitCode
+	}
+	return result
+}
@@ -0,0 +1,158 @@
+package shell
+
+import (
+	"context"
+	"io"
+	"strconv"
+	"strings"
+)
+
+const xargsUsage = "xargs: usage: xargs [-0r] [-d DELIM] [-n N] [-I REPLACE] [COMMAND [ARG]...]\n"
+
+// cmdXargs runs COMMAND once per batch of items read from stdin. Items are
+// separated by blanks and newlines unless -0 or -d says otherwise; use
+// -d '\n' for items that contain spaces. Each invocation is
+// dispatched like any other command, so shell builtins and commands on PATH
+// are available; the items are passed as literal arguments, without
+// variable or glob expansion. With -I, each input line (leading blanks
+// removed) is one item, substituted for REPLACE in the arguments, and the
+// command runs once per item. As in GNU xargs, empty input still runs the
+// command once without items, unless -r is given or -I is in use.
+//
+// As in GNU xargs, the exit code is 123 if any invocation failed.
+func (s *Shell) cmdXargs(ctx context.Context, args []string, stdin io.Reader) *ExecResult {
+	var (
+		replace    string
+		maxArgs    int
+		noRunEmpty bool
+		delim      string // empty splits on blanks and newlines
+	)
+	i := 0
+options:
+	for ; i < len(args); i++ {
+		arg := args[i]
+		// Options take their value attached ("-n2") or as the next argument.
+		value := func(flag string) (string, bool) {
+			if len(arg) > len(flag) {
+				return arg[len(flag):], true
+			}
+			if i+1 < len(args) {
+				i++
+				return args[i], true
+			}
+			return "", false
+		}
+		var ok bool
+		switch {
+		case arg == "-0":
+			delim = "\x00"
+			continue
+		case arg == "-r" || arg == "--no-run-if-empty":
+			noRunEmpty = true
+			continue
+		case strings.HasPrefix(arg, "-I"):
+			replace, ok = value("-I")
+		case strings.HasPrefix(arg, "-n"):
+			var n string
+			if n, ok = value("-n"); ok {
+				var err error
+				if maxArgs, err = strconv.Atoi(n); err != nil || maxArgs < 1 {
+					return stderrResult("xargs: invalid number for -n option: "+n+"\n", 1)
+				}
+			}
+		case strings.HasPrefix(arg, "-d"):
+			var d string
+			if d, ok = value("-d"); ok {
+				if delim, ok = parseXargsDelim(d); !ok {
+					return stderrResult("xargs: invalid delimiter: "+d+"\n", 1)
+				}
+			}
+		case strings.HasPrefix(arg, "-") && arg != "-":
+			return stderrResult("xargs: invalid option -- '"+strings.TrimLeft(arg, "-")+"'\n"+xargsUsage, 1)
+		default:
+			break options
+		}
+		if !ok {
+			return stderrResult("xargs: option requires an argument -- '"+arg[1:]+"'\n"+xargsUsage, 1)
+		}
+	}
+	command := args[i:]
+	if len(command) == 0 {
+		command = []string{"echo"}
+	}
+
+	var input string
+	if stdin != nil {
+		data, err := io.ReadAll(stdin)
+		if err != nil {
+			return stderrResult("xargs: "+err.Error()+"\n", 1)
+		}
+		input = string(data)
+	}
+	var items []string
+	switch {
+	case delim == "" && replace != "":
+		for _, line := range strings.Split(input, "\n") {
+			if line = strings.TrimLeft(line, " \t"); line != "" {
+				items = append(items, line)
+			}
+		}
+	case delim == "":
+		items = strings.Fields(input)
+	default:
+		for _, item := range strings.Split(input, delim) {
+			if item != "" {
+				items = append(items, item)
+			}
+		}
+	}
+
+	var batches [][]string
+	switch {
+	case replace != "":
+		for _, item := range items {
+			cmd := make([]string, len(command))
+			for j, a := range command {
+				cmd[j] = strings.ReplaceAll(a, replace, item)
+			}
+			batches = append(batches, cmd)
+		}
+	case maxArgs > 0:
+		for start := 0; start < len(items); start += maxArgs {
+			end := min(start+maxArgs, len(items))
+			batches = append(batches, append(append([]string(nil), command...), items[start:end]...))
+		}
+		if len(items) == 0 && !noRunEmpty {
+			batches = append(batches, command)
+		}
+	case len(items) > 0 || !noRunEmpty:
+		batches = append(batches, append(append([]string(nil), command...), items...))
+	}
+
+	res := &ExecResult{}
+	for _, batch := range batches {
+		result := s.runArgs(ctx, batch[0], batch[1:], nil)
+		res.appendOutput(result)
+		if result.Code != 0 {
+			res.Code = 123
+		}
+	}
+	return res
+}
+
+// parseXargsDelim decodes a -d argument: a single character or one of the
+// escapes \n, \t and \0.
+func parseXargsDelim(d string) (string, bool) {
+	switch d {
+	case `\n`:
+		return "\n", true
+	case `\t`:
+		return "\t", true
+	case `\0`:
+		return "\x00", true
+	}
+	if len([]rune(d)) != 1 {
+		return "", false
+	}
+	return d, true
+}
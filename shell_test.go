@@ -2,9 +2,13 @@ package grasp_test
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
 	"strings"
 	"testing"
+	"time"
 
 	grasp "github.com/jackfish212/grasp"
 	"github.com/jackfish212/grasp/builtins"
@@ -28,7 +32,7 @@ func setupShell(t *testing.T) (*grasp.Shell, *grasp.VirtualOS) {
 	root.AddFile("home/tester/hello.txt", []byte("hello world"), grasp.PermRW)
 	root.AddDir("tmp")
 
-	if err := builtins.RegisterBuiltinsOnFS(v, root); err != nil {
+	if _, err := builtins.RegisterBuiltinsOnFS(v, root); err != nil {
 		t.Fatal(err)
 	}
 
@@ -64,6 +68,40 @@ func TestShellCd(t *testing.T) {
 	}
 }
 
+func TestShellCdDash(t *testing.T) {
+	sh, _ := setupShell(t)
+	ctx := context.Background()
+
+	if result := sh.Execute(ctx, "cd -"); result.Code == 0 {
+		t.Error("cd - without OLDPWD should fail")
+	}
+
+	sh.Execute(ctx, "cd /tmp")
+	if got := strings.TrimSpace(sh.Execute(ctx, "echo $OLDPWD").Output); got != "/home/tester" {
+		t.Errorf("OLDPWD = %q, want /home/tester", got)
+	}
+
+	result := sh.Execute(ctx, "cd -")
+	if result.Code != 0 || strings.TrimSpace(result.Output) != "/home/tester" {
+		t.Errorf("cd - = %q (code %d), want /home/tester", result.Output, result.Code)
+	}
+	if sh.Cwd() != "/home/tester" {
+		t.Errorf("Cwd after cd - = %q, want /home/tester", sh.Cwd())
+	}
+
+	// Repeating "cd -" toggles between the two directories.
+	sh.Execute(ctx, "cd -")
+	if sh.Cwd() != "/tmp" {
+		t.Errorf("Cwd after second cd - = %q, want /tmp", sh.Cwd())
+	}
+
+	// A failed cd leaves both variables untouched.
+	sh.Execute(ctx, "cd /nonexistent")
+	if sh.Cwd() != "/tmp" || sh.Env.Get("OLDPWD") != "/home/tester" {
+		t.Errorf("after failed cd: PWD=%q OLDPWD=%q", sh.Cwd(), sh.Env.Get("OLDPWD"))
+	}
+}
+
 func TestShellCdNonexistent(t *testing.T) {
 	sh, _ := setupShell(t)
 	ctx := context.Background()
@@ -95,6 +133,158 @@ func TestShellEnv(t *testing.T) {
 	}
 }
 
+func TestShellExportUnset(t *testing.T) {
+	sh, v := setupShell(t)
+	ctx := context.Background()
+
+	// printvar prints the variables a command sees in its environment.
+	tools := mounts.NewMemFS(grasp.PermRX)
+	tools.AddExecFunc("printvar", func(ctx context.Context, args []string, _ io.Reader) (io.ReadCloser, error) {
+		var out strings.Builder
+		for _, name := range args {
+			out.WriteString(name + "=" + grasp.Env(ctx, name) + "\n")
+		}
+		return io.NopCloser(strings.NewReader(out.String())), nil
+	}, mounts.FuncMeta{Description: "print environment variables"})
+	if err := v.Mount("/tools", tools); err != nil {
+		t.Fatal(err)
+	}
+
+	sh.Execute(ctx, "LOCAL=1")
+	if r := sh.Execute(ctx, "export GREETING=hello"); r.Code != 0 {
+		t.Fatalf("export: code %d: %q", r.Code, r.Output)
+	}
+	if out := sh.Execute(ctx, "echo $GREETING $LOCAL").Output; out != "hello 1\n" {
+		t.Errorf("echo = %q, want %q", out, "hello 1\n")
+	}
+	if out := sh.Execute(ctx, "/tools/printvar GREETING LOCAL").Output; out != "GREETING=hello\nLOCAL=\n" {
+		t.Errorf("command env = %q; only exported variables should be passed", out)
+	}
+	if out := sh.Execute(ctx, "TEMP=x /tools/printvar TEMP").Output; out != "TEMP=x\n" {
+		t.Errorf("prefix assignment = %q, want %q", out, "TEMP=x\n")
+	}
+
+	sh.Execute(ctx, "export LOCAL")
+	if out := sh.Execute(ctx, "/tools/printvar LOCAL").Output; out != "LOCAL=1\n" {
+		t.Errorf("after export LOCAL = %q", out)
+	}
+	if out := sh.Execute(ctx, "export -p").Output; !strings.Contains(out, "export GREETING=\"hello\"\n") || strings.Contains(out, "TEMP") {
+		t.Errorf("export -p = %q", out)
+	}
+
+	if r := sh.Execute(ctx, "unset GREETING LOCAL"); r.Code != 0 {
+		t.Fatalf("unset: code %d: %q", r.Code, r.Output)
+	}
+	if out := sh.Execute(ctx, "env").Output; strings.Contains(out, "GREETING=") {
+		t.Errorf("env after unset = %q", out)
+	}
+	if out := sh.Execute(ctx, "/tools/printvar GREETING").Output; out != "GREETING=\n" {
+		t.Errorf("command env after unset = %q", out)
+	}
+
+	for _, cmd := range []string{"export 1x=2", "unset a-b"} {
+		if r := sh.Execute(ctx, cmd); r.Code != 1 || !strings.Contains(r.Stderr, "not a valid identifier") {
+			t.Errorf("%s: code %d, stderr %q", cmd, r.Code, r.Stderr)
+		}
+	}
+}
+
+func TestShellEnvIsolation(t *testing.T) {
+	_, v := setupShell(t)
+	ctx := context.Background()
+
+	alice := v.Shell("alice")
+	bob := v.Shell("bob")
+	alice.Execute(ctx, "export TASK=review")
+	bob.Execute(ctx, "export TASK=deploy")
+	alice.Execute(ctx, "unset PATH")
+
+	if out := alice.Execute(ctx, "echo $TASK").Output; out != "review\n" {
+		t.Errorf("alice TASK = %q, want review", out)
+	}
+	if out := bob.Execute(ctx, "echo $TASK").Output; out != "deploy\n" {
+		t.Errorf("bob TASK = %q, want deploy", out)
+	}
+	if bob.Env.Get("PATH") == "" {
+		t.Error("unset in one shell removed PATH from another")
+	}
+	if out := v.Shell("carol").Execute(ctx, "env").Output; strings.Contains(out, "TASK=") {
+		t.Errorf("new shell inherited another shell's export: %q", out)
+	}
+}
+
+func TestShellAlias(t *testing.T) {
+	sh, v := setupShell(t)
+	ctx := context.Background()
+
+	if r := sh.Execute(ctx, "alias greet='echo hello'"); r.Code != 0 {
+		t.Fatalf("alias: code %d: %q", r.Code, r.Output)
+	}
+	if out := sh.Execute(ctx, "greet world").Output; out != "hello world\n" {
+		t.Errorf("greet world = %q", out)
+	}
+	if out := sh.Execute(ctx, "echo greet; greet && greet").Output; out != "greet\nhello\nhello\n" {
+		t.Errorf("alias in command list = %q", out)
+	}
+	if out := sh.Execute(ctx, "echo 'greet' | cat").Output; out != "greet\n" {
+		t.Errorf("alias as argument = %q", out)
+	}
+
+	sh.Execute(ctx, "alias greet='echo hi'")
+	if out := sh.Execute(ctx, "greet").Output; out != "hi\n" {
+		t.Errorf("overridden alias = %q", out)
+	}
+	if out := sh.Execute(ctx, "alias").Output; out != "alias greet='echo hi'\n" {
+		t.Errorf("alias listing = %q", out)
+	}
+
+	// An expansion may hold a whole pipeline, and aliases may refer to
+	// other aliases.
+	if err := sh.Alias("first", "head -n 1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := sh.Alias("top", "echo one; echo two | first"); err != nil {
+		t.Fatal(err)
+	}
+	if out := sh.Execute(ctx, "top").Output; out != "one\ntwo\n" {
+		t.Errorf("top = %q", out)
+	}
+
+	if r := sh.Execute(ctx, "unalias greet"); r.Code != 0 {
+		t.Fatalf("unalias: code %d: %q", r.Code, r.Stderr)
+	}
+	if r := sh.Execute(ctx, "greet"); r.Code == 0 {
+		t.Errorf("greet after unalias succeeded: %q", r.Output)
+	}
+	if r := sh.Execute(ctx, "alias greet"); r.Code != 1 || !strings.Contains(r.Stderr, "not found") {
+		t.Errorf("alias greet after unalias: code %d, stderr %q", r.Code, r.Stderr)
+	}
+
+	if err := sh.Alias("bad name", "ls"); err == nil {
+		t.Error("Alias accepted a name with a space")
+	}
+	if out := v.Shell("other").Execute(ctx, "alias").Output; out != "" {
+		t.Errorf("aliases leaked to another shell: %q", out)
+	}
+}
+
+func TestShellAliasRecursive(t *testing.T) {
+	sh, _ := setupShell(t)
+	ctx := context.Background()
+
+	// Expanding echo again inside its own expansion would never end.
+	sh.Execute(ctx, "alias echo='echo [x]'")
+	if out := sh.Execute(ctx, "echo hi").Output; out != "[x] hi\n" {
+		t.Errorf("self-referencing alias = %q", out)
+	}
+
+	sh.Execute(ctx, "alias a=b")
+	sh.Execute(ctx, "alias b=a")
+	if r := sh.Execute(ctx, "a"); !strings.Contains(r.Stderr, "command not found: a") {
+		t.Errorf("mutually recursive aliases: code %d, stderr %q", r.Code, r.Stderr)
+	}
+}
+
 func TestShellHistory(t *testing.T) {
 	sh, _ := setupShell(t)
 	ctx := context.Background()
@@ -107,6 +297,70 @@ func TestShellHistory(t *testing.T) {
 	}
 }
 
+func TestShellHistoryEntries(t *testing.T) {
+	sh, v := setupShell(t)
+	ctx := context.Background()
+
+	before := time.Now()
+	sh.Execute(ctx, "echo first")
+	sh.Execute(ctx, "cat missing.txt")
+	sh.Execute(ctx, "cat missing.txt")
+
+	hist := sh.History()
+	if len(hist) != 2 {
+		t.Fatalf("History() = %+v, want 2 entries (repeats are not added)", hist)
+	}
+	if hist[0].Command != "echo first" || hist[0].ExitCode != 0 {
+		t.Errorf("entry 0 = %+v", hist[0])
+	}
+	if hist[1].Command != "cat missing.txt" || hist[1].ExitCode != 1 {
+		t.Errorf("entry 1 = %+v, want exit code 1", hist[1])
+	}
+	if hist[0].Time.Before(before.Truncate(time.Second)) || hist[0].Time.After(time.Now()) {
+		t.Errorf("entry 0 time = %v", hist[0].Time)
+	}
+
+	want := "    1  echo first\n    2  cat missing.txt\n    3  history\n"
+	if out := sh.Execute(ctx, "history").Output; out != want {
+		t.Errorf("history = %q, want %q", out, want)
+	}
+	if out := sh.Execute(ctx, "history 1").Output; out != "    4  history 1\n" {
+		t.Errorf("history 1 = %q", out)
+	}
+
+	// A new shell for the same user picks the history up from ~/.bash_history.
+	again := v.Shell("tester")
+	if got := again.History(); len(got) != 4 || got[0].Command != "echo first" {
+		t.Errorf("reloaded history = %+v", got)
+	}
+}
+
+func TestShellHistoryWraps(t *testing.T) {
+	_, v := setupShell(t)
+	ctx := context.Background()
+
+	sh := v.Shell("tester", grasp.WithHistorySize(3))
+	for i := 1; i <= 5; i++ {
+		sh.Execute(ctx, fmt.Sprintf("echo %d", i))
+	}
+	hist := sh.History()
+	if len(hist) != 3 || hist[0].Command != "echo 3" || hist[2].Command != "echo 5" {
+		t.Fatalf("History() = %+v, want the last 3 commands", hist)
+	}
+	// Numbers keep counting after older entries are dropped.
+	want := "    4  echo 4\n    5  echo 5\n    6  history\n"
+	if out := sh.Execute(ctx, "history").Output; out != want {
+		t.Errorf("history = %q, want %q", out, want)
+	}
+	if r := sh.Execute(ctx, "history -d 2"); r.Code != 1 {
+		t.Errorf("history -d of a dropped entry: code %d", r.Code)
+	}
+	sh.Execute(ctx, "history -c")
+	if n := sh.HistorySize(); n != 0 {
+		t.Errorf("HistorySize after history -c = %d", n)
+	}
+}
+
 func TestShellEmptyCommand(t *testing.T) {
 	sh, _ := setupShell(t)
 	ctx := context.Background()
@@ -209,6 +463,44 @@ func TestShellRedirectAppend(t *testing.T) {
 	}
 }
 
+func TestShellStdoutStderr(t *testing.T) {
+	sh, _ := setupShell(t)
+	ctx := context.Background()
+
+	result := sh.Execute(ctx, "echo hi")
+	if result.Stdout != "hi\n" || result.Stderr != "" || result.Output != "hi\n" {
+		t.Errorf("echo hi: stdout=%q stderr=%q output=%q", result.Stdout, result.Stderr, result.Output)
+	}
+
+	result = sh.Execute(ctx, "cat /missing")
+	if result.Code == 0 || result.Stdout != "" || result.Stderr == "" || result.Output != result.Stderr {
+		t.Errorf("cat /missing: code=%d stdout=%q stderr=%q output=%q", result.Code, result.Stdout, result.Stderr, result.Output)
+	}
+
+	result = sh.Execute(ctx, "echo a && cat /missing")
+	if result.Stdout != "a\n" || result.Stderr == "" || !strings.HasPrefix(result.Output, "a\n") {
+		t.Errorf("mixed: stdout=%q stderr=%q output=%q", result.Stdout, result.Stderr, result.Output)
+	}
+
+	// ">" captures stdout only; the error is still reported.
+	result = sh.Execute(ctx, "cat /missing > /tmp/out.txt")
+	if result.Code == 0 || result.Stderr == "" {
+		t.Errorf("cat /missing > file: code=%d stderr=%q", result.Code, result.Stderr)
+	}
+	if got := sh.Execute(ctx, "cat /tmp/out.txt").Output; got != "" {
+		t.Errorf("out.txt = %q, want empty", got)
+	}
+
+	// "2>" captures stderr only.
+	result = sh.Execute(ctx, "cat /missing 2> /tmp/err.txt")
+	if result.Code == 0 || result.Output != "" {
+		t.Errorf("cat /missing 2> file: code=%d output=%q", result.Code, result.Output)
+	}
+	if got := sh.Execute(ctx, "cat /tmp/err.txt").Output; !strings.Contains(got, "missing") {
+		t.Errorf("err.txt = %q, want error message", got)
+	}
+}
+
 // ─── Logical Operators ───
 
 func TestShellLogicalAnd(t *testing.T) {
@@ -280,6 +572,138 @@ func TestShellCommandGroupRedirect(t *testing.T) {
 	}
 }
 
+// ─── Conditionals ───
+
+func TestShellIf(t *testing.T) {
+	sh, _ := setupShell(t)
+	ctx := context.Background()
+
+	tests := []struct {
+		cmd, want string
+		code      int
+	}{
+		{"if [ -f hello.txt ]; then cat hello.txt; fi", "hello world", 0},
+		{"if [ -f missing.txt ]; then cat missing.txt; fi", "", 0},
+		{"if [ -d /tmp ]; then echo dir; else echo file; fi", "dir\n", 0},
+		{"if [ -d hello.txt ]; then echo dir; else echo file; fi", "file\n", 0},
+		{"if false; then echo a; elif [ 2 -gt 1 ]; then echo b; else echo c; fi", "b\n", 0},
+		{"if false; then echo a; elif false; then echo b; fi", "", 0},
+		{"if true; then false; fi", "", 1},
+		{"if true && [ x = x ]; then echo both; fi", "both\n", 0},
+		{"if true; then if false; then echo a; else echo nested; fi; fi", "nested\n", 0},
+		{"if true; then echo a; fi && echo after", "a\nafter\n", 0},
+		{"echo before; if false; then echo a; fi; echo if fi", "before\nif fi\n", 0},
+	}
+	for _, tt := range tests {
+		r := sh.Execute(ctx, tt.cmd)
+		if r.Output != tt.want || r.Code != tt.code {
+			t.Errorf("%s = %q (code %d), want %q (code %d)", tt.cmd, r.Output, r.Code, tt.want, tt.code)
+		}
+	}
+}
+
+func TestShellIfSyntaxErrors(t *testing.T) {
+	sh, _ := setupShell(t)
+	ctx := context.Background()
+
+	for _, cmd := range []string{
+		"if true; echo a; fi",
+		"if true; then echo a",
+		"if; then echo a; fi",
+		"if true; then fi",
+		"if true; then echo a; else fi",
+		"if true; then echo a; fi extra",
+	} {
+		if r := sh.Execute(ctx, cmd); r.Code != 2 || !strings.Contains(r.Stderr, "syntax error") {
+			t.Errorf("%s = %q (code %d), want a syntax error", cmd, r.Output, r.Code)
+		}
+	}
+}
+
+// ─── xargs ───
+
+func TestShellXargs(t *testing.T) {
+	sh, v := setupShell(t)
+	ctx := context.Background()
+	for name, content := range map[string]string{"a.txt": "alpha\n", "b.txt": "beta\n", "c.txt": "gamma\n"} {
+		if err := v.Write(ctx, "/tmp/feeds/"+name, strings.NewReader(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := v.Write(ctx, "/tmp/feeds dir/my notes.txt", strings.NewReader("spaced\n")); err != nil {
+		t.Fatal(err)
+	}
+	if err := v.Write(ctx, "/tmp/names", strings.NewReader("a.txt\n  b.txt\n\nc.txt\n")); err != nil {
+		t.Fatal(err)
+	}
+	// Items are passed literally, without variable or glob expansion.
+	if err := v.Write(ctx, "/tmp/items", strings.NewReader("one two\n$HOME\n*\n")); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		cmd, want string
+	}{
+		// With -I each line is one item; leading blanks and empty lines are dropped.
+		{"cat /tmp/names | xargs -I{} cat /tmp/feeds/{}", "alpha\nbeta\ngamma\n"},
+		{"cat /tmp/names | xargs -I {} echo [{}]", "[a.txt]\n[b.txt]\n[c.txt]\n"},
+		// One invocation with all items by default; echo is the default command.
+		{"ls /tmp/feeds | xargs", "a.txt b.txt c.txt\n"},
+		{"ls /tmp/feeds | xargs -n 2 echo", "a.txt b.txt\nc.txt\n"},
+		{"ls /tmp/feeds | xargs -n1 echo file:", "file: a.txt\nfile: b.txt\nfile: c.txt\n"},
+		{"echo x,y,z | xargs -d , -n 1 echo item", "item x\nitem y\nitem z\n\n"},
+		{"cat /tmp/items | xargs -d '\\n' -n 1 echo", "one two\n$HOME\n*\n"},
+		{"echo 'my notes.txt' | xargs -I{} cat '/tmp/feeds dir/{}'", "spaced\n"},
+		{"cat /tmp/items | xargs -I % echo '<%>'", "<one two>\n<$HOME>\n<*>\n"},
+	}
+	for _, tt := range tests {
+		result := sh.Execute(ctx, tt.cmd)
+		if result.Code != 0 || result.Output != tt.want {
+			t.Errorf("%s = %q (code %d), want %q", tt.cmd, result.Output, result.Code, tt.want)
+		}
+	}
+}
+
+func TestShellXargsNull(t *testing.T) {
+	sh, v := setupShell(t)
+	ctx := context.Background()
+	if err := v.Write(ctx, "/tmp/list", strings.NewReader("one two\x00three\x00")); err != nil {
+		t.Fatal(err)
+	}
+	result := sh.Execute(ctx, "cat --raw /tmp/list | xargs -0 -n 1 echo")
+	if result.Code != 0 || result.Output != "one two\nthree\n" {
+		t.Errorf("xargs -0 = %q (code %d)", result.Output, result.Code)
+	}
+}
+
+func TestShellXargsErrors(t *testing.T) {
+	sh, _ := setupShell(t)
+	ctx := context.Background()
+
+	// A failing invocation makes xargs exit with 123, and later items still run.
+	result := sh.Execute(ctx, "echo '/missing\n/home/tester/hello.txt' | xargs -n 1 cat")
+	if result.Code != 123 || !strings.Contains(result.Stdout, "hello world") || result.Stderr == "" {
+		t.Errorf("xargs with failure: code=%d stdout=%q stderr=%q", result.Code, result.Stdout, result.Stderr)
+	}
+	for _, cmd := range []string{"xargs -n 0 echo", "xargs -n", "xargs -q", "xargs -d ab"} {
+		if result := sh.Execute(ctx, "echo a | "+cmd); result.Code == 0 {
+			t.Errorf("%s: expected failure", cmd)
+		}
+	}
+	// Empty input runs the command once, unless -r is given or -I is used.
+	for cmd, want := range map[string]string{
+		"xargs echo hi":        "hi\n",
+		"xargs -n 1 echo hi":   "hi\n",
+		"xargs -r echo hi":     "",
+		"xargs -I{} echo {}":   "",
+		"xargs -r -n 1 echo x": "",
+	} {
+		if result := sh.Execute(ctx, "echo -n '' | "+cmd); result.Output != want {
+			t.Errorf("%s on empty input = %q, want %q", cmd, result.Output, want)
+		}
+	}
+}
+
 // ─── Environment Variable Expansion ───
 
 func TestShellEnvExpansion(t *testing.T) {
@@ -304,6 +728,99 @@ func TestShellEnvExpansionBraces(t *testing.T) {
 	}
 }
 
+func TestShellEnvExpansionQuotes(t *testing.T) {
+	sh, _ := setupShell(t)
+	ctx := context.Background()
+
+	tests := []struct{ cmd, want string }{
+		{"echo '$HOME'", "$HOME"},
+		{`echo "$USER"`, "tester"},
+		{`echo "it's $USER"`, "it's tester"},
+		{`echo '${USER}' $USER`, "${USER} tester"},
+	}
+	for _, tt := range tests {
+		result := sh.Execute(ctx, tt.cmd)
+		if got := strings.TrimSpace(result.Output); got != tt.want {
+			t.Errorf("%s = %q, want %q", tt.cmd, got, tt.want)
+		}
+	}
+}
+
+func TestShellVariableAssignment(t *testing.T) {
+	sh, v := setupShell(t)
+	ctx := context.Background()
+	if err := v.Mkdir(ctx, "/tmp/feeds", grasp.PermRWX); err != nil {
+		t.Fatal(err)
+	}
+	_ = v.Write(ctx, "/tmp/feeds/a.xml", strings.NewReader("<rss/>"))
+
+	tests := []struct{ cmd, want string }{
+		{"BASE=/tmp/feeds && ls $BASE", "a.xml"},
+		{"echo $BASE/a.xml", "/tmp/feeds/a.xml"},
+		{`MSG="hello   world"`, ""},
+		{`echo "$MSG"`, "hello   world"},
+		{"A=1 B=${A}2; echo $A $B", "1 12"},
+		{"N=$(echo one two)", ""},
+		{"echo $N", "one two"},
+		{"Q='$HOME' && echo $Q", "$HOME"},
+		{"DIR=~/sub && echo $DIR", "/home/tester/sub"},
+		{"TMPV=x env | grep TMPV", "TMPV=x"},
+		{"echo [$TMPV]", "[]"},
+		{"echo a=b", "a=b"},
+	}
+	for _, tt := range tests {
+		result := sh.Execute(ctx, tt.cmd)
+		if got := strings.TrimRight(result.Output, "\n"); got != tt.want || result.Code != 0 {
+			t.Errorf("%s = %q (code %d), want %q", tt.cmd, got, result.Code, tt.want)
+		}
+	}
+	if sh.Env.Get("BASE") != "/tmp/feeds" {
+		t.Errorf("Env BASE = %q", sh.Env.Get("BASE"))
+	}
+}
+
+func TestShellParameterExpansion(t *testing.T) {
+	sh, _ := setupShell(t)
+	ctx := context.Background()
+	sh.Env.Set("EMPTY", "")
+	sh.Env.Set("NAME", "grasp")
+
+	tests := []struct{ cmd, want string }{
+		{"echo ${UNSET:-fallback}", "fallback"},
+		{"echo ${EMPTY:-fallback}", "fallback"},
+		{"echo ${EMPTY-fallback}", ""},
+		{"echo ${NAME:-fallback}", "grasp"},
+		{"echo ${UNSET:-$HOME/x}", "/home/tester/x"},
+		{"echo ${UNSET:-${NAME}}", "grasp"},
+		{"echo ${NAME:+set} ${UNSET:+set}", "set"},
+		{"echo ${NAME:?missing}", "grasp"},
+		{"echo ${NEW:=assigned} $NEW", "assigned assigned"},
+		{"echo '${UNSET:?not expanded}'", "${UNSET:?not expanded}"},
+	}
+	for _, tt := range tests {
+		result := sh.Execute(ctx, tt.cmd)
+		if got := strings.TrimSpace(result.Output); got != tt.want || result.Code != 0 {
+			t.Errorf("%s = %q (code %d), want %q", tt.cmd, got, result.Code, tt.want)
+		}
+	}
+
+	errs := []struct{ cmd, want string }{
+		{"echo ${UNSET:?path is required}", "UNSET: path is required"},
+		{"echo ${EMPTY:?}", "EMPTY: parameter null or not set"},
+		{"X=${UNSET:?no value}", "UNSET: no value"},
+		{"echo ${:-x}", "bad substitution"},
+	}
+	for _, tt := range errs {
+		result := sh.Execute(ctx, tt.cmd)
+		if result.Code == 0 || !strings.Contains(result.Stderr, tt.want) || result.Stdout != "" {
+			t.Errorf("%s = stdout %q, stderr %q (code %d), want error %q", tt.cmd, result.Stdout, result.Stderr, result.Code, tt.want)
+		}
+	}
+	if out := sh.Execute(ctx, "echo ${UNSET:?unset} || echo recovered").Stdout; out != "recovered\n" {
+		t.Errorf("failed expansion || echo = %q", out)
+	}
+}
+
 func TestShellTildeExpansion(t *testing.T) {
 	sh, _ := setupShell(t)
 	ctx := context.Background()
@@ -553,3 +1070,451 @@ func TestShellCommandSubstitutionPwd(t *testing.T) {
 		t.Errorf("pwd substitution = %q, want %q", got, "/home/tester")
 	}
 }
+
+// ─── Session State ───
+
+func TestShellMarshalJSON(t *testing.T) {
+	sh, v := setupShell(t)
+	ctx := context.Background()
+
+	sh.Execute(ctx, "cd /tmp")
+	sh.Env.Set("GREETING", "hi")
+	sh.Env.Export("GREETING")
+	sh.Execute(ctx, "set -o pipefail")
+	data, err := json.Marshal(sh)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Checkpoint the session into the VFS, then restore it in a new shell.
+	if err := v.Write(ctx, "/tmp/session.json", strings.NewReader(string(data))); err != nil {
+		t.Fatal(err)
+	}
+	f, err := v.Open(ctx, "/tmp/session.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	saved, _ := io.ReadAll(f)
+	f.Close()
+
+	var restored grasp.Shell
+	if err := json.Unmarshal(saved, &restored); err != nil {
+		t.Fatal(err)
+	}
+	restored.AttachTo(v)
+
+	if restored.Cwd() != "/tmp" {
+		t.Errorf("Cwd = %q, want /tmp", restored.Cwd())
+	}
+	if !restored.Pipefail() {
+		t.Error("pipefail was not restored")
+	}
+	if got := restored.Env.Exported()["GREETING"]; got != "hi" {
+		t.Errorf("exported GREETING = %q, want hi", got)
+	}
+	if got, want := len(restored.History()), len(sh.History()); got != want || want != 2 {
+		t.Errorf("history has %d entries, want %d (2)", got, want)
+	}
+	if out := restored.Execute(ctx, "echo $GREETING $USER").Output; out != "hi tester\n" {
+		t.Errorf("echo $GREETING $USER = %q, want %q", out, "hi tester\n")
+	}
+	if out := restored.Execute(ctx, "pwd").Output; strings.TrimSpace(out) != "/tmp" {
+		t.Errorf("pwd = %q, want /tmp", out)
+	}
+
+	if err := json.Unmarshal([]byte(`{"cwd": 1}`), &restored); err == nil {
+		t.Error("expected error for malformed state")
+	}
+}
+
+// ─── Scripts ───
+
+func TestShellExecuteFile(t *testing.T) {
+	sh, v := setupShell(t)
+	ctx := context.Background()
+
+	script := "# greeting\necho start $0\n\nnonexistent_command\necho end\n"
+	if err := v.Write(ctx, "/tmp/run.sh", strings.NewReader(script)); err != nil {
+		t.Fatal(err)
+	}
+	result := sh.ExecuteFile(ctx, "/tmp/run.sh")
+	if !strings.Contains(result.Output, "start /tmp/run.sh") || !strings.Contains(result.Output, "end") {
+		t.Errorf("script output = %q", result.Output)
+	}
+	if result.Code != 0 {
+		t.Errorf("exit code = %d, want 0 (from last command)", result.Code)
+	}
+	if got := sh.Execute(ctx, "echo [$0]").Output; strings.TrimSpace(got) != "[]" {
+		t.Errorf("$0 should be unset after the script, got %q", got)
+	}
+}
+
+func TestShellExecuteFileSetE(t *testing.T) {
+	sh, v := setupShell(t)
+	ctx := context.Background()
+
+	script := "set -e\necho before\nnonexistent_command\necho after\n"
+	if err := v.Write(ctx, "/tmp/strict.sh", strings.NewReader(script)); err != nil {
+		t.Fatal(err)
+	}
+	result := sh.ExecuteFile(ctx, "/tmp/strict.sh")
+	if result.Code == 0 {
+		t.Error("set -e script should fail")
+	}
+	if strings.Contains(result.Output, "after") {
+		t.Errorf("set -e should stop at the failing command: %q", result.Output)
+	}
+
+	if result := sh.ExecuteFile(ctx, "/tmp/missing.sh"); result.Code != 1 {
+		t.Errorf("missing script: code = %d, want 1", result.Code)
+	}
+}
+
+func TestShellExecuteScript(t *testing.T) {
+	sh, _ := setupShell(t)
+	ctx := context.Background()
+
+	script := `# build a workspace
+mkdir /tmp/work
+cd /tmp/work
+DIR=$(pwd)
+echo "in $DIR" \
+  > where.txt
+
+cat where.txt
+`
+	results := sh.ExecuteScript(ctx, script)
+	if len(results) != 5 {
+		t.Fatalf("got %d results, want one per statement: %+v", len(results), results)
+	}
+	for i, r := range results {
+		if r.Code != 0 {
+			t.Errorf("statement %d: code %d, output %q", i, r.Code, r.Output)
+		}
+	}
+	if got := results[4].Output; got != "in /tmp/work\n" {
+		t.Errorf("cat where.txt = %q; cwd and variables should persist across statements", got)
+	}
+	if sh.Cwd() != "/tmp/work" {
+		t.Errorf("cwd after script = %q", sh.Cwd())
+	}
+
+	results = sh.ExecuteScript(ctx, "echo one\nnonexistent_command\necho two")
+	if len(results) != 3 || results[1].Code == 0 || results[2].Output != "two\n" {
+		t.Errorf("without set -e every statement runs: %+v", results)
+	}
+
+	results = sh.ExecuteScript(ctx, "set -e\necho one\nnonexistent_command\necho two")
+	if len(results) != 3 {
+		t.Fatalf("set -e: got %d results, want 3: %+v", len(results), results)
+	}
+	if results[2].Code == 0 {
+		t.Error("set -e: last result should be the failing statement")
+	}
+}
+
+func TestShellExecuteScriptHereDoc(t *testing.T) {
+	sh, v := setupShell(t)
+	ctx := context.Background()
+
+	script := `cat <<EOF > /tmp/notes.txt
+line1
+# not a comment
+
+line2 $USER
+EOF
+cat <<'END'
+$USER stays
+END
+echo done
+`
+	results := sh.ExecuteScript(ctx, script)
+	if len(results) != 3 {
+		t.Fatalf("got %d results, want 3: %+v", len(results), results)
+	}
+	for i, r := range results {
+		if r.Code != 0 {
+			t.Errorf("statement %d: code %d, output %q", i, r.Code, r.Output)
+		}
+	}
+	if got := results[1].Output; got != "$USER stays\n" {
+		t.Errorf("quoted here-document = %q", got)
+	}
+	if got, want := sh.Execute(ctx, "cat /tmp/notes.txt").Output, "line1\n# not a comment\n\nline2 "+sh.Env.Get("USER")+"\n"; got != want {
+		t.Errorf("here-document file = %q, want %q", got, want)
+	}
+
+	// source runs scripts the same way.
+	if err := v.Write(ctx, "/tmp/gen.sh", strings.NewReader(script)); err != nil {
+		t.Fatal(err)
+	}
+	if r := sh.Execute(ctx, "source /tmp/gen.sh"); r.Code != 0 || !strings.HasSuffix(r.Output, "done\n") {
+		t.Errorf("source with here-documents: code %d, output %q", r.Code, r.Output)
+	}
+}
+
+func TestShellExecuteScriptMultiLineIf(t *testing.T) {
+	sh, _ := setupShell(t)
+	ctx := context.Background()
+
+	script := `X=1
+if [ "$X" = 1 ]
+then
+  # a comment inside the compound
+  echo one
+  echo two
+elif [ "$X" = 2 ]; then
+  echo elif
+else
+  echo other
+fi
+if false; then echo no; fi
+if true
+then
+  if [ -d /tmp ]
+  then
+    echo nested
+  fi
+fi
+echo after
+`
+	results := sh.ExecuteScript(ctx, script)
+	var out strings.Builder
+	for i, r := range results {
+		if r.Code != 0 {
+			t.Errorf("statement %d: code %d, output %q", i, r.Code, r.Output)
+		}
+		out.WriteString(r.Output)
+	}
+	if len(results) != 5 {
+		t.Errorf("got %d results, want 5 (one per compound): %+v", len(results), results)
+	}
+	if got := out.String(); got != "one\ntwo\nnested\nafter\n" {
+		t.Errorf("output = %q", got)
+	}
+}
+
+func TestShellSource(t *testing.T) {
+	sh, v := setupShell(t)
+	ctx := context.Background()
+
+	script := "# set up the session\nNAME=grasp\necho hello $NAME\nalias greet='echo hi from $NAME'\ncd /tmp\n"
+	if err := v.Write(ctx, "/tmp/init.sh", strings.NewReader(script)); err != nil {
+		t.Fatal(err)
+	}
+	result := sh.Execute(ctx, "source /tmp/init.sh")
+	if result.Code != 0 || result.Output != "hello grasp\n" {
+		t.Fatalf("source: code %d, output %q", result.Code, result.Output)
+	}
+	if out := sh.Execute(ctx, "echo $NAME").Output; out != "grasp\n" {
+		t.Errorf("variable after source = %q", out)
+	}
+	if out := sh.Execute(ctx, "greet").Output; out != "hi from grasp\n" {
+		t.Errorf("alias after source = %q", out)
+	}
+	if sh.Cwd() != "/tmp" {
+		t.Errorf("cwd after source = %q, want /tmp", sh.Cwd())
+	}
+
+	sh.Execute(ctx, "NAME=other")
+	if out := sh.Execute(ctx, ". init.sh && echo $NAME").Output; out != "hello grasp\ngrasp\n" {
+		t.Errorf(". init.sh = %q", out)
+	}
+
+	if r := sh.Execute(ctx, "source"); r.Code != 2 {
+		t.Errorf("source without file: code %d, want 2", r.Code)
+	}
+	if r := sh.Execute(ctx, "source /tmp/missing.sh"); r.Code != 1 {
+		t.Errorf("source missing file: code %d, want 1", r.Code)
+	}
+	v.Write(ctx, "/tmp/loop.sh", strings.NewReader("source /tmp/loop.sh\n"))
+	if r := sh.Execute(ctx, "source /tmp/loop.sh"); r.Code == 0 || !strings.Contains(r.Output, "maximum nesting depth") {
+		t.Errorf("self-sourcing script: code %d, output %q", r.Code, r.Output)
+	}
+}
+
+func TestShellRead(t *testing.T) {
+	sh, v := setupShell(t)
+	ctx := context.Background()
+
+	if r := sh.Execute(ctx, "echo 'alice  smith' | read FIRST LAST"); r.Code != 0 {
+		t.Fatalf("read from pipe: code %d, output %q", r.Code, r.Output)
+	}
+	if out := sh.Execute(ctx, "echo $FIRST/$LAST").Output; out != "alice/smith\n" {
+		t.Errorf("fields from pipe = %q", out)
+	}
+	sh.Execute(ctx, "read GREETING <<EOF\nhello there\nEOF")
+	if out := sh.Execute(ctx, "echo $GREETING").Output; out != "hello there\n" {
+		t.Errorf("read from here-document = %q", out)
+	}
+
+	var prompts []string
+	answers := []string{"us-east-1", "42"}
+	sh.SetInputProvider(func(_ context.Context, prompt string) (string, error) {
+		prompts = append(prompts, prompt)
+		if len(answers) == 0 {
+			return "", io.EOF
+		}
+		line := answers[0]
+		answers = answers[1:]
+		return line, nil
+	})
+	r := sh.Execute(ctx, "read -p 'Region: ' REGION")
+	if r.Code != 0 || r.Stderr != "Region: " {
+		t.Errorf("read -p: code %d, stderr %q", r.Code, r.Stderr)
+	}
+	sh.Execute(ctx, "read -r")
+	if out := sh.Execute(ctx, "echo $REGION $REPLY").Output; out != "us-east-1 42\n" {
+		t.Errorf("values from input provider = %q", out)
+	}
+	if len(prompts) != 2 || prompts[0] != "Region: " || prompts[1] != "" {
+		t.Errorf("prompts = %q", prompts)
+	}
+	if r := sh.Execute(ctx, "read X"); r.Code != 1 {
+		t.Errorf("read at end of input: code %d, want 1", r.Code)
+	}
+
+	sh.SetInputProvider(func(ctx context.Context, _ string) (string, error) {
+		<-ctx.Done()
+		return "", ctx.Err()
+	})
+	if r := sh.Execute(ctx, "read -t 0.05 X"); r.Code != 142 {
+		t.Errorf("read -t: code %d, output %q", r.Code, r.Output)
+	}
+
+	// Input redirection from a file feeds read and external commands alike;
+	// a quoted "<" is an ordinary argument.
+	if err := v.Write(ctx, "/tmp/names.txt", strings.NewReader("ada lovelace\nalan turing\n")); err != nil {
+		t.Fatal(err)
+	}
+	if r := sh.Execute(ctx, "read FIRST LAST < /tmp/names.txt"); r.Code != 0 {
+		t.Fatalf("read < file: code %d, output %q", r.Code, r.Output)
+	}
+	if out := sh.Execute(ctx, "echo $FIRST/$LAST").Output; out != "ada/lovelace\n" {
+		t.Errorf("fields from file = %q", out)
+	}
+	for cmd, want := range map[string]string{
+		"cat < /tmp/names.txt":            "ada lovelace\nalan turing\n",
+		"cat </tmp/names.txt | grep alan": "alan turing\n",
+		"echo '<' x":                      "< x\n",
+	} {
+		if r := sh.Execute(ctx, cmd); r.Code != 0 || r.Output != want {
+			t.Errorf("%s = %q (code %d), want %q", cmd, r.Output, r.Code, want)
+		}
+	}
+
+	// Paths still go to the read command, which prints files.
+	if out := sh.Execute(ctx, "read hello.txt").Output; out != "hello world" {
+		t.Errorf("read hello.txt = %q", out)
+	}
+}
+
+func TestShellOutputLimit(t *testing.T) {
+	_, v := setupShell(t)
+	ctx := context.Background()
+	v.Write(ctx, "/tmp/big.txt", strings.NewReader("0123456789"))
+
+	tests := []struct {
+		strategy grasp.TruncationStrategy
+		want     string
+	}{
+		{grasp.TruncateTail, "012345"},
+		{grasp.TruncateHead, "456789"},
+		{grasp.TruncateMiddle, "012789"},
+	}
+	for _, tt := range tests {
+		sh := v.Shell("tester", grasp.WithOutputLimit(6), grasp.WithOutputLimitStrategy(tt.strategy))
+		r := sh.Execute(ctx, "cat /tmp/big.txt")
+		if r.Output != tt.want || r.Stdout != tt.want || !r.Truncated {
+			t.Errorf("strategy %d: output %q, stdout %q, truncated %v; want %q", tt.strategy, r.Output, r.Stdout, r.Truncated, tt.want)
+		}
+	}
+
+	sh := v.Shell("tester", grasp.WithOutputLimit(6))
+	if r := sh.Execute(ctx, "echo hi"); r.Output != "hi\n" || r.Truncated {
+		t.Errorf("short output: %q, truncated %v", r.Output, r.Truncated)
+	}
+	if r := sh.Execute(ctx, "echo 0123456789"); r.Output != "012345" || !r.Truncated {
+		t.Errorf("builtin output: %q, truncated %v", r.Output, r.Truncated)
+	}
+	// Redirected output is not limited.
+	sh.Execute(ctx, "cat /tmp/big.txt > /tmp/copy.txt")
+	if out := v.Shell("tester").Execute(ctx, "cat /tmp/copy.txt").Output; out != "0123456789" {
+		t.Errorf("redirected output = %q", out)
+	}
+}
+
+func TestShellOnBeforeExec(t *testing.T) {
+	sh, _ := setupShell(t)
+	ctx := context.Background()
+
+	var seen []string
+	sh.OnBeforeExec(func(_ context.Context, cmdLine string) error {
+		seen = append(seen, cmdLine)
+		return nil
+	})
+	sh.OnBeforeExec(func(_ context.Context, cmdLine string) error {
+		if strings.HasPrefix(cmdLine, "rm ") {
+			return errors.New("rm is not allowed")
+		}
+		return nil
+	})
+	var after []*grasp.ExecResult
+	sh.OnExec(func(_ string, result *grasp.ExecResult) {
+		after = append(after, result)
+	})
+
+	if r := sh.Execute(ctx, "echo ok"); r.Code != 0 || r.Output != "ok\n" {
+		t.Errorf("approved command: code %d, output %q", r.Code, r.Output)
+	}
+	r := sh.Execute(ctx, "rm hello.txt")
+	if r.Code != 1 || r.Output != "rm is not allowed\n" || r.Stderr != r.Output {
+		t.Errorf("rejected command: code %d, output %q, stderr %q", r.Code, r.Output, r.Stderr)
+	}
+	if out := sh.Execute(ctx, "cat hello.txt").Output; out != "hello world" {
+		t.Errorf("rejected command ran: cat = %q", out)
+	}
+	if len(seen) != 3 || seen[1] != "rm hello.txt" {
+		t.Errorf("hooks saw %q", seen)
+	}
+	if len(after) != 3 || after[1] != r {
+		t.Fatalf("OnExec saw %d results", len(after))
+	}
+	if after[1].Output != "rm is not allowed\n" {
+		t.Errorf("OnExec result for rejected command = %q", after[1].Output)
+	}
+}
+
+func TestShellTimeout(t *testing.T) {
+	_, v := setupShell(t)
+	ctx := context.Background()
+	const timeout = 100 * time.Millisecond
+
+	sh := v.Shell("tester", grasp.WithShellTimeout(timeout))
+	start := time.Now()
+	r := sh.Execute(ctx, "sleep 10")
+	elapsed := time.Since(start)
+	if r.Code != grasp.TimeoutExitCode || !strings.Contains(r.Output, "timed out") {
+		t.Errorf("sleep 10: code %d, output %q", r.Code, r.Output)
+	}
+	if elapsed < timeout || elapsed > timeout+time.Second {
+		t.Errorf("sleep 10 returned after %v, want about %v", elapsed, timeout)
+	}
+	if r := sh.Execute(ctx, "echo fast"); r.Code != 0 || r.Output != "fast\n" {
+		t.Errorf("fast command: code %d, output %q", r.Code, r.Output)
+	}
+
+	// A sourced script shares the deadline of the source command.
+	v.Write(ctx, "/tmp/slow.sh", strings.NewReader("echo start\nsleep 10\necho end\n"))
+	r = sh.Execute(ctx, "source /tmp/slow.sh")
+	if r.Code != grasp.TimeoutExitCode || strings.Count(r.Output, "timed out") != 1 || strings.Contains(r.Output, "end") {
+		t.Errorf("source slow.sh: code %d, output %q", r.Code, r.Output)
+	}
+
+	sh.SetTimeout(0)
+	if sh.Timeout() != 0 {
+		t.Errorf("Timeout() = %v after SetTimeout(0)", sh.Timeout())
+	}
+	if r := sh.Execute(ctx, "sleep 0.2"); r.Code != 0 {
+		t.Errorf("sleep without timeout: code %d, output %q", r.Code, r.Output)
+	}
+}
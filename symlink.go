@@ -0,0 +1,157 @@
+package grasp
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+)
+
+// maxSymlinkDepth bounds the number of symbolic links followed while
+// resolving a single path, matching the Linux limit.
+const maxSymlinkDepth = 40
+
+// Symlink creates a symbolic link at linkPath pointing to target. Relative
+// targets are resolved against the link's directory when the link is
+// followed. The provider mounted at linkPath must implement Linker.
+func (v *VirtualOS) Symlink(ctx context.Context, target, linkPath string) error {
+	linkPath, err := v.resolveParent(ctx, CleanPath(linkPath))
+	if err != nil {
+		return err
+	}
+
+	p, inner, err := v.mounts.Resolve(linkPath)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrNotFound, linkPath)
+	}
+	l, ok := p.(Linker)
+	if !ok {
+		return fmt.Errorf("%w: %s (provider does not support links)", ErrNotSupported, linkPath)
+	}
+	if err := l.Symlink(ctx, target, inner); err != nil {
+		return err
+	}
+	v.hub.emit(EventCreate, linkPath)
+	return nil
+}
+
+// Hardlink makes newPath another name for the file at oldPath. Both paths
+// must be served by the same provider, which must implement Linker.
+func (v *VirtualOS) Hardlink(ctx context.Context, oldPath, newPath string) error {
+	oldPath, err := v.resolveSymlinks(ctx, CleanPath(oldPath), maxSymlinkDepth)
+	if err != nil {
+		return err
+	}
+	newPath, err = v.resolveParent(ctx, CleanPath(newPath))
+	if err != nil {
+		return err
+	}
+
+	pOld, innerOld, err := v.mounts.Resolve(oldPath)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrNotFound, oldPath)
+	}
+	pNew, innerNew, err := v.mounts.Resolve(newPath)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrNotFound, newPath)
+	}
+	if pOld != pNew {
+		return fmt.Errorf("%w: cross-mount hard link not supported (%s → %s)", ErrNotSupported, oldPath, newPath)
+	}
+	l, ok := pOld.(Linker)
+	if !ok {
+		return fmt.Errorf("%w: %s (provider does not support links)", ErrNotSupported, oldPath)
+	}
+	if err := l.Link(ctx, innerOld, innerNew); err != nil {
+		return err
+	}
+	v.hub.emit(EventCreate, newPath)
+	return nil
+}
+
+// ReadLink returns the target of the symbolic link at path, as it was
+// stored. Links in the parent directories of path are followed.
+func (v *VirtualOS) ReadLink(ctx context.Context, path string) (string, error) {
+	path, err := v.resolveParent(ctx, CleanPath(path))
+	if err != nil {
+		return "", err
+	}
+	p, inner, err := v.mounts.Resolve(path)
+	if err != nil || inner == "" {
+		return "", fmt.Errorf("%w: %s", ErrNotSymlink, path)
+	}
+	l, ok := p.(Linker)
+	if !ok {
+		return "", fmt.Errorf("%w: %s", ErrNotSymlink, path)
+	}
+	return l.Readlink(ctx, inner)
+}
+
+// resolveSymlinks returns path with every symbolic link component replaced by
+// its target, following at most maxDepth links. Components that do not
+// exist are kept as-is so callers can create them.
+func (v *VirtualOS) resolveSymlinks(ctx context.Context, p string, maxDepth int) (string, error) {
+	parts := splitPath(p)
+	resolved := "/"
+	depth := 0
+	for i := 0; i < len(parts); i++ {
+		next := path.Join(resolved, parts[i])
+		target, ok := v.readLink(ctx, next)
+		if !ok {
+			resolved = next
+			continue
+		}
+		depth++
+		if depth > maxDepth {
+			return "", fmt.Errorf("%w: %s", ErrTooManyLinks, p)
+		}
+		if !strings.HasPrefix(target, "/") {
+			target = path.Join(resolved, target)
+		}
+		// Restart from the root with the target spliced in for the link.
+		parts = append(splitPath(CleanPath(target)), parts[i+1:]...)
+		resolved = "/"
+		i = -1
+	}
+	return resolved, nil
+}
+
+// resolveParent resolves links in the directory part of path but leaves the
+// final component untouched, for operations that act on a link itself.
+func (v *VirtualOS) resolveParent(ctx context.Context, p string) (string, error) {
+	if p == "/" {
+		return p, nil
+	}
+	dir, err := v.resolveSymlinks(ctx, path.Dir(p), maxSymlinkDepth)
+	if err != nil {
+		return "", err
+	}
+	return path.Join(dir, path.Base(p)), nil
+}
+
+// readLink reports the target of p if it is a symbolic link.
+func (v *VirtualOS) readLink(ctx context.Context, p string) (string, bool) {
+	prov, inner, err := v.mounts.Resolve(p)
+	if err != nil || inner == "" {
+		return "", false
+	}
+	l, ok := prov.(Linker)
+	if !ok {
+		return "", false
+	}
+	target, err := l.Readlink(ctx, inner)
+	if err != nil {
+		return "", false
+	}
+	return target, true
+}
+
+func splitPath(p string) []string {
+	var parts []string
+	for _, s := range strings.Split(p, "/") {
+		if s != "" {
+			parts = append(parts, s)
+		}
+	}
+	return parts
+}
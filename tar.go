@@ -0,0 +1,164 @@
+package grasp
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"path"
+
+	"github.com/jackfish212/grasp/internal/xz"
+	"github.com/jackfish212/grasp/mounts"
+)
+
+// OpenTar opens a tar archive stored at path on any mount and returns a
+// read-only TarFS exposing its contents, ready to be mounted:
+//
+//	tfs, err := v.OpenTar(ctx, "/data/backup.tar.gz")
+//	v.Mount("/extracted", tfs)
+//
+// Gzip, bzip2 and xz compression are detected from the file's magic bytes.
+func (v *VirtualOS) OpenTar(ctx context.Context, path string) (*mounts.TarFS, error) {
+	f, err := v.Open(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+
+	r, closeTar, err := tarStream(f)
+	if err != nil {
+		return nil, fmt.Errorf("open tar %s: %w", path, err)
+	}
+	defer closeTar()
+
+	tfs, err := mounts.NewTarFS(r)
+	if err != nil {
+		return nil, fmt.Errorf("open tar %s: %w", path, err)
+	}
+	return tfs, nil
+}
+
+// Import extracts the tar archive read from r into destPath, creating
+// destPath and any missing parent directories:
+//
+//	f, _ := os.Open("dataset.tar.gz")
+//	err := v.Import(ctx, f, "/workspace/dataset")
+//
+// Compression is detected as for OpenTar. Files are
+// written with Write and directories created with Mkdir, so the providers
+// beneath destPath decide permissions; symbolic and hard links are
+// recreated where the provider supports them. Entry names are confined to
+// destPath: "../" components cannot climb above it. Other entry types,
+// such as devices, are skipped. On error, entries already extracted are
+// left in place.
+func (v *VirtualOS) Import(ctx context.Context, r io.Reader, destPath string) error {
+	destPath = CleanPath(destPath)
+	stream, closeTar, err := tarStream(r)
+	if err != nil {
+		return fmt.Errorf("import tar into %s: %w", destPath, err)
+	}
+	defer closeTar()
+
+	if err := v.mkdirAll(ctx, destPath); err != nil {
+		return err
+	}
+	// Entry path within the destination, with any "../" removed.
+	target := func(name string) string {
+		return path.Join(destPath, path.Clean("/"+name))
+	}
+
+	tr := tar.NewReader(stream)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("import tar into %s: %w", destPath, err)
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		dst := target(hdr.Name)
+		if dst == destPath {
+			continue
+		}
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			err = v.mkdirAll(ctx, dst)
+		case tar.TypeReg, tar.TypeRegA:
+			if err = v.mkdirAll(ctx, path.Dir(dst)); err == nil {
+				err = v.Write(ctx, dst, tr)
+			}
+		case tar.TypeSymlink:
+			if err = v.mkdirAll(ctx, path.Dir(dst)); err == nil {
+				err = v.Symlink(ctx, hdr.Linkname, dst)
+			}
+		case tar.TypeLink:
+			if err = v.mkdirAll(ctx, path.Dir(dst)); err == nil {
+				err = v.Hardlink(ctx, target(hdr.Linkname), dst)
+			}
+		default:
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("import %s: %w", hdr.Name, err)
+		}
+	}
+}
+
+// mkdirAll creates dir and any missing parents.
+func (v *VirtualOS) mkdirAll(ctx context.Context, dir string) error {
+	dir = CleanPath(dir)
+	if e, err := v.Stat(ctx, dir); err == nil {
+		if !e.IsDir {
+			return fmt.Errorf("%w: %s", ErrNotDir, dir)
+		}
+		return nil
+	}
+	if dir != "/" {
+		if err := v.mkdirAll(ctx, path.Dir(dir)); err != nil {
+			return err
+		}
+	}
+	if err := v.Mkdir(ctx, dir, PermRWX); err != nil {
+		// Providers with implicit directories may report a directory
+		// only once something is in it.
+		if e, statErr := v.Stat(ctx, dir); statErr == nil && e.IsDir {
+			return nil
+		}
+		return err
+	}
+	return nil
+}
+
+// tarStream returns a reader for the uncompressed archive in r, detecting
+// gzip, bzip2 and xz compression from the magic bytes. The returned func
+// releases the decompressor.
+func tarStream(r io.Reader) (io.Reader, func(), error) {
+	br := bufio.NewReader(r)
+	magic, _ := br.Peek(6)
+
+	switch {
+	case bytes.HasPrefix(magic, []byte{0x1f, 0x8b}):
+		zr, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, nil, err
+		}
+		return zr, func() { _ = zr.Close() }, nil
+	case bytes.HasPrefix(magic, []byte("BZh")):
+		return bzip2.NewReader(br), func() {}, nil
+	case bytes.HasPrefix(magic, []byte{0xfd, '7', 'z', 'X', 'Z', 0x00}):
+		xr, err := xz.NewReader(br)
+		if err != nil {
+			return nil, nil, err
+		}
+		return xr, func() {}, nil
+	}
+	return br, func() {}, nil
+}
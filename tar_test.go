@@ -0,0 +1,186 @@
+package grasp
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"testing"
+)
+
+func buildTar(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	if err := tw.WriteHeader(&tar.Header{Name: "docs/", Typeflag: tar.TypeDir, Mode: 0o755}); err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range files {
+		hdr := &tar.Header{Name: name, Typeflag: tar.TypeReg, Mode: 0o644, Size: int64(len(content))}
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestVOSOpenTar(t *testing.T) {
+	v := setupVOS(t)
+	ctx := context.Background()
+	archive := buildTar(t, map[string]string{
+		"docs/readme.md": "# hi",
+		"src/main.go":    "package main",
+	})
+
+	var gz bytes.Buffer
+	zw := gzip.NewWriter(&gz)
+	_, _ = zw.Write(archive)
+	_ = zw.Close()
+	// The same entries, compressed by xz(1).
+	xz, err := os.ReadFile("testdata/archive.tar.xz")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, data := range map[string][]byte{"/a.tar": archive, "/a.tar.gz": gz.Bytes(), "/a.tar.xz": xz} {
+		t.Run(name, func(t *testing.T) {
+			if err := v.Write(ctx, name, bytes.NewReader(data)); err != nil {
+				t.Fatal(err)
+			}
+			tfs, err := v.OpenTar(ctx, name)
+			if err != nil {
+				t.Fatalf("OpenTar: %v", err)
+			}
+			mnt := "/x" + name
+			if err := v.Mount(mnt, tfs); err != nil {
+				t.Fatal(err)
+			}
+
+			entries, err := v.List(ctx, mnt, ListOpts{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(entries) != 2 || entries[0].Name != "docs" || entries[1].Name != "src" {
+				t.Errorf("List = %v, want [docs src]", entries)
+			}
+
+			f, err := v.Open(ctx, mnt+"/src/main.go")
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, _ := io.ReadAll(f)
+			_ = f.Close()
+			if string(got) != "package main" {
+				t.Errorf("content = %q", got)
+			}
+
+			if err := v.Write(ctx, mnt+"/new.txt", bytes.NewReader(nil)); err == nil {
+				t.Error("TarFS should be read-only")
+			}
+		})
+	}
+}
+
+func TestVOSOpenTarXZCorrupt(t *testing.T) {
+	v := setupVOS(t)
+	ctx := context.Background()
+	if err := v.Write(ctx, "/a.tar.xz", bytes.NewReader([]byte{0xfd, '7', 'z', 'X', 'Z', 0x00})); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := v.OpenTar(ctx, "/a.tar.xz"); err == nil {
+		t.Error("expected error for truncated xz archive")
+	}
+}
+
+func TestVOSImport(t *testing.T) {
+	ctx := context.Background()
+	archive := buildTar(t, map[string]string{
+		"docs/readme.md":   "# hi",
+		"src/pkg/main.go":  "package main",
+		"../../escape.txt": "contained",
+	})
+	var gz bytes.Buffer
+	zw := gzip.NewWriter(&gz)
+	_, _ = zw.Write(archive)
+	_ = zw.Close()
+
+	read := func(t *testing.T, v *VirtualOS, p string) string {
+		t.Helper()
+		f, err := v.Open(ctx, p)
+		if err != nil {
+			t.Fatalf("Open %s: %v", p, err)
+		}
+		defer func() { _ = f.Close() }()
+		data, _ := io.ReadAll(f)
+		return string(data)
+	}
+
+	for name, data := range map[string][]byte{"tar": archive, "tar.gz": gz.Bytes()} {
+		t.Run(name, func(t *testing.T) {
+			v := setupVOS(t)
+			if err := v.Import(ctx, bytes.NewReader(data), "/workspace/project"); err != nil {
+				t.Fatalf("Import: %v", err)
+			}
+			if got := read(t, v, "/workspace/project/docs/readme.md"); got != "# hi" {
+				t.Errorf("readme = %q", got)
+			}
+			if got := read(t, v, "/workspace/project/src/pkg/main.go"); got != "package main" {
+				t.Errorf("main.go = %q", got)
+			}
+			if got := read(t, v, "/workspace/project/escape.txt"); got != "contained" {
+				t.Errorf("escape.txt = %q", got)
+			}
+			if _, err := v.Stat(ctx, "/escape.txt"); err == nil {
+				t.Error("entry escaped the destination directory")
+			}
+		})
+	}
+}
+
+func TestVOSImportLinks(t *testing.T) {
+	v := setupVOS(t)
+	ctx := context.Background()
+
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	_ = tw.WriteHeader(&tar.Header{Name: "data.txt", Typeflag: tar.TypeReg, Mode: 0o644, Size: 4})
+	_, _ = tw.Write([]byte("data"))
+	_ = tw.WriteHeader(&tar.Header{Name: "latest", Typeflag: tar.TypeSymlink, Linkname: "data.txt"})
+	_ = tw.WriteHeader(&tar.Header{Name: "copy.txt", Typeflag: tar.TypeLink, Linkname: "data.txt"})
+	_ = tw.WriteHeader(&tar.Header{Name: "null", Typeflag: tar.TypeChar})
+	_ = tw.Close()
+
+	if err := v.Import(ctx, &buf, "/home/agent/import"); err != nil {
+		t.Fatalf("Import: %v", err)
+	}
+	for _, p := range []string{"/home/agent/import/latest", "/home/agent/import/copy.txt"} {
+		f, err := v.Open(ctx, p)
+		if err != nil {
+			t.Fatalf("Open %s: %v", p, err)
+		}
+		got, _ := io.ReadAll(f)
+		_ = f.Close()
+		if string(got) != "data" {
+			t.Errorf("%s = %q, want %q", p, got, "data")
+		}
+	}
+	if _, err := v.Stat(ctx, "/home/agent/import/null"); err == nil {
+		t.Error("device entry should be skipped")
+	}
+
+	if err := v.Import(ctx, bytes.NewReader([]byte("not a tar archive, just text")), "/tmp"); err == nil {
+		t.Error("expected error for malformed archive")
+	}
+	if err := v.Import(ctx, bytes.NewReader(buildTar(t, nil)), "/home/agent/notes.txt"); !errors.Is(err, ErrNotDir) {
+		t.Errorf("import into a file: err = %v, want ErrNotDir", err)
+	}
+}
@@ -0,0 +1,68 @@
+package grasp
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"strings"
+)
+
+const tempNameChars = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+
+// TempFile creates an empty file with a unique name in dir and returns its
+// path. It is the VFS counterpart of os.CreateTemp: a trailing run of at
+// least three X's in pattern is replaced with random characters, and a
+// pattern without one gets six random characters appended. An empty dir
+// means /tmp.
+func (v *VirtualOS) TempFile(ctx context.Context, dir, pattern string) (string, error) {
+	return v.createTemp(ctx, dir, pattern, func(p string) error {
+		return v.Write(ctx, p, strings.NewReader(""))
+	})
+}
+
+// TempDir creates a directory with a unique name in dir and returns its
+// path. The dir and pattern conventions match TempFile.
+func (v *VirtualOS) TempDir(ctx context.Context, dir, pattern string) (string, error) {
+	return v.createTemp(ctx, dir, pattern, func(p string) error {
+		return v.Mkdir(ctx, p, PermRWX)
+	})
+}
+
+func (v *VirtualOS) createTemp(ctx context.Context, dir, pattern string, create func(string) error) (string, error) {
+	if strings.Contains(pattern, "/") {
+		return "", fmt.Errorf("temp pattern %q contains a path separator", pattern)
+	}
+	if dir == "" {
+		dir = "/tmp"
+	}
+	n := len(pattern) - len(strings.TrimRight(pattern, "X"))
+	if n < 3 {
+		n = 0
+	}
+	prefix := strings.TrimSuffix(CleanPath(dir), "/") + "/" + pattern[:len(pattern)-n]
+	if n == 0 {
+		n = 6
+	}
+
+	// Names are random, so a clash with an existing entry is rare; retry a
+	// bounded number of times rather than looping forever on a full VFS.
+	for range 100 {
+		p := prefix + randomName(n)
+		if _, err := v.Stat(ctx, p); err == nil {
+			continue
+		}
+		if err := create(p); err != nil {
+			return "", err
+		}
+		return p, nil
+	}
+	return "", fmt.Errorf("temp pattern %q: no unique name found in %s", pattern, dir)
+}
+
+func randomName(n int) string {
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = tempNameChars[rand.IntN(len(tempNameChars))]
+	}
+	return string(b)
+}
@@ -7,14 +7,15 @@ import (
 
 // Entry represents a file, directory, or executable in the virtual filesystem.
 type Entry struct {
-	Name     string            // base name
-	Path     string            // full path within grasp
-	IsDir    bool              // true if directory
-	Perm     Perm              // permission bits
-	Size     int64             // size in bytes (0 for dirs / executables)
-	MimeType string            // MIME type hint
-	Modified time.Time         // last modification time
-	Meta     map[string]string // extensible metadata (e.g. "kind"="tool"|"prompt")
+	Name      string            // base name
+	Path      string            // full path within grasp
+	IsDir     bool              // true if directory
+	IsSymlink bool              // true if symbolic link
+	Perm      Perm              // permission bits
+	Size      int64             // size in bytes (0 for dirs / executables)
+	MimeType  string            // MIME type hint
+	Modified  time.Time         // last modification time
+	Meta      map[string]string // extensible metadata (e.g. "kind"="tool"|"prompt")
 }
 
 // String returns a formatted ls-style line for this entry.
@@ -24,6 +25,8 @@ func (e Entry) String() string {
 	if e.IsDir {
 		dirFlag = "d"
 		name += "/"
+	} else if e.IsSymlink {
+		dirFlag = "l"
 	}
 	kind := ""
 	if k, ok := e.Meta["kind"]; ok {
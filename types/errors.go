@@ -1,6 +1,9 @@
 package types
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+)
 
 var (
 	ErrNotFound        = errors.New("grasp: not found")
@@ -13,4 +16,42 @@ var (
 	ErrMountUnderMount = errors.New("grasp: mount under existing mount point")
 	ErrNotSupported    = errors.New("grasp: operation not supported")
 	ErrParentNotExist  = errors.New("grasp: parent directory does not exist")
+	ErrFileTooLarge    = errors.New("grasp: file too large")
+	ErrTooManyEntries  = errors.New("grasp: too many entries")
+	ErrNotSymlink      = errors.New("grasp: not a symbolic link")
+	ErrTooManyLinks    = errors.New("grasp: too many levels of symbolic links")
+	ErrLocked          = errors.New("grasp: file is locked")
 )
+
+// ExitError makes a command finish with a specific non-zero exit status.
+// An ExecFunc may return it instead of output, or its output stream may
+// return it from Read in place of io.EOF to report a status after the
+// output has been written. Err, if set, is shown as the error message;
+// a nil Err sets the status silently. Returned from Read with Code 0, it
+// writes Err to stderr as a warning and the command still succeeds.
+type ExitError struct {
+	Code int
+	Err  error
+}
+
+func (e *ExitError) Error() string {
+	if e.Err != nil {
+		return e.Err.Error()
+	}
+	return fmt.Sprintf("exit status %d", e.Code)
+}
+
+func (e *ExitError) Unwrap() error { return e.Err }
+
+// ExitCode returns the exit status a command failing with err should have:
+// 0 for nil, the code of an ExitError in the chain, and 1 otherwise.
+func ExitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+	var exitErr *ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.Code
+	}
+	return 1
+}
@@ -3,6 +3,18 @@ package types
 // ListOpts controls listing behaviour.
 type ListOpts struct {
 	Recursive bool
+
+	// Cursor is an opaque page token returned as ListResult.NextCursor by a
+	// previous paged listing. Empty starts from the beginning.
+	Cursor string
+	// Limit caps the number of entries in a page. Zero means no limit.
+	Limit int
+}
+
+// ListResult is one page of a paginated directory listing.
+type ListResult struct {
+	Entries    []Entry
+	NextCursor string // empty when there are no more entries
 }
 
 // SearchOpts controls search behaviour.
@@ -5,6 +5,7 @@ package types
 import (
 	"context"
 	"io"
+	"strings"
 )
 
 // Provider is the minimal interface that every mountable data source or tool
@@ -59,6 +60,75 @@ type Touchable interface {
 	Touch(ctx context.Context, path string) error
 }
 
+// PagedLister is optionally implemented by providers that can paginate
+// directory listings natively (e.g. a database "WHERE rowid > ?" or S3
+// StartAfter). The cursor format is private to the provider.
+type PagedLister interface {
+	ListPage(ctx context.Context, path string, opts ListOpts) (*ListResult, error)
+}
+
+// MetaProvider is optionally implemented by providers that store arbitrary
+// key/value metadata on entries. Setting a key to the empty string removes it.
+// GetMeta returns ErrNotFound when the entry or key does not exist.
+type MetaProvider interface {
+	SetMeta(ctx context.Context, path, key, value string) error
+	GetMeta(ctx context.Context, path, key string) (string, error)
+}
+
+// Chmoder is optionally implemented by providers that can change the
+// permission bits of an existing entry.
+type Chmoder interface {
+	Chmod(ctx context.Context, path string, perm Perm) error
+}
+
+// CompressProvider is optionally implemented by providers that can compress
+// and decompress files natively (e.g. at the storage layer). Callers such as
+// the gzip command delegate to it when available; otherwise they fall back to
+// reading, transforming, and writing the content back. As with gzip, Compress
+// replaces path with path+".gz" holding the gzip-compressed content, and
+// Decompress replaces path with DecompressedName(path). Either replaces an
+// existing file at the new name.
+type CompressProvider interface {
+	Compress(ctx context.Context, path string) error
+	Decompress(ctx context.Context, path string) error
+}
+
+// DecompressedName returns the name gunzip gives path: path without its
+// ".gz" suffix, or with ".tgz" replaced by ".tar". ok is false for any
+// other suffix.
+func DecompressedName(path string) (name string, ok bool) {
+	switch {
+	case strings.HasSuffix(path, ".gz"):
+		return strings.TrimSuffix(path, ".gz"), true
+	case strings.HasSuffix(path, ".tgz"):
+		return strings.TrimSuffix(path, ".tgz") + ".tar", true
+	}
+	return "", false
+}
+
+// Linker is optionally implemented by providers that support symbolic and
+// hard links. Readlink returns ErrNotSymlink when path exists but is not a
+// symbolic link. Link makes newPath refer to the same file as oldPath.
+type Linker interface {
+	Symlink(ctx context.Context, target, path string) error
+	Readlink(ctx context.Context, path string) (string, error)
+	Link(ctx context.Context, oldPath, newPath string) error
+}
+
+// LockedFile is a file held open under an exclusive lock. Close releases the
+// lock and, for providers that buffer, commits any writes.
+type LockedFile interface {
+	io.ReadWriteCloser
+}
+
+// ExclusiveOpener is optionally implemented by providers that can open a file
+// under an exclusive lock. OpenExclusive creates the file if it does not exist
+// and returns ErrLocked while another caller holds it. Locks are advisory:
+// they exclude other OpenExclusive callers, not plain reads and writes.
+type ExclusiveOpener interface {
+	OpenExclusive(ctx context.Context, path string) (LockedFile, error)
+}
+
 // MountInfoProvider is implemented by providers that can describe themselves.
 type MountInfoProvider interface {
 	MountInfo() (name, extra string)
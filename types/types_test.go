@@ -2,6 +2,8 @@ package types
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"io"
 	"strings"
 	"testing"
@@ -110,6 +112,32 @@ func TestOpenFlagHas(t *testing.T) {
 	}
 }
 
+// ─── Errors ───
+
+func TestExitCode(t *testing.T) {
+	wrapped := fmt.Errorf("wrapped: %w", &ExitError{Code: 2, Err: ErrNotFound})
+	tests := []struct {
+		err  error
+		want int
+	}{
+		{nil, 0},
+		{ErrNotFound, 1},
+		{&ExitError{Code: 3}, 3},
+		{wrapped, 2},
+	}
+	for _, tt := range tests {
+		if got := ExitCode(tt.err); got != tt.want {
+			t.Errorf("ExitCode(%v) = %d, want %d", tt.err, got, tt.want)
+		}
+	}
+	if !errors.Is(wrapped, ErrNotFound) {
+		t.Error("ExitError should unwrap to its Err")
+	}
+	if got := (&ExitError{Code: 4}).Error(); got != "exit status 4" {
+		t.Errorf("silent ExitError message = %q", got)
+	}
+}
+
 // ─── File ───
 
 func TestNewFile(t *testing.T) {
@@ -246,3 +274,23 @@ func TestEventTypeMatches(t *testing.T) {
 		t.Error("NONE should not match EventAll")
 	}
 }
+
+// ─── DecompressedName ───
+
+func TestDecompressedName(t *testing.T) {
+	tests := []struct {
+		path, want string
+		ok         bool
+	}{
+		{"data.json.gz", "data.json", true},
+		{"dir/backup.tgz", "dir/backup.tar", true},
+		{"notes.txt", "", false},
+		{"archive.gzip", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := DecompressedName(tt.path)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("DecompressedName(%q) = %q, %v; want %q, %v", tt.path, got, ok, tt.want, tt.ok)
+		}
+	}
+}
@@ -10,6 +10,14 @@ type WatchEvent struct {
 	Time    time.Time
 }
 
+// WatchOpts configures a watcher.
+type WatchOpts struct {
+	// Debounce, when positive, merges events for the same path that arrive
+	// within the window into one event (the last one), delivered once the
+	// path has been quiet for Debounce.
+	Debounce time.Duration
+}
+
 // EventType is a bitmask of filesystem event kinds.
 type EventType uint32
 
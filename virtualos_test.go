@@ -0,0 +1,250 @@
+package grasp_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	grasp "github.com/jackfish212/grasp"
+	"github.com/jackfish212/grasp/mounts"
+)
+
+// setupCrossMount returns a VOS with a MemFS at /mem and a LocalFS at /disk
+// in addition to the standard root, along with the LocalFS directory.
+func setupCrossMount(t *testing.T) (*grasp.VirtualOS, *grasp.Shell, string) {
+	t.Helper()
+	v, sh := setupIntegration(t)
+
+	mem := mounts.NewMemFS(grasp.PermRW)
+	mem.AddDir("docs")
+	mem.AddFile("docs/a.txt", []byte("alpha"), grasp.PermRW)
+	mem.AddFile("docs/b.md", []byte("bravo"), grasp.PermRW)
+	if err := v.Mount("/mem", mem); err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "c.txt"), []byte("charlie"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := v.Mount("/disk", mounts.NewLocalFS(dir, grasp.PermRW)); err != nil {
+		t.Fatal(err)
+	}
+	return v, sh, dir
+}
+
+// remoteFS is a read-only provider serving files from an HTTP server, as a
+// stand-in for network-backed mounts such as httpfs. The copy from a real
+// HTTPFS into dbfs is tested in dbfs, which can import both.
+type remoteFS struct {
+	base  string
+	files []string
+}
+
+func (r *remoteFS) Stat(_ context.Context, path string) (*grasp.Entry, error) {
+	if path == "" {
+		return &grasp.Entry{Name: "/", IsDir: true, Perm: grasp.PermRX}, nil
+	}
+	for _, f := range r.files {
+		if f == path {
+			return &grasp.Entry{Name: f, Path: f, Perm: grasp.PermRO}, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s", grasp.ErrNotFound, path)
+}
+
+func (r *remoteFS) List(_ context.Context, path string, _ grasp.ListOpts) ([]grasp.Entry, error) {
+	if path != "" {
+		return nil, fmt.Errorf("%w: %s", grasp.ErrNotDir, path)
+	}
+	var out []grasp.Entry
+	for _, f := range r.files {
+		out = append(out, grasp.Entry{Name: f, Path: f, Perm: grasp.PermRO})
+	}
+	return out, nil
+}
+
+func (r *remoteFS) Open(ctx context.Context, path string) (grasp.File, error) {
+	e, err := r.Stat(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, r.base+"/"+path, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	return grasp.NewFile(path, e, resp.Body), nil
+}
+
+func TestCrossMountCopyMemToLocal(t *testing.T) {
+	_, sh, dir := setupCrossMount(t)
+	ctx := context.Background()
+
+	if r := sh.Execute(ctx, "cp /mem/docs/a.txt /disk/a.txt"); r.Code != 0 {
+		t.Fatalf("cp: %q", r.Output)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "a.txt"))
+	if err != nil || string(data) != "alpha" {
+		t.Errorf("on-disk copy = %q, %v; want alpha", data, err)
+	}
+
+	if r := sh.Execute(ctx, "cp -r /mem/docs /disk/docs"); r.Code != 0 {
+		t.Fatalf("cp -r: %q", r.Output)
+	}
+	data, err = os.ReadFile(filepath.Join(dir, "docs", "b.md"))
+	if err != nil || string(data) != "bravo" {
+		t.Errorf("recursive copy = %q, %v; want bravo", data, err)
+	}
+}
+
+func TestCrossMountCopyRemoteToStore(t *testing.T) {
+	v, sh, _ := setupCrossMount(t)
+	ctx := context.Background()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		_, _ = fmt.Fprintf(w, "body of %s", strings.TrimPrefix(req.URL.Path, "/"))
+	}))
+	defer srv.Close()
+	if err := v.Mount("/remote", &remoteFS{base: srv.URL, files: []string{"feed.xml"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	if r := sh.Execute(ctx, "cp /remote/feed.xml /mem/feed.xml"); r.Code != 0 {
+		t.Fatalf("cp: %q", r.Output)
+	}
+	if r := sh.Execute(ctx, "cat /mem/feed.xml"); r.Output != "body of feed.xml" {
+		t.Errorf("copied content = %q", r.Output)
+	}
+
+	// The remote mount is read-only; copying into it must fail.
+	if r := sh.Execute(ctx, "cp /mem/docs/a.txt /remote/a.txt"); r.Code == 0 {
+		t.Error("cp into read-only remote mount should fail")
+	}
+}
+
+func TestCrossMountMove(t *testing.T) {
+	v, sh, dir := setupCrossMount(t)
+	ctx := context.Background()
+
+	// Across providers, Rename copies the entry and removes the original.
+	if err := v.Rename(ctx, "/mem/docs/a.txt", "/disk/a.txt"); err != nil {
+		t.Fatalf("cross-mount Rename: %v", err)
+	}
+	if _, err := v.Stat(ctx, "/mem/docs/a.txt"); !errors.Is(err, grasp.ErrNotFound) {
+		t.Errorf("source still present after move: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "a.txt")); err != nil {
+		t.Errorf("cross-mount move did not write to disk: %v", err)
+	}
+
+	if r := sh.Execute(ctx, "mv /disk/c.txt /disk/d.txt"); r.Code != 0 {
+		t.Fatalf("same-mount mv: %q", r.Output)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "d.txt")); err != nil {
+		t.Errorf("same-mount mv did not rename on disk: %v", err)
+	}
+}
+
+func TestCrossMountGlob(t *testing.T) {
+	_, sh, _ := setupCrossMount(t)
+	ctx := context.Background()
+
+	// The first component is matched against mount points, the rest against
+	// each mount's own entries.
+	r := sh.Execute(ctx, "echo /*/*.txt /mem/*/*.txt")
+	if strings.TrimSpace(r.Output) != "/disk/c.txt /mem/docs/a.txt" {
+		t.Errorf("glob across mounts = %q", r.Output)
+	}
+}
+
+func TestCrossMountFind(t *testing.T) {
+	_, sh, _ := setupCrossMount(t)
+	ctx := context.Background()
+
+	r := sh.Execute(ctx, "find / -name *.txt")
+	for _, want := range []string{"/mem/docs/a.txt", "/disk/c.txt"} {
+		if !strings.Contains(r.Output, want) {
+			t.Errorf("find / should reach %s across mounts: %q", want, r.Output)
+		}
+	}
+}
+
+func TestCrossMountWatch(t *testing.T) {
+	v, _, _ := setupCrossMount(t)
+	ctx := context.Background()
+
+	w := v.Watch("/", grasp.EventWrite)
+	defer func() { _ = w.Close() }()
+
+	if err := v.Write(ctx, "/mem/new.txt", strings.NewReader("m")); err != nil {
+		t.Fatal(err)
+	}
+	if err := v.Write(ctx, "/disk/new.txt", strings.NewReader("d")); err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	for len(got) < 2 {
+		select {
+		case ev := <-w.Events():
+			got = append(got, ev.Path)
+		case <-time.After(time.Second):
+			t.Fatalf("timeout waiting for events, got %v", got)
+		}
+	}
+	if got[0] != "/mem/new.txt" || got[1] != "/disk/new.txt" {
+		t.Errorf("events = %v, want writes on /mem and /disk", got)
+	}
+}
+
+func TestOpenHonorsContextDeadline(t *testing.T) {
+	v, _, _ := setupCrossMount(t)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		select {
+		case <-time.After(100 * time.Millisecond):
+		case <-req.Context().Done():
+			return
+		}
+		_, _ = fmt.Fprint(w, `{"number":1,"title":"late"}`)
+	}))
+	defer srv.Close()
+
+	if err := v.Mount("/remote", &remoteFS{base: srv.URL, files: []string{"feed.xml"}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := v.Mount("/github", mounts.NewGitHubFS(mounts.WithGitHubBaseURL(srv.URL))); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, p := range []string{"/remote/feed.xml", "/github/repos/o/r/issues/1"} {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		start := time.Now()
+		f, err := v.Open(ctx, p)
+		if err == nil {
+			// Providers may return before the body arrives; the read must
+			// still observe the deadline.
+			_, err = io.ReadAll(f)
+			_ = f.Close()
+		}
+		cancel()
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("Open(%s) err = %v, want context.DeadlineExceeded", p, err)
+		}
+		if elapsed := time.Since(start); elapsed >= 100*time.Millisecond {
+			t.Errorf("Open(%s) took %v, deadline not propagated", p, elapsed)
+		}
+	}
+}
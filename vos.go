@@ -3,12 +3,16 @@ package grasp
 import (
 	"bytes"
 	"context"
+	"encoding/base64"
 	"errors"
 	"fmt"
 	"io"
+	"log/slog"
 	stdpath "path"
 	"sort"
 	"strings"
+	"sync"
+	"sync/atomic"
 
 	"github.com/jackfish212/grasp/shell"
 )
@@ -19,17 +23,29 @@ import (
 type VirtualOS struct {
 	mounts *MountTable
 	hub    *watchHub
+	topics *topicHub
+
+	auditLog   atomic.Pointer[slog.Logger]
+	defaultCwd atomic.Value // string; "" means the user's home
+
+	pluginMu sync.Mutex // serialises RegisterPlugin
+	plugins  atomic.Pointer[[]Plugin]
 }
 
 // New creates a new VirtualOS instance.
 func New() *VirtualOS {
-	return &VirtualOS{mounts: NewMountTable(), hub: newWatchHub()}
+	return &VirtualOS{mounts: NewMountTable(), hub: newWatchHub(), topics: newTopicHub()}
 }
 
 // Watch creates a Watcher that receives events for paths under prefix
-// matching the given event mask. Use "/" or "" to watch all paths.
-func (v *VirtualOS) Watch(prefix string, mask EventType) *Watcher {
-	return v.hub.watch(prefix, mask)
+// matching the given event mask. Use "/" or "" to watch all paths. An
+// optional WatchOpts enables debouncing.
+func (v *VirtualOS) Watch(prefix string, mask EventType, opts ...WatchOpts) *Watcher {
+	var o WatchOpts
+	if len(opts) > 0 {
+		o = opts[0]
+	}
+	return v.hub.watch(prefix, mask, o)
 }
 
 // Notify emits a filesystem watch event. Use this for providers that generate
@@ -39,8 +55,13 @@ func (v *VirtualOS) Notify(evType EventType, path string) {
 }
 
 // Mount registers a Provider at the given path.
-func (v *VirtualOS) Mount(path string, p Provider) error {
+func (v *VirtualOS) Mount(path string, p Provider) (err error) {
 	path = CleanPath(path)
+	defer func() {
+		if err == nil {
+			v.pluginsMount(path, p)
+		}
+	}()
 
 	if path == "/" {
 		return v.mounts.Mount(path, p)
@@ -72,6 +93,26 @@ func (v *VirtualOS) Mount(path string, p Provider) error {
 	return v.mounts.Mount(path, p)
 }
 
+// MountGroup mounts one provider at several paths, for providers that serve
+// more than one namespace. Each mount behaves as if made by Mount: the
+// provider sees paths relative to whichever mount point matched. Paths are
+// mounted in order; if any fails, those already mounted are unmounted again
+// and the error is returned.
+func (v *VirtualOS) MountGroup(paths []string, p Provider) error {
+	if len(paths) == 0 {
+		return fmt.Errorf("%w: empty mount group", ErrNotSupported)
+	}
+	for i, path := range paths {
+		if err := v.Mount(path, p); err != nil {
+			for _, done := range paths[:i] {
+				_ = v.mounts.Unmount(done)
+			}
+			return err
+		}
+	}
+	return nil
+}
+
 // Unmount removes the mount at the given path.
 func (v *VirtualOS) Unmount(path string) error {
 	return v.mounts.Unmount(path)
@@ -83,10 +124,17 @@ func (v *VirtualOS) MountTable() *MountTable {
 }
 
 // Stat returns entry metadata.
-func (v *VirtualOS) Stat(ctx context.Context, path string) (*Entry, error) {
+func (v *VirtualOS) Stat(ctx context.Context, path string) (entry *Entry, err error) {
 	path = CleanPath(path)
+	defer func() { v.audit(ctx, "stat", path, err) }()
 
-	if p, inner, err := v.mounts.Resolve(path); err == nil {
+	// Like lstat, a link in the final component is reported, not followed.
+	target, err := v.resolveParent(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+
+	if p, inner, err := v.mounts.Resolve(target); err == nil {
 		// If inner is empty, this is a mount point itself - always return as directory
 		if inner == "" {
 			return &Entry{
@@ -102,7 +150,7 @@ func (v *VirtualOS) Stat(ctx context.Context, path string) (*Entry, error) {
 		}
 	}
 
-	if children := v.mounts.ChildMounts(path); len(children) > 0 {
+	if children := v.mounts.ChildMounts(target); len(children) > 0 {
 		return &Entry{
 			Name:  baseName(path),
 			Path:  path,
@@ -115,14 +163,20 @@ func (v *VirtualOS) Stat(ctx context.Context, path string) (*Entry, error) {
 }
 
 // List returns entries at a path, merging provider entries with virtual directories.
-func (v *VirtualOS) List(ctx context.Context, path string, opts ListOpts) ([]Entry, error) {
+func (v *VirtualOS) List(ctx context.Context, path string, opts ListOpts) (_ []Entry, err error) {
 	path = CleanPath(path)
+	defer func() { v.audit(ctx, "list", path, err) }()
+
+	target, err := v.resolveSymlinks(ctx, path, maxSymlinkDepth)
+	if err != nil {
+		return nil, err
+	}
 
 	var entries []Entry
 	seen := make(map[string]bool)
 	resolved := false
 
-	if p, inner, err := v.mounts.Resolve(path); err == nil {
+	if p, inner, err := v.mounts.Resolve(target); err == nil {
 		resolved = true
 		if provEntries, listErr := p.List(ctx, inner, opts); listErr == nil {
 			for _, e := range provEntries {
@@ -135,7 +189,7 @@ func (v *VirtualOS) List(ctx context.Context, path string, opts ListOpts) ([]Ent
 		}
 	}
 
-	for _, child := range v.mounts.ChildMounts(path) {
+	for _, child := range v.mounts.ChildMounts(target) {
 		if !seen[child.Name] {
 			entries = append(entries, child)
 			seen[child.Name] = true
@@ -149,14 +203,188 @@ func (v *VirtualOS) List(ctx context.Context, path string, opts ListOpts) ([]Ent
 	return entries, nil
 }
 
+// ReadDir returns the entries of the directory at path, including mount
+// points beneath it, in provider order. It is List with default options.
+//
+//	entries, err := v.ReadDir(ctx, "/data")
+//	for _, e := range entries {
+//		fmt.Println(e.Name, e.IsDir)
+//	}
+func (v *VirtualOS) ReadDir(ctx context.Context, path string) ([]Entry, error) {
+	return v.List(ctx, path, ListOpts{})
+}
+
+// ReadDirSorted is like ReadDir but returns the entries sorted by name,
+// giving a stable order regardless of provider.
+//
+//	entries, err := v.ReadDirSorted(ctx, "/")
+//	// entries[0].Name <= entries[1].Name <= ...
+func (v *VirtualOS) ReadDirSorted(ctx context.Context, path string) ([]Entry, error) {
+	entries, err := v.ReadDir(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name < entries[j].Name })
+	return entries, nil
+}
+
+// ListPage lists one page of path, starting after opts.Cursor and returning
+// at most opts.Limit entries. Providers implementing PagedLister paginate
+// natively when path has no child mounts; otherwise the full listing is
+// sorted by name and paged here.
+func (v *VirtualOS) ListPage(ctx context.Context, path string, opts ListOpts) (*ListResult, error) {
+	path = CleanPath(path)
+
+	if p, inner, err := v.mounts.Resolve(path); err == nil && len(v.mounts.ChildMounts(path)) == 0 {
+		if pl, ok := p.(PagedLister); ok {
+			res, err := pl.ListPage(ctx, inner, opts)
+			if err != nil {
+				return nil, err
+			}
+			for i := range res.Entries {
+				if !strings.HasPrefix(res.Entries[i].Path, "/") {
+					res.Entries[i].Path = CleanPath(path + "/" + res.Entries[i].Name)
+				}
+			}
+			return res, nil
+		}
+	}
+
+	after := ""
+	if opts.Cursor != "" {
+		b, err := base64.RawURLEncoding.DecodeString(opts.Cursor)
+		if err != nil {
+			return nil, fmt.Errorf("invalid list cursor %q: %w", opts.Cursor, err)
+		}
+		after = string(b)
+	}
+
+	entries, err := v.List(ctx, path, opts)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name < entries[j].Name })
+	start := sort.Search(len(entries), func(i int) bool { return entries[i].Name > after })
+	entries = entries[start:]
+
+	res := &ListResult{Entries: entries}
+	if opts.Limit > 0 && len(entries) > opts.Limit {
+		res.Entries = entries[:opts.Limit]
+		res.NextCursor = base64.RawURLEncoding.EncodeToString([]byte(res.Entries[opts.Limit-1].Name))
+	}
+	return res, nil
+}
+
+// Compress asks the mount's CompressProvider to replace path with a
+// compressed path+".gz" natively. Plugins see it as an open for writing,
+// and watchers a rename. It returns ErrNotSupported when the provider has
+// no native compression, so callers can fall back to rewriting the content
+// themselves.
+func (v *VirtualOS) Compress(ctx context.Context, path string) error {
+	path = CleanPath(path)
+	p, inner, err := v.mounts.Resolve(path)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrNotFound, path)
+	}
+	if err := v.pluginsOpen(ctx, path, true); err != nil {
+		return err
+	}
+	cp, ok := p.(CompressProvider)
+	if !ok {
+		return fmt.Errorf("%w: %s (provider does not support compression)", ErrNotSupported, path)
+	}
+	if err := cp.Compress(ctx, inner); err != nil {
+		return err
+	}
+	v.hub.emitRename(EventRename, path+".gz", path)
+	return nil
+}
+
+// Decompress asks the mount's CompressProvider to replace path with its
+// decompressed DecompressedName(path) natively. See Compress.
+func (v *VirtualOS) Decompress(ctx context.Context, path string) error {
+	path = CleanPath(path)
+	p, inner, err := v.mounts.Resolve(path)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrNotFound, path)
+	}
+	if err := v.pluginsOpen(ctx, path, true); err != nil {
+		return err
+	}
+	cp, ok := p.(CompressProvider)
+	if !ok {
+		return fmt.Errorf("%w: %s (provider does not support compression)", ErrNotSupported, path)
+	}
+	if err := cp.Decompress(ctx, inner); err != nil {
+		return err
+	}
+	if dst, ok := DecompressedName(path); ok {
+		v.hub.emitRename(EventRename, dst, path)
+	}
+	return nil
+}
+
+// SetMeta sets a metadata key on path via the mount's MetaProvider.
+// An empty value removes the key.
+func (v *VirtualOS) SetMeta(ctx context.Context, path, key, value string) error {
+	path = CleanPath(path)
+	p, inner, err := v.mounts.Resolve(path)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrNotFound, path)
+	}
+	mp, ok := p.(MetaProvider)
+	if !ok {
+		return fmt.Errorf("%w: %s (provider does not support metadata)", ErrNotSupported, path)
+	}
+	return mp.SetMeta(ctx, inner, key, value)
+}
+
+// GetMeta reads a metadata key from path via the mount's MetaProvider.
+func (v *VirtualOS) GetMeta(ctx context.Context, path, key string) (string, error) {
+	path = CleanPath(path)
+	p, inner, err := v.mounts.Resolve(path)
+	if err != nil {
+		return "", fmt.Errorf("%w: %s", ErrNotFound, path)
+	}
+	mp, ok := p.(MetaProvider)
+	if !ok {
+		return "", fmt.Errorf("%w: %s (provider does not support metadata)", ErrNotSupported, path)
+	}
+	return mp.GetMeta(ctx, inner, key)
+}
+
+// Chmod changes the permission bits of path via the mount's Chmoder.
+func (v *VirtualOS) Chmod(ctx context.Context, path string, perm Perm) error {
+	path = CleanPath(path)
+	p, inner, err := v.mounts.Resolve(path)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrNotFound, path)
+	}
+	c, ok := p.(Chmoder)
+	if !ok {
+		return fmt.Errorf("%w: %s (provider does not support chmod)", ErrNotSupported, path)
+	}
+	return c.Chmod(ctx, inner, perm)
+}
+
 // OpenFile opens a file with the given flags.
-func (v *VirtualOS) OpenFile(ctx context.Context, path string, flag OpenFlag) (File, error) {
+func (v *VirtualOS) OpenFile(ctx context.Context, path string, flag OpenFlag) (_ File, err error) {
 	path = CleanPath(path)
+	defer func() { v.audit(ctx, "open", path, err) }()
+
+	resolved, err := v.resolveSymlinks(ctx, path, maxSymlinkDepth)
+	if err != nil {
+		return nil, err
+	}
+	path = resolved
 
 	p, inner, err := v.mounts.Resolve(path)
 	if err != nil {
 		return nil, fmt.Errorf("%w: %s", ErrNotFound, path)
 	}
+	if err := v.pluginsOpen(ctx, path, flag.IsWritable()); err != nil {
+		return nil, err
+	}
 
 	if flag.IsReadable() && !flag.IsWritable() {
 		r, ok := p.(Readable)
@@ -202,14 +430,25 @@ func (v *VirtualOS) OpenFile(ctx context.Context, path string, flag OpenFlag) (F
 	return nil, fmt.Errorf("%w: invalid open flags for %s", ErrNotSupported, path)
 }
 
-// Open opens a file for reading.
-func (v *VirtualOS) Open(ctx context.Context, path string) (File, error) {
+// Open opens a file for reading. ctx is passed to the provider, so network
+// backed mounts give up once its deadline passes.
+func (v *VirtualOS) Open(ctx context.Context, path string) (_ File, err error) {
 	path = CleanPath(path)
+	defer func() { v.audit(ctx, "open", path, err) }()
+
+	resolved, err := v.resolveSymlinks(ctx, path, maxSymlinkDepth)
+	if err != nil {
+		return nil, err
+	}
+	path = resolved
 
 	p, inner, err := v.mounts.Resolve(path)
 	if err != nil {
 		return nil, fmt.Errorf("%w: %s", ErrNotFound, path)
 	}
+	if err := v.pluginsOpen(ctx, path, false); err != nil {
+		return nil, err
+	}
 
 	r, ok := p.(Readable)
 	if !ok {
@@ -226,13 +465,23 @@ func (v *VirtualOS) Open(ctx context.Context, path string) (File, error) {
 }
 
 // Write writes content to a path.
-func (v *VirtualOS) Write(ctx context.Context, path string, reader io.Reader) error {
+func (v *VirtualOS) Write(ctx context.Context, path string, reader io.Reader) (err error) {
 	path = CleanPath(path)
+	defer func() { v.audit(ctx, "write", path, err) }()
+
+	resolved, err := v.resolveSymlinks(ctx, path, maxSymlinkDepth)
+	if err != nil {
+		return err
+	}
+	path = resolved
 
 	p, inner, err := v.mounts.Resolve(path)
 	if err != nil {
 		return fmt.Errorf("%w: %s", ErrNotFound, path)
 	}
+	if err := v.pluginsWrite(ctx, path); err != nil {
+		return err
+	}
 
 	w, ok := p.(Writable)
 	if !ok {
@@ -259,6 +508,12 @@ func (v *VirtualOS) Write(ctx context.Context, path string, reader io.Reader) er
 func (v *VirtualOS) Exec(ctx context.Context, path string, args []string, stdin io.Reader) (io.ReadCloser, error) {
 	path = CleanPath(path)
 
+	resolved, err := v.resolveSymlinks(ctx, path, maxSymlinkDepth)
+	if err != nil {
+		return nil, err
+	}
+	path = resolved
+
 	p, inner, err := v.mounts.Resolve(path)
 	if err != nil {
 		return nil, fmt.Errorf("%w: %s", ErrNotFound, path)
@@ -284,6 +539,12 @@ func (v *VirtualOS) Exec(ctx context.Context, path string, args []string, stdin
 func (v *VirtualOS) Mkdir(ctx context.Context, path string, perm Perm) error {
 	path = CleanPath(path)
 
+	resolved, err := v.resolveParent(ctx, path)
+	if err != nil {
+		return err
+	}
+	path = resolved
+
 	p, inner, err := v.mounts.Resolve(path)
 	if err != nil {
 		return fmt.Errorf("%w: %s", ErrNotFound, path)
@@ -302,8 +563,15 @@ func (v *VirtualOS) Mkdir(ctx context.Context, path string, perm Perm) error {
 }
 
 // Remove removes a file or directory at the given path.
-func (v *VirtualOS) Remove(ctx context.Context, path string) error {
+func (v *VirtualOS) Remove(ctx context.Context, path string) (err error) {
 	path = CleanPath(path)
+	defer func() { v.audit(ctx, "remove", path, err) }()
+
+	resolved, err := v.resolveParent(ctx, path)
+	if err != nil {
+		return err
+	}
+	path = resolved
 
 	p, inner, err := v.mounts.Resolve(path)
 	if err != nil {
@@ -328,10 +596,18 @@ func (v *VirtualOS) Remove(ctx context.Context, path string) error {
 	return nil
 }
 
-// Rename moves/renames an entry.
+// Rename moves/renames an entry. Within one provider this is the
+// provider's own Rename. Across mounts the entry is copied and the original
+// removed; see renameAcross.
 func (v *VirtualOS) Rename(ctx context.Context, oldPath, newPath string) error {
-	oldPath = CleanPath(oldPath)
-	newPath = CleanPath(newPath)
+	oldPath, err := v.resolveParent(ctx, CleanPath(oldPath))
+	if err != nil {
+		return err
+	}
+	newPath, err = v.resolveParent(ctx, CleanPath(newPath))
+	if err != nil {
+		return err
+	}
 
 	pOld, innerOld, err := v.mounts.Resolve(oldPath)
 	if err != nil {
@@ -343,15 +619,15 @@ func (v *VirtualOS) Rename(ctx context.Context, oldPath, newPath string) error {
 		return fmt.Errorf("%w: %s", ErrNotFound, newPath)
 	}
 
-	if pOld != pNew {
-		return fmt.Errorf("%w: cross-mount rename not supported (%s → %s)", ErrNotSupported, oldPath, newPath)
-	}
-
 	m, ok := pOld.(Mutable)
 	if !ok {
 		return fmt.Errorf("%w: %s (provider is not mutable)", ErrNotSupported, oldPath)
 	}
 
+	if pOld != pNew {
+		return v.renameAcross(ctx, pOld, oldPath, innerOld, pNew, newPath, innerNew)
+	}
+
 	if err := m.Rename(ctx, innerOld, innerNew); err != nil {
 		return err
 	}
@@ -365,6 +641,12 @@ func (v *VirtualOS) Rename(ctx context.Context, oldPath, newPath string) error {
 func (v *VirtualOS) Touch(ctx context.Context, path string) error {
 	path = CleanPath(path)
 
+	resolved, err := v.resolveSymlinks(ctx, path, maxSymlinkDepth)
+	if err != nil {
+		return err
+	}
+	path = resolved
+
 	p, inner, err := v.mounts.Resolve(path)
 	if err != nil {
 		return fmt.Errorf("%w: %s", ErrNotFound, path)
@@ -482,7 +764,39 @@ func (v *VirtualOS) Search(ctx context.Context, query string, opts SearchOpts) (
 	return all, errors.Join(errs...)
 }
 
-// Shell creates a new Shell bound to this VOS.
-func (v *VirtualOS) Shell(user string) *shell.Shell {
-	return shell.NewShell(v, user)
+// Shell creates a new Shell bound to this VOS. The shell starts in the
+// default working directory if one is set, otherwise in the user's home.
+func (v *VirtualOS) Shell(user string, opts ...shell.ShellOption) *shell.Shell {
+	sh := shell.NewShell(v, user, opts...)
+	if cwd := v.DefaultCwd(); cwd != "" {
+		sh.Env.Set("PWD", cwd)
+	}
+	return sh
+}
+
+// SetDefaultCwd sets the working directory that shells created afterwards by
+// Shell start in. path must be an existing directory. An empty path restores
+// the default of starting in the user's home. Existing shells are unaffected.
+func (v *VirtualOS) SetDefaultCwd(path string) error {
+	if path == "" {
+		v.defaultCwd.Store("")
+		return nil
+	}
+	path = CleanPath(path)
+	entry, err := v.Stat(context.Background(), path)
+	if err != nil {
+		return err
+	}
+	if !entry.IsDir {
+		return fmt.Errorf("%w: %s", ErrNotDir, path)
+	}
+	v.defaultCwd.Store(path)
+	return nil
+}
+
+// DefaultCwd returns the working directory set by SetDefaultCwd, or "" if
+// none is set.
+func (v *VirtualOS) DefaultCwd() string {
+	cwd, _ := v.defaultCwd.Load().(string)
+	return cwd
 }
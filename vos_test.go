@@ -1,9 +1,13 @@
 package grasp
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
@@ -137,6 +141,38 @@ func TestVOSListNotFound(t *testing.T) {
 	}
 }
 
+func TestVOSReadDir(t *testing.T) {
+	v := setupVOS(t)
+	if err := v.Mount("/mnt", mounts.NewMemFS(PermRW)); err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	entries, err := v.ReadDir(ctx, "/")
+	if err != nil {
+		t.Fatalf("ReadDir /: %v", err)
+	}
+	if len(entries) != 3 {
+		t.Errorf("ReadDir / = %v, want bin, home and mnt", entries)
+	}
+
+	sorted, err := v.ReadDirSorted(ctx, "/")
+	if err != nil {
+		t.Fatalf("ReadDirSorted /: %v", err)
+	}
+	var names []string
+	for _, e := range sorted {
+		names = append(names, e.Name)
+	}
+	if strings.Join(names, ",") != "bin,home,mnt" {
+		t.Errorf("ReadDirSorted / = %v", names)
+	}
+
+	if _, err := New().ReadDirSorted(ctx, "/nowhere"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("ReadDirSorted unmounted dir: err = %v, want ErrNotFound", err)
+	}
+}
+
 func TestVOSOpenAndRead(t *testing.T) {
 	v := setupVOS(t)
 	ctx := context.Background()
@@ -294,10 +330,12 @@ func TestVOSRenameSameMount(t *testing.T) {
 
 func TestVOSRenameCrossMount(t *testing.T) {
 	v := New()
-	if err := v.Mount("/a", mounts.NewMemFS(PermRW)); err != nil {
+	a := mounts.NewMemFS(PermRW)
+	if err := v.Mount("/a", a); err != nil {
 		t.Fatal(err)
 	}
-	if err := v.Mount("/b", mounts.NewMemFS(PermRW)); err != nil {
+	dir := t.TempDir()
+	if err := v.Mount("/b", mounts.NewLocalFS(dir, PermRW)); err != nil {
 		t.Fatal(err)
 	}
 
@@ -305,10 +343,116 @@ func TestVOSRenameCrossMount(t *testing.T) {
 	if err := v.Write(ctx, "/a/file.txt", strings.NewReader("data")); err != nil {
 		t.Fatal(err)
 	}
+	if err := v.Mkdir(ctx, "/a/tree", PermRW); err != nil {
+		t.Fatal(err)
+	}
+	if err := v.Write(ctx, "/a/tree/sub/deep.txt", strings.NewReader("deep")); err != nil {
+		t.Fatal(err)
+	}
 
-	err := v.Rename(ctx, "/a/file.txt", "/b/file.txt")
-	if !errors.Is(err, ErrNotSupported) {
-		t.Errorf("cross-mount rename should fail with ErrNotSupported, got: %v", err)
+	// Same mount: the provider renames in place.
+	if err := v.Rename(ctx, "/a/file.txt", "/a/moved.txt"); err != nil {
+		t.Fatalf("same-mount Rename: %v", err)
+	}
+	if got := readAllVOS(t, v, "/a/moved.txt"); got != "data" {
+		t.Errorf("/a/moved.txt = %q, want data", got)
+	}
+
+	// Across mounts: copied, then the original removed.
+	w := v.Watch("/b", EventRename)
+	defer func() { _ = w.Close() }()
+	if err := v.Rename(ctx, "/a/moved.txt", "/b/file.txt"); err != nil {
+		t.Fatalf("cross-mount Rename: %v", err)
+	}
+	if got := readAllVOS(t, v, "/b/file.txt"); got != "data" {
+		t.Errorf("/b/file.txt = %q, want data", got)
+	}
+	if _, err := v.Stat(ctx, "/a/moved.txt"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("source still present after move: %v", err)
+	}
+	select {
+	case ev := <-w.Events():
+		if ev.Path != "/b/file.txt" || ev.OldPath != "/a/moved.txt" {
+			t.Errorf("rename event = %+v", ev)
+		}
+	case <-time.After(time.Second):
+		t.Error("timeout waiting for rename event")
+	}
+
+	if err := v.Rename(ctx, "/a/tree", "/b/tree"); err != nil {
+		t.Fatalf("cross-mount directory Rename: %v", err)
+	}
+	if got := readAllVOS(t, v, "/b/tree/sub/deep.txt"); got != "deep" {
+		t.Errorf("/b/tree/sub/deep.txt = %q, want deep", got)
+	}
+	if _, err := v.Stat(ctx, "/a/tree"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("source tree still present after move: %v", err)
+	}
+
+	if err := v.Rename(ctx, "/b", "/a/b"); !errors.Is(err, ErrNotSupported) {
+		t.Errorf("moving a mount point: err = %v, want ErrNotSupported", err)
+	}
+}
+
+// failingWriteFS is a MemFS whose writes to "broken" paths fail after
+// consuming part of the input, like a destination that fills up mid-copy.
+type failingWriteFS struct {
+	*mounts.MemFS
+}
+
+func (fs failingWriteFS) Write(ctx context.Context, path string, r io.Reader) error {
+	if strings.Contains(path, "broken") {
+		_, _ = io.CopyN(io.Discard, r, 2)
+		return errors.New("disk full")
+	}
+	return fs.MemFS.Write(ctx, path, r)
+}
+
+func TestVOSRenameCrossMountWriteFailure(t *testing.T) {
+	v := New()
+	if err := v.Mount("/a", mounts.NewMemFS(PermRW)); err != nil {
+		t.Fatal(err)
+	}
+	if err := v.Mount("/b", failingWriteFS{mounts.NewMemFS(PermRW)}); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	if err := v.Write(ctx, "/a/ok.txt", strings.NewReader("ok")); err != nil {
+		t.Fatal(err)
+	}
+	if err := v.Mkdir(ctx, "/a/dir", PermRW); err != nil {
+		t.Fatal(err)
+	}
+	if err := v.Write(ctx, "/a/dir/broken.txt", strings.NewReader("payload")); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct{ src, dst string }{
+		{"/a/dir/broken.txt", "/b/broken.txt"},
+		{"/a/dir", "/b/dir"},
+	} {
+		if err := v.Rename(ctx, tc.src, tc.dst); err == nil {
+			t.Errorf("Rename(%s, %s) succeeded, want write error", tc.src, tc.dst)
+		}
+		if got := readAllVOS(t, v, "/a/dir/broken.txt"); got != "payload" {
+			t.Errorf("source after failed move of %s = %q, want payload", tc.src, got)
+		}
+		entries, err := v.ReadDir(ctx, "/b")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(entries) != 0 {
+			t.Errorf("failed move of %s left %v behind in /b", tc.src, entries)
+		}
+	}
+
+	// The failure does not disturb later moves to the same mount.
+	if err := v.Rename(ctx, "/a/ok.txt", "/b/ok.txt"); err != nil {
+		t.Fatalf("Rename after failure: %v", err)
+	}
+	if got := readAllVOS(t, v, "/b/ok.txt"); got != "ok" {
+		t.Errorf("/b/ok.txt = %q, want ok", got)
 	}
 }
 
@@ -630,3 +774,767 @@ func TestVOSWatchPrefix(t *testing.T) {
 		t.Fatal("timeout waiting for event")
 	}
 }
+
+func TestVOSPublishSubscribe(t *testing.T) {
+	v := New()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	ch1, err := v.Subscribe(ctx, "jobs")
+	if err != nil {
+		t.Fatalf("Subscribe: %v", err)
+	}
+	ch2, err := v.Subscribe(ctx, "jobs")
+	if err != nil {
+		t.Fatalf("Subscribe: %v", err)
+	}
+	other, err := v.Subscribe(ctx, "other")
+	if err != nil {
+		t.Fatalf("Subscribe: %v", err)
+	}
+
+	if err := v.Publish(ctx, "jobs", "done"); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+
+	for _, ch := range []<-chan Event{ch1, ch2} {
+		select {
+		case ev := <-ch:
+			if ev.Topic != "jobs" || ev.Payload != "done" {
+				t.Errorf("unexpected event %+v", ev)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("timeout waiting for event")
+		}
+	}
+
+	select {
+	case ev := <-other:
+		t.Errorf("subscriber of other topic received %+v", ev)
+	default:
+	}
+}
+
+func TestVOSSubscribeCancel(t *testing.T) {
+	v := New()
+	ctx, cancel := context.WithCancel(context.Background())
+
+	ch, err := v.Subscribe(ctx, "jobs")
+	if err != nil {
+		t.Fatalf("Subscribe: %v", err)
+	}
+	cancel()
+
+	select {
+	case _, ok := <-ch:
+		if ok {
+			t.Error("expected channel to be closed")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("channel not closed after cancel")
+	}
+
+	if err := v.Publish(context.Background(), "jobs", "late"); err != nil {
+		t.Fatalf("Publish after cancel: %v", err)
+	}
+}
+
+func TestVOSSubscribeEmptyTopic(t *testing.T) {
+	v := New()
+	ctx := context.Background()
+	if _, err := v.Subscribe(ctx, ""); !errors.Is(err, ErrEmptyTopic) {
+		t.Errorf("Subscribe empty topic: got %v, want ErrEmptyTopic", err)
+	}
+	if err := v.Publish(ctx, "", "x"); !errors.Is(err, ErrEmptyTopic) {
+		t.Errorf("Publish empty topic: got %v, want ErrEmptyTopic", err)
+	}
+}
+
+func TestVOSAuditLog(t *testing.T) {
+	v := setupVOS(t)
+	var buf bytes.Buffer
+	v.WithAuditLog(slog.New(slog.NewTextHandler(&buf, nil)))
+
+	ctx := WithEnv(context.Background(), map[string]string{"USER": "alice"})
+	if err := v.Write(ctx, "/audit.txt", strings.NewReader("x")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := v.Stat(ctx, "/missing"); err == nil {
+		t.Fatal("expected error for missing path")
+	}
+
+	out := buf.String()
+	for _, want := range []string{
+		"user=alice op=write path=/audit.txt status=ok",
+		"user=alice op=stat path=/missing status=error",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("audit log missing %q:\n%s", want, out)
+		}
+	}
+
+	buf.Reset()
+	v.WithAuditLog(nil)
+	_, _ = v.Stat(ctx, "/audit.txt")
+	if buf.Len() != 0 {
+		t.Errorf("audit log written after disabling: %s", buf.String())
+	}
+}
+
+func TestVOSListPage(t *testing.T) {
+	v := setupVOS(t)
+	ctx := context.Background()
+	for _, name := range []string{"d", "b", "a", "c", "e"} {
+		if err := v.Write(ctx, "/page/"+name, strings.NewReader(name)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// listPages collects every page of path, checking the page size.
+	listPages := func(path string) (names []string, pages int) {
+		t.Helper()
+		opts := ListOpts{Limit: 2}
+		for {
+			res, err := v.ListPage(ctx, path, opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			pages++
+			if len(res.Entries) > 2 {
+				t.Fatalf("page has %d entries, want at most 2", len(res.Entries))
+			}
+			for _, e := range res.Entries {
+				names = append(names, e.Name)
+				if want := path + "/" + e.Name; e.Path != want {
+					t.Errorf("entry path = %q, want %q", e.Path, want)
+				}
+			}
+			if res.NextCursor == "" {
+				return names, pages
+			}
+			opts.Cursor = res.NextCursor
+		}
+	}
+
+	// The root MemFS pages natively.
+	names, pages := listPages("/page")
+	if got := strings.Join(names, ","); got != "a,b,c,d,e" {
+		t.Errorf("paged names = %s, want a,b,c,d,e", got)
+	}
+	if pages != 3 {
+		t.Errorf("pages = %d, want 3", pages)
+	}
+
+	// A child mount forces the fallback, which merges the mount point in.
+	if err := v.Mount("/page/bb", mounts.NewMemFS(PermRW)); err != nil {
+		t.Fatal(err)
+	}
+	names, pages = listPages("/page")
+	if got := strings.Join(names, ","); got != "a,b,bb,c,d,e" {
+		t.Errorf("paged names with child mount = %s, want a,b,bb,c,d,e", got)
+	}
+	if pages != 3 {
+		t.Errorf("pages with child mount = %d, want 3", pages)
+	}
+
+	if _, err := v.ListPage(ctx, "/page", ListOpts{Cursor: "!!"}); err == nil {
+		t.Error("expected error for malformed cursor")
+	}
+	if _, err := v.ListPage(ctx, "/home", ListOpts{Cursor: "!!"}); err == nil {
+		t.Error("expected error for malformed cursor from the provider")
+	}
+}
+
+func TestVOSSetGetMeta(t *testing.T) {
+	v := setupVOS(t)
+	ctx := context.Background()
+	if err := v.Write(ctx, "/m.txt", strings.NewReader("x")); err != nil {
+		t.Fatal(err)
+	}
+	if err := v.SetMeta(ctx, "/m.txt", "kind", "note"); err != nil {
+		t.Fatalf("SetMeta: %v", err)
+	}
+	got, err := v.GetMeta(ctx, "/m.txt", "kind")
+	if err != nil || got != "note" {
+		t.Errorf("GetMeta = %q, %v; want note", got, err)
+	}
+	entry, _ := v.Stat(ctx, "/m.txt")
+	if entry.Meta["kind"] != "note" {
+		t.Errorf("Stat meta kind = %q, want note", entry.Meta["kind"])
+	}
+	if _, err := v.GetMeta(ctx, "/m.txt", "missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("GetMeta missing key: err = %v, want ErrNotFound", err)
+	}
+}
+
+type compressingFS struct {
+	*mounts.MemFS
+	compressed []string
+}
+
+func (c *compressingFS) Compress(_ context.Context, path string) error {
+	c.compressed = append(c.compressed, path)
+	return nil
+}
+
+func (c *compressingFS) Decompress(context.Context, string) error { return nil }
+
+func TestVOSCompressDelegates(t *testing.T) {
+	v := setupVOS(t)
+	ctx := context.Background()
+	cfs := &compressingFS{MemFS: mounts.NewMemFS(PermRW)}
+	if err := v.Mount("/z", cfs); err != nil {
+		t.Fatal(err)
+	}
+	if err := v.Compress(ctx, "/z/a.txt"); err != nil {
+		t.Fatalf("Compress: %v", err)
+	}
+	if len(cfs.compressed) != 1 || cfs.compressed[0] != "a.txt" {
+		t.Errorf("provider Compress calls = %v, want [a.txt]", cfs.compressed)
+	}
+	if err := v.Compress(ctx, "/plain.txt"); !errors.Is(err, ErrNotSupported) {
+		t.Errorf("Compress on MemFS: err = %v, want ErrNotSupported", err)
+	}
+}
+
+func TestVOSSetDefaultCwd(t *testing.T) {
+	v := setupVOS(t)
+	ctx := context.Background()
+	if err := v.Mkdir(ctx, "/project", PermRWX); err != nil {
+		t.Fatal(err)
+	}
+	if err := v.Write(ctx, "/file.txt", strings.NewReader("x")); err != nil {
+		t.Fatal(err)
+	}
+
+	if v.DefaultCwd() != "" {
+		t.Errorf("DefaultCwd() = %q, want empty", v.DefaultCwd())
+	}
+	if err := v.SetDefaultCwd("/missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("SetDefaultCwd(missing): err = %v, want ErrNotFound", err)
+	}
+	if err := v.SetDefaultCwd("/file.txt"); !errors.Is(err, ErrNotDir) {
+		t.Errorf("SetDefaultCwd(file): err = %v, want ErrNotDir", err)
+	}
+
+	if err := v.SetDefaultCwd("/project/"); err != nil {
+		t.Fatal(err)
+	}
+	if got := v.DefaultCwd(); got != "/project" {
+		t.Errorf("DefaultCwd() = %q, want /project", got)
+	}
+	if got := v.Shell("alice").Cwd(); got != "/project" {
+		t.Errorf("new shell cwd = %q, want /project", got)
+	}
+
+	if err := v.SetDefaultCwd(""); err != nil {
+		t.Fatal(err)
+	}
+	if got := v.Shell("alice").Cwd(); got != "/home/alice" {
+		t.Errorf("shell cwd after reset = %q, want /home/alice", got)
+	}
+}
+
+func TestVOSSymlink(t *testing.T) {
+	v := setupVOS(t)
+	ctx := context.Background()
+	if err := v.Mkdir(ctx, "/data", PermRWX); err != nil {
+		t.Fatal(err)
+	}
+	if err := v.Write(ctx, "/data/a.txt", strings.NewReader("hello")); err != nil {
+		t.Fatal(err)
+	}
+	if err := v.Symlink(ctx, "a.txt", "/data/link.txt"); err != nil {
+		t.Fatal(err)
+	}
+	if err := v.Symlink(ctx, "/data", "/dlink"); err != nil {
+		t.Fatal(err)
+	}
+
+	if target, err := v.ReadLink(ctx, "/data/link.txt"); err != nil || target != "a.txt" {
+		t.Errorf("ReadLink = %q, %v; want a.txt", target, err)
+	}
+	if _, err := v.ReadLink(ctx, "/data/a.txt"); !errors.Is(err, ErrNotSymlink) {
+		t.Errorf("ReadLink(regular): err = %v, want ErrNotSymlink", err)
+	}
+
+	e, err := v.Stat(ctx, "/data/link.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !e.IsSymlink || e.Path != "/data/link.txt" {
+		t.Errorf("Stat(link) = %+v, want symlink entry", e)
+	}
+
+	// Links are followed in the final component and in parent directories.
+	for _, p := range []string{"/data/link.txt", "/dlink/a.txt", "/dlink/link.txt"} {
+		f, err := v.Open(ctx, p)
+		if err != nil {
+			t.Fatalf("Open(%s): %v", p, err)
+		}
+		data, _ := io.ReadAll(f)
+		_ = f.Close()
+		if string(data) != "hello" {
+			t.Errorf("Open(%s) = %q, want hello", p, data)
+		}
+	}
+	entries, err := v.List(ctx, "/dlink", ListOpts{})
+	if err != nil || len(entries) != 2 {
+		t.Errorf("List(/dlink) = %v, %v; want 2 entries", entries, err)
+	}
+
+	if err := v.Write(ctx, "/data/link.txt", strings.NewReader("changed")); err != nil {
+		t.Fatal(err)
+	}
+	if e, _ := v.Stat(ctx, "/data/a.txt"); e == nil || e.Size != int64(len("changed")) {
+		t.Errorf("write through link did not reach target: %+v", e)
+	}
+
+	// Removing the link leaves the target in place.
+	if err := v.Remove(ctx, "/data/link.txt"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := v.Stat(ctx, "/data/a.txt"); err != nil {
+		t.Errorf("target removed with link: %v", err)
+	}
+}
+
+func TestVOSSymlinkLoop(t *testing.T) {
+	v := setupVOS(t)
+	ctx := context.Background()
+	if err := v.Symlink(ctx, "/b", "/a"); err != nil {
+		t.Fatal(err)
+	}
+	if err := v.Symlink(ctx, "/a", "/b"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := v.Open(ctx, "/a"); !errors.Is(err, ErrTooManyLinks) {
+		t.Errorf("Open(loop): err = %v, want ErrTooManyLinks", err)
+	}
+	if _, err := v.Stat(ctx, "/a"); err != nil {
+		t.Errorf("Stat should not follow the final link: %v", err)
+	}
+}
+
+func TestVOSHardlink(t *testing.T) {
+	v := setupVOS(t)
+	ctx := context.Background()
+	if err := v.Write(ctx, "/orig.txt", strings.NewReader("one")); err != nil {
+		t.Fatal(err)
+	}
+	if err := v.Hardlink(ctx, "/orig.txt", "/alias.txt"); err != nil {
+		t.Fatal(err)
+	}
+	if err := v.Write(ctx, "/alias.txt", strings.NewReader("two")); err != nil {
+		t.Fatal(err)
+	}
+	f, err := v.Open(ctx, "/orig.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := io.ReadAll(f)
+	_ = f.Close()
+	if string(data) != "two" {
+		t.Errorf("orig.txt = %q, want two", data)
+	}
+	if err := v.Remove(ctx, "/orig.txt"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := v.Stat(ctx, "/alias.txt"); err != nil {
+		t.Errorf("alias removed with original: %v", err)
+	}
+
+	other := mounts.NewMemFS(PermRW)
+	if err := v.Mount("/other", other); err != nil {
+		t.Fatal(err)
+	}
+	if err := v.Hardlink(ctx, "/alias.txt", "/other/x.txt"); !errors.Is(err, ErrNotSupported) {
+		t.Errorf("cross-mount Hardlink: err = %v, want ErrNotSupported", err)
+	}
+}
+
+func TestVOSOpenExclusive(t *testing.T) {
+	v := setupVOS(t)
+	ctx := context.Background()
+
+	f, err := v.OpenExclusive(ctx, "/home/agent/notes.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := v.OpenExclusive(ctx, "/home/agent/notes.txt"); !errors.Is(err, ErrLocked) {
+		t.Errorf("second OpenExclusive: err = %v, want ErrLocked", err)
+	}
+	data, err := io.ReadAll(f)
+	if err != nil || string(data) != "my notes" {
+		t.Errorf("read = %q, %v; want my notes", data, err)
+	}
+	if _, err := io.WriteString(f, " and more"); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := v.Open(ctx, "/home/agent/notes.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ = io.ReadAll(r)
+	_ = r.Close()
+	if string(data) != "my notes and more" {
+		t.Errorf("content after Close = %q", data)
+	}
+
+	f, err = v.OpenExclusive(ctx, "/home/agent/new.lock")
+	if err != nil {
+		t.Fatalf("OpenExclusive should create missing files: %v", err)
+	}
+	_ = f.Close()
+	if _, err := v.Stat(ctx, "/home/agent/new.lock"); err != nil {
+		t.Errorf("lock file not created: %v", err)
+	}
+	if _, err := v.OpenExclusive(ctx, "/home"); !errors.Is(err, ErrIsDir) {
+		t.Errorf("OpenExclusive(dir): err = %v, want ErrIsDir", err)
+	}
+}
+
+func TestVOSWatchDebounce(t *testing.T) {
+	v := setupVOS(t)
+	ctx := context.Background()
+
+	w := v.Watch("/home", EventWrite, WatchOpts{Debounce: 50 * time.Millisecond})
+	defer func() { _ = w.Close() }()
+
+	for i := 0; i < 5; i++ {
+		if err := v.Write(ctx, "/home/agent/stream.txt", strings.NewReader(strings.Repeat("x", i+1))); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := v.Write(ctx, "/home/agent/other.txt", strings.NewReader("y")); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case ev := <-w.Events():
+		t.Fatalf("event %v delivered before the debounce window elapsed", ev)
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	got := map[string]int{}
+	timeout := time.After(time.Second)
+	for len(got) < 2 {
+		select {
+		case ev := <-w.Events():
+			got[ev.Path]++
+		case <-timeout:
+			t.Fatalf("timeout waiting for debounced events, got %v", got)
+		}
+	}
+	select {
+	case ev := <-w.Events():
+		t.Errorf("unexpected extra event %v", ev)
+	case <-time.After(100 * time.Millisecond):
+	}
+	if got["/home/agent/stream.txt"] != 1 || got["/home/agent/other.txt"] != 1 {
+		t.Errorf("events = %v, want one per path", got)
+	}
+}
+
+func TestVOSTempFile(t *testing.T) {
+	v := setupVOS(t)
+	ctx := context.Background()
+	for _, dir := range []string{"/tmp", "/data"} {
+		if err := v.Mkdir(ctx, dir, PermRWX); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	p, err := v.TempFile(ctx, "", "scratch-XXXXXX.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// X's are only replaced at the end of the pattern; otherwise a random
+	// suffix is appended, as with os.CreateTemp.
+	if !strings.HasPrefix(p, "/tmp/scratch-XXXXXX.txt") || len(p) != len("/tmp/scratch-XXXXXX.txt")+6 {
+		t.Errorf("TempFile = %q", p)
+	}
+	if e, err := v.Stat(ctx, p); err != nil || e.IsDir || e.Size != 0 {
+		t.Errorf("Stat(%s) = %v, %v; want empty file", p, e, err)
+	}
+
+	p, err = v.TempFile(ctx, "/data/", "run.XXXX")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(p, "/data/run.") || len(p) != len("/data/run.XXXX") {
+		t.Errorf("TempFile in /data = %q", p)
+	}
+
+	d, err := v.TempDir(ctx, "/data", "work-XXX")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e, err := v.Stat(ctx, d); err != nil || !e.IsDir {
+		t.Errorf("Stat(%s) = %v, %v; want directory", d, e, err)
+	}
+
+	seen := map[string]bool{}
+	for i := 0; i < 50; i++ {
+		p, err := v.TempFile(ctx, "/data", "u-XXXXXX")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if seen[p] {
+			t.Fatalf("duplicate temp name %s", p)
+		}
+		seen[p] = true
+	}
+
+	if _, err := v.TempFile(ctx, "/data", "a/XXXXXX"); err == nil {
+		t.Error("pattern with a separator should fail")
+	}
+}
+
+func TestVOSMountGroup(t *testing.T) {
+	v := setupVOS(t)
+	ctx := context.Background()
+	shared := mounts.NewMemFS(PermRW)
+	shared.AddFile("readme.md", []byte("shared"), PermRW)
+
+	if err := v.MountGroup([]string{"/users/alice", "/users/bob"}, shared); err != nil {
+		t.Fatalf("MountGroup: %v", err)
+	}
+	for _, p := range []string{"/users/alice/readme.md", "/users/bob/readme.md"} {
+		rc, err := v.Open(ctx, p)
+		if err != nil {
+			t.Fatalf("Open %s: %v", p, err)
+		}
+		data, _ := io.ReadAll(rc)
+		rc.Close()
+		if string(data) != "shared" {
+			t.Errorf("%s = %q, want %q", p, data, "shared")
+		}
+	}
+
+	// The provider receives paths with the mount prefix stripped, so a write
+	// through one mount point is visible through the other.
+	if err := v.Write(ctx, "/users/alice/new.txt", strings.NewReader("x")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := shared.Stat(ctx, "new.txt"); err != nil {
+		t.Errorf("provider did not see new.txt: %v", err)
+	}
+	if _, err := v.Stat(ctx, "/users/bob/new.txt"); err != nil {
+		t.Errorf("/users/bob/new.txt: %v", err)
+	}
+
+	// A failure part-way through leaves none of the group mounted.
+	err := v.MountGroup([]string{"/users/carol", "/users/alice"}, shared)
+	if !errors.Is(err, ErrAlreadyMounted) {
+		t.Fatalf("expected ErrAlreadyMounted, got %v", err)
+	}
+	if _, err := v.Stat(ctx, "/users/carol"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("/users/carol should have been unmounted, got %v", err)
+	}
+	if err := v.MountGroup(nil, shared); !errors.Is(err, ErrNotSupported) {
+		t.Errorf("empty group: expected ErrNotSupported, got %v", err)
+	}
+}
+
+func TestVOSWalk(t *testing.T) {
+	v := setupVOS(t)
+	ctx := context.Background()
+	data := mounts.NewMemFS(PermRW)
+	data.AddFile("b.txt", []byte("b"), PermRW)
+	data.AddDir("a")
+	data.AddFile("a/z.txt", []byte("z"), PermRW)
+	if err := v.Mount("/data", data); err != nil {
+		t.Fatal(err)
+	}
+	if err := v.Mount("/srv/cache", mounts.NewMemFS(PermRW)); err != nil {
+		t.Fatal(err)
+	}
+
+	var visited []string
+	err := v.Walk(ctx, "/", func(path string, e Entry, err error) error {
+		if err != nil {
+			return err
+		}
+		if path != "/" && e.Path != path {
+			t.Errorf("entry.Path = %q, want %q", e.Path, path)
+		}
+		visited = append(visited, path)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Walk: %v", err)
+	}
+	want := []string{
+		"/", "/bin", "/data", "/data/a", "/data/a/z.txt", "/data/b.txt",
+		"/home", "/home/agent", "/home/agent/notes.txt", "/srv", "/srv/cache",
+	}
+	if strings.Join(visited, ",") != strings.Join(want, ",") {
+		t.Errorf("visited %v\nwant %v", visited, want)
+	}
+
+	// SkipDir on a directory skips its contents; on a file, the rest of
+	// its directory.
+	visited = nil
+	err = v.Walk(ctx, "/", func(path string, e Entry, err error) error {
+		visited = append(visited, path)
+		switch path {
+		case "/home":
+			return SkipDir
+		case "/data/a/z.txt":
+			return SkipDir
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Walk with SkipDir: %v", err)
+	}
+	want = []string{"/", "/bin", "/data", "/data/a", "/data/a/z.txt", "/data/b.txt", "/home", "/srv", "/srv/cache"}
+	if strings.Join(visited, ",") != strings.Join(want, ",") {
+		t.Errorf("visited %v\nwant %v", visited, want)
+	}
+
+	visited = nil
+	err = v.Walk(ctx, "/data", func(path string, e Entry, err error) error {
+		visited = append(visited, path)
+		if path == "/data/a" {
+			return SkipAll
+		}
+		return nil
+	})
+	if err != nil || strings.Join(visited, ",") != "/data,/data/a" {
+		t.Errorf("Walk with SkipAll = %v, %v", visited, err)
+	}
+
+	// Errors from fn stop the walk; a missing root is reported to fn.
+	stop := errors.New("stop")
+	if err := v.Walk(ctx, "/data", func(string, Entry, error) error { return stop }); err != stop {
+		t.Errorf("Walk error = %v, want %v", err, stop)
+	}
+	err = v.Walk(ctx, "/nowhere", func(path string, e Entry, err error) error {
+		if path != "/nowhere" || !errors.Is(err, ErrNotFound) {
+			t.Errorf("fn(%q, %v), want /nowhere and ErrNotFound", path, err)
+		}
+		return err
+	})
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("Walk missing root = %v, want ErrNotFound", err)
+	}
+}
+
+func TestVOSCopy(t *testing.T) {
+	v := setupVOS(t)
+	ctx := context.Background()
+	mem := mounts.NewMemFS(PermRW)
+	mem.AddDir("docs")
+	mem.AddFile("docs/a.txt", []byte("alpha"), PermRW)
+	if err := v.Mount("/mem", mem); err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "c.txt"), []byte("charlie"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := v.Mount("/disk", mounts.NewLocalFS(dir, PermRW)); err != nil {
+		t.Fatal(err)
+	}
+
+	// MemFS to LocalFS, by file name and into a directory.
+	if err := v.Copy(ctx, "/mem/docs/a.txt", "/disk/a.txt"); err != nil {
+		t.Fatalf("Copy to file: %v", err)
+	}
+	if err := v.Copy(ctx, "/mem/docs/a.txt", "/disk"); err != nil {
+		t.Fatalf("Copy into dir: %v", err)
+	}
+	if data, err := os.ReadFile(filepath.Join(dir, "a.txt")); err != nil || string(data) != "alpha" {
+		t.Errorf("on-disk copy = %q, %v; want alpha", data, err)
+	}
+
+	// LocalFS to MemFS.
+	if err := v.Copy(ctx, "/disk/c.txt", "/mem/docs/c.txt"); err != nil {
+		t.Fatalf("Copy from disk: %v", err)
+	}
+	if got := readAllVOS(t, v, "/mem/docs/c.txt"); got != "charlie" {
+		t.Errorf("/mem/docs/c.txt = %q, want charlie", got)
+	}
+
+	if err := v.Copy(ctx, "/mem/docs", "/disk/docs"); !errors.Is(err, ErrIsDir) {
+		t.Errorf("Copy of a directory: err = %v, want ErrIsDir", err)
+	}
+	if err := v.Copy(ctx, "/mem/nope.txt", "/disk/nope.txt"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Copy of a missing file: err = %v, want ErrNotFound", err)
+	}
+	if err := v.Copy(ctx, "/mem/docs/a.txt", "/mem/docs"); !errors.Is(err, ErrNotSupported) {
+		t.Errorf("Copy onto itself: err = %v, want ErrNotSupported", err)
+	}
+}
+
+func TestVOSCopyDir(t *testing.T) {
+	v := setupVOS(t)
+	ctx := context.Background()
+	mem := mounts.NewMemFS(PermRW)
+	mem.AddDir("feeds")
+	mem.AddFile("feeds/hn.xml", []byte("hn"), PermRW)
+	mem.AddDir("feeds/blogs")
+	mem.AddFile("feeds/blogs/go.xml", []byte("go"), PermRW)
+	if err := v.Mount("/mem", mem); err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "src", "sub"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "src", "sub", "main.go"), []byte("package main"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := v.Mount("/disk", mounts.NewLocalFS(dir, PermRW)); err != nil {
+		t.Fatal(err)
+	}
+
+	// MemFS to LocalFS, creating missing parents.
+	if err := v.CopyDir(ctx, "/mem/feeds", "/disk/backup/feeds"); err != nil {
+		t.Fatalf("CopyDir to disk: %v", err)
+	}
+	for name, want := range map[string]string{"hn.xml": "hn", "blogs/go.xml": "go"} {
+		data, err := os.ReadFile(filepath.Join(dir, "backup", "feeds", filepath.FromSlash(name)))
+		if err != nil || string(data) != want {
+			t.Errorf("%s = %q, %v; want %q", name, data, err, want)
+		}
+	}
+
+	// LocalFS to MemFS.
+	if err := v.CopyDir(ctx, "/disk/src", "/mem/src"); err != nil {
+		t.Fatalf("CopyDir to memory: %v", err)
+	}
+	if got := readAllVOS(t, v, "/mem/src/sub/main.go"); got != "package main" {
+		t.Errorf("/mem/src/sub/main.go = %q", got)
+	}
+
+	// A tree spanning two mounts lands in a single provider.
+	if err := v.Mount("/mem/feeds/local", mounts.NewLocalFS(filepath.Join(dir, "src"), PermRW)); err != nil {
+		t.Fatal(err)
+	}
+	if err := v.CopyDir(ctx, "/mem/feeds", "/home/agent/feeds"); err != nil {
+		t.Fatalf("CopyDir across mounts: %v", err)
+	}
+	for p, want := range map[string]string{
+		"/home/agent/feeds/hn.xml":            "hn",
+		"/home/agent/feeds/blogs/go.xml":      "go",
+		"/home/agent/feeds/local/sub/main.go": "package main",
+	} {
+		if got := readAllVOS(t, v, p); got != want {
+			t.Errorf("%s = %q, want %q", p, got, want)
+		}
+	}
+
+	if err := v.CopyDir(ctx, "/mem/feeds", "/mem/feeds/copy"); !errors.Is(err, ErrNotSupported) {
+		t.Errorf("CopyDir into itself: err = %v, want ErrNotSupported", err)
+	}
+	if err := v.CopyDir(ctx, "/mem/feeds/hn.xml", "/mem/hn"); !errors.Is(err, ErrNotDir) {
+		t.Errorf("CopyDir of a file: err = %v, want ErrNotDir", err)
+	}
+}
@@ -0,0 +1,87 @@
+package grasp
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+)
+
+// SkipDir and SkipAll are the io/fs sentinels, so a WalkFunc written for
+// filepath.WalkDir works unchanged.
+var (
+	SkipDir = fs.SkipDir
+	SkipAll = fs.SkipAll
+)
+
+// WalkFunc is called by Walk for each file and directory it visits. The
+// contract matches fs.WalkDirFunc: err is non-nil when root cannot be
+// stat'ed (entry is then the zero Entry) or when a directory cannot be
+// listed (fn is then called a second time for that directory). Returning
+// SkipDir from a directory skips its contents; from a file, it skips the
+// rest of the file's directory. Returning SkipAll ends the walk, and any
+// other error stops it and is returned by Walk.
+type WalkFunc func(path string, entry Entry, err error) error
+
+// Walk visits root and everything below it in lexical order, calling fn
+// for each entry:
+//
+//	err := v.Walk(ctx, "/data", func(path string, e grasp.Entry, err error) error {
+//		if err != nil {
+//			return err
+//		}
+//		if e.IsDir && e.Name == ".git" {
+//			return grasp.SkipDir
+//		}
+//		fmt.Println(path)
+//		return nil
+//	})
+//
+// Mount points are walked into like any other directory. Symbolic links
+// are reported but not followed, so a link cycle cannot loop the walk.
+// The walk stops early with ctx's error if ctx is cancelled.
+func (v *VirtualOS) Walk(ctx context.Context, root string, fn WalkFunc) error {
+	root = CleanPath(root)
+	entry, err := v.Stat(ctx, root)
+	if err != nil {
+		err = fn(root, Entry{}, err)
+	} else {
+		entry.Path = root
+		err = v.walk(ctx, root, *entry, fn)
+	}
+	if errors.Is(err, SkipDir) || errors.Is(err, SkipAll) {
+		return nil
+	}
+	return err
+}
+
+func (v *VirtualOS) walk(ctx context.Context, path string, entry Entry, fn WalkFunc) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if err := fn(path, entry, nil); err != nil || !entry.IsDir || entry.IsSymlink {
+		if errors.Is(err, SkipDir) && entry.IsDir {
+			err = nil
+		}
+		return err
+	}
+
+	children, err := v.ReadDirSorted(ctx, path)
+	if err != nil {
+		if err = fn(path, entry, err); err != nil {
+			if errors.Is(err, SkipDir) {
+				err = nil
+			}
+			return err
+		}
+	}
+	for _, child := range children {
+		child.Path = CleanPath(path + "/" + child.Name)
+		if err := v.walk(ctx, child.Path, child, fn); err != nil {
+			if errors.Is(err, SkipDir) {
+				break
+			}
+			return err
+		}
+	}
+	return nil
+}
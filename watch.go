@@ -15,6 +15,17 @@ type Watcher struct {
 	hub    *watchHub
 	closed chan struct{}
 	once   sync.Once
+
+	debounce time.Duration
+	pmu      sync.Mutex
+	pending  map[string]*pendingEvent // path → latest debounced event
+}
+
+// pendingEvent is an event held back until its path has been quiet for the
+// watcher's debounce window.
+type pendingEvent struct {
+	ev    WatchEvent
+	timer *time.Timer
 }
 
 // Events returns the channel on which events are delivered.
@@ -27,6 +38,12 @@ func (w *Watcher) Close() error {
 	w.once.Do(func() {
 		close(w.closed)
 		w.hub.remove(w)
+		w.pmu.Lock()
+		for _, p := range w.pending {
+			p.timer.Stop()
+		}
+		w.pending = nil
+		w.pmu.Unlock()
 	})
 	return nil
 }
@@ -41,15 +58,61 @@ func newWatchHub() *watchHub {
 	return &watchHub{}
 }
 
+// deliver sends ev, or holds it back for the debounce window when enabled.
+func (w *Watcher) deliver(ev WatchEvent) {
+	if w.debounce <= 0 {
+		w.send(ev)
+		return
+	}
+	w.pmu.Lock()
+	defer w.pmu.Unlock()
+	if w.pending == nil {
+		select {
+		case <-w.closed:
+			return
+		default:
+		}
+		w.pending = make(map[string]*pendingEvent)
+	}
+	if p, ok := w.pending[ev.Path]; ok {
+		p.timer.Stop()
+	}
+	p := &pendingEvent{ev: ev}
+	p.timer = time.AfterFunc(w.debounce, func() { w.flush(p) })
+	w.pending[ev.Path] = p
+}
+
+// flush delivers p unless a newer event for its path has replaced it.
+func (w *Watcher) flush(p *pendingEvent) {
+	w.pmu.Lock()
+	if w.pending[p.ev.Path] != p {
+		w.pmu.Unlock()
+		return
+	}
+	delete(w.pending, p.ev.Path)
+	w.pmu.Unlock()
+	w.send(p.ev)
+}
+
+func (w *Watcher) send(ev WatchEvent) {
+	select {
+	case w.ch <- ev:
+	case <-w.closed:
+	default:
+		// channel full, drop event (back-pressure)
+	}
+}
+
 // watch creates a new Watcher that receives events matching mask for paths
 // under prefix. An empty prefix watches all paths.
-func (h *watchHub) watch(prefix string, mask EventType) *Watcher {
+func (h *watchHub) watch(prefix string, mask EventType, opts WatchOpts) *Watcher {
 	w := &Watcher{
-		ch:     make(chan WatchEvent, 64),
-		prefix: CleanPath(prefix),
-		mask:   mask,
-		hub:    h,
-		closed: make(chan struct{}),
+		ch:       make(chan WatchEvent, 64),
+		prefix:   CleanPath(prefix),
+		mask:     mask,
+		hub:      h,
+		closed:   make(chan struct{}),
+		debounce: opts.Debounce,
 	}
 	h.mu.Lock()
 	h.watchers = append(h.watchers, w)
@@ -89,11 +152,6 @@ func (h *watchHub) emitRename(evType EventType, path, oldPath string) {
 		if w.prefix != "/" && !strings.HasPrefix(path, w.prefix) {
 			continue
 		}
-		select {
-		case w.ch <- ev:
-		case <-w.closed:
-		default:
-			// channel full, drop event (back-pressure)
-		}
+		w.deliver(ev)
 	}
 }
@@ -0,0 +1,33 @@
+package grasp
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/jackfish212/grasp/mounts"
+)
+
+// OpenZip opens a ZIP archive stored at path on any mount and returns a
+// read-only ZipFS exposing its contents, ready to be mounted:
+//
+//	zfs, err := v.OpenZip(ctx, "/downloads/site.zip")
+//	v.Mount("/site", zfs)
+func (v *VirtualOS) OpenZip(ctx context.Context, path string) (*mounts.ZipFS, error) {
+	f, err := v.Open(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+
+	// archive/zip needs random access, so the archive is buffered in memory.
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return nil, fmt.Errorf("open zip %s: %w", path, err)
+	}
+	zfs, err := mounts.NewZipFS(data)
+	if err != nil {
+		return nil, fmt.Errorf("open zip %s: %w", path, err)
+	}
+	return zfs, nil
+}
@@ -0,0 +1,66 @@
+package grasp
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"io"
+	"testing"
+)
+
+func TestVOSOpenZip(t *testing.T) {
+	v := setupVOS(t)
+	ctx := context.Background()
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range map[string]string{
+		"report/summary.txt": "all good",
+		"data.csv":           "a,b\n",
+	} {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, _ = w.Write([]byte(content))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := v.Write(ctx, "/out.zip", &buf); err != nil {
+		t.Fatal(err)
+	}
+
+	zfs, err := v.OpenZip(ctx, "/out.zip")
+	if err != nil {
+		t.Fatalf("OpenZip: %v", err)
+	}
+	if err := v.Mount("/unzipped", zfs); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := v.List(ctx, "/unzipped", ListOpts{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 || entries[0].Name != "data.csv" || !entries[1].IsDir {
+		t.Errorf("List = %v, want [data.csv report/]", entries)
+	}
+
+	f, err := v.Open(ctx, "/unzipped/report/summary.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, _ := io.ReadAll(f)
+	_ = f.Close()
+	if string(got) != "all good" {
+		t.Errorf("content = %q", got)
+	}
+
+	if err := v.Write(ctx, "/bad.zip", bytes.NewReader([]byte("not a zip"))); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := v.OpenZip(ctx, "/bad.zip"); err == nil {
+		t.Error("OpenZip on invalid archive should fail")
+	}
+}